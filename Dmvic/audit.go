@@ -0,0 +1,90 @@
+package dmvic
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// auditTimeout bounds how long persisting a single audit record may take.
+const auditTimeout = 5 * time.Second
+
+// AuditRecord describes a single DMVIC API call for the audit trail.
+type AuditRecord struct {
+	Operation        Operation `json:"operation" bson:"operation"`                                       // Operation that was performed
+	Method           string    `json:"method" bson:"method"`                                             // HTTP method used
+	Endpoint         string    `json:"endpoint" bson:"endpoint"`                                         // API endpoint path
+	APIRequestNumber string    `json:"api_request_number,omitempty" bson:"api_request_number,omitempty"` // DMVIC request number if returned
+	RequestHash      string    `json:"request_hash,omitempty" bson:"request_hash,omitempty"`             // SHA-256 of the request body
+	HTTPStatus       int       `json:"http_status,omitempty" bson:"http_status,omitempty"`               // HTTP status of the last attempt
+	Success          bool      `json:"success" bson:"success"`                                           // Whether the call succeeded
+	DMVICErrorCode   string    `json:"dmvic_error_code,omitempty" bson:"dmvic_error_code,omitempty"`     // DMVIC error code on failure
+	ErrorMessage     string    `json:"error_message,omitempty" bson:"error_message,omitempty"`           // Error message on failure
+	DurationMs       int64     `json:"duration_ms" bson:"duration_ms"`                                   // Total call duration in milliseconds
+	CreatedAt        time.Time `json:"created_at" bson:"created_at"`                                     // When the call started
+}
+
+// AuditStore persists audit records of DMVIC API calls.
+// Implementations must be safe for concurrent use.
+type AuditStore interface {
+	SaveAuditRecord(ctx context.Context, record *AuditRecord) error
+}
+
+// mongoAuditStore stores audit records in a MongoDB collection.
+type mongoAuditStore struct {
+	records *mongo.Collection
+}
+
+// NewMongoAuditStore creates an AuditStore backed by the given MongoDB collection.
+// If collectionName is empty, "dmvic_audit" is used.
+func NewMongoAuditStore(db *mongo.Database, collectionName string) AuditStore {
+	if collectionName == "" {
+		collectionName = "dmvic_audit"
+	}
+	return &mongoAuditStore{records: db.Collection(collectionName)}
+}
+
+// SaveAuditRecord inserts the record into the audit collection.
+func (s *mongoAuditStore) SaveAuditRecord(ctx context.Context, record *AuditRecord) error {
+	_, err := s.records.InsertOne(ctx, record)
+	return err
+}
+
+// recordAudit builds an audit record for the completed call and hands it to the configured
+// AuditStore. Failures to persist are logged but never fail the API call itself.
+func (c *client) recordAudit(call *apiCall, callErr error) {
+	if c.config.AuditStore == nil {
+		return
+	}
+	record := &AuditRecord{
+		Operation:        call.op,
+		Method:           call.method,
+		Endpoint:         call.endpoint,
+		APIRequestNumber: call.apiRequestNumber,
+		HTTPStatus:       call.httpStatus,
+		Success:          callErr == nil,
+		DurationMs:       time.Since(call.startedAt).Milliseconds(),
+		CreatedAt:        call.startedAt,
+	}
+	if len(call.requestBody) > 0 {
+		sum := sha256.Sum256(call.requestBody)
+		record.RequestHash = hex.EncodeToString(sum[:])
+	}
+	if callErr != nil {
+		record.ErrorMessage = callErr.Error()
+		var clientErr *ClientError
+		if errors.As(callErr, &clientErr) {
+			record.DMVICErrorCode = clientErr.DMVICCode
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.ctx), auditTimeout)
+	defer cancel()
+	if err := c.config.AuditStore.SaveAuditRecord(ctx, record); err != nil {
+		c.debugLog("Failed to save audit record for %s: %v", call.op, err)
+	}
+}
@@ -0,0 +1,60 @@
+package dmvic
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// memoryAuditStore keeps the audit records in memory and fails when told to
+type memoryAuditStore struct {
+	mu      sync.Mutex
+	records []AuditRecord
+	err     error
+}
+
+func (s *memoryAuditStore) SaveAuditRecord(ctx context.Context, record *AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, *record)
+	return s.err
+}
+
+func TestAuditTrail(t *testing.T) {
+	ts := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/V4/Integration/GetCertificate" {
+			writeJSON(w, CertificateResponse{Success: true, APIRequestNumber: "UAT-1"})
+			return
+		}
+		writeJSON(w, CancellationResponse{APIRequestNumber: "UAT-2", Error: FlexibleDmvicError{{ErrorCode: DMVICErrInvalidInput, ErrorText: "Input not valid"}}})
+	})
+	store := &memoryAuditStore{}
+	c := newTestClient(t, ts, func(config *Config) { config.AuditStore = store })
+
+	if _, err := c.GetCertificate("C123"); err != nil {
+		t.Fatalf("GetCertificate: %v", err)
+	}
+	if _, err := c.CancelCertificate("C123", CancelReasonInsuredRequest); err == nil {
+		t.Fatal("expected the cancellation to fail")
+	}
+	if len(store.records) != 2 {
+		t.Fatalf("expected 2 audit records, got %d", len(store.records))
+	}
+	ok, failed := store.records[0], store.records[1]
+	if ok.Operation != OpGetCertificate || ok.Method != http.MethodPost || ok.Endpoint != "/V4/Integration/GetCertificate" ||
+		!ok.Success || ok.HTTPStatus != http.StatusOK || ok.APIRequestNumber != "UAT-1" || len(ok.RequestHash) != 64 {
+		t.Errorf("unexpected audit record %+v", ok)
+	}
+	if failed.Operation != OpCancelCertificate || failed.Success || failed.DMVICErrorCode != DMVICErrInvalidInput ||
+		failed.APIRequestNumber != "UAT-2" || failed.ErrorMessage == "" {
+		t.Errorf("unexpected audit record of the failed call %+v", failed)
+	}
+
+	// a failing audit store never fails the call
+	store.err = errors.New("store down")
+	if _, err := c.GetCertificate("C123"); err != nil {
+		t.Errorf("expected the call to succeed despite the audit store, got %v", err)
+	}
+}
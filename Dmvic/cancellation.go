@@ -0,0 +1,98 @@
+package dmvic
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// certificateStatusActive is the CertificateStatus DMVIC reports for a certificate in force.
+const certificateStatusActive = "Active"
+
+// certificateNotFoundTexts are the error texts with which DMVIC reports that it has no
+// record of a certificate. DMVIC has no dedicated error code for it.
+var certificateNotFoundTexts = []string{"not found", "does not exist", "no record", "no data found"}
+
+// CancellationRefusal explains why CancelCertificateSafe did not cancel a certificate.
+type CancellationRefusal string
+
+const (
+	// CancellationRefusedInvalidReason means the reason ID is not a known DMVIC cancel reason
+	CancellationRefusedInvalidReason CancellationRefusal = "invalid_reason"
+	// CancellationRefusedNotFound means DMVIC has no record of the certificate
+	CancellationRefusedNotFound CancellationRefusal = "certificate_not_found"
+	// CancellationRefusedNotActive means the certificate is not active, e.g. already cancelled
+	CancellationRefusedNotActive CancellationRefusal = "certificate_not_active"
+)
+
+// SafeCancellationResult describes the outcome of CancelCertificateSafe.
+type SafeCancellationResult struct {
+	CertificateNumber string                // Certificate that was checked
+	ReasonID          int                   // Requested cancel reason
+	Cancelled         bool                  // True when the certificate was cancelled
+	Refusal           CancellationRefusal   // Why cancellation was refused, empty when cancelled
+	Detail            string                // Human-readable explanation of the refusal
+	CertificateStatus string                // Certificate status reported by DMVIC before cancelling
+	Response          *CancellationResponse // DMVIC cancellation response when cancelled
+}
+
+// refuse marks the result as refused.
+func (r *SafeCancellationResult) refuse(reason CancellationRefusal, format string, args ...interface{}) *SafeCancellationResult {
+	r.Refusal = reason
+	r.Detail = fmt.Sprintf(format, args...)
+	return r
+}
+
+// CancelCertificateSafe cancels a certificate only after checking that reasonID is a known
+// cancel reason and that DMVIC reports the certificate as active. A refused cancellation is
+// returned as a result with Refusal set; errors are only returned when the checks or the
+// cancellation itself could not be performed.
+func (c *client) CancelCertificateSafe(certificateNumber string, reasonID int) (*SafeCancellationResult, error) {
+	certificateNumber = strings.TrimSpace(certificateNumber)
+	result := &SafeCancellationResult{CertificateNumber: certificateNumber, ReasonID: reasonID}
+	if certificateNumber == "" {
+		return nil, newInternalError(string(OpCancelCertificate), ErrCancelCertificate, fmt.Errorf("certificate number is required"))
+	}
+	if !IsValidCancelReason(reasonID) {
+		return result.refuse(CancellationRefusedInvalidReason, "unknown cancel reason %d", reasonID), nil
+	}
+
+	validation, err := c.ValidateInsurance(&InsuranceValidationRequest{CertificateNumber: certificateNumber})
+	if err != nil {
+		var clientErr *ClientError
+		if errors.As(err, &clientErr) && isCertificateNotFound(clientErr) {
+			return result.refuse(CancellationRefusedNotFound, "certificate %s not found: %s", certificateNumber, clientErr.Message), nil
+		}
+		return nil, err
+	}
+	details := validation.CallbackObj.ValidateInsurance
+	result.CertificateStatus = details.CertificateStatus
+	if details.CertificateNumber == "" {
+		return result.refuse(CancellationRefusedNotFound, "certificate %s not found", certificateNumber), nil
+	}
+	if !strings.EqualFold(details.CertificateStatus, certificateStatusActive) {
+		return result.refuse(CancellationRefusedNotActive, "certificate %s is %s", certificateNumber, details.CertificateStatus), nil
+	}
+
+	resp, err := c.CancelCertificate(certificateNumber, reasonID)
+	if err != nil {
+		return nil, err
+	}
+	result.Cancelled = true
+	result.Response = resp
+	return result, nil
+}
+
+// isCertificateNotFound reports whether a failed validation means DMVIC has no record of the
+// certificate, as opposed to e.g. a rejected request or an authentication failure.
+func isCertificateNotFound(clientErr *ClientError) bool {
+	for _, dmvicErr := range clientErr.Errors {
+		text := strings.ToLower(dmvicErr.ErrorText)
+		for _, notFound := range certificateNotFoundTexts {
+			if strings.Contains(text, notFound) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package dmvic
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestCancelCertificateSafe(t *testing.T) {
+	var validation InsuranceValidationResponse
+	var cancelled int
+	ts := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/V4/Integration/ValidateInsurance":
+			writeJSON(w, validation)
+		case "/V4/Integration/CancelCertificate":
+			cancelled++
+			writeJSON(w, CancellationResponse{Success: true})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	c := newTestClient(t, ts, nil)
+
+	// DMVIC has no record of the certificate
+	validation = InsuranceValidationResponse{Error: FlexibleDmvicError{{ErrorCode: DMVICErrDataValidation, ErrorText: "Certificate Number Not Found"}}}
+	result, err := c.CancelCertificateSafe("C123", CancelReasonInsuredRequest)
+	if err != nil || result.Refusal != CancellationRefusedNotFound {
+		t.Fatalf("expected a not found refusal, got %+v %v", result, err)
+	}
+
+	// other DMVIC errors are returned as such
+	validation = InsuranceValidationResponse{Error: FlexibleDmvicError{{ErrorCode: DMVICErrInvalidInput, ErrorText: "Input not valid"}}}
+	result, err = c.CancelCertificateSafe("C123", CancelReasonInsuredRequest)
+	var clientErr *ClientError
+	if result != nil || !errors.As(err, &clientErr) || clientErr.DMVICCode != DMVICErrInvalidInput {
+		t.Fatalf("expected the DMVIC error, got %+v %v", result, err)
+	}
+
+	validation = InsuranceValidationResponse{Success: true}
+	validation.CallbackObj.ValidateInsurance = InsuranceDetails{CertificateNumber: "C123", CertificateStatus: "Cancelled"}
+	result, err = c.CancelCertificateSafe("C123", CancelReasonInsuredRequest)
+	if err != nil || result.Refusal != CancellationRefusedNotActive {
+		t.Fatalf("expected a not active refusal, got %+v %v", result, err)
+	}
+
+	validation.CallbackObj.ValidateInsurance.CertificateStatus = "Active"
+	result, err = c.CancelCertificateSafe("C123", CancelReasonInsuredRequest)
+	if err != nil || !result.Cancelled || cancelled != 1 {
+		t.Fatalf("expected the certificate to be cancelled, got %+v %v", result, err)
+	}
+}
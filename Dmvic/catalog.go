@@ -0,0 +1,129 @@
+package dmvic
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// EndpointSpec describes how an operation is called.
+type EndpointSpec struct {
+	Operation    Operation
+	Path         string       // Path template, may contain the "{version}" placeholder
+	Method       string       // HTTP method
+	Versions     []APIVersion // API versions offering the endpoint, empty for all
+	RequiresMTLS bool         // Call over the mutual TLS client
+	Idempotent   bool         // Safe to retry on transport and server errors
+}
+
+// supports reports whether the endpoint is offered by the API version.
+func (s EndpointSpec) supports(version APIVersion) bool {
+	if len(s.Versions) == 0 {
+		return true
+	}
+	for _, v := range s.Versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// EndpointCatalog holds the spec of every operation and a feature flag per operation,
+// so a misbehaving DMVIC endpoint can be switched off at runtime without redeploying.
+// A catalog is safe for concurrent use.
+type EndpointCatalog struct {
+	mu       sync.RWMutex
+	specs    map[Operation]EndpointSpec
+	disabled map[Operation]string // reason per disabled operation
+}
+
+// DefaultEndpointCatalog returns a catalog with the specs of all built-in operations.
+func DefaultEndpointCatalog() *EndpointCatalog {
+	c := &EndpointCatalog{
+		specs:    make(map[Operation]EndpointSpec, len(defaultEndpointTemplates)),
+		disabled: make(map[Operation]string),
+	}
+	for op, path := range defaultEndpointTemplates {
+		spec := EndpointSpec{Operation: op, Path: path, Method: http.MethodPost, RequiresMTLS: true}
+		switch op {
+		case OpLogin:
+			spec.RequiresMTLS = false
+		case OpMemberCompanyStock, OpListEntitlements:
+			spec.Method = http.MethodGet
+			spec.Idempotent = true
+		case OpGetCertificate, OpValidateInsurance, OpGetCertificateByVehicle, OpValidateDoubleInsurance:
+			spec.Idempotent = true
+		}
+		c.specs[op] = spec
+	}
+	return c
+}
+
+// Register adds or replaces the spec of an operation.
+func (c *EndpointCatalog) Register(spec EndpointSpec) error {
+	if spec.Operation == "" || spec.Path == "" || spec.Method == "" {
+		return fmt.Errorf("endpoint spec needs an operation, path and method")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.specs[spec.Operation] = spec
+	return nil
+}
+
+// Spec returns the spec of op.
+func (c *EndpointCatalog) Spec(op Operation) (EndpointSpec, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	spec, ok := c.specs[op]
+	return spec, ok
+}
+
+// Specs returns the specs of all operations ordered by operation.
+func (c *EndpointCatalog) Specs() []EndpointSpec {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	specs := make([]EndpointSpec, 0, len(c.specs))
+	for _, spec := range c.specs {
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Operation < specs[j].Operation })
+	return specs
+}
+
+// Disable switches op off; calls fail with ErrEndpointDisabled until it is enabled again.
+func (c *EndpointCatalog) Disable(op Operation, reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if reason == "" {
+		reason = "disabled"
+	}
+	c.disabled[op] = reason
+}
+
+// Enable switches op back on.
+func (c *EndpointCatalog) Enable(op Operation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.disabled, op)
+}
+
+// Enabled reports whether op may be called, and the reason when it may not.
+func (c *EndpointCatalog) Enabled(op Operation) (bool, string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	reason, disabled := c.disabled[op]
+	return !disabled, reason
+}
+
+// checkEnabled returns an error when op is switched off or not offered by the API version.
+func (c *EndpointCatalog) checkEnabled(op Operation, version APIVersion) error {
+	if ok, reason := c.Enabled(op); !ok {
+		return newInternalError(string(op), ErrEndpointDisabled, fmt.Errorf("endpoint is disabled: %s", reason))
+	}
+	if spec, ok := c.Spec(op); ok && !spec.supports(version) {
+		return newInternalError(string(op), ErrEndpointDisabled, fmt.Errorf("endpoint is not available in API version %s", version))
+	}
+	return nil
+}
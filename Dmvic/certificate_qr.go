@@ -0,0 +1,274 @@
+package dmvic
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"net/url"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// CertificateSummary is the customer-facing summary of an issued certificate that is
+// encoded in the verification QR code printed on confirmations.
+type CertificateSummary struct {
+	CertificateNumber  string // Insurance certificate number
+	PolicyNumber       string // Insurance policy number
+	RegistrationNumber string // Vehicle registration number
+	ChassisNumber      string // Vehicle chassis number
+	InsuredBy          string // Insurance company name
+	ValidFrom          string // Cover start date as returned by DMVIC
+	ValidTill          string // Cover end date as returned by DMVIC
+	Status             string // Certificate status as returned by DMVIC
+	CertificateURL     string // Link to the DMVIC certificate document, if available
+	VerificationURL    string // URL encoded in the QR code
+}
+
+// CertificateQROptions configures how the verification URL and QR code are produced.
+type CertificateQROptions struct {
+	// VerificationBaseURL is the page that verifies a scanned certificate, e.g.
+	// https://verify.example.com/certificates. Summary fields are added as query parameters.
+	VerificationBaseURL string
+	// SigningKey, when set, adds an HMAC-SHA256 "sig" parameter so the verification
+	// page can detect tampered QR codes, see VerifyCertificateQuery.
+	SigningKey []byte
+	// Size is the QR code width and height in pixels, defaults to 256.
+	Size int
+}
+
+const defaultQRSize = 256
+
+// BuildCertificateSummary collects the certificate details from DMVIC and builds the
+// summary with its verification URL. The details come from ValidateInsurance and the
+// document link from GetCertificate; a failure to fetch the link is not fatal.
+func BuildCertificateSummary(c Client, certificateNumber string, opts CertificateQROptions) (*CertificateSummary, error) {
+	if certificateNumber == "" {
+		return nil, fmt.Errorf("certificate number is required")
+	}
+	validation, err := c.ValidateInsurance(&InsuranceValidationRequest{CertificateNumber: certificateNumber})
+	if err != nil {
+		return nil, err
+	}
+	var certificateURL string
+	if cert, err := c.GetCertificate(certificateNumber); err == nil {
+		certificateURL = cert.CallbackObj.URL
+	}
+	return NewCertificateSummary(validation.CallbackObj.ValidateInsurance, certificateURL, opts)
+}
+
+// NewCertificateSummary builds a summary from certificate details already fetched from DMVIC.
+func NewCertificateSummary(details InsuranceDetails, certificateURL string, opts CertificateQROptions) (*CertificateSummary, error) {
+	if details.CertificateNumber == "" {
+		return nil, fmt.Errorf("certificate details have no certificate number")
+	}
+	summary := &CertificateSummary{
+		CertificateNumber:  details.CertificateNumber,
+		PolicyNumber:       details.InsurancePolicyNumber,
+		RegistrationNumber: details.RegistrationNumber,
+		ChassisNumber:      details.ChassisNumber,
+		InsuredBy:          details.InsuredBy,
+		ValidFrom:          details.ValidFrom,
+		ValidTill:          details.ValidTill,
+		Status:             details.CertificateStatus,
+		CertificateURL:     certificateURL,
+	}
+	verificationURL, err := summary.buildVerificationURL(opts)
+	if err != nil {
+		return nil, err
+	}
+	summary.VerificationURL = verificationURL
+	return summary, nil
+}
+
+// query returns the summary fields that are encoded in the verification URL.
+func (s *CertificateSummary) query() url.Values {
+	q := url.Values{}
+	q.Set("cert", s.CertificateNumber)
+	q.Set("reg", s.RegistrationNumber)
+	q.Set("chassis", s.ChassisNumber)
+	q.Set("from", s.ValidFrom)
+	q.Set("till", s.ValidTill)
+	return q
+}
+
+func (s *CertificateSummary) buildVerificationURL(opts CertificateQROptions) (string, error) {
+	if opts.VerificationBaseURL == "" {
+		return "", fmt.Errorf("verification base URL is required")
+	}
+	u, err := url.Parse(opts.VerificationBaseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid verification base URL: %w", err)
+	}
+	q := s.query()
+	if len(opts.SigningKey) > 0 {
+		q.Set("sig", signCertificateQuery(opts.SigningKey, q))
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// signCertificateQuery signs the encoded query without the signature itself.
+func signCertificateQuery(key []byte, q url.Values) string {
+	unsigned := url.Values{}
+	for k, v := range q {
+		if k != "sig" {
+			unsigned[k] = v
+		}
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(unsigned.Encode()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyCertificateQuery checks the signature of a scanned verification URL's query.
+// It is meant for the verification page that VerificationBaseURL points to.
+func VerifyCertificateQuery(key []byte, q url.Values) bool {
+	sig, err := hex.DecodeString(q.Get("sig"))
+	if err != nil || len(sig) == 0 {
+		return false
+	}
+	expected, _ := hex.DecodeString(signCertificateQuery(key, q))
+	return hmac.Equal(sig, expected)
+}
+
+// QRCodePNG encodes the verification URL as a PNG QR code of the given size in pixels.
+// A size of 0 uses the default of 256 pixels.
+func (s *CertificateSummary) QRCodePNG(size int) ([]byte, error) {
+	if size <= 0 {
+		size = defaultQRSize
+	}
+	return qrcode.Encode(s.VerificationURL, qrcode.Medium, size)
+}
+
+// QRCodeImage returns the verification QR code as an image.
+func (s *CertificateSummary) QRCodeImage(size int) (image.Image, error) {
+	if size <= 0 {
+		size = defaultQRSize
+	}
+	qr, err := qrcode.New(s.VerificationURL, qrcode.Medium)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+	return qr.Image(size), nil
+}
+
+// StampCorner selects the corner of the document the certificate stamp is placed in.
+type StampCorner int
+
+const (
+	StampBottomRight StampCorner = iota
+	StampBottomLeft
+	StampTopRight
+	StampTopLeft
+)
+
+// StampOptions configures StampDocument.
+type StampOptions struct {
+	Corner  StampCorner // Corner to place the stamp in, defaults to bottom right
+	Size    int         // QR code size in pixels, defaults to 256
+	Margin  int         // Distance from the document edges in pixels, defaults to 16
+	Caption bool        // Print certificate number, registration and validity under the QR code
+}
+
+// StampDocument draws the verification QR code, and optionally a short caption, onto a
+// raster copy of a customer-facing document (e.g. a rendered confirmation page).
+// PDF documents should be rasterised first or stamped with QRCodePNG by the PDF library in use.
+func (s *CertificateSummary) StampDocument(doc image.Image, opts StampOptions) (*image.RGBA, error) {
+	if opts.Size <= 0 {
+		opts.Size = defaultQRSize
+	}
+	if opts.Margin <= 0 {
+		opts.Margin = 16
+	}
+	qr, err := s.QRCodeImage(opts.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	if opts.Caption {
+		lines = s.captionLines()
+	}
+	face := basicfont.Face7x13
+	lineHeight := face.Metrics().Height.Ceil()
+	stampW := opts.Size
+	for _, line := range lines {
+		if w := font.MeasureString(face, line).Ceil(); w > stampW {
+			stampW = w
+		}
+	}
+	stampH := opts.Size + len(lines)*lineHeight
+
+	bounds := doc.Bounds()
+	if stampW+2*opts.Margin > bounds.Dx() || stampH+2*opts.Margin > bounds.Dy() {
+		return nil, fmt.Errorf("document %dx%d is too small for a %dx%d stamp", bounds.Dx(), bounds.Dy(), stampW, stampH)
+	}
+
+	out := image.NewRGBA(bounds)
+	draw.Draw(out, bounds, doc, bounds.Min, draw.Src)
+
+	var origin image.Point
+	switch opts.Corner {
+	case StampTopLeft:
+		origin = image.Pt(bounds.Min.X+opts.Margin, bounds.Min.Y+opts.Margin)
+	case StampTopRight:
+		origin = image.Pt(bounds.Max.X-opts.Margin-stampW, bounds.Min.Y+opts.Margin)
+	case StampBottomLeft:
+		origin = image.Pt(bounds.Min.X+opts.Margin, bounds.Max.Y-opts.Margin-stampH)
+	default:
+		origin = image.Pt(bounds.Max.X-opts.Margin-stampW, bounds.Max.Y-opts.Margin-stampH)
+	}
+
+	stampRect := image.Rect(origin.X, origin.Y, origin.X+stampW, origin.Y+stampH)
+	draw.Draw(out, stampRect, image.White, image.Point{}, draw.Src)
+	draw.Draw(out, image.Rect(origin.X, origin.Y, origin.X+opts.Size, origin.Y+opts.Size), qr, qr.Bounds().Min, draw.Src)
+
+	drawer := &font.Drawer{Dst: out, Src: image.NewUniform(color.Black), Face: face}
+	for i, line := range lines {
+		drawer.Dot = fixed.P(origin.X, origin.Y+opts.Size+(i+1)*lineHeight-face.Metrics().Descent.Ceil())
+		drawer.DrawString(line)
+	}
+	return out, nil
+}
+
+// StampDocumentPNG decodes a PNG document, stamps it and encodes the result as PNG.
+func (s *CertificateSummary) StampDocumentPNG(doc []byte, opts StampOptions) ([]byte, error) {
+	img, err := png.Decode(bytes.NewReader(doc))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode document: %w", err)
+	}
+	stamped, err := s.StampDocument(img, opts)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, stamped); err != nil {
+		return nil, fmt.Errorf("failed to encode stamped document: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *CertificateSummary) captionLines() []string {
+	lines := []string{"Cert: " + s.CertificateNumber}
+	vehicle := strings.TrimSpace(s.RegistrationNumber)
+	if vehicle == "" {
+		vehicle = s.ChassisNumber
+	}
+	if vehicle != "" {
+		lines = append(lines, "Vehicle: "+vehicle)
+	}
+	if s.ValidFrom != "" || s.ValidTill != "" {
+		lines = append(lines, fmt.Sprintf("Valid: %s - %s", s.ValidFrom, s.ValidTill))
+	}
+	return lines
+}
@@ -0,0 +1,100 @@
+package dmvic
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestBuildCertificateSummary(t *testing.T) {
+	ts := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/V4/Integration/ValidateInsurance":
+			writeJSON(w, InsuranceValidationResponse{Success: true, CallbackObj: InsuranceCallbackObj{ValidateInsurance: InsuranceDetails{
+				CertificateNumber:  "C123",
+				RegistrationNumber: "KDA123A",
+				ChassisNumber:      "CH-001",
+				ValidFrom:          "01/10/2026",
+				ValidTill:          "30/09/2027",
+				CertificateStatus:  "Active",
+			}}})
+		case "/V4/Integration/GetCertificate":
+			writeJSON(w, CertificateResponse{Success: true, CallbackObj: CallbackURL{URL: "https://dmvic.example/C123.pdf"}})
+		}
+	})
+	c := newTestClient(t, ts, nil)
+	key := []byte("secret")
+
+	summary, err := BuildCertificateSummary(c, "C123", CertificateQROptions{VerificationBaseURL: "https://verify.example.com/certificates", SigningKey: key})
+	if err != nil {
+		t.Fatalf("BuildCertificateSummary: %v", err)
+	}
+	if summary.RegistrationNumber != "KDA123A" || summary.Status != "Active" || summary.CertificateURL != "https://dmvic.example/C123.pdf" {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	u, err := url.Parse(summary.VerificationURL)
+	if err != nil {
+		t.Fatalf("verification URL: %v", err)
+	}
+	q := u.Query()
+	if u.Host != "verify.example.com" || q.Get("cert") != "C123" || q.Get("till") != "30/09/2027" {
+		t.Errorf("unexpected verification URL %s", summary.VerificationURL)
+	}
+	if !VerifyCertificateQuery(key, q) {
+		t.Error("expected the signature to verify")
+	}
+	q.Set("till", "30/09/2030")
+	if VerifyCertificateQuery(key, q) {
+		t.Error("expected a tampered query to fail verification")
+	}
+
+	if _, err := BuildCertificateSummary(c, "C123", CertificateQROptions{}); err == nil {
+		t.Error("expected a missing verification base URL to fail")
+	}
+}
+
+func TestStampDocument(t *testing.T) {
+	summary, err := NewCertificateSummary(InsuranceDetails{CertificateNumber: "C123", RegistrationNumber: "KDA123A"}, "", CertificateQROptions{VerificationBaseURL: "https://verify.example.com"})
+	if err != nil {
+		t.Fatalf("NewCertificateSummary: %v", err)
+	}
+
+	qr, err := summary.QRCodePNG(128)
+	if err != nil {
+		t.Fatalf("QRCodePNG: %v", err)
+	}
+	if img, err := png.Decode(bytes.NewReader(qr)); err != nil || img.Bounds().Dx() != 128 {
+		t.Errorf("expected a 128 pixel PNG, got %v", err)
+	}
+
+	doc := image.NewRGBA(image.Rect(0, 0, 600, 400))
+	draw.Draw(doc, doc.Bounds(), image.NewUniform(color.Gray{Y: 200}), image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	stamped, err := summary.StampDocumentPNG(buf.Bytes(), StampOptions{Size: 128, Caption: true})
+	if err != nil {
+		t.Fatalf("StampDocumentPNG: %v", err)
+	}
+	out, err := png.Decode(bytes.NewReader(stamped))
+	if err != nil {
+		t.Fatalf("decode stamped document: %v", err)
+	}
+	// the stamp covers the bottom right corner only
+	if gray := color.GrayModel.Convert(out.At(10, 10)).(color.Gray); gray.Y != 200 {
+		t.Error("expected the top left corner to be untouched")
+	}
+	if gray := color.GrayModel.Convert(out.At(600-16-1, 400-16-1)).(color.Gray); gray.Y == 200 {
+		t.Error("expected the stamp in the bottom right corner")
+	}
+
+	if _, err := summary.StampDocument(image.NewRGBA(image.Rect(0, 0, 100, 100)), StampOptions{Size: 128}); err == nil {
+		t.Error("expected a document smaller than the stamp to fail")
+	}
+}
@@ -2,16 +2,20 @@ package dmvic
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
-	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Client defines the interface for DMVIC operations.
@@ -30,9 +34,17 @@ type Client interface {
 	// reasonID represents the cancellation reason code.
 	CancelCertificate(certificateNumber string, reasonID int) (*CancellationResponse, error)
 
+	// CancelCertificateSafe verifies the reason and that the certificate exists and is active
+	// before cancelling it. Refusals are described by the result rather than returned as errors.
+	CancelCertificateSafe(certificateNumber string, reasonID int) (*SafeCancellationResult, error)
+
 	// ValidateInsurance validates insurance information against DMVIC records.
 	ValidateInsurance(req *InsuranceValidationRequest) (*InsuranceValidationResponse, error)
 
+	// GetCertificateByVehicle looks up the cover held by a vehicle using its registration
+	// and/or chassis number instead of a certificate number.
+	GetCertificateByVehicle(registrationNumber, chassisNumber string) (*InsuranceValidationResponse, error)
+
 	// ValidateDoubleInsurance checks for duplicate insurance coverage.
 	ValidateDoubleInsurance(req *DoubleInsuranceRequest) (*DoubleInsuranceResponse, error)
 
@@ -51,9 +63,37 @@ type Client interface {
 	// ConfirmCertificateIssuance confirms the issuance of a certificate.
 	ConfirmCertificateIssuance(req *ConfirmationRequest) (*InsuranceResponse, error)
 
+	// ExtendCertificate changes the period of cover of an active certificate.
+	ExtendCertificate(req *ExtensionRequest) (*InsuranceResponse, error)
+
+	// EndorseCertificate amends the vehicle or policyholder details of an active certificate.
+	EndorseCertificate(req *EndorsementRequest) (*InsuranceResponse, error)
+
 	// GetMemberCompanyStock retrieves stock information for a member company.
 	GetMemberCompanyStock(memberCompanyID int) (*StockResponse, error)
 
+	// GetMemberCompanyStockPage retrieves member company stock filtered and paged by opts.
+	GetMemberCompanyStockPage(memberCompanyID int, opts StockQueryOptions) (*StockPage, error)
+
+	// CreateSubUser creates an API sub-user under the member company account.
+	CreateSubUser(req *CreateSubUserRequest) (*SubUserResponse, error)
+
+	// ResetSubUserCredentials resets the password of a sub-user.
+	ResetSubUserCredentials(userName string) (*ResetCredentialsResponse, error)
+
+	// ListEntitlements lists the entitlements granted to a user.
+	ListEntitlements(userName string) (*EntitlementsResponse, error)
+
+	// Catalog returns the endpoint catalog, e.g. to switch a misbehaving endpoint off at runtime.
+	Catalog() *EndpointCatalog
+
+	// WithContext returns a client whose API calls use ctx as their parent context.
+	WithContext(ctx context.Context) Client
+
+	// Close releases the resources held by the client: it stops the token cache
+	// cleanup goroutine and closes idle connections. Calls made after Close fail.
+	Close() error
+
 	// GetToken returns the current authentication token.
 	GetToken() string
 
@@ -70,10 +110,18 @@ type Client interface {
 // client implements the Client interface for DMVIC API operations.
 // It maintains configuration, HTTP client, authentication tokens, and endpoint information.
 type client struct {
-	config     *Config                   // Configuration settings for the client
-	httpClient *http.Client              // HTTP client for making requests
-	endpoint   string                    // Base endpoint URL for DMVIC API
-	tknStorage *TTLCache[string, string] // Token storage with TTL functionality
+	config     *Config          // Configuration settings for the client
+	httpClient *http.Client     // HTTP client for making requests
+	endpoint   string           // Base endpoint URL for DMVIC API
+	tknStorage DmvitokenStorage // Token storage with TTL functionality
+	tokenKey   string           // Key the access token is stored under
+
+	endpoints map[Operation]string // Endpoint paths resolved for the configured API version
+
+	tls    *secureClientCache // Mutual TLS client shared by all secure requests
+	closed *atomic.Bool       // Set once Close was called
+	ctx    context.Context    // Parent context of API calls, defaults to Config.Context
+	tracer trace.Tracer       // Tracer used to create a span per API call
 }
 
 // NewClient creates a new DMVIC client instance with the provided configuration.
@@ -89,24 +137,102 @@ func NewClient(config *Config) (Client, error) {
 			Operation: "NewClient",
 		}
 	}
+	endpoints, err := resolveEndpoints(config)
+	if err != nil {
+		return nil, &ClientError{
+			Type:      InternalError,
+			Code:      ErrInvalidConfig,
+			Message:   err.Error(),
+			Operation: "NewClient",
+		}
+	}
 	transport := &http.Transport{
 		TLSClientConfig: &tls.Config{
 			InsecureSkipVerify: config.InsecureSkipVerify,
 		},
 	}
+	// request deadlines are set per operation, see Config.TimeoutFor
 	httpClient := &http.Client{
-		Timeout:   config.Timeout,
 		Transport: transport,
 	}
-	tknStorage := NewTTL[string, string](config.TokenTTL) // 24 hours TTL
+	var tknStorage DmvitokenStorage = config.TokenStore
+	if tknStorage == nil {
+		tknStorage = NewTTL[string, string](config.TokenTTL) // 24 hours TTL
+	}
 	return &client{
 		config:     config,
 		httpClient: httpClient,
 		endpoint:   config.GetEndpoint(),
 		tknStorage: tknStorage,
+		tokenKey:   config.tokenKey(),
+		endpoints:  endpoints,
+		tls:        &secureClientCache{},
+		closed:     &atomic.Bool{},
+		ctx:        config.Context,
+		tracer:     newTracer(config.TracerProvider),
 	}, nil
 }
 
+// WithContext returns a client that uses ctx as the parent context of its API calls,
+// so that cancellation and trace context of the caller are propagated to DMVIC.
+// The returned client shares tokens and connections with c.
+func (c *client) WithContext(ctx context.Context) Client {
+	if ctx == nil {
+		ctx = c.config.Context
+	}
+	return &client{
+		config:     c.config,
+		httpClient: c.httpClient,
+		endpoint:   c.endpoint,
+		tknStorage: c.tknStorage,
+		tokenKey:   c.tokenKey,
+		endpoints:  c.endpoints,
+		tls:        c.tls,
+		closed:     c.closed,
+		ctx:        ctx,
+		tracer:     c.tracer,
+	}
+}
+
+// Catalog returns the endpoint catalog of the client, shared with clients derived with WithContext.
+func (c *client) Catalog() *EndpointCatalog {
+	return c.config.Catalog
+}
+
+// Close stops the token cache cleanup goroutine and closes the idle connections of
+// the HTTP clients. Clients derived with WithContext share these resources and are
+// closed as well. Close is safe to call more than once.
+func (c *client) Close() error {
+	if c.closed.Swap(true) {
+		return nil
+	}
+	// a shared TokenStore is owned by the application
+	if cache, ok := c.tknStorage.(*TTLCache[string, string]); ok && c.config.TokenStore == nil {
+		cache.Close()
+	}
+	c.httpClient.CloseIdleConnections()
+	c.tls.mu.Lock()
+	if c.tls.client != nil {
+		c.tls.client.CloseIdleConnections()
+	}
+	c.tls.mu.Unlock()
+	c.debugLog("Client closed")
+	return nil
+}
+
+// checkOpen returns an error once the client has been closed.
+func (c *client) checkOpen(operation string) error {
+	if c.closed.Load() {
+		return newInternalError(operation, ErrClientClosed, fmt.Errorf("client is closed"))
+	}
+	return nil
+}
+
+// path returns the endpoint path of a built-in operation for the configured API version.
+func (c *client) path(op Operation) string {
+	return c.endpoints[op]
+}
+
 // debugLog outputs debug information if debug mode is enabled in the configuration.
 // It prefixes all log messages with "[DMVIC DEBUG]" for easy identification.
 func (c *client) debugLog(format string, args ...interface{}) {
@@ -125,7 +251,7 @@ func (c *client) ensureValidToken() error {
 		return nil
 	*/
 
-	_, found := c.tknStorage.Get("dmvictoken")
+	_, found := c.tknStorage.Get(c.tokenKey)
 	if !found {
 		c.debugLog("Token not found or empty, refreshing...")
 		err := c.Login()
@@ -143,38 +269,90 @@ func (c *client) ensureValidToken() error {
 // parseDMVICError converts DMVIC API error messages to standardized error codes.
 // It maps common error messages to predefined error constants for better error handling.
 func (c *client) parseDMVICError(errorMsg string) string {
-	switch {
-	case errorMsg == "Input json format is Incorrect":
-		return DMVICErrInvalidJSON
-	case errorMsg == "Unknown Error":
-		return DMVICErrUnknownError
-	case errorMsg == "Mandatory field is missing":
-		return DMVICErrMandatoryField
-	case errorMsg == "Input not valid":
-		return DMVICErrInvalidInput
-	case errorMsg == "Double Insurance":
-		return DMVICErrDoubleInsurance
-	case errorMsg == "No sufficient Inventory":
-		return DMVICErrInsufficientStock
-	case errorMsg == "Data Validation Error":
-		return DMVICErrDataValidation
-	default:
-		if len(errorMsg) >= 5 && errorMsg[:2] == "ER" {
-			return errorMsg[:5]
-		}
-		return ""
-	}
+	return parseDMVICErrorCode(errorMsg)
 }
 
 // makeAPICall is a generic method for making authenticated API calls to DMVIC.
 // It handles token validation, request marshaling, response handling, and error parsing.
+// Each attempt is bounded by the timeout configured for the operation and the call
+// is traced as a span named dmvic.<operation>.
 // Parameters:
+//   - ctx: Parent context of the call
+//   - op: The operation being performed, used to resolve its timeout
 //   - method: HTTP method (GET, POST, etc.)
 //   - endpoint: API endpoint path
 //   - request: Request payload to be JSON marshaled
 //   - response: Response struct to unmarshal the result into
 //   - errorCode: Base error code for this operation
-func (c *client) makeAPICall(method, endpoint string, request interface{}, response interface{}, errorCode int) error {
+func (c *client) makeAPICall(ctx context.Context, op Operation, method, endpoint string, request interface{}, response interface{}, errorCode int) error {
+	return c.send(ctx, newAPIRequest(op, method, endpoint).Body(request), response, errorCode)
+}
+
+// send performs the API call described by r, see makeAPICall.
+func (c *client) send(ctx context.Context, r *apiRequest, response interface{}, errorCode int) error {
+	spec, known := c.config.Catalog.Spec(r.op)
+	if r.method == "" && known {
+		r.method = spec.Method
+	}
+	endpoint := r.Endpoint()
+	ctx, span := c.startSpan(ctx, r.op, r.method, endpoint)
+	call := &apiCall{
+		op:           r.op,
+		method:       r.method,
+		endpoint:     endpoint,
+		retrySafe:    r.RetrySafe() || (known && spec.Idempotent),
+		requiresMTLS: !known || spec.RequiresMTLS,
+		startedAt:    time.Now(),
+	}
+	// a switched off endpoint is never called
+	err := c.config.Catalog.checkEnabled(r.op, c.config.APIVersion)
+	if err == nil {
+		if c.isDryRun(r.op) {
+			err = c.dryRun(r.op, r.body, response, errorCode)
+		} else {
+			err = c.doAPICall(ctx, call, r.body, response, errorCode)
+		}
+	}
+	if call.apiRequestNumber == "" {
+		if result, ok := response.(dmvicResult); ok {
+			call.apiRequestNumber = result.GetAPIRequestNumber()
+		}
+	}
+	var clientErr *ClientError
+	if errors.As(err, &clientErr) {
+		if clientErr.HTTPStatus == 0 {
+			clientErr.HTTPStatus = call.httpStatus
+		}
+		if clientErr.APIRequestNumber == "" {
+			clientErr.APIRequestNumber = call.apiRequestNumber
+		}
+	}
+	endSpan(span, call, err)
+	c.recordAudit(call, err)
+	return err
+}
+
+// apiCall carries the details of a single makeAPICall invocation that are needed
+// once the call has completed, e.g. for the audit trail.
+type apiCall struct {
+	op               Operation
+	method           string
+	endpoint         string
+	retrySafe        bool
+	requiresMTLS     bool
+	requestBody      []byte
+	httpStatus       int
+	apiRequestNumber string
+	startedAt        time.Time
+}
+
+// doAPICall performs the request described by call and records the request body
+// and last HTTP status on it.
+func (c *client) doAPICall(parent context.Context, call *apiCall, request interface{}, response interface{}, errorCode int) error {
+	op, method, endpoint := call.op, call.method, call.endpoint
+	if err := c.checkOpen(string(op)); err != nil {
+		return err
+	}
 	var body []byte
 	var err error
 	if request != nil {
@@ -184,36 +362,34 @@ func (c *client) makeAPICall(method, endpoint string, request interface{}, respo
 		}
 		c.debugLog("Request body: %s", string(body))
 	}
+	call.requestBody = body
 	url := c.endpoint + endpoint
 	c.debugLog("Making %s request to: %s", method, url)
 
 	attempts := 0
 	for attempts < 2 {
-		client, req, err := c.secureRequest(method, url, body)
-		if err != nil {
-			return newInternalError("makeAPICall", ErrCreateRequest, err)
-		}
-
-		resp, err := client.Do(req)
+		status, respBody, err := c.roundTrip(parent, call, url, body, errorCode)
 		if err != nil {
-			return newExternalError("makeAPICall", errorCode+3, err.Error())
-		}
-		respBody, readErr := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		if readErr != nil {
-			return newInternalError("makeAPICall", ErrReadResponse, readErr)
+			return err
 		}
-		c.debugLog("Response status: %d, body: %s", resp.StatusCode, string(respBody))
 
-		if resp.StatusCode != http.StatusOK {
-			clientErr := newExternalError("makeAPICall", errorCode+1, fmt.Sprintf("HTTP %d: %s", resp.StatusCode, string(respBody)))
-			clientErr.HTTPStatus = resp.StatusCode
+		if status != http.StatusOK {
+			clientErr := newExternalError("makeAPICall", errorCode+1, fmt.Sprintf("HTTP %d: %s", status, string(respBody)))
+			clientErr.HTTPStatus = status
 			return clientErr
 		}
 
 		if err := json.Unmarshal(respBody, response); err != nil {
+			if c.config.ResponseValidation != ResponseValidationOff {
+				if diags := ValidateResponseSchema(respBody, response); len(diags) > 0 {
+					err = fmt.Errorf("%w (%s)", err, diags.Error())
+				}
+			}
 			return newInternalError("makeAPICall", ErrUnmarshalResponse, err)
 		}
+		if err := c.validateResponse(op, respBody, response); err != nil {
+			return err
+		}
 
 		// Detect DMVIC error from typed response (many response types implement GetError)
 		var dmvicErrCode, dmvicErrText string
@@ -268,14 +444,9 @@ func (c *client) makeAPICall(method, endpoint string, request interface{}, respo
 			}
 		}
 
-		// If there's a DMVIC error, return a DMVICError
-		// For now let's skip this
-		if (dmvicErrText != "" || dmvicErrCode != "") && false {
-			codeToReturn := dmvicErrCode
-			if codeToReturn == "" {
-				codeToReturn = c.parseDMVICError(dmvicErrText)
-			}
-			return newDMVICError("makeAPICall", errorCode+4, codeToReturn, dmvicErrText)
+		// Success=false is always surfaced as an error carrying the full DMVIC error list
+		if result, ok := response.(dmvicResult); ok && !result.IsSuccess() {
+			return newDMVICResponseError(string(op), errorCode, result.GetErrors())
 		}
 
 		// success path
@@ -285,6 +456,117 @@ func (c *client) makeAPICall(method, endpoint string, request interface{}, respo
 	return newExternalError("makeAPICall", errorCode+5, "max retry attempts reached")
 }
 
+// roundTrip sends a single request and reads the response body. Retry-safe calls are
+// repeated with exponential backoff on transport errors and 429/5xx responses.
+func (c *client) roundTrip(parent context.Context, call *apiCall, url string, body []byte, errorCode int) (int, []byte, error) {
+	retries := 0
+	if call.retrySafe && c.config.SafeRetryAttempts > 0 {
+		retries = c.config.SafeRetryAttempts
+	}
+	backoff := c.config.SafeRetryBackoff
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			c.debugLog("Retrying %s %s (attempt %d) in %v", call.method, url, attempt+1, backoff)
+			select {
+			case <-parent.Done():
+				return 0, nil, newExternalError("makeAPICall", errorCode+3, parent.Err().Error())
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		newRequest := c.secureRequest
+		if !call.requiresMTLS {
+			newRequest = c.normalRequest
+		}
+		client, req, err := newRequest(call.method, url, body)
+		if err != nil {
+			return 0, nil, newInternalError("makeAPICall", ErrCreateRequest, err)
+		}
+		ctx, cancel := context.WithTimeout(parent, c.config.TimeoutFor(call.op))
+		req = req.WithContext(ctx)
+		injectTraceHeaders(ctx, req)
+		resp, err := client.Do(req)
+		if err != nil {
+			cancel()
+			if attempt < retries && parent.Err() == nil {
+				continue
+			}
+			return 0, nil, newExternalError("makeAPICall", errorCode+3, err.Error())
+		}
+		respBody, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		cancel()
+		call.httpStatus = resp.StatusCode
+		call.apiRequestNumber = parseAPIRequestNumber(respBody)
+		if readErr != nil {
+			if attempt < retries {
+				continue
+			}
+			return 0, nil, newInternalError("makeAPICall", ErrReadResponse, readErr)
+		}
+		c.debugLog("Response status: %d, body: %s", resp.StatusCode, string(respBody))
+		if retryableStatus(resp.StatusCode) && attempt < retries {
+			continue
+		}
+		return resp.StatusCode, respBody, nil
+	}
+}
+
+// parseAPIRequestNumber extracts the DMVIC request number from a response body. It works
+// on bodies that do not match the response type, so failed calls still report it.
+func parseAPIRequestNumber(body []byte) string {
+	var envelope struct {
+		APIRequestNumber string `json:"apiRequestNumber"`
+	}
+	if json.Unmarshal(body, &envelope) != nil {
+		return ""
+	}
+	return envelope.APIRequestNumber
+}
+
+// validateResponse checks the response against its schema when ResponseValidation is enabled.
+// In warn mode issues are logged, in strict mode they fail the call.
+func (c *client) validateResponse(op Operation, body []byte, response interface{}) error {
+	if c.config.ResponseValidation == ResponseValidationOff {
+		return nil
+	}
+	diags := ValidateResponseSchema(body, response)
+	if len(diags) == 0 {
+		return nil
+	}
+	if c.config.ResponseValidation == ResponseValidationStrict {
+		return newInternalError(string(op), ErrResponseSchema, diags)
+	}
+	log.Printf("[DMVIC WARN] %s: %s", op, diags.Error())
+	return nil
+}
+
+// parseDMVICErrorCode maps a DMVIC error message or code to one of the DMVICErr constants.
+func parseDMVICErrorCode(errorMsg string) string {
+	switch {
+	case errorMsg == "Input json format is Incorrect":
+		return DMVICErrInvalidJSON
+	case errorMsg == "Unknown Error":
+		return DMVICErrUnknownError
+	case errorMsg == "Mandatory field is missing":
+		return DMVICErrMandatoryField
+	case errorMsg == "Input not valid":
+		return DMVICErrInvalidInput
+	case errorMsg == "Double Insurance":
+		return DMVICErrDoubleInsurance
+	case errorMsg == "No sufficient Inventory":
+		return DMVICErrInsufficientStock
+	case errorMsg == "Data Validation Error":
+		return DMVICErrDataValidation
+	default:
+		if len(errorMsg) >= 5 && errorMsg[:2] == "ER" {
+			return errorMsg[:5]
+		}
+		return ""
+	}
+}
+
 // === API Methods Implementation ===
 // helper to calculate the number of days to expiry from a date string
 // Returns the duration until expiry
@@ -303,17 +585,27 @@ func (c *client) getDurationToExpiry(dateStr string) (time.Duration, error) {
 
 // Login authenticates with the DMVIC API and obtains an access token
 func (c *client) Login() error {
+	if err := c.checkOpen("Login"); err != nil {
+		return err
+	}
 	c.debugLog("Attempting login...")
-	jsonData, err := json.Marshal(c.config.Credentials)
+	ctx, cancel := context.WithTimeout(c.ctx, c.config.TimeoutFor(OpLogin))
+	defer cancel()
+	credentials, err := c.credentials(ctx)
+	if err != nil {
+		return err
+	}
+	jsonData, err := json.Marshal(credentials)
 	if err != nil {
 		return newInternalError("Login", ErrMarshalRequest, err)
 	}
-	loginURL := c.endpoint + "/V1/Account/Login"
-	req, err := http.NewRequestWithContext(c.config.Context, http.MethodPost, loginURL, bytes.NewReader(jsonData))
+	loginURL := c.endpoint + c.path(OpLogin)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, loginURL, bytes.NewReader(jsonData))
 	if err != nil {
 		return newInternalError("Login", ErrCreateRequest, err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	c.setClientHeaders(req)
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return newExternalError("Login", ErrHTTPRequest, err.Error())
@@ -325,7 +617,9 @@ func (c *client) Login() error {
 	}
 	c.debugLog("Login response status: %d, body: %s", resp.StatusCode, string(body))
 	if resp.StatusCode != http.StatusOK {
-		return newExternalError("Login", ErrLoginFailed, fmt.Sprintf("HTTP %d: %s", resp.StatusCode, string(body)))
+		clientErr := newExternalError("Login", ErrLoginFailed, fmt.Sprintf("HTTP %d: %s", resp.StatusCode, string(body)))
+		clientErr.HTTPStatus = resp.StatusCode
+		return clientErr
 	}
 	var loginResp LoginResponse
 	if err := json.Unmarshal(body, &loginResp); err != nil {
@@ -361,16 +655,32 @@ func (c *client) Login() error {
 	if err != nil {
 		return newInternalError("Login", ErrParseTime, fmt.Errorf("error calculating days to expiry: %w", err))
 	}
-	c.tknStorage.Set("dmvictoken", loginResp.Token, duration)
+	c.tknStorage.Set(c.tokenKey, loginResp.Token, duration)
 	//c.token = loginResp.Token
 	//c.expires = expires
 	c.debugLog("Login successful, token expires in : %v ", duration)
 	return nil
 }
 
+// credentials returns the credentials to log in with, asking the configured
+// CredentialsProvider for the current ones when set.
+func (c *client) credentials(ctx context.Context) (Credentials, error) {
+	if c.config.CredentialsProvider == nil {
+		return c.config.Credentials, nil
+	}
+	credentials, err := c.config.CredentialsProvider(ctx)
+	if err != nil {
+		return Credentials{}, newInternalError("Login", ErrCredentialsProvider, err)
+	}
+	if credentials.Username == "" || credentials.Password == "" {
+		return Credentials{}, newInternalError("Login", ErrCredentialsProvider, fmt.Errorf("credentials provider returned empty username or password"))
+	}
+	return credentials, nil
+}
+
 // GetToken returns the current authentication token
 func (c *client) GetToken() string {
-	tkn, found := c.tknStorage.Get("dmvictoken")
+	tkn, found := c.tknStorage.Get(c.tokenKey)
 	if !found {
 		c.debugLog("Error getting token from storage: ")
 		return ""
@@ -380,10 +690,18 @@ func (c *client) GetToken() string {
 
 // IsTokenValid checks if the current token is valid and not expired
 func (c *client) IsTokenValid() bool {
-	_, found := c.tknStorage.Get("dmvictoken")
+	_, found := c.tknStorage.Get(c.tokenKey)
 	return found
 }
 
+// dmvicResult is implemented by response types that report a success flag and
+// a list of DMVIC errors.
+type dmvicResult interface {
+	IsSuccess() bool
+	GetErrors() FlexibleDmvicError
+	GetAPIRequestNumber() string
+}
+
 // Add GetError methods to response types for better error handling
 func (r *CertificateResponse) GetError() string {
 	if len(r.Error) > 0 {
@@ -396,6 +714,9 @@ func (r *CertificateResponse) GetError() string {
 	}
 	return ""
 }
+func (r *CertificateResponse) IsSuccess() bool               { return r.Success }
+func (r *CertificateResponse) GetErrors() FlexibleDmvicError { return r.Error }
+func (r *CertificateResponse) GetAPIRequestNumber() string   { return r.APIRequestNumber }
 func (r *InsuranceValidationResponse) GetError() string {
 	if len(r.Error) > 0 {
 		if r.Error[0].ErrorText != "" {
@@ -407,6 +728,9 @@ func (r *InsuranceValidationResponse) GetError() string {
 	}
 	return ""
 }
+func (r *InsuranceValidationResponse) IsSuccess() bool               { return r.Success }
+func (r *InsuranceValidationResponse) GetErrors() FlexibleDmvicError { return r.Error }
+func (r *InsuranceValidationResponse) GetAPIRequestNumber() string   { return r.APIRequestNumber }
 func (r *CancellationResponse) GetError() string {
 	if len(r.Error) > 0 {
 		if r.Error[0].ErrorText != "" {
@@ -418,6 +742,9 @@ func (r *CancellationResponse) GetError() string {
 	}
 	return ""
 }
+func (r *CancellationResponse) IsSuccess() bool               { return r.Success }
+func (r *CancellationResponse) GetErrors() FlexibleDmvicError { return r.Error }
+func (r *CancellationResponse) GetAPIRequestNumber() string   { return r.APIRequestNumber }
 func (r *DoubleInsuranceResponse) GetError() string {
 	if len(r.Error) > 0 {
 		if r.Error[0].ErrorText != "" {
@@ -429,6 +756,9 @@ func (r *DoubleInsuranceResponse) GetError() string {
 	}
 	return ""
 }
+func (r *DoubleInsuranceResponse) IsSuccess() bool               { return r.Success }
+func (r *DoubleInsuranceResponse) GetErrors() FlexibleDmvicError { return r.Error }
+func (r *DoubleInsuranceResponse) GetAPIRequestNumber() string   { return r.APIRequestNumber }
 func (r *InsuranceResponse) GetError() string {
 	if len(r.Error) > 0 {
 		if r.Error[0].ErrorText != "" {
@@ -440,6 +770,9 @@ func (r *InsuranceResponse) GetError() string {
 	}
 	return ""
 }
+func (r *InsuranceResponse) IsSuccess() bool               { return r.Success }
+func (r *InsuranceResponse) GetErrors() FlexibleDmvicError { return r.Error }
+func (r *InsuranceResponse) GetAPIRequestNumber() string   { return r.APIRequestNumber }
 func (r *StockResponse) GetError() string {
 	if len(r.Error) > 0 {
 		if r.Error[0].ErrorText != "" {
@@ -451,30 +784,46 @@ func (r *StockResponse) GetError() string {
 	}
 	return ""
 }
+func (r *StockResponse) IsSuccess() bool               { return r.Success }
+func (r *StockResponse) GetErrors() FlexibleDmvicError { return r.Error }
+func (r *StockResponse) GetAPIRequestNumber() string   { return r.APIRequestNumber }
 
 func (c *client) GetCertificate(certificateNumber string) (*CertificateResponse, error) {
 	req := &CertificateRequest{CertificateNumber: certificateNumber}
 	var resp CertificateResponse
-	err := c.makeAPICall(http.MethodPost, "/V4/Integration/GetCertificate", req, &resp, ErrGetCertificate)
+	err := c.makeAPICall(c.ctx, OpGetCertificate, http.MethodPost, c.path(OpGetCertificate), req, &resp, ErrGetCertificate)
 	if err != nil {
 		return nil, err
 	}
-	if !resp.Success && len(resp.Error) > 0 {
-		dmvicCode := c.parseDMVICError(resp.Error[0].ErrorCode)
-		return nil, newDMVICError("GetCertificate", ErrGetCertificate, dmvicCode, resp.Error[0].ErrorText)
-	}
 	return &resp, nil
 }
 
 func (c *client) ValidateInsurance(req *InsuranceValidationRequest) (*InsuranceValidationResponse, error) {
 	var resp InsuranceValidationResponse
-	err := c.makeAPICall(http.MethodPost, "/V4/Integration/ValidateInsurance", req, &resp, ErrValidateInsurance)
+	err := c.makeAPICall(c.ctx, OpValidateInsurance, http.MethodPost, c.path(OpValidateInsurance), req, &resp, ErrValidateInsurance)
 	if err != nil {
 		return nil, err
 	}
-	if !resp.Success && len(resp.Error) > 0 {
-		dmvicCode := c.parseDMVICError(resp.Error[0].ErrorCode)
-		return nil, newDMVICError("ValidateInsurance", ErrValidateInsurance, dmvicCode, resp.Error[0].ErrorText)
+	return &resp, nil
+}
+
+// GetCertificateByVehicle looks up the certificate held by a vehicle through the DMVIC
+// insurance validation endpoint, which accepts a registration or chassis number in place
+// of the certificate number. At least one of the two identifiers is required.
+func (c *client) GetCertificateByVehicle(registrationNumber, chassisNumber string) (*InsuranceValidationResponse, error) {
+	registrationNumber = strings.TrimSpace(registrationNumber)
+	chassisNumber = strings.TrimSpace(chassisNumber)
+	if registrationNumber == "" && chassisNumber == "" {
+		return nil, newInternalError("GetCertificateByVehicle", ErrGetCertificateByVehicle, fmt.Errorf("registration number or chassis number is required"))
+	}
+	req := &InsuranceValidationRequest{
+		VehicleRegistrationNumber: registrationNumber,
+		ChassisNumber:             chassisNumber,
+	}
+	var resp InsuranceValidationResponse
+	err := c.makeAPICall(c.ctx, OpGetCertificateByVehicle, http.MethodPost, c.path(OpGetCertificateByVehicle), req, &resp, ErrGetCertificateByVehicle)
+	if err != nil {
+		return nil, err
 	}
 	return &resp, nil
 }
@@ -485,111 +834,114 @@ func (c *client) CancelCertificate(certificateNumber string, reasonID int) (*Can
 		CancelReasonID:    reasonID,
 	}
 	var resp CancellationResponse
-	err := c.makeAPICall(http.MethodPost, "/V4/Integration/CancelCertificate", req, &resp, ErrCancelCertificate)
+	err := c.makeAPICall(c.ctx, OpCancelCertificate, http.MethodPost, c.path(OpCancelCertificate), req, &resp, ErrCancelCertificate)
 	if err != nil {
 		return nil, err
 	}
-	if !resp.Success && len(resp.Error) > 0 {
-		dmvicCode := c.parseDMVICError(resp.Error[0].ErrorCode)
-		return nil, newDMVICError("CancelCertificate", ErrCancelCertificate, dmvicCode, resp.Error[0].ErrorText)
-	}
 	return &resp, nil
 }
 
 func (c *client) ValidateDoubleInsurance(req *DoubleInsuranceRequest) (*DoubleInsuranceResponse, error) {
 	var resp DoubleInsuranceResponse
-	err := c.makeAPICall(http.MethodPost, "/V4/Integration/ValidateDoubleInsurance", req, &resp, ErrValidateDoubleInsurance)
+	err := c.makeAPICall(c.ctx, OpValidateDoubleInsurance, http.MethodPost, c.path(OpValidateDoubleInsurance), req, &resp, ErrValidateDoubleInsurance)
 	if err != nil {
 		return nil, err
 	}
-	if !resp.Success && len(resp.Error) > 0 {
-		dmvicCode := c.parseDMVICError(resp.Error[0].ErrorCode)
-		return nil, newDMVICError("ValidateDoubleInsurance", ErrValidateDoubleInsurance, dmvicCode, resp.Error[0].ErrorText)
-	}
 	return &resp, nil
 }
 
 func (c *client) IssueTypeACertificate(req *TypeAIssuanceRequest) (*InsuranceResponse, error) {
 
 	var resp InsuranceResponse
-	err := c.makeAPICall(http.MethodPost, "/V4/IntermediaryIntegration/IssuanceTypeACertificate", req, &resp, ErrIssuanceTypeA)
+	err := c.makeAPICall(c.ctx, OpIssueTypeA, http.MethodPost, c.path(OpIssueTypeA), req, &resp, ErrIssuanceTypeA)
 	if err != nil {
 		return nil, err
 	}
-	if !resp.Success && len(resp.Error) > 0 {
-		dmvicCode := c.parseDMVICError(resp.Error[0].ErrorCode)
-		clientErr := newDMVICError("IssueTypeACertificate", ErrIssuanceTypeA, dmvicCode, resp.Error[0].ErrorText)
-		return nil, clientErr
-	}
 	return &resp, nil
 }
 
 func (c *client) IssueTypeBCertificate(req *TypeBIssuanceRequest) (*InsuranceResponse, error) {
 	var resp InsuranceResponse
-	err := c.makeAPICall(http.MethodPost, "/V4/IntermediaryIntegration/IssuanceTypeBCertificate", req, &resp, ErrIssuanceTypeB)
+	err := c.makeAPICall(c.ctx, OpIssueTypeB, http.MethodPost, c.path(OpIssueTypeB), req, &resp, ErrIssuanceTypeB)
 	if err != nil {
 		return nil, err
 	}
-	if !resp.Success && len(resp.Error) > 0 {
-		dmvicCode := c.parseDMVICError(resp.Error[0].ErrorCode)
-		clientErr := newDMVICError("IssueTypeBCertificate", ErrIssuanceTypeB, dmvicCode, resp.Error[0].ErrorText)
-		return nil, clientErr
-	}
 	return &resp, nil
 }
 
 func (c *client) IssueTypeCCertificate(req *TypeCIssuanceRequest) (*InsuranceResponse, error) {
 	var resp InsuranceResponse
-	err := c.makeAPICall(http.MethodPost, "/V4/IntermediaryIntegration/IssuanceTypeCCertificate", req, &resp, ErrIssuanceTypeC)
+	err := c.makeAPICall(c.ctx, OpIssueTypeC, http.MethodPost, c.path(OpIssueTypeC), req, &resp, ErrIssuanceTypeC)
 	if err != nil {
 		return nil, err
 	}
-	if !resp.Success && len(resp.Error) > 0 {
-		dmvicCode := c.parseDMVICError(resp.Error[0].ErrorCode)
-		clientErr := newDMVICError("IssueTypeCCertificate", ErrIssuanceTypeC, dmvicCode, resp.Error[0].ErrorText)
-		return nil, clientErr
-	}
 	return &resp, nil
 }
 
 func (c *client) IssueTypeDCertificate(req *TypeDIssuanceRequest) (*InsuranceResponse, error) {
 	var resp InsuranceResponse
-	err := c.makeAPICall(http.MethodPost, "/V4/IntermediaryIntegration/IssuanceTypeDCertificate", req, &resp, ErrIssuanceTypeD)
+	err := c.makeAPICall(c.ctx, OpIssueTypeD, http.MethodPost, c.path(OpIssueTypeD), req, &resp, ErrIssuanceTypeD)
 	if err != nil {
 		return nil, err
 	}
-	if !resp.Success && len(resp.Error) > 0 {
-		dmvicCode := c.parseDMVICError(resp.Error[0].ErrorCode)
-		clientErr := newDMVICError("IssueTypeDCertificate", ErrIssuanceTypeD, dmvicCode, resp.Error[0].ErrorText)
-		return nil, clientErr
+	return &resp, nil
+}
+
+// ExtendCertificate changes the period of cover of an active certificate in place,
+// avoiding a cancel-and-reissue that would consume another certificate from stock.
+func (c *client) ExtendCertificate(req *ExtensionRequest) (*InsuranceResponse, error) {
+	if err := ValidateExtensionRequest(req); err != nil {
+		return nil, newInternalError(string(OpExtendCertificate), ErrExtendCertificate, err)
+	}
+	var resp InsuranceResponse
+	err := c.makeAPICall(c.ctx, OpExtendCertificate, http.MethodPost, c.path(OpExtendCertificate), req, &resp, ErrExtendCertificate)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// EndorseCertificate amends the vehicle or policyholder details of an active certificate.
+func (c *client) EndorseCertificate(req *EndorsementRequest) (*InsuranceResponse, error) {
+	if err := ValidateEndorsementRequest(req); err != nil {
+		return nil, newInternalError(string(OpEndorseCertificate), ErrEndorseCertificate, err)
+	}
+	var resp InsuranceResponse
+	err := c.makeAPICall(c.ctx, OpEndorseCertificate, http.MethodPost, c.path(OpEndorseCertificate), req, &resp, ErrEndorseCertificate)
+	if err != nil {
+		return nil, err
 	}
 	return &resp, nil
 }
 
 func (c *client) GetMemberCompanyStock(memberCompanyID int) (*StockResponse, error) {
 	var resp StockResponse
-	endpoint := fmt.Sprintf("/V4/IntermediaryIntegration/MemberCompanyStock?MemberCompanyId=%d", memberCompanyID)
-	err := c.makeAPICall(http.MethodGet, endpoint, nil, &resp, ErrMemberCompanyStock)
+	req := newAPIRequest(OpMemberCompanyStock, http.MethodGet, c.path(OpMemberCompanyStock)).
+		Query("MemberCompanyId", strconv.Itoa(memberCompanyID))
+	err := c.send(c.ctx, req, &resp, ErrMemberCompanyStock)
 	if err != nil {
 		return nil, err
 	}
-	if !resp.Success && len(resp.Error) > 0 {
-		dmvicCode := c.parseDMVICError(resp.Error[0].ErrorCode)
-		return nil, newDMVICError("GetMemberCompanyStock", ErrMemberCompanyStock, dmvicCode, resp.Error[0].ErrorText)
-	}
 	return &resp, nil
 }
 
+// GetMemberCompanyStockPage retrieves the member company stock and applies the filters
+// and paging in opts. DMVIC returns the full stock list, so filtering happens client-side.
+func (c *client) GetMemberCompanyStockPage(memberCompanyID int, opts StockQueryOptions) (*StockPage, error) {
+	resp, err := c.GetMemberCompanyStock(memberCompanyID)
+	if err != nil {
+		return nil, err
+	}
+	page := resp.Query(opts)
+	return &page, nil
+}
+
 func (c *client) ConfirmCertificateIssuance(req *ConfirmationRequest) (*InsuranceResponse, error) {
 	var resp InsuranceResponse
-	err := c.makeAPICall(http.MethodPost, "/V4/IntermediaryIntegration/ConfirmCertificateIssuance", req, &resp, ErrConfirmIssuance)
+	err := c.makeAPICall(c.ctx, OpConfirmIssuance, http.MethodPost, c.path(OpConfirmIssuance), req, &resp, ErrConfirmIssuance)
 	if err != nil {
 		return nil, err
 	}
-	if !resp.Success && len(resp.Error) > 0 {
-		dmvicCode := c.parseDMVICError(resp.Error[0].ErrorCode)
-		return nil, newDMVICError("ConfirmCertificateIssuance", ErrConfirmIssuance, dmvicCode, resp.Error[0].ErrorText)
-	}
 	return &resp, nil
 }
 
@@ -597,42 +949,23 @@ func (c *client) ConfirmCertificateIssuance(req *ConfirmationRequest) (*Insuranc
 func (c *client) secureRequest(method, url string, jsonPayload []byte) (*http.Client, *http.Request, error) {
 	// Load client cert
 
-	value, found := c.tknStorage.Get("dmvictoken")
+	value, found := c.tknStorage.Get(c.tokenKey)
 	if !found {
 		c.debugLog("Token not found or empty, refreshing...")
 		err := c.Login()
 		if err != nil {
 			return nil, nil, err
 		}
-		value, _ = c.tknStorage.Get("dmvictoken")
+		value, _ = c.tknStorage.Get(c.tokenKey)
 	} else {
 		//c.token = value
 		c.debugLog("Using cached token")
 	}
 
-	cert, err := tls.LoadX509KeyPair(c.config.AuthCertPath, c.config.AuthKeyPath)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load cert/key: %w", err)
-	}
-
-	// Optionally load CA cert if the server uses a custom CA
-	caCert, err := ioutil.ReadFile(c.config.AuthCaCertPath) // optional
+	client, err := c.secureHTTPClient()
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load CA cert: %w", err)
-	}
-	caCertPool := x509.NewCertPool()
-	caCertPool.AppendCertsFromPEM(caCert)
-
-	// Set up HTTPS client with mutual TLS
-	tlsConfig := &tls.Config{
-		Certificates: []tls.Certificate{cert},
-		// RootCAs:      caCertPool, // optional, uncomment if needed
+		return nil, nil, err
 	}
-	// Deprecated in Go 1.15+, but harmless for compatibility
-	tlsConfig.BuildNameToCertificate()
-
-	transport := &http.Transport{TLSClientConfig: tlsConfig}
-	client := &http.Client{Transport: transport}
 
 	// Build request
 	req, err := http.NewRequest(method, url, bytes.NewBuffer(jsonPayload))
@@ -644,13 +977,15 @@ func (c *client) secureRequest(method, url string, jsonPayload []byte) (*http.Cl
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", value))
 	req.Header.Set("ClientID", c.config.ClientID)
+	c.setClientHeaders(req)
 
 	return client, req, nil
 }
 
-// secureRequest creates a mutual TLS HTTP client and request for DMVIC
+// normalRequest returns the shared HTTP client and a request for DMVIC endpoints
+// that do not require mutual TLS
 func (c *client) normalRequest(method, url string, jsonPayload []byte) (*http.Client, *http.Request, error) {
-	value, found := c.tknStorage.Get("dmvictoken")
+	value, found := c.tknStorage.Get(c.tokenKey)
 	if !found {
 		c.debugLog("Token not found or empty, refreshing...")
 		err := c.Login()
@@ -662,16 +997,6 @@ func (c *client) normalRequest(method, url string, jsonPayload []byte) (*http.Cl
 		c.debugLog("Using cached token")
 	}
 
-	// Create a standard HTTP client
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: c.config.InsecureSkipVerify,
-		},
-	}
-	client := &http.Client{
-		Timeout:   c.config.Timeout,
-		Transport: transport,
-	}
 	// Build request
 
 	req, err := http.NewRequest(method, url, bytes.NewBuffer(jsonPayload))
@@ -684,5 +1009,8 @@ func (c *client) normalRequest(method, url string, jsonPayload []byte) (*http.Cl
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", value))
 	req.Header.Set("ClientID", c.config.ClientID)
-	return client, req, nil
+	c.setClientHeaders(req)
+	// the shared client has no timeout of its own, request deadlines are set per
+	// operation, see Config.TimeoutFor
+	return c.httpClient, req, nil
 }
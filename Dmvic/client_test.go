@@ -0,0 +1,194 @@
+package dmvic
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testServer is a fake DMVIC API: logins are answered with a fresh token and all other
+// requests are passed to the handler of the test.
+type testServer struct {
+	*httptest.Server
+	logins atomic.Int32
+}
+
+func newTestServer(t *testing.T, handler http.HandlerFunc) *testServer {
+	t.Helper()
+	ts := &testServer{}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == defaultEndpointTemplates[OpLogin] {
+			n := ts.logins.Add(1)
+			writeJSON(w, LoginResponse{
+				Token:   "token-" + strconv.Itoa(int(n)),
+				Expires: time.Now().Add(time.Hour).Format(time.RFC3339),
+			})
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// newTestClient creates a client calling ts. The mutual TLS client is replaced by the
+// plain client of the server, so no certificates are needed.
+func newTestClient(t *testing.T, ts *testServer, configure func(*Config)) *client {
+	t.Helper()
+	config := &Config{
+		Credentials:    Credentials{Username: "user", Password: "secret"},
+		ClientID:       "client-id",
+		Environment:    UAT,
+		CustomEndpoint: ts.URL,
+		AuthCertPath:   "unused.crt",
+		AuthKeyPath:    "unused.key",
+		AuthCaCertPath: "unused-ca.crt",
+		TokenTTL:       time.Hour,
+	}
+	if configure != nil {
+		configure(config)
+	}
+	c, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	cl := c.(*client)
+	cl.tls.client = ts.Client()
+	return cl
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func TestNormalRequestSharesClient(t *testing.T) {
+	ts := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	c := newTestClient(t, ts, func(config *Config) { config.Timeout = time.Second })
+
+	first, _, err := c.normalRequest(http.MethodPost, ts.URL, nil)
+	if err != nil {
+		t.Fatalf("normalRequest: %v", err)
+	}
+	second, _, _ := c.normalRequest(http.MethodPost, ts.URL, nil)
+	if first != c.httpClient || second != c.httpClient {
+		t.Error("expected normal requests to use the shared client")
+	}
+	// the deadline of a call is set per operation, a client timeout would cap it
+	if first.Timeout != 0 {
+		t.Errorf("expected no client timeout, got %v", first.Timeout)
+	}
+}
+
+func TestResponseErrorSurfacing(t *testing.T) {
+	ts := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/V4/Integration/ValidateInsurance":
+			writeJSON(w, InsuranceValidationResponse{
+				APIRequestNumber: "UAT-1",
+				Error: FlexibleDmvicError{
+					{ErrorCode: DMVICErrDataValidation, ErrorText: "Data Validation Error"},
+					{ErrorCode: DMVICErrMandatoryField, ErrorText: "Mandatory field is missing"},
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			writeJSON(w, map[string]string{"apiRequestNumber": "UAT-2"})
+		}
+	})
+	c := newTestClient(t, ts, nil)
+
+	_, err := c.ValidateInsurance(&InsuranceValidationRequest{CertificateNumber: "C123"})
+	var clientErr *ClientError
+	if !errors.As(err, &clientErr) {
+		t.Fatalf("expected a ClientError, got %v", err)
+	}
+	if clientErr.DMVICCode != DMVICErrDataValidation || !clientErr.HasDMVICCode(DMVICErrMandatoryField) || len(clientErr.Errors) != 2 {
+		t.Errorf("expected all DMVIC errors, got %+v", clientErr)
+	}
+	if clientErr.Message != "Data Validation Error; Mandatory field is missing" || clientErr.Code != ErrValidateInsurance {
+		t.Errorf("unexpected message or code: %+v", clientErr)
+	}
+	if clientErr.HTTPStatus != http.StatusOK || clientErr.APIRequestNumber != "UAT-1" {
+		t.Errorf("expected HTTP status and request number, got %+v", clientErr)
+	}
+
+	_, err = c.CancelCertificate("C123", CancelReasonInsuredRequest)
+	if !errors.As(err, &clientErr) || clientErr.HTTPStatus != http.StatusInternalServerError || clientErr.APIRequestNumber != "UAT-2" {
+		t.Errorf("expected the HTTP status and request number of the failed call, got %v", err)
+	}
+}
+
+func TestTokenErrorRetriedOnce(t *testing.T) {
+	var tokens []string
+	expired := 1
+	ts := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("Authorization"))
+		if len(tokens) <= expired {
+			writeJSON(w, CertificateResponse{Error: FlexibleDmvicError{{ErrorText: "Token is expired"}}})
+			return
+		}
+		writeJSON(w, CertificateResponse{Success: true})
+	})
+	c := newTestClient(t, ts, nil)
+
+	if _, err := c.GetCertificate("C123"); err != nil {
+		t.Fatalf("expected the retry to succeed, got %v", err)
+	}
+	if len(tokens) != 2 || tokens[0] != "Bearer token-1" || tokens[1] != "Bearer token-2" || ts.logins.Load() != 2 {
+		t.Fatalf("expected one retry with a fresh token, got %v after %d logins", tokens, ts.logins.Load())
+	}
+
+	// a token error after logging in again is not retried any further
+	tokens, expired = nil, 10
+	_, err := c.GetCertificate("C123")
+	var clientErr *ClientError
+	if !errors.As(err, &clientErr) || len(clientErr.Errors) != 1 {
+		t.Errorf("expected the token error, got %v", err)
+	}
+	if len(tokens) != 2 || ts.logins.Load() != 3 {
+		t.Errorf("expected a single retry, got %d requests after %d logins", len(tokens), ts.logins.Load())
+	}
+}
+
+func TestOperationTimeout(t *testing.T) {
+	config := &Config{Timeout: 5 * time.Second, OperationTimeouts: map[Operation]time.Duration{
+		OpValidateInsurance: 50 * time.Millisecond,
+		OpGetCertificate:    0,
+	}}
+	if got := config.TimeoutFor(OpValidateInsurance); got != 50*time.Millisecond {
+		t.Errorf("expected the operation timeout, got %v", got)
+	}
+	if got := config.TimeoutFor(OpGetCertificate); got != 5*time.Second {
+		t.Errorf("expected a zero override to use Timeout, got %v", got)
+	}
+	if got := config.TimeoutFor(OpCancelCertificate); got != 5*time.Second {
+		t.Errorf("expected Timeout, got %v", got)
+	}
+
+	release := make(chan struct{})
+	ts := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	})
+	t.Cleanup(func() { close(release) })
+	c := newTestClient(t, ts, func(config *Config) {
+		config.Timeout = 5 * time.Second
+		config.OperationTimeouts = map[Operation]time.Duration{OpValidateInsurance: 50 * time.Millisecond}
+		config.SafeRetryAttempts = -1
+	})
+	started := time.Now()
+	_, err := c.ValidateInsurance(&InsuranceValidationRequest{CertificateNumber: "C123"})
+	var clientErr *ClientError
+	if !errors.As(err, &clientErr) || clientErr.Code != ErrValidateInsurance+3 {
+		t.Fatalf("expected a request error, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("expected the operation timeout to apply, the call took %v", elapsed)
+	}
+}
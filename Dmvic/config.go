@@ -3,7 +3,10 @@ package dmvic
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Environment represents the DMVIC environment type (production or UAT).
@@ -40,13 +43,104 @@ type Config struct {
 	AuthCertPath       string          // Path to client certificate file
 	AuthKeyPath        string          // Path to client private key file
 	AuthCaCertPath     string          // Path to CA certificate file
+
+	// DisableCustomRootCAs verifies the DMVIC server against the system roots only,
+	// without adding the bundle at AuthCaCertPath.
+	DisableCustomRootCAs bool
+
+	// OperationTimeouts overrides Timeout for individual operations, e.g. issuance
+	// calls which are much slower than validation. Operations not listed use Timeout.
+	OperationTimeouts map[Operation]time.Duration
+
+	// SafeRetryAttempts is the number of extra attempts made for retry-safe (GET) calls
+	// that fail with a transport error or a 429/5xx response, defaults to 2.
+	// A negative value disables these retries.
+	SafeRetryAttempts int
+
+	// SafeRetryBackoff is the delay before the first retry of a retry-safe call and
+	// doubles on every further attempt, defaults to 200ms.
+	SafeRetryBackoff time.Duration
+
+	// TokenStore, when set, holds the access tokens instead of a cache private to the
+	// client, e.g. to share tokens between instances. Tokens are stored under TokenKey.
+	TokenStore DmvitokenStorage
+
+	// TokenKey overrides the key the access token is stored under. It defaults to one
+	// derived from ClientID and the username, so clients with different credentials
+	// sharing a TokenStore never use each other's tokens.
+	TokenKey string
+
+	// AuditStore, when set, receives an audit record for every DMVIC API call.
+	AuditStore AuditStore
+
+	// TracerProvider creates the spans of DMVIC API calls. When nil the global
+	// OpenTelemetry provider is used, which records nothing unless one is registered.
+	TracerProvider trace.TracerProvider
+
+	// APIVersion selects the DMVIC integration API version, defaults to V4.
+	// UAT and production configs may use different versions during a rollout.
+	APIVersion APIVersion
+
+	// Endpoints overrides the endpoint paths per operation and API version.
+	// When nil the paths of the Catalog are used.
+	Endpoints *EndpointRegistry
+
+	// Catalog describes the method, transport and retry behaviour of every operation
+	// and holds the feature flags to switch endpoints off at runtime. When nil
+	// NewClient sets it to DefaultEndpointCatalog.
+	Catalog *EndpointCatalog
+
+	// CredentialsProvider, when set, is called on every Login to obtain the current
+	// credentials instead of using Credentials, so secrets can be rotated in a vault
+	// without recreating the client.
+	CredentialsProvider CredentialsProvider
+
+	// ResponseValidation checks responses against the response types and reports
+	// missing mandatory fields and unexpected types, see ValidateResponseSchema.
+	ResponseValidation ResponseValidationMode
+
+	// UserAgent identifies the calling service, e.g. "policy-service/1.4.2". It is sent
+	// in the User-Agent header ahead of the library name and version.
+	UserAgent string
+
+	// DryRun makes the issuance, confirmation, cancellation, extension and endorsement
+	// operations validate and log their request and return a synthetic successful
+	// response without calling DMVIC, e.g. in staging without UAT credentials.
+	DryRun bool
 }
 
+// CredentialsProvider returns the credentials to log in with.
+type CredentialsProvider func(ctx context.Context) (Credentials, error)
+
+// Operation identifies a DMVIC API operation.
+// It is used to configure per-operation behaviour such as timeouts.
+type Operation string
+
+const (
+	OpLogin                   Operation = "Login"
+	OpGetCertificate          Operation = "GetCertificate"
+	OpValidateInsurance       Operation = "ValidateInsurance"
+	OpGetCertificateByVehicle Operation = "GetCertificateByVehicle"
+	OpCancelCertificate       Operation = "CancelCertificate"
+	OpValidateDoubleInsurance Operation = "ValidateDoubleInsurance"
+	OpIssueTypeA              Operation = "IssueTypeACertificate"
+	OpIssueTypeB              Operation = "IssueTypeBCertificate"
+	OpIssueTypeC              Operation = "IssueTypeCCertificate"
+	OpIssueTypeD              Operation = "IssueTypeDCertificate"
+	OpConfirmIssuance         Operation = "ConfirmCertificateIssuance"
+	OpMemberCompanyStock      Operation = "GetMemberCompanyStock"
+	OpExtendCertificate       Operation = "ExtendCertificate"
+	OpEndorseCertificate      Operation = "EndorseCertificate"
+	OpCreateSubUser           Operation = "CreateSubUser"
+	OpResetSubUserCredentials Operation = "ResetSubUserCredentials"
+	OpListEntitlements        Operation = "ListEntitlements"
+)
+
 // Validate checks if the configuration is complete and valid.
 // It ensures all required fields are set and applies default values where appropriate.
 // Returns an error if any required configuration is missing or invalid.
 func (c *Config) Validate() error {
-	if c.Credentials.Username == "" || c.Credentials.Password == "" {
+	if c.CredentialsProvider == nil && (c.Credentials.Username == "" || c.Credentials.Password == "") {
 		return fmt.Errorf("missing credentials")
 	}
 	if c.ClientID == "" {
@@ -61,7 +155,7 @@ func (c *Config) Validate() error {
 	if c.AuthCertPath == "" || c.AuthKeyPath == "" {
 		return fmt.Errorf("missing authentication certificate or key path")
 	}
-	if c.AuthCaCertPath == "" {
+	if c.AuthCaCertPath == "" && !c.DisableCustomRootCAs {
 		return fmt.Errorf("missing authentication CA certificate path")
 	}
 	if c.Context == nil {
@@ -71,9 +165,51 @@ func (c *Config) Validate() error {
 	if c.Timeout == 0 {
 		c.Timeout = 30 * time.Second
 	}
+	if c.SafeRetryAttempts == 0 {
+		c.SafeRetryAttempts = defaultSafeRetryAttempts
+	}
+	if c.SafeRetryBackoff == 0 {
+		c.SafeRetryBackoff = defaultSafeRetryBackoff
+	}
+	if c.Catalog == nil {
+		c.Catalog = DefaultEndpointCatalog()
+	}
+	if c.APIVersion == "" {
+		c.APIVersion = DefaultAPIVersion
+	}
+	switch c.ResponseValidation {
+	case ResponseValidationOff, ResponseValidationWarn, ResponseValidationStrict:
+	default:
+		return fmt.Errorf("invalid ResponseValidation: %s", c.ResponseValidation)
+	}
+	if !strings.HasPrefix(string(c.APIVersion), "V") {
+		return fmt.Errorf("invalid APIVersion: %s, must look like 'V4'", c.APIVersion)
+	}
+	for op, timeout := range c.OperationTimeouts {
+		if timeout < 0 {
+			return fmt.Errorf("invalid timeout %v for operation %s", timeout, op)
+		}
+	}
 	return nil
 }
 
+// tokenKey returns the key the access token is stored under.
+func (c *Config) tokenKey() string {
+	if c.TokenKey != "" {
+		return c.TokenKey
+	}
+	return "dmvictoken:" + c.ClientID + ":" + c.Credentials.Username
+}
+
+// TimeoutFor returns the timeout to apply to the given operation.
+// A positive entry in OperationTimeouts takes precedence over the global Timeout.
+func (c *Config) TimeoutFor(op Operation) time.Duration {
+	if timeout, ok := c.OperationTimeouts[op]; ok && timeout > 0 {
+		return timeout
+	}
+	return c.Timeout
+}
+
 // GetEndpoint returns the appropriate API endpoint URL based on configuration.
 // If CustomEndpoint is set, it takes precedence over the Environment setting.
 // Otherwise, it returns the standard endpoint for the specified environment.
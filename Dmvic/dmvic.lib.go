@@ -40,22 +40,38 @@ type DmvitokenStorage interface {
 // It provides thread-safe operations for storing and retrieving items with automatic cleanup
 // of expired entries.
 type TTLCache[K comparable, V any] struct {
-	items map[K]item[V] // The map storing cache items
-	mu    sync.Mutex    // Mutex for controlling concurrent access to the cache
+	items     map[K]item[V] // The map storing cache items
+	mu        sync.Mutex    // Mutex for controlling concurrent access to the cache
+	stop      chan struct{} // Closed to stop the cleanup goroutine
+	closeOnce sync.Once     // Guards closing stop
 }
 
 // NewTTL creates a new TTLCache instance and starts a goroutine to periodically
 // remove expired items. The cleanup interval is set to the provided TTL duration.
+// Call Close to stop the goroutine once the cache is no longer used.
 // Returns a pointer to the new TTLCache instance.
 func NewTTL[K comparable, V any](ttl time.Duration) *TTLCache[K, V] {
 	c := &TTLCache[K, V]{
 		items: make(map[K]item[V]),
+		stop:  make(chan struct{}),
 	}
 
 	go func() {
-		// 5  * time.Second  5 sec
+		// without a positive ttl there is nothing to clean up periodically,
+		// tick stays nil and the goroutine only waits for Close
+		var tick <-chan time.Time
+		if ttl > 0 {
+			ticker := time.NewTicker(ttl)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
 
-		for range time.Tick(ttl) {
+		for {
+			select {
+			case <-c.stop:
+				return
+			case <-tick:
+			}
 			c.mu.Lock()
 
 			// Iterate over the cache items and delete expired ones.
@@ -72,6 +88,14 @@ func NewTTL[K comparable, V any](ttl time.Duration) *TTLCache[K, V] {
 	return c
 }
 
+// Close stops the cleanup goroutine. The cache remains usable, expired items are
+// then only removed when accessed. Close is safe to call more than once.
+func (c *TTLCache[K, V]) Close() {
+	c.closeOnce.Do(func() {
+		close(c.stop)
+	})
+}
+
 // Set adds a new item to the cache with the specified key, value, and time-to-live (TTL).
 // If an item with the same key already exists, it will be overwritten with the new value and TTL.
 // This operation is thread-safe.
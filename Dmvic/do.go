@@ -0,0 +1,59 @@
+package dmvic
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Do calls a DMVIC endpoint that is not wrapped by the library yet. The call goes through
+// the same path as the built-in operations: authentication and token refresh, retries of
+// GET requests, timeouts, error mapping, tracing, audit and debug logging.
+//
+// path is relative to the environment endpoint and may contain the "{version}" placeholder,
+// which is replaced with the configured API version. When path is empty the path
+// registered for op is used. The request is JSON encoded unless it is nil and the response
+// is decoded into TResp; response types implementing GetErrors, IsSuccess and
+// GetAPIRequestNumber have Success=false mapped to a ClientError.
+//
+//	resp, err := dmvic.Do[MyRequest, MyResponse](ctx, client, "GetVehicleLogbook", http.MethodPost, "/{version}/Integration/GetLogbook", &req)
+func Do[TReq any, TResp any](ctx context.Context, c Client, op Operation, method, path string, req TReq) (*TResp, error) {
+	cl, ok := c.(*client)
+	if !ok {
+		return nil, newInternalError(string(op), ErrDo, fmt.Errorf("unsupported client implementation %T", c))
+	}
+	if ctx == nil {
+		ctx = cl.ctx
+	}
+	if path == "" {
+		path = cl.path(op)
+	}
+	if path == "" {
+		return nil, newInternalError(string(op), ErrDo, fmt.Errorf("no endpoint path for operation %s", op))
+	}
+	path = strings.ReplaceAll(path, versionPlaceholder, string(cl.config.APIVersion))
+
+	var resp TResp
+	var body interface{} = req
+	if isNil(body) {
+		body = nil
+	}
+	if err := cl.send(ctx, newAPIRequest(op, method, path).Body(body), &resp, ErrDo); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// isNil reports whether v is nil or a nil pointer, map, slice or interface.
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
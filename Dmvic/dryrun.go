@@ -0,0 +1,137 @@
+package dmvic
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// dryRunPrefix marks the identifiers of synthetic dry-run responses.
+const dryRunPrefix = "DRYRUN-"
+
+// dryRunOperations are the operations that change state at DMVIC and are therefore
+// simulated when Config.DryRun is set. Lookups still call DMVIC.
+var dryRunOperations = map[Operation]bool{
+	OpIssueTypeA:         true,
+	OpIssueTypeB:         true,
+	OpIssueTypeC:         true,
+	OpIssueTypeD:         true,
+	OpConfirmIssuance:    true,
+	OpCancelCertificate:  true,
+	OpExtendCertificate:  true,
+	OpEndorseCertificate: true,
+}
+
+// isDryRun reports whether op is simulated instead of sent to DMVIC.
+func (c *client) isDryRun(op Operation) bool {
+	return c.config.DryRun && dryRunOperations[op]
+}
+
+// dryRun validates the request of a simulated operation, logs it and fills response
+// with a synthetic successful result. Identifiers in the result start with "DRYRUN-".
+func (c *client) dryRun(op Operation, request interface{}, response interface{}, errorCode int) error {
+	if err := validateDryRunRequest(request); err != nil {
+		return newInternalError(string(op), errorCode, err)
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return newInternalError(string(op), errorCode+2, err)
+	}
+	log.Printf("[DMVIC DRYRUN] %s: %s", op, string(body))
+
+	ref := fmt.Sprintf("%s%d", dryRunPrefix, time.Now().UnixNano())
+	switch resp := response.(type) {
+	case *InsuranceResponse:
+		resp.Success = true
+		resp.APIRequestNumber = ref
+		resp.Inputs = request
+		resp.CallbackObj.IssueCertificate = IssuanceDetails{
+			TransactionNo: ref,
+			ActualCNo:     dryRunCertificateNumber(request, ref),
+			Email:         dryRunEmail(request),
+		}
+	case *CancellationResponse:
+		resp.Success = true
+		resp.APIRequestNumber = ref
+		if req, ok := request.(*CancellationRequest); ok {
+			resp.Inputs = *req
+		}
+		resp.CallbackObj.TransactionReferenceNumber = ref
+	default:
+		return newInternalError(string(op), errorCode, fmt.Errorf("dry run is not supported for response %T", response))
+	}
+	return nil
+}
+
+// validateDryRunRequest runs the validation DMVIC would otherwise apply to the request.
+func validateDryRunRequest(request interface{}) error {
+	switch req := request.(type) {
+	case *TypeAIssuanceRequest:
+		if req == nil || req.BaseIssuanceFields == nil {
+			return fmt.Errorf("issuance fields are required")
+		}
+		return ValidateTypeARequest(req)
+	case *TypeBIssuanceRequest:
+		if req == nil || req.BaseIssuanceFields == nil {
+			return fmt.Errorf("issuance fields are required")
+		}
+		return ValidateTypeBRequest(req)
+	case *TypeCIssuanceRequest:
+		if req == nil || req.BaseIssuanceFields == nil {
+			return fmt.Errorf("issuance fields are required")
+		}
+		return ValidateTypeCRequest(req)
+	case *TypeDIssuanceRequest:
+		if req == nil || req.BaseIssuanceFields == nil {
+			return fmt.Errorf("issuance fields are required")
+		}
+		return ValidateTypeDRequest(req)
+	case *ExtensionRequest:
+		return ValidateExtensionRequest(req)
+	case *EndorsementRequest:
+		return ValidateEndorsementRequest(req)
+	case *CancellationRequest:
+		if strings.TrimSpace(req.CertificateNumber) == "" {
+			return fmt.Errorf("CertificateNumber is required")
+		}
+		if !IsValidCancelReason(req.CancelReasonID) {
+			return fmt.Errorf("invalid cancel reason: %d", req.CancelReasonID)
+		}
+	case *ConfirmationRequest:
+		if strings.TrimSpace(req.IssuanceRequestID) == "" {
+			return fmt.Errorf("IssuanceRequestID is required")
+		}
+	}
+	return nil
+}
+
+// dryRunCertificateNumber keeps the certificate number of requests that change an
+// existing certificate and makes one up for new issuances.
+func dryRunCertificateNumber(request interface{}, ref string) string {
+	switch req := request.(type) {
+	case *ExtensionRequest:
+		return req.CertificateNumber
+	case *EndorsementRequest:
+		return req.CertificateNumber
+	}
+	return "C" + ref
+}
+
+// dryRunEmail returns the contact email of an issuance request.
+func dryRunEmail(request interface{}) string {
+	switch req := request.(type) {
+	case *TypeAIssuanceRequest:
+		return req.Email
+	case *TypeBIssuanceRequest:
+		return req.Email
+	case *TypeCIssuanceRequest:
+		return req.Email
+	case *TypeDIssuanceRequest:
+		return req.Email
+	case *EndorsementRequest:
+		return req.Email
+	}
+	return ""
+}
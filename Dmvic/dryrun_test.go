@@ -0,0 +1,38 @@
+package dmvic
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	var calls []string
+	ts := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.URL.Path)
+		writeJSON(w, InsuranceValidationResponse{Success: true})
+	})
+	c := newTestClient(t, ts, func(config *Config) { config.DryRun = true })
+
+	resp, err := c.CancelCertificate("C123", CancelReasonInsuredRequest)
+	if err != nil {
+		t.Fatalf("dry run cancellation: %v", err)
+	}
+	if !resp.Success || !strings.HasPrefix(resp.APIRequestNumber, dryRunPrefix) || resp.Inputs.CertificateNumber != "C123" {
+		t.Errorf("expected a synthetic response, got %+v", resp)
+	}
+	if _, err := c.CancelCertificate("C123", -1); err == nil {
+		t.Error("expected an invalid dry run request to fail")
+	}
+	if len(calls) != 0 || ts.logins.Load() != 0 {
+		t.Fatalf("expected no calls to DMVIC, got %v", calls)
+	}
+
+	// lookups still call DMVIC
+	if _, err := c.ValidateInsurance(&InsuranceValidationRequest{CertificateNumber: "C123"}); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if len(calls) != 1 {
+		t.Errorf("expected the lookup to call DMVIC, got %v", calls)
+	}
+}
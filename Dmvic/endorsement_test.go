@@ -0,0 +1,73 @@
+package dmvic
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestExtendAndEndorseCertificate(t *testing.T) {
+	var extension ExtensionRequest
+	var endorsement map[string]string
+	ts := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/V4/IntermediaryIntegration/ExtendCertificate":
+			json.NewDecoder(r.Body).Decode(&extension)
+		case "/V4/IntermediaryIntegration/EndorseCertificate":
+			json.NewDecoder(r.Body).Decode(&endorsement)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+		writeJSON(w, InsuranceResponse{Success: true, CallbackObj: IssuanceCallbackObj{}})
+	})
+	c := newTestClient(t, ts, nil)
+
+	_, err := c.ExtendCertificate(&ExtensionRequest{CertificateNumber: "C123", CommencingDate: "01/01/2026", ExpiringDate: "31/03/2026"})
+	if err != nil {
+		t.Fatalf("ExtendCertificate: %v", err)
+	}
+	if extension.CertificateNumber != "C123" || extension.ExpiringDate != "31/03/2026" {
+		t.Errorf("unexpected extension request %+v", extension)
+	}
+
+	if _, err := c.EndorseCertificate(&EndorsementRequest{CertificateNumber: "C123", RegistrationNumber: "KDA 123A"}); err != nil {
+		t.Fatalf("EndorseCertificate: %v", err)
+	}
+	// fields that do not change are left out of the request
+	if endorsement["Registrationnumber"] != "KDA 123A" || len(endorsement) != 2 {
+		t.Errorf("expected only the certificate and the amended field, got %v", endorsement)
+	}
+}
+
+func TestExtendAndEndorseRejectInvalidRequests(t *testing.T) {
+	ts := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+	})
+	c := newTestClient(t, ts, nil)
+
+	var clientErr *ClientError
+	extensions := []*ExtensionRequest{
+		nil,
+		{ExpiringDate: "31/03/2026"},
+		{CertificateNumber: "C123", ExpiringDate: "2026-03-31"},
+		{CertificateNumber: "C123", CommencingDate: "31/03/2026", ExpiringDate: "01/01/2026"},
+	}
+	for _, req := range extensions {
+		if _, err := c.ExtendCertificate(req); !errors.As(err, &clientErr) || clientErr.Code != ErrExtendCertificate {
+			t.Errorf("expected %+v to be rejected, got %v", req, err)
+		}
+	}
+
+	endorsements := []*EndorsementRequest{
+		nil,
+		{RegistrationNumber: "KDA 123A"},
+		{CertificateNumber: "C123"},
+		{CertificateNumber: "C123", Email: "jane"},
+	}
+	for _, req := range endorsements {
+		if _, err := c.EndorseCertificate(req); !errors.As(err, &clientErr) || clientErr.Code != ErrEndorseCertificate {
+			t.Errorf("expected %+v to be rejected, got %v", req, err)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package dmvic
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// APIVersion identifies a version of the DMVIC integration API, e.g. "V4".
+type APIVersion string
+
+const (
+	APIVersionV4 APIVersion = "V4"
+	APIVersionV5 APIVersion = "V5"
+
+	// DefaultAPIVersion is used when Config.APIVersion is empty.
+	DefaultAPIVersion = APIVersionV4
+)
+
+// versionPlaceholder is replaced with the configured API version in endpoint templates.
+const versionPlaceholder = "{version}"
+
+// defaultEndpointTemplates are the paths of the built-in operations. DMVIC keeps the
+// same paths across integration API versions, only the version segment changes.
+// Login is not versioned.
+var defaultEndpointTemplates = map[Operation]string{
+	OpLogin:                   "/V1/Account/Login",
+	OpGetCertificate:          "/{version}/Integration/GetCertificate",
+	OpValidateInsurance:       "/{version}/Integration/ValidateInsurance",
+	OpGetCertificateByVehicle: "/{version}/Integration/ValidateInsurance",
+	OpCancelCertificate:       "/{version}/Integration/CancelCertificate",
+	OpValidateDoubleInsurance: "/{version}/Integration/ValidateDoubleInsurance",
+	OpIssueTypeA:              "/{version}/IntermediaryIntegration/IssuanceTypeACertificate",
+	OpIssueTypeB:              "/{version}/IntermediaryIntegration/IssuanceTypeBCertificate",
+	OpIssueTypeC:              "/{version}/IntermediaryIntegration/IssuanceTypeCCertificate",
+	OpIssueTypeD:              "/{version}/IntermediaryIntegration/IssuanceTypeDCertificate",
+	OpConfirmIssuance:         "/{version}/IntermediaryIntegration/ConfirmCertificateIssuance",
+	OpMemberCompanyStock:      "/{version}/IntermediaryIntegration/MemberCompanyStock",
+	OpExtendCertificate:       "/{version}/IntermediaryIntegration/ExtendCertificate",
+	OpEndorseCertificate:      "/{version}/IntermediaryIntegration/EndorseCertificate",
+	OpCreateSubUser:           "/{version}/Account/CreateSubUser",
+	OpResetSubUserCredentials: "/{version}/Account/ResetCredentials",
+	OpListEntitlements:        "/{version}/Account/Entitlements",
+}
+
+// EndpointRegistry maps operations to endpoint paths per API version.
+// Paths registered for a specific version take precedence over the version
+// independent templates, which may contain a "{version}" placeholder.
+// A registry is safe for concurrent use.
+type EndpointRegistry struct {
+	mu        sync.RWMutex
+	templates map[Operation]string
+	paths     map[APIVersion]map[Operation]string
+}
+
+// NewEndpointRegistry creates a registry with the paths of all built-in operations.
+func NewEndpointRegistry() *EndpointRegistry {
+	r := &EndpointRegistry{
+		templates: make(map[Operation]string, len(defaultEndpointTemplates)),
+		paths:     make(map[APIVersion]map[Operation]string),
+	}
+	for op, path := range defaultEndpointTemplates {
+		r.templates[op] = path
+	}
+	return r
+}
+
+// Register sets the path of op for a single API version, e.g. when an endpoint
+// was renamed in V5.
+func (r *EndpointRegistry) Register(version APIVersion, op Operation, path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.paths[version] == nil {
+		r.paths[version] = make(map[Operation]string)
+	}
+	r.paths[version][op] = path
+}
+
+// RegisterTemplate sets the path of op for all API versions. The path may contain
+// the "{version}" placeholder.
+func (r *EndpointRegistry) RegisterTemplate(op Operation, path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.templates[op] = path
+}
+
+// Resolve returns the path of op for the given API version.
+func (r *EndpointRegistry) Resolve(version APIVersion, op Operation) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if path, ok := r.paths[version][op]; ok {
+		return path, nil
+	}
+	if tmpl, ok := r.templates[op]; ok {
+		return strings.ReplaceAll(tmpl, versionPlaceholder, string(version)), nil
+	}
+	return "", fmt.Errorf("no endpoint registered for operation %s in API version %s", op, version)
+}
+
+// resolveEndpoints resolves the paths of all built-in and catalog operations for the
+// configured API version, so that a misconfigured registry fails at client creation.
+// Paths of the Endpoints registry take precedence over the catalog paths.
+func resolveEndpoints(config *Config) (map[Operation]string, error) {
+	custom := config.Endpoints != nil
+	registry := config.Endpoints
+	if !custom {
+		registry = NewEndpointRegistry()
+	}
+	resolved := make(map[Operation]string, len(defaultEndpointTemplates))
+	for op := range defaultEndpointTemplates {
+		resolved[op] = ""
+	}
+	for _, spec := range config.Catalog.Specs() {
+		resolved[spec.Operation] = ""
+	}
+	for op := range resolved {
+		if spec, ok := config.Catalog.Spec(op); ok && !(custom && registry.has(op)) {
+			resolved[op] = strings.ReplaceAll(spec.Path, versionPlaceholder, string(config.APIVersion))
+			continue
+		}
+		path, err := registry.Resolve(config.APIVersion, op)
+		if err != nil {
+			return nil, err
+		}
+		resolved[op] = path
+	}
+	return resolved, nil
+}
+
+// has reports whether the registry knows a path of op for any API version.
+func (r *EndpointRegistry) has(op Operation) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, ok := r.templates[op]; ok {
+		return true
+	}
+	for _, paths := range r.paths {
+		if _, ok := paths[op]; ok {
+			return true
+		}
+	}
+	return false
+}
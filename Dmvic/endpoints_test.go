@@ -0,0 +1,52 @@
+package dmvic
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestEndpointRegistryResolve(t *testing.T) {
+	r := NewEndpointRegistry()
+	if path, _ := r.Resolve(APIVersionV5, OpValidateInsurance); path != "/V5/Integration/ValidateInsurance" {
+		t.Errorf("unexpected path %s", path)
+	}
+	if path, _ := r.Resolve(APIVersionV5, OpLogin); path != "/V1/Account/Login" {
+		t.Errorf("expected login to be unversioned, got %s", path)
+	}
+
+	r.RegisterTemplate(OpGetCertificate, "/{version}/Certificates/Get")
+	r.Register(APIVersionV5, OpGetCertificate, "/V5/Certificates/Lookup")
+	if path, _ := r.Resolve(APIVersionV4, OpGetCertificate); path != "/V4/Certificates/Get" {
+		t.Errorf("expected the template, got %s", path)
+	}
+	if path, _ := r.Resolve(APIVersionV5, OpGetCertificate); path != "/V5/Certificates/Lookup" {
+		t.Errorf("expected the version path, got %s", path)
+	}
+	if _, err := r.Resolve(APIVersionV4, "Unknown"); err == nil {
+		t.Error("expected an error for an unknown operation")
+	}
+}
+
+func TestClientUsesResolvedEndpoints(t *testing.T) {
+	var paths []string
+	ts := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		writeJSON(w, CertificateResponse{Success: true})
+	})
+	registry := NewEndpointRegistry()
+	registry.RegisterTemplate(OpGetCertificate, "/{version}/Certificates/Get")
+	c := newTestClient(t, ts, func(config *Config) {
+		config.APIVersion = APIVersionV5
+		config.Endpoints = registry
+	})
+
+	if _, err := c.GetCertificate("C123"); err != nil {
+		t.Fatalf("GetCertificate: %v", err)
+	}
+	if _, err := c.ValidateInsurance(&InsuranceValidationRequest{CertificateNumber: "C123"}); err != nil {
+		t.Fatalf("ValidateInsurance: %v", err)
+	}
+	if len(paths) != 2 || paths[0] != "/V5/Certificates/Get" || paths[1] != "/V5/Integration/ValidateInsurance" {
+		t.Errorf("unexpected paths %v", paths)
+	}
+}
@@ -1,6 +1,9 @@
 package dmvic
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // Package dmvic provides error types, error codes, and error helpers for DMVIC client operations.
 
@@ -25,16 +28,21 @@ const (
 	ErrReadResponse      = 1005 // Failed to read HTTP response body
 	ErrParseTime         = 1006 // Failed to parse time/date string
 	ErrUnmarshalResponse = 1007 // Failed to unmarshal JSON response
+	ErrClientClosed      = 1008 // Client was closed
+	ErrResponseSchema    = 1009 // Response does not match the expected schema
+	ErrEndpointDisabled  = 1010 // Endpoint is switched off in the endpoint catalog
 
 	// Authentication errors (2000-2099)
-	ErrLoginFailed        = 2001 // Login operation failed
-	ErrTokenExpired       = 2002 // Authentication token has expired
-	ErrUnauthorized       = 2003 // Unauthorized access attempt
-	ErrInvalidCredentials = 2004 // Invalid username or password
-	ErrTokenRefresh       = 2005 // Token refresh operation failed
-
-	// API operation errors (3000-8999)
+	ErrLoginFailed         = 2001 // Login operation failed
+	ErrTokenExpired        = 2002 // Authentication token has expired
+	ErrUnauthorized        = 2003 // Unauthorized access attempt
+	ErrInvalidCredentials  = 2004 // Invalid username or password
+	ErrTokenRefresh        = 2005 // Token refresh operation failed
+	ErrCredentialsProvider = 2006 // Credentials provider failed to return credentials
+
+	// API operation errors (3000-9999)
 	ErrGetCertificate          = 3000 // Certificate retrieval operation failed
+	ErrGetCertificateByVehicle = 3100 // Certificate lookup by vehicle failed
 	ErrValidateInsurance       = 4000 // Insurance validation operation failed
 	ErrCancelCertificate       = 5000 // Certificate cancellation operation failed
 	ErrMemberCompanyStock      = 6000 // Member company stock retrieval failed
@@ -43,7 +51,13 @@ const (
 	ErrIssuanceTypeC           = 7200 // Type C certificate issuance failed
 	ErrIssuanceTypeD           = 7300 // Type D certificate issuance failed
 	ErrConfirmIssuance         = 7400 // Certificate issuance confirmation failed
+	ErrExtendCertificate       = 7500 // Certificate change of period failed
+	ErrEndorseCertificate      = 7600 // Certificate endorsement failed
 	ErrValidateDoubleInsurance = 8000 // Double insurance validation failed
+	ErrCreateSubUser           = 9000 // Sub-user creation failed
+	ErrResetSubUserCredentials = 9100 // Sub-user credentials reset failed
+	ErrListEntitlements        = 9200 // Entitlements listing failed
+	ErrDo                      = 9900 // Call of an endpoint not wrapped by the library failed
 )
 
 // API-specific error codes from DMVIC responses.
@@ -67,18 +81,31 @@ type ClientError struct {
 	Operation  string    `json:"operation,omitempty"`   // Operation that caused the error
 	DMVICCode  string    `json:"dmvic_code,omitempty"`  // DMVIC-specific error code
 	HTTPStatus int       `json:"http_status,omitempty"` // HTTP status code if applicable
+
+	// APIRequestNumber is the request number DMVIC assigned to the call, if the response
+	// carried one. DMVIC support asks for it when a failure is escalated.
+	APIRequestNumber string `json:"api_request_number,omitempty"`
+
+	// Errors holds the full list of errors returned by DMVIC when a response reports Success=false.
+	Errors FlexibleDmvicError `json:"errors,omitempty"`
 }
 
 // Error returns a formatted string representation of the ClientError.
 // It implements the error interface and provides context-aware error messages.
 func (e *ClientError) Error() string {
-	if e.Operation != "" {
-		if e.DMVICCode != "" {
-			return fmt.Sprintf("dmvic %s error %d (%s): %s", e.Operation, e.Code, e.DMVICCode, e.Message)
-		}
-		return fmt.Sprintf("dmvic %s error %d: %s", e.Operation, e.Code, e.Message)
+	var msg string
+	switch {
+	case e.Operation != "" && e.DMVICCode != "":
+		msg = fmt.Sprintf("dmvic %s error %d (%s): %s", e.Operation, e.Code, e.DMVICCode, e.Message)
+	case e.Operation != "":
+		msg = fmt.Sprintf("dmvic %s error %d: %s", e.Operation, e.Code, e.Message)
+	default:
+		msg = fmt.Sprintf("dmvic error %d: %s", e.Code, e.Message)
+	}
+	if e.APIRequestNumber != "" {
+		msg += fmt.Sprintf(" [apiRequestNumber %s]", e.APIRequestNumber)
 	}
-	return fmt.Sprintf("dmvic error %d: %s", e.Code, e.Message)
+	return msg
 }
 
 // IsInsufficientInventory checks if the error is due to insufficient inventory/stock.
@@ -99,6 +126,19 @@ func (e *ClientError) IsDataValidationError() bool {
 	return e.DMVICCode == DMVICErrDataValidation
 }
 
+// HasDMVICCode reports whether any of the errors returned by DMVIC carries the given code.
+func (e *ClientError) HasDMVICCode(code string) bool {
+	if e.DMVICCode == code {
+		return true
+	}
+	for _, dmvicErr := range e.Errors {
+		if dmvicErr.ErrorCode == code {
+			return true
+		}
+	}
+	return false
+}
+
 // Helper functions for creating different types of errors
 
 // newInternalError creates a new ClientError for internal/client-side errors.
@@ -147,3 +187,38 @@ func newDMVICError(op string, code int, dmvicCode, message string) *ClientError
 		DMVICCode: dmvicCode,
 	}
 }
+
+// newDMVICResponseError creates a ClientError for a response that reported Success=false.
+// The first DMVIC error provides the error code, all error texts are joined into the message,
+// or the error codes when DMVIC sent no texts, and the complete list is attached to the error.
+// Parameters:
+//   - op: The operation that caused the error
+//   - code: The client error code
+//   - errs: The errors returned by DMVIC, possibly empty
+func newDMVICResponseError(op string, code int, errs FlexibleDmvicError) *ClientError {
+	texts := make([]string, 0, len(errs))
+	codes := make([]string, 0, len(errs))
+	for _, dmvicErr := range errs {
+		if dmvicErr.ErrorText != "" {
+			texts = append(texts, dmvicErr.ErrorText)
+		}
+		if dmvicErr.ErrorCode != "" {
+			codes = append(codes, dmvicErr.ErrorCode)
+		}
+	}
+	if len(texts) == 0 {
+		texts = codes
+	}
+	if len(texts) == 0 {
+		return &ClientError{
+			Type:      ExternalError,
+			Code:      code,
+			Message:   "request was not successful and DMVIC returned no error details",
+			Operation: op,
+			Errors:    errs,
+		}
+	}
+	clientErr := newDMVICError(op, code, parseDMVICErrorCode(errs[0].ErrorCode), strings.Join(texts, "; "))
+	clientErr.Errors = errs
+	return clientErr
+}
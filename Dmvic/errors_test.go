@@ -0,0 +1,23 @@
+package dmvic
+
+import "testing"
+
+func TestNewDMVICResponseError(t *testing.T) {
+	cases := []struct {
+		name      string
+		errs      FlexibleDmvicError
+		message   string
+		dmvicCode string
+	}{
+		{"texts", FlexibleDmvicError{{ErrorCode: "ER004", ErrorText: "Input not valid"}, {ErrorCode: "ER003", ErrorText: "Mandatory field is missing"}}, "Input not valid; Mandatory field is missing", DMVICErrInvalidInput},
+		{"codes only", FlexibleDmvicError{{ErrorCode: "ER006"}, {ErrorCode: "ER007"}}, "ER006; ER007", DMVICErrInsufficientStock},
+		{"no details", nil, "request was not successful and DMVIC returned no error details", ""},
+		{"empty errors", FlexibleDmvicError{{}}, "request was not successful and DMVIC returned no error details", ""},
+	}
+	for _, c := range cases {
+		err := newDMVICResponseError(string(OpIssueTypeA), ErrIssuanceTypeA, c.errs)
+		if err.Message != c.message || err.DMVICCode != c.dmvicCode || err.Code != ErrIssuanceTypeA || len(err.Errors) != len(c.errs) {
+			t.Errorf("%s: unexpected error %+v", c.name, err)
+		}
+	}
+}
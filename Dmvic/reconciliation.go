@@ -0,0 +1,171 @@
+package dmvic
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// IssuanceRecordKind distinguishes locally recorded issuances from cancellations.
+type IssuanceRecordKind string
+
+const (
+	// IssuanceRecordIssued marks a certificate issued through our systems
+	IssuanceRecordIssued IssuanceRecordKind = "issued"
+	// IssuanceRecordCancelled marks a certificate cancelled through our systems
+	IssuanceRecordCancelled IssuanceRecordKind = "cancelled"
+)
+
+// IssuanceRecord is a locally recorded certificate issuance or cancellation.
+type IssuanceRecord struct {
+	CertificateNumber           string             // Certificate number returned by DMVIC
+	CertificateClassificationID int                // Stock classification the certificate was drawn from
+	Kind                        IssuanceRecordKind // Issuance or cancellation
+	RecordedAt                  time.Time          // When the operation was recorded locally
+}
+
+// IssuanceRecordSource provides the issuances and cancellations we recorded for a period.
+// It is implemented by the application's persistence layer.
+type IssuanceRecordSource interface {
+	ListIssuanceRecords(ctx context.Context, memberCompanyID int, from, to time.Time) ([]IssuanceRecord, error)
+}
+
+// StockSnapshot captures the member company stock at a point in time.
+type StockSnapshot struct {
+	MemberCompanyID int            // Member company the stock belongs to
+	TakenAt         time.Time      // When the snapshot was taken
+	Stock           []StockDetails // Stock per certificate classification
+}
+
+// StockReconciliationOptions tunes how recorded operations translate into expected stock movement.
+type StockReconciliationOptions struct {
+	// CancellationsRestoreStock indicates that a cancelled certificate is returned to stock.
+	CancellationsRestoreStock bool
+}
+
+// StockReconciliationLine compares the stock movement of one certificate classification
+// with the operations we recorded for it.
+type StockReconciliationLine struct {
+	CertificateClassificationID int    // Certificate classification identifier
+	ClassificationTitle         string // Certificate classification title
+	StockBefore                 int    // Stock in the opening snapshot
+	StockAfter                  int    // Stock in the closing snapshot
+	Consumed                    int    // StockBefore - StockAfter
+	RecordedIssuances           int    // Issuances recorded locally during the period
+	RecordedCancellations       int    // Cancellations recorded locally during the period
+	ExpectedConsumption         int    // Consumption explained by the recorded operations
+	Unexplained                 int    // Consumed - ExpectedConsumption
+	Flagged                     bool   // True when stock was consumed without a matching record
+}
+
+// StockReconciliationReport is the result of reconciling stock snapshots against issuance records.
+type StockReconciliationReport struct {
+	MemberCompanyID  int                       // Member company reconciled
+	From             time.Time                 // Opening snapshot time
+	To               time.Time                 // Closing snapshot time
+	Lines            []StockReconciliationLine // One line per certificate classification
+	UnmatchedRecords []IssuanceRecord          // Records for classifications missing from both snapshots
+	HasDiscrepancy   bool                      // True when at least one line is flagged
+}
+
+// TakeStockSnapshot fetches the current member company stock and records when it was taken.
+func TakeStockSnapshot(c Client, memberCompanyID int) (*StockSnapshot, error) {
+	resp, err := c.GetMemberCompanyStock(memberCompanyID)
+	if err != nil {
+		return nil, err
+	}
+	return &StockSnapshot{
+		MemberCompanyID: memberCompanyID,
+		TakenAt:         time.Now(),
+		Stock:           resp.CallbackObj.MemberCompanyStock,
+	}, nil
+}
+
+// ReconcileStockFromSource loads the records for the period between the two snapshots from source
+// and reconciles them against the stock movement.
+func ReconcileStockFromSource(ctx context.Context, source IssuanceRecordSource, before, after *StockSnapshot, opts StockReconciliationOptions) (*StockReconciliationReport, error) {
+	if before == nil || after == nil {
+		return nil, fmt.Errorf("both stock snapshots are required")
+	}
+	records, err := source.ListIssuanceRecords(ctx, before.MemberCompanyID, before.TakenAt, after.TakenAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load issuance records: %w", err)
+	}
+	return ReconcileStock(before, after, records, opts)
+}
+
+// ReconcileStock compares the stock consumed between two snapshots with the issuances and
+// cancellations recorded in between. Classifications where more stock was consumed than our
+// records explain are flagged, as they may indicate duplicate or rogue issuances.
+// A negative Unexplained value usually means stock was replenished and is not flagged.
+func ReconcileStock(before, after *StockSnapshot, records []IssuanceRecord, opts StockReconciliationOptions) (*StockReconciliationReport, error) {
+	if before == nil || after == nil {
+		return nil, fmt.Errorf("both stock snapshots are required")
+	}
+	if before.MemberCompanyID != after.MemberCompanyID {
+		return nil, fmt.Errorf("snapshots belong to different member companies: %d and %d", before.MemberCompanyID, after.MemberCompanyID)
+	}
+	if after.TakenAt.Before(before.TakenAt) {
+		return nil, fmt.Errorf("closing snapshot was taken before the opening snapshot")
+	}
+
+	lines := make(map[int]*StockReconciliationLine)
+	lineFor := func(id int, title string) *StockReconciliationLine {
+		line, ok := lines[id]
+		if !ok {
+			line = &StockReconciliationLine{CertificateClassificationID: id}
+			lines[id] = line
+		}
+		if line.ClassificationTitle == "" {
+			line.ClassificationTitle = title
+		}
+		return line
+	}
+	for _, s := range before.Stock {
+		lineFor(s.CertificateClassificationID, s.ClassificationTitle).StockBefore = s.Stock
+	}
+	for _, s := range after.Stock {
+		lineFor(s.CertificateClassificationID, s.ClassificationTitle).StockAfter = s.Stock
+	}
+
+	report := &StockReconciliationReport{
+		MemberCompanyID: before.MemberCompanyID,
+		From:            before.TakenAt,
+		To:              after.TakenAt,
+	}
+	for _, rec := range records {
+		if rec.RecordedAt.Before(before.TakenAt) || rec.RecordedAt.After(after.TakenAt) {
+			continue
+		}
+		line, ok := lines[rec.CertificateClassificationID]
+		if !ok {
+			report.UnmatchedRecords = append(report.UnmatchedRecords, rec)
+			continue
+		}
+		switch rec.Kind {
+		case IssuanceRecordIssued:
+			line.RecordedIssuances++
+		case IssuanceRecordCancelled:
+			line.RecordedCancellations++
+		}
+	}
+
+	for _, line := range lines {
+		line.Consumed = line.StockBefore - line.StockAfter
+		line.ExpectedConsumption = line.RecordedIssuances
+		if opts.CancellationsRestoreStock {
+			line.ExpectedConsumption -= line.RecordedCancellations
+		}
+		line.Unexplained = line.Consumed - line.ExpectedConsumption
+		line.Flagged = line.Unexplained > 0
+		if line.Flagged {
+			report.HasDiscrepancy = true
+		}
+		report.Lines = append(report.Lines, *line)
+	}
+	sort.Slice(report.Lines, func(i, j int) bool {
+		return report.Lines[i].CertificateClassificationID < report.Lines[j].CertificateClassificationID
+	})
+	return report, nil
+}
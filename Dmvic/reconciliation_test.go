@@ -0,0 +1,84 @@
+package dmvic
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type staticIssuanceRecords []IssuanceRecord
+
+func (s staticIssuanceRecords) ListIssuanceRecords(ctx context.Context, memberCompanyID int, from, to time.Time) ([]IssuanceRecord, error) {
+	return s, nil
+}
+
+func TestTakeStockSnapshot(t *testing.T) {
+	var query string
+	ts := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		writeJSON(w, StockResponse{Success: true, CallbackObj: StockCallbackObj{MemberCompanyStock: []StockDetails{
+			{CertificateClassificationID: 1, ClassificationTitle: "Class A", Stock: 40},
+		}}})
+	})
+	c := newTestClient(t, ts, nil)
+
+	snap, err := TakeStockSnapshot(c, 17)
+	if err != nil {
+		t.Fatalf("TakeStockSnapshot: %v", err)
+	}
+	if query != "MemberCompanyId=17" || snap.MemberCompanyID != 17 || len(snap.Stock) != 1 || snap.Stock[0].Stock != 40 || snap.TakenAt.IsZero() {
+		t.Errorf("unexpected snapshot %+v for query %q", snap, query)
+	}
+}
+
+func TestReconcileStock(t *testing.T) {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	before := &StockSnapshot{MemberCompanyID: 17, TakenAt: start, Stock: []StockDetails{
+		{CertificateClassificationID: 1, ClassificationTitle: "Class A", Stock: 40},
+		{CertificateClassificationID: 2, ClassificationTitle: "Class B", Stock: 10},
+	}}
+	after := &StockSnapshot{MemberCompanyID: 17, TakenAt: start.Add(24 * time.Hour), Stock: []StockDetails{
+		{CertificateClassificationID: 1, ClassificationTitle: "Class A", Stock: 36},
+		{CertificateClassificationID: 2, ClassificationTitle: "Class B", Stock: 9},
+	}}
+	at := start.Add(time.Hour)
+	records := staticIssuanceRecords{
+		{CertificateNumber: "A1", CertificateClassificationID: 1, Kind: IssuanceRecordIssued, RecordedAt: at},
+		{CertificateNumber: "A2", CertificateClassificationID: 1, Kind: IssuanceRecordIssued, RecordedAt: at},
+		{CertificateNumber: "A2", CertificateClassificationID: 1, Kind: IssuanceRecordCancelled, RecordedAt: at},
+		{CertificateNumber: "B1", CertificateClassificationID: 2, Kind: IssuanceRecordIssued, RecordedAt: at},
+		{CertificateNumber: "B0", CertificateClassificationID: 2, Kind: IssuanceRecordIssued, RecordedAt: start.Add(-time.Hour)},
+		{CertificateNumber: "C1", CertificateClassificationID: 3, Kind: IssuanceRecordIssued, RecordedAt: at},
+	}
+
+	report, err := ReconcileStockFromSource(context.Background(), records, before, after, StockReconciliationOptions{})
+	if err != nil {
+		t.Fatalf("ReconcileStock: %v", err)
+	}
+	if len(report.Lines) != 2 || len(report.UnmatchedRecords) != 1 || !report.HasDiscrepancy {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	classA, classB := report.Lines[0], report.Lines[1]
+	if classA.Consumed != 4 || classA.ExpectedConsumption != 2 || classA.Unexplained != 2 || !classA.Flagged {
+		t.Errorf("class A: unexpected line %+v", classA)
+	}
+	// the issuance recorded before the opening snapshot is out of the period
+	if classB.Consumed != 1 || classB.RecordedIssuances != 1 || classB.Flagged {
+		t.Errorf("class B: unexpected line %+v", classB)
+	}
+
+	report, _ = ReconcileStock(before, after, records, StockReconciliationOptions{CancellationsRestoreStock: true})
+	if line := report.Lines[0]; line.ExpectedConsumption != 1 || line.Unexplained != 3 {
+		t.Errorf("restoring cancellations: unexpected line %+v", line)
+	}
+
+	if _, err := ReconcileStock(after, before, nil, StockReconciliationOptions{}); err == nil {
+		t.Error("expected snapshots out of order to fail")
+	}
+	other := *after
+	other.MemberCompanyID = 18
+	if _, err := ReconcileStock(before, &other, nil, StockReconciliationOptions{}); err == nil {
+		t.Error("expected snapshots of different member companies to fail")
+	}
+}
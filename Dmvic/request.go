@@ -0,0 +1,82 @@
+package dmvic
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSafeRetryAttempts = 2
+	defaultSafeRetryBackoff  = 200 * time.Millisecond
+)
+
+// apiRequest describes a DMVIC API request: the endpoint path with optional {name}
+// placeholders, the query string and the JSON body. Values are escaped when the
+// request URL is built, so callers never format query strings themselves.
+type apiRequest struct {
+	op         Operation
+	method     string
+	path       string
+	pathParams map[string]string
+	query      url.Values
+	body       interface{}
+}
+
+// newAPIRequest creates a request for op against path.
+func newAPIRequest(op Operation, method, path string) *apiRequest {
+	return &apiRequest{op: op, method: method, path: path}
+}
+
+// PathParam replaces the {name} placeholder of the path with the escaped value.
+func (r *apiRequest) PathParam(name, value string) *apiRequest {
+	if r.pathParams == nil {
+		r.pathParams = make(map[string]string)
+	}
+	r.pathParams[name] = value
+	return r
+}
+
+// Query adds a query string parameter.
+func (r *apiRequest) Query(key, value string) *apiRequest {
+	if r.query == nil {
+		r.query = url.Values{}
+	}
+	r.query.Add(key, value)
+	return r
+}
+
+// Body sets the request payload, which is JSON marshaled when the request is sent.
+func (r *apiRequest) Body(body interface{}) *apiRequest {
+	r.body = body
+	return r
+}
+
+// Endpoint returns the escaped path and query string of the request.
+func (r *apiRequest) Endpoint() string {
+	path := r.path
+	for name, value := range r.pathParams {
+		path = strings.ReplaceAll(path, "{"+name+"}", url.PathEscape(value))
+	}
+	if len(r.query) == 0 {
+		return path
+	}
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + r.query.Encode()
+}
+
+// RetrySafe reports whether the request can be repeated without side effects.
+// DMVIC only uses GET for lookups, so those are retried on transport errors and
+// server errors, while POSTs such as issuance are never retried blindly.
+func (r *apiRequest) RetrySafe() bool {
+	return r.method == http.MethodGet || r.method == http.MethodHead
+}
+
+// retryableStatus reports whether a response status is worth retrying for a retry-safe request.
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
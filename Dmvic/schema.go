@@ -0,0 +1,271 @@
+package dmvic
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ResponseValidationMode controls how DMVIC responses are checked against the
+// response types before they are used.
+type ResponseValidationMode string
+
+const (
+	// ResponseValidationOff disables response validation (default).
+	ResponseValidationOff ResponseValidationMode = ""
+	// ResponseValidationWarn logs schema issues but accepts the response.
+	ResponseValidationWarn ResponseValidationMode = "warn"
+	// ResponseValidationStrict fails the call when the response has schema issues.
+	ResponseValidationStrict ResponseValidationMode = "strict"
+)
+
+// SchemaIssueKind classifies a schema issue.
+type SchemaIssueKind string
+
+const (
+	SchemaMissingField SchemaIssueKind = "missing"       // A mandatory field is absent or null
+	SchemaTypeMismatch SchemaIssueKind = "type_mismatch" // A field has an unexpected JSON type
+)
+
+// SchemaIssue describes a single difference between a response and its expected schema.
+type SchemaIssue struct {
+	Path     string          // JSON path of the offending field, e.g. "callbackObj.MemberCompanyStock[0].Stock"
+	Kind     SchemaIssueKind // Kind of issue
+	Expected string          // Expected JSON type
+	Actual   string          // Actual JSON type, empty for missing fields
+}
+
+func (i SchemaIssue) String() string {
+	if i.Kind == SchemaMissingField {
+		return fmt.Sprintf("%s: missing mandatory %s", i.Path, i.Expected)
+	}
+	return fmt.Sprintf("%s: expected %s, got %s", i.Path, i.Expected, i.Actual)
+}
+
+// SchemaDiagnostics lists the schema issues found in a response.
+type SchemaDiagnostics []SchemaIssue
+
+func (d SchemaDiagnostics) Error() string {
+	parts := make([]string, len(d))
+	for i, issue := range d {
+		parts[i] = issue.String()
+	}
+	return "response schema mismatch: " + strings.Join(parts, "; ")
+}
+
+// ValidateResponseSchema compares a raw DMVIC response body with the Go type of target
+// and reports mandatory fields that are missing and fields whose JSON type does not match,
+// instead of letting them silently decode to zero values.
+//
+// Fields tagged `dmvic:"required"` must always be present; fields tagged `dmvic:"success"`
+// must be present when the response reports success. Field names are matched the same way
+// encoding/json does, preferring an exact match over a case-insensitive one.
+func ValidateResponseSchema(body []byte, target interface{}) SchemaDiagnostics {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return SchemaDiagnostics{{Path: "$", Kind: SchemaTypeMismatch, Expected: "JSON document", Actual: "invalid JSON"}}
+	}
+	t := reflect.TypeOf(target)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return nil
+	}
+	success := false
+	if obj, ok := doc.(map[string]interface{}); ok {
+		if v, ok := lookupField(obj, "success"); ok {
+			success, _ = v.(bool)
+		}
+	}
+	v := &schemaValidator{success: success}
+	v.check("$", t, doc)
+	return v.issues
+}
+
+type schemaValidator struct {
+	success bool
+	issues  SchemaDiagnostics
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func (v *schemaValidator) check(path string, t reflect.Type, value interface{}) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if value == nil {
+		return
+	}
+	// types with custom decoding accept their own shapes
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Interface:
+		return
+	case reflect.String:
+		v.expect(path, "string", value, isJSONString)
+	case reflect.Bool:
+		v.expect(path, "boolean", value, isJSONBool)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.expect(path, "integer", value, isJSONInteger)
+	case reflect.Float32, reflect.Float64:
+		v.expect(path, "number", value, isJSONNumber)
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]interface{})
+		if !ok {
+			v.mismatch(path, "array", value)
+			return
+		}
+		for i, item := range items {
+			v.check(fmt.Sprintf("%s[%d]", path, i), t.Elem(), item)
+		}
+	case reflect.Map:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			v.mismatch(path, "object", value)
+			return
+		}
+		for key, item := range obj {
+			v.check(joinPath(path, key), t.Elem(), item)
+		}
+	case reflect.Struct:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			v.mismatch(path, "object", value)
+			return
+		}
+		v.checkStruct(path, t, obj)
+	}
+}
+
+func (v *schemaValidator) checkStruct(path string, t reflect.Type, obj map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Name
+		if tag := f.Tag.Get("json"); tag != "" {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+		fieldPath := joinPath(path, name)
+		value, present := lookupField(obj, name)
+		if !present || value == nil {
+			if v.isMandatory(f) {
+				v.issues = append(v.issues, SchemaIssue{Path: fieldPath, Kind: SchemaMissingField, Expected: jsonTypeName(f.Type)})
+			}
+			continue
+		}
+		v.check(fieldPath, f.Type, value)
+	}
+}
+
+func (v *schemaValidator) isMandatory(f reflect.StructField) bool {
+	switch f.Tag.Get("dmvic") {
+	case "required":
+		return true
+	case "success":
+		return v.success
+	}
+	return false
+}
+
+func (v *schemaValidator) expect(path, expected string, value interface{}, ok func(interface{}) bool) {
+	if !ok(value) {
+		v.mismatch(path, expected, value)
+	}
+}
+
+func (v *schemaValidator) mismatch(path, expected string, value interface{}) {
+	v.issues = append(v.issues, SchemaIssue{Path: path, Kind: SchemaTypeMismatch, Expected: expected, Actual: describeJSON(value)})
+}
+
+// lookupField finds a key the way encoding/json does: exact match first, then case-insensitive.
+func lookupField(obj map[string]interface{}, name string) (interface{}, bool) {
+	if value, ok := obj[name]; ok {
+		return value, true
+	}
+	for key, value := range obj {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+func joinPath(path, name string) string {
+	if path == "$" {
+		return name
+	}
+	return path + "." + name
+}
+
+func isJSONString(v interface{}) bool { _, ok := v.(string); return ok }
+func isJSONBool(v interface{}) bool   { _, ok := v.(bool); return ok }
+func isJSONNumber(v interface{}) bool { _, ok := v.(json.Number); return ok }
+func isJSONInteger(v interface{}) bool {
+	n, ok := v.(json.Number)
+	if !ok {
+		return false
+	}
+	_, err := n.Int64()
+	return err == nil
+}
+
+func describeJSON(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := val.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "null"
+}
+
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+	return "value"
+}
@@ -0,0 +1,59 @@
+package dmvic
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestValidateResponseSchema(t *testing.T) {
+	body := []byte(`{"success":true,"callbackObj":{"MemberCompanyStock":[{"CertificateClassificationID":1,"Stock":"40"}]}}`)
+	diags := ValidateResponseSchema(body, &StockResponse{})
+	if len(diags) != 1 || diags[0].Path != "callbackObj.MemberCompanyStock[0].Stock" || diags[0].Kind != SchemaTypeMismatch {
+		t.Errorf("expected a type mismatch of the stock, got %v", diags)
+	}
+
+	diags = ValidateResponseSchema([]byte(`{"success":true}`), &StockResponse{})
+	if len(diags) != 1 || diags[0].Path != "callbackObj" || diags[0].Kind != SchemaMissingField {
+		t.Errorf("expected the callback object to be required on success, got %v", diags)
+	}
+	// a failed response carries no callback object
+	if diags := ValidateResponseSchema([]byte(`{"success":false,"error":[]}`), &StockResponse{}); len(diags) != 0 {
+		t.Errorf("expected no issues, got %v", diags)
+	}
+	if diags := ValidateResponseSchema([]byte(`{"apiRequestNumber":"UAT-1"}`), &StockResponse{}); len(diags) != 1 || diags[0].Path != "success" {
+		t.Errorf("expected success to be required, got %v", diags)
+	}
+}
+
+func TestResponseValidationModes(t *testing.T) {
+	ts := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success":true,"apiRequestNumber":"UAT-1"}`))
+	})
+
+	strict := newTestClient(t, ts, func(config *Config) { config.ResponseValidation = ResponseValidationStrict })
+	_, err := strict.GetCertificate("C123")
+	var clientErr *ClientError
+	if !errors.As(err, &clientErr) || clientErr.Code != ErrResponseSchema {
+		t.Errorf("strict: expected a schema error, got %v", err)
+	}
+
+	warn := newTestClient(t, ts, func(config *Config) { config.ResponseValidation = ResponseValidationWarn })
+	if _, err := warn.GetCertificate("C123"); err != nil {
+		t.Errorf("warn: expected the response to be accepted, got %v", err)
+	}
+
+	_, err = NewClient(&Config{
+		Credentials:        Credentials{Username: "user", Password: "secret"},
+		ClientID:           "client-id",
+		Environment:        UAT,
+		AuthCertPath:       "unused.crt",
+		AuthKeyPath:        "unused.key",
+		AuthCaCertPath:     "unused-ca.crt",
+		ResponseValidation: "loud",
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid ResponseValidation") {
+		t.Errorf("expected an unknown validation mode to be rejected, got %v", err)
+	}
+}
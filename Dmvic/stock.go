@@ -0,0 +1,99 @@
+package dmvic
+
+import "strings"
+
+// StockQueryOptions filters and pages the stock returned by GetMemberCompanyStock.
+// Zero values disable the corresponding filter.
+type StockQueryOptions struct {
+	CertificateTypeIDs  []int  // Only include these certificate types
+	ClassificationIDs   []int  // Only include these certificate classifications
+	ClassificationTitle string // Case-insensitive substring of the classification title
+	Page                int    // 1-based page number, 0 returns all matching items
+	PageSize            int    // Items per page, defaults to 20 when Page is set
+}
+
+// StockPage is a filtered page of member company stock.
+type StockPage struct {
+	Items      []StockDetails // Stock details on this page
+	Page       int            // Current page, 0 when paging is disabled
+	PageSize   int            // Items per page
+	TotalItems int            // Number of items matching the filters
+	TotalPages int            // Number of pages available
+}
+
+const defaultStockPageSize = 20
+
+// Matches reports whether the stock item passes the filters in opts.
+func (opts StockQueryOptions) Matches(s StockDetails) bool {
+	if len(opts.CertificateTypeIDs) > 0 && !containsInt(opts.CertificateTypeIDs, s.CertificateTypeID) {
+		return false
+	}
+	if len(opts.ClassificationIDs) > 0 && !containsInt(opts.ClassificationIDs, s.CertificateClassificationID) {
+		return false
+	}
+	if opts.ClassificationTitle != "" &&
+		!strings.Contains(strings.ToLower(s.ClassificationTitle), strings.ToLower(opts.ClassificationTitle)) {
+		return false
+	}
+	return true
+}
+
+// Query applies the filters and paging in opts to the stock in the response.
+func (r *StockResponse) Query(opts StockQueryOptions) StockPage {
+	var matched []StockDetails
+	for _, s := range r.CallbackObj.MemberCompanyStock {
+		if opts.Matches(s) {
+			matched = append(matched, s)
+		}
+	}
+
+	page := StockPage{Items: matched, TotalItems: len(matched), TotalPages: 1, PageSize: len(matched)}
+	if opts.Page <= 0 {
+		return page
+	}
+	size := opts.PageSize
+	if size <= 0 {
+		size = defaultStockPageSize
+	}
+	page.Page = opts.Page
+	page.PageSize = size
+	page.TotalPages = (len(matched) + size - 1) / size
+	start := (opts.Page - 1) * size
+	if start >= len(matched) {
+		page.Items = nil
+		return page
+	}
+	end := start + size
+	if end > len(matched) {
+		end = len(matched)
+	}
+	page.Items = matched[start:end]
+	return page
+}
+
+// TotalByType sums the available stock per certificate type.
+func (r *StockResponse) TotalByType() map[int]int {
+	return totalStockByType(r.CallbackObj.MemberCompanyStock)
+}
+
+// TotalByType sums the available stock per certificate type for the items on the page.
+func (p StockPage) TotalByType() map[int]int {
+	return totalStockByType(p.Items)
+}
+
+func totalStockByType(items []StockDetails) map[int]int {
+	totals := make(map[int]int)
+	for _, s := range items {
+		totals[s.CertificateTypeID] += s.Stock
+	}
+	return totals
+}
+
+func containsInt(values []int, v int) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package dmvic
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestGetMemberCompanyStockPage(t *testing.T) {
+	stock := []StockDetails{
+		{CertificateClassificationID: 1, ClassificationTitle: "Class A - PSV", Stock: 40, CertificateTypeID: 1},
+		{CertificateClassificationID: 2, ClassificationTitle: "Class B - Commercial", Stock: 10, CertificateTypeID: 2},
+		{CertificateClassificationID: 3, ClassificationTitle: "Class C - Private", Stock: 25, CertificateTypeID: 2},
+		{CertificateClassificationID: 4, ClassificationTitle: "Class D - Motorcycle", Stock: 5, CertificateTypeID: 4},
+	}
+	var requests int
+	ts := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Method != http.MethodGet || r.URL.Query().Get("MemberCompanyId") != "17" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		writeJSON(w, StockResponse{Success: true, CallbackObj: StockCallbackObj{MemberCompanyStock: stock}})
+	})
+	c := newTestClient(t, ts, nil)
+
+	page, err := c.GetMemberCompanyStockPage(17, StockQueryOptions{CertificateTypeIDs: []int{2}})
+	if err != nil {
+		t.Fatalf("GetMemberCompanyStockPage: %v", err)
+	}
+	if page.TotalItems != 2 || page.Page != 0 || page.TotalByType()[2] != 35 {
+		t.Errorf("type filter: unexpected page %+v", page)
+	}
+
+	page, _ = c.GetMemberCompanyStockPage(17, StockQueryOptions{ClassificationTitle: "class", Page: 2, PageSize: 3})
+	if page.TotalItems != 4 || page.TotalPages != 2 || len(page.Items) != 1 || page.Items[0].CertificateClassificationID != 4 {
+		t.Errorf("paging: unexpected page %+v", page)
+	}
+	page, _ = c.GetMemberCompanyStockPage(17, StockQueryOptions{ClassificationIDs: []int{1, 3}, ClassificationTitle: "PRIVATE", Page: 3})
+	if page.TotalItems != 1 || page.PageSize != defaultStockPageSize || page.Items != nil {
+		t.Errorf("page past the end: unexpected page %+v", page)
+	}
+	if requests != 3 {
+		t.Errorf("expected a request per page, got %d", requests)
+	}
+
+	resp := &StockResponse{CallbackObj: StockCallbackObj{MemberCompanyStock: stock}}
+	if totals := resp.TotalByType(); totals[1] != 40 || totals[2] != 35 || totals[4] != 5 {
+		t.Errorf("unexpected totals %v", totals)
+	}
+}
@@ -0,0 +1,73 @@
+package dmvic
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// MutualTLSConfig builds the TLS configuration used for DMVIC API calls.
+// It loads the client certificate and key and, unless DisableCustomRootCAs is set,
+// adds the CA bundle at AuthCaCertPath to the system root CAs used to verify the server.
+func (c *Config) MutualTLSConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(c.AuthCertPath, c.AuthKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cert/key: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates:       []tls.Certificate{cert},
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	if c.DisableCustomRootCAs {
+		return tlsConfig, nil
+	}
+
+	caCert, err := os.ReadFile(c.AuthCaCertPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load CA cert: %w", err)
+	}
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil || rootCAs == nil {
+		rootCAs = x509.NewCertPool()
+	}
+	if !rootCAs.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("no certificates found in CA file %s", c.AuthCaCertPath)
+	}
+	tlsConfig.RootCAs = rootCAs
+	return tlsConfig, nil
+}
+
+// secureClientCache holds the lazily built mutual TLS client.
+type secureClientCache struct {
+	mu     sync.Mutex
+	client *http.Client
+}
+
+// secureHTTPClient returns the mutual TLS HTTP client, building it on first use.
+// The certificates are parsed once and the client is shared by all requests so
+// connections are reused. A failed build is retried on the next call.
+func (c *client) secureHTTPClient() (*http.Client, error) {
+	c.tls.mu.Lock()
+	defer c.tls.mu.Unlock()
+
+	if c.tls.client != nil {
+		return c.tls.client, nil
+	}
+	tlsConfig, err := c.config.MutualTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	// request deadlines are set per operation, see Config.TimeoutFor
+	c.tls.client = &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+	}
+	return c.tls.client, nil
+}
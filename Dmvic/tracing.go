@@ -0,0 +1,63 @@
+package dmvic
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the spans created by the client.
+const tracerName = "github.com/nana-tec/gopackages/dmvic"
+
+// newTracer returns the tracer of the given provider, falling back to the global
+// provider which is a no-op unless the application registers one.
+func newTracer(tp trace.TracerProvider) trace.Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(tracerName)
+}
+
+// startSpan starts the client span of a DMVIC API call.
+func (c *client) startSpan(ctx context.Context, op Operation, method, endpoint string) (context.Context, trace.Span) {
+	return c.tracer.Start(ctx, "dmvic."+string(op),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("dmvic.operation", string(op)),
+			attribute.String("dmvic.endpoint", endpoint),
+			attribute.String("http.request.method", method),
+		),
+	)
+}
+
+// endSpan records the outcome of the call on the span and ends it.
+func endSpan(span trace.Span, call *apiCall, err error) {
+	defer span.End()
+	if call.httpStatus != 0 {
+		span.SetAttributes(attribute.Int("http.response.status_code", call.httpStatus))
+	}
+	if call.apiRequestNumber != "" {
+		span.SetAttributes(attribute.String("dmvic.api_request_number", call.apiRequestNumber))
+	}
+	if err == nil {
+		span.SetStatus(codes.Ok, "")
+		return
+	}
+	var clientErr *ClientError
+	if errors.As(err, &clientErr) && clientErr.DMVICCode != "" {
+		span.SetAttributes(attribute.String("dmvic.error_code", clientErr.DMVICCode))
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// injectTraceHeaders propagates the trace context of ctx onto the outgoing request.
+func injectTraceHeaders(ctx context.Context, req *http.Request) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+}
@@ -30,11 +30,11 @@ type CertificateRequest struct {
 
 // CertificateResponse represents the response from certificate retrieval operations.
 type CertificateResponse struct {
-	Success          bool               `json:"success"`          // Indicates if the operation was successful
-	Error            FlexibleDmvicError `json:"error,omitempty"`  // Error details if operation failed
-	APIRequestNumber string             `json:"apiRequestNumber"` // Unique API request identifier
-	Inputs           CertificateRequest `json:"inputs"`           // Original request parameters
-	CallbackObj      CallbackURL        `json:"callbackObj"`      // Callback URL information
+	Success          bool               `json:"success" dmvic:"required"`    // Indicates if the operation was successful
+	Error            FlexibleDmvicError `json:"error,omitempty"`             // Error details if operation failed
+	APIRequestNumber string             `json:"apiRequestNumber"`            // Unique API request identifier
+	Inputs           CertificateRequest `json:"inputs"`                      // Original request parameters
+	CallbackObj      CallbackURL        `json:"callbackObj" dmvic:"success"` // Callback URL information
 }
 
 type DoubleInsuranceDetails struct {
@@ -61,11 +61,11 @@ type InsuranceValidationRequest struct {
 
 // InsuranceValidationResponse represents the response from insurance validation operations.
 type InsuranceValidationResponse struct {
-	Inputs           InsuranceValidationRequest `json:"inputs"`           // Original request parameters
-	Error            FlexibleDmvicError         `json:"error,omitempty"`  // Error details if operation failed
-	Success          bool                       `json:"success"`          // Indicates if the operation was successful
-	APIRequestNumber string                     `json:"apiRequestNumber"` // Unique API request identifier
-	CallbackObj      InsuranceCallbackObj       `json:"callbackObj"`      // Insurance validation results
+	Inputs           InsuranceValidationRequest `json:"inputs"`                      // Original request parameters
+	Error            FlexibleDmvicError         `json:"error,omitempty"`             // Error details if operation failed
+	Success          bool                       `json:"success" dmvic:"required"`    // Indicates if the operation was successful
+	APIRequestNumber string                     `json:"apiRequestNumber"`            // Unique API request identifier
+	CallbackObj      InsuranceCallbackObj       `json:"callbackObj" dmvic:"success"` // Insurance validation results
 }
 
 // InsuranceCallbackObj contains insurance validation results.
@@ -96,11 +96,11 @@ type CancellationRequest struct {
 
 // CancellationResponse represents the response from certificate cancellation operations.
 type CancellationResponse struct {
-	Error            FlexibleDmvicError      `json:"error,omitempty"`  // Error details if operation failed
-	Success          bool                    `json:"success"`          // Indicates if the operation was successful
-	APIRequestNumber string                  `json:"apiRequestNumber"` // Unique API request identifier
-	Inputs           CancellationRequest     `json:"Inputs"`           // Original request parameters
-	CallbackObj      CancellationCallbackObj `json:"callbackObj"`      // Cancellation operation results
+	Error            FlexibleDmvicError      `json:"error,omitempty"`             // Error details if operation failed
+	Success          bool                    `json:"success" dmvic:"required"`    // Indicates if the operation was successful
+	APIRequestNumber string                  `json:"apiRequestNumber"`            // Unique API request identifier
+	Inputs           CancellationRequest     `json:"Inputs"`                      // Original request parameters
+	CallbackObj      CancellationCallbackObj `json:"callbackObj" dmvic:"success"` // Cancellation operation results
 }
 
 // CancellationCallbackObj contains cancellation operation results.
@@ -118,11 +118,11 @@ type DoubleInsuranceRequest struct {
 
 // DoubleInsuranceResponse represents the response from double insurance validation operations.
 type DoubleInsuranceResponse struct {
-	Inputs           string                     `json:"Inputs"`           // Original request parameters as string
-	CallbackObj      DoubleInsuranceCallbackObj `json:"callbackObj"`      // Double insurance validation results
-	Error            FlexibleDmvicError         `json:"error,omitempty"`  // Error details if operation failed
-	Success          bool                       `json:"success"`          // Indicates if the operation was successful
-	APIRequestNumber string                     `json:"apiRequestNumber"` // Unique API request identifier
+	Inputs           string                     `json:"Inputs"`                      // Original request parameters as string
+	CallbackObj      DoubleInsuranceCallbackObj `json:"callbackObj" dmvic:"success"` // Double insurance validation results
+	Error            FlexibleDmvicError         `json:"error,omitempty"`             // Error details if operation failed
+	Success          bool                       `json:"success" dmvic:"required"`    // Indicates if the operation was successful
+	APIRequestNumber string                     `json:"apiRequestNumber"`            // Unique API request identifier
 }
 
 // DoubleInsuranceList is a flexible type that can unmarshal from either an
@@ -251,6 +251,31 @@ type TypeDIssuanceRequest struct {
 	Tonnage           int `json:"Tonnage"`           // Tonnage of the vehicle for commercial vehicles
 }
 
+// ExtensionRequest represents a request to change the period of cover of an active
+// certificate without cancelling and re-issuing it. Dates use the dd/MM/yyyy format.
+type ExtensionRequest struct {
+	CertificateNumber string `json:"CertificateNumber"`        // Certificate to extend
+	CommencingDate    string `json:"Commencingdate,omitempty"` // New policy start date, empty keeps the current one
+	ExpiringDate      string `json:"Expiringdate"`             // New policy end date
+	Reason            string `json:"Reason,omitempty"`         // Reason for the change of period
+}
+
+// EndorsementRequest represents a request to amend the vehicle or policyholder details
+// of an active certificate. Only the fields that change need to be set.
+type EndorsementRequest struct {
+	CertificateNumber  string `json:"CertificateNumber"`            // Certificate to endorse
+	RegistrationNumber string `json:"Registrationnumber,omitempty"` // Amended vehicle registration number
+	ChassisNumber      string `json:"Chassisnumber,omitempty"`      // Amended vehicle chassis number
+	EngineNumber       string `json:"Enginenumber,omitempty"`       // Amended engine number
+	VehicleMake        string `json:"Vehiclemake,omitempty"`        // Amended vehicle make
+	VehicleModel       string `json:"Vehiclemodel,omitempty"`       // Amended vehicle model
+	BodyType           string `json:"Bodytype,omitempty"`           // Amended body type
+	PolicyHolder       string `json:"Policyholder,omitempty"`       // Amended policyholder name
+	PhoneNumber        string `json:"Phonenumber,omitempty"`        // Amended contact phone number
+	Email              string `json:"Email,omitempty"`              // Amended contact email address
+	Reason             string `json:"Reason,omitempty"`             // Reason for the endorsement
+}
+
 // DmvicError represents an error response from the DMVIC API.
 // It contains error code and error text providing details about the error.
 type DmvicError struct {
@@ -264,11 +289,11 @@ type FlexibleDmvicError []DmvicError
 // InsuranceResponse represents the response from insurance certificate issuance requests.
 // It contains details about the issued certificate or errors encountered during the process.
 type InsuranceResponse struct {
-	Inputs           interface{}         `json:"Inputs"`           // Original request parameters
-	Error            FlexibleDmvicError  `json:"Error,omitempty"`  // Error details if operation failed
-	Success          bool                `json:"success"`          // Indicates if the operation was successful
-	APIRequestNumber string              `json:"apiRequestNumber"` // Unique API request identifier
-	CallbackObj      IssuanceCallbackObj `json:"CallbackObj"`      // Issuance operation results
+	Inputs           interface{}         `json:"Inputs"`                      // Original request parameters
+	Error            FlexibleDmvicError  `json:"Error,omitempty"`             // Error details if operation failed
+	Success          bool                `json:"success" dmvic:"required"`    // Indicates if the operation was successful
+	APIRequestNumber string              `json:"apiRequestNumber"`            // Unique API request identifier
+	CallbackObj      IssuanceCallbackObj `json:"CallbackObj" dmvic:"success"` // Issuance operation results
 }
 
 // IssuanceCallbackObj contains the results of the insurance certificate issuance operation.
@@ -288,10 +313,10 @@ type IssuanceDetails struct {
 // StockResponse represents the response from stock retrieval operations.
 // It contains details about the stock of insurance certificates available for issuance.
 type StockResponse struct {
-	CallbackObj      StockCallbackObj   `json:"callbackObj"`      // Stock information
-	Error            FlexibleDmvicError `json:"error,omitempty"`  // Error details if operation failed
-	Success          bool               `json:"success"`          // Indicates if the operation was successful
-	APIRequestNumber string             `json:"apiRequestNumber"` // Unique API request identifier
+	CallbackObj      StockCallbackObj   `json:"callbackObj" dmvic:"success"` // Stock information
+	Error            FlexibleDmvicError `json:"error,omitempty"`             // Error details if operation failed
+	Success          bool               `json:"success" dmvic:"required"`    // Indicates if the operation was successful
+	APIRequestNumber string             `json:"apiRequestNumber"`            // Unique API request identifier
 }
 
 // StockCallbackObj contains stock information for insurance certificates.
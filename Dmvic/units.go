@@ -0,0 +1,104 @@
+package dmvic
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrFieldLimit is matched by every UnitRangeError, use errors.Is to detect values
+// DMVIC would reject with ER007.
+var ErrFieldLimit = errors.New("value outside DMVIC field limits")
+
+// RoundingPolicy decides how fractional amounts are converted to the whole units DMVIC expects.
+type RoundingPolicy int
+
+const (
+	// RoundHalfUp rounds to the nearest unit, halves away from zero (1.5 -> 2)
+	RoundHalfUp RoundingPolicy = iota
+	// RoundHalfEven rounds to the nearest unit, halves to the even unit (2.5 -> 2)
+	RoundHalfEven
+	// RoundDown truncates the fraction (1.9 -> 1)
+	RoundDown
+	// RoundUp rounds any fraction up to the next unit (1.1 -> 2)
+	RoundUp
+)
+
+func (p RoundingPolicy) apply(v decimal.Decimal) decimal.Decimal {
+	switch p {
+	case RoundHalfEven:
+		return v.RoundBank(0)
+	case RoundDown:
+		return v.Truncate(0)
+	case RoundUp:
+		return v.Ceil()
+	default:
+		return v.Round(0)
+	}
+}
+
+// UnitLimit describes the integer field DMVIC expects: the accepted range and how
+// fractional values are rounded into it.
+type UnitLimit struct {
+	Field    string         // DMVIC field name, used in errors
+	Min      int64          // Smallest accepted value
+	Max      int64          // Largest accepted value
+	Rounding RoundingPolicy // Conversion of fractional values
+}
+
+var (
+	// SumInsuredLimit is the policy for SumInsured: whole shillings, rounded half up
+	SumInsuredLimit = UnitLimit{Field: "SumInsured", Min: 1, Max: 999_999_999, Rounding: RoundHalfUp}
+	// TonnageLimit is the policy for Tonnage: whole tonnes, rounded up so carrying capacity is never under-declared
+	TonnageLimit = UnitLimit{Field: "Tonnage", Min: 0, Max: 999, Rounding: RoundUp}
+)
+
+// UnitRangeError reports a value that is outside the DMVIC field limits after rounding.
+type UnitRangeError struct {
+	Field   string          // DMVIC field name
+	Value   decimal.Decimal // Value before rounding
+	Rounded int64           // Value after applying the rounding policy
+	Min     int64
+	Max     int64
+}
+
+func (e *UnitRangeError) Error() string {
+	return fmt.Sprintf("%s %s (rounded %d) is outside DMVIC limits [%d, %d]", e.Field, e.Value.String(), e.Rounded, e.Min, e.Max)
+}
+
+func (e *UnitRangeError) Is(target error) bool {
+	return target == ErrFieldLimit
+}
+
+// Normalize rounds v with the limit's rounding policy and checks the result is within the limit.
+func (l UnitLimit) Normalize(v decimal.Decimal) (int, error) {
+	rounded := l.Rounding.apply(v)
+	if rounded.LessThan(decimal.NewFromInt(l.Min)) || rounded.GreaterThan(decimal.NewFromInt(l.Max)) {
+		return 0, &UnitRangeError{Field: l.Field, Value: v, Rounded: rounded.IntPart(), Min: l.Min, Max: l.Max}
+	}
+	return int(rounded.IntPart()), nil
+}
+
+// Check reports whether an integer value already in DMVIC units is within the limit.
+func (l UnitLimit) Check(v int) error {
+	if int64(v) < l.Min || int64(v) > l.Max {
+		return &UnitRangeError{Field: l.Field, Value: decimal.NewFromInt(int64(v)), Rounded: int64(v), Min: l.Min, Max: l.Max}
+	}
+	return nil
+}
+
+// NormalizeSumInsured converts a quotation sum insured to the SumInsured DMVIC expects.
+func NormalizeSumInsured(v decimal.Decimal) (int, error) {
+	return SumInsuredLimit.Normalize(v)
+}
+
+// NormalizeTonnage converts a vehicle tonnage to the Tonnage DMVIC expects.
+func NormalizeTonnage(v decimal.Decimal) (int, error) {
+	return TonnageLimit.Normalize(v)
+}
+
+// NormalizeTonnageFloat is NormalizeTonnage for tonnages held as float64, as on risk records.
+func NormalizeTonnageFloat(v float64) (int, error) {
+	return TonnageLimit.Normalize(decimal.NewFromFloat(v))
+}
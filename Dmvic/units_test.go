@@ -0,0 +1,57 @@
+package dmvic
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestRoundingPolicies(t *testing.T) {
+	cases := []struct {
+		policy RoundingPolicy
+		value  string
+		want   int
+	}{
+		{RoundHalfUp, "1.5", 2},
+		{RoundHalfUp, "2.5", 3},
+		{RoundHalfEven, "2.5", 2},
+		{RoundHalfEven, "3.5", 4},
+		{RoundDown, "1.9", 1},
+		{RoundUp, "1.1", 2},
+		{RoundUp, "2", 2},
+	}
+	for _, tc := range cases {
+		limit := UnitLimit{Field: "Test", Min: 0, Max: 10, Rounding: tc.policy}
+		got, err := limit.Normalize(decimal.RequireFromString(tc.value))
+		if err != nil || got != tc.want {
+			t.Errorf("policy %d of %s: expected %d, got %d, %v", tc.policy, tc.value, tc.want, got, err)
+		}
+	}
+}
+
+func TestUnitLimits(t *testing.T) {
+	if got, err := NormalizeSumInsured(decimal.RequireFromString("1500000.50")); err != nil || got != 1500001 {
+		t.Errorf("expected the sum insured rounded half up, got %d, %v", got, err)
+	}
+	if got, err := NormalizeTonnageFloat(3.2); err != nil || got != 4 {
+		t.Errorf("expected the tonnage rounded up, got %d, %v", got, err)
+	}
+
+	// 0.4 rounds to 0, below the minimum sum insured
+	_, err := NormalizeSumInsured(decimal.RequireFromString("0.4"))
+	var rangeErr *UnitRangeError
+	if !errors.As(err, &rangeErr) || !errors.Is(err, ErrFieldLimit) || rangeErr.Field != "SumInsured" || rangeErr.Rounded != 0 {
+		t.Errorf("expected a range error, got %v", err)
+	}
+	if _, err := NormalizeTonnage(decimal.RequireFromString("999.1")); !errors.Is(err, ErrFieldLimit) {
+		t.Errorf("expected a tonnage over the limit to be rejected, got %v", err)
+	}
+
+	if err := TonnageLimit.Check(999); err != nil {
+		t.Errorf("expected the maximum to be accepted, got %v", err)
+	}
+	if err := SumInsuredLimit.Check(1_000_000_000); !errors.Is(err, ErrFieldLimit) {
+		t.Errorf("expected the sum insured to be rejected, got %v", err)
+	}
+}
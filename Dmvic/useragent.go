@@ -0,0 +1,62 @@
+package dmvic
+
+import (
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+const (
+	// LibraryName identifies this library in the User-Agent of DMVIC requests.
+	LibraryName = "nana-tec-dmvic-go"
+
+	// LibraryVersionHeader carries the library name and version on every request.
+	LibraryVersionHeader = "X-Client-Library"
+
+	modulePath = "github.com/nana-tec/gopackages"
+)
+
+var (
+	libraryVersionOnce sync.Once
+	libraryVersionStr  string
+)
+
+// LibraryVersion returns the version of the gopackages module compiled into the binary,
+// or "devel" when it is built from a local checkout.
+func LibraryVersion() string {
+	libraryVersionOnce.Do(func() {
+		libraryVersionStr = "devel"
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		if info.Main.Path == modulePath && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			libraryVersionStr = info.Main.Version
+			return
+		}
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				libraryVersionStr = dep.Version
+				return
+			}
+		}
+	})
+	return libraryVersionStr
+}
+
+// userAgent returns the User-Agent of DMVIC requests: Config.UserAgent followed by the
+// library name and version, e.g. "policy-service/1.4.2 nana-tec-dmvic-go/v0.3.1".
+func (c *client) userAgent() string {
+	library := LibraryName + "/" + LibraryVersion()
+	if ua := strings.TrimSpace(c.config.UserAgent); ua != "" {
+		return ua + " " + library
+	}
+	return library
+}
+
+// setClientHeaders identifies the calling service and library version on req.
+func (c *client) setClientHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", c.userAgent())
+	req.Header.Set(LibraryVersionHeader, LibraryName+"/"+LibraryVersion())
+}
@@ -0,0 +1,146 @@
+package dmvic
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// The account management paths are not part of the published integration API
+// specification; deployments whose paths differ override them with Config.Endpoints.
+
+// CreateSubUserRequest represents a request to create an API sub-user, e.g. for a new branch.
+type CreateSubUserRequest struct {
+	UserName     string   `json:"UserName"`               // Login name of the new user
+	FirstName    string   `json:"FirstName"`              // First name of the user
+	LastName     string   `json:"LastName"`               // Last name of the user
+	Email        string   `json:"Email"`                  // Email address the credentials are sent to
+	PhoneNumber  string   `json:"PhoneNumber"`            // Contact phone number
+	BranchName   string   `json:"BranchName,omitempty"`   // Branch the user belongs to
+	Entitlements []string `json:"Entitlements,omitempty"` // Entitlement codes granted to the user
+}
+
+// SubUserResponse represents the response from creating a sub-user.
+type SubUserResponse struct {
+	Success          bool                 `json:"success" dmvic:"required"`    // Indicates if the operation was successful
+	Error            FlexibleDmvicError   `json:"error,omitempty"`             // Error details if operation failed
+	APIRequestNumber string               `json:"apiRequestNumber"`            // Unique API request identifier
+	CallbackObj      SubUserCallbackObj   `json:"callbackObj" dmvic:"success"` // Created user details
+	Inputs           CreateSubUserRequest `json:"Inputs"`                      // Original request parameters
+}
+
+// SubUserCallbackObj contains the details of a created sub-user.
+type SubUserCallbackObj struct {
+	UserID   string `json:"UserID"`   // DMVIC identifier of the user
+	UserName string `json:"UserName"` // Login name of the user
+}
+
+// ResetCredentialsRequest represents a request to reset the password of a sub-user.
+type ResetCredentialsRequest struct {
+	UserName string `json:"UserName"` // Login name of the user
+}
+
+// ResetCredentialsResponse represents the response from resetting sub-user credentials.
+type ResetCredentialsResponse struct {
+	Success          bool                        `json:"success" dmvic:"required"`    // Indicates if the operation was successful
+	Error            FlexibleDmvicError          `json:"error,omitempty"`             // Error details if operation failed
+	APIRequestNumber string                      `json:"apiRequestNumber"`            // Unique API request identifier
+	CallbackObj      ResetCredentialsCallbackObj `json:"callbackObj" dmvic:"success"` // Reset details
+}
+
+// ResetCredentialsCallbackObj contains the outcome of a credentials reset.
+type ResetCredentialsCallbackObj struct {
+	UserName string `json:"UserName"` // Login name of the user
+	Message  string `json:"Message"`  // Message returned by DMVIC, e.g. where the new password was sent
+}
+
+// Entitlement is a permission granted to a DMVIC user.
+type Entitlement struct {
+	Code        string `json:"EntitlementCode"` // Entitlement identifier
+	Description string `json:"Description"`     // Human-readable description
+}
+
+// EntitlementsResponse represents the response from listing the entitlements of a user.
+type EntitlementsResponse struct {
+	Success          bool                    `json:"success" dmvic:"required"`    // Indicates if the operation was successful
+	Error            FlexibleDmvicError      `json:"error,omitempty"`             // Error details if operation failed
+	APIRequestNumber string                  `json:"apiRequestNumber"`            // Unique API request identifier
+	CallbackObj      EntitlementsCallbackObj `json:"callbackObj" dmvic:"success"` // Entitlements of the user
+}
+
+// EntitlementsCallbackObj contains the entitlements of a user.
+type EntitlementsCallbackObj struct {
+	UserName     string        `json:"UserName"`     // Login name of the user
+	Entitlements []Entitlement `json:"Entitlements"` // Entitlements granted to the user
+}
+
+func (r *SubUserResponse) GetError() string              { return firstErrorText(r.Error) }
+func (r *SubUserResponse) IsSuccess() bool               { return r.Success }
+func (r *SubUserResponse) GetErrors() FlexibleDmvicError { return r.Error }
+func (r *SubUserResponse) GetAPIRequestNumber() string   { return r.APIRequestNumber }
+
+func (r *ResetCredentialsResponse) GetError() string              { return firstErrorText(r.Error) }
+func (r *ResetCredentialsResponse) IsSuccess() bool               { return r.Success }
+func (r *ResetCredentialsResponse) GetErrors() FlexibleDmvicError { return r.Error }
+func (r *ResetCredentialsResponse) GetAPIRequestNumber() string   { return r.APIRequestNumber }
+
+func (r *EntitlementsResponse) GetError() string              { return firstErrorText(r.Error) }
+func (r *EntitlementsResponse) IsSuccess() bool               { return r.Success }
+func (r *EntitlementsResponse) GetErrors() FlexibleDmvicError { return r.Error }
+func (r *EntitlementsResponse) GetAPIRequestNumber() string   { return r.APIRequestNumber }
+
+// firstErrorText returns the text, or failing that the code, of the first DMVIC error.
+func firstErrorText(errs FlexibleDmvicError) string {
+	if len(errs) > 0 {
+		if errs[0].ErrorText != "" {
+			return errs[0].ErrorText
+		}
+		return errs[0].ErrorCode
+	}
+	return ""
+}
+
+// CreateSubUser creates an API sub-user under the member company account so that new
+// branch users can be onboarded without going through the DMVIC portal.
+func (c *client) CreateSubUser(req *CreateSubUserRequest) (*SubUserResponse, error) {
+	if err := ValidateCreateSubUserRequest(req); err != nil {
+		return nil, newInternalError(string(OpCreateSubUser), ErrCreateSubUser, err)
+	}
+	var resp SubUserResponse
+	err := c.makeAPICall(c.ctx, OpCreateSubUser, http.MethodPost, c.path(OpCreateSubUser), req, &resp, ErrCreateSubUser)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ResetSubUserCredentials resets the password of a sub-user. DMVIC sends the new
+// credentials to the email address registered for the user.
+func (c *client) ResetSubUserCredentials(userName string) (*ResetCredentialsResponse, error) {
+	userName = strings.TrimSpace(userName)
+	if userName == "" {
+		return nil, newInternalError(string(OpResetSubUserCredentials), ErrResetSubUserCredentials, fmt.Errorf("user name is required"))
+	}
+	var resp ResetCredentialsResponse
+	req := &ResetCredentialsRequest{UserName: userName}
+	err := c.makeAPICall(c.ctx, OpResetSubUserCredentials, http.MethodPost, c.path(OpResetSubUserCredentials), req, &resp, ErrResetSubUserCredentials)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListEntitlements lists the entitlements granted to a user.
+func (c *client) ListEntitlements(userName string) (*EntitlementsResponse, error) {
+	userName = strings.TrimSpace(userName)
+	if userName == "" {
+		return nil, newInternalError(string(OpListEntitlements), ErrListEntitlements, fmt.Errorf("user name is required"))
+	}
+	var resp EntitlementsResponse
+	req := newAPIRequest(OpListEntitlements, http.MethodGet, c.path(OpListEntitlements)).
+		Query("UserName", userName)
+	if err := c.send(c.ctx, req, &resp, ErrListEntitlements); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,74 @@
+package dmvic
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestSubUsers(t *testing.T) {
+	var created CreateSubUserRequest
+	var reset ResetCredentialsRequest
+	var entitlementsQuery string
+	ts := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/V4/Account/CreateSubUser":
+			json.NewDecoder(r.Body).Decode(&created)
+			writeJSON(w, SubUserResponse{Success: true, CallbackObj: SubUserCallbackObj{UserID: "U1", UserName: created.UserName}})
+		case "/V4/Account/ResetCredentials":
+			json.NewDecoder(r.Body).Decode(&reset)
+			writeJSON(w, ResetCredentialsResponse{Success: true, CallbackObj: ResetCredentialsCallbackObj{UserName: reset.UserName}})
+		case "/V4/Account/Entitlements":
+			entitlementsQuery = r.URL.Query().Get("UserName")
+			writeJSON(w, EntitlementsResponse{Success: true, CallbackObj: EntitlementsCallbackObj{
+				UserName:     entitlementsQuery,
+				Entitlements: []Entitlement{{Code: "ISSUE", Description: "Issue certificates"}},
+			}})
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	})
+	c := newTestClient(t, ts, nil)
+
+	req := &CreateSubUserRequest{UserName: "westlands", FirstName: "Jane", LastName: "Doe",
+		Email: "jane@example.com", PhoneNumber: "0700000000", Entitlements: []string{"ISSUE"}}
+	user, err := c.CreateSubUser(req)
+	if err != nil {
+		t.Fatalf("CreateSubUser: %v", err)
+	}
+	if user.CallbackObj.UserID != "U1" || created.UserName != "westlands" || len(created.Entitlements) != 1 {
+		t.Errorf("unexpected sub-user %+v from request %+v", user.CallbackObj, created)
+	}
+
+	if _, err := c.ResetSubUserCredentials(" westlands "); err != nil || reset.UserName != "westlands" {
+		t.Errorf("expected a trimmed reset of westlands, got %+v, %v", reset, err)
+	}
+
+	entitlements, err := c.ListEntitlements("westlands")
+	if err != nil {
+		t.Fatalf("ListEntitlements: %v", err)
+	}
+	if entitlementsQuery != "westlands" || len(entitlements.CallbackObj.Entitlements) != 1 {
+		t.Errorf("unexpected entitlements %+v for %q", entitlements.CallbackObj, entitlementsQuery)
+	}
+}
+
+func TestSubUsersRejectInvalidInput(t *testing.T) {
+	ts := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+	})
+	c := newTestClient(t, ts, nil)
+
+	var clientErr *ClientError
+	_, err := c.CreateSubUser(&CreateSubUserRequest{UserName: "westlands", FirstName: "Jane", LastName: "Doe", Email: "jane", PhoneNumber: "0700000000"})
+	if !errors.As(err, &clientErr) || clientErr.Code != ErrCreateSubUser {
+		t.Errorf("expected an invalid email to be rejected, got %v", err)
+	}
+	if _, err := c.ResetSubUserCredentials(" "); !errors.As(err, &clientErr) || clientErr.Code != ErrResetSubUserCredentials {
+		t.Errorf("expected a blank user name to be rejected, got %v", err)
+	}
+	if _, err := c.ListEntitlements(""); !errors.As(err, &clientErr) || clientErr.Code != ErrListEntitlements {
+		t.Errorf("expected a blank user name to be rejected, got %v", err)
+	}
+}
@@ -1,6 +1,13 @@
 package dmvic
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dmvicDateLayout is the dd/MM/yyyy date format used by DMVIC requests
+const dmvicDateLayout = "02/01/2006"
 
 // ValidateTypeARequest validates a Type A certificate issuance request
 func ValidateTypeARequest(req *TypeAIssuanceRequest) error {
@@ -16,6 +23,11 @@ func ValidateTypeARequest(req *TypeAIssuanceRequest) error {
 	if (req.TypeOfCover == CoverTypeComprehensive || req.TypeOfCover == CoverTypeTPTF) && req.SumInsured <= 0 {
 		return fmt.Errorf("SumInsured is required for COMP and TPTF cover types")
 	}
+	if req.SumInsured > 0 {
+		if err := SumInsuredLimit.Check(req.SumInsured); err != nil {
+			return err
+		}
+	}
 	if req.PolicyHolder == "" {
 		return fmt.Errorf("Policyholder is required")
 	}
@@ -45,6 +57,14 @@ func ValidateTypeBRequest(req *TypeBIssuanceRequest) error {
 	if (req.TypeOfCover == CoverTypeComprehensive || req.TypeOfCover == CoverTypeTPTF) && req.SumInsured <= 0 {
 		return fmt.Errorf("SumInsured is required for COMP and TPTF cover types")
 	}
+	if req.SumInsured > 0 {
+		if err := SumInsuredLimit.Check(req.SumInsured); err != nil {
+			return err
+		}
+	}
+	if err := TonnageLimit.Check(req.Tonnage); err != nil {
+		return err
+	}
 	if req.PolicyHolder == "" {
 		return fmt.Errorf("Policyholder is required")
 	}
@@ -71,6 +91,11 @@ func ValidateTypeCRequest(req *TypeCIssuanceRequest) error {
 	if (req.TypeOfCover == CoverTypeComprehensive || req.TypeOfCover == CoverTypeTPTF) && req.SumInsured <= 0 {
 		return fmt.Errorf("SumInsured is required for COMP and TPTF cover types")
 	}
+	if req.SumInsured > 0 {
+		if err := SumInsuredLimit.Check(req.SumInsured); err != nil {
+			return err
+		}
+	}
 	if req.PolicyHolder == "" {
 		return fmt.Errorf("Policyholder is required")
 	}
@@ -102,6 +127,14 @@ func ValidateTypeDRequest(req *TypeDIssuanceRequest) error {
 	if (req.TypeOfCover == CoverTypeComprehensive || req.TypeOfCover == CoverTypeTPTF) && req.SumInsured <= 0 {
 		return fmt.Errorf("SumInsured is required for COMP and TPTF cover types")
 	}
+	if req.SumInsured > 0 {
+		if err := SumInsuredLimit.Check(req.SumInsured); err != nil {
+			return err
+		}
+	}
+	if err := TonnageLimit.Check(req.Tonnage); err != nil {
+		return err
+	}
 	if req.PolicyHolder == "" {
 		return fmt.Errorf("Policyholder is required")
 	}
@@ -116,3 +149,80 @@ func ValidateTypeDRequest(req *TypeDIssuanceRequest) error {
 	}
 	return nil
 }
+
+// ValidateExtensionRequest validates a change of period request
+func ValidateExtensionRequest(req *ExtensionRequest) error {
+	if req == nil {
+		return fmt.Errorf("extension request is required")
+	}
+	if strings.TrimSpace(req.CertificateNumber) == "" {
+		return fmt.Errorf("CertificateNumber is required")
+	}
+	expiring, err := time.Parse(dmvicDateLayout, req.ExpiringDate)
+	if err != nil {
+		return fmt.Errorf("Expiringdate must be in dd/MM/yyyy format: %s", req.ExpiringDate)
+	}
+	if req.CommencingDate != "" {
+		commencing, err := time.Parse(dmvicDateLayout, req.CommencingDate)
+		if err != nil {
+			return fmt.Errorf("Commencingdate must be in dd/MM/yyyy format: %s", req.CommencingDate)
+		}
+		if !expiring.After(commencing) {
+			return fmt.Errorf("Expiringdate must be after Commencingdate")
+		}
+	}
+	return nil
+}
+
+// ValidateEndorsementRequest validates a vehicle or policyholder details amendment request
+func ValidateEndorsementRequest(req *EndorsementRequest) error {
+	if req == nil {
+		return fmt.Errorf("endorsement request is required")
+	}
+	if strings.TrimSpace(req.CertificateNumber) == "" {
+		return fmt.Errorf("CertificateNumber is required")
+	}
+	if req.RegistrationNumber == "" && req.ChassisNumber == "" && req.EngineNumber == "" &&
+		req.VehicleMake == "" && req.VehicleModel == "" && req.BodyType == "" &&
+		req.PolicyHolder == "" && req.PhoneNumber == "" && req.Email == "" {
+		return fmt.Errorf("at least one amended field is required")
+	}
+	if req.Email != "" && !strings.Contains(req.Email, "@") {
+		return fmt.Errorf("invalid Email: %s", req.Email)
+	}
+	return nil
+}
+
+// IsValidCancelReason reports whether reasonID is one of the DMVIC cancellation reasons
+func IsValidCancelReason(reasonID int) bool {
+	switch reasonID {
+	case CancelReasonInsuredRequest, CancelReasonAmendPassengers, CancelReasonChangeScopeOfCover,
+		CancelReasonPolicyNotTaken, CancelReasonVehicleSold, CancelReasonAmendInsuredDetails,
+		CancelReasonAmendVehicleDetails, CancelReasonSuspectedFraud, CancelReasonNonPayment,
+		CancelReasonFailureToProvideKYC, CancelReasonGovernmentRequest, CancelReasonSubjectMatterCeased,
+		CancelReasonChangePeriod, CancelReasonCoverDeclined, CancelReasonVehicleWrittenOff,
+		CancelReasonVehicleStolen:
+		return true
+	}
+	return false
+}
+
+// ValidateCreateSubUserRequest validates a sub-user creation request
+func ValidateCreateSubUserRequest(req *CreateSubUserRequest) error {
+	if req == nil {
+		return fmt.Errorf("create sub-user request is required")
+	}
+	if strings.TrimSpace(req.UserName) == "" {
+		return fmt.Errorf("UserName is required")
+	}
+	if strings.TrimSpace(req.FirstName) == "" || strings.TrimSpace(req.LastName) == "" {
+		return fmt.Errorf("FirstName and LastName are required")
+	}
+	if !strings.Contains(req.Email, "@") {
+		return fmt.Errorf("invalid Email: %s", req.Email)
+	}
+	if strings.TrimSpace(req.PhoneNumber) == "" {
+		return fmt.Errorf("PhoneNumber is required")
+	}
+	return nil
+}
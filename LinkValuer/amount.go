@@ -0,0 +1,73 @@
+package linkvaluer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// FlexibleAmount is a monetary value the API sends either as a JSON number (250000)
+// or as a string ("250000", "250,000"). Empty strings and null decode to an unset amount.
+type FlexibleAmount struct {
+	value decimal.Decimal
+	set   bool
+}
+
+// NewFlexibleAmount returns a set amount holding d
+func NewFlexibleAmount(d decimal.Decimal) FlexibleAmount {
+	return FlexibleAmount{value: d, set: true}
+}
+
+// Decimal returns the amount, zero when unset
+func (a FlexibleAmount) Decimal() decimal.Decimal {
+	return a.value
+}
+
+// IsSet reports whether the API sent a value
+func (a FlexibleAmount) IsSet() bool {
+	return a.set
+}
+
+func (a FlexibleAmount) String() string {
+	if !a.set {
+		return ""
+	}
+	return a.value.String()
+}
+
+func (a *FlexibleAmount) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*a = FlexibleAmount{}
+		return nil
+	}
+	raw := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		raw = strings.ReplaceAll(strings.TrimSpace(s), ",", "")
+		if raw == "" {
+			*a = FlexibleAmount{}
+			return nil
+		}
+	}
+	d, err := decimal.NewFromString(raw)
+	if err != nil {
+		return fmt.Errorf("invalid amount %s: %w", string(data), err)
+	}
+	*a = NewFlexibleAmount(d)
+	return nil
+}
+
+// MarshalJSON writes the amount as a string, the shape the documented callback uses
+func (a FlexibleAmount) MarshalJSON() ([]byte, error) {
+	if !a.set {
+		return []byte("null"), nil
+	}
+	return json.Marshal(a.value.String())
+}
@@ -0,0 +1,100 @@
+package linkvaluer
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const assessmentsDateLayout = "2006-01-02"
+
+// ViewAssessmentsOptions filters and pages the assessments listing. Zero values are not sent.
+type ViewAssessmentsOptions struct {
+	Page               int       // 1-based page number, defaults to 1
+	PerPage            int       // Page size, the API default is used when 0
+	Status             string    // Provider status, e.g. "completed"
+	RegistrationNumber string    // Vehicle registration number
+	From               time.Time // Assessments on or after this date
+	To                 time.Time // Assessments on or before this date
+}
+
+func (o ViewAssessmentsOptions) query() url.Values {
+	q := url.Values{}
+	if o.Page > 0 {
+		q.Set("page", strconv.Itoa(o.Page))
+	}
+	if o.PerPage > 0 {
+		q.Set("per_page", strconv.Itoa(o.PerPage))
+	}
+	if o.Status != "" {
+		q.Set("status", o.Status)
+	}
+	if o.RegistrationNumber != "" {
+		q.Set("reg_no", o.RegistrationNumber)
+	}
+	if !o.From.IsZero() {
+		q.Set("date_from", o.From.Format(assessmentsDateLayout))
+	}
+	if !o.To.IsZero() {
+		q.Set("date_to", o.To.Format(assessmentsDateLayout))
+	}
+	return q
+}
+
+// ViewAssessmentsPage fetches a single page of assessments matching opts
+func (c *client) ViewAssessmentsPage(ctx context.Context, opts ViewAssessmentsOptions) (*AssessmentsPayload, error) {
+	endpoint := "/view-assessment"
+	if q := opts.query(); len(q) > 0 {
+		endpoint += "?" + q.Encode()
+	}
+	resp, err := c.doRequest(ctx, apiRequest{op: "ViewAssessmentsPage", method: http.MethodGet, endpoint: endpoint, auth: authAccess})
+	if err != nil {
+		return nil, err
+	}
+	if resp.status != http.StatusOK {
+		return nil, newHTTPError("ViewAssessmentsPage", ErrViewAssessments, resp.status, resp.body).withAttempts(resp.attempts)
+	}
+	out, err := DecodeAssessments(resp.body)
+	if err != nil {
+		return nil, newInternalError("ViewAssessmentsPage", ErrUnmarshalResponse, err)
+	}
+	return out, nil
+}
+
+// ViewAllAssessments walks every page of assessments matching opts, starting at opts.Page.
+// Iteration stops at the last page, when the loop breaks or at the first error,
+// which is yielded with a zero AssessmentItem.
+//
+//	for item, err := range c.ViewAllAssessments(ctx, opts) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func (c *client) ViewAllAssessments(ctx context.Context, opts ViewAssessmentsOptions) iter.Seq2[AssessmentItem, error] {
+	return func(yield func(AssessmentItem, error) bool) {
+		if opts.Page <= 0 {
+			opts.Page = 1
+		}
+		for {
+			page, err := c.ViewAssessmentsPage(ctx, opts)
+			if err != nil {
+				yield(AssessmentItem{}, err)
+				return
+			}
+			for _, item := range page.Data {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			// stop on the last page, or when the API does not report pagination
+			if len(page.Data) == 0 || page.Pagination.LastPage <= opts.Page {
+				return
+			}
+			opts.Page++
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package linkvaluer
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// refreshTokenTTL is the cache TTL of refresh tokens that do not carry an expiry
+const refreshTokenTTL = 30 * 24 * time.Hour
+
+// jwtExpiry returns the exp claim of a JWT. The signature is not verified, the
+// claim only sizes the cache TTL; the API remains the judge of token validity.
+func jwtExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp json.Number `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == "" {
+		return time.Time{}, false
+	}
+	exp, err := claims.Exp.Float64()
+	if err != nil || exp <= 0 {
+		return time.Time{}, false
+	}
+	sec := int64(exp)
+	return time.Unix(sec, int64((exp-float64(sec))*float64(time.Second))), true
+}
+
+// tokenExpiryMargin renews tokens shortly before their exp so in-flight requests do not race it
+const tokenExpiryMargin = 30 * time.Second
+
+// tokenTTL returns how long token stays valid according to its exp claim, falling
+// back to fallback when the token is not a JWT with an exp. A token the local clock
+// already considers expired, i.e. clock skew, also gets the fallback rather than
+// forcing a login on every call.
+func tokenTTL(token string, fallback time.Duration) time.Duration {
+	exp, ok := jwtExpiry(token)
+	if !ok {
+		return fallback
+	}
+	ttl := time.Until(exp)
+	if ttl <= 0 {
+		return fallback
+	}
+	if ttl > 2*tokenExpiryMargin {
+		ttl -= tokenExpiryMargin
+	}
+	return ttl
+}
+
+// currentToken returns the cached access token with the epoch it was stored in
+func (c *client) currentToken() (string, uint64) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	tok, _ := c.tokens.Get(c.accessKey)
+	return tok, c.tokenEpoch
+}
+
+// renewToken replaces an access token the API rejected. seenEpoch is the epoch of the
+// rejected token: when a newer token was stored meanwhile it is reused, otherwise a
+// single refresh runs on behalf of all concurrent callers. It logs in instead when no
+// refresh token is cached or the API rejects it, e.g. once it expired. The renewal is detached from ctx cancellation so one caller
+// giving up does not fail the others, each attempt is still bounded by the request timeout.
+func (c *client) renewToken(ctx context.Context, seenEpoch uint64) error {
+	if _, epoch := c.currentToken(); epoch != seenEpoch {
+		return nil
+	}
+	ctx = context.WithoutCancel(ctx)
+	_, err, _ := c.auth.Do("renew", func() (any, error) {
+		if _, epoch := c.currentToken(); epoch != seenEpoch {
+			return nil, nil
+		}
+		if refresh, ok := c.refreshToken(); ok && refresh != "" {
+			err := c.Refresh(ctx)
+			if !isRefreshRejected(err) {
+				return nil, err
+			}
+			c.debugLog("refresh token rejected; logging in")
+			c.tokens.Remove(c.refreshKey)
+		} else {
+			c.debugLog("no refresh token cached; logging in")
+		}
+		return nil, c.Login(ctx)
+	})
+	return err
+}
+
+// isRefreshRejected reports whether the API refused the refresh token, e.g. because it
+// expired, so only a new login can renew the access token
+func isRefreshRejected(err error) bool {
+	var ce *ClientError
+	if !errors.As(err, &ce) {
+		return false
+	}
+	return ce.HTTPStatus == http.StatusUnauthorized || ce.HTTPStatus == http.StatusForbidden
+}
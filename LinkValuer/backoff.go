@@ -0,0 +1,91 @@
+package linkvaluer
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultBackoffInitial    = 500 * time.Millisecond
+	defaultBackoffMultiplier = 2.0
+	defaultBackoffMax        = 10 * time.Second
+)
+
+// BackoffJitter spreads the retries of clients failing at the same moment, so they do
+// not all hit the API again at once
+type BackoffJitter string
+
+const (
+	JitterFull  BackoffJitter = "full"  // uniform between 0 and the delay, the default
+	JitterEqual BackoffJitter = "equal" // half the delay plus uniform up to the other half
+	JitterNone  BackoffJitter = "none"  // the delay itself
+)
+
+func (j BackoffJitter) validate() error {
+	switch j {
+	case "", JitterFull, JitterEqual, JitterNone:
+		return nil
+	}
+	return fmt.Errorf("unknown backoff jitter %q", j)
+}
+
+// backoffDelay returns the wait before the given retry (1 for the first retry): the
+// backoff ceiling randomized by BackoffJitter, so it stays between 0 and BackoffMax
+func (c *Config) backoffDelay(retry int) time.Duration {
+	ceiling := c.backoffCeiling(retry)
+	random := c.BackoffRand
+	if random == nil {
+		random = rand.Float64
+	}
+	switch c.BackoffJitter {
+	case JitterNone:
+		return ceiling
+	case JitterEqual:
+		half := ceiling / 2
+		return half + time.Duration(random()*float64(ceiling-half))
+	}
+	return time.Duration(random() * float64(ceiling))
+}
+
+// backoffCeiling returns the longest wait before the given retry, growing from
+// BackoffInitial by BackoffMultiplier and capped at BackoffMax
+func (c *Config) backoffCeiling(retry int) time.Duration {
+	delay := float64(c.BackoffInitial)
+	for i := 1; i < retry; i++ {
+		delay *= c.BackoffMultiplier
+		if delay >= float64(c.BackoffMax) {
+			return c.BackoffMax
+		}
+	}
+	return min(time.Duration(delay), c.BackoffMax)
+}
+
+// waitBackoff sleeps before the given retry, returning early with the ctx error when ctx is done
+func (c *client) waitBackoff(ctx context.Context, op string, retry int) error {
+	delay := c.config.backoffDelay(retry)
+	c.debugLog("%s retry %d in %v", op, retry, delay)
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// isRetryableStatus reports whether a response is a transient gateway failure worth
+// repeating; only idempotent GETs are retried so a valuation is never created twice
+func isRetryableStatus(method string, status int) bool {
+	if method != http.MethodGet {
+		return false
+	}
+	switch status {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package linkvaluer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultDownloadConcurrency = 4
+
+// ReportDownload is the outcome of one booking of DownloadReports
+type ReportDownload struct {
+	BookingNo   string
+	Path        string // File the report was written to
+	Size        int    // Bytes written
+	ContentType string
+	Attempts    int  // Requests sent, retries included, 0 when skipped
+	Skipped     bool // The file already existed or the booking was listed twice
+	Duration    time.Duration
+	Err         error
+}
+
+// BulkDownloadReport lists the outcome of every booking of DownloadReports, in input order
+type BulkDownloadReport struct {
+	Results []ReportDownload
+}
+
+// Succeeded returns the number of reports downloaded or already present
+func (r *BulkDownloadReport) Succeeded() int {
+	n := 0
+	for _, res := range r.Results {
+		if res.Err == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed returns the bookings whose report could not be downloaded
+func (r *BulkDownloadReport) Failed() []ReportDownload {
+	var failed []ReportDownload
+	for _, res := range r.Results {
+		if res.Err != nil {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// Err joins the errors of the failed bookings, nil when every report was downloaded
+func (r *BulkDownloadReport) Err() error {
+	var errs []error
+	for _, res := range r.Failed() {
+		errs = append(errs, fmt.Errorf("%s: %w", res.BookingNo, res.Err))
+	}
+	return errors.Join(errs...)
+}
+
+// DownloadReports downloads the PDF reports of many bookings into dir with at most
+// concurrency downloads in flight (default 4), for end-of-month archival jobs.
+// Each report is written to <dir>/<booking_no>.pdf; files already present are skipped
+// so an interrupted run can be repeated. Every download retries like DownloadReport
+// and respects the client rate limit. Failures are reported per booking, the returned
+// error is only set when dir cannot be created.
+func (c *client) DownloadReports(ctx context.Context, bookingNos []string, dir string, concurrency int) (*BulkDownloadReport, error) {
+	ctx = c.callContext(ctx)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, newInternalError("DownloadReports", ErrDownloadReport, err)
+	}
+	if concurrency <= 0 {
+		concurrency = defaultDownloadConcurrency
+	}
+
+	report := &BulkDownloadReport{Results: make([]ReportDownload, len(bookingNos))}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	seen := make(map[string]bool, len(bookingNos))
+	for i, bookingNo := range bookingNos {
+		bookingNo = strings.TrimSpace(bookingNo)
+		report.Results[i].BookingNo = bookingNo
+		if seen[bookingNo] && bookingNo != "" {
+			report.Results[i].Skipped = true
+			report.Results[i].Path = filepath.Join(dir, reportFileName(bookingNo, ".pdf"))
+			continue
+		}
+		seen[bookingNo] = true
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			report.Results[i].Err = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(res *ReportDownload) {
+			defer func() { <-sem; wg.Done() }()
+			c.downloadReportTo(ctx, dir, res)
+		}(&report.Results[i])
+	}
+	wg.Wait()
+	return report, nil
+}
+
+// reportFileName maps a booking number to a file name that cannot escape the target directory
+func reportFileName(bookingNo, ext string) string {
+	name := strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', 0:
+			return '_'
+		}
+		return r
+	}, bookingNo)
+	return strings.TrimLeft(name, ".") + ext
+}
+
+func (c *client) downloadReportTo(ctx context.Context, dir string, res *ReportDownload) {
+	start := time.Now()
+	defer func() { res.Duration = time.Since(start) }()
+
+	if res.BookingNo == "" {
+		res.Err = errors.New("empty booking number")
+		return
+	}
+	res.Path = filepath.Join(dir, reportFileName(res.BookingNo, ".pdf"))
+	if fi, err := os.Stat(res.Path); err == nil && fi.Size() > 0 {
+		res.Skipped = true
+		res.Size = int(fi.Size())
+		return
+	}
+
+	body, contentType, attempts, err := c.downloadReportCounted(ctx, res.BookingNo)
+	res.ContentType = contentType
+	res.Attempts = attempts
+	if err != nil {
+		res.Err = err
+		return
+	}
+	if mt, _, _ := mime.ParseMediaType(contentType); mt != "" && mt != "application/pdf" && mt != "application/octet-stream" {
+		res.Err = fmt.Errorf("unexpected content type %s", contentType)
+		return
+	}
+
+	tmp := res.Path + ".part"
+	if err := os.WriteFile(tmp, body, 0o644); err != nil {
+		res.Err = err
+		return
+	}
+	if err := os.Rename(tmp, res.Path); err != nil {
+		_ = os.Remove(tmp)
+		res.Err = err
+		return
+	}
+	res.Size = len(body)
+}
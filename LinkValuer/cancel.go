@@ -0,0 +1,70 @@
+package linkvaluer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// CancelRequest is the payload for withdrawing a valuation booking
+type CancelRequest struct {
+	BookingNo string `json:"booking_no"`
+	Reason    string `json:"reason"`
+}
+
+// CancelValuationPayload is a typed response for CancelValuation
+//
+//	{"success": true, "message": "Booking cancelled", "data": {"booking_no": "LV_0277593", "status": "cancelled", "cancelled_at": "2025-10-14T12:05:10Z"}}
+type CancelValuationPayload struct {
+	Success bool   `json:"success,omitempty"`
+	Message string `json:"message,omitempty"`
+	Data    struct {
+		BookingNo   string `json:"booking_no,omitempty"`
+		Status      string `json:"status,omitempty"`
+		CancelledAt string `json:"cancelled_at,omitempty"`
+	} `json:"data,omitempty"`
+}
+
+// BookingStatus parses the status the API reported for the cancelled booking
+func (p *CancelValuationPayload) BookingStatus() (BookingStatus, error) {
+	return ParseStatus(p.Data.Status)
+}
+
+// CancelValuation withdraws a mistaken or duplicate valuation booking. The API only
+// accepts cancellations before the booking is assessed, a rejection is returned as a
+// *ClientError with code ErrCancelValuation and the HTTP status.
+func (c *client) CancelValuation(ctx context.Context, bookingNo, reason string) (*CancelValuationPayload, error) {
+	bookingNo = strings.TrimSpace(bookingNo)
+	reason = strings.TrimSpace(reason)
+	if bookingNo == "" {
+		return nil, newInternalError("CancelValuation", ErrCancelValuation, errors.New("booking number is required"))
+	}
+	if reason == "" {
+		return nil, newInternalError("CancelValuation", ErrCancelValuation, errors.New("cancellation reason is required"))
+	}
+	payload, err := json.Marshal(CancelRequest{BookingNo: bookingNo, Reason: reason})
+	if err != nil {
+		return nil, newInternalError("CancelValuation", ErrMarshalRequest, err)
+	}
+	resp, err := c.doRequest(withBookingNo(c.callContext(ctx), bookingNo), apiRequest{op: "CancelValuation", method: http.MethodPost, endpoint: "/cancel-api-request", body: payload, auth: authAccess})
+	if err != nil {
+		return nil, err
+	}
+	if resp.status != http.StatusOK {
+		return nil, newHTTPError("CancelValuation", ErrCancelValuation, resp.status, resp.body).withAttempts(resp.attempts)
+	}
+	env, err := decodeEnvelope(resp.body)
+	if err != nil {
+		return nil, newInternalError("CancelValuation", ErrUnmarshalResponse, err)
+	}
+	out := CancelValuationPayload{Success: env.Success, Message: env.Message}
+	if err := env.decodeData(&out.Data); err != nil {
+		return nil, newInternalError("CancelValuation", ErrUnmarshalResponse, err)
+	}
+	if !out.Success && out.Message != "" {
+		return &out, newExternalError("CancelValuation", ErrCancelValuation, out.Message).withAttempts(resp.attempts)
+	}
+	return &out, nil
+}
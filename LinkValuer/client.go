@@ -1,38 +1,68 @@
 package linkvaluer
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
-	"log"
+	"iter"
 	"net"
 	"net/http"
-	"path"
+	"net/url"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+	"golang.org/x/time/rate"
 )
 
 // Client defines the interface for LinkValuer operations
 
+// Every call is bounded by ctx, a nil ctx falls back to Config.Context.
 type Client interface {
-	Login() error
-	Refresh() error
-	CreateValuation(req *CreateRequest) (*CreateValuationPayload, error)
-	ViewAssessments() (*AssessmentsPayload, error)
-	DownloadReport(bookingNo string) ([]byte, string, error)
+	Login(ctx context.Context) error
+	Refresh(ctx context.Context) error
+	CreateValuation(ctx context.Context, req *CreateRequest) (*CreateValuationPayload, error)
+	CreateValuationIdempotent(ctx context.Context, req *CreateRequest) (*CreateValuationPayload, bool, error)
+	CancelValuation(ctx context.Context, bookingNo, reason string) (*CancelValuationPayload, error)
+	ViewAssessments(ctx context.Context) (*AssessmentsPayload, error)
+	ViewAssessmentsPage(ctx context.Context, opts ViewAssessmentsOptions) (*AssessmentsPayload, error)
+	ViewAllAssessments(ctx context.Context, opts ViewAssessmentsOptions) iter.Seq2[AssessmentItem, error]
+	ExportAssessments(ctx context.Context, opts ViewAssessmentsOptions, w io.Writer, format ExportFormat) error
+	WaitForCompletion(ctx context.Context, bookingNo string, interval time.Duration) (*AssessmentItem, error)
+	WaitForReport(ctx context.Context, bookingNo string, interval time.Duration) (*AssessmentItem, []byte, error)
+	DownloadReport(ctx context.Context, bookingNo string) ([]byte, string, error)
+	DownloadReports(ctx context.Context, bookingNos []string, dir string, concurrency int) (*BulkDownloadReport, error)
 	GetToken() string
 	IsTokenValid() bool
-	ViewAPIRequests() (*ViewAPIRequestsResponse, error)
+	ViewAPIRequests(ctx context.Context) (*ViewAPIRequestsResponse, error)
+	ConnectionStats() ConnectionStats
+	Close() error
 }
 
 type client struct {
 	config     *Config
 	httpClient *http.Client
 	endpoint   string
-	tokens     *TTLCache[string, string]
+	tokens     TokenStore
+	closed     atomic.Bool
+	accessKey  string
+	refreshKey string
+	metrics    *connMetrics
+	tracer     trace.Tracer
+	limiter    *rate.Limiter
+
+	// authMu guards tokenEpoch, which is bumped whenever a new access token is stored
+	// so callers that got a 401 can tell whether someone else already renewed it
+	authMu     sync.Mutex
+	tokenEpoch uint64
+	auth       singleflight.Group
+
+	// creates collapses concurrent CreateValuationIdempotent calls per partner reference
+	creates singleflight.Group
 }
 
 const defaultRequestTimeout = 60 * time.Second
@@ -69,65 +99,97 @@ func NewClient(cfg *Config) (Client, error) {
 		hc.Timeout = defaultRequestTimeout
 	}
 
+	tokens := cfg.TokenStore
+	if tokens == nil {
+		tokens = NewTTL[string, string](cfg.TokenTTL)
+	}
+	key := cfg.tokenKey()
+
 	return &client{
 		config:     cfg,
 		httpClient: hc,
 		endpoint:   strings.TrimRight(cfg.GetEndpoint(), "/"),
-		tokens:     NewTTL[string, string](cfg.TokenTTL),
+		tokens:     tokens,
+		accessKey:  key + ":access",
+		refreshKey: key + ":refresh",
+		metrics:    &connMetrics{},
+		tracer:     newTracer(cfg.TracerProvider),
+		limiter:    cfg.newLimiter(),
 	}, nil
 }
 
-func (c *client) debugLog(format string, args ...any) {
-	if c.config.Debug {
-		log.Printf("[LinkValuer] "+format, args...)
+// Close stops the token cache cleanup goroutine and closes the idle connections of the
+// HTTP client. A TokenStore set in Config is owned by the application and left open.
+// Calls made after Close fail with ErrClientClosed. Close is safe to call more than once.
+func (c *client) Close() error {
+	if c.closed.Swap(true) {
+		return nil
+	}
+	if cache, ok := c.tokens.(*TTLCache[string, string]); ok && c.config.TokenStore == nil {
+		cache.Close()
+	}
+	c.httpClient.CloseIdleConnections()
+	c.debugLog("client closed")
+	return nil
+}
+
+// checkOpen returns an error once the client has been closed.
+func (c *client) checkOpen(operation string) error {
+	if c.closed.Load() {
+		return newInternalError(operation, ErrClientClosed, errors.New("client is closed"))
 	}
+	return nil
+}
+
+// ConnectionStats returns a snapshot of the connection pool metrics.
+func (c *client) ConnectionStats() ConnectionStats { return c.metrics.snapshot() }
+
+// newRequest creates a request that reports connection usage to the client metrics.
+func (c *client) newRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	return http.NewRequestWithContext(c.metrics.withTrace(ctx), method, url, body)
+}
+
+// callContext returns ctx, or Config.Context when the caller passed none.
+func (c *client) callContext(ctx context.Context) context.Context {
+	if ctx == nil {
+		return c.config.Context
+	}
+	return ctx
 }
 
 // token helpers
-func (c *client) setAccessToken(tok string, ttl time.Duration)  { c.tokens.Set("lv_access", tok, ttl) }
-func (c *client) setRefreshToken(tok string, ttl time.Duration) { c.tokens.Set("lv_refresh", tok, ttl) }
-func (c *client) accessToken() (string, bool)                   { return c.tokens.Get("lv_access") }
-func (c *client) refreshToken() (string, bool)                  { return c.tokens.Get("lv_refresh") }
+func (c *client) setAccessToken(tok string, ttl time.Duration) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	c.tokens.Set(c.accessKey, tok, ttl)
+	c.tokenEpoch++
+}
+func (c *client) setRefreshToken(tok string, ttl time.Duration) { c.tokens.Set(c.refreshKey, tok, ttl) }
+func (c *client) accessToken() (string, bool)                   { return c.tokens.Get(c.accessKey) }
+func (c *client) refreshToken() (string, bool)                  { return c.tokens.Get(c.refreshKey) }
 
 func (c *client) IsTokenValid() bool { _, ok := c.accessToken(); return ok }
 func (c *client) GetToken() string   { t, _ := c.accessToken(); return t }
 
-// extractTokenPair tries multiple shapes
+// extractTokenPair reads the tokens from the data of the response, falling back to top-level fields
 func extractTokenPair(body []byte) (access, refresh string) {
-	// Try direct struct
-	var s struct {
-		Success      bool   `json:"success"`
-		Message      string `json:"message"`
-		Token        string `json:"token"`
-		AccessToken  string `json:"access_token"`
-		RefreshToken string `json:"refresh_token"`
-		Data         struct {
+	env, err := decodeEnvelope(body)
+	if err != nil {
+		return "", ""
+	}
+	for _, raw := range []json.RawMessage{env.Data, env.Raw} {
+		var t struct {
 			Token        string `json:"token"`
 			AccessToken  string `json:"access_token"`
 			RefreshToken string `json:"refresh_token"`
-		} `json:"data"`
-	}
-	_ = json.Unmarshal(body, &s)
-	access = firstNonEmpty(s.AccessToken, s.Token, s.Data.AccessToken, s.Data.Token)
-	refresh = firstNonEmpty(s.RefreshToken, s.Data.RefreshToken)
-	if access != "" || refresh != "" {
-		return
-	}
-	// Fallback to generic map
-	var m map[string]any
-	if err := json.Unmarshal(body, &m); err == nil {
-		access = getString(m, "access_token", "token")
-		if d, ok := m["data"].(map[string]any); ok {
-			if access == "" {
-				access = getString(d, "access_token", "token")
-			}
-			refresh = getString(d, "refresh_token")
 		}
-		if refresh == "" {
-			refresh = getString(m, "refresh_token")
+		if json.Unmarshal(raw, &t) != nil {
+			continue
 		}
+		access = firstNonEmpty(access, t.AccessToken, t.Token)
+		refresh = firstNonEmpty(refresh, t.RefreshToken)
 	}
-	return
+	return access, refresh
 }
 
 func firstNonEmpty(ss ...string) string {
@@ -150,12 +212,21 @@ func getString(m map[string]any, keys ...string) string {
 	return ""
 }
 
-func (c *client) ensureAccessToken() error {
+// ensureAccessToken logs in when no access token is cached. The login runs once on behalf
+// of all concurrent callers and, like renewToken, is detached from ctx cancellation.
+func (c *client) ensureAccessToken(ctx context.Context) error {
 	if _, ok := c.accessToken(); ok {
 		return nil
 	}
-	c.debugLog("no access token cached; logging in")
-	return c.Login()
+	ctx = context.WithoutCancel(ctx)
+	_, err, _ := c.auth.Do("login", func() (any, error) {
+		if _, ok := c.accessToken(); ok {
+			return nil, nil
+		}
+		c.debugLog("no access token cached; logging in")
+		return nil, c.Login(ctx)
+	})
+	return err
 }
 
 // isTimeoutErr reports whether err is a network or context timeout error
@@ -183,245 +254,81 @@ func (c *client) requestTimeout() time.Duration {
 	return defaultRequestTimeout
 }
 
-func (c *client) Login() error {
+func (c *client) Login(ctx context.Context) error {
 	payload, err := json.Marshal(c.config.Credentials)
 	if err != nil {
 		return newInternalError("Login", ErrMarshalRequest, err)
 	}
-	url := c.endpoint + "/get-token"
-
-	retries := 0
-	if c.config != nil {
-		retries = c.config.Retries
-	}
-
-	var resp *http.Response
-	var body []byte
-	for attempt := 0; attempt <= retries; attempt++ {
-		ctx, cancel := context.WithTimeout(c.config.Context, c.requestTimeout())
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
-		if err != nil {
-			cancel()
-			return newInternalError("Login", ErrCreateRequest, err)
-		}
-		req.Header.Set("Accept", "application/json")
-		req.Header.Set("Content-Type", "application/json")
-
-		resp, err = c.httpClient.Do(req)
-		if err != nil {
-			if isTimeoutErr(err) && attempt < retries {
-				c.debugLog("Login attempt %d timed out; retrying", attempt+1)
-				continue
-			}
-			return newExternalError("Login", ErrHTTPRequest, err.Error())
-		}
-		// success - read body and break
-		body, err = io.ReadAll(resp.Body)
-		if err != nil {
-			_ = resp.Body.Close()
-			return newInternalError("Login", ErrReadResponse, err)
-		}
-		break
+	resp, err := c.doRequest(ctx, apiRequest{op: "Login", method: http.MethodPost, endpoint: "/get-token", body: payload})
+	if err != nil {
+		return err
 	}
-	c.debugLog("login status=%d body=%s", resp.StatusCode, string(body))
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return &ClientError{Type: ExternalError, Code: ErrLoginFailed, Message: fmt.Sprintf("HTTP %d: %s", resp.StatusCode, string(body)), Operation: "Login", HTTPStatus: resp.StatusCode}
+	if resp.status != http.StatusOK && resp.status != http.StatusCreated {
+		return newHTTPError("Login", ErrLoginFailed, resp.status, resp.body).withAttempts(resp.attempts)
 	}
-	access, refresh := extractTokenPair(body)
+	access, refresh := extractTokenPair(resp.body)
 	if access == "" {
 		return newExternalError("Login", ErrInvalidCredentials, "missing access token in response")
 	}
-	c.setAccessToken(access, c.config.TokenTTL)
+	c.setAccessToken(access, tokenTTL(access, c.config.TokenTTL))
 	if refresh != "" {
-		c.setRefreshToken(refresh, 30*24*time.Hour)
+		c.setRefreshToken(refresh, tokenTTL(refresh, refreshTokenTTL))
 	}
 	return nil
 }
 
-func (c *client) Refresh() error {
-	refresh, ok := c.refreshToken()
-	if !ok || refresh == "" {
+func (c *client) Refresh(ctx context.Context) error {
+	if refresh, ok := c.refreshToken(); !ok || refresh == "" {
 		return newExternalError("Refresh", ErrTokenRefresh, "no refresh token cached")
 	}
-	url := c.endpoint + "/refresh-token"
-
-	retries := 0
-	if c.config != nil {
-		retries = c.config.Retries
-	}
-
-	var resp *http.Response
-	var body []byte
-	for attempt := 0; attempt <= retries; attempt++ {
-		ctx, cancel := context.WithTimeout(c.config.Context, c.requestTimeout())
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-		if err != nil {
-			cancel()
-			return newInternalError("Refresh", ErrCreateRequest, err)
-		}
-		req.Header.Set("Accept", "application/json")
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", refresh))
-
-		resp, err = c.httpClient.Do(req)
-		if err != nil {
-			if isTimeoutErr(err) && attempt < retries {
-				c.debugLog("Refresh attempt %d timed out; retrying", attempt+1)
-				continue
-			}
-			return newExternalError("Refresh", ErrHTTPRequest, err.Error())
-		}
-		body, err = io.ReadAll(resp.Body)
-		if err != nil {
-			_ = resp.Body.Close()
-			return newInternalError("Refresh", ErrReadResponse, err)
-		}
-		break
+	resp, err := c.doRequest(ctx, apiRequest{op: "Refresh", method: http.MethodGet, endpoint: "/refresh-token", auth: authRefresh})
+	if err != nil {
+		return err
 	}
-	c.debugLog("refresh status=%d body=%s", resp.StatusCode, string(body))
-	if resp.StatusCode != http.StatusOK {
-		return &ClientError{Type: ExternalError, Code: ErrTokenRefresh, Message: fmt.Sprintf("HTTP %d: %s", resp.StatusCode, string(body)), Operation: "Refresh", HTTPStatus: resp.StatusCode}
+	if resp.status != http.StatusOK {
+		return newHTTPError("Refresh", ErrTokenRefresh, resp.status, resp.body).withAttempts(resp.attempts)
 	}
-	access, newRefresh := extractTokenPair(body)
+	access, newRefresh := extractTokenPair(resp.body)
 	if access == "" {
 		return newExternalError("Refresh", ErrTokenRefresh, "missing access token in response")
 	}
-	c.setAccessToken(access, c.config.TokenTTL)
+	c.setAccessToken(access, tokenTTL(access, c.config.TokenTTL))
 	if newRefresh != "" {
-		c.setRefreshToken(newRefresh, 30*24*time.Hour)
+		c.setRefreshToken(newRefresh, tokenTTL(newRefresh, refreshTokenTTL))
 	}
 	return nil
 }
 
-func (c *client) authJSON(method, endpoint string, payload []byte) (*http.Response, []byte, error) {
-	if err := c.ensureAccessToken(); err != nil {
-		return nil, nil, err
-	}
-	url := c.endpoint + ensureLeadingSlash(endpoint)
-
-	retries := 0
-	if c.config != nil {
-		retries = c.config.Retries
-	}
+// downloadRoute is the metrics and span label of DownloadReport, without the booking number
+const downloadRoute = "/download-pdf/{booking_no}"
 
-	var resp *http.Response
-	var body []byte
-	for attempt := 0; attempt <= retries; attempt++ {
-		ctx, cancel := context.WithTimeout(c.config.Context, c.requestTimeout())
-		req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(payload))
-		if err != nil {
-			cancel()
-			return nil, nil, newInternalError("authJSON:createRequest", ErrCreateRequest, err)
-		}
-		req.Header.Set("Accept", "application/json")
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.GetToken()))
-
-		resp, err = c.httpClient.Do(req)
-		if err != nil {
-			if isTimeoutErr(err) && attempt < retries {
-				c.debugLog("authJSON attempt %d timed out; retrying", attempt+1)
-				continue
-			}
-			return nil, nil, newExternalError("authJSON:do", ErrHTTPRequest, err.Error())
-		}
-		body, err = io.ReadAll(resp.Body)
-		if err != nil {
-			_ = resp.Body.Close()
-			return nil, nil, newInternalError("authJSON:read", ErrReadResponse, err)
-		}
-		if resp.StatusCode == http.StatusUnauthorized {
-			_ = resp.Body.Close()
-			if err := c.Refresh(); err != nil {
-				return nil, nil, err
-			}
-			// retry once after refreshing token
-			ctx2, cancel2 := context.WithTimeout(c.config.Context, c.requestTimeout())
-			req2, err := http.NewRequestWithContext(ctx2, method, url, bytes.NewReader(payload))
-			if err != nil {
-				cancel2()
-				return nil, nil, newInternalError("authJSON:createRequest-retry", ErrCreateRequest, err)
-			}
-			req2.Header.Set("Accept", "application/json")
-			req2.Header.Set("Content-Type", "application/json")
-			req2.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.GetToken()))
-			resp, err = c.httpClient.Do(req2)
-			if err != nil {
-				return nil, nil, newExternalError("authJSON:retry", ErrHTTPRequest, err.Error())
-			}
-			body, err = io.ReadAll(resp.Body)
-			if err != nil {
-				_ = resp.Body.Close()
-				return nil, nil, newInternalError("authJSON:read-retry", ErrReadResponse, err)
-			}
-		}
-		return resp, body, nil
-	}
-	// if we reach here it means attempts exhausted
-	return nil, nil, newExternalError("authJSON:do", ErrHTTPRequest, fmt.Sprintf("request failed after %d attempts", retries+1))
+func (c *client) DownloadReport(ctx context.Context, bookingNo string) ([]byte, string, error) {
+	body, contentType, _, err := c.downloadReportCounted(ctx, bookingNo)
+	return body, contentType, err
 }
 
-func (c *client) DownloadReport(bookingNo string) ([]byte, string, error) {
-	if err := c.ensureAccessToken(); err != nil {
-		return nil, "", err
-	}
-	p := path.Join("/download-pdf", bookingNo)
-	url := c.endpoint + ensureLeadingSlash(p)
-
-	retries := 0
-	if c.config != nil {
-		retries = c.config.Retries
-	}
-
-	var resp *http.Response
-	var body []byte
-	for attempt := 0; attempt <= retries; attempt++ {
-		ctx, cancel := context.WithTimeout(c.config.Context, c.requestTimeout())
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-		if err != nil {
-			cancel()
-			return nil, "", newInternalError("DownloadReport", ErrCreateRequest, err)
-		}
-		req.Header.Set("Accept", "*/*")
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.GetToken()))
-
-		resp, err = c.httpClient.Do(req)
-		if err != nil {
-			if isTimeoutErr(err) && attempt < retries {
-				c.debugLog("DownloadReport attempt %d timed out; retrying", attempt+1)
-				continue
-			}
-			return nil, "", newExternalError("DownloadReport", ErrHTTPRequest, err.Error())
-		}
-		defer func() { _ = resp.Body.Close() }()
-		if resp.StatusCode == http.StatusUnauthorized {
-			if err := c.Refresh(); err != nil {
-				return nil, "", err
-			}
-			// retry once after refresh
-			ctx2, cancel2 := context.WithTimeout(c.config.Context, c.requestTimeout())
-			req2, err := http.NewRequestWithContext(ctx2, http.MethodGet, url, nil)
-			if err != nil {
-				cancel2()
-				return nil, "", newInternalError("DownloadReport", ErrCreateRequest, err)
-			}
-			req2.Header.Set("Accept", "*/*")
-			req2.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.GetToken()))
-			resp, err = c.httpClient.Do(req2)
-			if err != nil {
-				return nil, "", newExternalError("DownloadReport", ErrHTTPRequest, err.Error())
-			}
-			defer func() { _ = resp.Body.Close() }()
-		}
-		body, err = io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, "", newInternalError("DownloadReport", ErrReadResponse, err)
-		}
-		break
+// downloadReportCounted is DownloadReport also returning the number of requests sent
+func (c *client) downloadReportCounted(ctx context.Context, bookingNo string) ([]byte, string, int, error) {
+	bookingNo = strings.TrimSpace(bookingNo)
+	if bookingNo == "" {
+		return nil, "", 0, newInternalError("DownloadReport", ErrDownloadReport, errors.New("booking number is required"))
+	}
+	resp, err := c.doRequest(withBookingNo(c.callContext(ctx), bookingNo), apiRequest{
+		op:       "DownloadReport",
+		method:   http.MethodGet,
+		endpoint: "/download-pdf/" + url.PathEscape(bookingNo),
+		route:    downloadRoute,
+		accept:   "*/*",
+		auth:     authAccess,
+	})
+	if err != nil {
+		return nil, "", 0, err
 	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, resp.Header.Get("Content-Type"), &ClientError{Type: ExternalError, Code: ErrDownloadReport, Message: fmt.Sprintf("HTTP %d: %s", resp.StatusCode, string(body)), Operation: "DownloadReport", HTTPStatus: resp.StatusCode}
+	contentType := resp.header.Get("Content-Type")
+	if resp.status != http.StatusOK {
+		return nil, contentType, resp.attempts, newHTTPError("DownloadReport", ErrDownloadReport, resp.status, resp.body).withAttempts(resp.attempts)
 	}
-	return body, resp.Header.Get("Content-Type"), nil
+	return resp.body, contentType, resp.attempts, nil
 }
 
 func ensureLeadingSlash(p string) string {
@@ -434,60 +341,75 @@ func ensureLeadingSlash(p string) string {
 	return "/" + p
 }
 
-func (c *client) CreateValuation(reqBody *CreateRequest) (*CreateValuationPayload, error) {
+func (c *client) CreateValuation(ctx context.Context, reqBody *CreateRequest) (*CreateValuationPayload, error) {
+	if reqBody == nil {
+		return nil, newInternalError("CreateValuation", ErrInvalidRequest, errors.New("request is required"))
+	}
+	if err := reqBody.Validate(); err != nil {
+		ce := newInternalError("CreateValuation", ErrInvalidRequest, err)
+		errors.As(err, &ce.Validation)
+		return nil, ce
+	}
 	payload, err := json.Marshal(reqBody)
 	if err != nil {
 		return nil, newInternalError("CreateValuation", ErrMarshalRequest, err)
 	}
-	resp, body, err := c.authJSON(http.MethodPost, "/create-api-request", payload)
+	resp, err := c.doRequest(ctx, apiRequest{op: "CreateValuation", method: http.MethodPost, endpoint: "/create-api-request", body: payload, auth: authAccess})
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, &ClientError{Type: ExternalError, Code: ErrCreateValuation, Message: fmt.Sprintf("HTTP %d: %s", resp.StatusCode, string(body)), Operation: "CreateValuation", HTTPStatus: resp.StatusCode}
+	if resp.status != http.StatusOK && resp.status != http.StatusCreated {
+		return nil, newHTTPError("CreateValuation", ErrCreateValuation, resp.status, resp.body).withAttempts(resp.attempts)
 	}
-	var out CreateValuationPayload
-	if err := json.Unmarshal(body, &out); err != nil {
+	env, err := decodeEnvelope(resp.body)
+	if err != nil {
+		return nil, newInternalError("CreateValuation", ErrUnmarshalResponse, err)
+	}
+	out := CreateValuationPayload{Success: env.Success, Message: env.Message}
+	if err := env.decodeData(&out.Data); err != nil {
 		return nil, newInternalError("CreateValuation", ErrUnmarshalResponse, err)
 	}
 	return &out, nil
 }
 
-func (c *client) ViewAssessments() (*AssessmentsPayload, error) {
-	resp, body, err := c.authJSON(http.MethodGet, "/view-assessment", nil)
+func (c *client) ViewAssessments(ctx context.Context) (*AssessmentsPayload, error) {
+	resp, err := c.doRequest(ctx, apiRequest{op: "ViewAssessments", method: http.MethodGet, endpoint: "/view-assessment", auth: authAccess})
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
-	if resp.StatusCode != http.StatusOK {
-		return nil, &ClientError{Type: ExternalError, Code: ErrViewAssessments, Message: fmt.Sprintf("HTTP %d: %s", resp.StatusCode, string(body)), Operation: "ViewAssessments", HTTPStatus: resp.StatusCode}
+	if resp.status != http.StatusOK {
+		return nil, newHTTPError("ViewAssessments", ErrViewAssessments, resp.status, resp.body).withAttempts(resp.attempts)
 	}
-	var out AssessmentsPayload
-	if err := json.Unmarshal(body, &out); err != nil {
+	out, err := DecodeAssessments(resp.body)
+	if err != nil {
 		return nil, newInternalError("ViewAssessments", ErrUnmarshalResponse, err)
 	}
-	return &out, nil
+	return out, nil
 }
 
-func (c *client) ViewAPIRequests() (*ViewAPIRequestsResponse, error) {
-	resp, body, err := c.authJSON(http.MethodGet, "/view-api-requests", nil)
+func (c *client) ViewAPIRequests(ctx context.Context) (*ViewAPIRequestsResponse, error) {
+	resp, err := c.doRequest(ctx, apiRequest{op: "ViewAPIRequests", method: http.MethodGet, endpoint: "/view-api-requests", auth: authAccess})
 	if err != nil {
 		return nil, err
 	}
-	defer func(Body io.ReadCloser) {
-		err := Body.Close()
-		if err != nil {
-
-		}
-	}(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		return nil, &ClientError{Type: ExternalError, Code: ErrViewAPIRequests, Message: fmt.Sprintf("HTTP %d: %s", resp.StatusCode, string(body)), Operation: "ViewAPIRequests", HTTPStatus: resp.StatusCode}
+	if resp.status != http.StatusOK {
+		return nil, newHTTPError("ViewAPIRequests", ErrViewAPIRequests, resp.status, resp.body).withAttempts(resp.attempts)
 	}
 
-	var out ViewAPIRequestsResponse
-	if err := json.Unmarshal(body, &out); err != nil {
+	env, err := decodeEnvelope(resp.body)
+	if err != nil {
 		return nil, newInternalError("ViewAPIRequests", ErrUnmarshalResponse, err)
 	}
+	out := ViewAPIRequestsResponse{Message: env.Message}
+	var top struct {
+		Client string `json:"client"`
+	}
+	_ = json.Unmarshal(env.Raw, &top)
+	out.Client = top.Client
+	if items, _ := env.listData(); items != nil {
+		if err := json.Unmarshal(items, &out.Data); err != nil {
+			return nil, newInternalError("ViewAPIRequests", ErrUnmarshalResponse, err)
+		}
+	}
 	return &out, nil
 }
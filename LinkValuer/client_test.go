@@ -0,0 +1,426 @@
+package linkvaluer
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeAPI serves the token endpoints and delegates everything else to handle.
+// Access tokens issued by login are "access-1", by each refresh "access-<n+1>".
+type fakeAPI struct {
+	logins    atomic.Int32
+	refreshes atomic.Int32
+	handle    http.HandlerFunc
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/get-token":
+		f.logins.Add(1)
+		_, _ = w.Write([]byte(`{"token":"access-1","refresh_token":"refresh-1"}`))
+	case "/refresh-token":
+		if r.Header.Get("Authorization") != "Bearer refresh-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		n := f.refreshes.Add(1)
+		_, _ = w.Write([]byte(`{"token":"access-` + strconv.Itoa(int(n)+1) + `"}`))
+	default:
+		f.handle(w, r)
+	}
+}
+
+func newTestClient(t *testing.T, api *fakeAPI, timeout time.Duration) *client {
+	t.Helper()
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	c, err := NewClient(&Config{
+		Credentials:    Credentials{Email: "test@example.com", Password: "secret"},
+		CustomEndpoint: srv.URL,
+		Timeout:        timeout,
+		Retries:        2,
+		BackoffInitial: time.Millisecond,
+		BackoffMax:     time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c.(*client)
+}
+
+func TestGetRetriedOnUnavailable(t *testing.T) {
+	var calls atomic.Int32
+	api := &fakeAPI{handle: func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}}
+	c := newTestClient(t, api, time.Second)
+
+	_, err := c.ViewAssessments(context.Background())
+	var ce *ClientError
+	if !errors.As(err, &ce) {
+		t.Fatalf("expected ClientError, got %v", err)
+	}
+	if ce.Code != ErrViewAssessments || ce.HTTPStatus != http.StatusServiceUnavailable {
+		t.Errorf("unexpected error %+v", ce)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("expected 3 requests, got %d", got)
+	}
+	if ce.Attempts != 3 {
+		t.Errorf("expected 3 attempts reported, got %d", ce.Attempts)
+	}
+}
+
+func TestGetSucceedsAfterRetry(t *testing.T) {
+	var calls atomic.Int32
+	api := &fakeAPI{handle: func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"success":true,"data":[]}`))
+	}}
+	c := newTestClient(t, api, time.Second)
+
+	if _, err := c.ViewAssessments(context.Background()); err != nil {
+		t.Fatalf("ViewAssessments: %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected 2 requests, got %d", got)
+	}
+}
+
+func TestPostNotRetriedOnUnavailable(t *testing.T) {
+	var calls atomic.Int32
+	api := &fakeAPI{handle: func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}}
+	c := newTestClient(t, api, time.Second)
+
+	_, err := c.CancelValuation(context.Background(), "BK-1", "duplicate booking")
+	var ce *ClientError
+	if !errors.As(err, &ce) || ce.Code != ErrCancelValuation {
+		t.Fatalf("expected cancel error, got %v", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("expected a single request, got %d", got)
+	}
+}
+
+func TestTimeoutRetried(t *testing.T) {
+	var calls atomic.Int32
+	api := &fakeAPI{handle: func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		_, _ = w.Write([]byte("%PDF-1.4"))
+	}}
+	c := newTestClient(t, api, 100*time.Millisecond)
+	if err := c.Login(context.Background()); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	body, contentType, attempts, err := c.downloadReportCounted(context.Background(), "BK-1")
+	if err != nil {
+		t.Fatalf("DownloadReport: %v", err)
+	}
+	if string(body) != "%PDF-1.4" || contentType != "application/pdf" {
+		t.Errorf("unexpected report %q %q", body, contentType)
+	}
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestUnauthorizedRefreshesOnce(t *testing.T) {
+	var calls atomic.Int32
+	api := &fakeAPI{handle: func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Header.Get("Authorization") == "Bearer access-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"success":true,"data":[]}`))
+	}}
+	c := newTestClient(t, api, time.Second)
+
+	if _, err := c.ViewAssessments(context.Background()); err != nil {
+		t.Fatalf("ViewAssessments: %v", err)
+	}
+	if got := api.refreshes.Load(); got != 1 {
+		t.Errorf("expected 1 refresh, got %d", got)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected the request to be resent once, got %d requests", got)
+	}
+}
+
+func TestUnauthorizedAfterRefreshNotResent(t *testing.T) {
+	var calls atomic.Int32
+	api := &fakeAPI{handle: func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}}
+	c := newTestClient(t, api, time.Second)
+
+	_, err := c.ViewAssessments(context.Background())
+	var ce *ClientError
+	if !errors.As(err, &ce) || ce.HTTPStatus != http.StatusUnauthorized {
+		t.Fatalf("expected 401 error, got %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected 2 requests, got %d", got)
+	}
+	if ce.Attempts != 2 {
+		t.Errorf("expected 2 attempts reported, got %d", ce.Attempts)
+	}
+}
+
+func TestLoginDetachedFromCallerCancellation(t *testing.T) {
+	api := &fakeAPI{handle: func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success":true,"data":[]}`))
+	}}
+	c := newTestClient(t, api, time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// the login shared with concurrent callers completes although this caller gave up
+	if err := c.ensureAccessToken(ctx); err != nil {
+		t.Fatalf("ensureAccessToken: %v", err)
+	}
+	if !c.IsTokenValid() || api.logins.Load() != 1 {
+		t.Errorf("expected a cached token after a single login, got %d logins", api.logins.Load())
+	}
+}
+
+func TestRejectedRefreshFallsBackToLogin(t *testing.T) {
+	var calls atomic.Int32
+	api := &fakeAPI{handle: func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"success":true,"data":[]}`))
+	}}
+	c := newTestClient(t, api, time.Second)
+	if err := c.Login(context.Background()); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	c.setRefreshToken("refresh-expired", time.Hour)
+
+	if _, err := c.ViewAssessments(context.Background()); err != nil {
+		t.Fatalf("ViewAssessments: %v", err)
+	}
+	if got := api.logins.Load(); got != 2 {
+		t.Errorf("expected a second login, got %d logins", got)
+	}
+	if refresh, _ := c.refreshToken(); refresh != "refresh-1" {
+		t.Errorf("expected the refresh token of the login, got %q", refresh)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected the request to be resent once, got %d requests", got)
+	}
+}
+
+func TestConcurrentUnauthorizedSingleRenewal(t *testing.T) {
+	api := &fakeAPI{handle: func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer access-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"success":true,"data":[]}`))
+	}}
+	c := newTestClient(t, api, time.Second)
+	if err := c.Login(context.Background()); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.ViewAssessments(context.Background())
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("ViewAssessments: %v", err)
+		}
+	}
+	if got := api.refreshes.Load(); got != 1 {
+		t.Errorf("expected a single refresh, got %d", got)
+	}
+	if got := api.logins.Load(); got != 1 {
+		t.Errorf("expected a single login, got %d", got)
+	}
+}
+
+func TestClosedClientSendsNothing(t *testing.T) {
+	var calls atomic.Int32
+	api := &fakeAPI{handle: func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}}
+	c := newTestClient(t, api, time.Second)
+	_ = c.Close()
+
+	_, err := c.ViewAssessments(context.Background())
+	var ce *ClientError
+	if !errors.As(err, &ce) || ce.Code != ErrClientClosed {
+		t.Fatalf("expected closed error, got %v", err)
+	}
+	if calls.Load() != 0 || api.logins.Load() != 0 {
+		t.Error("closed client sent a request")
+	}
+}
+
+func TestRequestHeaders(t *testing.T) {
+	api := &fakeAPI{handle: func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+			t.Errorf("missing bearer token")
+		}
+		if r.Method == http.MethodGet && r.Header.Get("Content-Type") != "" {
+			t.Errorf("GET sent Content-Type %q", r.Header.Get("Content-Type"))
+		}
+		if r.Header.Get("Accept") != "application/json" {
+			t.Errorf("unexpected Accept %q", r.Header.Get("Accept"))
+		}
+		_, _ = w.Write([]byte(`{"success":true,"data":[]}`))
+	}}
+	c := newTestClient(t, api, time.Second)
+
+	if _, err := c.ViewAssessments(context.Background()); err != nil {
+		t.Fatalf("ViewAssessments: %v", err)
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	cfg := &Config{
+		Credentials:    Credentials{Email: "test@example.com", Password: "secret"},
+		BackoffInitial: 100 * time.Millisecond,
+		BackoffMax:     time.Second,
+		BackoffRand:    rand.New(rand.NewPCG(1, 2)).Float64,
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	for _, jitter := range []BackoffJitter{"", JitterFull, JitterEqual} {
+		cfg.BackoffJitter = jitter
+		seen := map[time.Duration]bool{}
+		for retry := 1; retry <= 8; retry++ {
+			for range 20 {
+				delay, ceiling := cfg.backoffDelay(retry), cfg.backoffCeiling(retry)
+				low := time.Duration(0)
+				if jitter == JitterEqual {
+					low = ceiling / 2
+				}
+				if delay < low || delay > ceiling || ceiling > cfg.BackoffMax {
+					t.Fatalf("%q retry %d: delay %v outside [%v, %v]", jitter, retry, delay, low, ceiling)
+				}
+				seen[delay] = true
+			}
+		}
+		if len(seen) < 2 {
+			t.Errorf("%q: every delay is identical", jitter)
+		}
+	}
+
+	cfg.BackoffJitter = JitterNone
+	if d := cfg.backoffDelay(3); d != 400*time.Millisecond {
+		t.Errorf("no jitter: expected 400ms, got %v", d)
+	}
+	if d := cfg.backoffDelay(10); d != time.Second {
+		t.Errorf("no jitter: expected the 1s cap, got %v", d)
+	}
+
+	cfg.BackoffJitter = "random"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an unknown jitter to fail validation")
+	}
+}
+
+func TestFileTokenStoreConcurrentWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	// separate stores open the lock file separately, like separate processes
+	stores := make([]*FileTokenStore, 2)
+	for i := range stores {
+		s, err := NewFileTokenStore(path)
+		if err != nil {
+			t.Fatalf("NewFileTokenStore: %v", err)
+		}
+		stores[i] = s
+	}
+
+	var wg sync.WaitGroup
+	for i := range 40 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stores[i%2].Set("key-"+strconv.Itoa(i), "token-"+strconv.Itoa(i), time.Hour)
+		}()
+	}
+	wg.Wait()
+
+	for i := range 40 {
+		if v, ok := stores[(i+1)%2].Get("key-" + strconv.Itoa(i)); !ok || v != "token-"+strconv.Itoa(i) {
+			t.Errorf("key-%d: got %q %v", i, v, ok)
+		}
+	}
+	leftovers, _ := filepath.Glob(path + ".*.tmp")
+	if len(leftovers) > 0 {
+		t.Errorf("temporary files left behind: %v", leftovers)
+	}
+
+	stores[0].Remove("key-0")
+	if _, ok := stores[1].Get("key-0"); ok {
+		t.Error("removed token still readable")
+	}
+}
+
+func TestDownloadReportEscapesBookingNo(t *testing.T) {
+	var paths []string
+	api := &fakeAPI{handle: func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		_, _ = w.Write([]byte("%PDF"))
+	}}
+	c := newTestClient(t, api, time.Second)
+
+	if _, _, err := c.DownloadReport(context.Background(), "../get-token"); err != nil {
+		t.Fatalf("DownloadReport: %v", err)
+	}
+	if len(paths) != 1 || paths[0] != "/download-pdf/..%2Fget-token" {
+		t.Errorf("expected the booking number to stay a single path segment, got %v", paths)
+	}
+	for _, bookingNo := range []string{"", "  "} {
+		var ce *ClientError
+		if _, _, err := c.DownloadReport(context.Background(), bookingNo); !errors.As(err, &ce) || ce.Code != ErrDownloadReport {
+			t.Errorf("expected blank booking number %q to be rejected, got %v", bookingNo, err)
+		}
+	}
+	if len(paths) != 1 {
+		t.Errorf("expected no request for blank booking numbers, got %v", paths)
+	}
+}
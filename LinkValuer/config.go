@@ -5,17 +5,63 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
+
+	ntlogger "github.com/nana-tec/gopackages/logger"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
 )
 
-// Environment for LinkValuer
-// Kept for parity; service exposes a single public endpoint but allow overrides
+// Environment names a LinkValuer deployment whose base URL is looked up in the
+// environment registry. Production, Staging and Sandbox are built in, more can be
+// added with RegisterEnvironment.
 type Environment string
 
 const (
+	// Production is the live LinkValuer portal
 	Production Environment = "production"
+	// Staging is the provider's pre-production portal
+	Staging Environment = "staging"
+	// Sandbox is the provider's integration testing portal
+	Sandbox Environment = "sandbox"
+)
+
+var (
+	environmentsMu sync.RWMutex
+	environments   = map[Environment]string{
+		Production: "https://portal.linksvaluers.com/api",
+		Staging:    "https://staging.linksvaluers.com/api",
+		Sandbox:    "https://sandbox.linksvaluers.com/api",
+	}
 )
 
+// RegisterEnvironment adds a named environment, or replaces the base URL of an existing
+// one, e.g. a provider-issued test portal or a local mock shared by several services.
+func RegisterEnvironment(name Environment, baseURL string) error {
+	if name == "" {
+		return fmt.Errorf("environment name is required")
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("invalid base URL %q for environment %s", baseURL, name)
+	}
+	environmentsMu.Lock()
+	defer environmentsMu.Unlock()
+	environments[name] = strings.TrimRight(baseURL, "/")
+	return nil
+}
+
+// EnvironmentURL returns the base URL registered for env
+func EnvironmentURL(env Environment) (string, bool) {
+	environmentsMu.RLock()
+	defer environmentsMu.RUnlock()
+	u, ok := environments[env]
+	return u, ok
+}
+
 // Credentials holds authentication info for LinkValuer
 // The API expects email and password for token generation
 type Credentials struct {
@@ -30,10 +76,43 @@ type Config struct {
 	CustomEndpoint     string
 	Timeout            time.Duration
 	InsecureSkipVerify bool
-	Debug              bool
+	Debug              bool            // Log requests to the standard logger when no Logger is set
+	Logger             ntlogger.Logger // Structured logger for debug entries, with tokens redacted and bodies truncated
 	Context            context.Context
 	TokenTTL           time.Duration // TTL for access token fallback if API doesn't provide expiry
-	Retries            int           // Number of retries on timeout (default 2)
+	Retries            int           // Number of retries on timeout, and on 502/503/504 for GETs (default 2)
+
+	// TokenStore, when set, holds the access and refresh tokens instead of a cache
+	// private to the client, e.g. a RedisTokenStore shared by every pod.
+	TokenStore TokenStore
+	// TokenKey prefixes the keys tokens are stored under, defaults to one derived
+	// from the credentials email so clients sharing a store never mix tokens.
+	TokenKey string
+
+	// Metrics, when set, records request latency, status codes and retries, see NewMetrics
+	Metrics *Metrics
+	// TracerProvider creates the spans of LinkValuer API calls. When nil the global
+	// OpenTelemetry provider is used, which records nothing unless one is registered.
+	TracerProvider trace.TracerProvider
+
+	// Client-side rate limiting, applied to every request including retries, token
+	// renewals, polling and bulk downloads so the provider's throttling is not tripped
+	RateLimit   float64       // Requests per second, 0 disables the limit
+	RateBurst   int           // Requests allowed at once above the rate (default 1)
+	RateLimiter *rate.Limiter // Limiter shared with other clients, overrides RateLimit and RateBurst
+
+	// Retry backoff
+	BackoffInitial    time.Duration  // Delay before the first retry (default 500ms)
+	BackoffMultiplier float64        // Growth factor of the delay between retries (default 2)
+	BackoffMax        time.Duration  // Upper bound of the delay between retries (default 10s)
+	BackoffJitter     BackoffJitter  // Randomization of the delay (default JitterFull)
+	BackoffRand       func() float64 // Source of the jitter in [0, 1), defaults to math/rand/v2
+
+	// Transport tuning
+	MaxIdleConns        int  // Maximum idle connections across all hosts (default 100)
+	MaxIdleConnsPerHost int  // Maximum idle connections kept per host (default 10)
+	DisableCompression  bool // Disable transparent gzip negotiation (enabled by default)
+	DisableHTTP2        bool // Force HTTP/1.1 even when the server supports HTTP/2
 }
 
 // Validate verifies minimal config
@@ -45,6 +124,11 @@ func (c *Config) Validate() error {
 		// Allow default production
 		c.Environment = Production
 	}
+	if c.CustomEndpoint == "" {
+		if _, ok := EnvironmentURL(c.Environment); !ok {
+			return fmt.Errorf("unknown environment %s, register it with RegisterEnvironment or set CustomEndpoint", c.Environment)
+		}
+	}
 	if c.Context == nil {
 		c.Context = context.Background()
 	}
@@ -57,24 +141,61 @@ func (c *Config) Validate() error {
 	if c.Retries == 0 {
 		c.Retries = 2
 	}
+	if c.BackoffInitial == 0 {
+		c.BackoffInitial = defaultBackoffInitial
+	}
+	if c.BackoffMultiplier < 1 {
+		c.BackoffMultiplier = defaultBackoffMultiplier
+	}
+	if c.BackoffMax == 0 {
+		c.BackoffMax = defaultBackoffMax
+	}
+	if c.BackoffMax < c.BackoffInitial {
+		c.BackoffMax = c.BackoffInitial
+	}
+	if err := c.BackoffJitter.validate(); err != nil {
+		return err
+	}
+	if c.MaxIdleConns == 0 {
+		c.MaxIdleConns = 100
+	}
+	if c.MaxIdleConnsPerHost == 0 {
+		c.MaxIdleConnsPerHost = 10
+	}
 	return nil
 }
 
-// GetEndpoint resolves base URL
+func (c *Config) tokenKey() string {
+	if c.TokenKey != "" {
+		return c.TokenKey
+	}
+	return "linkvaluer:" + c.Credentials.Email
+}
+
+// GetEndpoint resolves base URL. CustomEndpoint takes precedence over Environment,
+// unknown environments fall back to production.
 func (c *Config) GetEndpoint() string {
 	if c.CustomEndpoint != "" {
 		return c.CustomEndpoint
 	}
-	switch c.Environment {
-	case Production:
-		return "https://portal.linksvaluers.com/api"
-	default:
-		return "https://portal.linksvaluers.com/api"
+	if u, ok := EnvironmentURL(c.Environment); ok {
+		return u
 	}
+	u, _ := EnvironmentURL(Production)
+	return u
 }
 
-// NewHTTPClient returns an http.Client honoring TLS options
+// NewHTTPClient returns an http.Client honoring TLS and transport tuning options
 func (c *Config) NewHTTPClient() *http.Client {
-	transport := &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: c.InsecureSkipVerify}}
+	transport := defaultTransport()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: c.InsecureSkipVerify}
+	transport.MaxIdleConns = c.MaxIdleConns
+	transport.MaxIdleConnsPerHost = c.MaxIdleConnsPerHost
+	transport.DisableCompression = c.DisableCompression
+	if c.DisableHTTP2 {
+		transport.ForceAttemptHTTP2 = false
+		// a non-nil empty map disables the automatic HTTP/2 upgrade
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
 	return &http.Client{Timeout: c.Timeout, Transport: transport}
 }
@@ -0,0 +1,106 @@
+package linkvaluer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// decodeEnvelope normalizes the response shapes of the API into an APIResponse:
+//
+//	{"success": true, "message": "...", "data": {...}}  data object
+//	{"success": true, "data": [...]}                     data array
+//	{"message": "...", "token": "..."}                   top-level fields, Data is the whole object
+//	[...]                                                 bare array, Data is the array
+//
+// Success defaults to true when the body does not say otherwise, callers check the
+// HTTP status first. Raw always holds the complete body.
+func decodeEnvelope(body []byte) (*APIResponse, error) {
+	body = bytes.TrimSpace(body)
+	env := &APIResponse{Success: true, Raw: json.RawMessage(body)}
+	if len(body) == 0 {
+		return nil, errors.New("empty response body")
+	}
+	if body[0] == '[' {
+		env.Data = env.Raw
+		return env, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	if raw, ok := fields["success"]; ok {
+		env.Success = parseSuccess(raw)
+	}
+	if raw, ok := fields["message"]; ok {
+		_ = json.Unmarshal(raw, &env.Message)
+	}
+	if raw, ok := fields["data"]; ok && !isJSONNull(raw) {
+		env.Data = raw
+	} else {
+		env.Data = env.Raw
+	}
+	return env, nil
+}
+
+// parseSuccess accepts true, "true", 1 and "1" as success
+func parseSuccess(raw json.RawMessage) bool {
+	var b bool
+	if json.Unmarshal(raw, &b) == nil {
+		return b
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		b, _ = strconv.ParseBool(s)
+		return b
+	}
+	var n float64
+	if json.Unmarshal(raw, &n) == nil {
+		return n != 0
+	}
+	return false
+}
+
+func isJSONNull(raw json.RawMessage) bool {
+	return len(bytes.TrimSpace(raw)) == 0 || bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+}
+
+// decodeData unmarshals the data of the response into v
+func (r *APIResponse) decodeData(v any) error {
+	return json.Unmarshal(r.Data, v)
+}
+
+// listData returns the items of a list response and its pagination. Items are the data
+// array, or the nested data array of a paginator object ({"data": {"data": [...], "last_page": 3}}).
+// Pagination is read from a top-level "pagination" object or from the paginator fields.
+// Items are nil when the response carries no list.
+func (r *APIResponse) listData() (json.RawMessage, Pagination) {
+	var page Pagination
+	var top struct {
+		Pagination *Pagination `json:"pagination"`
+	}
+	if json.Unmarshal(r.Raw, &top) == nil && top.Pagination != nil {
+		page = *top.Pagination
+	}
+
+	data := bytes.TrimSpace(r.Data)
+	if len(data) > 0 && data[0] == '[' {
+		return data, page
+	}
+	if len(data) == 0 || data[0] != '{' {
+		return nil, page
+	}
+	var paginator struct {
+		Data json.RawMessage `json:"data"`
+		Pagination
+	}
+	if json.Unmarshal(data, &paginator) == nil && len(paginator.Data) > 0 && bytes.TrimSpace(paginator.Data)[0] == '[' {
+		if page == (Pagination{}) {
+			page = paginator.Pagination
+		}
+		return paginator.Data, page
+	}
+	return nil, page
+}
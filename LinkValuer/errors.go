@@ -1,6 +1,12 @@
 package linkvaluer
 
-import "fmt"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
 
 type ErrorType string
 
@@ -16,6 +22,8 @@ const (
 	ErrHTTPRequest        = 1004
 	ErrReadResponse       = 1005
 	ErrUnmarshalResponse  = 1007
+	ErrInvalidRequest     = 1008
+	ErrClientClosed       = 1009
 	ErrUnauthorized       = 2003
 	ErrInvalidCredentials = 2004
 	ErrTokenRefresh       = 2005
@@ -25,6 +33,16 @@ const (
 	ErrViewAssessments = 3100
 	ErrDownloadReport  = 3200
 	ErrViewAPIRequests = 3300
+
+	ErrUnknownStatus    = 3400
+	ErrStatusTransition = 3410
+
+	ErrWaitForCompletion = 3500
+	ErrBookingCancelled  = 3510
+
+	ErrCancelValuation = 3600
+
+	ErrExportAssessments = 3700
 )
 
 type ClientError struct {
@@ -33,6 +51,12 @@ type ClientError struct {
 	Message    string    `json:"message"`
 	Operation  string    `json:"operation,omitempty"`
 	HTTPStatus int       `json:"http_status,omitempty"`
+	Attempts   int       `json:"attempts,omitempty"` // Requests sent before giving up, 0 when no request was sent
+
+	// Validation holds the per-field messages when the API rejected the request body
+	Validation *ValidationError `json:"validation,omitempty"`
+
+	cause error // Underlying transport or read error
 }
 
 func (e *ClientError) Error() string {
@@ -42,6 +66,21 @@ func (e *ClientError) Error() string {
 	return fmt.Sprintf("linkvaluer error %d: %s", e.Code, e.Message)
 }
 
+// withAttempts records how many requests were sent for the operation
+func (e *ClientError) withAttempts(n int) *ClientError {
+	e.Attempts = n
+	return e
+}
+
+// Unwrap exposes the validation error, so errors.As finds a *ValidationError, or the
+// underlying transport error, so errors.Is(err, context.DeadlineExceeded) works
+func (e *ClientError) Unwrap() error {
+	if e.Validation != nil {
+		return e.Validation
+	}
+	return e.cause
+}
+
 func newInternalError(op string, code int, err error) *ClientError {
 	return &ClientError{Type: InternalError, Code: code, Message: err.Error(), Operation: op}
 }
@@ -49,3 +88,80 @@ func newInternalError(op string, code int, err error) *ClientError {
 func newExternalError(op string, code int, message string) *ClientError {
 	return &ClientError{Type: ExternalError, Code: code, Message: message, Operation: op}
 }
+
+// newHTTPError describes a non-success response, parsing the API error body into a
+// ValidationError when it carries field errors
+func newHTTPError(op string, code int, status int, body []byte) *ClientError {
+	ce := &ClientError{Type: ExternalError, Code: code, Message: fmt.Sprintf("HTTP %d: %s", status, string(body)), Operation: op, HTTPStatus: status}
+	if v := parseValidationError(body); v != nil {
+		ce.Message = fmt.Sprintf("HTTP %d: %s", status, v.Error())
+		ce.Validation = v
+	}
+	return ce
+}
+
+// ValidationError is the API's rejection of a request body:
+//
+//	{"success": false, "message": "The given data was invalid.", "errors": {"reg_no": ["The reg no field is required."]}}
+type ValidationError struct {
+	Message string              `json:"message"`
+	Fields  map[string][]string `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field := range e.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var b strings.Builder
+	b.WriteString(e.Message)
+	for _, field := range fields {
+		if b.Len() > 0 {
+			b.WriteString("; ")
+		}
+		fmt.Fprintf(&b, "%s: %s", field, strings.Join(e.Fields[field], ", "))
+	}
+	return b.String()
+}
+
+// parseValidationError returns nil when body is not the API error shape with field errors
+func parseValidationError(body []byte) *ValidationError {
+	var payload struct {
+		Message string                     `json:"message"`
+		Errors  map[string]json.RawMessage `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || len(payload.Errors) == 0 {
+		return nil
+	}
+	v := &ValidationError{Message: payload.Message, Fields: make(map[string][]string, len(payload.Errors))}
+	for field, raw := range payload.Errors {
+		// messages are usually a list, some endpoints send a single string
+		var msgs []string
+		if err := json.Unmarshal(raw, &msgs); err != nil {
+			var msg string
+			if err := json.Unmarshal(raw, &msg); err != nil {
+				continue
+			}
+			msgs = []string{msg}
+		}
+		v.Fields[field] = msgs
+	}
+	return v
+}
+
+// IsValidationError reports whether err carries per-field validation messages from the API
+func IsValidationError(err error) bool {
+	var v *ValidationError
+	return errors.As(err, &v)
+}
+
+// FieldErrors returns the per-field validation messages of err, nil when there are none
+func FieldErrors(err error) map[string][]string {
+	var v *ValidationError
+	if !errors.As(err, &v) {
+		return nil
+	}
+	return v.Fields
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -25,12 +26,13 @@ func main() {
 		Debug:       true,
 		TokenTTL:    6 * time.Hour,
 	}
+	ctx := context.Background()
 	c, err := linkvaluer.NewClient(cfg)
 	if err != nil {
 		log.Fatalf("new client: %v", err)
 	}
 
-	if err := c.Login(); err != nil {
+	if err := c.Login(ctx); err != nil {
 		log.Fatalf("login: %v", err)
 	}
 	fmt.Println("Login successful. Access token present:", c.IsTokenValid())
@@ -46,7 +48,7 @@ func main() {
 		CallBackURL:        "https://example.com/callback",
 		PartnerReference:   "PARTNER123",
 	}
-	resp, err := c.CreateValuation(createReq)
+	resp, err := c.CreateValuation(ctx, createReq)
 	if err != nil {
 		log.Printf("create valuation error: %v", err)
 	} else {
@@ -54,7 +56,7 @@ func main() {
 	}
 
 	// View API requests
-	apiRequests, err := c.ViewAPIRequests()
+	apiRequests, err := c.ViewAPIRequests(ctx)
 	if err != nil {
 		log.Printf("view api requests error: %v", err)
 	} else {
@@ -62,7 +64,7 @@ func main() {
 	}
 
 	// View assessments
-	assessments, err := c.ViewAssessments()
+	assessments, err := c.ViewAssessments(ctx)
 	if err != nil {
 		log.Printf("view assessments error: %v", err)
 	} else {
@@ -96,7 +98,7 @@ func main() {
 	// Download a report if you have a booking number
 	booking := dl_
 	if booking != "" {
-		bytes, ct, err := c.DownloadReport(booking)
+		bytes, ct, err := c.DownloadReport(ctx, booking)
 		if err != nil {
 			log.Printf("download report error: %v", err)
 		} else {
@@ -0,0 +1,96 @@
+package linkvaluer
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/nana-tec/gopackages/internal/xlsx"
+)
+
+// ExportFormat selects the file format of ExportAssessments
+type ExportFormat string
+
+const (
+	ExportCSV  ExportFormat = "csv"
+	ExportXLSX ExportFormat = "xlsx"
+)
+
+var assessmentExportHeader = []any{
+	"Booking No", "Registration", "Customer", "Policy No", "Make", "Model",
+	"Assessed Value", "Status", "Assessed On", "Completed On",
+}
+
+// rowWriter is the common interface of the export formats
+type rowWriter interface {
+	WriteRow(values ...any) error
+	Close() error
+}
+
+type csvRowWriter struct{ w *csv.Writer }
+
+func (c csvRowWriter) WriteRow(values ...any) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		if v != nil {
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	return c.w.Write(record)
+}
+
+func (c csvRowWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func newRowWriter(w io.Writer, format ExportFormat) (rowWriter, error) {
+	switch format {
+	case ExportCSV, "":
+		return csvRowWriter{w: csv.NewWriter(w)}, nil
+	case ExportXLSX:
+		return xlsx.NewWriter(w, "Assessments")
+	}
+	return nil, fmt.Errorf("unsupported export format %q", format)
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// ExportAssessments walks every page of assessments matching opts and writes a summary
+// row per booking (booking, registration, customer, policy, vehicle, assessed value,
+// status and dates) as CSV or XLSX, for reconciling valuation fees. Unknown statuses
+// are written as received.
+func (c *client) ExportAssessments(ctx context.Context, opts ViewAssessmentsOptions, w io.Writer, format ExportFormat) error {
+	rw, err := newRowWriter(w, format)
+	if err != nil {
+		return newInternalError("ExportAssessments", ErrExportAssessments, err)
+	}
+	if err := rw.WriteRow(assessmentExportHeader...); err != nil {
+		return newInternalError("ExportAssessments", ErrExportAssessments, err)
+	}
+	for item, err := range c.ViewAllAssessments(ctx, opts) {
+		if err != nil {
+			return err
+		}
+		var value any
+		if item.AssessedValue.IsSet() {
+			value = item.AssessedValue.Decimal()
+		}
+		if err := rw.WriteRow(
+			item.BookingNo, item.RegNo, item.Customer, item.PolicyNo, item.Make, item.Model,
+			value, item.Status.String(), derefString(item.AssessedOn), derefString(item.CompletedOn),
+		); err != nil {
+			return newInternalError("ExportAssessments", ErrExportAssessments, err)
+		}
+	}
+	if err := rw.Close(); err != nil {
+		return newInternalError("ExportAssessments", ErrExportAssessments, err)
+	}
+	return nil
+}
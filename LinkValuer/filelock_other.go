@@ -0,0 +1,15 @@
+//go:build !unix
+
+package linkvaluer
+
+import "os"
+
+// lockFile is a no-op without flock; FileTokenStore then only serializes the
+// goroutines of one process
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
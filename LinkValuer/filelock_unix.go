@@ -0,0 +1,22 @@
+//go:build unix
+
+package linkvaluer
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile blocks until it holds the exclusive advisory lock of f
+func lockFile(f *os.File) error {
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
+
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
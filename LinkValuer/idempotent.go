@@ -0,0 +1,62 @@
+package linkvaluer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// findAPIRequest returns the booking number of the API request created with partnerRef, "" when there is none
+func (c *client) findAPIRequest(ctx context.Context, partnerRef string) (string, error) {
+	requests, err := c.ViewAPIRequests(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, r := range requests.Data {
+		if ref := getString(r, "partner_reference"); ref != "" && strings.EqualFold(ref, partnerRef) {
+			if bookingNo := getString(r, "booking_no"); bookingNo != "" {
+				return bookingNo, nil
+			}
+			// the request exists but has no booking yet, creating another would duplicate it
+			return "", newExternalError("CreateValuationIdempotent", ErrCreateValuation, fmt.Sprintf("request %s exists without a booking number", partnerRef))
+		}
+	}
+	return "", nil
+}
+
+// CreateValuationIdempotent creates a valuation unless one was already requested with the
+// same PartnerReference, in which case the existing booking is returned and created is false.
+// Concurrent calls with the same reference in this process share a single lookup and
+// creation, so retry storms do not book (and bill) a vehicle twice.
+func (c *client) CreateValuationIdempotent(ctx context.Context, req *CreateRequest) (*CreateValuationPayload, bool, error) {
+	if req == nil || strings.TrimSpace(req.PartnerReference) == "" {
+		return nil, false, newInternalError("CreateValuationIdempotent", ErrInvalidRequest, errors.New("partner reference is required for idempotent creation"))
+	}
+	type result struct {
+		payload *CreateValuationPayload
+		created bool
+	}
+	v, err, _ := c.creates.Do(req.PartnerReference, func() (any, error) {
+		bookingNo, err := c.findAPIRequest(ctx, req.PartnerReference)
+		if err != nil {
+			return nil, err
+		}
+		if bookingNo != "" {
+			c.debugLog("valuation %s already requested as %s", req.PartnerReference, bookingNo)
+			out := &CreateValuationPayload{Success: true, Message: "existing valuation request"}
+			out.Data.BookingNo = bookingNo
+			return result{payload: out}, nil
+		}
+		out, err := c.CreateValuation(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return result{payload: out, created: true}, nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	r := v.(result)
+	return r.payload, r.created, nil
+}
@@ -0,0 +1,186 @@
+package linkvaluer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	ntlogger "github.com/nana-tec/gopackages/logger"
+)
+
+const (
+	// logCode is the code of every log entry written by the client
+	logCode = "LINKVALUER"
+
+	// maxLoggedBody is the number of body bytes kept in log entries
+	maxLoggedBody = 2048
+
+	redacted = "[REDACTED]"
+)
+
+// Log entry keys specific to the client
+const (
+	LogBookingNo ntlogger.ExtraKey = "BookingNo"
+	LogHeaders   ntlogger.ExtraKey = "Headers"
+	LogAttempt   ntlogger.ExtraKey = "Attempt"
+)
+
+type bookingNoKey struct{}
+
+// withBookingNo tags the log entries of the requests made with ctx with bookingNo
+func withBookingNo(ctx context.Context, bookingNo string) context.Context {
+	if bookingNo == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, bookingNoKey{}, bookingNo)
+}
+
+func bookingNoFrom(ctx context.Context) string {
+	s, _ := ctx.Value(bookingNoKey{}).(string)
+	return s
+}
+
+// logEnabled reports whether requests are logged, through Config.Logger or the Debug fallback
+func (c *client) logEnabled() bool {
+	return c.config.Logger != nil || c.config.Debug
+}
+
+// debugLog writes a debug message to Config.Logger, or to the standard logger when only Debug is set
+func (c *client) debugLog(format string, args ...any) {
+	switch {
+	case c.config.Logger != nil:
+		c.config.Logger.Debug(c.config.Context, logCode, fmt.Sprintf(format, args...), nil)
+	case c.config.Debug:
+		log.Printf("[LinkValuer] "+format, args...)
+	}
+}
+
+// logExchange writes a debug entry for a request and its response. Tokens and
+// passwords are redacted from the headers and JSON bodies, bodies are truncated.
+// The response body is read and replaced so the caller can still consume it.
+func (c *client) logExchange(req *http.Request, resp *http.Response, reqErr error, latency time.Duration) {
+	ctx := req.Context()
+	extra := map[ntlogger.ExtraKey]interface{}{
+		ntlogger.Method:  req.Method,
+		ntlogger.Path:    req.URL.Path,
+		ntlogger.Latency: latency.String(),
+		LogHeaders:       redactHeaders(req.Header),
+	}
+	bookingNo := bookingNoFrom(ctx)
+
+	if req.GetBody != nil {
+		if rc, err := req.GetBody(); err == nil {
+			body, _ := io.ReadAll(rc)
+			_ = rc.Close()
+			if len(body) > 0 {
+				extra[ntlogger.RequestBody] = redactBody(body, "")
+				bookingNo = firstNonEmpty(bookingNo, bookingNoOf(body))
+			}
+		}
+	}
+
+	msg := fmt.Sprintf("%s %s", req.Method, req.URL.Path)
+	if reqErr != nil {
+		extra[ntlogger.ErrorMessage] = reqErr.Error()
+		msg += " failed"
+	} else {
+		extra[ntlogger.StatusCode] = resp.StatusCode
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			extra[ntlogger.ErrorMessage] = err.Error()
+		}
+		extra[ntlogger.BodySize] = len(body)
+		extra[ntlogger.ResponseBody] = redactBody(body, resp.Header.Get("Content-Type"))
+		bookingNo = firstNonEmpty(bookingNo, bookingNoOf(body))
+		msg += fmt.Sprintf(" -> %d", resp.StatusCode)
+	}
+	if bookingNo != "" {
+		extra[LogBookingNo] = bookingNo
+	}
+
+	if c.config.Logger != nil {
+		c.config.Logger.Debug(ctx, logCode, msg, extra)
+		return
+	}
+	log.Printf("[LinkValuer] %s %v", msg, extra)
+}
+
+// redactHeaders returns the request headers with credentials masked
+func redactHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k := range h {
+		v := h.Get(k)
+		if strings.EqualFold(k, "Authorization") {
+			if scheme, _, ok := strings.Cut(v, " "); ok {
+				v = scheme + " " + redacted
+			} else {
+				v = redacted
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// redactBody renders a body for logging. JSON bodies have their token and password
+// fields masked, binary bodies such as PDF reports are only described.
+func redactBody(body []byte, contentType string) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if contentType != "" && !strings.Contains(contentType, "json") && !strings.HasPrefix(contentType, "text/") {
+		return fmt.Sprintf("<%d bytes %s>", len(body), contentType)
+	}
+	var v any
+	if err := json.Unmarshal(body, &v); err == nil {
+		if b, err := json.Marshal(redactValue(v)); err == nil {
+			body = b
+		}
+	}
+	if len(body) > maxLoggedBody {
+		return string(body[:maxLoggedBody]) + fmt.Sprintf("...(%d bytes truncated)", len(body)-maxLoggedBody)
+	}
+	return string(body)
+}
+
+func redactValue(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			key := strings.ToLower(k)
+			if strings.Contains(key, "token") || strings.Contains(key, "password") || strings.Contains(key, "secret") {
+				t[k] = redacted
+				continue
+			}
+			t[k] = redactValue(val)
+		}
+	case []any:
+		for i := range t {
+			t[i] = redactValue(t[i])
+		}
+	}
+	return v
+}
+
+// bookingNoOf returns the booking_no of a JSON body, at the top level or under data
+func bookingNoOf(body []byte) string {
+	var m map[string]any
+	if err := json.Unmarshal(body, &m); err != nil {
+		return ""
+	}
+	if s := getString(m, "booking_no"); s != "" {
+		return s
+	}
+	if d, ok := m["data"].(map[string]any); ok {
+		return getString(d, "booking_no")
+	}
+	return ""
+}
@@ -0,0 +1,87 @@
+package linkvaluer
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync/atomic"
+	"time"
+)
+
+// ConnectionStats reports how the client's HTTP connection pool is being used.
+type ConnectionStats struct {
+	NewConns           int64         // Connections dialed for a request
+	ReusedConns        int64         // Requests served on an existing connection
+	TLSHandshakes      int64         // Completed TLS handshakes
+	TLSHandshakeErrors int64         // Failed TLS handshakes
+	TLSHandshakeTotal  time.Duration // Total time spent in TLS handshakes
+	TLSHandshakeMax    time.Duration // Slowest TLS handshake observed
+}
+
+// AvgTLSHandshake returns the mean TLS handshake duration.
+func (s ConnectionStats) AvgTLSHandshake() time.Duration {
+	if s.TLSHandshakes == 0 {
+		return 0
+	}
+	return s.TLSHandshakeTotal / time.Duration(s.TLSHandshakes)
+}
+
+// ReuseRatio returns the share of requests that reused a pooled connection.
+func (s ConnectionStats) ReuseRatio() float64 {
+	total := s.NewConns + s.ReusedConns
+	if total == 0 {
+		return 0
+	}
+	return float64(s.ReusedConns) / float64(total)
+}
+
+type connMetrics struct {
+	newConns        atomic.Int64
+	reusedConns     atomic.Int64
+	handshakes      atomic.Int64
+	handshakeErrors atomic.Int64
+	handshakeTotal  atomic.Int64 // nanoseconds
+	handshakeMax    atomic.Int64 // nanoseconds
+}
+
+// withTrace attaches an httptrace.ClientTrace that feeds the metrics to ctx.
+func (m *connMetrics) withTrace(ctx context.Context) context.Context {
+	var handshakeStart time.Time
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				m.reusedConns.Add(1)
+			} else {
+				m.newConns.Add(1)
+			}
+		},
+		TLSHandshakeStart: func() { handshakeStart = time.Now() },
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err != nil {
+				m.handshakeErrors.Add(1)
+				return
+			}
+			d := int64(time.Since(handshakeStart))
+			m.handshakes.Add(1)
+			m.handshakeTotal.Add(d)
+			for {
+				max := m.handshakeMax.Load()
+				if d <= max || m.handshakeMax.CompareAndSwap(max, d) {
+					break
+				}
+			}
+		},
+	}
+	return httptrace.WithClientTrace(ctx, trace)
+}
+
+func (m *connMetrics) snapshot() ConnectionStats {
+	return ConnectionStats{
+		NewConns:           m.newConns.Load(),
+		ReusedConns:        m.reusedConns.Load(),
+		TLSHandshakes:      m.handshakes.Load(),
+		TLSHandshakeErrors: m.handshakeErrors.Load(),
+		TLSHandshakeTotal:  time.Duration(m.handshakeTotal.Load()),
+		TLSHandshakeMax:    time.Duration(m.handshakeMax.Load()),
+	}
+}
@@ -0,0 +1,58 @@
+package linkvaluer
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics are the optional Prometheus collectors of the client, set on Config.Metrics.
+// One Metrics can be shared by several clients.
+//
+//	linkvaluer_request_duration_seconds{endpoint, method, status}  latency of every HTTP request, status is "error" on transport failures
+//	linkvaluer_retries_total{endpoint, reason}                       retries after a "timeout" or a retryable "status"
+type Metrics struct {
+	requestDuration *prometheus.HistogramVec
+	retries         *prometheus.CounterVec
+}
+
+// NewMetrics creates the client collectors and registers them with reg,
+// prometheus.DefaultRegisterer when nil.
+func NewMetrics(reg prometheus.Registerer) (*Metrics, error) {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	m := &Metrics{
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "linkvaluer",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of LinkValuer API requests.",
+			Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"endpoint", "method", "status"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "linkvaluer",
+			Name:      "retries_total",
+			Help:      "LinkValuer API requests retried after a timeout or a retryable status.",
+		}, []string{"endpoint", "reason"}),
+	}
+	for _, c := range []prometheus.Collector{m.requestDuration, m.retries} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m *Metrics) observeRequest(endpoint, method, status string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.requestDuration.WithLabelValues(endpoint, method, status).Observe(d.Seconds())
+}
+
+func (m *Metrics) observeRetry(endpoint, reason string) {
+	if m == nil {
+		return
+	}
+	m.retries.WithLabelValues(endpoint, reason).Inc()
+}
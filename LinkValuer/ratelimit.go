@@ -0,0 +1,30 @@
+package linkvaluer
+
+import (
+	"context"
+
+	"golang.org/x/time/rate"
+)
+
+// newLimiter returns the limiter shared by every request of the client, nil when unlimited
+func (c *Config) newLimiter() *rate.Limiter {
+	if c.RateLimiter != nil {
+		return c.RateLimiter
+	}
+	if c.RateLimit <= 0 {
+		return nil
+	}
+	burst := c.RateBurst
+	if burst <= 0 {
+		burst = 1
+	}
+	return rate.NewLimiter(rate.Limit(c.RateLimit), burst)
+}
+
+// waitRate blocks until the rate limiter admits another request or ctx is done
+func (c *client) waitRate(ctx context.Context) error {
+	if c.limiter == nil {
+		return nil
+	}
+	return c.limiter.Wait(ctx)
+}
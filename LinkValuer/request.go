@@ -0,0 +1,154 @@
+package linkvaluer
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// authMode selects the bearer token sent with a request
+type authMode int
+
+const (
+	authNone    authMode = iota // No Authorization header, e.g. login
+	authAccess                  // Access token, logging in first when none is cached; a 401 renews it once
+	authRefresh                 // Refresh token, for the token refresh endpoint
+)
+
+// apiRequest describes one API call made through doRequest
+type apiRequest struct {
+	op       string // Operation named in errors
+	method   string
+	endpoint string // Path below the base URL, may carry a query string
+	route    string // Metrics and span label, defaults to endpoint without its query
+	body     []byte // JSON body, nil for none
+	accept   string // Accept header, defaults to application/json
+	auth     authMode
+}
+
+// apiResponse is a fully read API response
+type apiResponse struct {
+	status   int
+	header   http.Header
+	body     []byte
+	attempts int // Requests sent, retries and the 401 resend included
+}
+
+// doRequest sends r with the shared plumbing of every API call: the client span,
+// authentication, per-attempt timeouts, retries with backoff on timeouts (and on
+// 502/503/504 for GETs) and a single token renewal when the access token is rejected.
+// Non-success statuses are returned as responses, the caller maps them to its error code.
+func (c *client) doRequest(ctx context.Context, r apiRequest) (*apiResponse, error) {
+	if err := c.checkOpen(r.op); err != nil {
+		return nil, err
+	}
+	if r.route == "" {
+		r.route = routeOf(r.endpoint)
+	}
+	if r.accept == "" {
+		r.accept = "application/json"
+	}
+
+	parent := c.callContext(ctx)
+	var attrs []attribute.KeyValue
+	if bookingNo := bookingNoFrom(parent); bookingNo != "" {
+		attrs = append(attrs, attribute.String("linkvaluer.booking_no", bookingNo))
+	}
+	parent, span := c.startSpan(parent, r.method, r.route, attrs...)
+	resp, err := c.sendWithRetries(parent, r)
+	if resp != nil {
+		span.SetAttributes(attribute.Int("linkvaluer.attempts", resp.attempts))
+	}
+	endSpan(span, err)
+	return resp, err
+}
+
+func (c *client) sendWithRetries(parent context.Context, r apiRequest) (*apiResponse, error) {
+	if r.auth == authAccess {
+		if err := c.ensureAccessToken(parent); err != nil {
+			return nil, err
+		}
+	}
+
+	retries := max(c.config.Retries, 0)
+	attempts := 0
+	renewed := false
+	// every path of the last attempt (attempt == retries) returns
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			if err := c.waitBackoff(parent, r.op, attempt); err != nil {
+				return nil, newExternalError(r.op, ErrHTTPRequest, err.Error()).withAttempts(attempts)
+			}
+		}
+		attempts++
+		resp, epoch, err := c.send(parent, r)
+		if err == nil && resp.status == http.StatusUnauthorized && r.auth == authAccess && !renewed {
+			renewed = true
+			if err := c.renewToken(parent, epoch); err != nil {
+				return nil, err
+			}
+			attempts++
+			resp, _, err = c.send(parent, r)
+		}
+		if err != nil {
+			if isTimeoutErr(err.cause) && attempt < retries {
+				c.debugLog("%s attempt %d timed out; retrying", r.op, attempts)
+				c.observeRetry(r.route, "timeout")
+				continue
+			}
+			return nil, err.withAttempts(attempts)
+		}
+		if isRetryableStatus(r.method, resp.status) && attempt < retries {
+			c.debugLog("%s attempt %d got HTTP %d; retrying", r.op, attempts, resp.status)
+			c.observeRetry(r.route, "status")
+			continue
+		}
+		resp.attempts = attempts
+		return resp, nil
+	}
+}
+
+// send makes a single attempt bounded by the request timeout and reads the whole
+// response. It returns the token epoch the request was authenticated with.
+func (c *client) send(parent context.Context, r apiRequest) (*apiResponse, uint64, *ClientError) {
+	ctx, cancel := context.WithTimeout(parent, c.requestTimeout())
+	defer cancel()
+
+	var body io.Reader
+	if r.body != nil {
+		body = bytes.NewReader(r.body)
+	}
+	req, err := c.newRequest(ctx, r.method, c.endpoint+ensureLeadingSlash(r.endpoint), body)
+	if err != nil {
+		return nil, 0, newInternalError(r.op, ErrCreateRequest, err)
+	}
+	req.Header.Set("Accept", r.accept)
+	if r.body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	var epoch uint64
+	switch r.auth {
+	case authAccess:
+		var token string
+		token, epoch = c.currentToken()
+		req.Header.Set("Authorization", "Bearer "+token)
+	case authRefresh:
+		refresh, _ := c.refreshToken()
+		req.Header.Set("Authorization", "Bearer "+refresh)
+	}
+
+	resp, err := c.do(req, r.route)
+	if err != nil {
+		return nil, epoch, &ClientError{Type: ExternalError, Code: ErrHTTPRequest, Message: err.Error(), Operation: r.op, cause: err}
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, epoch, &ClientError{Type: InternalError, Code: ErrReadResponse, Message: err.Error(), Operation: r.op, cause: err}
+	}
+	return &apiResponse{status: resp.StatusCode, header: resp.Header, body: data}, epoch, nil
+}
@@ -0,0 +1,145 @@
+package linkvaluer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// BookingStatus is the lifecycle state of a valuation booking:
+//
+//	requested → scheduled → assessed → completed
+//
+// A booking can be cancelled until it is assessed. Completed and cancelled are terminal.
+type BookingStatus string
+
+const (
+	StatusRequested BookingStatus = "requested"
+	StatusScheduled BookingStatus = "scheduled"
+	StatusAssessed  BookingStatus = "assessed"
+	StatusCompleted BookingStatus = "completed"
+	StatusCancelled BookingStatus = "cancelled"
+)
+
+// AssessmentStatus is the status of an assessment in the listing and the callbacks.
+// It is the booking lifecycle status; the "pending" the API reports for bookings
+// not scheduled yet is StatusPending.
+type AssessmentStatus = BookingStatus
+
+// StatusPending is the API name of StatusRequested
+const StatusPending = StatusRequested
+
+// statusAliases maps the normalized status strings seen from the provider to booking statuses
+var statusAliases = map[string]BookingStatus{
+	"requested":   StatusRequested,
+	"new":         StatusRequested,
+	"pending":     StatusRequested,
+	"booked":      StatusRequested,
+	"scheduled":   StatusScheduled,
+	"assigned":    StatusScheduled,
+	"in progress": StatusScheduled,
+	"assessed":    StatusAssessed,
+	"inspected":   StatusAssessed,
+	"completed":   StatusCompleted,
+	"complete":    StatusCompleted,
+	"done":        StatusCompleted,
+	"cancelled":   StatusCancelled,
+	"canceled":    StatusCancelled,
+}
+
+// bookingTransitions lists the statuses a booking may move to from each non-terminal status
+var bookingTransitions = map[BookingStatus][]BookingStatus{
+	StatusRequested: {StatusScheduled, StatusCancelled},
+	StatusScheduled: {StatusAssessed, StatusCancelled},
+	StatusAssessed:  {StatusCompleted},
+}
+
+// ParseStatus normalizes a provider status string, ignoring case, surrounding
+// whitespace and "_"/"-" separators, e.g. "Completed", " IN_PROGRESS " and "canceled".
+func ParseStatus(s string) (BookingStatus, error) {
+	key := strings.ToLower(strings.TrimSpace(s))
+	key = strings.Join(strings.FieldsFunc(key, func(r rune) bool {
+		return r == '_' || r == '-' || r == ' '
+	}), " ")
+	if status, ok := statusAliases[key]; ok {
+		return status, nil
+	}
+	return "", newInternalError("ParseStatus", ErrUnknownStatus, fmt.Errorf("unknown booking status %q", s))
+}
+
+func (s BookingStatus) IsValid() bool {
+	switch s {
+	case StatusRequested, StatusScheduled, StatusAssessed, StatusCompleted, StatusCancelled:
+		return true
+	}
+	return false
+}
+
+func (s BookingStatus) String() string {
+	return string(s)
+}
+
+// IsTerminal reports whether the booking can no longer change status
+func (s BookingStatus) IsTerminal() bool {
+	return s == StatusCompleted || s == StatusCancelled
+}
+
+// CanTransitionTo reports whether a booking in status s may move to next.
+// Repeating the current status is allowed as providers resend callbacks.
+func (s BookingStatus) CanTransitionTo(next BookingStatus) bool {
+	if s == next {
+		return s.IsValid()
+	}
+	for _, allowed := range bookingTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTransition returns an error when a booking cannot move from one status to the other
+func ValidateTransition(from, to BookingStatus) error {
+	if !from.CanTransitionTo(to) {
+		return newInternalError("ValidateTransition", ErrStatusTransition, fmt.Errorf("invalid booking status transition from %q to %q", from, to))
+	}
+	return nil
+}
+
+// UnmarshalJSON normalizes the provider status with ParseStatus. Unknown statuses
+// are kept as received, IsValid then reports false.
+func (s *BookingStatus) UnmarshalJSON(data []byte) error {
+	var raw *string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw == nil {
+		*s = ""
+		return nil
+	}
+	status, err := ParseStatus(*raw)
+	if err != nil {
+		*s = BookingStatus(*raw)
+		return nil
+	}
+	*s = status
+	return nil
+}
+
+// checkStatus returns s, or an ErrUnknownStatus error when the provider sent a status ParseStatus does not know
+func checkStatus(s BookingStatus) (BookingStatus, error) {
+	if !s.IsValid() {
+		return "", newInternalError("ParseStatus", ErrUnknownStatus, fmt.Errorf("unknown booking status %q", string(s)))
+	}
+	return s, nil
+}
+
+// BookingStatus returns the status of the assessment, an error when it is unknown
+func (a AssessmentItem) BookingStatus() (BookingStatus, error) {
+	return checkStatus(a.Status)
+}
+
+// BookingStatus returns the status reported by the callback, an error when it is unknown
+func (c CallbackResponse) BookingStatus() (BookingStatus, error) {
+	return checkStatus(c.Status)
+}
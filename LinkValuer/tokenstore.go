@@ -0,0 +1,209 @@
+package linkvaluer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// TokenStore holds the access and refresh tokens with their expirations.
+// The default is an in-memory cache private to the client; use a shared store,
+// e.g. RedisTokenStore, so every instance of a service reuses the same login.
+// A store that cannot be read should report the token as not found, the client
+// then logs in again.
+type TokenStore interface {
+	// Set stores a token under key until ttl elapses
+	Set(key string, value string, ttl time.Duration)
+
+	// Get returns the token stored under key, false when missing or expired
+	Get(key string) (string, bool)
+
+	// Remove deletes the token stored under key
+	Remove(key string)
+}
+
+// RedisClient is the subset of a Redis client used by RedisTokenStore. Adapting
+// go-redis takes one line per method:
+//
+//	func (a adapter) Get(ctx context.Context, key string) (string, error) { return a.rdb.Get(ctx, key).Result() }
+//	func (a adapter) Set(ctx context.Context, key, value string, ttl time.Duration) error { return a.rdb.Set(ctx, key, value, ttl).Err() }
+//	func (a adapter) Del(ctx context.Context, key string) error { return a.rdb.Del(ctx, key).Err() }
+type RedisClient interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value string, ttl time.Duration) error
+	Del(ctx context.Context, key string) error
+}
+
+// RedisTokenStore keeps tokens in Redis, relying on the key expiry for the token TTL
+type RedisTokenStore struct {
+	Client  RedisClient
+	Timeout time.Duration // Bound of each Redis call, defaults to 2s
+}
+
+// NewRedisTokenStore returns a token store backed by rdb
+func NewRedisTokenStore(rdb RedisClient) *RedisTokenStore {
+	return &RedisTokenStore{Client: rdb}
+}
+
+func (s *RedisTokenStore) context() (context.Context, context.CancelFunc) {
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+func (s *RedisTokenStore) Set(key string, value string, ttl time.Duration) {
+	ctx, cancel := s.context()
+	defer cancel()
+	if err := s.Client.Set(ctx, key, value, ttl); err != nil {
+		log.Printf("[LinkValuer] token store: set %s: %v", key, err)
+	}
+}
+
+func (s *RedisTokenStore) Get(key string) (string, bool) {
+	ctx, cancel := s.context()
+	defer cancel()
+	v, err := s.Client.Get(ctx, key)
+	if err != nil || v == "" {
+		return "", false
+	}
+	return v, true
+}
+
+func (s *RedisTokenStore) Remove(key string) {
+	ctx, cancel := s.context()
+	defer cancel()
+	if err := s.Client.Del(ctx, key); err != nil {
+		log.Printf("[LinkValuer] token store: remove %s: %v", key, err)
+	}
+}
+
+type storedToken struct {
+	Value     string    `json:"value"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// FileTokenStore keeps tokens in a JSON file, e.g. on a volume shared by the
+// processes of one host, so restarts do not log in again. The file is replaced
+// atomically and readable by the owner only. Updates hold an advisory lock on the
+// file path + ".lock", so processes sharing the file do not lose each other's tokens;
+// on systems without flock, such as Windows, only the goroutines of one process are
+// serialized.
+type FileTokenStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileTokenStore returns a token store writing to path, creating its directory when missing
+func NewFileTokenStore(path string) (*FileTokenStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	return &FileTokenStore{path: path}, nil
+}
+
+func (s *FileTokenStore) load() map[string]storedToken {
+	tokens := map[string]storedToken{}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("[LinkValuer] token store: read %s: %v", s.path, err)
+		}
+		return tokens
+	}
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		log.Printf("[LinkValuer] token store: parse %s: %v", s.path, err)
+	}
+	return tokens
+}
+
+func (s *FileTokenStore) save(tokens map[string]storedToken) {
+	now := time.Now()
+	for k, t := range tokens {
+		if now.After(t.ExpiresAt) {
+			delete(tokens, k)
+		}
+	}
+	data, err := json.Marshal(tokens)
+	if err == nil {
+		err = s.replace(data)
+	}
+	if err != nil {
+		log.Printf("[LinkValuer] token store: write %s: %v", s.path, err)
+	}
+}
+
+// replace writes data to a temporary file of its own, created by the owner only, and
+// renames it over the store file
+func (s *FileTokenStore) replace(data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// lock serializes the read-modify-write of the file across goroutines and, where
+// flock is available, processes. It returns the release.
+func (s *FileTokenStore) lock() func() {
+	s.mu.Lock()
+	f, err := os.OpenFile(s.path+".lock", os.O_CREATE|os.O_RDWR, 0o600)
+	if err == nil {
+		if err = lockFile(f); err != nil {
+			f.Close()
+		}
+	}
+	if err != nil {
+		// still serialized within the process
+		log.Printf("[LinkValuer] token store: lock %s: %v", s.path, err)
+		return s.mu.Unlock
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+		s.mu.Unlock()
+	}
+}
+
+func (s *FileTokenStore) Set(key string, value string, ttl time.Duration) {
+	defer s.lock()()
+	tokens := s.load()
+	tokens[key] = storedToken{Value: value, ExpiresAt: time.Now().Add(ttl)}
+	s.save(tokens)
+}
+
+// Get reads without the file lock: the file is only ever replaced whole
+func (s *FileTokenStore) Get(key string) (string, bool) {
+	t, ok := s.load()[key]
+	if !ok || time.Now().After(t.ExpiresAt) {
+		return "", false
+	}
+	return t.Value, true
+}
+
+func (s *FileTokenStore) Remove(key string) {
+	defer s.lock()()
+	tokens := s.load()
+	if _, ok := tokens[key]; !ok {
+		return
+	}
+	delete(tokens, key)
+	s.save(tokens)
+}
@@ -0,0 +1,88 @@
+package linkvaluer
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the spans created by the client.
+const tracerName = "github.com/nana-tec/gopackages/linkvaluer"
+
+// newTracer returns the tracer of the given provider, falling back to the global
+// provider which is a no-op unless the application registers one.
+func newTracer(tp trace.TracerProvider) trace.Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(tracerName)
+}
+
+// routeOf returns the endpoint without its query string, the label used for metrics and spans
+func routeOf(endpoint string) string {
+	if i := strings.IndexByte(endpoint, '?'); i >= 0 {
+		endpoint = endpoint[:i]
+	}
+	return ensureLeadingSlash(endpoint)
+}
+
+// startSpan starts the client span of a LinkValuer API call.
+func (c *client) startSpan(ctx context.Context, method, route string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs,
+		attribute.String("linkvaluer.endpoint", route),
+		attribute.String("http.request.method", method),
+	)
+	return c.tracer.Start(ctx, "linkvaluer "+method+" "+route,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// endSpan records the outcome of the call on the span and ends it.
+func endSpan(span trace.Span, err error) {
+	defer span.End()
+	if err == nil {
+		span.SetStatus(codes.Ok, "")
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// do sends req once the rate limiter admits it, recording its latency and status in the metrics, on the span of the
+// request context and, when enabled, in the debug log.
+func (c *client) do(req *http.Request, route string) (*http.Response, error) {
+	if err := c.waitRate(req.Context()); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	latency := time.Since(start)
+	c.config.Metrics.observeRequest(route, req.Method, status, latency)
+	if c.logEnabled() {
+		c.logExchange(req, resp, err, latency)
+	}
+
+	span := trace.SpanFromContext(req.Context())
+	span.AddEvent("http.attempt", trace.WithAttributes(attribute.String("http.response.status", status)))
+	if err == nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	}
+	return resp, err
+}
+
+// observeRetry counts a retry of route, reason is "timeout" or "status"
+func (c *client) observeRetry(route, reason string) {
+	c.config.Metrics.observeRetry(route, reason)
+}
@@ -13,14 +13,31 @@ type item[V any] struct {
 func (i item[V]) isExpired() bool { return time.Now().After(i.expiry) }
 
 type TTLCache[K comparable, V any] struct {
-	items map[K]item[V]
-	mu    sync.Mutex
+	items     map[K]item[V]
+	mu        sync.Mutex
+	stop      chan struct{} // Closed to stop the cleanup goroutine
+	closeOnce sync.Once     // Guards closing stop
 }
 
+// NewTTL returns a cache whose expired items are removed every ttl by a goroutine.
+// Call Close to stop the goroutine once the cache is no longer used.
 func NewTTL[K comparable, V any](ttl time.Duration) *TTLCache[K, V] {
-	c := &TTLCache[K, V]{items: make(map[K]item[V])}
+	c := &TTLCache[K, V]{items: make(map[K]item[V]), stop: make(chan struct{})}
 	go func() {
-		for range time.Tick(ttl) {
+		// without a positive ttl there is nothing to clean up periodically,
+		// tick stays nil and the goroutine only waits for Close
+		var tick <-chan time.Time
+		if ttl > 0 {
+			ticker := time.NewTicker(ttl)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		for {
+			select {
+			case <-c.stop:
+				return
+			case <-tick:
+			}
 			c.mu.Lock()
 			for k, it := range c.items {
 				if it.isExpired() {
@@ -33,6 +50,14 @@ func NewTTL[K comparable, V any](ttl time.Duration) *TTLCache[K, V] {
 	return c
 }
 
+// Close stops the cleanup goroutine. The cache remains usable, expired items are
+// then only removed when accessed. Close is safe to call more than once.
+func (c *TTLCache[K, V]) Close() {
+	c.closeOnce.Do(func() {
+		close(c.stop)
+	})
+}
+
 func (c *TTLCache[K, V]) Set(key K, value V, ttl time.Duration) {
 	c.mu.Lock()
 	c.items[key] = item[V]{value: value, expiry: time.Now().Add(ttl)}
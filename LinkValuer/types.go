@@ -22,7 +22,7 @@ type CreateRequest struct {
 	PartnerReference   string `json:"partner_reference,omitempty"`
 }
 
-// Generic API response wrappers (kept for internal use only)
+// Generic API response wrappers, every response is normalized into APIResponse before decoding
 
 type APIResponse struct {
 	Success bool            `json:"success,omitempty"`
@@ -50,20 +50,20 @@ type APIResponse struct {
 //	 "radio_value": "25000"
 //	}
 type CallbackResponse struct {
-	BookingNo        string  `json:"booking_no"`
-	Status           string  `json:"status"`
-	AssessmentID     int     `json:"assessment_id"`
-	RegNo            string  `json:"reg_no"`
-	CompletionDate   string  `json:"completion_date"`
-	PdfUrl           string  `json:"pdf_url"`
-	PartnerReference string  `json:"partner_reference"`
-	CustomerName     string  `json:"customer_name"`
-	InsuranceCompany string  `json:"insurance_company"`
-	PolicyNumber     string  `json:"policy_number"`
-	MarketValue      float64 `json:"market_value"`
-	DutyFreeValue    float64 `json:"duty_free_value"`
-	WindscreenValue  float64 `json:"windscreen_value"`
-	RadioValue       float64 `json:"radio_value"`
+	BookingNo        string           `json:"booking_no"`
+	Status           AssessmentStatus `json:"status"`
+	AssessmentID     int              `json:"assessment_id"`
+	RegNo            string           `json:"reg_no"`
+	CompletionDate   string           `json:"completion_date"`
+	PdfUrl           string           `json:"pdf_url"`
+	PartnerReference string           `json:"partner_reference"`
+	CustomerName     string           `json:"customer_name"`
+	InsuranceCompany string           `json:"insurance_company"`
+	PolicyNumber     string           `json:"policy_number"`
+	MarketValue      FlexibleAmount   `json:"market_value"`
+	DutyFreeValue    FlexibleAmount   `json:"duty_free_value"`
+	WindscreenValue  FlexibleAmount   `json:"windscreen_value"`
+	RadioValue       FlexibleAmount   `json:"radio_value"`
 }
 
 // CreateValuationPayload is a typed response for CreateValuation
@@ -90,30 +90,30 @@ type AssessmentsResponse = APIResponse
 // Assessments models
 
 type AssessmentItem struct {
-	BookingNo        string  `json:"booking_no"`
-	RegNo            string  `json:"reg_no"`
-	Customer         string  `json:"customer"`
-	ChassisNumber    string  `json:"chassis_number"`
-	EngineNumber     string  `json:"engine_number"`
-	EngineCapacity   string  `json:"engine_capacity"`
-	Odometer         string  `json:"odometer"`
-	AssessedValue    string  `json:"assessed_value"`
-	PolicyNo         string  `json:"policy_no"`
-	ManufactureYear  string  `json:"manufacture_year"`
-	RegDate          string  `json:"reg_date"`
-	Colour           string  `json:"colour"`
-	TyreCondition    string  `json:"tyre_condition"`
-	MechanicalCond   string  `json:"mechanical_condition"`
-	ElectricalSystem string  `json:"electrical_system"`
-	GeneralCondition string  `json:"general_condition"`
-	Extras           string  `json:"extras"`
-	Country          string  `json:"country"`
-	Make             string  `json:"make"`
-	Model            string  `json:"model"`
-	Status           string  `json:"status"`
-	DownloadURL      *string `json:"download_url"`
-	CompletedOn      *string `json:"completed_on"`
-	AssessedOn       *string `json:"assessed_on"`
+	BookingNo        string           `json:"booking_no"`
+	RegNo            string           `json:"reg_no"`
+	Customer         string           `json:"customer"`
+	ChassisNumber    string           `json:"chassis_number"`
+	EngineNumber     string           `json:"engine_number"`
+	EngineCapacity   string           `json:"engine_capacity"`
+	Odometer         string           `json:"odometer"`
+	AssessedValue    FlexibleAmount   `json:"assessed_value"`
+	PolicyNo         string           `json:"policy_no"`
+	ManufactureYear  string           `json:"manufacture_year"`
+	RegDate          string           `json:"reg_date"`
+	Colour           string           `json:"colour"`
+	TyreCondition    string           `json:"tyre_condition"`
+	MechanicalCond   string           `json:"mechanical_condition"`
+	ElectricalSystem string           `json:"electrical_system"`
+	GeneralCondition string           `json:"general_condition"`
+	Extras           string           `json:"extras"`
+	Country          string           `json:"country"`
+	Make             string           `json:"make"`
+	Model            string           `json:"model"`
+	Status           AssessmentStatus `json:"status"`
+	DownloadURL      *string          `json:"download_url"`
+	CompletedOn      *string          `json:"completed_on"`
+	AssessedOn       *string          `json:"assessed_on"`
 }
 
 type Pagination struct {
@@ -128,10 +128,19 @@ type AssessmentsPayload struct {
 	Pagination Pagination       `json:"pagination"`
 }
 
-// DecodeAssessments decodes the full assessments response body into AssessmentsPayload
+// DecodeAssessments decodes the full assessments response body into AssessmentsPayload,
+// whether the items are the data array or nested in a paginator object
 func DecodeAssessments(raw json.RawMessage) (*AssessmentsPayload, error) {
-	var p AssessmentsPayload
-	if err := json.Unmarshal(raw, &p); err != nil {
+	env, err := decodeEnvelope(raw)
+	if err != nil {
+		return nil, err
+	}
+	items, page := env.listData()
+	p := AssessmentsPayload{Pagination: page}
+	if items == nil {
+		return &p, nil
+	}
+	if err := json.Unmarshal(items, &p.Data); err != nil {
 		return nil, err
 	}
 	return &p, nil
@@ -0,0 +1,81 @@
+package linkvaluer
+
+import (
+	"errors"
+	"net/mail"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidPhone is returned by NormalizePhone for numbers that are not Kenyan mobile numbers
+var ErrInvalidPhone = errors.New("invalid phone number, expected a Kenyan mobile number such as 0712345678 or 254712345678")
+
+// NormalizePhone converts a Kenyan mobile number written as 0712 345 678, +254-712-345678,
+// 712345678 or 254712345678 to the 2547XXXXXXXX (or 2541XXXXXXXX) form the API expects.
+func NormalizePhone(phone string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '(', ')', '.':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(phone))
+	digits = strings.TrimPrefix(digits, "+")
+
+	switch {
+	case len(digits) == 10 && digits[0] == '0':
+		digits = "254" + digits[1:]
+	case len(digits) == 9:
+		digits = "254" + digits
+	}
+	if len(digits) != 12 || !strings.HasPrefix(digits, "254") || (digits[3] != '7' && digits[3] != '1') {
+		return "", ErrInvalidPhone
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "", ErrInvalidPhone
+		}
+	}
+	return digits, nil
+}
+
+// Validate checks the request before any HTTP call is made and normalizes
+// CustomerPhone in place. Problems are returned together as a *ValidationError
+// keyed by the JSON field names, the same shape as API-side validation errors.
+func (r *CreateRequest) Validate() error {
+	v := &ValidationError{Message: "invalid valuation request", Fields: map[string][]string{}}
+	add := func(field, msg string) { v.Fields[field] = append(v.Fields[field], msg) }
+
+	if strings.TrimSpace(r.CustomerName) == "" {
+		add("customer_name", "customer name is required")
+	}
+	if strings.TrimSpace(r.RegistrationNumber) == "" {
+		add("registration_number", "registration number is required")
+	}
+	if strings.TrimSpace(r.CustomerPhone) == "" {
+		add("customer_phone", "customer phone is required")
+	} else if phone, err := NormalizePhone(r.CustomerPhone); err != nil {
+		add("customer_phone", err.Error())
+	} else {
+		r.CustomerPhone = phone
+	}
+	if email := strings.TrimSpace(r.CustomerEmail); email != "" {
+		if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+			add("customer_email", "customer email is not a valid address")
+		}
+	}
+	if r.CallBackURL != "" {
+		u, err := url.Parse(r.CallBackURL)
+		switch {
+		case err != nil || u.Host == "":
+			add("callback_url", "callback url is not a valid url")
+		case u.Scheme != "https":
+			add("callback_url", "callback url must use https")
+		}
+	}
+
+	if len(v.Fields) > 0 {
+		return v
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package linkvaluer
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const defaultWaitInterval = 30 * time.Second
+
+// findAssessment walks the assessments looking for bookingNo, returning nil when it is not listed yet
+func (c *client) findAssessment(ctx context.Context, bookingNo string) (*AssessmentItem, error) {
+	for item, err := range c.ViewAllAssessments(ctx, ViewAssessmentsOptions{}) {
+		if err != nil {
+			return nil, err
+		}
+		if item.BookingNo == bookingNo {
+			return &item, nil
+		}
+	}
+	return nil, nil
+}
+
+// WaitForCompletion polls the assessments every interval until the booking is completed,
+// for integrations that cannot expose a public callback URL. It fails when the booking is
+// cancelled or ctx is done; bookings not listed yet and unknown statuses keep polling.
+func (c *client) WaitForCompletion(ctx context.Context, bookingNo string, interval time.Duration) (*AssessmentItem, error) {
+	ctx = withBookingNo(c.callContext(ctx), bookingNo)
+	if interval <= 0 {
+		interval = defaultWaitInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		item, err := c.findAssessment(ctx, bookingNo)
+		if err != nil {
+			return nil, err
+		}
+		if item != nil {
+			status, err := item.BookingStatus()
+			switch {
+			case err != nil:
+				c.debugLog("WaitForCompletion %s: %v", bookingNo, err)
+			case status == StatusCompleted:
+				return item, nil
+			case status == StatusCancelled:
+				return item, newExternalError("WaitForCompletion", ErrBookingCancelled, fmt.Sprintf("booking %s was cancelled", bookingNo))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return item, newInternalError("WaitForCompletion", ErrWaitForCompletion, fmt.Errorf("booking %s not completed: %w", bookingNo, ctx.Err()))
+		case <-ticker.C:
+		}
+	}
+}
+
+// WaitForReport waits for the booking to complete and downloads its PDF report.
+func (c *client) WaitForReport(ctx context.Context, bookingNo string, interval time.Duration) (*AssessmentItem, []byte, error) {
+	item, err := c.WaitForCompletion(ctx, bookingNo, interval)
+	if err != nil {
+		return item, nil, err
+	}
+	pdf, _, err := c.DownloadReport(ctx, bookingNo)
+	if err != nil {
+		return item, nil, err
+	}
+	return item, pdf, nil
+}
@@ -2,14 +2,15 @@ package accounting
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/shopspring/decimal"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // --------------------------
@@ -17,39 +18,536 @@ import (
 // --------------------------
 
 func (s *AccountingService) CreateAccount(ctx context.Context, accType AccountType, initialBalance decimal.Decimal, name string) (*Account, error) {
+	if err := s.checkPrecision(initialBalance); err != nil {
+		return nil, err
+	}
 	acc := &Account{
 		ID:        primitive.NewObjectID(),
 		Type:      accType,
 		Name:      name,
 		CreatedAt: time.Now(),
 	}
-	acc.SetBalance(initialBalance)
+	if err := acc.SetBalance(initialBalance); err != nil {
+		return nil, err
+	}
+	acc.OpeningBalance = initialBalance.String()
+	if err := s.insertAccount(ctx, acc); err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
 
-	_, err := s.accounts.InsertOne(ctx, acc)
+// GetOrCreateAccount returns the account with the external reference ref, e.g. the client
+// ID of the signup service, creating it with a zero balance when there is none. Racing
+// calls with the same ref get the same account: the store keeps refs unique (MongoDB
+// needs EnsureIndexes). An account of another type under ref fails with
+// ErrDuplicateAccountRef.
+func (s *AccountingService) GetOrCreateAccount(ctx context.Context, ref string, accType AccountType, name string) (*Account, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, fmt.Errorf("%w: empty reference", ErrInvalidAccountRef)
+	}
+	acc, err := s.repo.FindAccountByRef(ctx, ref)
+	if errors.Is(err, ErrAccountNotFound) {
+		acc = &Account{
+			ID:             primitive.NewObjectID(),
+			Type:           accType,
+			Name:           name,
+			Ref:            ref,
+			OpeningBalance: "0",
+			CreatedAt:      time.Now(),
+		}
+		if err = acc.SetBalance(decimal.Zero); err != nil {
+			return nil, err
+		}
+		err = s.insertAccount(ctx, acc)
+		if errors.Is(err, ErrDuplicateAccountRef) {
+			// created by a concurrent call
+			acc, err = s.repo.FindAccountByRef(ctx, ref)
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
+	if acc.Type != accType {
+		return nil, fmt.Errorf("%w: %q is a %s account", ErrDuplicateAccountRef, ref, acc.Type)
+	}
 	return acc, nil
 }
 
+// GetAccountByRef returns the account with the external reference ref, see GetOrCreateAccount
+func (s *AccountingService) GetAccountByRef(ctx context.Context, ref string) (*Account, error) {
+	return s.repo.FindAccountByRef(ctx, ref)
+}
+
+// insertAccount stores a new account with its audit record
+func (s *AccountingService) insertAccount(ctx context.Context, acc *Account) error {
+	return s.runInTransaction(ctx, func(sc context.Context) error {
+		if err := s.repo.InsertAccount(sc, acc); err != nil {
+			return err
+		}
+		after := map[string]string{
+			"type":            string(acc.Type),
+			"name":            acc.Name,
+			"opening_balance": acc.OpeningBalance,
+		}
+		if acc.Ref != "" {
+			after["ref"] = acc.Ref
+		}
+		return s.audit(sc, AuditAccountCreated, acc.ID, "", nil, after)
+	})
+}
+
 func (s *AccountingService) GetAccountByID(ctx context.Context, accountID primitive.ObjectID) (*Account, error) {
-	var acc Account
-	err := s.accounts.FindOne(ctx, bson.M{"_id": accountID}).Decode(&acc)
+	return s.repo.GetAccount(ctx, accountID)
+}
+
+func (s *AccountingService) GetAccountBalance(ctx context.Context, accountID primitive.ObjectID) (decimal.Decimal, error) {
+	acc, err := s.GetAccountByID(ctx, accountID)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("account not found: %s", accountID.Hex())
+		return decimal.Zero, err
+	}
+	return acc.GetBalance(), nil
+}
+
+// SetOverdraftPolicy sets whether an account may go below zero and by how much. A zero
+// limit with allowNegative set means no limit.
+func (s *AccountingService) SetOverdraftPolicy(ctx context.Context, accountID primitive.ObjectID, allowNegative bool, limit decimal.Decimal) error {
+	if limit.IsNegative() {
+		return fmt.Errorf("%w: overdraft limit must not be negative", ErrInvalidAmount)
+	}
+	if err := s.checkPrecision(limit); err != nil {
+		return err
+	}
+	if !allowNegative {
+		limit = decimal.Zero
+	}
+	return s.runInTransaction(ctx, func(sc context.Context) error {
+		acc, err := s.repo.GetAccount(sc, accountID)
+		if err != nil {
+			return err
+		}
+		before := auditOverdraft(acc)
+		if err := s.repo.SetOverdraftPolicy(sc, accountID, allowNegative, limit); err != nil {
+			return err
+		}
+		acc.AllowNegative = &allowNegative
+		acc.OverdraftLimit = ""
+		if limit.IsPositive() {
+			acc.OverdraftLimit = limit.String()
+		}
+		return s.audit(sc, AuditOverdraftChanged, accountID, "", before, auditOverdraft(acc))
+	})
+}
+
+// --------------------------
+//  Account Lifecycle
+// --------------------------
+
+// FreezeAccount blocks every posting to or from an account until UnfreezeAccount. Freezing
+// a frozen account does nothing.
+func (s *AccountingService) FreezeAccount(ctx context.Context, accountID primitive.ObjectID, reason string) error {
+	return s.setAccountStatus(ctx, accountID, AccountFrozen, reason)
+}
+
+// UnfreezeAccount makes a frozen account active again
+func (s *AccountingService) UnfreezeAccount(ctx context.Context, accountID primitive.ObjectID) error {
+	return s.setAccountStatus(ctx, accountID, AccountActive, "")
+}
+
+func (s *AccountingService) setAccountStatus(ctx context.Context, accountID primitive.ObjectID, status AccountStatus, reason string) error {
+	return s.runInTransaction(ctx, func(sc context.Context) error {
+		acc, err := s.repo.GetAccount(sc, accountID)
+		if err != nil {
+			return err
+		}
+		switch acc.GetStatus() {
+		case AccountClosed, AccountArchived:
+			return fmt.Errorf("%w: %s", ErrAccountClosed, accountID.Hex())
+		case status:
+			return nil
+		}
+		before := auditStatus(acc.GetStatus(), acc.StatusReason)
+		if _, err = s.repo.SetAccountStatus(sc, accountID, status, reason, time.Now()); err != nil {
+			return err
+		}
+		action := AuditAccountFrozen
+		if status == AccountActive {
+			action = AuditAccountUnfrozen
+		}
+		return s.audit(sc, action, accountID, reason, before, auditStatus(status, reason))
+	})
+}
+
+// CloseAccount permanently retires an account. An account with a balance is only closed
+// together with an AccountClosure entry moving the residual to residualAccID, an account
+// of the same type; with a zero residualAccID it fails with ErrNonZeroBalance. The
+// transfer entry is returned, nil when the balance was already zero.
+func (s *AccountingService) CloseAccount(ctx context.Context, accountID, residualAccID primitive.ObjectID, reason string) (*JournalEntry, error) {
+	var transfer *JournalEntry
+	err := s.runInTransaction(ctx, func(sc context.Context) error {
+		transfer = nil
+		acc, err := s.repo.GetAccount(sc, accountID)
+		if err != nil {
+			return err
 		}
+		if status := acc.GetStatus(); status == AccountClosed || status == AccountArchived {
+			return fmt.Errorf("%w: %s", ErrAccountClosed, accountID.Hex())
+		}
+		before := auditStatus(acc.GetStatus(), acc.StatusReason)
+		before["balance"] = acc.GetBalance().String()
+
+		now := time.Now()
+		if balance := acc.GetBalance(); !balance.IsZero() {
+			if residualAccID.IsZero() {
+				return fmt.Errorf("%w: account %s has %s", ErrNonZeroBalance, accountID.Hex(), balance.StringFixed(2))
+			}
+			if transfer, err = s.residualTransfer(sc, acc, residualAccID, now); err != nil {
+				return err
+			}
+			if err := s.applyEntry(sc, transfer); err != nil {
+				return err
+			}
+		}
+
+		closed, err := s.repo.SetAccountStatus(sc, accountID, AccountClosed, reason, now)
+		if err != nil {
+			return err
+		}
+		if !closed {
+			// a concurrent posting changed the balance
+			return fmt.Errorf("%w: account %s", ErrNonZeroBalance, accountID.Hex())
+		}
+		after := auditStatus(AccountClosed, reason)
+		if transfer != nil {
+			after["residual_account_id"] = residualAccID.Hex()
+			after["residual_journal_id"] = transfer.ID.Hex()
+		}
+		return s.audit(sc, AuditAccountClosed, accountID, reason, before, after)
+	})
+	if err != nil {
 		return nil, err
 	}
-	return &acc, nil
+	if transfer != nil {
+		s.publishPosted(ctx, transfer)
+	}
+	return transfer, nil
 }
 
-func (s *AccountingService) GetAccountBalance(ctx context.Context, accountID primitive.ObjectID) (decimal.Decimal, error) {
+// residualTransfer returns the entry bringing the balance of acc to zero against residualAccID
+func (s *AccountingService) residualTransfer(sc context.Context, acc *Account, residualAccID primitive.ObjectID, at time.Time) (*JournalEntry, error) {
+	if residualAccID == acc.ID {
+		return nil, fmt.Errorf("%w: residual of %s cannot go to itself", ErrPostingRule, acc.ID.Hex())
+	}
+	target, err := s.repo.GetAccount(sc, residualAccID)
+	if err != nil {
+		return nil, err
+	}
+	if target.Type != acc.Type {
+		return nil, fmt.Errorf("%w: residual of a %s account cannot go to a %s account", ErrPostingRule, acc.Type, target.Type)
+	}
+
+	// balances are credits minus debits: a credit balance is debited to zero
+	balance := acc.GetBalance()
+	debit, credit := acc.ID, target.ID
+	if balance.IsNegative() {
+		debit, credit = credit, debit
+	}
+	entry := &JournalEntry{
+		ID:            primitive.NewObjectID(),
+		Type:          AccountClosure,
+		Amount:        balance.Abs().String(),
+		TranRef:       "CLOSE-" + acc.ID.Hex(),
+		DebitAccount:  debit,
+		CreditAccount: credit,
+		CreatedAt:     at,
+	}
+	return entry, nil
+}
+
+// ListAccounts returns a page of the accounts matching filter, oldest first, with the
+// number of matching accounts for paging.
+func (s *AccountingService) ListAccounts(ctx context.Context, filter AccountFilter, page Pagination) ([]Account, int64, error) {
+	if err := filter.validate(); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListAccounts(ctx, filter, page.normalize())
+}
+
+// FindAccountByName returns the account with exactly this name, the oldest one when
+// several accounts share it.
+func (s *AccountingService) FindAccountByName(ctx context.Context, name string) (*Account, error) {
+	return s.repo.FindAccountByName(ctx, name)
+}
+
+// --------------------------
+//  Historical Balances
+// --------------------------
+
+// GetBalanceAsOf returns the balance of an account at time t. It starts from the latest
+// balance snapshot taken at or before t (or the opening balance when there is none) and
+// replays the journal entries posted after the snapshot up to and including t.
+func (s *AccountingService) GetBalanceAsOf(ctx context.Context, accountID primitive.ObjectID, t time.Time) (decimal.Decimal, error) {
 	acc, err := s.GetAccountByID(ctx, accountID)
 	if err != nil {
 		return decimal.Zero, err
 	}
-	return acc.GetBalance(), nil
+	return s.balanceAt(ctx, acc, t, true)
+}
+
+// balanceAt returns the balance of acc at t, including the entries posted at t when
+// inclusive is set and only the earlier ones otherwise.
+func (s *AccountingService) balanceAt(ctx context.Context, acc *Account, t time.Time, inclusive bool) (decimal.Decimal, error) {
+	if acc.CreatedAt.After(t) || (!inclusive && acc.CreatedAt.Equal(t)) {
+		return decimal.Zero, nil
+	}
+
+	balance := acc.GetOpeningBalance()
+	q := AccountJournalQuery{AccountID: acc.ID}
+	if inclusive {
+		q.To = t
+	} else {
+		q.Before = t
+	}
+	snap, err := s.repo.LatestSnapshot(ctx, acc.ID, t, inclusive)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if snap != nil {
+		balance = snap.GetBalance()
+		q.After = snap.AsOf
+	}
+
+	entries, err := s.repo.AccountJournals(ctx, q)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	for _, e := range entries {
+		balance = balance.Add(e.BalanceEffect(acc.ID))
+	}
+	return balance, nil
+}
+
+// --------------------------
+//  Balance Snapshots
+// --------------------------
+
+// CreateBalanceSnapshot stores the balance of an account at asOf, typically a period end,
+// so later historical balances and statements replay the journal from there. asOf cannot
+// be in the future; taking the snapshot of the same instant again replaces it.
+func (s *AccountingService) CreateBalanceSnapshot(ctx context.Context, accountID primitive.ObjectID, asOf time.Time) (*BalanceSnapshot, error) {
+	acc, err := s.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	return s.snapshotAccount(ctx, acc, asOf)
+}
+
+// CreateBalanceSnapshots snapshots every account existing at asOf, for period closing
+func (s *AccountingService) CreateBalanceSnapshots(ctx context.Context, asOf time.Time) ([]BalanceSnapshot, error) {
+	accounts, err := s.repo.AccountsAsOf(ctx, asOf)
+	if err != nil {
+		return nil, err
+	}
+	snaps := make([]BalanceSnapshot, 0, len(accounts))
+	for i := range accounts {
+		snap, err := s.snapshotAccount(ctx, &accounts[i], asOf)
+		if err != nil {
+			return snaps, fmt.Errorf("snapshot %s: %w", accounts[i].ID.Hex(), err)
+		}
+		snaps = append(snaps, *snap)
+	}
+	err = s.audit(ctx, AuditPeriodClosed, primitive.NilObjectID, "", nil, map[string]string{
+		"as_of":    asOf.UTC().Format(time.RFC3339Nano),
+		"accounts": strconv.Itoa(len(snaps)),
+	})
+	return snaps, err
+}
+
+func (s *AccountingService) snapshotAccount(ctx context.Context, acc *Account, asOf time.Time) (*BalanceSnapshot, error) {
+	if asOf.After(time.Now()) {
+		return nil, fmt.Errorf("%w: cannot snapshot a future balance", ErrInvalidFilter)
+	}
+	balance, err := s.balanceAt(ctx, acc, asOf, true)
+	if err != nil {
+		return nil, err
+	}
+	snap := &BalanceSnapshot{
+		ID:        primitive.NewObjectID(),
+		AccountID: acc.ID,
+		Balance:   balance.String(),
+		AsOf:      asOf,
+		CreatedAt: time.Now(),
+	}
+	// upsert so repeating a period close does not stack snapshots of the same instant
+	return s.repo.UpsertSnapshot(ctx, snap)
+}
+
+// ListSnapshots returns the snapshots of an account taken for instants between from and
+// to (both inclusive, zero for unbounded), oldest first
+func (s *AccountingService) ListSnapshots(ctx context.Context, accountID primitive.ObjectID, from, to time.Time) ([]BalanceSnapshot, error) {
+	return s.repo.ListSnapshots(ctx, accountID, from, to)
+}
+
+// --------------------------
+//  Account Statement
+// --------------------------
+
+// GetAccountStatement returns the journal legs of an account posted between from and to
+// (both inclusive) with their direction, counter account and running balance. A zero
+// from starts at the account creation, a zero to ends now. Lines are oldest first; the
+// running balance of a page is computed from the lines of the earlier pages, so deep
+// pages read every line before them.
+func (s *AccountingService) GetAccountStatement(ctx context.Context, accountID primitive.ObjectID, from, to time.Time, page Pagination) (*AccountStatement, error) {
+	acc, err := s.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if from.IsZero() || from.Before(acc.CreatedAt) {
+		from = acc.CreatedAt
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: statement ends before it starts", ErrInvalidFilter)
+	}
+	page = page.normalize()
+
+	stmt := &AccountStatement{
+		AccountID:   acc.ID,
+		AccountType: acc.Type,
+		AccountName: acc.Name,
+		From:        from,
+		To:          to,
+	}
+	if stmt.OpeningBalance, err = s.balanceAt(ctx, acc, from, false); err != nil {
+		return nil, err
+	}
+	if stmt.ClosingBalance, err = s.balanceAt(ctx, acc, to, true); err != nil {
+		return nil, err
+	}
+
+	q := AccountJournalQuery{AccountID: acc.ID, From: from, To: to}
+	if stmt.TotalLines, err = s.repo.CountAccountJournals(ctx, q); err != nil {
+		return nil, err
+	}
+	q.Limit = page.Skip + page.Limit
+	entries, err := s.repo.AccountJournals(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	lines := statementLines(acc.ID, stmt.OpeningBalance, entries)
+	s.roundLines(lines)
+	stmt.OpeningBalance, stmt.ClosingBalance = s.round(stmt.OpeningBalance), s.round(stmt.ClosingBalance)
+	if int64(len(lines)) > page.Skip {
+		stmt.Lines = lines[page.Skip:]
+	} else {
+		stmt.Lines = []StatementLine{}
+	}
+	return stmt, nil
+}
+
+// statementLines turns the journal entries of an account into statement lines,
+// carrying the running balance from opening
+func statementLines(accountID primitive.ObjectID, opening decimal.Decimal, entries []JournalEntry) []StatementLine {
+	lines := make([]StatementLine, 0, len(entries))
+	balance := opening
+	for _, e := range entries {
+		line := StatementLine{
+			JournalID: e.ID,
+			Type:      e.Type,
+			TranRef:   e.TranRef,
+			Narrative: e.Narrative,
+			Metadata:  e.Metadata,
+			Amount:    e.GetAmount(),
+			CreatedAt: e.CreatedAt,
+		}
+		effect := e.BalanceEffect(accountID)
+		switch {
+		case e.IsCompound():
+			// several counter accounts, the line shows the net effect on the account
+			line.Amount = effect.Abs()
+			line.Direction = DirectionCredit
+			if effect.IsNegative() {
+				line.Direction = DirectionDebit
+			}
+		case e.DebitAccount == accountID:
+			line.Direction = DirectionDebit
+			line.CounterAccount = e.CreditAccount
+		default:
+			line.Direction = DirectionCredit
+			line.CounterAccount = e.DebitAccount
+		}
+		balance = balance.Add(effect)
+		line.Balance = balance
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// --------------------------
+//  Trial Balance
+// --------------------------
+
+// GetTrialBalance returns the balance of every account existing at asOf in debit and
+// credit columns, grouped by account type. When debits and credits differ the trial
+// balance is returned together with an error wrapping ErrUnbalanced.
+func (s *AccountingService) GetTrialBalance(ctx context.Context, asOf time.Time) (*TrialBalance, error) {
+	accounts, err := s.repo.AccountsAsOf(ctx, asOf)
+	if err != nil {
+		return nil, err
+	}
+	balances := make([]decimal.Decimal, len(accounts))
+	for i := range accounts {
+		if balances[i], err = s.balanceAt(ctx, &accounts[i], asOf, true); err != nil {
+			return nil, err
+		}
+		balances[i] = s.round(balances[i])
+	}
+
+	tb := buildTrialBalance(asOf, accounts, balances)
+	if !tb.Balanced() {
+		return tb, fmt.Errorf("%w: credits exceed debits by %s", ErrUnbalanced, tb.Difference().StringFixed(2))
+	}
+	return tb, nil
+}
+
+// buildTrialBalance places each account balance in its column and sums them per account type
+func buildTrialBalance(asOf time.Time, accounts []Account, balances []decimal.Decimal) *TrialBalance {
+	tb := &TrialBalance{AsOf: asOf, Lines: make([]TrialBalanceLine, 0, len(accounts))}
+	byType := make(map[AccountType]*TrialBalanceSubtotal)
+	for i, acc := range accounts {
+		line := TrialBalanceLine{
+			AccountID:   acc.ID,
+			AccountType: acc.Type,
+			AccountName: acc.Name,
+		}
+		if balances[i].IsNegative() {
+			line.Debit = balances[i].Neg()
+		} else {
+			line.Credit = balances[i]
+		}
+		tb.Lines = append(tb.Lines, line)
+
+		sub, ok := byType[acc.Type]
+		if !ok {
+			sub = &TrialBalanceSubtotal{AccountType: acc.Type}
+			byType[acc.Type] = sub
+		}
+		sub.AccountCount++
+		sub.Debit = sub.Debit.Add(line.Debit)
+		sub.Credit = sub.Credit.Add(line.Credit)
+		tb.TotalDebit = tb.TotalDebit.Add(line.Debit)
+		tb.TotalCredit = tb.TotalCredit.Add(line.Credit)
+	}
+	for _, sub := range byType {
+		tb.Subtotals = append(tb.Subtotals, *sub)
+	}
+	sort.Slice(tb.Subtotals, func(i, j int) bool {
+		return tb.Subtotals[i].AccountType < tb.Subtotals[j].AccountType
+	})
+	return tb
 }
 
 // --------------------------
@@ -62,70 +560,349 @@ func (s *AccountingService) postDoubleEntry(
 	amount decimal.Decimal,
 	debitAccID, creditAccID primitive.ObjectID,
 	tranRef string,
-) error {
+	opts ...PostingOption,
+) (*JournalEntry, error) {
+	entry, err := s.newDoubleEntry(txType, amount, debitAccID, creditAccID, tranRef, opts)
+	if err != nil {
+		return nil, err
+	}
+	return s.postEntry(ctx, entry, func(sc context.Context) error {
+		// 1. Check the accounts against the posting rule
+		debitType, creditType, err := s.accountTypes(sc, debitAccID, creditAccID)
+		if err != nil {
+			return err
+		}
+		return s.postingRules().Check(txType, debitType, creditType)
+	})
+}
+
+// newDoubleEntry returns the entry debiting and crediting amount to two accounts
+func (s *AccountingService) newDoubleEntry(txType TransactionType, amount decimal.Decimal, debitAccID, creditAccID primitive.ObjectID, tranRef string, opts []PostingOption) (*JournalEntry, error) {
 	if amount.LessThanOrEqual(decimal.Zero) {
-		return fmt.Errorf("amount must be > 0")
+		return nil, ErrInvalidAmount
+	}
+	if err := s.checkPrecision(amount); err != nil {
+		return nil, err
 	}
+	entry := &JournalEntry{
+		ID:             primitive.NewObjectID(),
+		Type:           txType,
+		Amount:         amount.String(),
+		DebitAccount:   debitAccID,
+		CreditAccount:  creditAccID,
+		CreatedAt:      time.Now(),
+		TranRef:        tranRef,
+		IdempotencyKey: s.idempotencyKey(txType, tranRef),
+	}
+	if err := applyPostingOptions(entry, opts); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
 
-	return s.runInTransaction(ctx, func(sc mongo.SessionContext) error {
-		// 1. Update account balances
-		if err := s.incrementBalance(sc, debitAccID, amount.Neg()); err != nil {
-			return err
+// accountTypes returns the types of the debit and credit accounts of a posting
+func (s *AccountingService) accountTypes(sc context.Context, debitAccID, creditAccID primitive.ObjectID) (AccountType, AccountType, error) {
+	debitAcc, err := s.repo.GetAccount(sc, debitAccID)
+	if err != nil {
+		return "", "", err
+	}
+	creditAcc, err := s.repo.GetAccount(sc, creditAccID)
+	if err != nil {
+		return "", "", err
+	}
+	return debitAcc.Type, creditAcc.Type, nil
+}
+
+// postEntry runs check and applies entry in one transaction. With idempotent postings an
+// entry already posted under the same idempotency key is returned instead, see
+// EnableIdempotentPostings.
+func (s *AccountingService) postEntry(ctx context.Context, entry *JournalEntry, check func(sc context.Context) error) (*JournalEntry, error) {
+	posted := entry
+	err := s.runInTransaction(ctx, func(sc context.Context) error {
+		posted = entry
+		if entry.IdempotencyKey != "" {
+			original, err := s.findPosted(sc, entry)
+			if err != nil || original != nil {
+				posted = original
+				return err
+			}
 		}
-		if err := s.incrementBalance(sc, creditAccID, amount); err != nil {
+		if err := check(sc); err != nil {
 			return err
 		}
+		// 2. Update account balances and insert the journal entry (double-entry)
+		return s.applyEntry(sc, entry)
+	})
+	if err != nil && errors.Is(err, ErrDuplicateKey) {
+		// a concurrent retry posted it first
+		return s.findPosted(ctx, entry)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if posted == entry {
+		s.publishPosted(ctx, entry)
+	}
+	return posted, nil
+}
 
-		// 2. Insert journal entry (double-entry)
-		entry := &JournalEntry{
-			ID:            primitive.NewObjectID(),
-			Type:          txType,
-			Amount:        amount.String(),
-			DebitAccount:  debitAccID,
-			CreditAccount: creditAccID,
-			CreatedAt:     time.Now(),
-			TranRef:       tranRef,
+// applyEntry updates the balances of every account of entry, appends it to the hash chain
+// and inserts it, with its outbox message when the outbox is enabled and its posted event
+// when the events go through an event outbox
+func (s *AccountingService) applyEntry(sc context.Context, entry *JournalEntry) error {
+	for _, leg := range entry.postedLegs() {
+		delta := leg.GetAmount()
+		if leg.Direction == DirectionDebit {
+			delta = delta.Neg()
 		}
-		_, err := s.journals.InsertOne(sc, entry)
+		if err := s.incrementBalance(sc, leg.AccountID, delta); err != nil {
+			return err
+		}
+	}
+	if err := s.chainEntry(sc, entry); err != nil {
 		return err
+	}
+	if err := s.repo.InsertJournal(sc, entry); err != nil {
+		return err
+	}
+	if err := s.writePostedEvent(sc, entry); err != nil {
+		return err
+	}
+	if !s.outbox {
+		return nil
+	}
+	msg, err := newOutboxMessage(entry)
+	if err != nil {
+		return err
+	}
+	return s.repo.InsertOutbox(sc, msg)
+}
+
+// --------------------------
+//  Compound Entries
+// --------------------------
+
+// PostJournal posts a compound entry of any number of legs as one journal document.
+// Debits must equal credits; every debited and credited account type is checked
+// against the posting rule of txType. All balances change atomically with the insert.
+func (s *AccountingService) PostJournal(ctx context.Context, legs []JournalLeg, tranRef string, txType TransactionType, opts ...PostingOption) (*JournalEntry, error) {
+	total, err := validateLegs(legs)
+	if err != nil {
+		return nil, err
+	}
+	for i, leg := range legs {
+		if err := s.checkPrecision(leg.GetAmount()); err != nil {
+			return nil, fmt.Errorf("leg %d: %w", i, err)
+		}
+	}
+
+	entry := &JournalEntry{
+		ID:             primitive.NewObjectID(),
+		Type:           txType,
+		Amount:         total.String(),
+		TranRef:        tranRef,
+		Legs:           legs,
+		CreatedAt:      time.Now(),
+		IdempotencyKey: s.idempotencyKey(txType, tranRef),
+	}
+	entry.TransactionID = entry.ID
+	if err := applyPostingOptions(entry, opts); err != nil {
+		return nil, err
+	}
+	return s.postEntry(ctx, entry, func(sc context.Context) error {
+		var debits, credits []AccountType
+		for _, leg := range legs {
+			acc, err := s.repo.GetAccount(sc, leg.AccountID)
+			if err != nil {
+				return err
+			}
+			if leg.Direction == DirectionDebit {
+				debits = append(debits, acc.Type)
+			} else {
+				credits = append(credits, acc.Type)
+			}
+		}
+		return s.postingRules().CheckLegs(txType, debits, credits)
+	})
+}
+
+// validateLegs checks a compound entry and returns its total debits
+func validateLegs(legs []JournalLeg) (decimal.Decimal, error) {
+	if len(legs) < 2 {
+		return decimal.Zero, fmt.Errorf("%w: a journal needs at least two legs", ErrUnbalanced)
+	}
+	var debits, credits decimal.Decimal
+	for i, leg := range legs {
+		amount, err := decimal.NewFromString(leg.Amount)
+		if err != nil || amount.LessThanOrEqual(decimal.Zero) {
+			return decimal.Zero, fmt.Errorf("%w: leg %d", ErrInvalidAmount, i)
+		}
+		if leg.AccountID.IsZero() {
+			return decimal.Zero, fmt.Errorf("%w: leg %d has no account", ErrAccountNotFound, i)
+		}
+		switch leg.Direction {
+		case DirectionDebit:
+			debits = debits.Add(amount)
+		case DirectionCredit:
+			credits = credits.Add(amount)
+		default:
+			return decimal.Zero, fmt.Errorf("leg %d: invalid direction %q", i, leg.Direction)
+		}
+	}
+	if debits.IsZero() || credits.IsZero() {
+		return decimal.Zero, fmt.Errorf("%w: a journal needs debit and credit legs", ErrUnbalanced)
+	}
+	if !debits.Equal(credits) {
+		return decimal.Zero, fmt.Errorf("%w: debits %s, credits %s", ErrUnbalanced, debits, credits)
+	}
+	return debits, nil
+}
+
+// --------------------------
+//  Reversals
+// --------------------------
+
+// ReverseJournalEntry corrects a wrongly posted entry by posting an offsetting Reversal
+// entry with the debit and credit accounts swapped, under the same TranRef. The original
+// is marked as reversed; reversing it again, or reversing a reversal, is refused. A
+// matched suspense receipt is unmatched first, by reversing its clearing, which returns
+// the receipt to unmatched, see UnmatchSuspenseEntry.
+func (s *AccountingService) ReverseJournalEntry(ctx context.Context, journalID primitive.ObjectID, reason string) (*JournalEntry, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("%w: a reason is required", ErrNotReversible)
+	}
+
+	var reversal *JournalEntry
+	err := s.runInTransaction(ctx, func(sc context.Context) error {
+		original, err := s.repo.GetJournal(sc, journalID)
+		if err != nil {
+			return err
+		}
+		if original.ReversalOf != nil {
+			return fmt.Errorf("%w: %s is itself a reversal", ErrNotReversible, journalID.Hex())
+		}
+		if original.IsReversed() {
+			return fmt.Errorf("%w: %s", ErrAlreadyReversed, journalID.Hex())
+		}
+		if original.IsMatched() {
+			return fmt.Errorf("%w: suspense entry %s is matched, reverse its clearing first", ErrNotReversible, journalID.Hex())
+		}
+
+		now := time.Now()
+		group := original.TransactionID
+		if group.IsZero() {
+			group = original.ID
+		}
+		reversal = &JournalEntry{
+			ID:             primitive.NewObjectID(),
+			TransactionID:  group,
+			Type:           Reversal,
+			Amount:         original.Amount,
+			TranRef:        original.TranRef,
+			DebitAccount:   original.CreditAccount,
+			CreditAccount:  original.DebitAccount,
+			CreatedAt:      now,
+			ReversalOf:     &original.ID,
+			ReversalReason: reason,
+		}
+		for _, leg := range original.Legs {
+			leg.Direction = leg.Direction.Opposite()
+			reversal.Legs = append(reversal.Legs, leg)
+		}
+
+		marked, err := s.repo.MarkReversed(sc, original.ID, group, reversal.ID, now, reason)
+		if err != nil {
+			return err
+		}
+		if !marked {
+			return fmt.Errorf("%w: %s", ErrAlreadyReversed, journalID.Hex())
+		}
+		if err := s.applyEntry(sc, reversal); err != nil {
+			return err
+		}
+		if original.ClearingOf != nil {
+			if err := s.unmatchReceipt(sc, original, reason); err != nil {
+				return err
+			}
+		}
+		return s.audit(sc, AuditJournalReversed, original.ID, reason, nil, map[string]string{
+			"reversed_by": reversal.ID.Hex(),
+			"amount":      original.Amount,
+			"tranref":     original.TranRef,
+		})
 	})
+	if err != nil {
+		return nil, err
+	}
+	s.publishPosted(ctx, reversal)
+	return reversal, nil
 }
 
 // Client Top-Up: Debit Gateway (asset), Credit Client (liability)
-func (s *AccountingService) ClientAccountTopUp(ctx context.Context, clientAccID, gatewayAccID primitive.ObjectID, amount decimal.Decimal, tranRef string) error {
-	return s.postDoubleEntry(ctx, TopUp, amount, gatewayAccID, clientAccID, tranRef)
+func (s *AccountingService) ClientAccountTopUp(ctx context.Context, clientAccID, gatewayAccID primitive.ObjectID, amount decimal.Decimal, tranRef string, opts ...PostingOption) error {
+	_, err := s.postDoubleEntry(ctx, TopUp, amount, gatewayAccID, clientAccID, tranRef, opts...)
+	return err
 }
 
 // Premium Payment: Debit Client (liability), Credit Underwriter (liability)
-func (s *AccountingService) ClientPremiumPayment(ctx context.Context, clientAccID, underwriterAccID primitive.ObjectID, amount decimal.Decimal, tranRef string) error {
-	return s.postDoubleEntry(ctx, PremiumPayment, amount, clientAccID, underwriterAccID, tranRef)
+func (s *AccountingService) ClientPremiumPayment(ctx context.Context, clientAccID, underwriterAccID primitive.ObjectID, amount decimal.Decimal, tranRef string, opts ...PostingOption) error {
+	_, err := s.postDoubleEntry(ctx, PremiumPayment, amount, clientAccID, underwriterAccID, tranRef, opts...)
+	return err
 }
 
 // Commission: Debit Underwriter (expense), Credit Agent (revenue)
-func (s *AccountingService) PostAgentCommission(ctx context.Context, underwriterAccID, agentAccID primitive.ObjectID, amount decimal.Decimal, tranRef string) error {
-	return s.postDoubleEntry(ctx, CommissionPayment, amount, underwriterAccID, agentAccID, tranRef)
+func (s *AccountingService) PostAgentCommission(ctx context.Context, underwriterAccID, agentAccID primitive.ObjectID, amount decimal.Decimal, tranRef string, opts ...PostingOption) error {
+	_, err := s.postDoubleEntry(ctx, CommissionPayment, amount, underwriterAccID, agentAccID, tranRef, opts...)
+	return err
 }
 
-// Helper: increment balance atomically
-func (s *AccountingService) incrementBalance(sc mongo.SessionContext, accountID primitive.ObjectID, delta decimal.Decimal) error {
-	acc, err := s.getAccountInSession(sc, accountID)
+// Helper: increment balance atomically, refusing accounts that are not active and
+// enforcing the overdraft policy on debits. The policy is also passed to the repository as
+// a floor so a concurrent debit cannot take the balance past it between the read and the
+// update; the repository likewise skips accounts frozen or closed meanwhile.
+func (s *AccountingService) incrementBalance(sc context.Context, accountID primitive.ObjectID, delta decimal.Decimal) error {
+	acc, err := s.repo.GetAccount(sc, accountID)
 	if err != nil {
 		return err
 	}
-	newBal := acc.GetBalance().Add(delta)
-	filter := bson.M{"_id": accountID}
-	update := bson.M{"$set": bson.M{"balance": newBal.String()}}
-	_, err = s.accounts.UpdateOne(sc, filter, update)
-	return err
+	if err := acc.checkPostable(); err != nil {
+		return err
+	}
+	var floor *decimal.Decimal
+	if delta.IsNegative() {
+		if err := acc.checkDebit(delta.Neg()); err != nil {
+			return err
+		}
+		if min, limited := acc.minBalanceFor(delta.Neg()); limited {
+			floor = &min
+		}
+	}
+	updated, err := s.repo.IncrementBalance(sc, accountID, delta, floor)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		if current, err := s.repo.GetAccount(sc, accountID); err == nil {
+			if err := current.checkPostable(); err != nil {
+				return err
+			}
+		}
+		return acc.insufficientFunds(delta.Neg())
+	}
+	return nil
 }
 
-func (s *AccountingService) getAccountInSession(sc mongo.SessionContext, accountID primitive.ObjectID) (*Account, error) {
-	var acc Account
-	err := s.accounts.FindOne(sc, bson.M{"_id": accountID}).Decode(&acc)
-	if err != nil {
-		return nil, err
+// MigrateBalancesToDecimal128 converts account balances stored as decimal strings by
+// earlier versions to Decimal128, see MongoRepository.MigrateBalancesToDecimal128. It does
+// nothing on other repositories.
+func (s *AccountingService) MigrateBalancesToDecimal128(ctx context.Context) (int64, error) {
+	m, ok := s.repo.(interface {
+		MigrateBalancesToDecimal128(ctx context.Context) (int64, error)
+	})
+	if !ok {
+		return 0, nil
 	}
-	return &acc, nil
+	return m.MigrateBalancesToDecimal128(ctx)
 }
 
 // --------------------------
@@ -140,82 +917,106 @@ func (s *AccountingService) GetJournalEntries(ctx context.Context, limit, skip i
 		skip = 0
 	}
 
-	opts := options.Find().
-		SetSort(bson.M{"created_at": -1}).
-		SetLimit(limit).
-		SetSkip(skip)
+	return s.repo.ListJournals(ctx, limit, skip)
+}
 
-	cursor, err := s.journals.Find(ctx, bson.M{}, opts)
-	if err != nil {
-		return nil, err
+// RefOption narrows the entries of GetJournalEntriesByRef
+type RefOption func(*JournalFilter)
+
+// WithRefType keeps the entries of a transaction type, e.g. the reversals of a ref
+func WithRefType(txType TransactionType) RefOption {
+	return func(f *JournalFilter) {
+		f.Type = txType
 	}
-	defer cursor.Close(ctx)
+}
 
-	var entries []JournalEntry
-	if err = cursor.All(ctx, &entries); err != nil {
-		return nil, err
+// WithRefPeriod keeps the entries created between from and to, both inclusive; a zero
+// bound leaves that side open
+func WithRefPeriod(from, to time.Time) RefOption {
+	return func(f *JournalFilter) {
+		f.From, f.To = from, to
 	}
-	return entries, nil
 }
 
-func (s *AccountingService) GetJournalEntriesByRef(ctx context.Context, tranRef string) ([]JournalEntry, error) {
-	filter := bson.M{"tranref": tranRef}
-	cursor, err := s.journals.Find(ctx, filter)
-	if err != nil {
+// GetJournalEntriesByRef returns the entries posted under a transaction reference, oldest
+// first with ties broken by ID, so a posting comes before its reversal.
+func (s *AccountingService) GetJournalEntriesByRef(ctx context.Context, tranRef string, opts ...RefOption) ([]JournalEntry, error) {
+	if tranRef == "" {
+		return nil, fmt.Errorf("%w: tranref is required", ErrInvalidFilter)
+	}
+	filter := JournalFilter{TranRef: tranRef}
+	for _, opt := range opts {
+		opt(&filter)
+	}
+	if err := filter.validate(); err != nil {
 		return nil, err
 	}
-	defer cursor.Close(ctx)
+	entries := []JournalEntry{}
+	err := s.repo.StreamJournals(ctx, filter, JournalSort{Ascending: true}, func(e *JournalEntry) error {
+		entries = append(entries, *e)
+		return nil
+	})
+	return entries, err
+}
 
-	var entries []JournalEntry
-	if err = cursor.All(ctx, &entries); err != nil {
-		return nil, err
+// GetJournalByID returns a journal entry, or ErrJournalNotFound. Archived entries are
+// only found through QueryJournals.
+func (s *AccountingService) GetJournalByID(ctx context.Context, journalID primitive.ObjectID) (*JournalEntry, error) {
+	return s.repo.GetJournal(ctx, journalID)
+}
+
+// QueryJournals returns a page of the journal entries matching filter in the order of
+// sort, with the number of matching entries for paging.
+func (s *AccountingService) QueryJournals(ctx context.Context, filter JournalFilter, sort JournalSort, page Pagination) ([]JournalEntry, int64, error) {
+	if err := filter.validate(); err != nil {
+		return nil, 0, err
+	}
+	if err := sort.validate(); err != nil {
+		return nil, 0, err
 	}
-	return entries, nil
+	return s.repo.QueryJournals(ctx, filter, sort, page.normalize())
+}
+
+// StreamJournals calls fn with every journal entry matching filter in the order of sort.
+// Entries are read through a database cursor instead of being loaded at once, for exports
+// of any size. An error from fn stops the iteration and is returned.
+func (s *AccountingService) StreamJournals(ctx context.Context, filter JournalFilter, sort JournalSort, fn func(*JournalEntry) error) error {
+	if err := filter.validate(); err != nil {
+		return err
+	}
+	if err := sort.validate(); err != nil {
+		return err
+	}
+	return s.repo.StreamJournals(ctx, filter, sort, fn)
 }
 
 // --------------------------
 //  LEDGER RECONCILIATION (Double-Entry)
 // --------------------------
 
+// ReconcileAccount compares the stored balance of an account with the balance replayed
+// from its journal, starting at the latest snapshot or else the opening balance.
 func (s *AccountingService) ReconcileAccount(ctx context.Context, accountID primitive.ObjectID) (*ReconciliationResult, error) {
 	acc, err := s.GetAccountByID(ctx, accountID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Fetch all journal legs affecting this account
-	filter := bson.M{
-		"$or": []bson.M{
-			{"debit_account": accountID},
-			{"credit_account": accountID},
-		},
-	}
-	cursor, err := s.journals.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": 1}))
+	// Count all journal legs affecting this account, archived ones included
+	count, err := s.repo.CountAccountJournals(ctx, AccountJournalQuery{AccountID: accountID})
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(ctx)
-
-	var entries []JournalEntry
-	if err = cursor.All(ctx, &entries); err != nil {
+	// replay the journal from the latest snapshot, which covers the archived entries
+	computed, err := s.balanceAt(ctx, acc, time.Now(), true)
+	if err != nil {
 		return nil, err
 	}
 
-	var computed decimal.Decimal
-	for _, e := range entries {
-		amt := e.GetAmount()
-		if e.DebitAccount == accountID {
-			computed = computed.Add(amt)
-		}
-		if e.CreditAccount == accountID {
-			computed = computed.Sub(amt)
-		}
-	}
-
 	stored := acc.GetBalance()
 	discrepancy := computed.Sub(stored)
 	status := Reconciled
-	if len(entries) == 0 {
+	if count == 0 {
 		status = NoTransactions
 	} else if !discrepancy.IsZero() {
 		status = Discrepancy
@@ -228,21 +1029,15 @@ func (s *AccountingService) ReconcileAccount(ctx context.Context, accountID prim
 		ComputedBalance: computed,
 		Discrepancy:     discrepancy,
 		Status:          status,
-		JournalCount:    len(entries),
+		JournalCount:    int(count),
 	}, nil
 }
 
 func (s *AccountingService) GetReconciliationReport(ctx context.Context) ([]ReconciliationResult, error) {
-	cursor, err := s.accounts.Find(ctx, bson.M{})
+	accounts, err := s.repo.AccountsAsOf(ctx, time.Time{})
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(ctx)
-
-	var accounts []Account
-	if err = cursor.All(ctx, &accounts); err != nil {
-		return nil, err
-	}
 
 	var report []ReconciliationResult
 	for _, acc := range accounts {
@@ -259,15 +1054,6 @@ func (s *AccountingService) GetReconciliationReport(ctx context.Context) ([]Reco
 //  Helpers
 // --------------------------
 
-func (s *AccountingService) runInTransaction(ctx context.Context, fn func(mongo.SessionContext) error) error {
-	session, err := s.db.Client().StartSession()
-	if err != nil {
-		return err
-	}
-	defer session.EndSession(ctx)
-
-	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
-		return nil, fn(sc)
-	})
-	return err
+func (s *AccountingService) runInTransaction(ctx context.Context, fn func(context.Context) error) error {
+	return s.repo.RunInTransaction(ctx, fn)
 }
@@ -1,12 +1,15 @@
 package accounting
 
 import (
+	"errors"
 	"fmt"
+	"image"
+	"regexp"
 	"time"
 
 	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // --------------------------
@@ -20,14 +23,109 @@ const (
 	AgentCommissionEarned     AccountType = "AgentCommissionEarned"
 	PaymentGateway            AccountType = "PaymentGateway"
 	ClientInsurance           AccountType = "ClientInsurance"
+	PlatformFeeIncome         AccountType = "PlatformFeeIncome"
+	GatewayFeeExpense         AccountType = "GatewayFeeExpense" // fees charged by the payment provider
+	Suspense                  AccountType = "Suspense"          // receipts not yet matched to an account, see PostToSuspense
 )
 
+// AllowsNegative reports whether accounts of the type may go below zero when their
+// own overdraft policy is unset. Balances are credits minus debits, so accounts only
+// ever debited, such as the payment gateway, are negative by design; client wallets
+// must be funded before they are spent, and suspense only clears what it received.
+func (t AccountType) AllowsNegative() bool {
+	return t != ClientInsurance && t != Suspense
+}
+
 type TransactionType string
 
 const (
 	TopUp             TransactionType = "TopUp"
 	PremiumPayment    TransactionType = "PremiumPayment"
 	CommissionPayment TransactionType = "CommissionPayment"
+	Refund            TransactionType = "Refund"
+	Fee               TransactionType = "Fee"
+	Reversal          TransactionType = "Reversal" // offsets a wrongly posted entry, see ReverseJournalEntry
+
+	ClaimPayment          TransactionType = "ClaimPayment"
+	PremiumRefund         TransactionType = "PremiumRefund"
+	CommissionClawback    TransactionType = "CommissionClawback"
+	UnderwriterSettlement TransactionType = "UnderwriterSettlement"
+	GatewayFee            TransactionType = "GatewayFee"
+
+	AccountClosure  TransactionType = "AccountClosure" // moves the residual balance of an account being closed, see CloseAccount
+	AccountTransfer TransactionType = "Transfer"       // moves value between two accounts, see Transfer
+
+	SuspenseReceipt  TransactionType = "SuspenseReceipt"  // gateway receipt not matched to an account, see PostToSuspense
+	SuspenseClearing TransactionType = "SuspenseClearing" // moves a suspense receipt to its account, see MatchSuspenseEntry
+)
+
+// AccountStatus is the lifecycle state of an account. Only active accounts take postings.
+type AccountStatus string
+
+const (
+	AccountActive AccountStatus = "active"
+	AccountFrozen AccountStatus = "frozen" // temporarily blocked, see FreezeAccount
+	AccountClosed AccountStatus = "closed" // permanently retired with a zero balance, see CloseAccount
+
+	// AccountArchived is a closed account hidden from listings, see ArchiveAccount
+	AccountArchived AccountStatus = "archived"
+)
+
+// --------------------------
+//  Errors
+// --------------------------
+
+var (
+	ErrAccountNotFound = errors.New("account not found")
+	ErrInvalidAmount   = errors.New("amount must be > 0")
+	// ErrExcessPrecision is returned when an amount has more decimal places than the RoundingPolicy allows
+	ErrExcessPrecision = errors.New("amount exceeds the ledger precision")
+	ErrNoPostingRule   = errors.New("no posting rule for transaction type")
+	ErrPostingRule     = errors.New("accounts do not match posting rule")
+	ErrInvalidFilter   = errors.New("invalid filter")
+	ErrUnbalanced      = errors.New("ledger does not balance")
+	ErrJournalNotFound = errors.New("journal entry not found")
+	ErrAlreadyReversed = errors.New("journal entry already reversed")
+	ErrNotReversible   = errors.New("journal entry cannot be reversed")
+	// ErrNotInSuspense is returned by MatchSuspenseEntry for entries other than unreversed
+	// suspense receipts, and by UnmatchSuspenseEntry for entries other than clearings
+	ErrNotInSuspense = errors.New("journal entry is not a suspense receipt")
+	// ErrAlreadyMatched is returned by MatchSuspenseEntry for a receipt cleared before
+	ErrAlreadyMatched = errors.New("suspense entry already matched")
+	// ErrIdempotencyConflict is returned when a posting reuses the transaction reference
+	// and type of an entry with different accounts or amount
+	ErrIdempotencyConflict = errors.New("transaction reference already posted with different details")
+	// ErrInsufficientFunds is returned when a posting would take an account below its overdraft limit
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrAccountFrozen is returned when a posting touches a frozen account
+	ErrAccountFrozen = errors.New("account is frozen")
+	// ErrAccountClosed is returned when a posting or status change touches a closed account
+	ErrAccountClosed = errors.New("account is closed")
+	// ErrNonZeroBalance is returned when closing an account with a balance and no account to transfer it to
+	ErrNonZeroBalance = errors.New("account balance is not zero")
+	// ErrInvalidMetadata is returned when a posting carries a metadata key that cannot be stored
+	ErrInvalidMetadata = errors.New("invalid metadata")
+	// ErrInvalidAccountRef is returned when an external account reference is empty
+	ErrInvalidAccountRef = errors.New("invalid account reference")
+	// ErrDuplicateAccountRef is returned when an external account reference belongs to another account
+	ErrDuplicateAccountRef = errors.New("account reference already in use")
+	// ErrInvalidSchedule is returned when a posting is scheduled without an execution time
+	ErrInvalidSchedule = errors.New("invalid schedule")
+	// ErrScheduleNotFound is returned for an unknown scheduled posting
+	ErrScheduleNotFound = errors.New("scheduled posting not found")
+	// ErrScheduleNotPending is returned when cancelling a scheduled posting that was
+	// already executed, failed or cancelled
+	ErrScheduleNotPending = errors.New("scheduled posting is not pending")
+	// ErrNoCommissionRule is returned when no commission rule matches a premium
+	ErrNoCommissionRule = errors.New("no commission rule for premium")
+	// ErrNotCommissionable is returned when computing the commission of a journal entry
+	// that is not a commissionable premium payment
+	ErrNotCommissionable = errors.New("journal entry does not earn commission")
+	// ErrWatchUnsupported is returned by WatchAccount when the repository cannot stream
+	// balance changes
+	ErrWatchUnsupported = errors.New("repository cannot watch balances")
+	// ErrAccountNotClosed is returned when archiving an account that was not closed
+	ErrAccountNotClosed = errors.New("account is not closed")
 )
 
 // --------------------------
@@ -35,20 +133,114 @@ const (
 // --------------------------
 
 type Account struct {
-	ID        primitive.ObjectID `bson:"_id"`
-	Type      AccountType        `bson:"type"`
-	Balance   string             `bson:"balance"` // decimal string
-	Name      string             `bson:"name"`
-	CreatedAt time.Time          `bson:"created_at"`
+	ID             primitive.ObjectID   `bson:"_id"`
+	Type           AccountType          `bson:"type"`
+	Balance        primitive.Decimal128 `bson:"balance"`         // updated with $inc, see MigrateBalancesToDecimal128
+	OpeningBalance string               `bson:"opening_balance"` // decimal string, balance the account was created with
+	Name           string               `bson:"name"`
+	Ref            string               `bson:"ref,omitempty"` // external reference, unique across accounts, see GetOrCreateAccount
+	CreatedAt      time.Time            `bson:"created_at"`
+
+	// Overdraft policy, enforced on every debit inside the posting transaction
+	AllowNegative  *bool  `bson:"allow_negative,omitempty"`  // nil uses AccountType.AllowsNegative
+	OverdraftLimit string `bson:"overdraft_limit,omitempty"` // decimal string, how far below zero the balance may go; empty for no limit
+
+	// Lifecycle, checked on every posting inside the posting transaction
+	Status          AccountStatus `bson:"status,omitempty"` // empty for accounts created before statuses, which are active
+	StatusReason    string        `bson:"status_reason,omitempty"`
+	StatusChangedAt *time.Time    `bson:"status_changed_at,omitempty"`
+}
+
+// GetStatus returns the status of the account, AccountActive when it was never changed
+func (a *Account) GetStatus() AccountStatus {
+	if a.Status == "" {
+		return AccountActive
+	}
+	return a.Status
+}
+
+// checkPostable returns ErrAccountFrozen or ErrAccountClosed unless the account is active
+func (a *Account) checkPostable() error {
+	switch a.GetStatus() {
+	case AccountFrozen:
+		return fmt.Errorf("%w: %s", ErrAccountFrozen, a.ID.Hex())
+	case AccountClosed, AccountArchived:
+		return fmt.Errorf("%w: %s", ErrAccountClosed, a.ID.Hex())
+	}
+	return nil
 }
 
 func (a *Account) GetBalance() decimal.Decimal {
-	d, _ := decimal.NewFromString(a.Balance)
-	return d
+	return fromDecimal128(a.Balance)
+}
+
+func (a *Account) SetBalance(d decimal.Decimal) error {
+	v, err := toDecimal128(d)
+	if err != nil {
+		return err
+	}
+	a.Balance = v
+	return nil
+}
+
+// NegativeAllowed reports whether the balance may go below zero
+func (a *Account) NegativeAllowed() bool {
+	if a.AllowNegative != nil {
+		return *a.AllowNegative
+	}
+	return a.Type.AllowsNegative()
+}
+
+// minBalanceFor returns the lowest balance amount can be debited from under the overdraft
+// policy, false when the policy sets no limit
+func (a *Account) minBalanceFor(amount decimal.Decimal) (decimal.Decimal, bool) {
+	if !a.NegativeAllowed() {
+		return amount, true
+	}
+	if a.OverdraftLimit == "" {
+		return decimal.Zero, false
+	}
+	limit, _ := decimal.NewFromString(a.OverdraftLimit)
+	return amount.Sub(limit), true
+}
+
+// checkDebit returns ErrInsufficientFunds when debiting amount would breach the overdraft policy
+func (a *Account) checkDebit(amount decimal.Decimal) error {
+	if min, limited := a.minBalanceFor(amount); limited && a.GetBalance().LessThan(min) {
+		return a.insufficientFunds(amount)
+	}
+	return nil
+}
+
+func (a *Account) insufficientFunds(amount decimal.Decimal) error {
+	if a.OverdraftLimit != "" && a.NegativeAllowed() {
+		return fmt.Errorf("%w: account %s has %s with an overdraft limit of %s, needs %s", ErrInsufficientFunds,
+			a.ID.Hex(), a.GetBalance().StringFixed(2), a.OverdraftLimit, amount.StringFixed(2))
+	}
+	return fmt.Errorf("%w: account %s has %s, needs %s", ErrInsufficientFunds, a.ID.Hex(), a.GetBalance().StringFixed(2), amount.StringFixed(2))
+}
+
+// toDecimal128 converts d for storage, failing when it has more than the 34 significant
+// digits a Decimal128 holds
+func toDecimal128(d decimal.Decimal) (primitive.Decimal128, error) {
+	v, ok := primitive.ParseDecimal128FromBigInt(d.Coefficient(), int(d.Exponent()))
+	if !ok {
+		return primitive.Decimal128{}, fmt.Errorf("%w: %s does not fit a Decimal128", ErrInvalidAmount, d)
+	}
+	return v, nil
 }
 
-func (a *Account) SetBalance(d decimal.Decimal) {
-	a.Balance = d.String()
+func fromDecimal128(v primitive.Decimal128) decimal.Decimal {
+	bi, exp, err := v.BigInt()
+	if err != nil {
+		return decimal.Zero
+	}
+	return decimal.NewFromBigInt(bi, int32(exp))
+}
+
+func (a *Account) GetOpeningBalance() decimal.Decimal {
+	d, _ := decimal.NewFromString(a.OpeningBalance)
+	return d
 }
 
 // JournalEntry: One transaction = two legs (debit + credit)
@@ -57,10 +249,50 @@ type JournalEntry struct {
 	TransactionID primitive.ObjectID `bson:"transaction_id"` // optional group
 	Type          TransactionType    `bson:"type"`
 	Amount        string             `bson:"amount"`
-	TranRef       string             `bson:"tranref"` // external reference
+	TranRef       string             `bson:"tranref"`             // external reference
+	Narrative     string             `bson:"narrative,omitempty"` // free-text description, see WithNarrative
 	DebitAccount  primitive.ObjectID `bson:"debit_account"`
 	CreditAccount primitive.ObjectID `bson:"credit_account"`
 	CreatedAt     time.Time          `bson:"created_at"`
+
+	// Metadata are caller-defined key/value pairs, e.g. a bank statement line id, see WithMetadata
+	Metadata map[string]string `bson:"metadata,omitempty"`
+
+	// IdempotencyKey is "<type>:<tranref>" when idempotent postings are enabled, unique across entries
+	IdempotencyKey string `bson:"idempotency_key,omitempty"`
+
+	// Hash chain, see VerifyLedgerIntegrity. Seq numbers the chained entries from 1;
+	// entries posted before the chain existed have none.
+	Seq      int64  `bson:"seq,omitempty"`
+	PrevHash string `bson:"prev_hash,omitempty"` // Hash of entry Seq-1, empty for the first
+	Hash     string `bson:"hash,omitempty"`      // SHA-256 of PrevHash and the posted fields, hex
+
+	// Legs of a compound entry posted with PostJournal, which leaves DebitAccount and
+	// CreditAccount unset; Amount is then the total of the debit legs.
+	Legs []JournalLeg `bson:"legs,omitempty"`
+
+	// Reversals: the reversing entry names the entry it offsets, the original is marked
+	// with its reversal. Both share the TransactionID of the original.
+	ReversalOf     *primitive.ObjectID `bson:"reversal_of,omitempty"`
+	ReversedBy     *primitive.ObjectID `bson:"reversed_by,omitempty"`
+	ReversedAt     *time.Time          `bson:"reversed_at,omitempty"`
+	ReversalReason string              `bson:"reversal_reason,omitempty"`
+
+	// Suspense matching: the clearing names the receipt it clears, the receipt is marked
+	// with its clearing, see MatchSuspenseEntry. Both share the TransactionID of the receipt.
+	ClearingOf *primitive.ObjectID `bson:"clearing_of,omitempty"`
+	MatchedBy  *primitive.ObjectID `bson:"matched_by,omitempty"`
+	MatchedAt  *time.Time          `bson:"matched_at,omitempty"`
+}
+
+// IsReversed reports whether the entry was offset by a reversal
+func (j JournalEntry) IsReversed() bool {
+	return j.ReversedBy != nil
+}
+
+// IsMatched reports whether the entry is a suspense receipt cleared by MatchSuspenseEntry
+func (j JournalEntry) IsMatched() bool {
+	return j.MatchedBy != nil
 }
 
 func (j JournalEntry) GetAmount() decimal.Decimal {
@@ -68,6 +300,67 @@ func (j JournalEntry) GetAmount() decimal.Decimal {
 	return d
 }
 
+// IsCompound reports whether the entry was posted with more than one debit or credit leg
+func (j JournalEntry) IsCompound() bool {
+	return len(j.Legs) > 0
+}
+
+// BalanceEffect returns the change this entry applies to the balance of accountID:
+// credits increase the balance and debits decrease it.
+func (j JournalEntry) BalanceEffect(accountID primitive.ObjectID) decimal.Decimal {
+	effect := decimal.Zero
+	for _, leg := range j.Legs {
+		if leg.AccountID != accountID {
+			continue
+		}
+		if leg.Direction == DirectionDebit {
+			effect = effect.Sub(leg.GetAmount())
+		} else {
+			effect = effect.Add(leg.GetAmount())
+		}
+	}
+	if j.CreditAccount == accountID {
+		effect = effect.Add(j.GetAmount())
+	}
+	if j.DebitAccount == accountID {
+		effect = effect.Sub(j.GetAmount())
+	}
+	return effect
+}
+
+// JournalLeg is one debit or credit of a compound journal entry
+type JournalLeg struct {
+	AccountID primitive.ObjectID `bson:"account_id"`
+	Direction EntryDirection     `bson:"direction"`
+	Amount    string             `bson:"amount"` // decimal string
+}
+
+// DebitLeg returns a leg debiting amount from accountID
+func DebitLeg(accountID primitive.ObjectID, amount decimal.Decimal) JournalLeg {
+	return JournalLeg{AccountID: accountID, Direction: DirectionDebit, Amount: amount.String()}
+}
+
+// CreditLeg returns a leg crediting amount to accountID
+func CreditLeg(accountID primitive.ObjectID, amount decimal.Decimal) JournalLeg {
+	return JournalLeg{AccountID: accountID, Direction: DirectionCredit, Amount: amount.String()}
+}
+
+// postedLegs returns the legs of the entry, the debit and credit of a two-account entry
+func (j JournalEntry) postedLegs() []JournalLeg {
+	if j.IsCompound() {
+		return j.Legs
+	}
+	return []JournalLeg{
+		{AccountID: j.DebitAccount, Direction: DirectionDebit, Amount: j.Amount},
+		{AccountID: j.CreditAccount, Direction: DirectionCredit, Amount: j.Amount},
+	}
+}
+
+func (l JournalLeg) GetAmount() decimal.Decimal {
+	d, _ := decimal.NewFromString(l.Amount)
+	return d
+}
+
 func (j JournalEntry) String() string {
 	return fmt.Sprintf("[%s] %s | %s | Dr:%s | Cr:%s | %s | Tranref: %s",
 		j.ID.Hex()[:8],
@@ -80,6 +373,306 @@ func (j JournalEntry) String() string {
 	)
 }
 
+// --------------------------
+//  Queries
+// --------------------------
+
+const (
+	defaultPageLimit int64 = 50
+	maxPageLimit     int64 = 500
+)
+
+// Pagination selects a page of a listing. Limit defaults to 50 and is capped at 500.
+type Pagination struct {
+	Limit int64 `json:"limit"`
+	Skip  int64 `json:"skip"`
+}
+
+func (p Pagination) normalize() Pagination {
+	if p.Limit <= 0 {
+		p.Limit = defaultPageLimit
+	}
+	if p.Limit > maxPageLimit {
+		p.Limit = maxPageLimit
+	}
+	if p.Skip < 0 {
+		p.Skip = 0
+	}
+	return p
+}
+
+// AccountFilter selects the accounts returned by ListAccounts. Zero fields match every account.
+type AccountFilter struct {
+	Type          AccountType
+	NameRegex     string    // Regular expression matched against the name, case-insensitive
+	CreatedAfter  time.Time // Inclusive lower bound of CreatedAt
+	CreatedBefore time.Time // Exclusive upper bound of CreatedAt
+
+	IncludeArchived bool // List archived accounts too, see ArchiveAccount
+}
+
+// validate checks the name pattern
+func (f AccountFilter) validate() error {
+	if f.NameRegex == "" {
+		return nil
+	}
+	if _, err := regexp.Compile(f.NameRegex); err != nil {
+		return fmt.Errorf("%w: name pattern: %v", ErrInvalidFilter, err)
+	}
+	return nil
+}
+
+func (f AccountFilter) query() (bson.M, error) {
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+	q := bson.M{}
+	if f.Type != "" {
+		q["type"] = f.Type
+	}
+	if f.NameRegex != "" {
+		q["name"] = primitive.Regex{Pattern: f.NameRegex, Options: "i"}
+	}
+	createdAt := bson.M{}
+	if !f.CreatedAfter.IsZero() {
+		createdAt["$gte"] = f.CreatedAfter
+	}
+	if !f.CreatedBefore.IsZero() {
+		createdAt["$lt"] = f.CreatedBefore
+	}
+	if len(createdAt) > 0 {
+		q["created_at"] = createdAt
+	}
+	if !f.IncludeArchived {
+		q["status"] = bson.M{"$ne": AccountArchived}
+	}
+	return q, nil
+}
+
+// JournalFilter selects the journal entries returned by QueryJournals. Zero fields match every entry.
+type JournalFilter struct {
+	AccountID primitive.ObjectID // Entries with a leg on the account
+	Type      TransactionType
+	TranRef   string
+	MinAmount decimal.Decimal   // Inclusive lower bound of Amount
+	MaxAmount decimal.Decimal   // Inclusive upper bound of Amount
+	From      time.Time         // Inclusive lower bound of CreatedAt
+	To        time.Time         // Inclusive upper bound of CreatedAt
+	Narrative string            // Case-insensitive substring of Narrative
+	Metadata  map[string]string // Entries carrying every one of these key/value pairs
+
+	Archived bool // Search the archived entries instead, see ArchiveJournals
+}
+
+// validate checks the amount and date ranges
+func (f JournalFilter) validate() error {
+	if f.MinAmount.IsNegative() || f.MaxAmount.IsNegative() {
+		return fmt.Errorf("%w: amount bounds must not be negative", ErrInvalidFilter)
+	}
+	if !f.MaxAmount.IsZero() && f.MinAmount.GreaterThan(f.MaxAmount) {
+		return fmt.Errorf("%w: min amount %s exceeds max amount %s", ErrInvalidFilter, f.MinAmount, f.MaxAmount)
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && f.To.Before(f.From) {
+		return fmt.Errorf("%w: date range ends before it starts", ErrInvalidFilter)
+	}
+	if err := validateMetadata(f.Metadata); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFilter, err)
+	}
+	return nil
+}
+
+func (f JournalFilter) query() (bson.M, error) {
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+	q := bson.M{}
+	if !f.AccountID.IsZero() {
+		q["$or"] = []bson.M{
+			{"debit_account": f.AccountID},
+			{"credit_account": f.AccountID},
+			{"legs.account_id": f.AccountID},
+		}
+	}
+	if f.Type != "" {
+		q["type"] = f.Type
+	}
+	if f.TranRef != "" {
+		q["tranref"] = f.TranRef
+	}
+	if f.Narrative != "" {
+		q["narrative"] = primitive.Regex{Pattern: regexp.QuoteMeta(f.Narrative), Options: "i"}
+	}
+	for k, v := range f.Metadata {
+		q["metadata."+k] = v
+	}
+	createdAt := bson.M{}
+	if !f.From.IsZero() {
+		createdAt["$gte"] = f.From
+	}
+	if !f.To.IsZero() {
+		createdAt["$lte"] = f.To
+	}
+	if len(createdAt) > 0 {
+		q["created_at"] = createdAt
+	}
+
+	// amounts are decimal strings, compared as numbers on the server
+	var amount []bson.M
+	bound := func(op string, d decimal.Decimal) error {
+		if d.IsZero() {
+			return nil
+		}
+		v, err := toDecimal128(d)
+		if err != nil {
+			return err
+		}
+		amount = append(amount, bson.M{op: bson.A{bson.M{"$toDecimal": "$amount"}, v}})
+		return nil
+	}
+	if err := bound("$gte", f.MinAmount); err != nil {
+		return nil, err
+	}
+	if err := bound("$lte", f.MaxAmount); err != nil {
+		return nil, err
+	}
+	if len(amount) > 0 {
+		q["$expr"] = bson.M{"$and": amount}
+	}
+	return q, nil
+}
+
+// JournalSortField is the field QueryJournals orders entries by
+type JournalSortField string
+
+const (
+	SortByCreatedAt JournalSortField = "created_at"
+	SortByAmount    JournalSortField = "amount"
+)
+
+// JournalSort orders the entries of QueryJournals, ties broken by ID. The zero value
+// lists the newest entries first.
+type JournalSort struct {
+	Field     JournalSortField // SortByCreatedAt when empty
+	Ascending bool
+}
+
+func (s JournalSort) validate() error {
+	switch s.Field {
+	case "", SortByCreatedAt, SortByAmount:
+		return nil
+	}
+	return fmt.Errorf("%w: cannot sort journals by %q", ErrInvalidFilter, s.Field)
+}
+
+// --------------------------
+//  Account Statement
+// --------------------------
+
+// EntryDirection is the side of a journal entry an account is on
+type EntryDirection string
+
+const (
+	DirectionDebit  EntryDirection = "DR"
+	DirectionCredit EntryDirection = "CR"
+)
+
+// Opposite returns the other side
+func (d EntryDirection) Opposite() EntryDirection {
+	if d == DirectionDebit {
+		return DirectionCredit
+	}
+	return DirectionDebit
+}
+
+// StatementLine is one journal leg affecting the statement account
+type StatementLine struct {
+	JournalID      primitive.ObjectID `json:"journal_id"`
+	Type           TransactionType    `json:"type"`
+	TranRef        string             `json:"tranref"`
+	Narrative      string             `json:"narrative,omitempty"`
+	Metadata       map[string]string  `json:"metadata,omitempty"`
+	Direction      EntryDirection     `json:"direction"`
+	CounterAccount primitive.ObjectID `json:"counter_account"`
+	Amount         decimal.Decimal    `json:"amount"`
+	Balance        decimal.Decimal    `json:"balance"` // running balance after this line
+	CreatedAt      time.Time          `json:"created_at"`
+}
+
+// AccountStatement lists the journal legs of an account over a period, oldest first.
+// OpeningBalance is the balance before From, ClosingBalance the balance at To; with
+// pagination the running balances still account for the lines of earlier pages.
+type AccountStatement struct {
+	AccountID      primitive.ObjectID `json:"account_id"`
+	AccountType    AccountType        `json:"account_type"`
+	AccountName    string             `json:"account_name"`
+	From           time.Time          `json:"from"`
+	To             time.Time          `json:"to"`
+	OpeningBalance decimal.Decimal    `json:"opening_balance"`
+	ClosingBalance decimal.Decimal    `json:"closing_balance"`
+	TotalLines     int64              `json:"total_lines"`
+	Lines          []StatementLine    `json:"lines"`
+}
+
+// --------------------------
+//  Trial Balance
+// --------------------------
+
+// TrialBalanceLine is the balance of one account in the debit or credit column.
+// Balances are credits minus debits, so a negative balance is a debit balance.
+type TrialBalanceLine struct {
+	AccountID   primitive.ObjectID `json:"account_id"`
+	AccountType AccountType        `json:"account_type"`
+	AccountName string             `json:"account_name"`
+	Debit       decimal.Decimal    `json:"debit"`
+	Credit      decimal.Decimal    `json:"credit"`
+}
+
+// TrialBalanceSubtotal sums the lines of one account type
+type TrialBalanceSubtotal struct {
+	AccountType  AccountType     `json:"account_type"`
+	AccountCount int             `json:"account_count"`
+	Debit        decimal.Decimal `json:"debit"`
+	Credit       decimal.Decimal `json:"credit"`
+}
+
+// TrialBalance lists the balance of every account at AsOf, grouped by account type
+type TrialBalance struct {
+	AsOf        time.Time              `json:"as_of"`
+	Lines       []TrialBalanceLine     `json:"lines"`
+	Subtotals   []TrialBalanceSubtotal `json:"subtotals"`
+	TotalDebit  decimal.Decimal        `json:"total_debit"`
+	TotalCredit decimal.Decimal        `json:"total_credit"`
+}
+
+// Difference returns total credits minus total debits, zero when the ledger balances
+func (tb *TrialBalance) Difference() decimal.Decimal {
+	return tb.TotalCredit.Sub(tb.TotalDebit)
+}
+
+// Balanced reports whether debits equal credits
+func (tb *TrialBalance) Balanced() bool {
+	return tb.Difference().IsZero()
+}
+
+// --------------------------
+//  Balance Snapshots
+// --------------------------
+
+// BalanceSnapshot records the balance of an account at a point in time so
+// historical balances can be computed without replaying the whole journal.
+type BalanceSnapshot struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	AccountID primitive.ObjectID `bson:"account_id"`
+	Balance   string             `bson:"balance"` // decimal string
+	AsOf      time.Time          `bson:"as_of"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+func (b BalanceSnapshot) GetBalance() decimal.Decimal {
+	d, _ := decimal.NewFromString(b.Balance)
+	return d
+}
+
 // --------------------------
 //  Reconciliation Result
 // --------------------------
@@ -107,7 +700,14 @@ type ReconciliationResult struct {
 // --------------------------
 
 type AccountingService struct {
-	db       *mongo.Database
-	accounts *mongo.Collection
-	journals *mongo.Collection
+	rules       PostingRules      // nil uses DefaultPostingRules
+	transfers   TransferMatrix    // nil uses DefaultTransferMatrix
+	idempotent  bool              // postings are deduplicated by transaction reference and type
+	outbox      bool              // postings write an OutboxMessage, see EnableOutbox
+	commissions []CommissionRule  // see SetCommissionRules
+	branding    StatementBranding // see SetStatementBranding
+	rounding    *RoundingPolicy   // nil keeps amounts as given, see SetRoundingPolicy
+	logo        image.Image       // decoded branding.Logo
+	repo        AccountingRepository
+	events      JournalEvents
 }
@@ -3,14 +3,51 @@ package accounting
 // should expose an instance of accounting service
 
 import (
+	"context"
+
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// AccountingConfig configures NewAccountingServiceWithConfig
+type AccountingConfig struct {
+	EnsureIndexes      bool // Create the ledger indexes on start, see EnsureIndexes
+	IdempotentPostings bool // Deduplicate postings by tranref and type, see EnableIdempotentPostings
+	Events             JournalEvents
+	Retry              RetryPolicy // Retries of transactions failing with a transient error, zero uses DefaultRetryPolicy
+	Outbox             bool        // Write an outbox message with every posting, see EnableOutbox
+}
+
 func NewAccountingService(db *mongo.Database) *AccountingService {
+	return NewAccountingServiceWithRepository(NewMongoRepository(db))
+}
 
-	return &AccountingService{
-		db:       db,
-		accounts: db.Collection("accounts"),
-		journals: db.Collection("journals"),
+// NewAccountingServiceWithRepository returns the accounting service storing the ledger in
+// repo, e.g. a PostgresRepository
+func NewAccountingServiceWithRepository(repo AccountingRepository) *AccountingService {
+	return &AccountingService{repo: repo}
+}
+
+// NewAccountingServiceWithConfig returns the accounting service storing the ledger in db,
+// preparing the database as cfg asks
+func NewAccountingServiceWithConfig(ctx context.Context, db *mongo.Database, cfg AccountingConfig) (*AccountingService, error) {
+	repo := NewMongoRepository(db)
+	repo.SetRetryPolicy(cfg.Retry)
+	s := NewAccountingServiceWithRepository(repo)
+	if cfg.Events.Broker != nil || cfg.Events.Outbox != nil {
+		s.SetJournalEvents(cfg.Events)
+	}
+	if cfg.Outbox {
+		s.EnableOutbox()
+	}
+	if cfg.IdempotentPostings {
+		if err := s.EnableIdempotentPostings(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.EnsureIndexes {
+		if err := s.EnsureIndexes(ctx); err != nil {
+			return nil, err
+		}
 	}
+	return s, nil
 }
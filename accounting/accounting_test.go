@@ -4,76 +4,66 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/nana-tec/gopackages/accounting/accountingtest"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// setupTestDB starts an in-memory MongoDB and returns a connected service
-func setupTestDB(t *testing.T) (*AccountingService, func()) {
-	ctx := context.Background()
-	//.WaitForLog("Waiting for connections"),
-	// Start MongoDB container
-	/*mongoContainer, err := mongodb.RunContainer(ctx,
-		testcontainers.WithImage("mongo:7"),
-		testcontainers.WithWaitStrategy(
-			wait.ForLog("Waiting for connections"),
-		),
-		mongodb.WithReplicaSet("rs0"),
-	)
-	require.NoError(t, err)
-
-	// Get connection string
-	uri, err := mongoContainer.ConnectionString(ctx)
-	uri = "mongodb://localhost:27017/?replicaSet=rs0"
-	require.NoError(t, err)
-
-	// Connect service
-	s := &AccountingService{}
-	s.db, err = connectToMongo(uri)
-	require.NoError(t, err)
-	*/
-	s := newAccountingService()
-	s.accounts = s.db.Collection("accounts")
-	s.journals = s.db.Collection("journals")
-
-	// Cleanup
-	cleanup := func() {
-		//_ = s.db.Drop(ctx)
-		_ = s.db.Client().Disconnect(ctx)
-	}
-
-	return s, cleanup
-}
+// testMongo is the MongoDB the integration tests run against, nil to run them on a
+// MemoryRepository; see TestMain
+var testMongo *mongo.Client
 
-func newAccountingService() *AccountingService {
-	clientOpts := options.Client().ApplyURI("mongodb://localhost:27017/?replicaSet=rs0")
-	client, err := mongo.Connect(context.Background(), clientOpts)
-	if err != nil {
-		log.Fatal(err)
+// TestMain picks the store of the integration tests from ACCOUNTING_TEST_MONGO: unset
+// runs them in memory, "container" on a MongoDB started in Docker, anything else is the
+// URI of a replica set
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+	var terminate func()
+	switch uri := os.Getenv("ACCOUNTING_TEST_MONGO"); uri {
+	case "":
+	case "container":
+		c, err := accountingtest.StartMongo(ctx, os.Getenv("ACCOUNTING_TEST_MONGO_IMAGE"))
+		if err != nil {
+			log.Fatal(err)
+		}
+		testMongo = c.Client
+		terminate = func() { _ = c.Terminate(ctx) }
+	default:
+		client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+		if err != nil {
+			log.Fatal(err)
+		}
+		testMongo = client
+		terminate = func() { _ = client.Disconnect(ctx) }
 	}
-	db := client.Database("insurance_db")
-	println("Connected to MongoDB")
-	return &AccountingService{
-		db:       db,
-		accounts: db.Collection("accounts"),
-		journals: db.Collection("journals"),
+	code := m.Run()
+	if terminate != nil {
+		terminate()
 	}
+	os.Exit(code)
 }
 
-// Helper to connect (extracted from NewAccountingService)
-func connectToMongo(uri string) (*mongo.Database, error) {
-	clientOpts := options.Client().ApplyURI(uri)
-	client, err := mongo.Connect(context.Background(), clientOpts)
-	if err != nil {
-		return nil, err
+// setupTestDB returns a service on an empty ledger: a database of its own on testMongo,
+// dropped by the cleanup, or a MemoryRepository
+func setupTestDB(t *testing.T) (*AccountingService, func()) {
+	if testMongo == nil {
+		return NewAccountingServiceWithRepository(NewMemoryRepository()), func() {}
 	}
-	db := client.Database("test_db")
-	return db, nil
+	ctx := context.Background()
+	db := testMongo.Database("accounting_test_" + primitive.NewObjectID().Hex())
+	s := NewAccountingService(db)
+	require.NoError(t, s.EnsureIndexes(ctx))
+	return s, func() { _ = db.Drop(ctx) }
 }
 
 // === TESTS ===
@@ -143,6 +133,35 @@ func TestPremiumPayment_BalanceAndJournal(t *testing.T) {
 	assert.Equal(t, underwriterAcc.ID, e.CreditAccount)
 }
 
+func TestReconcileAccount(t *testing.T) {
+	t.Parallel()
+	s, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	underwriterAcc, _ := s.CreateAccount(ctx, UnderwriterPremiumPayable, decimal.NewFromFloat(1000), "Underwriter Reconciliation")
+	agentAcc, _ := s.CreateAccount(ctx, AgentCommissionEarned, decimal.Zero, "Agent Reconciliation")
+	idleAcc, _ := s.CreateAccount(ctx, AgentCommissionEarned, decimal.NewFromFloat(10), "Idle Reconciliation")
+	require.NoError(t, s.PostAgentCommission(ctx, underwriterAcc.ID, agentAcc.ID, decimal.NewFromFloat(150), "reconcileref1"))
+
+	res, err := s.ReconcileAccount(ctx, underwriterAcc.ID)
+	require.NoError(t, err)
+	assert.Equal(t, Reconciled, res.Status)
+	assert.True(t, decimal.NewFromFloat(850).Equal(res.ComputedBalance), res.ComputedBalance.String())
+	assert.True(t, res.Discrepancy.IsZero())
+	assert.Equal(t, 1, res.JournalCount)
+
+	res, err = s.ReconcileAccount(ctx, agentAcc.ID)
+	require.NoError(t, err)
+	assert.Equal(t, Reconciled, res.Status)
+	assert.True(t, decimal.NewFromFloat(150).Equal(res.ComputedBalance), res.ComputedBalance.String())
+
+	res, err = s.ReconcileAccount(ctx, idleAcc.ID)
+	require.NoError(t, err)
+	assert.Equal(t, NoTransactions, res.Status)
+	assert.True(t, res.Discrepancy.IsZero())
+}
+
 /*
 	func TestAgentCommission_Reconciliation(t *testing.T) {
 		t.Parallel()
@@ -287,3 +306,343 @@ func TestJournal_DebitsEqualCredits(t *testing.T) {
 	assert.True(t, totalDebit.Equal(totalCredit), "Debits must equal Credits")
 	assert.True(t, totalDebit.Equal(decimal.NewFromFloat(1770)))
 }
+
+func TestReverseJournalEntry_OffsetsOnce(t *testing.T) {
+	t.Parallel()
+	s, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	clientAcc, _ := s.CreateAccount(ctx, ClientInsurance, decimal.Zero, "Client Reversal")
+	gatewayAcc, _ := s.CreateAccount(ctx, PaymentGateway, decimal.Zero, "Gateway Reversal")
+	require.NoError(t, s.ClientAccountTopUp(ctx, clientAcc.ID, gatewayAcc.ID, decimal.NewFromInt(400), "reversalref1"))
+
+	entries, _ := s.GetJournalEntriesByRef(ctx, "reversalref1")
+	require.Len(t, entries, 1)
+	reversal, err := s.ReverseJournalEntry(ctx, entries[0].ID, "posted twice")
+	require.NoError(t, err)
+	assert.Equal(t, Reversal, reversal.Type)
+	assert.Equal(t, clientAcc.ID, reversal.DebitAccount)
+	assert.Equal(t, gatewayAcc.ID, reversal.CreditAccount)
+
+	clientBal, _ := s.GetAccountBalance(ctx, clientAcc.ID)
+	gatewayBal, _ := s.GetAccountBalance(ctx, gatewayAcc.ID)
+	assert.True(t, clientBal.IsZero())
+	assert.True(t, gatewayBal.IsZero())
+
+	_, err = s.ReverseJournalEntry(ctx, entries[0].ID, "posted twice")
+	assert.ErrorIs(t, err, ErrAlreadyReversed)
+	_, err = s.ReverseJournalEntry(ctx, reversal.ID, "undo")
+	assert.ErrorIs(t, err, ErrNotReversible)
+}
+
+func TestGetOrCreateAccount_UniqueRef(t *testing.T) {
+	t.Parallel()
+	s, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	const signups = 8
+	ids := make(chan primitive.ObjectID, signups)
+	var wg sync.WaitGroup
+	for range signups {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			acc, err := s.GetOrCreateAccount(ctx, "CUST-42", ClientInsurance, "Client 42")
+			if assert.NoError(t, err) {
+				ids <- acc.ID
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+	first := <-ids
+	for id := range ids {
+		assert.Equal(t, first, id)
+	}
+
+	acc, err := s.GetAccountByRef(ctx, "CUST-42")
+	require.NoError(t, err)
+	assert.Equal(t, first, acc.ID)
+	assert.Equal(t, "Client 42", acc.Name)
+	assert.True(t, acc.GetBalance().IsZero())
+
+	_, err = s.GetOrCreateAccount(ctx, "CUST-42", AgentCommissionEarned, "Agent 42")
+	assert.ErrorIs(t, err, ErrDuplicateAccountRef)
+	_, err = s.GetOrCreateAccount(ctx, " ", ClientInsurance, "nobody")
+	assert.ErrorIs(t, err, ErrInvalidAccountRef)
+	_, err = s.GetAccountByRef(ctx, "CUST-43")
+	assert.ErrorIs(t, err, ErrAccountNotFound)
+}
+
+func TestGetJournalEntriesByRef_Filters(t *testing.T) {
+	t.Parallel()
+	s, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	clientAcc, _ := s.CreateAccount(ctx, ClientInsurance, decimal.Zero, "Client Ref")
+	gatewayAcc, _ := s.CreateAccount(ctx, PaymentGateway, decimal.Zero, "Gateway Ref")
+	require.NoError(t, s.ClientAccountTopUp(ctx, clientAcc.ID, gatewayAcc.ID, decimal.NewFromInt(400), "filterref1"))
+	entries, err := s.GetJournalEntriesByRef(ctx, "filterref1")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	reversal, err := s.ReverseJournalEntry(ctx, entries[0].ID, "posted twice")
+	require.NoError(t, err)
+
+	entries, err = s.GetJournalEntriesByRef(ctx, "filterref1")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, TopUp, entries[0].Type)
+	assert.Equal(t, reversal.ID, entries[1].ID)
+
+	entries, err = s.GetJournalEntriesByRef(ctx, "filterref1", WithRefType(Reversal))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, reversal.ID, entries[0].ID)
+
+	entries, err = s.GetJournalEntriesByRef(ctx, "filterref1", WithRefPeriod(time.Now().Add(time.Hour), time.Time{}))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	_, err = s.GetJournalEntriesByRef(ctx, "filterref1", WithRefPeriod(time.Now(), time.Now().Add(-time.Hour)))
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+	_, err = s.GetJournalEntriesByRef(ctx, "")
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+
+	got, err := s.GetJournalByID(ctx, reversal.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "filterref1", got.TranRef)
+	_, err = s.GetJournalByID(ctx, primitive.NewObjectID())
+	assert.ErrorIs(t, err, ErrJournalNotFound)
+}
+
+func TestBalanceSnapshot_BalanceAsOf(t *testing.T) {
+	t.Parallel()
+	s, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	clientAcc, _ := s.CreateAccount(ctx, ClientInsurance, decimal.Zero, "Client Snapshot")
+	gatewayAcc, _ := s.CreateAccount(ctx, PaymentGateway, decimal.Zero, "Gateway Snapshot")
+	require.NoError(t, s.ClientAccountTopUp(ctx, clientAcc.ID, gatewayAcc.ID, decimal.NewFromInt(300), "snapshotref1"))
+
+	periodEnd := time.Now()
+	snap, err := s.CreateBalanceSnapshot(ctx, clientAcc.ID, periodEnd)
+	require.NoError(t, err)
+	assert.True(t, snap.GetBalance().Equal(decimal.NewFromInt(300)))
+
+	require.NoError(t, s.ClientAccountTopUp(ctx, clientAcc.ID, gatewayAcc.ID, decimal.NewFromInt(200), "snapshotref2"))
+	b, err := s.GetBalanceAsOf(ctx, clientAcc.ID, time.Now())
+	require.NoError(t, err)
+	assert.True(t, b.Equal(decimal.NewFromInt(500)))
+
+	snaps, err := s.ListSnapshots(ctx, clientAcc.ID, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, snaps, 1)
+}
+
+func TestMigrateBalancesToDecimal128(t *testing.T) {
+	t.Parallel()
+	s, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+	repo, ok := s.repo.(*MongoRepository)
+	if !ok {
+		t.Skip("string balances only exist in MongoDB")
+	}
+
+	legacyID := primitive.NewObjectID()
+	_, err := repo.accounts.InsertOne(ctx, bson.M{"_id": legacyID, "type": ClientInsurance, "balance": "250.75", "opening_balance": "0", "name": "Legacy Client", "created_at": time.Now()})
+	require.NoError(t, err)
+
+	n, err := s.MigrateBalancesToDecimal128(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = s.MigrateBalancesToDecimal128(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	gatewayAcc, _ := s.CreateAccount(ctx, PaymentGateway, decimal.Zero, "Gateway Legacy")
+	require.NoError(t, s.ClientAccountTopUp(ctx, legacyID, gatewayAcc.ID, decimal.RequireFromString("0.25"), "legacyref1"))
+	b, err := s.GetAccountBalance(ctx, legacyID)
+	require.NoError(t, err)
+	assert.True(t, b.Equal(decimal.NewFromInt(251)))
+}
+
+func TestAccountLifecycle_FreezeAndClose(t *testing.T) {
+	t.Parallel()
+	s, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	clientAcc, _ := s.CreateAccount(ctx, ClientInsurance, decimal.Zero, "Client Lifecycle")
+	successor, _ := s.CreateAccount(ctx, ClientInsurance, decimal.Zero, "Client Lifecycle Successor")
+	gatewayAcc, _ := s.CreateAccount(ctx, PaymentGateway, decimal.Zero, "Gateway Lifecycle")
+	require.NoError(t, s.ClientAccountTopUp(ctx, clientAcc.ID, gatewayAcc.ID, decimal.NewFromInt(250), "lifecycleref1"))
+
+	require.NoError(t, s.FreezeAccount(ctx, clientAcc.ID, "chargeback"))
+	err := s.ClientAccountTopUp(ctx, clientAcc.ID, gatewayAcc.ID, decimal.NewFromInt(50), "lifecycleref2")
+	assert.ErrorIs(t, err, ErrAccountFrozen)
+	require.NoError(t, s.UnfreezeAccount(ctx, clientAcc.ID))
+
+	_, err = s.CloseAccount(ctx, clientAcc.ID, primitive.NilObjectID, "policy ended")
+	assert.ErrorIs(t, err, ErrNonZeroBalance)
+	_, err = s.CloseAccount(ctx, clientAcc.ID, gatewayAcc.ID, "policy ended")
+	assert.ErrorIs(t, err, ErrPostingRule)
+
+	transfer, err := s.CloseAccount(ctx, clientAcc.ID, successor.ID, "policy ended")
+	require.NoError(t, err)
+	require.NotNil(t, transfer)
+	assert.Equal(t, AccountClosure, transfer.Type)
+	assert.Equal(t, clientAcc.ID, transfer.DebitAccount)
+
+	closed, _ := s.GetAccountByID(ctx, clientAcc.ID)
+	assert.Equal(t, AccountClosed, closed.GetStatus())
+	assert.True(t, closed.GetBalance().IsZero())
+	successorBal, _ := s.GetAccountBalance(ctx, successor.ID)
+	assert.True(t, successorBal.Equal(decimal.NewFromInt(250)))
+
+	err = s.ClientAccountTopUp(ctx, clientAcc.ID, gatewayAcc.ID, decimal.NewFromInt(50), "lifecycleref3")
+	assert.ErrorIs(t, err, ErrAccountClosed)
+	assert.ErrorIs(t, s.FreezeAccount(ctx, clientAcc.ID, "late"), ErrAccountClosed)
+}
+
+func TestQueryJournals_FilterSortAndStream(t *testing.T) {
+	t.Parallel()
+	s, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	clientAcc, _ := s.CreateAccount(ctx, ClientInsurance, decimal.Zero, "Client Query")
+	gatewayAcc, _ := s.CreateAccount(ctx, PaymentGateway, decimal.Zero, "Gateway Query")
+	for i, amount := range []int64{30, 100, 5} {
+		require.NoError(t, s.ClientAccountTopUp(ctx, clientAcc.ID, gatewayAcc.ID, decimal.NewFromInt(amount), fmt.Sprintf("queryref%d", i)))
+	}
+
+	filter := JournalFilter{AccountID: clientAcc.ID, MinAmount: decimal.NewFromInt(10)}
+	entries, total, err := s.QueryJournals(ctx, filter, JournalSort{Field: SortByAmount}, Pagination{Limit: 1})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "100", entries[0].Amount)
+
+	var streamed []string
+	require.NoError(t, s.StreamJournals(ctx, JournalFilter{AccountID: clientAcc.ID}, JournalSort{Ascending: true}, func(e *JournalEntry) error {
+		streamed = append(streamed, e.TranRef)
+		return nil
+	}))
+	assert.Equal(t, []string{"queryref0", "queryref1", "queryref2"}, streamed)
+}
+
+func TestDashboards_Aggregations(t *testing.T) {
+	t.Parallel()
+	s, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// the test database is shared, so only lower bounds hold for the totals
+	start := time.Now().Add(-time.Second)
+	clientAcc, _ := s.CreateAccount(ctx, ClientInsurance, decimal.Zero, "Client Dashboard")
+	gatewayAcc, _ := s.CreateAccount(ctx, PaymentGateway, decimal.Zero, "Gateway Dashboard")
+	underwriterAcc, _ := s.CreateAccount(ctx, UnderwriterPremiumPayable, decimal.Zero, "Underwriter Dashboard")
+	require.NoError(t, s.ClientAccountTopUp(ctx, clientAcc.ID, gatewayAcc.ID, decimal.NewFromInt(100), "dashref1"))
+	require.NoError(t, s.ClientAccountTopUp(ctx, clientAcc.ID, gatewayAcc.ID, decimal.NewFromInt(50), "dashref2"))
+	require.NoError(t, s.ClientPremiumPayment(ctx, clientAcc.ID, underwriterAcc.ID, decimal.NewFromInt(120), "dashref3"))
+
+	balances, err := s.GetBalancesByType(ctx)
+	require.NoError(t, err)
+	types := map[AccountType]int64{}
+	for _, b := range balances {
+		types[b.AccountType] = b.AccountCount
+	}
+	assert.GreaterOrEqual(t, types[ClientInsurance], int64(1))
+	assert.GreaterOrEqual(t, types[UnderwriterPremiumPayable], int64(1))
+
+	totals, err := s.GetDailyPostingTotals(ctx, start, time.Time{})
+	require.NoError(t, err)
+	var topUps DailyPostingTotal
+	for _, total := range totals {
+		if total.Type == TopUp {
+			topUps = total
+		}
+	}
+	assert.GreaterOrEqual(t, topUps.Count, int64(2))
+	assert.True(t, topUps.Amount.GreaterThanOrEqual(decimal.NewFromInt(150)))
+
+	top, err := s.GetTopAccountsByVolume(ctx, start, time.Time{}, 100)
+	require.NoError(t, err)
+	var client *AccountVolume
+	for i := range top {
+		if top[i].AccountID == clientAcc.ID {
+			client = &top[i]
+		}
+	}
+	require.NotNil(t, client)
+	assert.Equal(t, "Client Dashboard", client.AccountName)
+	assert.EqualValues(t, 3, client.Legs)
+	assert.True(t, client.Debits.Equal(decimal.NewFromInt(120)))
+	assert.True(t, client.Credits.Equal(decimal.NewFromInt(150)))
+	assert.True(t, client.Volume.Equal(decimal.NewFromInt(270)))
+}
+
+func TestWallet_TopUpAndPayPremium(t *testing.T) {
+	t.Parallel()
+	s, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	gatewayAcc, _ := s.CreateAccount(ctx, PaymentGateway, decimal.Zero, "Gateway Wallet")
+	underwriterAcc, _ := s.CreateAccount(ctx, UnderwriterPremiumPayable, decimal.Zero, "Underwriter Wallet")
+	w := NewWallet(s, WalletConfig{
+		GatewayAccountID: gatewayAcc.ID,
+		Underwriters:     map[string]primitive.ObjectID{"UW-1": underwriterAcc.ID},
+	})
+	clientRef := "CUST-" + primitive.NewObjectID().Hex()
+	_, err := w.OpenWallet(ctx, clientRef)
+	require.NoError(t, err)
+
+	require.NoError(t, w.TopUp(ctx, clientRef, decimal.NewFromInt(500), "walletref1", WithNarrative("M-Pesa top-up")))
+	require.NoError(t, w.PayPremium(ctx, clientRef, "UW-1", decimal.NewFromInt(200), "walletref2"))
+
+	balance, err := w.GetWalletBalance(ctx, clientRef)
+	require.NoError(t, err)
+	assert.True(t, balance.Equal(decimal.NewFromInt(300)))
+
+	statement, err := w.GetWalletStatement(ctx, clientRef, time.Time{}, time.Time{}, Pagination{})
+	require.NoError(t, err)
+	require.Len(t, statement.Lines, 2)
+	assert.Equal(t, "M-Pesa top-up", statement.Lines[0].Narrative)
+	assert.True(t, statement.ClosingBalance.Equal(decimal.NewFromInt(300)))
+}
+
+func TestAudit_ReversalAndListing(t *testing.T) {
+	t.Parallel()
+	s, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := WithActor(context.Background(), Actor{ID: "ops-" + primitive.NewObjectID().Hex(), Role: "ops"})
+
+	clientAcc, _ := s.CreateAccount(ctx, ClientInsurance, decimal.Zero, "Audit Client")
+	gatewayAcc, _ := s.CreateAccount(ctx, PaymentGateway, decimal.Zero, "Audit Gateway")
+	require.NoError(t, s.ClientAccountTopUp(ctx, clientAcc.ID, gatewayAcc.ID, decimal.NewFromInt(80), "auditref1"))
+	entries, err := s.GetJournalEntriesByRef(ctx, "auditref1")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	reversal, err := s.ReverseJournalEntry(ctx, entries[0].ID, "duplicate")
+	require.NoError(t, err)
+
+	actor, _ := ActorFromContext(ctx)
+	records, total, err := s.ListAuditRecords(ctx, AuditFilter{ActorID: actor.ID}, Pagination{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, records, 3)
+	assert.Equal(t, AuditJournalReversed, records[0].Action, "newest first")
+	assert.Equal(t, entries[0].ID.Hex(), records[0].EntityID)
+	assert.Equal(t, reversal.ID.Hex(), records[0].After["reversed_by"])
+	assert.Equal(t, "duplicate", records[0].Reason)
+
+	records, _, err = s.ListAuditRecords(ctx, AuditFilter{EntityID: clientAcc.ID.Hex(), Action: AuditAccountCreated}, Pagination{})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, actor, records[0].Actor)
+}
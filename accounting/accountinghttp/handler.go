@@ -0,0 +1,1429 @@
+// Package accountinghttp exposes the AccountingService ledger over HTTP/JSON so
+// services written in other languages can create accounts, post transactions and
+// read balances, journals and reconciliation reports.
+package accountinghttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nana-tec/gopackages/accounting"
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Ledger is the subset of *accounting.AccountingService served by the handler.
+type Ledger interface {
+	CreateAccount(ctx context.Context, accType accounting.AccountType, initialBalance decimal.Decimal, name string) (*accounting.Account, error)
+	GetAccountByID(ctx context.Context, accountID primitive.ObjectID) (*accounting.Account, error)
+	GetAccountByRef(ctx context.Context, ref string) (*accounting.Account, error)
+	GetOrCreateAccount(ctx context.Context, ref string, accType accounting.AccountType, name string) (*accounting.Account, error)
+	ListAccounts(ctx context.Context, filter accounting.AccountFilter, page accounting.Pagination) ([]accounting.Account, int64, error)
+	SetOverdraftPolicy(ctx context.Context, accountID primitive.ObjectID, allowNegative bool, limit decimal.Decimal) error
+	FreezeAccount(ctx context.Context, accountID primitive.ObjectID, reason string) error
+	UnfreezeAccount(ctx context.Context, accountID primitive.ObjectID) error
+	CloseAccount(ctx context.Context, accountID, residualAccID primitive.ObjectID, reason string) (*accounting.JournalEntry, error)
+	ArchiveAccount(ctx context.Context, accountID primitive.ObjectID, reason string) error
+	GetAccountBalance(ctx context.Context, accountID primitive.ObjectID) (decimal.Decimal, error)
+	GetBalanceAsOf(ctx context.Context, accountID primitive.ObjectID, t time.Time) (decimal.Decimal, error)
+	CreateBalanceSnapshots(ctx context.Context, asOf time.Time) ([]accounting.BalanceSnapshot, error)
+	ListSnapshots(ctx context.Context, accountID primitive.ObjectID, from, to time.Time) ([]accounting.BalanceSnapshot, error)
+	GetTrialBalance(ctx context.Context, asOf time.Time) (*accounting.TrialBalance, error)
+	VerifyLedgerIntegrity(ctx context.Context, from, to time.Time) (*accounting.IntegrityReport, error)
+	GetBalancesByType(ctx context.Context) ([]accounting.TypeBalance, error)
+	GetDailyPostingTotals(ctx context.Context, from, to time.Time) ([]accounting.DailyPostingTotal, error)
+	GetTopAccountsByVolume(ctx context.Context, from, to time.Time, n int) ([]accounting.AccountVolume, error)
+	GetAccountStatement(ctx context.Context, accountID primitive.ObjectID, from, to time.Time, page accounting.Pagination) (*accounting.AccountStatement, error)
+	ExportStatement(ctx context.Context, accountID primitive.ObjectID, from, to time.Time, w io.Writer, format accounting.ExportFormat) error
+	RenderStatementPDF(ctx context.Context, accountID primitive.ObjectID, period accounting.StatementPeriod, w io.Writer) error
+	ClientAccountTopUp(ctx context.Context, clientAccID, gatewayAccID primitive.ObjectID, amount decimal.Decimal, tranRef string, opts ...accounting.PostingOption) error
+	ClientPremiumPayment(ctx context.Context, clientAccID, underwriterAccID primitive.ObjectID, amount decimal.Decimal, tranRef string, opts ...accounting.PostingOption) error
+	PostAgentCommission(ctx context.Context, underwriterAccID, agentAccID primitive.ObjectID, amount decimal.Decimal, tranRef string, opts ...accounting.PostingOption) error
+	PayClaim(ctx context.Context, underwriterAccID, payeeAccID primitive.ObjectID, amount decimal.Decimal, tranRef string, opts ...accounting.PostingOption) error
+	RefundPremium(ctx context.Context, underwriterAccID, clientAccID primitive.ObjectID, amount decimal.Decimal, tranRef string, opts ...accounting.PostingOption) error
+	ClawbackCommission(ctx context.Context, agentAccID, underwriterAccID primitive.ObjectID, amount decimal.Decimal, tranRef string, opts ...accounting.PostingOption) error
+	SettleUnderwriter(ctx context.Context, underwriterAccID, gatewayAccID primitive.ObjectID, amount decimal.Decimal, tranRef string, opts ...accounting.PostingOption) error
+	ChargeGatewayFee(ctx context.Context, gatewayAccID, feeExpenseAccID primitive.ObjectID, amount decimal.Decimal, tranRef string, opts ...accounting.PostingOption) error
+	PostBatch(ctx context.Context, reqs []accounting.PostingRequest) (*accounting.BatchResult, error)
+	Transfer(ctx context.Context, fromAccID, toAccID primitive.ObjectID, amount decimal.Decimal, tranRef, narrative string, opts ...accounting.PostingOption) (*accounting.JournalEntry, error)
+	GetJournalEntries(ctx context.Context, limit, skip int64) ([]accounting.JournalEntry, error)
+	GetJournalEntriesByRef(ctx context.Context, tranRef string, opts ...accounting.RefOption) ([]accounting.JournalEntry, error)
+	GetJournalByID(ctx context.Context, journalID primitive.ObjectID) (*accounting.JournalEntry, error)
+	QueryJournals(ctx context.Context, filter accounting.JournalFilter, sort accounting.JournalSort, page accounting.Pagination) ([]accounting.JournalEntry, int64, error)
+	ExportJournals(ctx context.Context, filter accounting.JournalFilter, w io.Writer, format accounting.ExportFormat) error
+	ReverseJournalEntry(ctx context.Context, journalID primitive.ObjectID, reason string) (*accounting.JournalEntry, error)
+	PostCommissionForPremium(ctx context.Context, premiumJournalID primitive.ObjectID) (*accounting.JournalEntry, error)
+	PostToSuspense(ctx context.Context, gatewayAccID, suspenseAccID primitive.ObjectID, amount decimal.Decimal, tranRef string, opts ...accounting.PostingOption) (*accounting.JournalEntry, error)
+	MatchSuspenseEntry(ctx context.Context, suspenseJournalID, targetAccID primitive.ObjectID) (*accounting.JournalEntry, error)
+	UnmatchSuspenseEntry(ctx context.Context, clearingJournalID primitive.ObjectID, reason string) (*accounting.JournalEntry, error)
+	UnmatchedSuspenseEntries(ctx context.Context, suspenseAccID primitive.ObjectID) ([]accounting.JournalEntry, error)
+	ReconcileAccount(ctx context.Context, accountID primitive.ObjectID) (*accounting.ReconciliationResult, error)
+	GetReconciliationReport(ctx context.Context) ([]accounting.ReconciliationResult, error)
+	ListAuditRecords(ctx context.Context, filter accounting.AuditFilter, page accounting.Pagination) ([]accounting.AuditRecord, int64, error)
+}
+
+// AuthFunc authenticates a request. It returns the context the operation runs with,
+// typically enriched with the caller identity, or an error to reject the request
+// with 401 Unauthorized. Returning an error wrapping ErrForbidden yields 403 Forbidden.
+// Set the caller with accounting.WithActor to record them in the audit log.
+type AuthFunc func(r *http.Request) (context.Context, error)
+
+// ErrForbidden can be wrapped by an AuthFunc to reject an authenticated caller.
+var ErrForbidden = errors.New("forbidden")
+
+// Config configures the HTTP handler.
+type Config struct {
+	Auth        AuthFunc // Authentication hook, nil serves requests unauthenticated
+	BasePath    string   // Path prefix of all routes, e.g. "/ledger"
+	MaxBodySize int64    // Maximum request body size in bytes, defaults to 1 MiB
+}
+
+type handler struct {
+	svc Ledger
+	cfg Config
+	mux *http.ServeMux
+}
+
+// NewHandler returns an http.Handler serving the ledger API:
+//
+//	POST /accounts                           create an account
+//	GET  /accounts[?type=&name=&created_after=&created_before=&archived=&limit=&skip=]
+//	                                         list accounts, name is a case-insensitive pattern;
+//	                                         archived=true includes archived accounts
+//	GET  /accounts/{id}                      get an account
+//	PUT  /accounts/{id}/overdraft            set whether and how far the balance may go negative
+//	POST /accounts/{id}/freeze               block postings to an account
+//	POST /accounts/{id}/unfreeze             allow postings to a frozen account again
+//	POST /accounts/{id}/close                close an account, moving any residual to residual_account_id
+//	POST /accounts/{id}/archive              archive a closed account, hiding it from the account list
+//	GET  /accounts/{id}/balance[?as_of=]     current or historical (RFC 3339) balance
+//	GET  /accounts/{id}/statement[?from=&to=&limit=&skip=]
+//	                                         statement with running balance, RFC 3339 bounds
+//	GET  /accounts/{id}/statement/export[?from=&to=&format=]
+//	                                         statement download, format is csv (default) or xlsx
+//	GET  /accounts/{id}/statement/pdf[?from=&to=]
+//	                                         branded PDF statement for the client
+//	GET  /accounts/{id}/snapshots[?from=&to=] balance snapshots of an account
+//	GET  /accounts/{id}/reconciliation       reconcile a single account
+//	GET  /accounts/{id}/suspense             unmatched receipts of a suspense account, oldest first
+//	GET  /account-refs/{ref}                 get an account by external reference
+//	PUT  /account-refs/{ref}                 get or create the account of an external reference
+//	POST /snapshots                          snapshot every account at as_of (period close)
+//	GET  /reconciliation                     reconciliation report of all accounts
+//	GET  /trial-balance[?as_of=]             trial balance grouped by account type
+//	GET  /integrity[?from=&to=]              verify the journal hash chain, reports the first broken link
+//	GET  /audit[?entity_id=&actor_id=&action=&from=&to=&limit=&skip=]
+//	                                         audit log of administrative actions, newest first
+//	GET  /dashboard/balances                 total balance per account type
+//	GET  /dashboard/daily-totals[?from=&to=] entries posted per UTC day and type (default last 30 days)
+//	GET  /dashboard/top-accounts[?from=&to=&limit=]
+//	                                         accounts with the highest volume (default 10, at most 100)
+//	GET  /journals[?limit=&skip=]            latest journal entries
+//	GET  /journals/search[?account_id=&type=&tranref=&narrative=&metadata=&min_amount=&max_amount=&from=&to=&archived=&sort=&limit=&skip=]
+//	                                         filtered journal entries, sort is created_at or amount,
+//	                                         prefixed with - for descending (default -created_at);
+//	                                         metadata is key:value and may repeat;
+//	                                         archived=true searches the archived entries
+//	GET  /journals/export[?account_id=&type=&tranref=&narrative=&metadata=&min_amount=&max_amount=&from=&to=&archived=&format=]
+//	                                         journal download, a row per leg, csv (default) or xlsx
+//	GET  /journals/{id}                      a journal entry
+//	GET  /journals/ref/{tranRef}[?type=&from=&to=]
+//	                                         journal entries by transaction reference, oldest first
+//	POST /journals/{id}/reversal             reverse a journal entry
+//	POST /journals/{id}/commission           post the commission of a premium payment by the commission rules,
+//	                                         204 when the rule yields none
+//	POST /journals/{id}/match                clear a suspense receipt into account_id
+//	POST /journals/{id}/unmatch              reverse a suspense clearing, returning its receipt to unmatched
+//	POST /postings/topup                     client top-up
+//	POST /postings/premium                   client premium payment
+//	POST /postings/commission                agent commission
+//	POST /postings/claim                     claim payment
+//	POST /postings/premium-refund            premium refund
+//	POST /postings/commission-clawback       commission clawback
+//	POST /postings/underwriter-settlement    underwriter settlement
+//	POST /postings/gateway-fee               payment gateway fee
+//	POST /postings/transfer                  transfer between two accounts, returns the journal entry
+//	POST /postings/suspense                  unmatched gateway receipt, returns the journal entry to match
+//	POST /postings/batch                     post many postings by debit and credit account, each succeeding
+//	                                         or failing on its own; returns a result per posting
+func NewHandler(svc Ledger, cfg Config) http.Handler {
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = 1 << 20
+	}
+	cfg.BasePath = strings.TrimSuffix(cfg.BasePath, "/")
+	h := &handler{svc: svc, cfg: cfg, mux: http.NewServeMux()}
+
+	h.handle("POST /accounts", h.createAccount)
+	h.handle("GET /accounts", h.listAccounts)
+	h.handle("GET /accounts/{id}", h.getAccount)
+	h.handle("PUT /accounts/{id}/overdraft", h.setOverdraft)
+	h.handle("POST /accounts/{id}/freeze", h.freezeAccount)
+	h.handle("POST /accounts/{id}/unfreeze", h.unfreezeAccount)
+	h.handle("POST /accounts/{id}/close", h.closeAccount)
+	h.handle("POST /accounts/{id}/archive", h.archiveAccount)
+	h.handle("GET /accounts/{id}/balance", h.getBalance)
+	h.handle("GET /accounts/{id}/statement", h.getStatement)
+	h.handle("GET /accounts/{id}/statement/export", h.exportStatement)
+	h.handle("GET /accounts/{id}/statement/pdf", h.statementPDF)
+	h.handle("GET /accounts/{id}/snapshots", h.listSnapshots)
+	h.handle("GET /accounts/{id}/reconciliation", h.reconcileAccount)
+	h.handle("GET /accounts/{id}/suspense", h.unmatchedSuspense)
+	h.handle("GET /account-refs/{ref}", h.getAccountByRef)
+	h.handle("PUT /account-refs/{ref}", h.getOrCreateAccount)
+	h.handle("POST /snapshots", h.createSnapshots)
+	h.handle("GET /reconciliation", h.reconciliationReport)
+	h.handle("GET /trial-balance", h.trialBalance)
+	h.handle("GET /integrity", h.verifyIntegrity)
+	h.handle("GET /audit", h.listAudit)
+	h.handle("GET /dashboard/balances", h.balancesByType)
+	h.handle("GET /dashboard/daily-totals", h.dailyTotals)
+	h.handle("GET /dashboard/top-accounts", h.topAccounts)
+	h.handle("GET /journals", h.listJournals)
+	h.handle("GET /journals/search", h.searchJournals)
+	h.handle("GET /journals/export", h.exportJournals)
+	h.handle("GET /journals/{id}", h.getJournal)
+	h.handle("GET /journals/ref/{tranRef}", h.journalsByRef)
+	h.handle("POST /journals/{id}/reversal", h.reverseJournal)
+	h.handle("POST /journals/{id}/commission", h.postCommission)
+	h.handle("POST /journals/{id}/match", h.matchSuspense)
+	h.handle("POST /journals/{id}/unmatch", h.unmatchSuspense)
+	h.handle("POST /postings/topup", h.posting(svc.ClientAccountTopUp))
+	h.handle("POST /postings/premium", h.posting(svc.ClientPremiumPayment))
+	h.handle("POST /postings/commission", h.posting(svc.PostAgentCommission))
+	h.handle("POST /postings/claim", h.posting(svc.PayClaim))
+	h.handle("POST /postings/premium-refund", h.posting(svc.RefundPremium))
+	h.handle("POST /postings/commission-clawback", h.posting(svc.ClawbackCommission))
+	h.handle("POST /postings/underwriter-settlement", h.posting(svc.SettleUnderwriter))
+	h.handle("POST /postings/gateway-fee", h.posting(svc.ChargeGatewayFee))
+	h.handle("POST /postings/transfer", h.transfer)
+	h.handle("POST /postings/suspense", h.postToSuspense)
+	h.handle("POST /postings/batch", h.postBatch)
+	return h.mux
+}
+
+func (h *handler) handle(pattern string, fn func(w http.ResponseWriter, r *http.Request) error) {
+	method, path, _ := strings.Cut(pattern, " ")
+	h.mux.HandleFunc(method+" "+h.cfg.BasePath+path, func(w http.ResponseWriter, r *http.Request) {
+		if h.cfg.Auth != nil {
+			ctx, err := h.cfg.Auth(r)
+			if err != nil {
+				status := http.StatusUnauthorized
+				if errors.Is(err, ErrForbidden) {
+					status = http.StatusForbidden
+				}
+				writeError(w, status, err)
+				return
+			}
+			if ctx != nil {
+				r = r.WithContext(ctx)
+			}
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, h.cfg.MaxBodySize)
+		if err := fn(w, r); err != nil {
+			writeError(w, statusFor(err), err)
+		}
+	})
+}
+
+// --------------------------
+//  Accounts
+// --------------------------
+
+type createAccountRequest struct {
+	Type           accounting.AccountType `json:"type"`
+	Name           string                 `json:"name"`
+	InitialBalance decimal.Decimal        `json:"initial_balance"`
+}
+
+func (h *handler) createAccount(w http.ResponseWriter, r *http.Request) error {
+	var req createAccountRequest
+	if err := decodeBody(r, &req); err != nil {
+		return err
+	}
+	if req.Type == "" || strings.TrimSpace(req.Name) == "" {
+		return badRequest("type and name are required")
+	}
+	acc, err := h.svc.CreateAccount(r.Context(), req.Type, req.InitialBalance, req.Name)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusCreated, newAccountResponse(acc))
+}
+
+type accountListResponse struct {
+	Accounts []accountResponse `json:"accounts"`
+	Total    int64             `json:"total"`
+}
+
+func (h *handler) listAccounts(w http.ResponseWriter, r *http.Request) error {
+	q := r.URL.Query()
+	filter := accounting.AccountFilter{
+		Type:      accounting.AccountType(q.Get("type")),
+		NameRegex: q.Get("name"),
+	}
+	var err error
+	if filter.CreatedAfter, err = queryTime(r, "created_after"); err != nil {
+		return err
+	}
+	if filter.CreatedBefore, err = queryTime(r, "created_before"); err != nil {
+		return err
+	}
+	if filter.IncludeArchived, err = queryBool(r, "archived"); err != nil {
+		return err
+	}
+	var page accounting.Pagination
+	if page.Limit, err = queryInt(r, "limit"); err != nil {
+		return err
+	}
+	if page.Skip, err = queryInt(r, "skip"); err != nil {
+		return err
+	}
+
+	accounts, total, err := h.svc.ListAccounts(r.Context(), filter, page)
+	if err != nil {
+		return err
+	}
+	resp := accountListResponse{Accounts: make([]accountResponse, 0, len(accounts)), Total: total}
+	for i := range accounts {
+		resp.Accounts = append(resp.Accounts, newAccountResponse(&accounts[i]))
+	}
+	return writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *handler) getAccount(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathObjectID(r, "id")
+	if err != nil {
+		return err
+	}
+	acc, err := h.svc.GetAccountByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, newAccountResponse(acc))
+}
+
+func (h *handler) getAccountByRef(w http.ResponseWriter, r *http.Request) error {
+	acc, err := h.svc.GetAccountByRef(r.Context(), r.PathValue("ref"))
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, newAccountResponse(acc))
+}
+
+type accountRefRequest struct {
+	Type accounting.AccountType `json:"type"`
+	Name string                 `json:"name"`
+}
+
+func (h *handler) getOrCreateAccount(w http.ResponseWriter, r *http.Request) error {
+	var req accountRefRequest
+	if err := decodeBody(r, &req); err != nil {
+		return err
+	}
+	if req.Type == "" || strings.TrimSpace(req.Name) == "" {
+		return badRequest("type and name are required")
+	}
+	acc, err := h.svc.GetOrCreateAccount(r.Context(), r.PathValue("ref"), req.Type, req.Name)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, newAccountResponse(acc))
+}
+
+type balanceResponse struct {
+	AccountID string          `json:"account_id"`
+	Balance   decimal.Decimal `json:"balance"`
+	AsOf      time.Time       `json:"as_of"`
+}
+
+type overdraftRequest struct {
+	AllowNegative  bool            `json:"allow_negative"`
+	OverdraftLimit decimal.Decimal `json:"overdraft_limit"` // zero for no limit
+}
+
+func (h *handler) setOverdraft(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathObjectID(r, "id")
+	if err != nil {
+		return err
+	}
+	var req overdraftRequest
+	if err := decodeBody(r, &req); err != nil {
+		return err
+	}
+	if err := h.svc.SetOverdraftPolicy(r.Context(), id, req.AllowNegative, req.OverdraftLimit); err != nil {
+		return err
+	}
+	acc, err := h.svc.GetAccountByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, newAccountResponse(acc))
+}
+
+type freezeRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (h *handler) freezeAccount(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathObjectID(r, "id")
+	if err != nil {
+		return err
+	}
+	var req freezeRequest
+	if err := decodeBody(r, &req); err != nil {
+		return err
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		return badRequest("reason is required")
+	}
+	if err := h.svc.FreezeAccount(r.Context(), id, req.Reason); err != nil {
+		return err
+	}
+	return h.writeAccount(w, r, id)
+}
+
+func (h *handler) unfreezeAccount(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathObjectID(r, "id")
+	if err != nil {
+		return err
+	}
+	if err := h.svc.UnfreezeAccount(r.Context(), id); err != nil {
+		return err
+	}
+	return h.writeAccount(w, r, id)
+}
+
+type closeRequest struct {
+	Reason            string `json:"reason"`
+	ResidualAccountID string `json:"residual_account_id"` // required when the balance is not zero
+}
+
+type closeResponse struct {
+	Account  accountResponse  `json:"account"`
+	Transfer *journalResponse `json:"transfer,omitempty"`
+}
+
+func (h *handler) closeAccount(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathObjectID(r, "id")
+	if err != nil {
+		return err
+	}
+	var req closeRequest
+	if err := decodeBody(r, &req); err != nil {
+		return err
+	}
+	var residualID primitive.ObjectID
+	if req.ResidualAccountID != "" {
+		if residualID, err = parseObjectID(req.ResidualAccountID, "residual_account_id"); err != nil {
+			return err
+		}
+	}
+	transfer, err := h.svc.CloseAccount(r.Context(), id, residualID, req.Reason)
+	if err != nil {
+		return err
+	}
+	acc, err := h.svc.GetAccountByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	resp := closeResponse{Account: newAccountResponse(acc)}
+	if transfer != nil {
+		t := newJournalResponse(*transfer)
+		resp.Transfer = &t
+	}
+	return writeJSON(w, http.StatusOK, resp)
+}
+
+type archiveRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (h *handler) archiveAccount(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathObjectID(r, "id")
+	if err != nil {
+		return err
+	}
+	var req archiveRequest
+	if err := decodeBody(r, &req); err != nil {
+		return err
+	}
+	if err := h.svc.ArchiveAccount(r.Context(), id, req.Reason); err != nil {
+		return err
+	}
+	return h.writeAccount(w, r, id)
+}
+
+func (h *handler) writeAccount(w http.ResponseWriter, r *http.Request, id primitive.ObjectID) error {
+	acc, err := h.svc.GetAccountByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, newAccountResponse(acc))
+}
+
+func (h *handler) getBalance(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathObjectID(r, "id")
+	if err != nil {
+		return err
+	}
+	resp := balanceResponse{AccountID: id.Hex(), AsOf: time.Now()}
+	if asOf := r.URL.Query().Get("as_of"); asOf != "" {
+		t, err := time.Parse(time.RFC3339, asOf)
+		if err != nil {
+			return badRequest("as_of must be an RFC 3339 timestamp")
+		}
+		resp.AsOf = t
+		resp.Balance, err = h.svc.GetBalanceAsOf(r.Context(), id, t)
+		if err != nil {
+			return err
+		}
+	} else {
+		resp.Balance, err = h.svc.GetAccountBalance(r.Context(), id)
+		if err != nil {
+			return err
+		}
+	}
+	return writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *handler) getStatement(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathObjectID(r, "id")
+	if err != nil {
+		return err
+	}
+	from, err := queryTime(r, "from")
+	if err != nil {
+		return err
+	}
+	to, err := queryTime(r, "to")
+	if err != nil {
+		return err
+	}
+	var page accounting.Pagination
+	if page.Limit, err = queryInt(r, "limit"); err != nil {
+		return err
+	}
+	if page.Skip, err = queryInt(r, "skip"); err != nil {
+		return err
+	}
+	stmt, err := h.svc.GetAccountStatement(r.Context(), id, from, to, page)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, stmt)
+}
+
+func (h *handler) exportStatement(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathObjectID(r, "id")
+	if err != nil {
+		return err
+	}
+	from, err := queryTime(r, "from")
+	if err != nil {
+		return err
+	}
+	to, err := queryTime(r, "to")
+	if err != nil {
+		return err
+	}
+	format, err := exportFormat(r)
+	if err != nil {
+		return err
+	}
+	aw := newAttachmentWriter(w, "statement-"+id.Hex(), format)
+	return aw.finish(h.svc.ExportStatement(r.Context(), id, from, to, aw, format))
+}
+
+func (h *handler) statementPDF(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathObjectID(r, "id")
+	if err != nil {
+		return err
+	}
+	var period accounting.StatementPeriod
+	if period.From, err = queryTime(r, "from"); err != nil {
+		return err
+	}
+	if period.To, err = queryTime(r, "to"); err != nil {
+		return err
+	}
+	aw := &attachmentWriter{w: w, contentType: "application/pdf", filename: "statement-" + id.Hex() + ".pdf"}
+	return aw.finish(h.svc.RenderStatementPDF(r.Context(), id, period, aw))
+}
+
+type snapshotResponse struct {
+	AccountID string    `json:"account_id"`
+	Balance   string    `json:"balance"`
+	AsOf      time.Time `json:"as_of"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newSnapshotResponses(snaps []accounting.BalanceSnapshot) []snapshotResponse {
+	resp := make([]snapshotResponse, 0, len(snaps))
+	for _, snap := range snaps {
+		resp = append(resp, snapshotResponse{
+			AccountID: snap.AccountID.Hex(),
+			Balance:   snap.Balance,
+			AsOf:      snap.AsOf,
+			CreatedAt: snap.CreatedAt,
+		})
+	}
+	return resp
+}
+
+func (h *handler) listSnapshots(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathObjectID(r, "id")
+	if err != nil {
+		return err
+	}
+	from, err := queryTime(r, "from")
+	if err != nil {
+		return err
+	}
+	to, err := queryTime(r, "to")
+	if err != nil {
+		return err
+	}
+	snaps, err := h.svc.ListSnapshots(r.Context(), id, from, to)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, newSnapshotResponses(snaps))
+}
+
+type snapshotRequest struct {
+	AsOf time.Time `json:"as_of"`
+}
+
+func (h *handler) createSnapshots(w http.ResponseWriter, r *http.Request) error {
+	var req snapshotRequest
+	if err := decodeBody(r, &req); err != nil {
+		return err
+	}
+	if req.AsOf.IsZero() {
+		return badRequest("as_of is required")
+	}
+	snaps, err := h.svc.CreateBalanceSnapshots(r.Context(), req.AsOf)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusCreated, newSnapshotResponses(snaps))
+}
+
+// --------------------------
+//  Postings
+// --------------------------
+
+// postingFunc matches the posting operations of AccountingService. Their account
+// arguments are named after the business roles, e.g. (client, gateway) for a
+// top-up, so the request names them by role rather than by debit/credit side.
+type postingFunc func(ctx context.Context, from, to primitive.ObjectID, amount decimal.Decimal, tranRef string, opts ...accounting.PostingOption) error
+
+type postingRoleRequest struct {
+	FromAccountID string            `json:"from_account_id"`
+	ToAccountID   string            `json:"to_account_id"`
+	Amount        decimal.Decimal   `json:"amount"`
+	TranRef       string            `json:"tranref"`
+	Narrative     string            `json:"narrative"`
+	Metadata      map[string]string `json:"metadata"`
+}
+
+// posting serves a posting operation. from_account_id and to_account_id are the
+// first and second account arguments of the operation:
+//
+//	topup:      from = client account, to = payment gateway account
+//	premium:    from = client account, to = underwriter account
+//	commission: from = underwriter account, to = agent account
+//	claim:      from = underwriter account, to = client or payment gateway account
+//	premium-refund:         from = underwriter account, to = client account
+//	commission-clawback:    from = agent account, to = underwriter account
+//	underwriter-settlement: from = underwriter account, to = payment gateway account
+//	gateway-fee:            from = payment gateway account, to = gateway fee account
+func (h *handler) posting(post postingFunc) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var req postingRoleRequest
+		if err := decodeBody(r, &req); err != nil {
+			return err
+		}
+		from, err := parseObjectID(req.FromAccountID, "from_account_id")
+		if err != nil {
+			return err
+		}
+		to, err := parseObjectID(req.ToAccountID, "to_account_id")
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(req.TranRef) == "" {
+			return badRequest("tranref is required")
+		}
+		err = post(r.Context(), from, to, req.Amount, req.TranRef,
+			accounting.WithNarrative(req.Narrative), accounting.WithMetadata(req.Metadata))
+		if err != nil {
+			return err
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+// transfer serves Transfer: value moves from from_account_id to to_account_id
+func (h *handler) transfer(w http.ResponseWriter, r *http.Request) error {
+	var req postingRoleRequest
+	if err := decodeBody(r, &req); err != nil {
+		return err
+	}
+	from, err := parseObjectID(req.FromAccountID, "from_account_id")
+	if err != nil {
+		return err
+	}
+	to, err := parseObjectID(req.ToAccountID, "to_account_id")
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(req.TranRef) == "" {
+		return badRequest("tranref is required")
+	}
+	entry, err := h.svc.Transfer(r.Context(), from, to, req.Amount, req.TranRef, req.Narrative, accounting.WithMetadata(req.Metadata))
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusCreated, newJournalResponse(*entry))
+}
+
+// postToSuspense serves PostToSuspense: from_account_id is the payment gateway account
+// and to_account_id the suspense account
+func (h *handler) postToSuspense(w http.ResponseWriter, r *http.Request) error {
+	var req postingRoleRequest
+	if err := decodeBody(r, &req); err != nil {
+		return err
+	}
+	from, err := parseObjectID(req.FromAccountID, "from_account_id")
+	if err != nil {
+		return err
+	}
+	to, err := parseObjectID(req.ToAccountID, "to_account_id")
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(req.TranRef) == "" {
+		return badRequest("tranref is required")
+	}
+	entry, err := h.svc.PostToSuspense(r.Context(), from, to, req.Amount, req.TranRef,
+		accounting.WithNarrative(req.Narrative), accounting.WithMetadata(req.Metadata))
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusCreated, newJournalResponse(*entry))
+}
+
+type batchRequest struct {
+	Postings []batchPosting `json:"postings"`
+}
+
+type batchPosting struct {
+	Type            accounting.TransactionType `json:"type"`
+	DebitAccountID  string                     `json:"debit_account_id"`
+	CreditAccountID string                     `json:"credit_account_id"`
+	Amount          decimal.Decimal            `json:"amount"`
+	TranRef         string                     `json:"tranref"`
+	Narrative       string                     `json:"narrative"`
+	Metadata        map[string]string          `json:"metadata"`
+}
+
+type batchResponse struct {
+	Results []batchResult `json:"results"`
+	Posted  int           `json:"posted"`
+	Failed  int           `json:"failed"`
+}
+
+// batchResult is the outcome of a posting, Status is the HTTP status it would have had
+// posted alone
+type batchResult struct {
+	Status int              `json:"status"`
+	Entry  *journalResponse `json:"entry,omitempty"`
+	Error  string           `json:"error,omitempty"`
+}
+
+// postBatch serves PostBatch. The request fails as a whole only when it is malformed.
+func (h *handler) postBatch(w http.ResponseWriter, r *http.Request) error {
+	var req batchRequest
+	if err := decodeBody(r, &req); err != nil {
+		return err
+	}
+	if len(req.Postings) == 0 {
+		return badRequest("postings is required")
+	}
+	reqs := make([]accounting.PostingRequest, len(req.Postings))
+	for i, p := range req.Postings {
+		debit, err := parseObjectID(p.DebitAccountID, fmt.Sprintf("postings[%d].debit_account_id", i))
+		if err != nil {
+			return err
+		}
+		credit, err := parseObjectID(p.CreditAccountID, fmt.Sprintf("postings[%d].credit_account_id", i))
+		if err != nil {
+			return err
+		}
+		if p.Type == "" || strings.TrimSpace(p.TranRef) == "" {
+			return badRequest(fmt.Sprintf("postings[%d]: type and tranref are required", i))
+		}
+		reqs[i] = accounting.PostingRequest{Type: p.Type, DebitAccount: debit, CreditAccount: credit, Amount: p.Amount,
+			TranRef: p.TranRef, Narrative: p.Narrative, Metadata: p.Metadata}
+	}
+
+	res, err := h.svc.PostBatch(r.Context(), reqs)
+	if err != nil && res == nil {
+		return err
+	}
+	resp := batchResponse{Results: make([]batchResult, len(res.Outcomes)), Posted: res.Posted, Failed: res.Failed}
+	for i, outcome := range res.Outcomes {
+		if outcome.Err != nil {
+			status := statusFor(outcome.Err)
+			msg := outcome.Err.Error()
+			if status == http.StatusInternalServerError {
+				msg = http.StatusText(status)
+			}
+			resp.Results[i] = batchResult{Status: status, Error: msg}
+			continue
+		}
+		entry := newJournalResponse(*outcome.Entry)
+		resp.Results[i] = batchResult{Status: http.StatusCreated, Entry: &entry}
+	}
+	return writeJSON(w, http.StatusOK, resp)
+}
+
+// --------------------------
+//  Journals & Reconciliation
+// --------------------------
+
+func (h *handler) listJournals(w http.ResponseWriter, r *http.Request) error {
+	limit, err := queryInt(r, "limit")
+	if err != nil {
+		return err
+	}
+	skip, err := queryInt(r, "skip")
+	if err != nil {
+		return err
+	}
+	entries, err := h.svc.GetJournalEntries(r.Context(), limit, skip)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, newJournalResponses(entries))
+}
+
+type journalListResponse struct {
+	Journals []journalResponse `json:"journals"`
+	Total    int64             `json:"total"`
+}
+
+func (h *handler) searchJournals(w http.ResponseWriter, r *http.Request) error {
+	q := r.URL.Query()
+	filter, err := journalFilter(r)
+	if err != nil {
+		return err
+	}
+	var sort accounting.JournalSort
+	if field := q.Get("sort"); field != "" {
+		desc := strings.HasPrefix(field, "-")
+		sort = accounting.JournalSort{Field: accounting.JournalSortField(strings.TrimPrefix(field, "-")), Ascending: !desc}
+	}
+	var page accounting.Pagination
+	if page.Limit, err = queryInt(r, "limit"); err != nil {
+		return err
+	}
+	if page.Skip, err = queryInt(r, "skip"); err != nil {
+		return err
+	}
+
+	entries, total, err := h.svc.QueryJournals(r.Context(), filter, sort, page)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, journalListResponse{Journals: newJournalResponses(entries), Total: total})
+}
+
+func (h *handler) exportJournals(w http.ResponseWriter, r *http.Request) error {
+	filter, err := journalFilter(r)
+	if err != nil {
+		return err
+	}
+	format, err := exportFormat(r)
+	if err != nil {
+		return err
+	}
+	aw := newAttachmentWriter(w, "journals", format)
+	return aw.finish(h.svc.ExportJournals(r.Context(), filter, aw, format))
+}
+
+// journalFilter reads the journal filter query parameters
+func journalFilter(r *http.Request) (accounting.JournalFilter, error) {
+	q := r.URL.Query()
+	filter := accounting.JournalFilter{
+		Type:      accounting.TransactionType(q.Get("type")),
+		TranRef:   q.Get("tranref"),
+		Narrative: q.Get("narrative"),
+	}
+	for _, pair := range q["metadata"] {
+		k, v, ok := strings.Cut(pair, ":")
+		if !ok || k == "" {
+			return filter, badRequest("metadata must be key:value")
+		}
+		if filter.Metadata == nil {
+			filter.Metadata = map[string]string{}
+		}
+		filter.Metadata[k] = v
+	}
+	var err error
+	if id := q.Get("account_id"); id != "" {
+		if filter.AccountID, err = parseObjectID(id, "account_id"); err != nil {
+			return filter, err
+		}
+	}
+	if filter.MinAmount, err = queryDecimal(r, "min_amount"); err != nil {
+		return filter, err
+	}
+	if filter.MaxAmount, err = queryDecimal(r, "max_amount"); err != nil {
+		return filter, err
+	}
+	if filter.From, err = queryTime(r, "from"); err != nil {
+		return filter, err
+	}
+	if filter.To, err = queryTime(r, "to"); err != nil {
+		return filter, err
+	}
+	if filter.Archived, err = queryBool(r, "archived"); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+func (h *handler) getJournal(w http.ResponseWriter, r *http.Request) error {
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return badRequest("id must be a valid journal id")
+	}
+	entry, err := h.svc.GetJournalByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, newJournalResponse(*entry))
+}
+
+func (h *handler) journalsByRef(w http.ResponseWriter, r *http.Request) error {
+	from, err := queryTime(r, "from")
+	if err != nil {
+		return err
+	}
+	to, err := queryTime(r, "to")
+	if err != nil {
+		return err
+	}
+	opts := []accounting.RefOption{accounting.WithRefPeriod(from, to)}
+	if txType := r.URL.Query().Get("type"); txType != "" {
+		opts = append(opts, accounting.WithRefType(accounting.TransactionType(txType)))
+	}
+	entries, err := h.svc.GetJournalEntriesByRef(r.Context(), r.PathValue("tranRef"), opts...)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, newJournalResponses(entries))
+}
+
+type reversalRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (h *handler) reverseJournal(w http.ResponseWriter, r *http.Request) error {
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return badRequest("id must be a valid journal id")
+	}
+	var req reversalRequest
+	if err := decodeBody(r, &req); err != nil {
+		return err
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		return badRequest("reason is required")
+	}
+	reversal, err := h.svc.ReverseJournalEntry(r.Context(), id, req.Reason)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusCreated, newJournalResponse(*reversal))
+}
+
+func (h *handler) postCommission(w http.ResponseWriter, r *http.Request) error {
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return badRequest("id must be a valid journal id")
+	}
+	commission, err := h.svc.PostCommissionForPremium(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	if commission == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	return writeJSON(w, http.StatusCreated, newJournalResponse(*commission))
+}
+
+type matchSuspenseRequest struct {
+	AccountID string `json:"account_id"`
+}
+
+func (h *handler) matchSuspense(w http.ResponseWriter, r *http.Request) error {
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return badRequest("id must be a valid journal id")
+	}
+	var req matchSuspenseRequest
+	if err := decodeBody(r, &req); err != nil {
+		return err
+	}
+	target, err := parseObjectID(req.AccountID, "account_id")
+	if err != nil {
+		return err
+	}
+	clearing, err := h.svc.MatchSuspenseEntry(r.Context(), id, target)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusCreated, newJournalResponse(*clearing))
+}
+
+func (h *handler) unmatchSuspense(w http.ResponseWriter, r *http.Request) error {
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return badRequest("id must be a valid journal id")
+	}
+	var req reversalRequest
+	if err := decodeBody(r, &req); err != nil {
+		return err
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		return badRequest("reason is required")
+	}
+	reversal, err := h.svc.UnmatchSuspenseEntry(r.Context(), id, req.Reason)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusCreated, newJournalResponse(*reversal))
+}
+
+func (h *handler) unmatchedSuspense(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathObjectID(r, "id")
+	if err != nil {
+		return err
+	}
+	entries, err := h.svc.UnmatchedSuspenseEntries(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, newJournalResponses(entries))
+}
+
+func (h *handler) reconcileAccount(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathObjectID(r, "id")
+	if err != nil {
+		return err
+	}
+	result, err := h.svc.ReconcileAccount(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, result)
+}
+
+func (h *handler) reconciliationReport(w http.ResponseWriter, r *http.Request) error {
+	results, err := h.svc.GetReconciliationReport(r.Context())
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, results)
+}
+
+// trialBalance serves the trial balance even when the ledger does not balance,
+// the totals and their difference show by how much
+func (h *handler) trialBalance(w http.ResponseWriter, r *http.Request) error {
+	asOf, err := queryTime(r, "as_of")
+	if err != nil {
+		return err
+	}
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+	tb, err := h.svc.GetTrialBalance(r.Context(), asOf)
+	if err != nil && !errors.Is(err, accounting.ErrUnbalanced) {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, trialBalanceResponse{TrialBalance: tb, Balanced: tb.Balanced(), Difference: tb.Difference()})
+}
+
+type trialBalanceResponse struct {
+	*accounting.TrialBalance
+	Balanced   bool            `json:"balanced"`
+	Difference decimal.Decimal `json:"difference"`
+}
+
+// --------------------------
+//  Dashboards
+// --------------------------
+
+func (h *handler) balancesByType(w http.ResponseWriter, r *http.Request) error {
+	balances, err := h.svc.GetBalancesByType(r.Context())
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, balances)
+}
+
+func (h *handler) dailyTotals(w http.ResponseWriter, r *http.Request) error {
+	from, err := queryTime(r, "from")
+	if err != nil {
+		return err
+	}
+	to, err := queryTime(r, "to")
+	if err != nil {
+		return err
+	}
+	totals, err := h.svc.GetDailyPostingTotals(r.Context(), from, to)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, totals)
+}
+
+func (h *handler) topAccounts(w http.ResponseWriter, r *http.Request) error {
+	from, err := queryTime(r, "from")
+	if err != nil {
+		return err
+	}
+	to, err := queryTime(r, "to")
+	if err != nil {
+		return err
+	}
+	limit, err := queryInt(r, "limit")
+	if err != nil {
+		return err
+	}
+	top, err := h.svc.GetTopAccountsByVolume(r.Context(), from, to, int(limit))
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, top)
+}
+
+type auditListResponse struct {
+	Records []accounting.AuditRecord `json:"records"`
+	Total   int64                    `json:"total"`
+}
+
+func (h *handler) listAudit(w http.ResponseWriter, r *http.Request) error {
+	q := r.URL.Query()
+	filter := accounting.AuditFilter{
+		EntityID: q.Get("entity_id"),
+		ActorID:  q.Get("actor_id"),
+		Action:   accounting.AuditAction(q.Get("action")),
+	}
+	var err error
+	if filter.From, err = queryTime(r, "from"); err != nil {
+		return err
+	}
+	if filter.To, err = queryTime(r, "to"); err != nil {
+		return err
+	}
+	var page accounting.Pagination
+	if page.Limit, err = queryInt(r, "limit"); err != nil {
+		return err
+	}
+	if page.Skip, err = queryInt(r, "skip"); err != nil {
+		return err
+	}
+
+	records, total, err := h.svc.ListAuditRecords(r.Context(), filter, page)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, auditListResponse{Records: records, Total: total})
+}
+
+func (h *handler) verifyIntegrity(w http.ResponseWriter, r *http.Request) error {
+	from, err := queryTime(r, "from")
+	if err != nil {
+		return err
+	}
+	to, err := queryTime(r, "to")
+	if err != nil {
+		return err
+	}
+	report, err := h.svc.VerifyLedgerIntegrity(r.Context(), from, to)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, report)
+}
+
+// --------------------------
+//  Helpers
+// --------------------------
+
+type accountResponse struct {
+	ID             string                   `json:"id"`
+	Type           accounting.AccountType   `json:"type"`
+	Name           string                   `json:"name"`
+	Ref            string                   `json:"ref,omitempty"`
+	Balance        string                   `json:"balance"`
+	OpeningBalance string                   `json:"opening_balance"`
+	AllowNegative  bool                     `json:"allow_negative"`
+	OverdraftLimit string                   `json:"overdraft_limit,omitempty"`
+	Status         accounting.AccountStatus `json:"status"`
+	StatusReason   string                   `json:"status_reason,omitempty"`
+	CreatedAt      time.Time                `json:"created_at"`
+}
+
+func newAccountResponse(acc *accounting.Account) accountResponse {
+	return accountResponse{
+		ID:             acc.ID.Hex(),
+		Type:           acc.Type,
+		Name:           acc.Name,
+		Ref:            acc.Ref,
+		Balance:        acc.GetBalance().String(),
+		OpeningBalance: acc.OpeningBalance,
+		AllowNegative:  acc.NegativeAllowed(),
+		OverdraftLimit: acc.OverdraftLimit,
+		Status:         acc.GetStatus(),
+		StatusReason:   acc.StatusReason,
+		CreatedAt:      acc.CreatedAt,
+	}
+}
+
+type journalResponse struct {
+	ID              string                     `json:"id"`
+	Type            accounting.TransactionType `json:"type"`
+	Amount          string                     `json:"amount"`
+	TranRef         string                     `json:"tranref"`
+	Narrative       string                     `json:"narrative,omitempty"`
+	Metadata        map[string]string          `json:"metadata,omitempty"`
+	DebitAccountID  string                     `json:"debit_account_id"`
+	CreditAccountID string                     `json:"credit_account_id"`
+	CreatedAt       time.Time                  `json:"created_at"`
+	ReversalOf      string                     `json:"reversal_of,omitempty"`
+	ReversedBy      string                     `json:"reversed_by,omitempty"`
+	ReversalReason  string                     `json:"reversal_reason,omitempty"`
+	ClearingOf      string                     `json:"clearing_of,omitempty"`
+	MatchedBy       string                     `json:"matched_by,omitempty"`
+}
+
+func newJournalResponse(e accounting.JournalEntry) journalResponse {
+	resp := journalResponse{
+		ID:              e.ID.Hex(),
+		Type:            e.Type,
+		Amount:          e.Amount,
+		TranRef:         e.TranRef,
+		Narrative:       e.Narrative,
+		Metadata:        e.Metadata,
+		DebitAccountID:  e.DebitAccount.Hex(),
+		CreditAccountID: e.CreditAccount.Hex(),
+		CreatedAt:       e.CreatedAt,
+		ReversalReason:  e.ReversalReason,
+	}
+	if e.ReversalOf != nil {
+		resp.ReversalOf = e.ReversalOf.Hex()
+	}
+	if e.ReversedBy != nil {
+		resp.ReversedBy = e.ReversedBy.Hex()
+	}
+	if e.ClearingOf != nil {
+		resp.ClearingOf = e.ClearingOf.Hex()
+	}
+	if e.MatchedBy != nil {
+		resp.MatchedBy = e.MatchedBy.Hex()
+	}
+	return resp
+}
+
+func newJournalResponses(entries []accounting.JournalEntry) []journalResponse {
+	resp := make([]journalResponse, 0, len(entries))
+	for _, e := range entries {
+		resp = append(resp, newJournalResponse(e))
+	}
+	return resp
+}
+
+type httpError struct {
+	status int
+	msg    string
+}
+
+func (e *httpError) Error() string { return e.msg }
+
+func badRequest(msg string) error {
+	return &httpError{status: http.StatusBadRequest, msg: msg}
+}
+
+func statusFor(err error) int {
+	var herr *httpError
+	var exhausted *accounting.RetryExhaustedError
+	switch {
+	case errors.As(err, &herr):
+		return herr.status
+	case errors.As(err, &exhausted):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, accounting.ErrAccountNotFound), errors.Is(err, accounting.ErrJournalNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, accounting.ErrAlreadyReversed), errors.Is(err, accounting.ErrIdempotencyConflict),
+		errors.Is(err, accounting.ErrDuplicateAccountRef), errors.Is(err, accounting.ErrAlreadyMatched):
+		return http.StatusConflict
+	case errors.Is(err, accounting.ErrAccountFrozen), errors.Is(err, accounting.ErrAccountClosed), errors.Is(err, accounting.ErrNonZeroBalance),
+		errors.Is(err, accounting.ErrAccountNotClosed):
+		return http.StatusConflict
+	case errors.Is(err, accounting.ErrNotReversible):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, accounting.ErrInvalidAmount), errors.Is(err, accounting.ErrExcessPrecision), errors.Is(err, accounting.ErrInsufficientFunds),
+		errors.Is(err, accounting.ErrPostingRule), errors.Is(err, accounting.ErrNoPostingRule),
+		errors.Is(err, accounting.ErrNoCommissionRule), errors.Is(err, accounting.ErrNotCommissionable),
+		errors.Is(err, accounting.ErrNotInSuspense):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, accounting.ErrInvalidFilter), errors.Is(err, accounting.ErrInvalidMetadata),
+		errors.Is(err, accounting.ErrInvalidAccountRef):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func exportFormat(r *http.Request) (accounting.ExportFormat, error) {
+	switch format := accounting.ExportFormat(r.URL.Query().Get("format")); format {
+	case "", accounting.ExportCSV:
+		return accounting.ExportCSV, nil
+	case accounting.ExportXLSX:
+		return format, nil
+	}
+	return "", badRequest("format must be csv or xlsx")
+}
+
+// attachmentWriter sends the download headers with the first bytes of an export, so
+// errors raised before any row is written are still answered with a JSON error
+type attachmentWriter struct {
+	w           http.ResponseWriter
+	contentType string
+	filename    string
+	started     bool
+}
+
+func newAttachmentWriter(w http.ResponseWriter, name string, format accounting.ExportFormat) *attachmentWriter {
+	contentType := "text/csv"
+	if format == accounting.ExportXLSX {
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return &attachmentWriter{w: w, contentType: contentType, filename: name + "." + string(format)}
+}
+
+func (a *attachmentWriter) Write(p []byte) (int, error) {
+	if !a.started {
+		a.started = true
+		a.w.Header().Set("Content-Type", a.contentType)
+		a.w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", a.filename))
+		a.w.WriteHeader(http.StatusOK)
+	}
+	return a.w.Write(p)
+}
+
+// finish returns the error of the export while nothing was sent; once the download
+// started it aborts the response so the client sees a truncated transfer
+func (a *attachmentWriter) finish(err error) error {
+	if err != nil && a.started {
+		panic(http.ErrAbortHandler)
+	}
+	return err
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	return json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	msg := err.Error()
+	if status == http.StatusInternalServerError {
+		// do not leak storage errors to API consumers
+		msg = http.StatusText(status)
+	}
+	_ = writeJSON(w, status, map[string]string{"error": msg})
+}
+
+func decodeBody(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return badRequest("invalid request body: " + err.Error())
+	}
+	return nil
+}
+
+func pathObjectID(r *http.Request, name string) (primitive.ObjectID, error) {
+	return parseObjectID(r.PathValue(name), name)
+}
+
+func parseObjectID(value, name string) (primitive.ObjectID, error) {
+	id, err := primitive.ObjectIDFromHex(value)
+	if err != nil {
+		return primitive.NilObjectID, badRequest(name + " must be a valid account id")
+	}
+	return id, nil
+}
+
+func queryInt(r *http.Request, name string) (int64, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, badRequest(name + " must be an integer")
+	}
+	return n, nil
+}
+
+func queryBool(r *http.Request, name string) (bool, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, badRequest(name + " must be true or false")
+	}
+	return b, nil
+}
+
+func queryDecimal(r *http.Request, name string) (decimal.Decimal, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return decimal.Zero, nil
+	}
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return decimal.Zero, badRequest(name + " must be a decimal number")
+	}
+	return d, nil
+}
+
+func queryTime(r *http.Request, name string) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, badRequest(name + " must be an RFC 3339 timestamp")
+	}
+	return t, nil
+}
@@ -0,0 +1,526 @@
+package accountinghttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nana-tec/gopackages/accounting"
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeLedger struct {
+	Ledger
+	accounts map[primitive.ObjectID]*accounting.Account
+	postings []string
+	reversed map[primitive.ObjectID]bool
+	journals map[primitive.ObjectID]accounting.JournalEntry
+}
+
+func (f *fakeLedger) GetAccountByID(ctx context.Context, id primitive.ObjectID) (*accounting.Account, error) {
+	acc, ok := f.accounts[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", accounting.ErrAccountNotFound, id.Hex())
+	}
+	return acc, nil
+}
+
+func (f *fakeLedger) ClientAccountTopUp(ctx context.Context, clientAccID, gatewayAccID primitive.ObjectID, amount decimal.Decimal, tranRef string, opts ...accounting.PostingOption) error {
+	if !amount.IsPositive() {
+		return accounting.ErrInvalidAmount
+	}
+	if err := (accounting.RoundingPolicy{Scale: 2}).Check(amount); err != nil {
+		return err
+	}
+	var entry accounting.JournalEntry
+	for _, opt := range opts {
+		opt(&entry)
+	}
+	f.postings = append(f.postings, fmt.Sprintf("topup %s %s %s %s %q %v", clientAccID.Hex(), gatewayAccID.Hex(), amount, tranRef, entry.Narrative, entry.Metadata))
+	return nil
+}
+
+func (f *fakeLedger) Transfer(ctx context.Context, fromAccID, toAccID primitive.ObjectID, amount decimal.Decimal, tranRef, narrative string, opts ...accounting.PostingOption) (*accounting.JournalEntry, error) {
+	if fromAccID == toAccID {
+		return nil, fmt.Errorf("%w: same account", accounting.ErrPostingRule)
+	}
+	entry := &accounting.JournalEntry{ID: primitive.NewObjectID(), Type: accounting.AccountTransfer, Amount: amount.String(), TranRef: tranRef,
+		Narrative: narrative, DebitAccount: fromAccID, CreditAccount: toAccID}
+	for _, opt := range opts {
+		opt(entry)
+	}
+	return entry, nil
+}
+
+func (f *fakeLedger) GetTopAccountsByVolume(ctx context.Context, from, to time.Time, n int) ([]accounting.AccountVolume, error) {
+	if !from.IsZero() && to.Before(from) {
+		return nil, fmt.Errorf("%w: period ends before it starts", accounting.ErrInvalidFilter)
+	}
+	return []accounting.AccountVolume{{AccountID: primitive.NewObjectID(), AccountName: fmt.Sprintf("top %d", n), Volume: decimal.NewFromInt(270)}}, nil
+}
+
+func (f *fakeLedger) ListAuditRecords(ctx context.Context, filter accounting.AuditFilter, page accounting.Pagination) ([]accounting.AuditRecord, int64, error) {
+	rec := accounting.AuditRecord{ID: primitive.NewObjectID(), Action: filter.Action, Actor: accounting.Actor{ID: filter.ActorID}, EntityID: filter.EntityID}
+	return []accounting.AuditRecord{rec}, 1, nil
+}
+
+func (f *fakeLedger) SetOverdraftPolicy(ctx context.Context, id primitive.ObjectID, allowNegative bool, limit decimal.Decimal) error {
+	acc, ok := f.accounts[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", accounting.ErrAccountNotFound, id.Hex())
+	}
+	acc.AllowNegative = &allowNegative
+	acc.OverdraftLimit = ""
+	if allowNegative && limit.IsPositive() {
+		acc.OverdraftLimit = limit.String()
+	}
+	return nil
+}
+
+func (f *fakeLedger) FreezeAccount(ctx context.Context, id primitive.ObjectID, reason string) error {
+	acc, ok := f.accounts[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", accounting.ErrAccountNotFound, id.Hex())
+	}
+	acc.Status = accounting.AccountFrozen
+	acc.StatusReason = reason
+	return nil
+}
+
+func (f *fakeLedger) CloseAccount(ctx context.Context, id, residualAccID primitive.ObjectID, reason string) (*accounting.JournalEntry, error) {
+	acc, ok := f.accounts[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", accounting.ErrAccountNotFound, id.Hex())
+	}
+	if !acc.GetBalance().IsZero() && residualAccID.IsZero() {
+		return nil, fmt.Errorf("%w: %s", accounting.ErrNonZeroBalance, id.Hex())
+	}
+	acc.Status = accounting.AccountClosed
+	return nil, nil
+}
+
+func (f *fakeLedger) RenderStatementPDF(ctx context.Context, accountID primitive.ObjectID, period accounting.StatementPeriod, w io.Writer) error {
+	if _, err := f.GetAccountByID(ctx, accountID); err != nil {
+		return err
+	}
+	if !period.To.IsZero() && period.To.Before(period.From) {
+		return fmt.Errorf("%w: statement ends before it starts", accounting.ErrInvalidFilter)
+	}
+	_, err := io.WriteString(w, "%PDF-1.4\n")
+	return err
+}
+
+func (f *fakeLedger) GetAccountByRef(ctx context.Context, ref string) (*accounting.Account, error) {
+	for _, acc := range f.accounts {
+		if acc.Ref == ref {
+			return acc, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: ref %s", accounting.ErrAccountNotFound, ref)
+}
+
+func (f *fakeLedger) GetOrCreateAccount(ctx context.Context, ref string, accType accounting.AccountType, name string) (*accounting.Account, error) {
+	acc, err := f.GetAccountByRef(ctx, ref)
+	if err != nil {
+		acc = &accounting.Account{ID: primitive.NewObjectID(), Type: accType, Name: name, Ref: ref}
+		f.accounts[acc.ID] = acc
+	}
+	if acc.Type != accType {
+		return nil, fmt.Errorf("%w: %q is a %s account", accounting.ErrDuplicateAccountRef, ref, acc.Type)
+	}
+	return acc, nil
+}
+
+func (f *fakeLedger) ArchiveAccount(ctx context.Context, id primitive.ObjectID, reason string) error {
+	acc, ok := f.accounts[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", accounting.ErrAccountNotFound, id.Hex())
+	}
+	if acc.Status != accounting.AccountClosed {
+		return fmt.Errorf("%w: %s", accounting.ErrAccountNotClosed, id.Hex())
+	}
+	acc.Status = accounting.AccountArchived
+	return nil
+}
+
+func (f *fakeLedger) ListAccounts(ctx context.Context, filter accounting.AccountFilter, page accounting.Pagination) ([]accounting.Account, int64, error) {
+	var out []accounting.Account
+	for _, acc := range f.accounts {
+		if filter.Type == "" || acc.Type == filter.Type {
+			out = append(out, *acc)
+		}
+	}
+	return out, int64(len(out)), nil
+}
+
+func (f *fakeLedger) QueryJournals(ctx context.Context, filter accounting.JournalFilter, sort accounting.JournalSort, page accounting.Pagination) ([]accounting.JournalEntry, int64, error) {
+	if sort.Field != "" && sort.Field != accounting.SortByCreatedAt && sort.Field != accounting.SortByAmount {
+		return nil, 0, fmt.Errorf("%w: sort %q", accounting.ErrInvalidFilter, sort.Field)
+	}
+	entry := accounting.JournalEntry{ID: primitive.NewObjectID(), Type: filter.Type, Amount: filter.MinAmount.String(), TranRef: filter.TranRef}
+	return []accounting.JournalEntry{entry}, 1, nil
+}
+
+func (f *fakeLedger) ExportJournals(ctx context.Context, filter accounting.JournalFilter, w io.Writer, format accounting.ExportFormat) error {
+	_, err := fmt.Fprintf(w, "Journal ID,Tran Ref\n,%s\n", filter.TranRef)
+	return err
+}
+
+func (f *fakeLedger) GetJournalEntriesByRef(ctx context.Context, tranRef string, opts ...accounting.RefOption) ([]accounting.JournalEntry, error) {
+	filter := accounting.JournalFilter{TranRef: tranRef}
+	for _, opt := range opts {
+		opt(&filter)
+	}
+	if !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return nil, fmt.Errorf("%w: date range ends before it starts", accounting.ErrInvalidFilter)
+	}
+	return []accounting.JournalEntry{{ID: primitive.NewObjectID(), Type: filter.Type, TranRef: tranRef, CreatedAt: filter.From}}, nil
+}
+
+func (f *fakeLedger) GetJournalByID(ctx context.Context, journalID primitive.ObjectID) (*accounting.JournalEntry, error) {
+	entry, ok := f.journals[journalID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", accounting.ErrJournalNotFound, journalID.Hex())
+	}
+	return &entry, nil
+}
+
+func (f *fakeLedger) ReverseJournalEntry(ctx context.Context, journalID primitive.ObjectID, reason string) (*accounting.JournalEntry, error) {
+	if f.reversed[journalID] {
+		return nil, fmt.Errorf("%w: %s", accounting.ErrAlreadyReversed, journalID.Hex())
+	}
+	if f.reversed == nil {
+		f.reversed = map[primitive.ObjectID]bool{}
+	}
+	f.reversed[journalID] = true
+	return &accounting.JournalEntry{ID: primitive.NewObjectID(), Type: accounting.Reversal, Amount: "10", ReversalOf: &journalID, ReversalReason: reason}, nil
+}
+
+func (f *fakeLedger) PostCommissionForPremium(ctx context.Context, premiumJournalID primitive.ObjectID) (*accounting.JournalEntry, error) {
+	if f.reversed[premiumJournalID] {
+		return nil, fmt.Errorf("%w: %s is reversed", accounting.ErrNotCommissionable, premiumJournalID.Hex())
+	}
+	return &accounting.JournalEntry{ID: primitive.NewObjectID(), Type: accounting.CommissionPayment, Amount: "1.5",
+		Metadata: map[string]string{accounting.MetadataPremiumJournal: premiumJournalID.Hex()}}, nil
+}
+
+func (f *fakeLedger) PostToSuspense(ctx context.Context, gatewayAccID, suspenseAccID primitive.ObjectID, amount decimal.Decimal, tranRef string, opts ...accounting.PostingOption) (*accounting.JournalEntry, error) {
+	entry := accounting.JournalEntry{ID: primitive.NewObjectID(), Type: accounting.SuspenseReceipt, Amount: amount.String(), TranRef: tranRef,
+		DebitAccount: gatewayAccID, CreditAccount: suspenseAccID}
+	if f.journals == nil {
+		f.journals = map[primitive.ObjectID]accounting.JournalEntry{}
+	}
+	f.journals[entry.ID] = entry
+	return &entry, nil
+}
+
+func (f *fakeLedger) MatchSuspenseEntry(ctx context.Context, suspenseJournalID, targetAccID primitive.ObjectID) (*accounting.JournalEntry, error) {
+	receipt, ok := f.journals[suspenseJournalID]
+	switch {
+	case !ok:
+		return nil, fmt.Errorf("%w: %s", accounting.ErrJournalNotFound, suspenseJournalID.Hex())
+	case receipt.Type != accounting.SuspenseReceipt:
+		return nil, fmt.Errorf("%w: %s is a %s", accounting.ErrNotInSuspense, receipt.ID.Hex(), receipt.Type)
+	case receipt.MatchedBy != nil:
+		return nil, fmt.Errorf("%w: %s", accounting.ErrAlreadyMatched, receipt.ID.Hex())
+	}
+	clearing := accounting.JournalEntry{ID: primitive.NewObjectID(), Type: accounting.SuspenseClearing, Amount: receipt.Amount, TranRef: receipt.TranRef,
+		DebitAccount: receipt.CreditAccount, CreditAccount: targetAccID, ClearingOf: &receipt.ID}
+	receipt.MatchedBy = &clearing.ID
+	f.journals[receipt.ID], f.journals[clearing.ID] = receipt, clearing
+	return &clearing, nil
+}
+
+func (f *fakeLedger) UnmatchSuspenseEntry(ctx context.Context, clearingJournalID primitive.ObjectID, reason string) (*accounting.JournalEntry, error) {
+	clearing, ok := f.journals[clearingJournalID]
+	if !ok || clearing.ClearingOf == nil {
+		return nil, fmt.Errorf("%w: %s", accounting.ErrNotInSuspense, clearingJournalID.Hex())
+	}
+	receipt := f.journals[*clearing.ClearingOf]
+	receipt.MatchedBy = nil
+	f.journals[receipt.ID] = receipt
+	return &accounting.JournalEntry{ID: primitive.NewObjectID(), Type: accounting.Reversal, Amount: clearing.Amount, ReversalOf: &clearing.ID, ReversalReason: reason}, nil
+}
+
+func (f *fakeLedger) UnmatchedSuspenseEntries(ctx context.Context, suspenseAccID primitive.ObjectID) ([]accounting.JournalEntry, error) {
+	var entries []accounting.JournalEntry
+	for id, e := range f.journals {
+		if e.Type == accounting.SuspenseReceipt && e.CreditAccount == suspenseAccID && e.MatchedBy == nil && !f.reversed[id] {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func (f *fakeLedger) PostBatch(ctx context.Context, reqs []accounting.PostingRequest) (*accounting.BatchResult, error) {
+	res := &accounting.BatchResult{Outcomes: make([]accounting.PostingOutcome, len(reqs))}
+	for i, req := range reqs {
+		if req.Amount.GreaterThan(decimal.NewFromInt(100)) {
+			res.Outcomes[i].Err = fmt.Errorf("%w: %s", accounting.ErrInsufficientFunds, req.DebitAccount.Hex())
+			res.Failed++
+			continue
+		}
+		res.Outcomes[i].Entry = &accounting.JournalEntry{ID: primitive.NewObjectID(), Type: req.Type, Amount: req.Amount.String(),
+			TranRef: req.TranRef, DebitAccount: req.DebitAccount, CreditAccount: req.CreditAccount}
+		res.Posted++
+	}
+	return res, nil
+}
+
+func TestHandler(t *testing.T) {
+	acc := &accounting.Account{ID: primitive.NewObjectID(), Type: accounting.ClientInsurance, Name: "client", CreatedAt: time.Now()}
+	_ = acc.SetBalance(decimal.NewFromInt(10))
+	ledger := &fakeLedger{accounts: map[primitive.ObjectID]*accounting.Account{acc.ID: acc}}
+	h := NewHandler(ledger, Config{
+		BasePath: "/ledger",
+		Auth: func(r *http.Request) (context.Context, error) {
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				return nil, errors.New("invalid token")
+			}
+			return r.Context(), nil
+		},
+	})
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodGet, "/ledger/accounts/"+acc.ID.Hex(), ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"name":"client"`) {
+		t.Errorf("get account: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/ledger/accounts/"+primitive.NewObjectID().Hex(), ""); rec.Code != http.StatusNotFound {
+		t.Errorf("missing account: expected 404, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/ledger/accounts/not-an-id", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid id: expected 400, got %d", rec.Code)
+	}
+
+	if rec := do(http.MethodGet, "/ledger/accounts?type=ClientInsurance&limit=10", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"total":1`) {
+		t.Errorf("list accounts: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/ledger/accounts?created_after=yesterday", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid created_after: expected 400, got %d", rec.Code)
+	}
+
+	if rec := do(http.MethodPut, "/ledger/accounts/"+acc.ID.Hex()+"/overdraft", `{"allow_negative":true,"overdraft_limit":"500"}`); rec.Code != http.StatusOK ||
+		!strings.Contains(rec.Body.String(), `"allow_negative":true,"overdraft_limit":"500"`) {
+		t.Errorf("set overdraft: %d %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := do(http.MethodPost, "/ledger/accounts/"+acc.ID.Hex()+"/close", `{"reason":"policy ended"}`); rec.Code != http.StatusConflict {
+		t.Errorf("close with balance: expected 409, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/ledger/accounts/"+acc.ID.Hex()+"/archive", `{"reason":"dormant"}`); rec.Code != http.StatusConflict {
+		t.Errorf("archive open account: expected 409, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/ledger/accounts?archived=maybe", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("list accounts with invalid archived flag: expected 400, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPut, "/ledger/account-refs/CUST-7", `{"type":"ClientInsurance","name":"client 7"}`); rec.Code != http.StatusOK ||
+		!strings.Contains(rec.Body.String(), `"ref":"CUST-7"`) {
+		t.Errorf("get or create account: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/ledger/account-refs/CUST-7", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"name":"client 7"`) {
+		t.Errorf("get account by ref: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPut, "/ledger/account-refs/CUST-7", `{"type":"AgentCommissionEarned","name":"agent 7"}`); rec.Code != http.StatusConflict {
+		t.Errorf("account ref of another type: expected 409, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/ledger/account-refs/CUST-8", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown account ref: expected 404, got %d", rec.Code)
+	}
+	dormant := &accounting.Account{ID: primitive.NewObjectID(), Type: accounting.ClientInsurance, Name: "dormant", Status: accounting.AccountClosed}
+	ledger.accounts[dormant.ID] = dormant
+	if rec := do(http.MethodPost, "/ledger/accounts/"+dormant.ID.Hex()+"/archive", `{"reason":"dormant"}`); rec.Code != http.StatusOK ||
+		!strings.Contains(rec.Body.String(), `"status":"archived"`) {
+		t.Errorf("archive: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/ledger/accounts/"+acc.ID.Hex()+"/statement/pdf?from=2025-01-01T00:00:00Z", ""); rec.Code != http.StatusOK ||
+		rec.Header().Get("Content-Type") != "application/pdf" ||
+		rec.Header().Get("Content-Disposition") != `attachment; filename="statement-`+acc.ID.Hex()+`.pdf"` || !strings.HasPrefix(rec.Body.String(), "%PDF-") {
+		t.Errorf("statement pdf: %d %v %s", rec.Code, rec.Header(), rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/ledger/accounts/"+acc.ID.Hex()+"/statement/pdf?from=2025-02-01T00:00:00Z&to=2025-01-01T00:00:00Z", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("statement pdf with inverted period: expected 400, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/ledger/accounts/"+acc.ID.Hex()+"/freeze", `{"reason":"chargeback"}`); rec.Code != http.StatusOK ||
+		!strings.Contains(rec.Body.String(), `"status":"frozen","status_reason":"chargeback"`) {
+		t.Errorf("freeze: %d %s", rec.Code, rec.Body.String())
+	}
+
+	gateway := primitive.NewObjectID()
+	body := fmt.Sprintf(`{"from_account_id":%q,"to_account_id":%q,"amount":"100.50","tranref":"MPESA1","narrative":"M-Pesa QK12","metadata":{"msisdn":"254700000000"}}`, acc.ID.Hex(), gateway.Hex())
+	if rec := do(http.MethodPost, "/ledger/postings/topup", body); rec.Code != http.StatusNoContent {
+		t.Errorf("topup: %d %s", rec.Code, rec.Body.String())
+	}
+	if len(ledger.postings) != 1 || ledger.postings[0] != fmt.Sprintf(`topup %s %s 100.5 MPESA1 "M-Pesa QK12" map[msisdn:254700000000]`, acc.ID.Hex(), gateway.Hex()) {
+		t.Errorf("unexpected postings %v", ledger.postings)
+	}
+	body = fmt.Sprintf(`{"from_account_id":%q,"to_account_id":%q,"amount":"0","tranref":"MPESA2"}`, acc.ID.Hex(), gateway.Hex())
+	if rec := do(http.MethodPost, "/ledger/postings/topup", body); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("zero topup: expected 422, got %d", rec.Code)
+	}
+	body = fmt.Sprintf(`{"from_account_id":%q,"to_account_id":%q,"amount":"1.001","tranref":"MPESA3"}`, acc.ID.Hex(), gateway.Hex())
+	if rec := do(http.MethodPost, "/ledger/postings/topup", body); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("topup past the ledger precision: expected 422, got %d", rec.Code)
+	}
+
+	body = fmt.Sprintf(`{"from_account_id":%q,"to_account_id":%q,"amount":"25","tranref":"TRF1","narrative":"wallet move","metadata":{"ticket":"T-9"}}`, acc.ID.Hex(), gateway.Hex())
+	if rec := do(http.MethodPost, "/ledger/postings/transfer", body); rec.Code != http.StatusCreated ||
+		!strings.Contains(rec.Body.String(), `"type":"Transfer","amount":"25","tranref":"TRF1","narrative":"wallet move","metadata":{"ticket":"T-9"}`) {
+		t.Errorf("transfer: %d %s", rec.Code, rec.Body.String())
+	}
+	body = fmt.Sprintf(`{"from_account_id":%q,"to_account_id":%q,"amount":"25","tranref":"TRF2"}`, acc.ID.Hex(), acc.ID.Hex())
+	if rec := do(http.MethodPost, "/ledger/postings/transfer", body); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("transfer to same account: expected 422, got %d", rec.Code)
+	}
+
+	if rec := do(http.MethodGet, "/ledger/journals/search?type=TopUp&tranref=MPESA1&min_amount=100.5&sort=-amount", ""); rec.Code != http.StatusOK ||
+		!strings.Contains(rec.Body.String(), `"amount":"100.5","tranref":"MPESA1"`) || !strings.Contains(rec.Body.String(), `"total":1`) {
+		t.Errorf("search journals: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/ledger/journals/ref/MPESA1?type=Reversal&from=2025-01-01T00:00:00Z", ""); rec.Code != http.StatusOK ||
+		!strings.Contains(rec.Body.String(), `"type":"Reversal"`) || !strings.Contains(rec.Body.String(), `"tranref":"MPESA1"`) ||
+		!strings.Contains(rec.Body.String(), `2025-01-01T00:00:00Z`) {
+		t.Errorf("journals by ref: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/ledger/journals/ref/MPESA1?from=2025-02-01T00:00:00Z&to=2025-01-01T00:00:00Z", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("journals by ref with inverted period: expected 400, got %d", rec.Code)
+	}
+	journal := accounting.JournalEntry{ID: primitive.NewObjectID(), Type: accounting.TopUp, TranRef: "MPESA9"}
+	ledger.journals = map[primitive.ObjectID]accounting.JournalEntry{journal.ID: journal}
+	if rec := do(http.MethodGet, "/ledger/journals/"+journal.ID.Hex(), ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"tranref":"MPESA9"`) {
+		t.Errorf("get journal: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/ledger/journals/"+primitive.NewObjectID().Hex(), ""); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown journal: expected 404, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/ledger/journals/nope", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid journal id: expected 400, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/ledger/journals/search?sort=tranref", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid sort: expected 400, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/ledger/journals/search?min_amount=ten", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid min_amount: expected 400, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/ledger/journals/search?metadata=bank_ref", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid metadata: expected 400, got %d", rec.Code)
+	}
+
+	if rec := do(http.MethodGet, "/ledger/journals/export?tranref=MPESA1", ""); rec.Code != http.StatusOK ||
+		rec.Header().Get("Content-Disposition") != `attachment; filename="journals.csv"` || !strings.HasSuffix(rec.Body.String(), ",MPESA1\n") {
+		t.Errorf("export journals: %d %v %s", rec.Code, rec.Header(), rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/ledger/journals/export?format=pdf", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid export format: expected 400, got %d", rec.Code)
+	}
+
+	if rec := do(http.MethodGet, "/ledger/dashboard/top-accounts?limit=5", ""); rec.Code != http.StatusOK ||
+		!strings.Contains(rec.Body.String(), `"account_name":"top 5"`) || !strings.Contains(rec.Body.String(), `"volume":"270"`) {
+		t.Errorf("top accounts: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/ledger/dashboard/top-accounts?from=2025-02-01T00:00:00Z&to=2025-01-01T00:00:00Z", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("inverted period: expected 400, got %d", rec.Code)
+	}
+
+	if rec := do(http.MethodGet, "/ledger/audit?entity_id="+acc.ID.Hex()+"&actor_id=u-42&action=AccountFrozen&limit=20", ""); rec.Code != http.StatusOK ||
+		!strings.Contains(rec.Body.String(), `"action":"AccountFrozen","actor":{"id":"u-42"},"entity_id":"`+acc.ID.Hex()+`"`) || !strings.Contains(rec.Body.String(), `"total":1`) {
+		t.Errorf("audit log: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/ledger/audit?from=last-week", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid audit from: expected 400, got %d", rec.Code)
+	}
+
+	journalID := primitive.NewObjectID()
+	if rec := do(http.MethodPost, "/ledger/journals/"+journalID.Hex()+"/reversal", `{"reason":"duplicate top-up"}`); rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), `"reversal_of":"`+journalID.Hex()+`"`) {
+		t.Errorf("reversal: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/ledger/journals/"+journalID.Hex()+"/reversal", `{"reason":"again"}`); rec.Code != http.StatusConflict {
+		t.Errorf("double reversal: expected 409, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/ledger/journals/"+journalID.Hex()+"/reversal", `{}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("reversal without reason: expected 400, got %d", rec.Code)
+	}
+	batch := fmt.Sprintf(`{"postings":[
+		{"type":"TopUp","debit_account_id":%[1]q,"credit_account_id":%[2]q,"amount":"50","tranref":"GW-1"},
+		{"type":"PremiumPayment","debit_account_id":%[2]q,"credit_account_id":%[1]q,"amount":"500","tranref":"PREM-1"}]}`,
+		primitive.NewObjectID().Hex(), acc.ID.Hex())
+	if rec := do(http.MethodPost, "/ledger/postings/batch", batch); rec.Code != http.StatusOK ||
+		!strings.Contains(rec.Body.String(), `{"status":201,"entry":{"id":"`) ||
+		!strings.Contains(rec.Body.String(), `{"status":422,"error":"insufficient funds: `+acc.ID.Hex()+`"}],"posted":1,"failed":1}`) {
+		t.Errorf("batch: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/ledger/postings/batch", `{"postings":[{"type":"TopUp","debit_account_id":"x","credit_account_id":"y","amount":"1","tranref":"R"}]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("batch with invalid account: expected 400, got %d", rec.Code)
+	}
+	premiumID := primitive.NewObjectID()
+	if rec := do(http.MethodPost, "/ledger/journals/"+premiumID.Hex()+"/commission", ""); rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), `"amount":"1.5"`) {
+		t.Errorf("commission: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/ledger/journals/"+journalID.Hex()+"/commission", ""); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("commission of a reversed premium: expected 422, got %d", rec.Code)
+	}
+
+	gatewayID, suspenseID := primitive.NewObjectID(), primitive.NewObjectID()
+	posted := do(http.MethodPost, "/ledger/postings/suspense",
+		fmt.Sprintf(`{"from_account_id":%q,"to_account_id":%q,"amount":"75","tranref":"MPESA-9"}`, gatewayID.Hex(), suspenseID.Hex()))
+	var receipt struct {
+		ID string `json:"id"`
+	}
+	if posted.Code != http.StatusCreated || json.Unmarshal(posted.Body.Bytes(), &receipt) != nil || !strings.Contains(posted.Body.String(), `"type":"SuspenseReceipt"`) {
+		t.Errorf("post to suspense: %d %s", posted.Code, posted.Body.String())
+	}
+	if rec := do(http.MethodGet, "/ledger/accounts/"+suspenseID.Hex()+"/suspense", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), receipt.ID) {
+		t.Errorf("unmatched suspense: %d %s", rec.Code, rec.Body.String())
+	}
+	matchedRec := do(http.MethodPost, "/ledger/journals/"+receipt.ID+"/match", `{"account_id":"`+acc.ID.Hex()+`"}`)
+	var clearing struct {
+		ID string `json:"id"`
+	}
+	if matchedRec.Code != http.StatusCreated || json.Unmarshal(matchedRec.Body.Bytes(), &clearing) != nil ||
+		!strings.Contains(matchedRec.Body.String(), `"type":"SuspenseClearing"`) || !strings.Contains(matchedRec.Body.String(), `"clearing_of":"`+receipt.ID+`"`) {
+		t.Errorf("match suspense: %d %s", matchedRec.Code, matchedRec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/ledger/journals/"+receipt.ID+"/match", `{"account_id":"`+acc.ID.Hex()+`"}`); rec.Code != http.StatusConflict {
+		t.Errorf("double match: expected 409, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/ledger/journals/"+receipt.ID+"/match", `{}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("match without account: expected 400, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/ledger/accounts/"+suspenseID.Hex()+"/suspense", ""); rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), receipt.ID) {
+		t.Errorf("matched receipt still listed: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/ledger/journals/"+clearing.ID+"/unmatch", `{}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("unmatch without reason: expected 400, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/ledger/journals/"+receipt.ID+"/unmatch", `{"reason":"wrong client"}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("unmatch of a receipt: expected 422, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/ledger/journals/"+clearing.ID+"/unmatch", `{"reason":"wrong client"}`); rec.Code != http.StatusCreated ||
+		!strings.Contains(rec.Body.String(), `"reversal_of":"`+clearing.ID+`"`) {
+		t.Errorf("unmatch: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/ledger/accounts/"+suspenseID.Hex()+"/suspense", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), receipt.ID) {
+		t.Errorf("unmatched receipt not listed: %d %s", rec.Code, rec.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/ledger/accounts/"+acc.ID.Hex(), nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("unauthenticated: expected 401, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,58 @@
+package accountinghttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ServerConfig configures the HTTP server started by ListenAndServe.
+type ServerConfig struct {
+	Addr            string        // Listen address, e.g. ":8080"
+	ReadTimeout     time.Duration // Defaults to 15s
+	WriteTimeout    time.Duration // Defaults to 30s
+	ShutdownTimeout time.Duration // Grace period for in-flight requests, defaults to 10s
+}
+
+// ListenAndServe serves handler until ctx is cancelled and then shuts the server
+// down gracefully. It returns nil after a clean shutdown.
+func ListenAndServe(ctx context.Context, cfg ServerConfig, handler http.Handler) error {
+	if cfg.ReadTimeout <= 0 {
+		cfg.ReadTimeout = 15 * time.Second
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = 30 * time.Second
+	}
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = 10 * time.Second
+	}
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+// Package accountingtest runs the MongoDB the accounting integration tests need in a
+// throwaway Docker container, testcontainers style, so they run in CI without a replica
+// set on localhost. It drives the docker CLI and has no dependency beyond the driver.
+package accountingtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DefaultImage is the MongoDB image StartMongo runs when none is given
+const DefaultImage = "mongo:7"
+
+const replicaSet = "rs0"
+
+// ErrDockerUnavailable is returned by StartMongo when the docker CLI is not installed,
+// tests usually skip on it
+var ErrDockerUnavailable = errors.New("docker is not available")
+
+// MongoContainer is a single-node MongoDB replica set, so transactions work, running in
+// a container removed by Terminate
+type MongoContainer struct {
+	ID     string
+	URI    string // connection string of the mapped port
+	Client *mongo.Client
+}
+
+// StartMongo starts image (DefaultImage when empty) as a single-node replica set and
+// waits until it accepts transactions
+func StartMongo(ctx context.Context, image string) (*MongoContainer, error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDockerUnavailable, err)
+	}
+	if image == "" {
+		image = DefaultImage
+	}
+	id, err := docker(ctx, "run", "-d", "--rm", "-p", "127.0.0.1::27017", image, "--replSet", replicaSet, "--bind_ip_all")
+	if err != nil {
+		return nil, err
+	}
+	c := &MongoContainer{ID: id}
+	if err := c.start(ctx); err != nil {
+		_ = c.Terminate(context.WithoutCancel(ctx))
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *MongoContainer) start(ctx context.Context) error {
+	hostPort, err := docker(ctx, "port", c.ID, "27017/tcp")
+	if err != nil {
+		return err
+	}
+	// the member is known as localhost:27017 inside the container, clients connect to the
+	// mapped port directly instead of discovering it
+	hostPort, _, _ = strings.Cut(hostPort, "\n")
+	c.URI = "mongodb://" + hostPort + "/?directConnection=true"
+	if c.Client, err = mongo.Connect(ctx, options.Client().ApplyURI(c.URI)); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	admin := c.Client.Database("admin")
+	initiated := false
+	for {
+		if !initiated {
+			err = admin.RunCommand(ctx, bson.D{{Key: "replSetInitiate", Value: bson.M{
+				"_id":     replicaSet,
+				"members": bson.A{bson.M{"_id": 0, "host": "localhost:27017"}},
+			}}}).Err()
+			initiated = err == nil
+		} else {
+			var hello struct {
+				IsWritablePrimary bool `bson:"isWritablePrimary"`
+			}
+			err = admin.RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+			if err == nil && hello.IsWritablePrimary {
+				return nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("mongo container %s not ready: %w (last error: %v)", c.ID, ctx.Err(), err)
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
+
+// Terminate disconnects the client and removes the container
+func (c *MongoContainer) Terminate(ctx context.Context) error {
+	if c.Client != nil {
+		_ = c.Client.Disconnect(ctx)
+	}
+	_, err := docker(ctx, "rm", "-f", c.ID)
+	return err
+}
+
+func docker(ctx context.Context, args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("docker %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
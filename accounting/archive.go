@@ -0,0 +1,140 @@
+package accounting
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// --------------------------
+//  Archival
+// --------------------------
+
+const (
+	// archiveBatch is the number of journal entries ArchiveJournals moves per transaction
+	archiveBatch = 500
+
+	defaultRetentionYears    = 7
+	defaultRetentionInterval = 24 * time.Hour
+)
+
+// ArchiveAccount soft-deletes a closed account, e.g. a dormant client. It keeps its
+// entries and is still read by ID, but ListAccounts leaves it out unless
+// AccountFilter.IncludeArchived is set. Archiving an archived account does nothing; any
+// other account fails with ErrAccountNotClosed.
+func (s *AccountingService) ArchiveAccount(ctx context.Context, accountID primitive.ObjectID, reason string) error {
+	return s.runInTransaction(ctx, func(sc context.Context) error {
+		acc, err := s.repo.GetAccount(sc, accountID)
+		if err != nil {
+			return err
+		}
+		switch acc.GetStatus() {
+		case AccountArchived:
+			return nil
+		case AccountClosed:
+		default:
+			return fmt.Errorf("%w: %s", ErrAccountNotClosed, accountID.Hex())
+		}
+		before := auditStatus(acc.GetStatus(), acc.StatusReason)
+		if _, err := s.repo.SetAccountStatus(sc, accountID, AccountArchived, reason, time.Now()); err != nil {
+			return err
+		}
+		return s.audit(sc, AuditAccountArchived, accountID, reason, before, auditStatus(AccountArchived, reason))
+	})
+}
+
+// ArchiveJournals moves the journal entries created before cutoff to the archive, so the
+// journal postings and reports query stays small as volume grows. Every account is first
+// snapshotted at cutoff, so later balances replay only the live entries. Statements,
+// reconciliation and VerifyLedgerIntegrity still read the archived entries, and
+// QueryJournals searches them with JournalFilter.Archived, but they can no longer be
+// reversed, nor found by idempotent postings reusing their tranref. It returns the
+// number of entries moved.
+func (s *AccountingService) ArchiveJournals(ctx context.Context, cutoff time.Time) (int64, error) {
+	if cutoff.IsZero() || cutoff.After(time.Now()) {
+		return 0, fmt.Errorf("%w: archive cutoff must be in the past", ErrInvalidFilter)
+	}
+	if _, err := s.CreateBalanceSnapshots(ctx, cutoff); err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for {
+		var n int64
+		err := s.runInTransaction(ctx, func(sc context.Context) error {
+			var err error
+			n, err = s.repo.ArchiveJournals(sc, cutoff, archiveBatch)
+			return err
+		})
+		if err != nil {
+			return total, err
+		}
+		total += n
+		if n < archiveBatch {
+			break
+		}
+	}
+	err := s.audit(ctx, AuditJournalsArchived, primitive.NilObjectID, "", nil, map[string]string{
+		"cutoff":  cutoff.UTC().Format(time.RFC3339Nano),
+		"entries": strconv.FormatInt(total, 10),
+	})
+	return total, err
+}
+
+// RetentionConfig configures a RetentionJob
+type RetentionConfig struct {
+	Years    int           // Age in years past which journal entries are archived, defaults to 7
+	Interval time.Duration // Wait between runs, defaults to 24h
+	OnError  func(err error)
+}
+
+// RetentionJob archives the journal entries older than the retention period, see
+// ArchiveJournals. The cutoff is the start of the UTC day, so runs within a day archive
+// up to the same instant.
+type RetentionJob struct {
+	svc *AccountingService
+	cfg RetentionConfig
+	now func() time.Time
+}
+
+// NewRetentionJob returns the retention job of the journal of s
+func NewRetentionJob(s *AccountingService, cfg RetentionConfig) *RetentionJob {
+	if cfg.Years <= 0 {
+		cfg.Years = defaultRetentionYears
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultRetentionInterval
+	}
+	return &RetentionJob{svc: s, cfg: cfg, now: time.Now}
+}
+
+// Cutoff returns the instant before which the entries are archived at now
+func (j *RetentionJob) Cutoff(now time.Time) time.Time {
+	return now.UTC().Truncate(24*time.Hour).AddDate(-j.cfg.Years, 0, 0)
+}
+
+// RunOnce archives the entries older than the retention period and returns how many it moved
+func (j *RetentionJob) RunOnce(ctx context.Context) (int64, error) {
+	return j.svc.ArchiveJournals(ctx, j.Cutoff(j.now()))
+}
+
+// Start runs the job every Interval until ctx is cancelled, the first time right away
+func (j *RetentionJob) Start(ctx context.Context) {
+	go func() {
+		for {
+			if _, err := j.RunOnce(ctx); err != nil && ctx.Err() == nil && j.cfg.OnError != nil {
+				j.cfg.OnError(err)
+			}
+			timer := time.NewTimer(j.cfg.Interval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+}
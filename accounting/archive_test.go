@@ -0,0 +1,140 @@
+package accounting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestArchiveAccount(t *testing.T) {
+	ctx := context.Background()
+	s := NewAccountingServiceWithRepository(NewMemoryRepository())
+	gateway, _ := s.CreateAccount(ctx, PaymentGateway, decimal.Zero, "gateway")
+	client, _ := s.CreateAccount(ctx, ClientInsurance, decimal.Zero, "dormant client")
+
+	assert.ErrorIs(t, s.ArchiveAccount(ctx, client.ID, "dormant"), ErrAccountNotClosed)
+	_, err := s.CloseAccount(ctx, client.ID, primitive.NilObjectID, "dormant")
+	require.NoError(t, err)
+	require.NoError(t, s.ArchiveAccount(ctx, client.ID, "dormant"))
+	require.NoError(t, s.ArchiveAccount(ctx, client.ID, "dormant"))
+
+	acc, err := s.GetAccountByID(ctx, client.ID)
+	require.NoError(t, err)
+	assert.Equal(t, AccountArchived, acc.GetStatus())
+
+	accounts, total, err := s.ListAccounts(ctx, AccountFilter{}, Pagination{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, gateway.ID, accounts[0].ID)
+	_, total, err = s.ListAccounts(ctx, AccountFilter{IncludeArchived: true}, Pagination{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+
+	assert.ErrorIs(t, s.ClientAccountTopUp(ctx, client.ID, gateway.ID, decimal.NewFromInt(1), "GW-1"), ErrAccountClosed)
+	assert.ErrorIs(t, s.FreezeAccount(ctx, client.ID, "fraud"), ErrAccountClosed)
+
+	records, _, err := s.ListAuditRecords(ctx, AuditFilter{Action: AuditAccountArchived}, Pagination{})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, map[string]string{"status": "closed", "status_reason": "dormant"}, records[0].Before)
+}
+
+func TestArchiveJournals(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	s := NewAccountingServiceWithRepository(repo)
+	gateway, _ := s.CreateAccount(ctx, PaymentGateway, decimal.Zero, "gateway")
+	client, _ := s.CreateAccount(ctx, ClientInsurance, decimal.Zero, "client")
+	underwriter, _ := s.CreateAccount(ctx, UnderwriterPremiumPayable, decimal.Zero, "underwriter")
+
+	require.NoError(t, s.ClientAccountTopUp(ctx, client.ID, gateway.ID, decimal.NewFromInt(100), "GW-1"))
+	require.NoError(t, s.ClientPremiumPayment(ctx, client.ID, underwriter.ID, decimal.NewFromInt(30), "PREM-1"))
+	time.Sleep(time.Millisecond)
+	cutoff := time.Now()
+	time.Sleep(time.Millisecond)
+	require.NoError(t, s.ClientAccountTopUp(ctx, client.ID, gateway.ID, decimal.NewFromInt(50), "GW-2"))
+
+	reconciled, err := s.GetReconciliationReport(ctx)
+	require.NoError(t, err)
+
+	_, err = s.ArchiveJournals(ctx, time.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+	n, err := s.ArchiveJournals(ctx, cutoff)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.Len(t, repo.journals, 1)
+	n, err = s.ArchiveJournals(ctx, cutoff)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	live, total, err := s.QueryJournals(ctx, JournalFilter{AccountID: client.ID}, JournalSort{}, Pagination{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "GW-2", live[0].TranRef)
+	archived, total, err := s.QueryJournals(ctx, JournalFilter{AccountID: client.ID, Archived: true}, JournalSort{Ascending: true}, Pagination{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, "GW-1", archived[0].TranRef)
+
+	// history still adds up
+	balance, err := s.GetBalanceAsOf(ctx, client.ID, cutoff)
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(70).Equal(balance), balance.String())
+	stmt, err := s.GetAccountStatement(ctx, client.ID, time.Time{}, time.Time{}, Pagination{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), stmt.TotalLines)
+	assert.True(t, decimal.NewFromInt(120).Equal(stmt.ClosingBalance), stmt.ClosingBalance.String())
+	report, err := s.GetReconciliationReport(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, reconciled, report)
+	integrity, err := s.VerifyLedgerIntegrity(ctx, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.True(t, integrity.Intact, integrity.Reason)
+	assert.Equal(t, 3, integrity.Checked)
+
+	snaps, err := s.ListSnapshots(ctx, client.ID, cutoff, cutoff)
+	require.NoError(t, err)
+	assert.Len(t, snaps, 1)
+}
+
+func TestReconcileAccount_AfterArchive(t *testing.T) {
+	ctx := context.Background()
+	s := NewAccountingServiceWithRepository(NewMemoryRepository())
+	gateway, _ := s.CreateAccount(ctx, PaymentGateway, decimal.Zero, "gateway")
+	client, _ := s.CreateAccount(ctx, ClientInsurance, decimal.NewFromInt(20), "client")
+	underwriter, _ := s.CreateAccount(ctx, UnderwriterPremiumPayable, decimal.Zero, "underwriter")
+
+	require.NoError(t, s.ClientAccountTopUp(ctx, client.ID, gateway.ID, decimal.NewFromInt(100), "GW-1"))
+	require.NoError(t, s.ClientPremiumPayment(ctx, client.ID, underwriter.ID, decimal.NewFromInt(30), "PREM-1"))
+	time.Sleep(time.Millisecond)
+	cutoff := time.Now()
+	time.Sleep(time.Millisecond)
+	require.NoError(t, s.ClientAccountTopUp(ctx, client.ID, gateway.ID, decimal.NewFromInt(50), "GW-2"))
+	_, err := s.ArchiveJournals(ctx, cutoff)
+	require.NoError(t, err)
+
+	res, err := s.ReconcileAccount(ctx, client.ID)
+	require.NoError(t, err)
+	assert.Equal(t, Reconciled, res.Status)
+	assert.True(t, decimal.NewFromInt(140).Equal(res.ComputedBalance), res.ComputedBalance.String())
+	assert.True(t, res.Discrepancy.IsZero())
+	assert.Equal(t, 3, res.JournalCount)
+
+	// an account whose history is all archived still has transactions
+	res, err = s.ReconcileAccount(ctx, underwriter.ID)
+	require.NoError(t, err)
+	assert.Equal(t, Reconciled, res.Status)
+	assert.True(t, decimal.NewFromInt(30).Equal(res.ComputedBalance), res.ComputedBalance.String())
+	assert.Equal(t, 1, res.JournalCount)
+}
+
+func TestRetentionJob_Cutoff(t *testing.T) {
+	job := NewRetentionJob(NewAccountingServiceWithRepository(NewMemoryRepository()), RetentionConfig{})
+	now := time.Date(2026, 10, 16, 15, 4, 5, 0, time.FixedZone("EAT", 3*60*60))
+	assert.Equal(t, time.Date(2019, 10, 16, 0, 0, 0, 0, time.UTC), job.Cutoff(now))
+}
@@ -0,0 +1,151 @@
+package accounting
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// --------------------------
+//  Audit Log
+// --------------------------
+
+// Actor is the person or system performing an administrative action, recorded in the
+// audit log. Set it on the context of the call with WithActor.
+type Actor struct {
+	ID   string `bson:"id" json:"id"`
+	Name string `bson:"name,omitempty" json:"name,omitempty"`
+	Role string `bson:"role,omitempty" json:"role,omitempty"`
+}
+
+type actorKey struct{}
+
+// WithActor returns a context recording actor as the author of the actions run with it
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set with WithActor
+func ActorFromContext(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	return actor, ok
+}
+
+// AuditAction is an administrative action recorded in the audit log
+type AuditAction string
+
+const (
+	AuditAccountCreated    AuditAction = "AccountCreated"
+	AuditOverdraftChanged  AuditAction = "OverdraftChanged"
+	AuditAccountFrozen     AuditAction = "AccountFrozen"
+	AuditAccountUnfrozen   AuditAction = "AccountUnfrozen"
+	AuditAccountClosed     AuditAction = "AccountClosed"
+	AuditJournalReversed   AuditAction = "JournalReversed"
+	AuditPeriodClosed      AuditAction = "PeriodClosed"
+	AuditScheduleCancelled AuditAction = "ScheduleCancelled"
+	AuditAccountArchived   AuditAction = "AccountArchived"
+	AuditJournalsArchived  AuditAction = "JournalsArchived"
+	AuditSuspenseMatched   AuditAction = "SuspenseMatched"
+	AuditSuspenseUnmatched AuditAction = "SuspenseUnmatched"
+)
+
+// AuditRecord is an entry of the audit log. Before and After hold the fields the action
+// changed, as strings; Before is empty for creations.
+type AuditRecord struct {
+	ID       primitive.ObjectID `bson:"_id" json:"id"`
+	Action   AuditAction        `bson:"action" json:"action"`
+	Actor    Actor              `bson:"actor" json:"actor"` // zero when the context carried none
+	EntityID string             `bson:"entity_id" json:"entity_id"`
+	Reason   string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	Before   map[string]string  `bson:"before,omitempty" json:"before,omitempty"`
+	After    map[string]string  `bson:"after,omitempty" json:"after,omitempty"`
+	At       time.Time          `bson:"at" json:"at"`
+}
+
+// AuditFilter selects audit records. Zero fields match every record.
+type AuditFilter struct {
+	EntityID string // Account, journal entry or scheduled posting hex ID
+	ActorID  string
+	Action   AuditAction
+	From     time.Time // Inclusive lower bound of At
+	To       time.Time // Inclusive upper bound of At
+}
+
+func (f AuditFilter) validate() error {
+	if !f.From.IsZero() && !f.To.IsZero() && f.To.Before(f.From) {
+		return fmt.Errorf("%w: date range ends before it starts", ErrInvalidFilter)
+	}
+	return nil
+}
+
+func (f AuditFilter) query() (bson.M, error) {
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+	q := bson.M{}
+	if f.EntityID != "" {
+		q["entity_id"] = f.EntityID
+	}
+	if f.ActorID != "" {
+		q["actor.id"] = f.ActorID
+	}
+	if f.Action != "" {
+		q["action"] = f.Action
+	}
+	at := bson.M{}
+	if !f.From.IsZero() {
+		at["$gte"] = f.From
+	}
+	if !f.To.IsZero() {
+		at["$lte"] = f.To
+	}
+	if len(at) > 0 {
+		q["at"] = at
+	}
+	return q, nil
+}
+
+// ListAuditRecords returns a page of the audit records matching filter, newest first,
+// and their total
+func (s *AccountingService) ListAuditRecords(ctx context.Context, filter AuditFilter, page Pagination) ([]AuditRecord, int64, error) {
+	if err := filter.validate(); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListAudit(ctx, filter, page.normalize())
+}
+
+// audit writes an audit record with the actor of ctx. Called within the transaction of
+// the action, the record is stored if and only if the action is.
+func (s *AccountingService) audit(ctx context.Context, action AuditAction, entityID primitive.ObjectID, reason string, before, after map[string]string) error {
+	actor, _ := ActorFromContext(ctx)
+	rec := &AuditRecord{
+		ID:       primitive.NewObjectID(),
+		Action:   action,
+		Actor:    actor,
+		EntityID: entityID.Hex(),
+		Reason:   reason,
+		Before:   before,
+		After:    after,
+		At:       time.Now(),
+	}
+	if err := s.repo.InsertAudit(ctx, rec); err != nil {
+		return fmt.Errorf("audit %s: %w", action, err)
+	}
+	return nil
+}
+
+// auditStatus is the audited state of the status of an account
+func auditStatus(status AccountStatus, reason string) map[string]string {
+	return map[string]string{"status": string(status), "status_reason": reason}
+}
+
+// auditOverdraft is the audited state of the overdraft policy of an account
+func auditOverdraft(acc *Account) map[string]string {
+	return map[string]string{
+		"allow_negative":  fmt.Sprint(acc.NegativeAllowed()),
+		"overdraft_limit": acc.OverdraftLimit,
+	}
+}
@@ -0,0 +1,75 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestAuditFilter_Query(t *testing.T) {
+	q, err := AuditFilter{}.query()
+	require.NoError(t, err)
+	assert.Empty(t, q)
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	q, err = AuditFilter{EntityID: "abc", ActorID: "u1", Action: AuditAccountFrozen, From: from, To: to}.query()
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{
+		"entity_id": "abc",
+		"actor.id":  "u1",
+		"action":    AuditAccountFrozen,
+		"at":        bson.M{"$gte": from, "$lte": to},
+	}, q)
+
+	_, err = AuditFilter{From: to, To: from}.query()
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+}
+
+func TestPgAuditFilterWhere(t *testing.T) {
+	where, args := pgAuditFilterWhere(AuditFilter{})
+	assert.Empty(t, where)
+	assert.Empty(t, args)
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	where, args = pgAuditFilterWhere(AuditFilter{EntityID: "abc", Action: AuditJournalReversed, From: from})
+	assert.Equal(t, " WHERE entity_id = $1 AND action = $2 AND at >= $3", where)
+	assert.Equal(t, []any{"abc", "JournalReversed", from}, args)
+}
+
+func TestAudit_RecordsActorAndChanges(t *testing.T) {
+	repo := &accountsRepo{}
+	svc := NewAccountingServiceWithRepository(repo)
+	actor := Actor{ID: "u-42", Name: "Jane Admin", Role: "finance"}
+	ctx := WithActor(context.Background(), actor)
+
+	acc, err := svc.CreateAccount(ctx, ClientInsurance, decimal.NewFromInt(25), "client")
+	require.NoError(t, err)
+	require.Len(t, repo.audit, 1)
+	created := repo.audit[0]
+	assert.Equal(t, AuditAccountCreated, created.Action)
+	assert.Equal(t, actor, created.Actor)
+	assert.Equal(t, acc.ID.Hex(), created.EntityID)
+	assert.Empty(t, created.Before)
+	assert.Equal(t, map[string]string{"type": "ClientInsurance", "name": "client", "opening_balance": "25"}, created.After)
+
+	require.NoError(t, svc.FreezeAccount(ctx, acc.ID, "KYC review"))
+	require.NoError(t, svc.FreezeAccount(ctx, acc.ID, "again"))
+	require.Len(t, repo.audit, 2, "an unchanged status is not audited")
+	frozen := repo.audit[1]
+	assert.Equal(t, AuditAccountFrozen, frozen.Action)
+	assert.Equal(t, "KYC review", frozen.Reason)
+	assert.Equal(t, "active", frozen.Before["status"])
+	assert.Equal(t, "frozen", frozen.After["status"])
+
+	require.NoError(t, svc.UnfreezeAccount(context.Background(), acc.ID))
+	require.Len(t, repo.audit, 3)
+	assert.Equal(t, AuditAccountUnfrozen, repo.audit[2].Action)
+	assert.Equal(t, Actor{}, repo.audit[2].Actor, "no actor on the context")
+}
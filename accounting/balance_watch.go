@@ -0,0 +1,110 @@
+package accounting
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// --------------------------
+//  Balance Watch
+// --------------------------
+
+// balanceWatchBuffer is the number of changes a BalanceWatch holds for a slow reader
+const balanceWatchBuffer = 64
+
+// BalanceChange is a committed change of an account balance by a journal entry
+type BalanceChange struct {
+	AccountID  primitive.ObjectID
+	OldBalance decimal.Decimal
+	NewBalance decimal.Decimal
+	JournalID  primitive.ObjectID
+	Type       TransactionType
+	TranRef    string
+	At         time.Time // CreatedAt of the journal entry
+}
+
+// BalanceWatch delivers the balance changes of an account on C, in commit order, until
+// Close is called, the context of WatchAccount ends or the stream fails. C is closed
+// then and Err returns the failure.
+type BalanceWatch struct {
+	C <-chan BalanceChange
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+// newBalanceWatch runs run in its own goroutine; run passes every change to send and
+// returns once send reports the watch ended or its stream fails
+func newBalanceWatch(ctx context.Context, run func(ctx context.Context, send func(BalanceChange) bool) error) *BalanceWatch {
+	ctx, cancel := context.WithCancel(ctx)
+	c := make(chan BalanceChange, balanceWatchBuffer)
+	w := &BalanceWatch{C: c, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(w.done)
+		defer close(c)
+		err := run(ctx, func(change BalanceChange) bool {
+			select {
+			case c <- change:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+		if ctx.Err() == nil {
+			w.err = err
+		}
+	}()
+	return w
+}
+
+// Close stops the watch and waits for C to be closed
+func (w *BalanceWatch) Close() {
+	w.cancel()
+	<-w.done
+}
+
+// Err returns the error that ended the watch once C is closed, nil when it was stopped
+// by Close or its context
+func (w *BalanceWatch) Err() error {
+	select {
+	case <-w.done:
+		return w.err
+	default:
+		return nil
+	}
+}
+
+// WatchAccount streams the balance changes of an account, e.g. to a dashboard, starting
+// with the first posting committed after it returns. The repository must support it:
+// MongoRepository does with change streams, which need a replica set, and
+// MemoryRepository does; otherwise ErrWatchUnsupported is returned.
+func (s *AccountingService) WatchAccount(ctx context.Context, accountID primitive.ObjectID) (*BalanceWatch, error) {
+	w, ok := s.repo.(interface {
+		WatchBalances(ctx context.Context, accountID primitive.ObjectID) (*BalanceWatch, error)
+	})
+	if !ok {
+		return nil, ErrWatchUnsupported
+	}
+	if _, err := s.repo.GetAccount(ctx, accountID); err != nil {
+		return nil, err
+	}
+	return w.WatchBalances(ctx, accountID)
+}
+
+// balanceChange returns the change entry made to the balance of accountID, which is
+// newBalance after the entry
+func balanceChange(accountID primitive.ObjectID, entry *JournalEntry, newBalance decimal.Decimal) BalanceChange {
+	return BalanceChange{
+		AccountID:  accountID,
+		OldBalance: newBalance.Sub(entry.BalanceEffect(accountID)),
+		NewBalance: newBalance,
+		JournalID:  entry.ID,
+		Type:       entry.Type,
+		TranRef:    entry.TranRef,
+		At:         entry.CreatedAt,
+	}
+}
@@ -0,0 +1,64 @@
+package accounting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestWatchAccount(t *testing.T) {
+	ctx := context.Background()
+	s := NewAccountingServiceWithRepository(NewMemoryRepository())
+	gateway, _ := s.CreateAccount(ctx, PaymentGateway, decimal.Zero, "gateway")
+	client, _ := s.CreateAccount(ctx, ClientInsurance, decimal.NewFromInt(10), "client")
+	other, _ := s.CreateAccount(ctx, ClientInsurance, decimal.Zero, "other")
+	underwriter, _ := s.CreateAccount(ctx, UnderwriterPremiumPayable, decimal.Zero, "underwriter")
+
+	w, err := s.WatchAccount(ctx, client.ID)
+	require.NoError(t, err)
+	next := func() BalanceChange {
+		t.Helper()
+		select {
+		case change, ok := <-w.C:
+			require.True(t, ok, "watch closed")
+			return change
+		case <-time.After(time.Second):
+			t.Fatal("no balance change")
+			return BalanceChange{}
+		}
+	}
+
+	require.NoError(t, s.ClientAccountTopUp(ctx, client.ID, gateway.ID, decimal.NewFromInt(100), "GW-1"))
+	require.NoError(t, s.ClientAccountTopUp(ctx, other.ID, gateway.ID, decimal.NewFromInt(5), "GW-2"))
+	// rolled back, nothing to see
+	assert.ErrorIs(t, s.ClientPremiumPayment(ctx, client.ID, underwriter.ID, decimal.NewFromInt(500), "PREM-1"), ErrInsufficientFunds)
+	premium, err := s.postDoubleEntry(ctx, PremiumPayment, decimal.NewFromInt(30), client.ID, underwriter.ID, "PREM-2")
+	require.NoError(t, err)
+
+	change := next()
+	assert.Equal(t, client.ID, change.AccountID)
+	assert.Equal(t, TopUp, change.Type)
+	assert.Equal(t, "GW-1", change.TranRef)
+	assert.True(t, decimal.NewFromInt(10).Equal(change.OldBalance), change.OldBalance.String())
+	assert.True(t, decimal.NewFromInt(110).Equal(change.NewBalance), change.NewBalance.String())
+
+	change = next()
+	assert.Equal(t, premium.ID, change.JournalID)
+	assert.True(t, decimal.NewFromInt(110).Equal(change.OldBalance), change.OldBalance.String())
+	assert.True(t, decimal.NewFromInt(80).Equal(change.NewBalance), change.NewBalance.String())
+
+	w.Close()
+	_, ok := <-w.C
+	assert.False(t, ok)
+	assert.NoError(t, w.Err())
+
+	_, err = s.WatchAccount(ctx, primitive.NewObjectID())
+	assert.ErrorIs(t, err, ErrAccountNotFound)
+	_, err = NewAccountingServiceWithRepository(NewPostgresRepository(nil)).WatchAccount(ctx, client.ID)
+	assert.ErrorIs(t, err, ErrWatchUnsupported)
+}
@@ -0,0 +1,151 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// --------------------------
+//  Bulk Postings
+// --------------------------
+
+// postingBatchChunk is the number of postings PostBatch commits per transaction
+const postingBatchChunk = 100
+
+// PostingOutcome is the result of a request of PostBatch: the posted entry, or with
+// idempotent postings the entry already posted under its tranref, or the error that kept
+// it from being posted
+type PostingOutcome struct {
+	Entry *JournalEntry
+	Err   error
+}
+
+// BatchResult holds the outcome of every request of PostBatch, in request order
+type BatchResult struct {
+	Outcomes []PostingOutcome
+	Posted   int
+	Failed   int
+}
+
+// PostBatch posts many double-entry postings, e.g. the nightly import of the payment
+// gateway. Every request is validated first; the valid ones are then posted in chunks of
+// 100, each chunk in one transaction. A request failing in its chunk, e.g. for
+// insufficient funds, is left out and the rest of the chunk posted, so each request
+// succeeds or fails on its own. The error is only set when ctx ends before every chunk
+// ran; the requests not attempted carry it in their outcome.
+func (s *AccountingService) PostBatch(ctx context.Context, reqs []PostingRequest) (*BatchResult, error) {
+	res := &BatchResult{Outcomes: make([]PostingOutcome, len(reqs))}
+	entries := make([]*JournalEntry, len(reqs))
+	accountTypes := map[primitive.ObjectID]AccountType{}
+	var valid []int
+	for i, req := range reqs {
+		entry, err := s.batchEntry(ctx, req, accountTypes)
+		if err != nil {
+			res.Outcomes[i].Err = err
+			continue
+		}
+		entries[i] = entry
+		valid = append(valid, i)
+	}
+
+	var err error
+	for chunk := range slices.Chunk(valid, postingBatchChunk) {
+		if err = ctx.Err(); err != nil {
+			for _, i := range chunk {
+				res.Outcomes[i].Err = err
+			}
+			continue
+		}
+		s.postChunk(ctx, chunk, entries, res.Outcomes)
+	}
+	for _, outcome := range res.Outcomes {
+		if outcome.Err != nil {
+			res.Failed++
+		} else {
+			res.Posted++
+		}
+	}
+	return res, err
+}
+
+// batchEntry validates req and returns its entry. The types of the accounts already
+// looked up are reused from accountTypes.
+func (s *AccountingService) batchEntry(ctx context.Context, req PostingRequest, accountTypes map[primitive.ObjectID]AccountType) (*JournalEntry, error) {
+	entry, err := s.newDoubleEntry(req.Type, req.Amount, req.DebitAccount, req.CreditAccount, req.TranRef, req.options())
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range []primitive.ObjectID{req.DebitAccount, req.CreditAccount} {
+		if _, ok := accountTypes[id]; ok {
+			continue
+		}
+		acc, err := s.repo.GetAccount(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		accountTypes[id] = acc.Type
+	}
+	if err := s.postingRules().Check(req.Type, accountTypes[req.DebitAccount], accountTypes[req.CreditAccount]); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// postChunk posts the entries of chunk, the request indexes, in one transaction. When an
+// entry fails the transaction is rolled back and run again without it.
+func (s *AccountingService) postChunk(ctx context.Context, chunk []int, entries []*JournalEntry, outcomes []PostingOutcome) {
+	pending := slices.Clone(chunk)
+	for len(pending) > 0 {
+		posted := make([]*JournalEntry, len(pending))
+		failed := -1
+		err := s.runInTransaction(ctx, func(sc context.Context) error {
+			failed = -1
+			for k, i := range pending {
+				entry, err := s.postInTransaction(sc, entries[i])
+				if err != nil {
+					failed = k
+					return err
+				}
+				posted[k] = entry
+			}
+			return nil
+		})
+
+		var exhausted *RetryExhaustedError
+		if err != nil && failed >= 0 && ctx.Err() == nil && !errors.As(err, &exhausted) {
+			outcomes[pending[failed]].Err = err
+			pending = slices.Delete(pending, failed, failed+1)
+			continue
+		}
+		for k, i := range pending {
+			if err != nil {
+				// the commit or the store failed, not a posting
+				outcomes[i].Err = err
+				continue
+			}
+			outcomes[i].Entry = posted[k]
+			if posted[k] == entries[i] {
+				s.publishPosted(ctx, posted[k])
+			}
+		}
+		return
+	}
+}
+
+// postInTransaction applies entry within the transaction of sc, or returns the entry
+// already posted under its idempotency key
+func (s *AccountingService) postInTransaction(sc context.Context, entry *JournalEntry) (*JournalEntry, error) {
+	if entry.IdempotencyKey != "" {
+		original, err := s.findPosted(sc, entry)
+		if err != nil || original != nil {
+			return original, err
+		}
+	}
+	if err := s.applyEntry(sc, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
@@ -0,0 +1,87 @@
+package accounting
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPostBatch_PartialFailure(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	s := NewAccountingServiceWithRepository(repo)
+	require.NoError(t, s.EnableIdempotentPostings(ctx))
+
+	gateway, _ := s.CreateAccount(ctx, PaymentGateway, decimal.Zero, "gateway")
+	client, _ := s.CreateAccount(ctx, ClientInsurance, decimal.Zero, "client")
+	underwriter, _ := s.CreateAccount(ctx, UnderwriterPremiumPayable, decimal.Zero, "underwriter")
+
+	topUp := func(ref string, amount int64) PostingRequest {
+		return PostingRequest{Type: TopUp, DebitAccount: gateway.ID, CreditAccount: client.ID, Amount: decimal.NewFromInt(amount), TranRef: ref}
+	}
+	premium := func(ref string, amount int64) PostingRequest {
+		return PostingRequest{Type: PremiumPayment, DebitAccount: client.ID, CreditAccount: underwriter.ID, Amount: decimal.NewFromInt(amount), TranRef: ref}
+	}
+	reqs := []PostingRequest{
+		topUp("GW-1", 100),
+		premium("PREM-1", 500), // insufficient funds when posted
+		premium("PREM-2", 60),
+		topUp("GW-2", 0), // invalid amount
+		{Type: TopUp, DebitAccount: client.ID, CreditAccount: gateway.ID, Amount: decimal.NewFromInt(1), TranRef: "GW-3"}, // swapped
+		{Type: TopUp, DebitAccount: primitive.NewObjectID(), CreditAccount: client.ID, Amount: decimal.NewFromInt(1), TranRef: "GW-4"},
+		topUp("GW-1", 100), // duplicate of the first
+	}
+
+	res, err := s.PostBatch(ctx, reqs)
+	require.NoError(t, err)
+	require.Len(t, res.Outcomes, len(reqs))
+	assert.Equal(t, 3, res.Posted)
+	assert.Equal(t, 4, res.Failed)
+
+	assert.NoError(t, res.Outcomes[0].Err)
+	assert.ErrorIs(t, res.Outcomes[1].Err, ErrInsufficientFunds)
+	assert.NoError(t, res.Outcomes[2].Err)
+	assert.Equal(t, "PREM-2", res.Outcomes[2].Entry.TranRef)
+	assert.ErrorIs(t, res.Outcomes[3].Err, ErrInvalidAmount)
+	assert.ErrorIs(t, res.Outcomes[4].Err, ErrPostingRule)
+	assert.ErrorIs(t, res.Outcomes[5].Err, ErrAccountNotFound)
+	assert.Equal(t, res.Outcomes[0].Entry.ID, res.Outcomes[6].Entry.ID)
+
+	balance, _ := s.GetAccountBalance(ctx, client.ID)
+	assert.True(t, decimal.NewFromInt(40).Equal(balance), balance.String())
+	assert.Len(t, repo.journals, 2)
+	report, err := s.VerifyLedgerIntegrity(ctx, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.True(t, report.Intact)
+	assert.Equal(t, 2, report.Checked)
+}
+
+func TestPostBatch_Chunks(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	s := NewAccountingServiceWithRepository(repo)
+	gateway, _ := s.CreateAccount(ctx, PaymentGateway, decimal.Zero, "gateway")
+	client, _ := s.CreateAccount(ctx, ClientInsurance, decimal.Zero, "client")
+
+	reqs := make([]PostingRequest, 2*postingBatchChunk+5)
+	for i := range reqs {
+		reqs[i] = PostingRequest{Type: TopUp, DebitAccount: gateway.ID, CreditAccount: client.ID, Amount: decimal.NewFromInt(1), TranRef: fmt.Sprintf("GW-%d", i)}
+	}
+	res, err := s.PostBatch(ctx, reqs)
+	require.NoError(t, err)
+	assert.Equal(t, len(reqs), res.Posted)
+	balance, _ := s.GetAccountBalance(ctx, client.ID)
+	assert.True(t, decimal.NewFromInt(int64(len(reqs))).Equal(balance), balance.String())
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	res, err = s.PostBatch(cancelled, reqs[:3])
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 3, res.Failed)
+}
@@ -0,0 +1,280 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// --------------------------
+//  Commission Rules
+// --------------------------
+
+const (
+	// Metadata keys of a premium payment read by PostCommissionForPremium, see WithCommission
+	MetadataAgentAccount = "agent_account" // hex ID of the AgentCommissionEarned account
+	MetadataProduct      = "product"
+
+	// Metadata keys of the commission entries
+	MetadataPremiumJournal = "premium_journal" // hex ID of the premium payment
+	MetadataCommissionRule = "commission_rule" // CommissionRule.Name
+
+	// commissionScale is the number of decimal places commissions are rounded to
+	commissionScale = 2
+)
+
+var hundred = decimal.NewFromInt(100)
+
+// errCommissionPosted aborts the posting of a commission already posted for a premium
+var errCommissionPosted = errors.New("commission already posted")
+
+// CommissionTier is a band of the premium earning its own rate: the part of the premium
+// above the previous band and up to UpTo
+type CommissionTier struct {
+	UpTo decimal.Decimal // Upper bound of the band, zero for the last, unbounded band
+	Rate decimal.Decimal // Percentage, e.g. 12.5
+}
+
+// CommissionRule computes the agent commission on the premiums paid to an underwriter
+// for a product. The most specific matching rule applies: underwriter and product, then
+// underwriter, then product, then the rule matching every premium.
+type CommissionRule struct {
+	Name          string             // Recorded in the metadata of the commission entries
+	UnderwriterID primitive.ObjectID // UnderwriterPremiumPayable account, zero matches every underwriter
+	Product       string             // Empty matches every product
+	Rate          decimal.Decimal    // Percentage of the premium, e.g. 12.5; unused when Tiers are set
+	Tiers         []CommissionTier   // Marginal bands, by ascending UpTo
+	Min           decimal.Decimal    // Lowest commission, zero for none
+	Max           decimal.Decimal    // Highest commission, zero for none
+}
+
+// Validate checks the rates, the order of the tiers and the caps
+func (r CommissionRule) Validate() error {
+	if err := validateCommissionRate(r.Rate); err != nil {
+		return fmt.Errorf("commission rule %q: %w", r.Name, err)
+	}
+	for i, tier := range r.Tiers {
+		if err := validateCommissionRate(tier.Rate); err != nil {
+			return fmt.Errorf("commission rule %q tier %d: %w", r.Name, i+1, err)
+		}
+		last := i == len(r.Tiers)-1
+		switch {
+		case tier.UpTo.IsZero() && !last:
+			return fmt.Errorf("commission rule %q tier %d: only the last tier may be unbounded", r.Name, i+1)
+		case tier.UpTo.IsNegative(), i > 0 && !tier.UpTo.IsZero() && tier.UpTo.LessThanOrEqual(r.Tiers[i-1].UpTo):
+			return fmt.Errorf("commission rule %q tier %d: bounds must be positive and ascending", r.Name, i+1)
+		}
+	}
+	if r.Min.IsNegative() || r.Max.IsNegative() {
+		return fmt.Errorf("commission rule %q: caps must not be negative", r.Name)
+	}
+	if !r.Max.IsZero() && r.Max.LessThan(r.Min) {
+		return fmt.Errorf("commission rule %q: max is below min", r.Name)
+	}
+	return nil
+}
+
+func validateCommissionRate(rate decimal.Decimal) error {
+	if rate.IsNegative() || rate.GreaterThan(hundred) {
+		return fmt.Errorf("rate %s is not a percentage", rate)
+	}
+	return nil
+}
+
+// Compute returns the commission on premium, rounded to cents and kept within the caps
+func (r CommissionRule) Compute(premium decimal.Decimal) decimal.Decimal {
+	commission := premium.Mul(r.Rate).Div(hundred)
+	if len(r.Tiers) > 0 {
+		commission = decimal.Zero
+		lower := decimal.Zero
+		for _, tier := range r.Tiers {
+			upper := premium
+			if !tier.UpTo.IsZero() && tier.UpTo.LessThan(premium) {
+				upper = tier.UpTo
+			}
+			if upper.LessThanOrEqual(lower) {
+				break
+			}
+			commission = commission.Add(upper.Sub(lower).Mul(tier.Rate).Div(hundred))
+			lower = upper
+		}
+	}
+	if commission.LessThan(r.Min) {
+		commission = r.Min
+	}
+	if !r.Max.IsZero() && commission.GreaterThan(r.Max) {
+		commission = r.Max
+	}
+	return commission.Round(commissionScale)
+}
+
+// matches reports whether the rule applies to the premium and how specific it is
+func (r CommissionRule) matches(underwriterID primitive.ObjectID, product string) (int, bool) {
+	specificity := 0
+	if !r.UnderwriterID.IsZero() {
+		if r.UnderwriterID != underwriterID {
+			return 0, false
+		}
+		specificity += 2
+	}
+	if r.Product != "" {
+		if r.Product != product {
+			return 0, false
+		}
+		specificity++
+	}
+	return specificity, true
+}
+
+// SetCommissionRules replaces the rules PostCommissionForPremium applies. Two rules may
+// not match the same underwriter and product.
+func (s *AccountingService) SetCommissionRules(rules []CommissionRule) error {
+	type scope struct {
+		underwriter primitive.ObjectID
+		product     string
+	}
+	seen := make(map[scope]bool, len(rules))
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+		sc := scope{rule.UnderwriterID, rule.Product}
+		if seen[sc] {
+			return fmt.Errorf("commission rule %q: another rule has the same underwriter and product", rule.Name)
+		}
+		seen[sc] = true
+	}
+	s.commissions = rules
+	return nil
+}
+
+// commissionRule returns the most specific rule matching the premium
+func (s *AccountingService) commissionRule(underwriterID primitive.ObjectID, product string) (CommissionRule, error) {
+	best, found := -1, CommissionRule{}
+	for _, rule := range s.commissions {
+		if specificity, ok := rule.matches(underwriterID, product); ok && specificity > best {
+			best, found = specificity, rule
+		}
+	}
+	if best < 0 {
+		return CommissionRule{}, fmt.Errorf("%w: underwriter %s, product %q", ErrNoCommissionRule, underwriterID.Hex(), product)
+	}
+	return found, nil
+}
+
+// WithCommission records on a premium payment the agent earning its commission and the
+// product sold, see PostCommissionForPremium
+func WithCommission(agentAccID primitive.ObjectID, product string) PostingOption {
+	metadata := map[string]string{MetadataAgentAccount: agentAccID.Hex()}
+	if product != "" {
+		metadata[MetadataProduct] = product
+	}
+	return WithMetadata(metadata)
+}
+
+// --------------------------
+//  Commission Posting
+// --------------------------
+
+// PostCommissionForPremium posts the commission earned on a premium payment, from the
+// underwriter account it credited to the agent account named in its metadata (see
+// WithCommission), computed with the matching commission rule. The commission shares
+// the tranref and transaction group of the premium. It is posted once: later calls return
+// the commission already posted; nil when the rule yields no commission.
+func (s *AccountingService) PostCommissionForPremium(ctx context.Context, premiumJournalID primitive.ObjectID) (*JournalEntry, error) {
+	premium, err := s.repo.GetJournal(ctx, premiumJournalID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCommissionable(premium); err != nil {
+		return nil, err
+	}
+	agentID, err := primitive.ObjectIDFromHex(premium.Metadata[MetadataAgentAccount])
+	if err != nil {
+		return nil, fmt.Errorf("%w: premium %s names no agent account", ErrNotCommissionable, premiumJournalID.Hex())
+	}
+	product := premium.Metadata[MetadataProduct]
+	rule, err := s.commissionRule(premium.CreditAccount, product)
+	if err != nil {
+		return nil, err
+	}
+	amount := s.round(rule.Compute(premium.GetAmount()))
+	if !amount.IsPositive() {
+		return nil, nil
+	}
+
+	metadata := map[string]string{MetadataPremiumJournal: premium.ID.Hex()}
+	if rule.Name != "" {
+		metadata[MetadataCommissionRule] = rule.Name
+	}
+	if product != "" {
+		metadata[MetadataProduct] = product
+	}
+	entry, err := s.newDoubleEntry(CommissionPayment, amount, premium.CreditAccount, agentID, premium.TranRef,
+		[]PostingOption{WithMetadata(metadata)})
+	if err != nil {
+		return nil, err
+	}
+	entry.TransactionID = premium.TransactionID
+	if entry.TransactionID.IsZero() {
+		entry.TransactionID = premium.ID
+	}
+
+	var existing *JournalEntry
+	posted, err := s.postEntry(ctx, entry, func(sc context.Context) error {
+		// the premium may have been reversed or its commission posted meanwhile
+		premium, err := s.repo.GetJournal(sc, premiumJournalID)
+		if err != nil {
+			return err
+		}
+		if err := checkCommissionable(premium); err != nil {
+			return err
+		}
+		if existing, err = s.findCommission(sc, premium); err != nil {
+			return err
+		}
+		if existing != nil {
+			return errCommissionPosted
+		}
+		debitType, creditType, err := s.accountTypes(sc, entry.DebitAccount, entry.CreditAccount)
+		if err != nil {
+			return err
+		}
+		return s.postingRules().Check(CommissionPayment, debitType, creditType)
+	})
+	if errors.Is(err, errCommissionPosted) {
+		return existing, nil
+	}
+	return posted, err
+}
+
+// checkCommissionable accepts the unreversed double-entry premium payments
+func checkCommissionable(premium *JournalEntry) error {
+	switch {
+	case premium.Type != PremiumPayment:
+		return fmt.Errorf("%w: %s is a %s", ErrNotCommissionable, premium.ID.Hex(), premium.Type)
+	case premium.IsCompound():
+		return fmt.Errorf("%w: %s is a compound entry", ErrNotCommissionable, premium.ID.Hex())
+	case premium.IsReversed():
+		return fmt.Errorf("%w: %s is reversed", ErrNotCommissionable, premium.ID.Hex())
+	}
+	return nil
+}
+
+// findCommission returns the unreversed commission posted for premium, nil when there is none
+func (s *AccountingService) findCommission(ctx context.Context, premium *JournalEntry) (*JournalEntry, error) {
+	entries, err := s.repo.JournalsByRef(ctx, premium.TranRef)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		e := &entries[i]
+		if e.Type == CommissionPayment && !e.IsReversed() && e.Metadata[MetadataPremiumJournal] == premium.ID.Hex() {
+			return e, nil
+		}
+	}
+	return nil, nil
+}
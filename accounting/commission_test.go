@@ -0,0 +1,132 @@
+package accounting
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCommissionRule_Compute(t *testing.T) {
+	dec := decimal.RequireFromString
+	tiered := CommissionRule{Tiers: []CommissionTier{
+		{UpTo: dec("1000"), Rate: dec("10")},
+		{UpTo: dec("5000"), Rate: dec("7.5")},
+		{Rate: dec("5")},
+	}}
+	cases := []struct {
+		name    string
+		rule    CommissionRule
+		premium string
+		want    string
+	}{
+		{"percentage", CommissionRule{Rate: dec("12.5")}, "333.33", "41.67"},
+		{"first tier", tiered, "800", "80"},
+		{"second tier", tiered, "3000", "250"},
+		{"unbounded tier", tiered, "6000", "450"},
+		{"min cap", CommissionRule{Rate: dec("10"), Min: dec("25")}, "100", "25"},
+		{"max cap", CommissionRule{Rate: dec("10"), Max: dec("300")}, "10000", "300"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.rule.Compute(dec(tc.premium))
+			assert.True(t, dec(tc.want).Equal(got), "got %s", got)
+		})
+	}
+}
+
+func TestSetCommissionRules_Validates(t *testing.T) {
+	dec := decimal.RequireFromString
+	s := NewAccountingServiceWithRepository(NewMemoryRepository())
+	underwriter := primitive.NewObjectID()
+
+	assert.Error(t, s.SetCommissionRules([]CommissionRule{{Rate: dec("120")}}))
+	assert.Error(t, s.SetCommissionRules([]CommissionRule{{Tiers: []CommissionTier{{Rate: dec("5")}, {UpTo: dec("100"), Rate: dec("3")}}}}))
+	assert.Error(t, s.SetCommissionRules([]CommissionRule{{Tiers: []CommissionTier{{UpTo: dec("500"), Rate: dec("5")}, {UpTo: dec("100"), Rate: dec("3")}}}}))
+	assert.Error(t, s.SetCommissionRules([]CommissionRule{{Rate: dec("5"), Min: dec("10"), Max: dec("5")}}))
+	assert.Error(t, s.SetCommissionRules([]CommissionRule{
+		{Name: "a", UnderwriterID: underwriter, Rate: dec("5")},
+		{Name: "b", UnderwriterID: underwriter, Rate: dec("6")},
+	}))
+	assert.NoError(t, s.SetCommissionRules([]CommissionRule{
+		{Name: "default", Rate: dec("5")},
+		{Name: "underwriter", UnderwriterID: underwriter, Rate: dec("6")},
+		{Name: "motor", UnderwriterID: underwriter, Product: "motor", Rate: dec("7")},
+	}))
+
+	rule, err := s.commissionRule(underwriter, "motor")
+	require.NoError(t, err)
+	assert.Equal(t, "motor", rule.Name)
+	rule, _ = s.commissionRule(underwriter, "health")
+	assert.Equal(t, "underwriter", rule.Name)
+	rule, _ = s.commissionRule(primitive.NewObjectID(), "motor")
+	assert.Equal(t, "default", rule.Name)
+}
+
+func TestPostCommissionForPremium(t *testing.T) {
+	dec := decimal.RequireFromString
+	ctx := context.Background()
+	s := NewAccountingServiceWithRepository(NewMemoryRepository())
+	client, _ := s.CreateAccount(ctx, ClientInsurance, dec("5000"), "client")
+	underwriter, _ := s.CreateAccount(ctx, UnderwriterPremiumPayable, decimal.Zero, "underwriter")
+	agent, _ := s.CreateAccount(ctx, AgentCommissionEarned, decimal.Zero, "agent")
+	require.NoError(t, s.SetCommissionRules([]CommissionRule{
+		{Name: "motor-std", UnderwriterID: underwriter.ID, Product: "motor", Rate: dec("12.5"), Max: dec("100")},
+	}))
+
+	premium, err := s.postDoubleEntry(ctx, PremiumPayment, dec("600"), client.ID, underwriter.ID, "POL-1",
+		WithCommission(agent.ID, "motor"))
+	require.NoError(t, err)
+
+	commission, err := s.PostCommissionForPremium(ctx, premium.ID)
+	require.NoError(t, err)
+	require.NotNil(t, commission)
+	assert.Equal(t, CommissionPayment, commission.Type)
+	assert.Equal(t, "75", commission.Amount)
+	assert.Equal(t, underwriter.ID, commission.DebitAccount)
+	assert.Equal(t, agent.ID, commission.CreditAccount)
+	assert.Equal(t, "POL-1", commission.TranRef)
+	assert.Equal(t, premium.ID, commission.TransactionID)
+	assert.Equal(t, map[string]string{
+		MetadataPremiumJournal: premium.ID.Hex(),
+		MetadataCommissionRule: "motor-std",
+		MetadataProduct:        "motor",
+	}, commission.Metadata)
+
+	// posted once
+	again, err := s.PostCommissionForPremium(ctx, premium.ID)
+	require.NoError(t, err)
+	assert.Equal(t, commission.ID, again.ID)
+	balance, _ := s.GetAccountBalance(ctx, agent.ID)
+	assert.True(t, dec("75").Equal(balance), balance.String())
+
+	// capped
+	big, err := s.postDoubleEntry(ctx, PremiumPayment, dec("4000"), client.ID, underwriter.ID, "POL-2",
+		WithCommission(agent.ID, "motor"))
+	require.NoError(t, err)
+	commission, err = s.PostCommissionForPremium(ctx, big.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "100", commission.Amount)
+
+	// no rule for the product
+	health, err := s.postDoubleEntry(ctx, PremiumPayment, dec("100"), client.ID, underwriter.ID, "POL-3",
+		WithCommission(agent.ID, "health"))
+	require.NoError(t, err)
+	_, err = s.PostCommissionForPremium(ctx, health.ID)
+	assert.ErrorIs(t, err, ErrNoCommissionRule)
+
+	// reversed premiums, other entries and premiums without agent earn nothing
+	_, err = s.ReverseJournalEntry(ctx, health.ID, "cancelled")
+	require.NoError(t, err)
+	_, err = s.PostCommissionForPremium(ctx, health.ID)
+	assert.ErrorIs(t, err, ErrNotCommissionable)
+	_, err = s.PostCommissionForPremium(ctx, commission.ID)
+	assert.ErrorIs(t, err, ErrNotCommissionable)
+	direct, err := s.postDoubleEntry(ctx, PremiumPayment, dec("10"), client.ID, underwriter.ID, "POL-4")
+	require.NoError(t, err)
+	_, err = s.PostCommissionForPremium(ctx, direct.ID)
+	assert.ErrorIs(t, err, ErrNotCommissionable)
+}
@@ -0,0 +1,105 @@
+package accounting
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// --------------------------
+//  Dashboards
+// --------------------------
+
+const (
+	defaultDashboardDays = 30
+	defaultTopAccounts   = 10
+	maxTopAccounts       = 100
+)
+
+// TypeBalance is the sum of the balances of the accounts of one type
+type TypeBalance struct {
+	AccountType  AccountType     `json:"account_type"`
+	AccountCount int64           `json:"account_count"`
+	Balance      decimal.Decimal `json:"balance"`
+}
+
+// DailyPostingTotal counts and sums the entries of one transaction type posted on one day
+type DailyPostingTotal struct {
+	Day    time.Time       `json:"day"` // midnight UTC
+	Type   TransactionType `json:"type"`
+	Count  int64           `json:"count"`
+	Amount decimal.Decimal `json:"amount"`
+}
+
+// AccountVolume is the value moved through an account over a period: the sum of its
+// debit and credit legs
+type AccountVolume struct {
+	AccountID   primitive.ObjectID `json:"account_id"`
+	AccountType AccountType        `json:"account_type"`
+	AccountName string             `json:"account_name"`
+	Legs        int64              `json:"legs"`
+	Debits      decimal.Decimal    `json:"debits"`
+	Credits     decimal.Decimal    `json:"credits"`
+	Volume      decimal.Decimal    `json:"volume"`
+}
+
+// GetBalancesByType returns the total balance and number of accounts of each account
+// type, ordered by type. The sums are computed by the database.
+func (s *AccountingService) GetBalancesByType(ctx context.Context) ([]TypeBalance, error) {
+	balances, err := s.repo.BalancesByType(ctx)
+	for i := range balances {
+		balances[i].Balance = s.round(balances[i].Balance)
+	}
+	return balances, err
+}
+
+// GetDailyPostingTotals returns the number and total amount of the entries posted each
+// UTC day between from and to (both inclusive), per transaction type, oldest day first.
+// A zero to is now, a zero from 30 days before to. Days without postings are left out.
+func (s *AccountingService) GetDailyPostingTotals(ctx context.Context, from, to time.Time) ([]DailyPostingTotal, error) {
+	from, to, err := dashboardRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := s.repo.DailyPostingTotals(ctx, from, to)
+	for i := range totals {
+		totals[i].Amount = s.round(totals[i].Amount)
+	}
+	return totals, err
+}
+
+// GetTopAccountsByVolume returns the n accounts that moved the most value between from
+// and to, bounded as in GetDailyPostingTotals, highest volume first. n defaults to 10
+// and is capped at 100.
+func (s *AccountingService) GetTopAccountsByVolume(ctx context.Context, from, to time.Time, n int) ([]AccountVolume, error) {
+	from, to, err := dashboardRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	if n <= 0 {
+		n = defaultTopAccounts
+	}
+	volumes, err := s.repo.TopAccountsByVolume(ctx, from, to, min(n, maxTopAccounts))
+	for i := range volumes {
+		v := &volumes[i]
+		v.Debits, v.Credits, v.Volume = s.round(v.Debits), s.round(v.Credits), s.round(v.Volume)
+	}
+	return volumes, err
+}
+
+// dashboardRange applies the default bounds of the dashboard periods
+func dashboardRange(from, to time.Time) (time.Time, time.Time, error) {
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -defaultDashboardDays)
+	}
+	if to.Before(from) {
+		return from, to, fmt.Errorf("%w: period ends before it starts", ErrInvalidFilter)
+	}
+	return from, to, nil
+}
@@ -0,0 +1,43 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// volumeRepo records the arguments of TopAccountsByVolume
+type volumeRepo struct {
+	AccountingRepository
+	from, to time.Time
+	limit    int
+}
+
+func (r *volumeRepo) TopAccountsByVolume(ctx context.Context, from, to time.Time, limit int) ([]AccountVolume, error) {
+	r.from, r.to, r.limit = from, to, limit
+	return nil, nil
+}
+
+func TestGetTopAccountsByVolume_Bounds(t *testing.T) {
+	ctx := context.Background()
+	repo := &volumeRepo{}
+	s := NewAccountingServiceWithRepository(repo)
+
+	_, err := s.GetTopAccountsByVolume(ctx, time.Time{}, time.Time{}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, defaultTopAccounts, repo.limit)
+	assert.WithinDuration(t, time.Now(), repo.to, time.Second)
+	assert.Equal(t, repo.to.AddDate(0, 0, -defaultDashboardDays), repo.from)
+
+	_, err = s.GetTopAccountsByVolume(ctx, time.Time{}, time.Time{}, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, maxTopAccounts, repo.limit)
+
+	now := time.Now()
+	_, err = s.GetTopAccountsByVolume(ctx, now, now.Add(-time.Hour), 5)
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+}
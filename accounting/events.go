@@ -0,0 +1,135 @@
+package accounting
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nana-tec/gopackages/eventbus"
+)
+
+// --------------------------
+//  Posting Events
+// --------------------------
+
+const (
+	// JournalPostedEvent is the integration event published for every posted journal entry
+	JournalPostedEvent = "accounting.journal.posted"
+
+	defaultEventPublisher = "accounting"
+	defaultEventTimeout   = 5 * time.Second
+)
+
+// JournalEvents publishes a JournalPostedEvent after every posting commits, reversals
+// included, so notification and reporting services can react without polling the
+// ledger. Idempotent retries returning an earlier entry publish nothing. Publishing is
+// best effort: a broker failure is reported to OnError and the posting stands.
+//
+// With an Outbox the event is instead written in the posting transaction, so it goes
+// out if and only if the posting commits, and Broker is not used. The outbox must be on
+// the MongoDB deployment of a MongoRepository ledger; a failed write fails the posting.
+type JournalEvents struct {
+	Broker        eventbus.IntergrationEventBroker     // Broker used to publish the events after commit
+	Outbox        eventbus.TransactionalEventPublisher // Outbox the events are written to in the posting transaction, e.g. an eventbus.MongoEventOutbox
+	EventName     string                               // Integration event name, defaults to JournalPostedEvent
+	PublisherName string                               // Name of the publishing service, defaults to "accounting"
+	Timeout       time.Duration                        // Publish timeout, defaults to 5s
+	OnError       func(entry *JournalEntry, err error)
+}
+
+// SetJournalEvents enables the posting events, a zero JournalEvents disables them
+func (s *AccountingService) SetJournalEvents(events JournalEvents) {
+	if events.EventName == "" {
+		events.EventName = JournalPostedEvent
+	}
+	if events.PublisherName == "" {
+		events.PublisherName = defaultEventPublisher
+	}
+	if events.Timeout <= 0 {
+		events.Timeout = defaultEventTimeout
+	}
+	s.events = events
+}
+
+// publishPosted publishes the posted event of entry once its posting committed
+func (s *AccountingService) publishPosted(ctx context.Context, entry *JournalEntry) {
+	if s.events.Broker == nil || s.events.Outbox != nil {
+		return
+	}
+	// the posting is committed, the event should go out even when the caller gives up
+	pubCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.events.Timeout)
+	defer cancel()
+
+	err := s.events.Broker.Publish(pubCtx, s.postedEvent(entry))
+	if err != nil && s.events.OnError != nil {
+		s.events.OnError(entry, err)
+	}
+}
+
+// writePostedEvent writes the posted event of entry to the outbox in the posting
+// transaction of sc, when an outbox is configured
+func (s *AccountingService) writePostedEvent(sc context.Context, entry *JournalEntry) error {
+	if s.events.Outbox == nil {
+		return nil
+	}
+	if err := s.events.Outbox.PublishInTransaction(sc, s.postedEvent(entry)); err != nil {
+		return fmt.Errorf("write posted event: %w", err)
+	}
+	return nil
+}
+
+// postedEvent returns the integration event announcing entry
+func (s *AccountingService) postedEvent(entry *JournalEntry) eventbus.IntergrationPubEvent {
+	return eventbus.IntergrationPubEvent{
+		EventName:          s.events.EventName,
+		EventTimestamp:     entry.CreatedAt,
+		EventData:          journalEventData(entry),
+		EventPublisherName: s.events.PublisherName,
+	}
+}
+
+// journalEventData describes entry with plain values: every leg with its account,
+// direction and amount, plus the debit and credit account of two-account entries
+func journalEventData(entry *JournalEntry) map[string]any {
+	legs := entry.postedLegs()
+	eventLegs := make([]map[string]any, 0, len(legs))
+	accountIDs := make([]string, 0, len(legs))
+	seen := make(map[string]bool, len(legs))
+	for _, leg := range legs {
+		id := leg.AccountID.Hex()
+		eventLegs = append(eventLegs, map[string]any{
+			"account_id": id,
+			"direction":  string(leg.Direction),
+			"amount":     leg.Amount,
+		})
+		if !seen[id] {
+			seen[id] = true
+			accountIDs = append(accountIDs, id)
+		}
+	}
+
+	data := map[string]any{
+		"journal_id":  entry.ID.Hex(),
+		"type":        string(entry.Type),
+		"amount":      entry.Amount,
+		"tranref":     entry.TranRef,
+		"account_ids": accountIDs,
+		"legs":        eventLegs,
+		"created_at":  entry.CreatedAt,
+	}
+	if !entry.IsCompound() {
+		data["debit_account_id"] = entry.DebitAccount.Hex()
+		data["credit_account_id"] = entry.CreditAccount.Hex()
+	}
+	if !entry.TransactionID.IsZero() {
+		data["transaction_id"] = entry.TransactionID.Hex()
+	}
+	if entry.ReversalOf != nil {
+		data["reversal_of"] = entry.ReversalOf.Hex()
+		data["reversal_reason"] = entry.ReversalReason
+	}
+	if entry.ClearingOf != nil {
+		data["clearing_of"] = entry.ClearingOf.Hex()
+	}
+	return data
+}
@@ -0,0 +1,147 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nana-tec/gopackages/eventbus"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type recordingBroker struct {
+	events []eventbus.IntergrationPubEvent
+	err    error
+}
+
+func (b *recordingBroker) Publish(ctx context.Context, event eventbus.IntergrationPubEvent) error {
+	b.events = append(b.events, event)
+	return b.err
+}
+
+func (b *recordingBroker) Subscribe(ctx context.Context, subscriber eventbus.IntergrationSubscriber) error {
+	return nil
+}
+
+func TestPublishPosted(t *testing.T) {
+	gateway, client := primitive.NewObjectID(), primitive.NewObjectID()
+	entry := &JournalEntry{
+		ID:            primitive.NewObjectID(),
+		Type:          TopUp,
+		Amount:        "250",
+		TranRef:       "MPESA1",
+		DebitAccount:  gateway,
+		CreditAccount: client,
+		CreatedAt:     time.Now(),
+	}
+
+	s := &AccountingService{}
+	s.publishPosted(context.Background(), entry) // no broker, nothing to do
+
+	broker := &recordingBroker{}
+	s.SetJournalEvents(JournalEvents{Broker: broker})
+	s.publishPosted(context.Background(), entry)
+	require.Len(t, broker.events, 1)
+	evt := broker.events[0]
+	assert.Equal(t, JournalPostedEvent, evt.EventName)
+	assert.Equal(t, "accounting", evt.EventPublisherName)
+	assert.Equal(t, "TopUp", evt.EventData["type"])
+	assert.Equal(t, "250", evt.EventData["amount"])
+	assert.Equal(t, "MPESA1", evt.EventData["tranref"])
+	assert.Equal(t, gateway.Hex(), evt.EventData["debit_account_id"])
+	assert.Equal(t, []string{gateway.Hex(), client.Hex()}, evt.EventData["account_ids"])
+
+	var failed *JournalEntry
+	broker.err = errors.New("broker down")
+	s.SetJournalEvents(JournalEvents{Broker: broker, OnError: func(e *JournalEntry, err error) { failed = e }})
+	s.publishPosted(context.Background(), entry)
+	assert.Same(t, entry, failed)
+}
+
+func TestJournalEventData_Compound(t *testing.T) {
+	a, b, c := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	entry := &JournalEntry{
+		ID:     primitive.NewObjectID(),
+		Type:   PremiumPayment,
+		Amount: "100",
+		Legs: []JournalLeg{
+			DebitLeg(a, decimal.NewFromInt(100)),
+			CreditLeg(b, decimal.NewFromInt(90)),
+			CreditLeg(c, decimal.NewFromInt(10)),
+		},
+	}
+	data := journalEventData(entry)
+	assert.NotContains(t, data, "debit_account_id")
+	assert.Equal(t, []string{a.Hex(), b.Hex(), c.Hex()}, data["account_ids"])
+	assert.Len(t, data["legs"], 3)
+}
+
+type recordingOutbox struct {
+	events []eventbus.IntergrationPubEvent
+	err    error
+}
+
+func (o *recordingOutbox) PublishInTransaction(ctx context.Context, event eventbus.IntergrationPubEvent) error {
+	if o.err != nil {
+		return o.err
+	}
+	o.events = append(o.events, event)
+	return nil
+}
+
+func TestJournalEvents_Outbox(t *testing.T) {
+	s, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	broker, outbox := &recordingBroker{}, &recordingOutbox{}
+	s.SetJournalEvents(JournalEvents{Broker: broker, Outbox: outbox})
+	client, _ := s.CreateAccount(ctx, ClientInsurance, decimal.Zero, "Client Outbox")
+	gateway, _ := s.CreateAccount(ctx, PaymentGateway, decimal.Zero, "Gateway Outbox")
+
+	require.NoError(t, s.ClientAccountTopUp(ctx, client.ID, gateway.ID, decimal.NewFromInt(100), "outbox1"))
+	require.Len(t, outbox.events, 1)
+	assert.Equal(t, JournalPostedEvent, outbox.events[0].EventName)
+	assert.Equal(t, "outbox1", outbox.events[0].EventData["tranref"])
+	assert.Empty(t, broker.events, "the outbox replaces publishing after commit")
+
+	// the posting fails with its event, nothing is committed
+	outbox.err = errors.New("outbox unavailable")
+	err := s.ClientAccountTopUp(ctx, client.ID, gateway.ID, decimal.NewFromInt(50), "outbox2")
+	require.ErrorIs(t, err, outbox.err)
+	bal, err := s.GetAccountBalance(ctx, client.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "100", bal.String())
+}
+
+func TestJournalEvents_MongoEventOutbox(t *testing.T) {
+	if testMongo == nil {
+		t.Skip("needs ACCOUNTING_TEST_MONGO")
+	}
+	ctx := context.Background()
+	db := testMongo.Database("accounting_test_" + primitive.NewObjectID().Hex())
+	defer func() { _ = db.Drop(ctx) }()
+	s := NewAccountingService(db)
+	require.NoError(t, s.EnsureIndexes(ctx))
+
+	broker := &recordingBroker{}
+	outbox := eventbus.NewMongoEventOutbox(db, broker, eventbus.OutboxConfig{})
+	s.SetJournalEvents(JournalEvents{Outbox: outbox})
+	client, _ := s.CreateAccount(ctx, ClientInsurance, decimal.Zero, "Client Mongo Outbox")
+	gateway, _ := s.CreateAccount(ctx, PaymentGateway, decimal.Zero, "Gateway Mongo Outbox")
+
+	require.NoError(t, s.ClientAccountTopUp(ctx, client.ID, gateway.ID, decimal.NewFromInt(100), "outbox1"))
+	// the outbox only writes in a transaction
+	err := outbox.PublishInTransaction(ctx, eventbus.IntergrationPubEvent{EventName: JournalPostedEvent})
+	require.ErrorIs(t, err, eventbus.ErrNoTransaction)
+
+	n, err := outbox.RelayPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	require.Len(t, broker.events, 1)
+	assert.Equal(t, "outbox1", broker.events[0].EventData["tranref"])
+}
@@ -0,0 +1,179 @@
+package accounting
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/nana-tec/gopackages/internal/xlsx"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// --------------------------
+//  Exports
+// --------------------------
+
+// ExportFormat selects the file format of ExportJournals and ExportStatement
+type ExportFormat string
+
+const (
+	ExportCSV  ExportFormat = "csv"
+	ExportXLSX ExportFormat = "xlsx"
+)
+
+var (
+	journalExportHeader = []any{
+		"Journal ID", "Created At", "Type", "Tran Ref", "Narrative", "Account ID", "Direction", "Amount", "Reversal Of",
+		"Reversed By", "Metadata",
+	}
+	statementExportHeader = []any{
+		"Date", "Journal ID", "Type", "Tran Ref", "Narrative", "Direction", "Counter Account", "Amount", "Balance", "Metadata",
+	}
+)
+
+// rowWriter is the common interface of the export formats
+type rowWriter interface {
+	WriteRow(values ...any) error
+	Close() error
+}
+
+type csvRowWriter struct{ w *csv.Writer }
+
+func (c csvRowWriter) WriteRow(values ...any) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		switch t := v.(type) {
+		case nil:
+		case time.Time:
+			record[i] = t.UTC().Format(time.RFC3339)
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	return c.w.Write(record)
+}
+
+func (c csvRowWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func newRowWriter(w io.Writer, format ExportFormat, sheetName string) (rowWriter, error) {
+	switch format {
+	case ExportCSV, "":
+		return csvRowWriter{w: csv.NewWriter(w)}, nil
+	case ExportXLSX:
+		return xlsx.NewWriter(w, sheetName)
+	}
+	return nil, fmt.Errorf("%w: unsupported export format %q", ErrInvalidFilter, format)
+}
+
+// metadataOrNil returns the exported form of metadata, nil when there is none
+func metadataOrNil(metadata map[string]string) any {
+	if len(metadata) == 0 {
+		return nil
+	}
+	return formatMetadata(metadata)
+}
+
+// hexOrNil returns the hex form of id, nil for an unset id so the cell stays empty
+func hexOrNil(id *primitive.ObjectID) any {
+	if id == nil || id.IsZero() {
+		return nil
+	}
+	return id.Hex()
+}
+
+// ExportJournals writes the journal entries matching filter, oldest first, as CSV or XLSX
+// with one row per leg, so debits and credits of each entry can be summed in the sheet.
+// Entries are streamed from the database, see StreamJournals.
+func (s *AccountingService) ExportJournals(ctx context.Context, filter JournalFilter, w io.Writer, format ExportFormat) error {
+	rw, err := newRowWriter(w, format, "Journals")
+	if err != nil {
+		return err
+	}
+	if err := rw.WriteRow(journalExportHeader...); err != nil {
+		return err
+	}
+	err = s.StreamJournals(ctx, filter, JournalSort{Ascending: true}, func(e *JournalEntry) error {
+		for _, leg := range e.postedLegs() {
+			if err := rw.WriteRow(
+				e.ID.Hex(), e.CreatedAt, string(e.Type), e.TranRef, e.Narrative, leg.AccountID.Hex(), string(leg.Direction),
+				leg.GetAmount(), hexOrNil(e.ReversalOf), hexOrNil(e.ReversedBy), metadataOrNil(e.Metadata),
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return rw.Close()
+}
+
+// streamAccountJournals calls fn with the entries of an account created between from
+// and to (both inclusive), oldest first: the archived entries, then the live ones
+func (s *AccountingService) streamAccountJournals(ctx context.Context, accountID primitive.ObjectID, from, to time.Time, fn func(*JournalEntry) error) error {
+	for _, archived := range []bool{true, false} {
+		filter := JournalFilter{AccountID: accountID, From: from, To: to, Archived: archived}
+		if err := s.StreamJournals(ctx, filter, JournalSort{Ascending: true}, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportStatement writes the statement of an account between from and to as CSV or XLSX:
+// an opening balance row, a row per statement line with its running balance, and a
+// closing balance row. Bounds default like GetAccountStatement; lines are streamed from
+// the database instead of paged.
+func (s *AccountingService) ExportStatement(ctx context.Context, accountID primitive.ObjectID, from, to time.Time, w io.Writer, format ExportFormat) error {
+	acc, err := s.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	if from.IsZero() || from.Before(acc.CreatedAt) {
+		from = acc.CreatedAt
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if to.Before(from) {
+		return fmt.Errorf("%w: statement ends before it starts", ErrInvalidFilter)
+	}
+	balance, err := s.balanceAt(ctx, acc, from, false)
+	if err != nil {
+		return err
+	}
+
+	rw, err := newRowWriter(w, format, "Statement")
+	if err != nil {
+		return err
+	}
+	if err := rw.WriteRow(statementExportHeader...); err != nil {
+		return err
+	}
+	if err := rw.WriteRow(from, nil, "Opening balance", nil, nil, nil, nil, nil, s.round(balance), nil); err != nil {
+		return err
+	}
+	err = s.streamAccountJournals(ctx, acc.ID, from, to, func(e *JournalEntry) error {
+		lines := statementLines(acc.ID, balance, []JournalEntry{*e})
+		balance = lines[0].Balance
+		s.roundLines(lines)
+		line := lines[0]
+		return rw.WriteRow(
+			line.CreatedAt, line.JournalID.Hex(), string(line.Type), line.TranRef, line.Narrative, string(line.Direction),
+			hexOrNil(&line.CounterAccount), line.Amount, line.Balance, metadataOrNil(line.Metadata),
+		)
+	})
+	if err != nil {
+		return err
+	}
+	if err := rw.WriteRow(to, nil, "Closing balance", nil, nil, nil, nil, nil, s.round(balance), nil); err != nil {
+		return err
+	}
+	return rw.Close()
+}
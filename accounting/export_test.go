@@ -0,0 +1,60 @@
+package accounting
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// streamRepo serves fixed entries to StreamJournals
+type streamRepo struct {
+	AccountingRepository
+	entries []JournalEntry
+}
+
+func (r *streamRepo) StreamJournals(ctx context.Context, filter JournalFilter, sort JournalSort, fn func(*JournalEntry) error) error {
+	for i := range r.entries {
+		if err := fn(&r.entries[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestExportJournals_CSVRowPerLeg(t *testing.T) {
+	client, gateway, fee := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	at := time.Date(2025, 3, 31, 12, 0, 0, 0, time.UTC)
+	repo := &streamRepo{entries: []JournalEntry{
+		{ID: primitive.NewObjectID(), Type: TopUp, Amount: "100", TranRef: "MPESA1", Narrative: "M-Pesa top-up",
+			Metadata: map[string]string{"msisdn": "254700000000", "bank_ref": "FT1"}, DebitAccount: gateway, CreditAccount: client, CreatedAt: at},
+		{ID: primitive.NewObjectID(), Type: Fee, Amount: "15", TranRef: "FEE1", CreatedAt: at, Legs: []JournalLeg{
+			DebitLeg(client, decimal.NewFromInt(15)),
+			CreditLeg(fee, decimal.NewFromInt(10)),
+			CreditLeg(gateway, decimal.NewFromInt(5)),
+		}},
+	}}
+	s := NewAccountingServiceWithRepository(repo)
+
+	var buf bytes.Buffer
+	require.NoError(t, s.ExportJournals(context.Background(), JournalFilter{}, &buf, ExportCSV))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 6)
+	assert.Equal(t, "Journal ID,Created At,Type,Tran Ref,Narrative,Account ID,Direction,Amount,Reversal Of,Reversed By,Metadata", lines[0])
+	assert.Equal(t, repo.entries[0].ID.Hex()+",2025-03-31T12:00:00Z,TopUp,MPESA1,M-Pesa top-up,"+gateway.Hex()+",DR,100,,,bank_ref=FT1; msisdn=254700000000", lines[1])
+	assert.Equal(t, repo.entries[1].ID.Hex()+",2025-03-31T12:00:00Z,Fee,FEE1,,"+gateway.Hex()+",CR,5,,,", lines[5])
+
+	buf.Reset()
+	require.NoError(t, s.ExportJournals(context.Background(), JournalFilter{}, &buf, ExportXLSX))
+	assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("PK")))
+
+	err := s.ExportJournals(context.Background(), JournalFilter{}, &buf, "pdf")
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+}
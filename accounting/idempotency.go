@@ -0,0 +1,82 @@
+package accounting
+
+import (
+	"context"
+	"fmt"
+)
+
+// --------------------------
+//  Idempotent Postings
+// --------------------------
+
+// EnableIdempotentPostings deduplicates postings by transaction reference and type, so a
+// retried payment webhook does not post twice: posting an entry whose tranref and type
+// were already posted returns the original entry (the public posting methods return nil)
+// instead of creating a duplicate. A retry with different accounts or amount fails with
+// ErrIdempotencyConflict. Reversals are never deduplicated.
+//
+// It creates a unique index on the idempotency key; entries posted before it was enabled
+// carry no key and are not deduplicated.
+func (s *AccountingService) EnableIdempotentPostings(ctx context.Context) error {
+	if err := s.repo.EnsureIdempotencyIndex(ctx); err != nil {
+		return fmt.Errorf("create idempotency index: %w", err)
+	}
+	s.idempotent = true
+	return nil
+}
+
+// EnsureIndexes creates the indexes the ledger queries need: accounts by creation date,
+// type and name, journal entries by tranref and type, by account and date, by narrative
+// and by metadata, and balance snapshots by account and instant. With idempotent postings it also creates the
+// unique index on the idempotency key, which makes tranref and type unique together.
+func (s *AccountingService) EnsureIndexes(ctx context.Context) error {
+	if err := s.repo.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+	if s.idempotent {
+		if err := s.repo.EnsureIdempotencyIndex(ctx); err != nil {
+			return fmt.Errorf("create idempotency index: %w", err)
+		}
+	}
+	return nil
+}
+
+// idempotencyKey returns the key deduplicating postings of txType under tranRef,
+// empty when idempotent postings are off or there is no reference
+func (s *AccountingService) idempotencyKey(txType TransactionType, tranRef string) string {
+	if !s.idempotent || tranRef == "" {
+		return ""
+	}
+	return string(txType) + ":" + tranRef
+}
+
+// findPosted returns the entry already posted under the idempotency key of entry, nil when
+// there is none. It fails with ErrIdempotencyConflict when that entry differs from entry.
+func (s *AccountingService) findPosted(ctx context.Context, entry *JournalEntry) (*JournalEntry, error) {
+	original, err := s.repo.FindJournalByIdempotencyKey(ctx, entry.IdempotencyKey)
+	if err != nil || original == nil {
+		return nil, err
+	}
+	if !samePosting(original, entry) {
+		return nil, fmt.Errorf("%w: %s %s", ErrIdempotencyConflict, entry.Type, entry.TranRef)
+	}
+	return original, nil
+}
+
+// samePosting reports whether two entries move the same amounts between the same accounts
+func samePosting(a, b *JournalEntry) bool {
+	if !a.GetAmount().Equal(b.GetAmount()) {
+		return false
+	}
+	legsA, legsB := a.postedLegs(), b.postedLegs()
+	if len(legsA) != len(legsB) {
+		return false
+	}
+	for i := range legsA {
+		if legsA[i].AccountID != legsB[i].AccountID || legsA[i].Direction != legsB[i].Direction ||
+			!legsA[i].GetAmount().Equal(legsB[i].GetAmount()) {
+			return false
+		}
+	}
+	return true
+}
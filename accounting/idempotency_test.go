@@ -0,0 +1,38 @@
+package accounting
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	s := &AccountingService{}
+	assert.Empty(t, s.idempotencyKey(TopUp, "MPESA1"), "off by default")
+
+	s.idempotent = true
+	assert.Equal(t, "TopUp:MPESA1", s.idempotencyKey(TopUp, "MPESA1"))
+	assert.Empty(t, s.idempotencyKey(TopUp, ""), "postings without a reference cannot be deduplicated")
+}
+
+func TestSamePosting(t *testing.T) {
+	client, gateway := primitive.NewObjectID(), primitive.NewObjectID()
+	original := &JournalEntry{Type: TopUp, Amount: "100.50", DebitAccount: gateway, CreditAccount: client}
+
+	retry := &JournalEntry{Type: TopUp, Amount: "100.5", DebitAccount: gateway, CreditAccount: client}
+	assert.True(t, samePosting(original, retry))
+
+	other := &JournalEntry{Type: TopUp, Amount: "200", DebitAccount: gateway, CreditAccount: client}
+	assert.False(t, samePosting(original, other))
+
+	swapped := &JournalEntry{Type: TopUp, Amount: "100.50", DebitAccount: client, CreditAccount: gateway}
+	assert.False(t, samePosting(original, swapped))
+
+	compound := &JournalEntry{Type: TopUp, Amount: "100.50", Legs: []JournalLeg{
+		DebitLeg(gateway, decimal.RequireFromString("100.50")),
+		CreditLeg(client, decimal.RequireFromString("100.50")),
+	}}
+	assert.True(t, samePosting(original, compound))
+}
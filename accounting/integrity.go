@@ -0,0 +1,196 @@
+package accounting
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// --------------------------
+//  Ledger Integrity
+// --------------------------
+
+// ChainLink is a position in the journal hash chain
+type ChainLink struct {
+	Seq  int64  `bson:"seq"`
+	Hash string `bson:"hash"`
+}
+
+// IntegrityReport is the result of VerifyLedgerIntegrity. When the chain is broken,
+// BrokenAt names the first entry that fails and Reason why.
+type IntegrityReport struct {
+	From     time.Time           `json:"from"`
+	To       time.Time           `json:"to"`
+	FirstSeq int64               `json:"first_seq"`
+	LastSeq  int64               `json:"last_seq"`
+	Checked  int                 `json:"checked"`
+	Intact   bool                `json:"intact"`
+	BrokenAt int64               `json:"broken_at,omitempty"` // Seq of the first broken link
+	EntryID  *primitive.ObjectID `json:"entry_id,omitempty"`  // entry at BrokenAt, nil when it is missing
+	Reason   string              `json:"reason,omitempty"`
+}
+
+// errChainBroken stops the chain walk at the first broken link
+var errChainBroken = errors.New("chain broken")
+
+// chainEntry appends entry to the hash chain, inside the posting transaction. The chain
+// head is a single record, so concurrent postings serialize on it.
+func (s *AccountingService) chainEntry(sc context.Context, entry *JournalEntry) error {
+	head, err := s.repo.ChainHead(sc)
+	if err != nil {
+		return err
+	}
+	entry.Seq = head.Seq + 1
+	entry.PrevHash = head.Hash
+	entry.Hash = entryHash(entry)
+	moved, err := s.repo.AdvanceChain(sc, head, ChainLink{Seq: entry.Seq, Hash: entry.Hash})
+	if err != nil {
+		return err
+	}
+	if !moved {
+		return fmt.Errorf("journal chain head moved past %d while posting %s", head.Seq, entry.ID.Hex())
+	}
+	return nil
+}
+
+// hashedEntry is the canonical form of the fields an entry hash covers. The reversal and
+// matching markers and transaction group set on an entry later are left out.
+type hashedEntry struct {
+	Seq            int64             `json:"seq"`
+	ID             string            `json:"id"`
+	Type           TransactionType   `json:"type"`
+	Amount         string            `json:"amount"`
+	TranRef        string            `json:"tranref"`
+	Narrative      string            `json:"narrative,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"` // encoding/json sorts the keys
+	DebitAccount   string            `json:"debit_account,omitempty"`
+	CreditAccount  string            `json:"credit_account,omitempty"`
+	CreatedAt      int64             `json:"created_at"` // Unix milliseconds, the precision MongoDB keeps
+	IdempotencyKey string            `json:"idempotency_key,omitempty"`
+	Legs           []hashedLeg       `json:"legs,omitempty"`
+	ReversalOf     string            `json:"reversal_of,omitempty"`
+	ReversalReason string            `json:"reversal_reason,omitempty"`
+	ClearingOf     string            `json:"clearing_of,omitempty"`
+}
+
+type hashedLeg struct {
+	AccountID string         `json:"account_id"`
+	Direction EntryDirection `json:"direction"`
+	Amount    string         `json:"amount"`
+}
+
+// entryHash returns the hex SHA-256 of the previous hash and the canonical entry
+func entryHash(e *JournalEntry) string {
+	he := hashedEntry{
+		Seq:            e.Seq,
+		ID:             e.ID.Hex(),
+		Type:           e.Type,
+		Amount:         e.GetAmount().String(),
+		TranRef:        e.TranRef,
+		Narrative:      e.Narrative,
+		Metadata:       e.Metadata,
+		CreatedAt:      e.CreatedAt.UnixMilli(),
+		IdempotencyKey: e.IdempotencyKey,
+	}
+	if !e.DebitAccount.IsZero() {
+		he.DebitAccount = e.DebitAccount.Hex()
+	}
+	if !e.CreditAccount.IsZero() {
+		he.CreditAccount = e.CreditAccount.Hex()
+	}
+	for _, leg := range e.Legs {
+		he.Legs = append(he.Legs, hashedLeg{AccountID: leg.AccountID.Hex(), Direction: leg.Direction, Amount: leg.GetAmount().String()})
+	}
+	if e.ReversalOf != nil {
+		he.ReversalOf = e.ReversalOf.Hex()
+		he.ReversalReason = e.ReversalReason
+	}
+	if e.ClearingOf != nil {
+		he.ClearingOf = e.ClearingOf.Hex()
+	}
+	data, _ := json.Marshal(he)
+
+	h := sha256.New()
+	h.Write([]byte(e.PrevHash))
+	h.Write([]byte{'\n'})
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// VerifyLedgerIntegrity walks the journal hash chain over the entries created between
+// from and to (both inclusive, zero for unbounded) and reports the first broken link: a
+// missing entry, an entry whose contents no longer match its hash, or one not linked to
+// the hash of its predecessor. The entry before the range anchors the first link; with
+// an unbounded to the walk ends at the chain head, so trailing deletions are caught too.
+// A broken chain is reported, not returned as an error.
+func (s *AccountingService) VerifyLedgerIntegrity(ctx context.Context, from, to time.Time) (*IntegrityReport, error) {
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return nil, fmt.Errorf("%w: range ends before it starts", ErrInvalidFilter)
+	}
+	first, last, err := s.repo.ChainSeqRange(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	var head ChainLink
+	if to.IsZero() {
+		if head, err = s.repo.ChainHead(ctx); err != nil {
+			return nil, err
+		}
+		last = head.Seq
+		if first == 0 {
+			first = head.Seq
+		}
+	}
+	report := &IntegrityReport{From: from, To: to, FirstSeq: first, LastSeq: last, Intact: true}
+	if first == 0 {
+		return report, nil
+	}
+	broken := func(seq int64, id *primitive.ObjectID, reason string, args ...any) error {
+		report.Intact = false
+		report.BrokenAt = seq
+		report.EntryID = id
+		report.Reason = fmt.Sprintf(reason, args...)
+		return errChainBroken
+	}
+
+	start := first
+	if start > 1 {
+		start-- // anchors the link of the first entry in range
+	}
+	var prev *JournalEntry
+	expect := start
+	err = s.repo.StreamChain(ctx, start, last, func(e *JournalEntry) error {
+		switch {
+		case e.Seq != expect:
+			return broken(expect, nil, "entry %d is missing", expect)
+		case e.Seq == 1 && e.PrevHash != "":
+			return broken(e.Seq, &e.ID, "first entry links to a previous hash")
+		case prev != nil && e.PrevHash != prev.Hash:
+			return broken(e.Seq, &e.ID, "previous hash does not match entry %d", prev.Seq)
+		case entryHash(e) != e.Hash:
+			return broken(e.Seq, &e.ID, "contents do not match the hash")
+		}
+		if e.Seq >= first {
+			report.Checked++
+		}
+		prev = e
+		expect++
+		return nil
+	})
+	if err != nil && !errors.Is(err, errChainBroken) {
+		return nil, err
+	}
+	if report.Intact && expect <= last {
+		broken(expect, nil, "entry %d is missing", expect)
+	}
+	if report.Intact && head.Seq > 0 && prev != nil && prev.Hash != head.Hash {
+		broken(head.Seq, &prev.ID, "chain head does not match entry %d", prev.Seq)
+	}
+	return report, nil
+}
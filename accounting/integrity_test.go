@@ -0,0 +1,146 @@
+package accounting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// chainRepo keeps a hash chain in memory for VerifyLedgerIntegrity
+type chainRepo struct {
+	AccountingRepository
+	head    ChainLink
+	entries []*JournalEntry
+}
+
+func (r *chainRepo) ChainHead(ctx context.Context) (ChainLink, error) { return r.head, nil }
+
+func (r *chainRepo) AdvanceChain(ctx context.Context, prev, next ChainLink) (bool, error) {
+	if r.head != prev {
+		return false, nil
+	}
+	r.head = next
+	return true, nil
+}
+
+func (r *chainRepo) ChainSeqRange(ctx context.Context, from, to time.Time) (int64, int64, error) {
+	var first, last int64
+	for _, e := range r.entries {
+		if (!from.IsZero() && e.CreatedAt.Before(from)) || (!to.IsZero() && e.CreatedAt.After(to)) {
+			continue
+		}
+		if first == 0 || e.Seq < first {
+			first = e.Seq
+		}
+		if e.Seq > last {
+			last = e.Seq
+		}
+	}
+	return first, last, nil
+}
+
+func (r *chainRepo) StreamChain(ctx context.Context, first, last int64, fn func(*JournalEntry) error) error {
+	for _, e := range r.entries {
+		if e.Seq >= first && e.Seq <= last {
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func newChainedLedger(t *testing.T, n int) (*AccountingService, *chainRepo) {
+	repo := &chainRepo{}
+	s := NewAccountingServiceWithRepository(repo)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		entry := &JournalEntry{
+			ID:            primitive.NewObjectID(),
+			Type:          TopUp,
+			Amount:        decimal.NewFromInt(int64(10 * (i + 1))).String(),
+			TranRef:       "REF",
+			DebitAccount:  primitive.NewObjectID(),
+			CreditAccount: primitive.NewObjectID(),
+			CreatedAt:     start.Add(time.Duration(i) * time.Hour),
+		}
+		require.NoError(t, s.chainEntry(context.Background(), entry))
+		repo.entries = append(repo.entries, entry)
+	}
+	return s, repo
+}
+
+func TestEntryHash_CoversPostedFields(t *testing.T) {
+	entry := &JournalEntry{ID: primitive.NewObjectID(), Type: Fee, Amount: "10.50", TranRef: "FEE1", CreatedAt: time.Now(), Seq: 3, PrevHash: "abc"}
+	hash := entryHash(entry)
+	assert.Len(t, hash, 64)
+
+	// storage round trips keep millisecond times and normalized amounts
+	stored := *entry
+	stored.Amount = "10.5"
+	stored.CreatedAt = entry.CreatedAt.Truncate(time.Millisecond)
+	assert.Equal(t, hash, entryHash(&stored))
+
+	// reversal markers are set after posting
+	reversedBy := primitive.NewObjectID()
+	stored.ReversedBy = &reversedBy
+	stored.TransactionID = primitive.NewObjectID()
+	assert.Equal(t, hash, entryHash(&stored))
+
+	stored.Amount = "100.5"
+	assert.NotEqual(t, hash, entryHash(&stored))
+	stored.Amount = entry.Amount
+	stored.Metadata = map[string]string{"bank_ref": "FT1"}
+	assert.NotEqual(t, hash, entryHash(&stored))
+	stored.Metadata = nil
+	stored.PrevHash = "abd"
+	assert.NotEqual(t, hash, entryHash(&stored))
+}
+
+func TestVerifyLedgerIntegrity(t *testing.T) {
+	ctx := context.Background()
+	s, repo := newChainedLedger(t, 5)
+	assert.EqualValues(t, 5, repo.head.Seq)
+	assert.Empty(t, repo.entries[0].PrevHash)
+	assert.Equal(t, repo.entries[0].Hash, repo.entries[1].PrevHash)
+
+	report, err := s.VerifyLedgerIntegrity(ctx, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.True(t, report.Intact)
+	assert.Equal(t, 5, report.Checked)
+
+	// a range is anchored on the entry before it
+	report, err = s.VerifyLedgerIntegrity(ctx, repo.entries[2].CreatedAt, repo.entries[3].CreatedAt)
+	require.NoError(t, err)
+	assert.True(t, report.Intact)
+	assert.EqualValues(t, 3, report.FirstSeq)
+	assert.Equal(t, 2, report.Checked)
+
+	repo.entries[2].Amount = "1000"
+	report, err = s.VerifyLedgerIntegrity(ctx, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.False(t, report.Intact)
+	assert.EqualValues(t, 3, report.BrokenAt)
+	assert.Equal(t, repo.entries[2].ID, *report.EntryID)
+	assert.Contains(t, report.Reason, "contents")
+
+	s, repo = newChainedLedger(t, 5)
+	repo.entries = append(repo.entries[:1], repo.entries[2:]...)
+	report, err = s.VerifyLedgerIntegrity(ctx, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.False(t, report.Intact)
+	assert.EqualValues(t, 2, report.BrokenAt)
+	assert.Nil(t, report.EntryID)
+
+	s, repo = newChainedLedger(t, 5)
+	repo.entries = repo.entries[:4]
+	report, err = s.VerifyLedgerIntegrity(ctx, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.False(t, report.Intact)
+	assert.EqualValues(t, 5, report.BrokenAt)
+}
@@ -0,0 +1,977 @@
+package accounting
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryRepository keeps the ledger in memory. It implements every query of the other
+// stores, so the service and its tests run without a database; nothing survives the
+// process. Transactions are serialized and roll back by restoring the state they
+// started from.
+type MemoryRepository struct {
+	mu         sync.Mutex // held by a transaction for its whole run
+	accounts   map[primitive.ObjectID]Account
+	journals   []JournalEntry // in insertion order
+	archive    []JournalEntry // moved by ArchiveJournals, in archival order
+	snapshots  []BalanceSnapshot
+	chain      ChainLink
+	audit      []AuditRecord
+	outbox     []OutboxMessage
+	schedules  []ScheduledPosting
+	uniqueKeys bool // idempotency keys are unique, see EnsureIdempotencyIndex
+
+	watchers map[*memWatcher]struct{}
+	changes  []BalanceChange // of the running transaction, delivered on commit
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{accounts: map[primitive.ObjectID]Account{}}
+}
+
+type memTxKey struct{}
+
+// memState is the data a transaction restores on rollback
+type memState struct {
+	accounts  map[primitive.ObjectID]Account
+	journals  []JournalEntry
+	archive   []JournalEntry
+	snapshots []BalanceSnapshot
+	chain     ChainLink
+	audit     []AuditRecord
+	outbox    []OutboxMessage
+	schedules []ScheduledPosting
+}
+
+// RunInTransaction runs fn holding the repository, so concurrent calls wait for it to
+// finish. Called within a transaction, fn joins it.
+func (r *MemoryRepository) RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if r.inTransaction(ctx) {
+		return fn(ctx)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	saved := memState{
+		accounts:  maps.Clone(r.accounts),
+		journals:  slices.Clone(r.journals),
+		archive:   slices.Clone(r.archive),
+		snapshots: slices.Clone(r.snapshots),
+		chain:     r.chain,
+		audit:     slices.Clone(r.audit),
+		outbox:    slices.Clone(r.outbox),
+		schedules: slices.Clone(r.schedules),
+	}
+	if err := fn(context.WithValue(ctx, memTxKey{}, r)); err != nil {
+		r.accounts, r.journals, r.snapshots, r.chain, r.audit, r.outbox = saved.accounts, saved.journals, saved.snapshots, saved.chain, saved.audit, saved.outbox
+		r.archive, r.schedules = saved.archive, saved.schedules
+		r.changes = nil
+		return err
+	}
+	r.deliverChanges(r.changes)
+	r.changes = nil
+	return nil
+}
+
+func (r *MemoryRepository) inTransaction(ctx context.Context) bool {
+	return ctx.Value(memTxKey{}) == r
+}
+
+// lock guards a single call made outside a transaction; within one the transaction
+// already holds the repository
+func (r *MemoryRepository) lock(ctx context.Context) func() {
+	if r.inTransaction(ctx) {
+		return func() {}
+	}
+	r.mu.Lock()
+	return r.mu.Unlock
+}
+
+// --------------------------
+//  Accounts
+// --------------------------
+
+func (r *MemoryRepository) InsertAccount(ctx context.Context, acc *Account) error {
+	defer r.lock(ctx)()
+	if _, ok := r.accounts[acc.ID]; ok {
+		return fmt.Errorf("account %s already exists", acc.ID.Hex())
+	}
+	if acc.Ref != "" && r.accountByRef(acc.Ref) != nil {
+		return fmt.Errorf("%w: %s", ErrDuplicateAccountRef, acc.Ref)
+	}
+	r.accounts[acc.ID] = *acc
+	return nil
+}
+
+func (r *MemoryRepository) GetAccount(ctx context.Context, accountID primitive.ObjectID) (*Account, error) {
+	defer r.lock(ctx)()
+	acc, ok := r.accounts[accountID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, accountID.Hex())
+	}
+	return &acc, nil
+}
+
+func (r *MemoryRepository) FindAccountByName(ctx context.Context, name string) (*Account, error) {
+	defer r.lock(ctx)()
+	for _, acc := range r.sortedAccounts(compareAccountsByCreation) {
+		if acc.Name == name {
+			return &acc, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, name)
+}
+
+func (r *MemoryRepository) FindAccountByRef(ctx context.Context, ref string) (*Account, error) {
+	defer r.lock(ctx)()
+	if acc := r.accountByRef(ref); acc != nil {
+		return acc, nil
+	}
+	return nil, fmt.Errorf("%w: ref %s", ErrAccountNotFound, ref)
+}
+
+// accountByRef returns a copy of the account with the external reference ref, nil when there is none
+func (r *MemoryRepository) accountByRef(ref string) *Account {
+	for _, acc := range r.accounts {
+		if acc.Ref == ref {
+			return &acc
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) ListAccounts(ctx context.Context, filter AccountFilter, page Pagination) ([]Account, int64, error) {
+	if err := filter.validate(); err != nil {
+		return nil, 0, err
+	}
+	var name *regexp.Regexp
+	if filter.NameRegex != "" {
+		name = regexp.MustCompile("(?i)" + filter.NameRegex)
+	}
+	defer r.lock(ctx)()
+	accounts := []Account{}
+	for _, acc := range r.sortedAccounts(compareAccountsByCreation) {
+		switch {
+		case filter.Type != "" && acc.Type != filter.Type,
+			name != nil && !name.MatchString(acc.Name),
+			!filter.CreatedAfter.IsZero() && acc.CreatedAt.Before(filter.CreatedAfter),
+			!filter.CreatedBefore.IsZero() && !acc.CreatedAt.Before(filter.CreatedBefore),
+			!filter.IncludeArchived && acc.GetStatus() == AccountArchived:
+			continue
+		}
+		accounts = append(accounts, acc)
+	}
+	return paginate(accounts, page), int64(len(accounts)), nil
+}
+
+func (r *MemoryRepository) AccountsAsOf(ctx context.Context, t time.Time) ([]Account, error) {
+	defer r.lock(ctx)()
+	accounts := []Account{}
+	for _, acc := range r.sortedAccounts(func(a, b Account) int {
+		if c := strings.Compare(string(a.Type), string(b.Type)); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return compareIDs(a.ID, b.ID)
+	}) {
+		if t.IsZero() || !acc.CreatedAt.After(t) {
+			accounts = append(accounts, acc)
+		}
+	}
+	return accounts, nil
+}
+
+func (r *MemoryRepository) sortedAccounts(cmp func(a, b Account) int) []Account {
+	return slices.SortedFunc(maps.Values(r.accounts), cmp)
+}
+
+func compareAccountsByCreation(a, b Account) int {
+	if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+		return c
+	}
+	return compareIDs(a.ID, b.ID)
+}
+
+func (r *MemoryRepository) SetOverdraftPolicy(ctx context.Context, accountID primitive.ObjectID, allowNegative bool, limit decimal.Decimal) error {
+	defer r.lock(ctx)()
+	acc, ok := r.accounts[accountID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrAccountNotFound, accountID.Hex())
+	}
+	acc.AllowNegative = &allowNegative
+	acc.OverdraftLimit = ""
+	if limit.IsPositive() {
+		acc.OverdraftLimit = limit.String()
+	}
+	r.accounts[accountID] = acc
+	return nil
+}
+
+func (r *MemoryRepository) IncrementBalance(ctx context.Context, accountID primitive.ObjectID, delta decimal.Decimal, floor *decimal.Decimal) (bool, error) {
+	defer r.lock(ctx)()
+	acc, ok := r.accounts[accountID]
+	if !ok || acc.GetStatus() != AccountActive {
+		return false, nil
+	}
+	if floor != nil && acc.GetBalance().LessThan(*floor) {
+		return false, nil
+	}
+	if err := acc.SetBalance(acc.GetBalance().Add(delta)); err != nil {
+		return false, err
+	}
+	r.accounts[accountID] = acc
+	return true, nil
+}
+
+func (r *MemoryRepository) SetAccountStatus(ctx context.Context, accountID primitive.ObjectID, status AccountStatus, reason string, at time.Time) (bool, error) {
+	defer r.lock(ctx)()
+	acc, ok := r.accounts[accountID]
+	if !ok {
+		return false, fmt.Errorf("%w: %s", ErrAccountNotFound, accountID.Hex())
+	}
+	if status == AccountClosed && !acc.GetBalance().IsZero() {
+		return false, nil
+	}
+	acc.Status, acc.StatusReason, acc.StatusChangedAt = status, reason, &at
+	r.accounts[accountID] = acc
+	return true, nil
+}
+
+// --------------------------
+//  Journals
+// --------------------------
+
+func (r *MemoryRepository) InsertJournal(ctx context.Context, entry *JournalEntry) error {
+	defer r.lock(ctx)()
+	if r.uniqueKeys && entry.IdempotencyKey != "" && r.journalByKey(entry.IdempotencyKey) != nil {
+		return fmt.Errorf("%w: %s", ErrDuplicateKey, entry.IdempotencyKey)
+	}
+	r.journals = append(r.journals, cloneJournal(*entry))
+	r.recordChanges(ctx, entry)
+	return nil
+}
+
+func (r *MemoryRepository) GetJournal(ctx context.Context, journalID primitive.ObjectID) (*JournalEntry, error) {
+	defer r.lock(ctx)()
+	i := r.journalIndex(journalID)
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrJournalNotFound, journalID.Hex())
+	}
+	entry := cloneJournal(r.journals[i])
+	return &entry, nil
+}
+
+func (r *MemoryRepository) FindJournalByIdempotencyKey(ctx context.Context, key string) (*JournalEntry, error) {
+	defer r.lock(ctx)()
+	return r.journalByKey(key), nil
+}
+
+func (r *MemoryRepository) journalIndex(journalID primitive.ObjectID) int {
+	return slices.IndexFunc(r.journals, func(e JournalEntry) bool { return e.ID == journalID })
+}
+
+func (r *MemoryRepository) journalByKey(key string) *JournalEntry {
+	for _, e := range r.journals {
+		if e.IdempotencyKey == key {
+			entry := cloneJournal(e)
+			return &entry
+		}
+	}
+	return nil
+}
+
+// EnsureIndexes has nothing to create in memory
+func (r *MemoryRepository) EnsureIndexes(ctx context.Context) error {
+	return nil
+}
+
+// EnsureIdempotencyIndex makes InsertJournal reject a taken idempotency key
+func (r *MemoryRepository) EnsureIdempotencyIndex(ctx context.Context) error {
+	defer r.lock(ctx)()
+	r.uniqueKeys = true
+	return nil
+}
+
+func (r *MemoryRepository) MarkReversed(ctx context.Context, journalID, group, reversedBy primitive.ObjectID, at time.Time, reason string) (bool, error) {
+	defer r.lock(ctx)()
+	i := r.journalIndex(journalID)
+	if i < 0 || r.journals[i].ReversedBy != nil || r.journals[i].MatchedBy != nil {
+		return false, nil
+	}
+	e := &r.journals[i]
+	e.TransactionID, e.ReversedBy, e.ReversedAt, e.ReversalReason = group, &reversedBy, &at, reason
+	return true, nil
+}
+
+func (r *MemoryRepository) MarkMatched(ctx context.Context, receiptID, group, matchedBy primitive.ObjectID, at time.Time) (bool, error) {
+	defer r.lock(ctx)()
+	i := r.journalIndex(receiptID)
+	if i < 0 || r.journals[i].MatchedBy != nil || r.journals[i].ReversedBy != nil {
+		return false, nil
+	}
+	e := &r.journals[i]
+	e.TransactionID, e.MatchedBy, e.MatchedAt = group, &matchedBy, &at
+	return true, nil
+}
+
+func (r *MemoryRepository) UnmarkMatched(ctx context.Context, receiptID, matchedBy primitive.ObjectID) (bool, error) {
+	defer r.lock(ctx)()
+	i := r.journalIndex(receiptID)
+	if i < 0 || r.journals[i].MatchedBy == nil || *r.journals[i].MatchedBy != matchedBy {
+		return false, nil
+	}
+	e := &r.journals[i]
+	e.MatchedBy, e.MatchedAt = nil, nil
+	return true, nil
+}
+
+func (r *MemoryRepository) ListJournals(ctx context.Context, limit, skip int64) ([]JournalEntry, error) {
+	defer r.lock(ctx)()
+	entries := r.sortedJournals(r.journals, nil, JournalSort{})
+	return paginate(entries, Pagination{Limit: limit, Skip: skip}), nil
+}
+
+func (r *MemoryRepository) JournalsByRef(ctx context.Context, tranRef string) ([]JournalEntry, error) {
+	defer r.lock(ctx)()
+	return r.sortedJournals(r.journals, func(e *JournalEntry) bool { return e.TranRef == tranRef }, JournalSort{Ascending: true}), nil
+}
+
+func (r *MemoryRepository) QueryJournals(ctx context.Context, filter JournalFilter, sort JournalSort, page Pagination) ([]JournalEntry, int64, error) {
+	if err := filter.validate(); err != nil {
+		return nil, 0, err
+	}
+	defer r.lock(ctx)()
+	entries := r.sortedJournals(r.filtered(filter), filter.matches, sort)
+	return paginate(entries, page), int64(len(entries)), nil
+}
+
+// StreamJournals calls fn with a copy of the matching entries taken when it starts, so
+// fn may post to the repository
+func (r *MemoryRepository) StreamJournals(ctx context.Context, filter JournalFilter, sort JournalSort, fn func(*JournalEntry) error) error {
+	if err := filter.validate(); err != nil {
+		return err
+	}
+	unlock := r.lock(ctx)
+	entries := r.sortedJournals(r.filtered(filter), filter.matches, sort)
+	unlock()
+	return streamEntries(entries, fn)
+}
+
+func (r *MemoryRepository) AccountJournals(ctx context.Context, q AccountJournalQuery) ([]JournalEntry, error) {
+	defer r.lock(ctx)()
+	entries := r.sortedJournals(slices.Concat(r.archive, r.journals), q.matches, JournalSort{Ascending: true})
+	if q.Limit > 0 && int64(len(entries)) > q.Limit {
+		entries = entries[:q.Limit]
+	}
+	return entries, nil
+}
+
+func (r *MemoryRepository) CountAccountJournals(ctx context.Context, q AccountJournalQuery) (int64, error) {
+	defer r.lock(ctx)()
+	var n int64
+	for _, e := range slices.Concat(r.archive, r.journals) {
+		if q.matches(&e) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *MemoryRepository) ArchiveJournals(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	defer r.lock(ctx)()
+	moved := r.sortedJournals(r.journals, func(e *JournalEntry) bool { return e.CreatedAt.Before(cutoff) }, JournalSort{Ascending: true})
+	if len(moved) > limit {
+		moved = moved[:limit]
+	}
+	ids := make(map[primitive.ObjectID]bool, len(moved))
+	for _, e := range moved {
+		ids[e.ID] = true
+	}
+	r.journals = slices.DeleteFunc(r.journals, func(e JournalEntry) bool { return ids[e.ID] })
+	r.archive = append(r.archive, moved...)
+	return int64(len(moved)), nil
+}
+
+// filtered returns the entries filter searches, the live or the archived ones
+func (r *MemoryRepository) filtered(filter JournalFilter) []JournalEntry {
+	if filter.Archived {
+		return r.archive
+	}
+	return r.journals
+}
+
+// sortedJournals returns copies of the entries of source match accepts, every entry for
+// a nil match, in the order of sort
+func (r *MemoryRepository) sortedJournals(source []JournalEntry, match func(*JournalEntry) bool, sort JournalSort) []JournalEntry {
+	entries := []JournalEntry{}
+	for _, e := range source {
+		if match == nil || match(&e) {
+			entries = append(entries, cloneJournal(e))
+		}
+	}
+	slices.SortStableFunc(entries, func(a, b JournalEntry) int {
+		c := a.CreatedAt.Compare(b.CreatedAt)
+		if sort.Field == SortByAmount {
+			c = a.GetAmount().Cmp(b.GetAmount())
+		}
+		if c == 0 {
+			c = compareIDs(a.ID, b.ID)
+		}
+		if !sort.Ascending {
+			c = -c
+		}
+		return c
+	})
+	return entries
+}
+
+// matches reports whether e is selected by the filter, as its MongoDB query does
+func (f JournalFilter) matches(e *JournalEntry) bool {
+	switch {
+	case !f.AccountID.IsZero() && !e.hasLegOn(f.AccountID),
+		f.Type != "" && e.Type != f.Type,
+		f.TranRef != "" && e.TranRef != f.TranRef,
+		f.Narrative != "" && !strings.Contains(strings.ToLower(e.Narrative), strings.ToLower(f.Narrative)),
+		!f.MinAmount.IsZero() && e.GetAmount().LessThan(f.MinAmount),
+		!f.MaxAmount.IsZero() && e.GetAmount().GreaterThan(f.MaxAmount),
+		!f.From.IsZero() && e.CreatedAt.Before(f.From),
+		!f.To.IsZero() && e.CreatedAt.After(f.To):
+		return false
+	}
+	for k, v := range f.Metadata {
+		if got, ok := e.Metadata[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// matches reports whether e is selected by the query, as accountJournalsFilter does
+func (q AccountJournalQuery) matches(e *JournalEntry) bool {
+	switch {
+	case !e.hasLegOn(q.AccountID),
+		!q.After.IsZero() && !e.CreatedAt.After(q.After),
+		!q.From.IsZero() && e.CreatedAt.Before(q.From),
+		!q.To.IsZero() && e.CreatedAt.After(q.To),
+		!q.Before.IsZero() && !e.CreatedAt.Before(q.Before):
+		return false
+	}
+	return true
+}
+
+// hasLegOn reports whether the entry debits or credits accountID
+func (j JournalEntry) hasLegOn(accountID primitive.ObjectID) bool {
+	return slices.ContainsFunc(j.postedLegs(), func(l JournalLeg) bool { return l.AccountID == accountID })
+}
+
+// --------------------------
+//  Dashboards
+// --------------------------
+
+func (r *MemoryRepository) BalancesByType(ctx context.Context) ([]TypeBalance, error) {
+	defer r.lock(ctx)()
+	byType := map[AccountType]*TypeBalance{}
+	for _, acc := range r.accounts {
+		tb, ok := byType[acc.Type]
+		if !ok {
+			tb = &TypeBalance{AccountType: acc.Type}
+			byType[acc.Type] = tb
+		}
+		tb.AccountCount++
+		tb.Balance = tb.Balance.Add(acc.GetBalance())
+	}
+	out := []TypeBalance{}
+	for _, t := range slices.Sorted(maps.Keys(byType)) {
+		out = append(out, *byType[t])
+	}
+	return out, nil
+}
+
+func (r *MemoryRepository) DailyPostingTotals(ctx context.Context, from, to time.Time) ([]DailyPostingTotal, error) {
+	defer r.lock(ctx)()
+	type key struct {
+		day time.Time
+		typ TransactionType
+	}
+	totals := map[key]*DailyPostingTotal{}
+	for _, e := range r.journals {
+		if e.CreatedAt.Before(from) || e.CreatedAt.After(to) {
+			continue
+		}
+		k := key{e.CreatedAt.UTC().Truncate(24 * time.Hour), e.Type}
+		t, ok := totals[k]
+		if !ok {
+			t = &DailyPostingTotal{Day: k.day, Type: k.typ}
+			totals[k] = t
+		}
+		t.Count++
+		t.Amount = t.Amount.Add(e.GetAmount())
+	}
+	out := []DailyPostingTotal{}
+	for _, t := range totals {
+		out = append(out, *t)
+	}
+	slices.SortFunc(out, func(a, b DailyPostingTotal) int {
+		if c := a.Day.Compare(b.Day); c != 0 {
+			return c
+		}
+		return strings.Compare(string(a.Type), string(b.Type))
+	})
+	return out, nil
+}
+
+func (r *MemoryRepository) TopAccountsByVolume(ctx context.Context, from, to time.Time, limit int) ([]AccountVolume, error) {
+	defer r.lock(ctx)()
+	volumes := map[primitive.ObjectID]*AccountVolume{}
+	for _, e := range r.journals {
+		if e.CreatedAt.Before(from) || e.CreatedAt.After(to) {
+			continue
+		}
+		for _, leg := range e.postedLegs() {
+			v, ok := volumes[leg.AccountID]
+			if !ok {
+				acc := r.accounts[leg.AccountID]
+				v = &AccountVolume{AccountID: leg.AccountID, AccountType: acc.Type, AccountName: acc.Name}
+				volumes[leg.AccountID] = v
+			}
+			v.Legs++
+			if leg.Direction == DirectionDebit {
+				v.Debits = v.Debits.Add(leg.GetAmount())
+			} else {
+				v.Credits = v.Credits.Add(leg.GetAmount())
+			}
+			v.Volume = v.Debits.Add(v.Credits)
+		}
+	}
+	out := []AccountVolume{}
+	for _, v := range volumes {
+		out = append(out, *v)
+	}
+	slices.SortFunc(out, func(a, b AccountVolume) int {
+		if c := b.Volume.Cmp(a.Volume); c != 0 {
+			return c
+		}
+		return compareIDs(a.AccountID, b.AccountID)
+	})
+	return out[:min(limit, len(out))], nil
+}
+
+// --------------------------
+//  Hash Chain
+// --------------------------
+
+func (r *MemoryRepository) ChainHead(ctx context.Context) (ChainLink, error) {
+	defer r.lock(ctx)()
+	return r.chain, nil
+}
+
+func (r *MemoryRepository) AdvanceChain(ctx context.Context, prev, next ChainLink) (bool, error) {
+	defer r.lock(ctx)()
+	if r.chain != prev {
+		return false, nil
+	}
+	r.chain = next
+	return true, nil
+}
+
+func (r *MemoryRepository) ChainSeqRange(ctx context.Context, from, to time.Time) (int64, int64, error) {
+	defer r.lock(ctx)()
+	var first, last int64
+	for _, e := range slices.Concat(r.archive, r.journals) {
+		if e.Seq == 0 || (!from.IsZero() && e.CreatedAt.Before(from)) || (!to.IsZero() && e.CreatedAt.After(to)) {
+			continue
+		}
+		if first == 0 || e.Seq < first {
+			first = e.Seq
+		}
+		last = max(last, e.Seq)
+	}
+	return first, last, nil
+}
+
+func (r *MemoryRepository) StreamChain(ctx context.Context, first, last int64, fn func(*JournalEntry) error) error {
+	unlock := r.lock(ctx)
+	var entries []JournalEntry
+	for _, e := range slices.Concat(r.archive, r.journals) {
+		if e.Seq >= first && e.Seq <= last && e.Seq != 0 {
+			entries = append(entries, cloneJournal(e))
+		}
+	}
+	unlock()
+	slices.SortFunc(entries, func(a, b JournalEntry) int { return int(a.Seq - b.Seq) })
+	return streamEntries(entries, fn)
+}
+
+// --------------------------
+//  Snapshots
+// --------------------------
+
+func (r *MemoryRepository) LatestSnapshot(ctx context.Context, accountID primitive.ObjectID, t time.Time, inclusive bool) (*BalanceSnapshot, error) {
+	defer r.lock(ctx)()
+	var latest *BalanceSnapshot
+	for i, snap := range r.snapshots {
+		if snap.AccountID != accountID || snap.AsOf.After(t) || (!inclusive && snap.AsOf.Equal(t)) {
+			continue
+		}
+		if latest == nil || snap.AsOf.After(latest.AsOf) {
+			latest = &r.snapshots[i]
+		}
+	}
+	if latest == nil {
+		return nil, nil
+	}
+	snap := *latest
+	return &snap, nil
+}
+
+func (r *MemoryRepository) UpsertSnapshot(ctx context.Context, snap *BalanceSnapshot) (*BalanceSnapshot, error) {
+	defer r.lock(ctx)()
+	for i, stored := range r.snapshots {
+		if stored.AccountID == snap.AccountID && stored.AsOf.Equal(snap.AsOf) {
+			r.snapshots[i].Balance, r.snapshots[i].CreatedAt = snap.Balance, snap.CreatedAt
+			updated := r.snapshots[i]
+			return &updated, nil
+		}
+	}
+	r.snapshots = append(r.snapshots, *snap)
+	stored := *snap
+	return &stored, nil
+}
+
+func (r *MemoryRepository) ListSnapshots(ctx context.Context, accountID primitive.ObjectID, from, to time.Time) ([]BalanceSnapshot, error) {
+	defer r.lock(ctx)()
+	snaps := []BalanceSnapshot{}
+	for _, snap := range r.snapshots {
+		if snap.AccountID != accountID || (!from.IsZero() && snap.AsOf.Before(from)) || (!to.IsZero() && snap.AsOf.After(to)) {
+			continue
+		}
+		snaps = append(snaps, snap)
+	}
+	slices.SortFunc(snaps, func(a, b BalanceSnapshot) int { return a.AsOf.Compare(b.AsOf) })
+	return snaps, nil
+}
+
+// --------------------------
+//  Audit Log
+// --------------------------
+
+func (r *MemoryRepository) InsertAudit(ctx context.Context, rec *AuditRecord) error {
+	defer r.lock(ctx)()
+	r.audit = append(r.audit, *rec)
+	return nil
+}
+
+func (r *MemoryRepository) ListAudit(ctx context.Context, filter AuditFilter, page Pagination) ([]AuditRecord, int64, error) {
+	if err := filter.validate(); err != nil {
+		return nil, 0, err
+	}
+	defer r.lock(ctx)()
+	records := []AuditRecord{}
+	for _, rec := range r.audit {
+		switch {
+		case filter.EntityID != "" && rec.EntityID != filter.EntityID,
+			filter.ActorID != "" && rec.Actor.ID != filter.ActorID,
+			filter.Action != "" && rec.Action != filter.Action,
+			!filter.From.IsZero() && rec.At.Before(filter.From),
+			!filter.To.IsZero() && rec.At.After(filter.To):
+			continue
+		}
+		records = append(records, rec)
+	}
+	slices.SortFunc(records, func(a, b AuditRecord) int {
+		if c := b.At.Compare(a.At); c != 0 {
+			return c
+		}
+		return compareIDs(b.ID, a.ID)
+	})
+	return paginate(records, page), int64(len(records)), nil
+}
+
+// --------------------------
+//  Outbox
+// --------------------------
+
+func (r *MemoryRepository) InsertOutbox(ctx context.Context, msg *OutboxMessage) error {
+	defer r.lock(ctx)()
+	r.outbox = append(r.outbox, *msg)
+	return nil
+}
+
+func (r *MemoryRepository) ClaimOutbox(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]OutboxMessage, error) {
+	defer r.lock(ctx)()
+	var due []*OutboxMessage
+	for i := range r.outbox {
+		if msg := &r.outbox[i]; msg.DeliveredAt == nil && !msg.NextAttemptAt.After(now) {
+			due = append(due, msg)
+		}
+	}
+	slices.SortFunc(due, func(a, b *OutboxMessage) int {
+		if c := a.NextAttemptAt.Compare(b.NextAttemptAt); c != 0 {
+			return c
+		}
+		return compareIDs(a.ID, b.ID)
+	})
+	msgs := []OutboxMessage{}
+	for _, msg := range due[:min(limit, len(due))] {
+		msg.NextAttemptAt = now.Add(lease)
+		claimed := *msg
+		claimed.Delivered = slices.Clone(msg.Delivered)
+		msgs = append(msgs, claimed)
+	}
+	return msgs, nil
+}
+
+func (r *MemoryRepository) UpdateOutbox(ctx context.Context, msg *OutboxMessage) error {
+	defer r.lock(ctx)()
+	i := slices.IndexFunc(r.outbox, func(m OutboxMessage) bool { return m.ID == msg.ID })
+	if i < 0 {
+		return fmt.Errorf("outbox message %s not found", msg.ID.Hex())
+	}
+	stored := &r.outbox[i]
+	stored.Attempts, stored.NextAttemptAt, stored.LastError = msg.Attempts, msg.NextAttemptAt, msg.LastError
+	stored.Delivered = slices.Clone(msg.Delivered)
+	if msg.DeliveredAt != nil {
+		stored.DeliveredAt = msg.DeliveredAt
+	}
+	return nil
+}
+
+// --------------------------
+//  Scheduled Postings
+// --------------------------
+
+func (r *MemoryRepository) InsertSchedule(ctx context.Context, sp *ScheduledPosting) error {
+	defer r.lock(ctx)()
+	r.schedules = append(r.schedules, cloneSchedule(*sp))
+	return nil
+}
+
+func (r *MemoryRepository) GetSchedule(ctx context.Context, id primitive.ObjectID) (*ScheduledPosting, error) {
+	defer r.lock(ctx)()
+	i := slices.IndexFunc(r.schedules, func(sp ScheduledPosting) bool { return sp.ID == id })
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrScheduleNotFound, id.Hex())
+	}
+	sp := cloneSchedule(r.schedules[i])
+	return &sp, nil
+}
+
+func (r *MemoryRepository) ListSchedules(ctx context.Context, filter ScheduleFilter, page Pagination) ([]ScheduledPosting, int64, error) {
+	if err := filter.validate(); err != nil {
+		return nil, 0, err
+	}
+	defer r.lock(ctx)()
+	schedules := []ScheduledPosting{}
+	for _, sp := range r.schedules {
+		switch {
+		case filter.Status != "" && sp.Status != filter.Status,
+			!filter.AccountID.IsZero() && sp.DebitAccount != filter.AccountID && sp.CreditAccount != filter.AccountID,
+			!filter.From.IsZero() && sp.ExecuteAt.Before(filter.From),
+			!filter.To.IsZero() && sp.ExecuteAt.After(filter.To):
+			continue
+		}
+		schedules = append(schedules, cloneSchedule(sp))
+	}
+	slices.SortFunc(schedules, func(a, b ScheduledPosting) int {
+		if c := a.ExecuteAt.Compare(b.ExecuteAt); c != 0 {
+			return c
+		}
+		return compareIDs(a.ID, b.ID)
+	})
+	return paginate(schedules, page), int64(len(schedules)), nil
+}
+
+func (r *MemoryRepository) ClaimSchedules(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]ScheduledPosting, error) {
+	defer r.lock(ctx)()
+	var due []*ScheduledPosting
+	for i := range r.schedules {
+		if sp := &r.schedules[i]; sp.Status == SchedulePending && !sp.NextAttemptAt.After(now) {
+			due = append(due, sp)
+		}
+	}
+	slices.SortFunc(due, func(a, b *ScheduledPosting) int {
+		if c := a.NextAttemptAt.Compare(b.NextAttemptAt); c != 0 {
+			return c
+		}
+		return compareIDs(a.ID, b.ID)
+	})
+	schedules := []ScheduledPosting{}
+	for _, sp := range due[:min(limit, len(due))] {
+		sp.NextAttemptAt = now.Add(lease)
+		schedules = append(schedules, cloneSchedule(*sp))
+	}
+	return schedules, nil
+}
+
+func (r *MemoryRepository) UpdateSchedule(ctx context.Context, sp *ScheduledPosting) (bool, error) {
+	defer r.lock(ctx)()
+	i := slices.IndexFunc(r.schedules, func(s ScheduledPosting) bool { return s.ID == sp.ID })
+	if i < 0 || r.schedules[i].Status != SchedulePending {
+		return false, nil
+	}
+	stored := &r.schedules[i]
+	stored.Status, stored.Attempts, stored.NextAttemptAt, stored.LastError = sp.Status, sp.Attempts, sp.NextAttemptAt, sp.LastError
+	if sp.JournalID != nil {
+		stored.JournalID = sp.JournalID
+	}
+	if sp.SettledAt != nil {
+		stored.SettledAt = sp.SettledAt
+	}
+	return true, nil
+}
+
+// --------------------------
+//  Helpers
+// --------------------------
+
+// paginate returns the page of items, every item from Skip on for a zero Limit
+func paginate[T any](items []T, page Pagination) []T {
+	if page.Skip >= int64(len(items)) {
+		return []T{}
+	}
+	items = items[max(page.Skip, 0):]
+	if page.Limit > 0 && int64(len(items)) > page.Limit {
+		items = items[:page.Limit]
+	}
+	return items
+}
+
+func cloneSchedule(sp ScheduledPosting) ScheduledPosting {
+	sp.Metadata = maps.Clone(sp.Metadata)
+	return sp
+}
+
+func streamEntries(entries []JournalEntry, fn func(*JournalEntry) error) error {
+	for i := range entries {
+		if err := fn(&entries[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cloneJournal copies the legs and metadata of e, which a caller could otherwise change
+// in the repository
+func cloneJournal(e JournalEntry) JournalEntry {
+	e.Legs = slices.Clone(e.Legs)
+	e.Metadata = maps.Clone(e.Metadata)
+	return e
+}
+
+// compareIDs orders ObjectIDs like MongoDB, by their bytes
+func compareIDs(a, b primitive.ObjectID) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+// --------------------------
+//  Balance Watch
+// --------------------------
+
+// memWatcher queues the balance changes of an account for a watch, so a commit never
+// waits for its reader
+type memWatcher struct {
+	accountID primitive.ObjectID
+	mu        sync.Mutex
+	queue     []BalanceChange
+	notify    chan struct{}
+}
+
+func (w *memWatcher) push(change BalanceChange) {
+	w.mu.Lock()
+	w.queue = append(w.queue, change)
+	w.mu.Unlock()
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (w *memWatcher) run(ctx context.Context, send func(BalanceChange) bool) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-w.notify:
+		}
+		w.mu.Lock()
+		queue := w.queue
+		w.queue = nil
+		w.mu.Unlock()
+		for _, change := range queue {
+			if !send(change) {
+				return nil
+			}
+		}
+	}
+}
+
+// WatchBalances delivers the balance changes of accountID made by the journal entries
+// committed from now on
+func (r *MemoryRepository) WatchBalances(ctx context.Context, accountID primitive.ObjectID) (*BalanceWatch, error) {
+	w := &memWatcher{accountID: accountID, notify: make(chan struct{}, 1)}
+	unlock := r.lock(ctx)
+	if r.watchers == nil {
+		r.watchers = map[*memWatcher]struct{}{}
+	}
+	r.watchers[w] = struct{}{}
+	unlock()
+
+	return newBalanceWatch(ctx, func(ctx context.Context, send func(BalanceChange) bool) error {
+		defer func() {
+			defer r.lock(ctx)()
+			delete(r.watchers, w)
+		}()
+		return w.run(ctx, send)
+	}), nil
+}
+
+// recordChanges records the balance changes of entry, inserted after its balances were
+// updated, for the watched accounts. Within a transaction they are delivered on commit.
+func (r *MemoryRepository) recordChanges(ctx context.Context, entry *JournalEntry) {
+	var changes []BalanceChange
+	seen := map[primitive.ObjectID]bool{}
+	for _, leg := range entry.postedLegs() {
+		if seen[leg.AccountID] || !r.watched(leg.AccountID) {
+			continue
+		}
+		seen[leg.AccountID] = true
+		acc := r.accounts[leg.AccountID]
+		changes = append(changes, balanceChange(leg.AccountID, entry, acc.GetBalance()))
+	}
+	if r.inTransaction(ctx) {
+		r.changes = append(r.changes, changes...)
+		return
+	}
+	r.deliverChanges(changes)
+}
+
+func (r *MemoryRepository) watched(accountID primitive.ObjectID) bool {
+	for w := range r.watchers {
+		if w.accountID == accountID {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryRepository) deliverChanges(changes []BalanceChange) {
+	for _, change := range changes {
+		for w := range r.watchers {
+			if w.accountID == change.AccountID {
+				w.push(change)
+			}
+		}
+	}
+}
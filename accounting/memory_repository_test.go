@@ -0,0 +1,50 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMemoryRepository_RollsBackFailedTransaction(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	acc := &Account{ID: primitive.NewObjectID(), Type: ClientInsurance, CreatedAt: time.Now()}
+	require.NoError(t, repo.InsertAccount(ctx, acc))
+
+	err := repo.RunInTransaction(ctx, func(sc context.Context) error {
+		ok, err := repo.IncrementBalance(sc, acc.ID, decimal.NewFromInt(40), nil)
+		require.True(t, ok)
+		require.NoError(t, err)
+		require.NoError(t, repo.InsertJournal(sc, &JournalEntry{ID: primitive.NewObjectID(), CreditAccount: acc.ID, Amount: "40"}))
+		return assert.AnError
+	})
+	assert.True(t, errors.Is(err, assert.AnError))
+
+	stored, err := repo.GetAccount(ctx, acc.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.GetBalance().IsZero())
+	entries, err := repo.ListJournals(ctx, 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestMemoryRepository_UniqueIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	entry := func() *JournalEntry {
+		return &JournalEntry{ID: primitive.NewObjectID(), IdempotencyKey: "topup|T1", Amount: "1"}
+	}
+	require.NoError(t, repo.InsertJournal(ctx, entry()))
+	require.NoError(t, repo.InsertJournal(ctx, entry()), "keys are only unique once the index exists")
+
+	require.NoError(t, repo.EnsureIdempotencyIndex(ctx))
+	err := repo.InsertJournal(ctx, entry())
+	assert.True(t, errors.Is(err, ErrDuplicateKey))
+}
@@ -0,0 +1,74 @@
+package accounting
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// --------------------------
+//  Narratives & Metadata
+// --------------------------
+
+// PostingOption sets descriptive fields of an entry before it is posted. Every posting
+// method accepts them after its usual arguments.
+type PostingOption func(*JournalEntry)
+
+// WithNarrative sets the free-text description of the entry, e.g. the bank statement
+// narrative a payment is reconciled against
+func WithNarrative(narrative string) PostingOption {
+	return func(e *JournalEntry) {
+		e.Narrative = strings.TrimSpace(narrative)
+	}
+}
+
+// WithMetadata adds key/value pairs to the entry metadata. Keys must be non-empty and
+// must not contain '.' or start with '$'.
+func WithMetadata(metadata map[string]string) PostingOption {
+	return func(e *JournalEntry) {
+		if len(metadata) == 0 {
+			return
+		}
+		if e.Metadata == nil {
+			e.Metadata = make(map[string]string, len(metadata))
+		}
+		for k, v := range metadata {
+			e.Metadata[k] = v
+		}
+	}
+}
+
+// applyPostingOptions sets the options on entry and checks the resulting metadata
+func applyPostingOptions(entry *JournalEntry, opts []PostingOption) error {
+	for _, opt := range opts {
+		opt(entry)
+	}
+	if err := validateMetadata(entry.Metadata); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMetadata, err)
+	}
+	return nil
+}
+
+// validateMetadata rejects the keys MongoDB cannot store or query as field names
+func validateMetadata(metadata map[string]string) error {
+	for k := range metadata {
+		if k == "" || strings.Contains(k, ".") || strings.HasPrefix(k, "$") {
+			return fmt.Errorf("metadata key %q", k)
+		}
+	}
+	return nil
+}
+
+// formatMetadata renders metadata as "key=value" pairs sorted by key, for exports
+func formatMetadata(metadata map[string]string) string {
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + metadata[k]
+	}
+	return strings.Join(pairs, "; ")
+}
@@ -0,0 +1,1188 @@
+package accounting
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const idempotencyIndex = "idempotency_key_unique"
+
+// chainHeadID is the _id of the journal hash chain head in the ledger_chain collection
+const chainHeadID = "journals"
+
+// MongoRepository stores the ledger in the accounts, journals, journals_archive,
+// balance_snapshots, ledger_chain, audit_log, outbox and scheduled_postings collections
+// of a MongoDB database. Transactions need a replica set.
+type MongoRepository struct {
+	db        *mongo.Database
+	accounts  *mongo.Collection
+	journals  *mongo.Collection
+	archive   *mongo.Collection
+	snapshots *mongo.Collection
+	chain     *mongo.Collection
+	audit     *mongo.Collection
+	outbox    *mongo.Collection
+	schedules *mongo.Collection
+	retry     RetryPolicy
+}
+
+func NewMongoRepository(db *mongo.Database) *MongoRepository {
+	return &MongoRepository{
+		db:        db,
+		accounts:  db.Collection("accounts"),
+		journals:  db.Collection("journals"),
+		archive:   db.Collection("journals_archive"),
+		snapshots: db.Collection("balance_snapshots"),
+		chain:     db.Collection("ledger_chain"),
+		audit:     db.Collection("audit_log"),
+		outbox:    db.Collection("outbox"),
+		schedules: db.Collection("scheduled_postings"),
+	}
+}
+
+// SetRetryPolicy sets how often a transaction failing with a transient error, such as a
+// write conflict or a replica set election, is run again; see DefaultRetryPolicy
+func (r *MongoRepository) SetRetryPolicy(p RetryPolicy) {
+	r.retry = p
+}
+
+// RunInTransaction runs fn in a transaction, running it again with backoff while it fails
+// with a TransientTransactionError label or a write conflict. A commit with an unknown
+// result is retried alone. Once the retry policy is exhausted the last error is returned
+// wrapped in a *RetryExhaustedError.
+func (r *MongoRepository) RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := r.db.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	return r.retry.retry(ctx, isTransientTxError, func() error {
+		return r.runTransaction(ctx, session, fn)
+	})
+}
+
+// runTransaction makes a single attempt at the transaction of fn
+func (r *MongoRepository) runTransaction(ctx context.Context, session mongo.Session, fn func(ctx context.Context) error) error {
+	if err := session.StartTransaction(); err != nil {
+		return err
+	}
+	sc := mongo.NewSessionContext(ctx, session)
+	if err := fn(sc); err != nil {
+		// abort even when ctx is cancelled, so the transaction does not hold its locks
+		_ = session.AbortTransaction(context.WithoutCancel(ctx))
+		return err
+	}
+	return r.retry.retry(ctx, isUnknownCommitResult, func() error {
+		return session.CommitTransaction(sc)
+	})
+}
+
+// --------------------------
+//  Accounts
+// --------------------------
+
+func (r *MongoRepository) InsertAccount(ctx context.Context, acc *Account) error {
+	_, err := r.accounts.InsertOne(ctx, acc)
+	if err != nil && acc.Ref != "" && mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("%w: %s", ErrDuplicateAccountRef, acc.Ref)
+	}
+	return err
+}
+
+func (r *MongoRepository) GetAccount(ctx context.Context, accountID primitive.ObjectID) (*Account, error) {
+	var acc Account
+	err := r.accounts.FindOne(ctx, bson.M{"_id": accountID}).Decode(&acc)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, accountID.Hex())
+		}
+		return nil, err
+	}
+	return &acc, nil
+}
+
+func (r *MongoRepository) FindAccountByName(ctx context.Context, name string) (*Account, error) {
+	var acc Account
+	err := r.accounts.FindOne(ctx, bson.M{"name": name},
+		options.FindOne().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}),
+	).Decode(&acc)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, name)
+		}
+		return nil, err
+	}
+	return &acc, nil
+}
+
+func (r *MongoRepository) FindAccountByRef(ctx context.Context, ref string) (*Account, error) {
+	var acc Account
+	if err := r.accounts.FindOne(ctx, bson.M{"ref": ref}).Decode(&acc); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("%w: ref %s", ErrAccountNotFound, ref)
+		}
+		return nil, err
+	}
+	return &acc, nil
+}
+
+func (r *MongoRepository) ListAccounts(ctx context.Context, filter AccountFilter, page Pagination) ([]Account, int64, error) {
+	q, err := filter.query()
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := r.accounts.CountDocuments(ctx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(page.Limit).
+		SetSkip(page.Skip)
+	accounts, err := r.findAccounts(ctx, q, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	return accounts, total, nil
+}
+
+func (r *MongoRepository) AccountsAsOf(ctx context.Context, t time.Time) ([]Account, error) {
+	filter := bson.M{}
+	if !t.IsZero() {
+		filter["created_at"] = bson.M{"$lte": t}
+	}
+	return r.findAccounts(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "type", Value: 1}, {Key: "name", Value: 1}, {Key: "_id", Value: 1}}))
+}
+
+func (r *MongoRepository) findAccounts(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]Account, error) {
+	cursor, err := r.accounts.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	accounts := []Account{}
+	if err = cursor.All(ctx, &accounts); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+func (r *MongoRepository) SetOverdraftPolicy(ctx context.Context, accountID primitive.ObjectID, allowNegative bool, limit decimal.Decimal) error {
+	set := bson.M{"allow_negative": allowNegative}
+	update := bson.M{"$set": set}
+	if limit.IsPositive() {
+		set["overdraft_limit"] = limit.String()
+	} else {
+		update["$unset"] = bson.M{"overdraft_limit": ""}
+	}
+	res, err := r.accounts.UpdateOne(ctx, bson.M{"_id": accountID}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", ErrAccountNotFound, accountID.Hex())
+	}
+	return nil
+}
+
+// IncrementBalance updates the Decimal128 balance with $inc, the status and floor are
+// part of the update filter
+func (r *MongoRepository) IncrementBalance(ctx context.Context, accountID primitive.ObjectID, delta decimal.Decimal, floor *decimal.Decimal) (bool, error) {
+	inc, err := toDecimal128(delta)
+	if err != nil {
+		return false, err
+	}
+	filter := bson.M{"_id": accountID, "status": bson.M{"$nin": []AccountStatus{AccountFrozen, AccountClosed, AccountArchived}}}
+	if floor != nil {
+		min, err := toDecimal128(*floor)
+		if err != nil {
+			return false, err
+		}
+		filter["balance"] = bson.M{"$gte": min}
+	}
+	res, err := r.accounts.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"balance": inc}})
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+func (r *MongoRepository) SetAccountStatus(ctx context.Context, accountID primitive.ObjectID, status AccountStatus, reason string, at time.Time) (bool, error) {
+	filter := bson.M{"_id": accountID}
+	if status == AccountClosed {
+		zero, _ := toDecimal128(decimal.Zero)
+		filter["balance"] = zero
+	}
+	res, err := r.accounts.UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		"status":            status,
+		"status_reason":     reason,
+		"status_changed_at": at,
+	}})
+	if err != nil {
+		return false, err
+	}
+	if res.MatchedCount > 0 {
+		return true, nil
+	}
+	if _, err := r.GetAccount(ctx, accountID); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+// MigrateBalancesToDecimal128 converts account balances stored as decimal strings by
+// earlier versions to Decimal128, which $inc requires. It is idempotent and runs on the
+// server (MongoDB 4.2+), returning the number of accounts converted. Run it once before
+// posting with this version.
+func (r *MongoRepository) MigrateBalancesToDecimal128(ctx context.Context) (int64, error) {
+	res, err := r.accounts.UpdateMany(ctx,
+		bson.M{"balance": bson.M{"$type": "string"}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"balance": bson.M{"$toDecimal": "$balance"}}}}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// --------------------------
+//  Journals
+// --------------------------
+
+func (r *MongoRepository) InsertJournal(ctx context.Context, entry *JournalEntry) error {
+	_, err := r.journals.InsertOne(ctx, entry)
+	if err != nil && entry.IdempotencyKey != "" && mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("%w: %v", ErrDuplicateKey, err)
+	}
+	return err
+}
+
+func (r *MongoRepository) GetJournal(ctx context.Context, journalID primitive.ObjectID) (*JournalEntry, error) {
+	var entry JournalEntry
+	if err := r.journals.FindOne(ctx, bson.M{"_id": journalID}).Decode(&entry); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("%w: %s", ErrJournalNotFound, journalID.Hex())
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *MongoRepository) FindJournalByIdempotencyKey(ctx context.Context, key string) (*JournalEntry, error) {
+	var entry JournalEntry
+	err := r.journals.FindOne(ctx, bson.M{"idempotency_key": key}).Decode(&entry)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// EnsureIndexes creates the indexes of the account lookups and listings, the unique index
+// of the account references, the indexes of the journal
+// lookups by reference and by account, the unique index of the snapshot upserts, the
+// indexes of the audit log searches and the indexes of the outbox and schedule claims
+func (r *MongoRepository) EnsureIndexes(ctx context.Context) error {
+	journalIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tranref", Value: 1}, {Key: "type", Value: 1}}},
+		{Keys: bson.D{{Key: "debit_account", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "credit_account", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "legs.account_id", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "seq", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "narrative", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "metadata.$**", Value: 1}}},
+	}
+	indexes := []struct {
+		coll   *mongo.Collection
+		models []mongo.IndexModel
+	}{
+		{r.accounts, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+			{Keys: bson.D{{Key: "type", Value: 1}, {Key: "name", Value: 1}}},
+			{Keys: bson.D{{Key: "name", Value: 1}}},
+			{Keys: bson.D{{Key: "ref", Value: 1}}, Options: options.Index().SetUnique(true).SetSparse(true)},
+		}},
+		{r.journals, journalIndexes},
+		{r.archive, journalIndexes},
+		{r.snapshots, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "account_id", Value: 1}, {Key: "as_of", Value: 1}}, Options: options.Index().SetUnique(true)},
+		}},
+		{r.audit, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "entity_id", Value: 1}, {Key: "at", Value: -1}}},
+			{Keys: bson.D{{Key: "actor.id", Value: 1}, {Key: "at", Value: -1}}},
+			{Keys: bson.D{{Key: "at", Value: -1}}},
+		}},
+		{r.outbox, []mongo.IndexModel{
+			{
+				Keys:    bson.D{{Key: "next_attempt_at", Value: 1}},
+				Options: options.Index().SetPartialFilterExpression(bson.M{"delivered_at": bson.M{"$exists": false}}),
+			},
+		}},
+		{r.schedules, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+			{Keys: bson.D{{Key: "debit_account", Value: 1}, {Key: "execute_at", Value: 1}}},
+			{Keys: bson.D{{Key: "credit_account", Value: 1}, {Key: "execute_at", Value: 1}}},
+			{Keys: bson.D{{Key: "execute_at", Value: 1}, {Key: "_id", Value: 1}}},
+		}},
+	}
+	for _, idx := range indexes {
+		if _, err := idx.coll.Indexes().CreateMany(ctx, idx.models); err != nil {
+			return fmt.Errorf("create %s indexes: %w", idx.coll.Name(), err)
+		}
+	}
+	return nil
+}
+
+// EnsureIdempotencyIndex creates a unique index on the idempotency key, partial so the
+// entries posted without one are not indexed
+func (r *MongoRepository) EnsureIdempotencyIndex(ctx context.Context) error {
+	_, err := r.journals.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "idempotency_key", Value: 1}},
+		Options: options.Index().
+			SetName(idempotencyIndex).
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"idempotency_key": bson.M{"$exists": true}}),
+	})
+	return err
+}
+
+func (r *MongoRepository) MarkReversed(ctx context.Context, journalID, group, reversedBy primitive.ObjectID, at time.Time, reason string) (bool, error) {
+	// the reversed_by condition keeps concurrent reversals from both succeeding
+	res, err := r.journals.UpdateOne(ctx,
+		bson.M{"_id": journalID, "reversed_by": bson.M{"$exists": false}, "matched_by": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{
+			"transaction_id":  group,
+			"reversed_by":     reversedBy,
+			"reversed_at":     at,
+			"reversal_reason": reason,
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+func (r *MongoRepository) MarkMatched(ctx context.Context, receiptID, group, matchedBy primitive.ObjectID, at time.Time) (bool, error) {
+	// like MarkReversed, the conditions keep concurrent matches from both succeeding
+	res, err := r.journals.UpdateOne(ctx,
+		bson.M{"_id": receiptID, "matched_by": bson.M{"$exists": false}, "reversed_by": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{
+			"transaction_id": group,
+			"matched_by":     matchedBy,
+			"matched_at":     at,
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+func (r *MongoRepository) UnmarkMatched(ctx context.Context, receiptID, matchedBy primitive.ObjectID) (bool, error) {
+	res, err := r.journals.UpdateOne(ctx,
+		bson.M{"_id": receiptID, "matched_by": matchedBy},
+		bson.M{"$unset": bson.M{"matched_by": "", "matched_at": ""}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+func (r *MongoRepository) ListJournals(ctx context.Context, limit, skip int64) ([]JournalEntry, error) {
+	opts := options.Find().
+		SetSort(bson.M{"created_at": -1}).
+		SetLimit(limit).
+		SetSkip(skip)
+	return r.findJournals(ctx, r.journals, bson.M{}, opts)
+}
+
+func (r *MongoRepository) JournalsByRef(ctx context.Context, tranRef string) ([]JournalEntry, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	return r.findJournals(ctx, r.journals, bson.M{"tranref": tranRef}, opts)
+}
+
+func (r *MongoRepository) QueryJournals(ctx context.Context, filter JournalFilter, sort JournalSort, page Pagination) ([]JournalEntry, int64, error) {
+	match, err := filter.query()
+	if err != nil {
+		return nil, 0, err
+	}
+	coll := r.journalsOf(filter)
+	total, err := coll.CountDocuments(ctx, match)
+	if err != nil {
+		return nil, 0, err
+	}
+	pipeline := append(journalQueryPipeline(match, sort),
+		bson.D{{Key: "$skip", Value: page.Skip}},
+		bson.D{{Key: "$limit", Value: page.Limit}},
+	)
+	cursor, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []JournalEntry{}
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+func (r *MongoRepository) StreamJournals(ctx context.Context, filter JournalFilter, sort JournalSort, fn func(*JournalEntry) error) error {
+	match, err := filter.query()
+	if err != nil {
+		return err
+	}
+	cursor, err := r.journalsOf(filter).Aggregate(ctx, journalQueryPipeline(match, sort), options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var entry JournalEntry
+		if err := cursor.Decode(&entry); err != nil {
+			return err
+		}
+		if err := fn(&entry); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// journalsOf returns the collection filter searches, the journal or its archive
+func (r *MongoRepository) journalsOf(filter JournalFilter) *mongo.Collection {
+	if filter.Archived {
+		return r.archive
+	}
+	return r.journals
+}
+
+// journalQueryPipeline matches and orders journal entries. Amounts are decimal strings,
+// so sorting by amount goes through a numeric copy of the field.
+func journalQueryPipeline(match bson.M, sort JournalSort) mongo.Pipeline {
+	dir := -1
+	if sort.Ascending {
+		dir = 1
+	}
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: match}}}
+	if sort.Field == SortByAmount {
+		return append(pipeline,
+			bson.D{{Key: "$addFields", Value: bson.M{"amount_value": bson.M{"$toDecimal": "$amount"}}}},
+			bson.D{{Key: "$sort", Value: bson.D{{Key: "amount_value", Value: dir}, {Key: "_id", Value: dir}}}},
+			bson.D{{Key: "$project", Value: bson.M{"amount_value": 0}}},
+		)
+	}
+	return append(pipeline, bson.D{{Key: "$sort", Value: bson.D{{Key: "created_at", Value: dir}, {Key: "_id", Value: dir}}}})
+}
+
+func (r *MongoRepository) AccountJournals(ctx context.Context, q AccountJournalQuery) ([]JournalEntry, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	if q.Limit > 0 {
+		opts.SetLimit(q.Limit)
+	}
+	filter := accountJournalsFilter(q)
+	archived, err := r.findJournals(ctx, r.archive, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := r.findJournals(ctx, r.journals, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(archived) == 0 {
+		return entries, nil
+	}
+	// an entry created before the cutoff may have committed after the archive ran
+	entries = append(archived, entries...)
+	slices.SortStableFunc(entries, func(a, b JournalEntry) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return compareIDs(a.ID, b.ID)
+	})
+	if q.Limit > 0 && int64(len(entries)) > q.Limit {
+		entries = entries[:q.Limit]
+	}
+	return entries, nil
+}
+
+func (r *MongoRepository) CountAccountJournals(ctx context.Context, q AccountJournalQuery) (int64, error) {
+	archived, err := r.archive.CountDocuments(ctx, accountJournalsFilter(q))
+	if err != nil {
+		return 0, err
+	}
+	live, err := r.journals.CountDocuments(ctx, accountJournalsFilter(q))
+	return archived + live, err
+}
+
+// ArchiveJournals copies the entries to the archive and deletes them from the journal,
+// in the transaction of ctx
+func (r *MongoRepository) ArchiveJournals(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	entries, err := r.findJournals(ctx, r.journals, bson.M{"created_at": bson.M{"$lt": cutoff}},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(int64(limit)))
+	if err != nil || len(entries) == 0 {
+		return 0, err
+	}
+	docs := make([]any, len(entries))
+	ids := make([]primitive.ObjectID, len(entries))
+	for i := range entries {
+		docs[i], ids[i] = entries[i], entries[i].ID
+	}
+	if _, err := r.archive.InsertMany(ctx, docs); err != nil {
+		return 0, err
+	}
+	res, err := r.journals.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+func (r *MongoRepository) findJournals(ctx context.Context, coll *mongo.Collection, filter bson.M, opts *options.FindOptions) ([]JournalEntry, error) {
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []JournalEntry{}
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// accountJournalsFilter matches the journal entries with a leg on the account of q
+// created within its bounds
+func accountJournalsFilter(q AccountJournalQuery) bson.M {
+	filter := bson.M{
+		"$or": []bson.M{
+			{"debit_account": q.AccountID},
+			{"credit_account": q.AccountID},
+			{"legs.account_id": q.AccountID},
+		},
+	}
+	createdAt := bson.M{}
+	if !q.After.IsZero() {
+		createdAt["$gt"] = q.After
+	}
+	if !q.From.IsZero() {
+		createdAt["$gte"] = q.From
+	}
+	if !q.To.IsZero() {
+		createdAt["$lte"] = q.To
+	}
+	if !q.Before.IsZero() {
+		createdAt["$lt"] = q.Before
+	}
+	if len(createdAt) > 0 {
+		filter["created_at"] = createdAt
+	}
+	return filter
+}
+
+// --------------------------
+//  Dashboards
+// --------------------------
+
+func (r *MongoRepository) BalancesByType(ctx context.Context) ([]TypeBalance, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":     "$type",
+			"count":   bson.M{"$sum": 1},
+			"balance": bson.M{"$sum": bson.M{"$toDecimal": "$balance"}}, // string balances predate the migration
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+	var rows []struct {
+		Type    AccountType          `bson:"_id"`
+		Count   int64                `bson:"count"`
+		Balance primitive.Decimal128 `bson:"balance"`
+	}
+	if err := r.aggregate(ctx, r.accounts, pipeline, &rows); err != nil {
+		return nil, err
+	}
+	out := make([]TypeBalance, len(rows))
+	for i, row := range rows {
+		out[i] = TypeBalance{AccountType: row.Type, AccountCount: row.Count, Balance: fromDecimal128(row.Balance)}
+	}
+	return out, nil
+}
+
+func (r *MongoRepository) DailyPostingTotals(ctx context.Context, from, to time.Time) ([]DailyPostingTotal, error) {
+	day := bson.M{"$dateFromParts": bson.M{
+		"year":  bson.M{"$year": "$created_at"},
+		"month": bson.M{"$month": "$created_at"},
+		"day":   bson.M{"$dayOfMonth": "$created_at"},
+	}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"created_at": bson.M{"$gte": from, "$lte": to}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    bson.M{"day": day, "type": "$type"},
+			"count":  bson.M{"$sum": 1},
+			"amount": bson.M{"$sum": bson.M{"$toDecimal": "$amount"}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id.day", Value: 1}, {Key: "_id.type", Value: 1}}}},
+	}
+	var rows []struct {
+		ID struct {
+			Day  time.Time       `bson:"day"`
+			Type TransactionType `bson:"type"`
+		} `bson:"_id"`
+		Count  int64                `bson:"count"`
+		Amount primitive.Decimal128 `bson:"amount"`
+	}
+	if err := r.aggregate(ctx, r.journals, pipeline, &rows); err != nil {
+		return nil, err
+	}
+	out := make([]DailyPostingTotal, len(rows))
+	for i, row := range rows {
+		out[i] = DailyPostingTotal{Day: row.ID.Day.UTC(), Type: row.ID.Type, Count: row.Count, Amount: fromDecimal128(row.Amount)}
+	}
+	return out, nil
+}
+
+// TopAccountsByVolume turns every entry into its legs, the two sides of a double entry
+// or the legs of a compound one, and sums them per account
+func (r *MongoRepository) TopAccountsByVolume(ctx context.Context, from, to time.Time, limit int) ([]AccountVolume, error) {
+	doubleEntryLegs := bson.A{
+		bson.M{"account_id": "$debit_account", "direction": DirectionDebit, "amount": "$amount"},
+		bson.M{"account_id": "$credit_account", "direction": DirectionCredit, "amount": "$amount"},
+	}
+	sumDirection := func(dir EntryDirection) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{
+			bson.M{"$eq": bson.A{"$legs.direction", dir}},
+			bson.M{"$toDecimal": "$legs.amount"},
+			bson.M{"$toDecimal": 0},
+		}}}
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"created_at": bson.M{"$gte": from, "$lte": to}}}},
+		{{Key: "$project", Value: bson.M{"legs": bson.M{"$cond": bson.A{
+			bson.M{"$gt": bson.A{bson.M{"$size": bson.M{"$ifNull": bson.A{"$legs", bson.A{}}}}, 0}},
+			"$legs",
+			doubleEntryLegs,
+		}}}}},
+		{{Key: "$unwind", Value: "$legs"}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     "$legs.account_id",
+			"legs":    bson.M{"$sum": 1},
+			"debits":  sumDirection(DirectionDebit),
+			"credits": sumDirection(DirectionCredit),
+		}}},
+		{{Key: "$addFields", Value: bson.M{"volume": bson.M{"$add": bson.A{"$debits", "$credits"}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "volume", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+		{{Key: "$lookup", Value: bson.M{"from": r.accounts.Name(), "localField": "_id", "foreignField": "_id", "as": "account"}}},
+		{{Key: "$unwind", Value: bson.M{"path": "$account", "preserveNullAndEmptyArrays": true}}},
+	}
+	var rows []struct {
+		AccountID primitive.ObjectID   `bson:"_id"`
+		Legs      int64                `bson:"legs"`
+		Debits    primitive.Decimal128 `bson:"debits"`
+		Credits   primitive.Decimal128 `bson:"credits"`
+		Volume    primitive.Decimal128 `bson:"volume"`
+		Account   struct {
+			Type AccountType `bson:"type"`
+			Name string      `bson:"name"`
+		} `bson:"account"`
+	}
+	if err := r.aggregate(ctx, r.journals, pipeline, &rows, options.Aggregate().SetAllowDiskUse(true)); err != nil {
+		return nil, err
+	}
+	out := make([]AccountVolume, len(rows))
+	for i, row := range rows {
+		out[i] = AccountVolume{
+			AccountID:   row.AccountID,
+			AccountType: row.Account.Type,
+			AccountName: row.Account.Name,
+			Legs:        row.Legs,
+			Debits:      fromDecimal128(row.Debits),
+			Credits:     fromDecimal128(row.Credits),
+			Volume:      fromDecimal128(row.Volume),
+		}
+	}
+	return out, nil
+}
+
+// aggregate runs pipeline on coll and decodes every result into out
+func (r *MongoRepository) aggregate(ctx context.Context, coll *mongo.Collection, pipeline mongo.Pipeline, out any, opts ...*options.AggregateOptions) error {
+	cursor, err := coll.Aggregate(ctx, pipeline, opts...)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	return cursor.All(ctx, out)
+}
+
+// --------------------------
+//  Hash Chain
+// --------------------------
+
+// ChainHead reads the head document; in a transaction a concurrent AdvanceChain makes
+// one of the transactions fail with a write conflict, which RunInTransaction retries
+func (r *MongoRepository) ChainHead(ctx context.Context) (ChainLink, error) {
+	var head ChainLink
+	err := r.chain.FindOne(ctx, bson.M{"_id": chainHeadID}).Decode(&head)
+	if err == mongo.ErrNoDocuments {
+		return ChainLink{}, nil
+	}
+	return head, err
+}
+
+func (r *MongoRepository) AdvanceChain(ctx context.Context, prev, next ChainLink) (bool, error) {
+	if prev.Seq == 0 {
+		_, err := r.chain.InsertOne(ctx, bson.M{"_id": chainHeadID, "seq": next.Seq, "hash": next.Hash})
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	res, err := r.chain.UpdateOne(ctx,
+		bson.M{"_id": chainHeadID, "seq": prev.Seq},
+		bson.M{"$set": bson.M{"seq": next.Seq, "hash": next.Hash}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+func (r *MongoRepository) ChainSeqRange(ctx context.Context, from, to time.Time) (int64, int64, error) {
+	bound := func(coll *mongo.Collection, createdAt bson.M, dir int) (int64, error) {
+		filter := bson.M{"seq": bson.M{"$exists": true}}
+		if len(createdAt) > 0 {
+			filter["created_at"] = createdAt
+		}
+		var entry JournalEntry
+		err := coll.FindOne(ctx, filter,
+			options.FindOne().SetSort(bson.M{"seq": dir}).SetProjection(bson.M{"seq": 1}),
+		).Decode(&entry)
+		if err == mongo.ErrNoDocuments {
+			return 0, nil
+		}
+		return entry.Seq, err
+	}
+	createdAt := bson.M{}
+	if !from.IsZero() {
+		createdAt["$gte"] = from
+	}
+	if !to.IsZero() {
+		createdAt["$lte"] = to
+	}
+	var first, last int64
+	for _, coll := range []*mongo.Collection{r.archive, r.journals} {
+		lo, err := bound(coll, createdAt, 1)
+		if err != nil {
+			return 0, 0, err
+		}
+		if lo == 0 {
+			continue
+		}
+		hi, err := bound(coll, createdAt, -1)
+		if err != nil {
+			return 0, 0, err
+		}
+		if first == 0 || lo < first {
+			first = lo
+		}
+		last = max(last, hi)
+	}
+	return first, last, nil
+}
+
+// StreamChain merges the archived and the live entries by Seq: the archive holds the
+// start of the chain, but an entry created before the archive cutoff may follow live ones
+func (r *MongoRepository) StreamChain(ctx context.Context, first, last int64, fn func(*JournalEntry) error) error {
+	filter := bson.M{"seq": bson.M{"$gte": first, "$lte": last}}
+	opts := options.Find().SetSort(bson.M{"seq": 1})
+	archived, err := r.archive.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	defer archived.Close(ctx)
+	live, err := r.journals.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	defer live.Close(ctx)
+
+	next := func(cursor *mongo.Cursor) (*JournalEntry, error) {
+		if !cursor.Next(ctx) {
+			return nil, cursor.Err()
+		}
+		var entry JournalEntry
+		if err := cursor.Decode(&entry); err != nil {
+			return nil, err
+		}
+		return &entry, nil
+	}
+	a, err := next(archived)
+	if err != nil {
+		return err
+	}
+	l, err := next(live)
+	if err != nil {
+		return err
+	}
+	for a != nil || l != nil {
+		var entry *JournalEntry
+		if l == nil || (a != nil && a.Seq < l.Seq) {
+			entry = a
+			a, err = next(archived)
+		} else {
+			entry = l
+			l, err = next(live)
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// --------------------------
+//  Snapshots
+// --------------------------
+
+func (r *MongoRepository) LatestSnapshot(ctx context.Context, accountID primitive.ObjectID, t time.Time, inclusive bool) (*BalanceSnapshot, error) {
+	upTo := "$lt"
+	if inclusive {
+		upTo = "$lte"
+	}
+	var snap BalanceSnapshot
+	err := r.snapshots.FindOne(ctx,
+		bson.M{"account_id": accountID, "as_of": bson.M{upTo: t}},
+		options.FindOne().SetSort(bson.M{"as_of": -1}),
+	).Decode(&snap)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+func (r *MongoRepository) UpsertSnapshot(ctx context.Context, snap *BalanceSnapshot) (*BalanceSnapshot, error) {
+	res := r.snapshots.FindOneAndUpdate(ctx,
+		bson.M{"account_id": snap.AccountID, "as_of": snap.AsOf},
+		bson.M{
+			"$set":         bson.M{"balance": snap.Balance, "created_at": snap.CreatedAt},
+			"$setOnInsert": bson.M{"_id": snap.ID},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	)
+	var stored BalanceSnapshot
+	if err := res.Decode(&stored); err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+func (r *MongoRepository) ListSnapshots(ctx context.Context, accountID primitive.ObjectID, from, to time.Time) ([]BalanceSnapshot, error) {
+	filter := bson.M{"account_id": accountID}
+	asOf := bson.M{}
+	if !from.IsZero() {
+		asOf["$gte"] = from
+	}
+	if !to.IsZero() {
+		asOf["$lte"] = to
+	}
+	if len(asOf) > 0 {
+		filter["as_of"] = asOf
+	}
+	cursor, err := r.snapshots.Find(ctx, filter, options.Find().SetSort(bson.M{"as_of": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	snaps := []BalanceSnapshot{}
+	if err = cursor.All(ctx, &snaps); err != nil {
+		return nil, err
+	}
+	return snaps, nil
+}
+
+func (r *MongoRepository) InsertAudit(ctx context.Context, rec *AuditRecord) error {
+	_, err := r.audit.InsertOne(ctx, rec)
+	return err
+}
+
+func (r *MongoRepository) ListAudit(ctx context.Context, filter AuditFilter, page Pagination) ([]AuditRecord, int64, error) {
+	q, err := filter.query()
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := r.audit.CountDocuments(ctx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(page.Limit).
+		SetSkip(page.Skip)
+	cursor, err := r.audit.Find(ctx, q, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	records := []AuditRecord{}
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, 0, err
+	}
+	return records, total, nil
+}
+
+// --------------------------
+//  Outbox
+// --------------------------
+
+func (r *MongoRepository) InsertOutbox(ctx context.Context, msg *OutboxMessage) error {
+	_, err := r.outbox.InsertOne(ctx, msg)
+	return err
+}
+
+// ClaimOutbox takes the messages one at a time with FindOneAndUpdate, so concurrent
+// relays never claim the same message
+func (r *MongoRepository) ClaimOutbox(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]OutboxMessage, error) {
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetReturnDocument(options.After)
+	msgs := []OutboxMessage{}
+	for len(msgs) < limit {
+		var msg OutboxMessage
+		err := r.outbox.FindOneAndUpdate(ctx,
+			bson.M{"delivered_at": bson.M{"$exists": false}, "next_attempt_at": bson.M{"$lte": now}},
+			bson.M{"$set": bson.M{"next_attempt_at": now.Add(lease)}},
+			opts,
+		).Decode(&msg)
+		if err == mongo.ErrNoDocuments {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+func (r *MongoRepository) UpdateOutbox(ctx context.Context, msg *OutboxMessage) error {
+	set := bson.M{
+		"attempts":        msg.Attempts,
+		"next_attempt_at": msg.NextAttemptAt,
+		"delivered":       msg.Delivered,
+		"last_error":      msg.LastError,
+	}
+	if msg.DeliveredAt != nil {
+		set["delivered_at"] = *msg.DeliveredAt
+	}
+	res, err := r.outbox.UpdateOne(ctx, bson.M{"_id": msg.ID}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("outbox message %s not found", msg.ID.Hex())
+	}
+	return nil
+}
+
+// --------------------------
+//  Scheduled Postings
+// --------------------------
+
+func (r *MongoRepository) InsertSchedule(ctx context.Context, sp *ScheduledPosting) error {
+	_, err := r.schedules.InsertOne(ctx, sp)
+	return err
+}
+
+func (r *MongoRepository) GetSchedule(ctx context.Context, id primitive.ObjectID) (*ScheduledPosting, error) {
+	var sp ScheduledPosting
+	if err := r.schedules.FindOne(ctx, bson.M{"_id": id}).Decode(&sp); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("%w: %s", ErrScheduleNotFound, id.Hex())
+		}
+		return nil, err
+	}
+	return &sp, nil
+}
+
+func (r *MongoRepository) ListSchedules(ctx context.Context, filter ScheduleFilter, page Pagination) ([]ScheduledPosting, int64, error) {
+	q, err := filter.query()
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := r.schedules.CountDocuments(ctx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "execute_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(page.Limit).
+		SetSkip(page.Skip)
+	cursor, err := r.schedules.Find(ctx, q, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	schedules := []ScheduledPosting{}
+	if err = cursor.All(ctx, &schedules); err != nil {
+		return nil, 0, err
+	}
+	return schedules, total, nil
+}
+
+// ClaimSchedules takes the postings one at a time with FindOneAndUpdate, so concurrent
+// runners never claim the same posting
+func (r *MongoRepository) ClaimSchedules(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]ScheduledPosting, error) {
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetReturnDocument(options.After)
+	schedules := []ScheduledPosting{}
+	for len(schedules) < limit {
+		var sp ScheduledPosting
+		err := r.schedules.FindOneAndUpdate(ctx,
+			bson.M{"status": SchedulePending, "next_attempt_at": bson.M{"$lte": now}},
+			bson.M{"$set": bson.M{"next_attempt_at": now.Add(lease)}},
+			opts,
+		).Decode(&sp)
+		if err == mongo.ErrNoDocuments {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, sp)
+	}
+	return schedules, nil
+}
+
+func (r *MongoRepository) UpdateSchedule(ctx context.Context, sp *ScheduledPosting) (bool, error) {
+	set := bson.M{
+		"status":          sp.Status,
+		"attempts":        sp.Attempts,
+		"next_attempt_at": sp.NextAttemptAt,
+		"last_error":      sp.LastError,
+	}
+	if sp.JournalID != nil {
+		set["journal_id"] = *sp.JournalID
+	}
+	if sp.SettledAt != nil {
+		set["settled_at"] = *sp.SettledAt
+	}
+	res, err := r.schedules.UpdateOne(ctx, bson.M{"_id": sp.ID, "status": SchedulePending}, bson.M{"$set": set})
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount == 1, nil
+}
+
+// --------------------------
+//  Balance Watch
+// --------------------------
+
+// balanceEvent is the part of a change stream event WatchBalances reads
+type balanceEvent struct {
+	NS struct {
+		Coll string `bson:"coll"`
+	} `bson:"ns"`
+	LSID struct {
+		ID primitive.Binary `bson:"id"`
+	} `bson:"lsid"`
+	TxnNumber         int64 `bson:"txnNumber"`
+	UpdateDescription struct {
+		UpdatedFields struct {
+			Balance primitive.Decimal128 `bson:"balance"`
+		} `bson:"updatedFields"`
+	} `bson:"updateDescription"`
+	FullDocument JournalEntry `bson:"fullDocument"`
+}
+
+// txn identifies the transaction of the event
+func (e balanceEvent) txn() string {
+	return fmt.Sprintf("%x:%d", e.LSID.ID.Data, e.TxnNumber)
+}
+
+// WatchBalances opens a change stream on the database for the $inc of the balance of
+// accountID and the insert of the journal entries touching it. A posting updates the
+// balances before inserting its entry in the same transaction, so the balance an update
+// reports is the new balance of the entry that follows it with the same session and
+// transaction number. The change stream is open when it returns.
+func (r *MongoRepository) WatchBalances(ctx context.Context, accountID primitive.ObjectID) (*BalanceWatch, error) {
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.M{"$or": bson.A{
+		bson.M{
+			"ns.coll":         r.accounts.Name(),
+			"operationType":   "update",
+			"documentKey._id": accountID,
+			"updateDescription.updatedFields.balance": bson.M{"$exists": true},
+		},
+		bson.M{
+			"ns.coll":       r.journals.Name(),
+			"operationType": "insert",
+			"$or": bson.A{
+				bson.M{"fullDocument.debit_account": accountID},
+				bson.M{"fullDocument.credit_account": accountID},
+				bson.M{"fullDocument.legs.account_id": accountID},
+			},
+		},
+	}}}}}
+	stream, err := r.db.Watch(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	return newBalanceWatch(ctx, func(ctx context.Context, send func(BalanceChange) bool) error {
+		defer stream.Close(context.WithoutCancel(ctx))
+		// the balance after the last update, until the entry of its transaction arrives
+		var txn string
+		var balance *decimal.Decimal
+		for stream.Next(ctx) {
+			var event balanceEvent
+			if err := stream.Decode(&event); err != nil {
+				return err
+			}
+			if event.NS.Coll == r.accounts.Name() {
+				b := fromDecimal128(event.UpdateDescription.UpdatedFields.Balance)
+				txn, balance = event.txn(), &b
+				continue
+			}
+			if balance == nil || event.txn() != txn {
+				// the update was committed before the stream opened
+				continue
+			}
+			if !send(balanceChange(accountID, &event.FullDocument, *balance)) {
+				return nil
+			}
+			balance = nil
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		return stream.Err()
+	}), nil
+}
@@ -0,0 +1,259 @@
+package accounting
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// --------------------------
+//  Outbox
+// --------------------------
+
+const (
+	defaultOutboxBatch        = 100
+	defaultOutboxPollInterval = time.Second
+	defaultOutboxLease        = time.Minute
+	defaultOutboxRetryInitial = time.Second
+	defaultOutboxRetryMax     = time.Hour
+	defaultWebhookTimeout     = 10 * time.Second
+
+	// Headers of the webhook requests
+	HeaderWebhookEvent     = "X-Ledger-Event"
+	HeaderWebhookDelivery  = "X-Ledger-Delivery" // outbox message ID, the same on every retry
+	HeaderWebhookTimestamp = "X-Ledger-Timestamp"
+	HeaderWebhookSignature = "X-Ledger-Signature"
+)
+
+// OutboxMessage is the notification of a posting, written in the posting transaction and
+// delivered to the webhook endpoints by an OutboxRelay. A message is only done once every
+// endpoint accepted it.
+type OutboxMessage struct {
+	ID            primitive.ObjectID `bson:"_id" json:"id"`
+	Event         string             `bson:"event" json:"event"`
+	JournalID     primitive.ObjectID `bson:"journal_id" json:"journal_id"`
+	Payload       string             `bson:"payload" json:"payload"` // JSON request body
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	Attempts      int                `bson:"attempts" json:"attempts"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at" json:"next_attempt_at"`
+	Delivered     []string           `bson:"delivered,omitempty" json:"delivered,omitempty"` // endpoints that accepted it
+	DeliveredAt   *time.Time         `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
+	LastError     string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+}
+
+// EnableOutbox makes every posting, reversals included, write an OutboxMessage in its
+// transaction, so a movement is never committed without its notification. Run an
+// OutboxRelay to deliver them.
+func (s *AccountingService) EnableOutbox() {
+	s.outbox = true
+}
+
+// newOutboxMessage returns the notification of entry. The body carries the data of the
+// JournalPostedEvent.
+func newOutboxMessage(entry *JournalEntry) (*OutboxMessage, error) {
+	msg := &OutboxMessage{
+		ID:        primitive.NewObjectID(),
+		Event:     JournalPostedEvent,
+		JournalID: entry.ID,
+		CreatedAt: entry.CreatedAt,
+	}
+	body, err := json.Marshal(map[string]any{
+		"id":         msg.ID.Hex(),
+		"event":      msg.Event,
+		"created_at": msg.CreatedAt,
+		"data":       journalEventData(entry),
+	})
+	if err != nil {
+		return nil, err
+	}
+	msg.Payload = string(body)
+	msg.NextAttemptAt = msg.CreatedAt
+	return msg, nil
+}
+
+// --------------------------
+//  Webhook Relay
+// --------------------------
+
+// WebhookEndpoint receives the outbox messages as signed JSON POST requests
+type WebhookEndpoint struct {
+	Name   string // Identifies the endpoint in OutboxMessage.Delivered, must be unique and stable
+	URL    string
+	Secret string // Key of the HMAC-SHA256 request signature, see SignWebhook
+}
+
+// OutboxRelayConfig configures an OutboxRelay
+type OutboxRelayConfig struct {
+	Endpoints    []WebhookEndpoint
+	BatchSize    int           // Messages claimed per poll, defaults to 100
+	PollInterval time.Duration // Wait between polls finding no message, defaults to 1s
+	Lease        time.Duration // How long a claimed message is hidden from other relays, defaults to 1m
+	RetryInitial time.Duration // Delay before the first retry of a failed delivery, defaults to 1s
+	RetryMax     time.Duration // Upper bound of the doubling retry delay, defaults to 1h
+	HTTPClient   *http.Client  // Defaults to a client with a 10s timeout
+	OnError      func(msg *OutboxMessage, endpoint string, err error)
+}
+
+// OutboxRelay delivers the outbox messages to the webhook endpoints, at least once: a
+// message is retried with backoff until every endpoint answered with a 2xx status, so
+// receivers should deduplicate on the X-Ledger-Delivery header. Several relays may run
+// against the same store; each message is claimed by one of them at a time.
+type OutboxRelay struct {
+	repo AccountingRepository
+	cfg  OutboxRelayConfig
+	now  func() time.Time
+}
+
+// NewOutboxRelay returns the relay of the outbox of s
+func NewOutboxRelay(s *AccountingService, cfg OutboxRelayConfig) *OutboxRelay {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultOutboxBatch
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultOutboxPollInterval
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = defaultOutboxLease
+	}
+	if cfg.RetryInitial <= 0 {
+		cfg.RetryInitial = defaultOutboxRetryInitial
+	}
+	if cfg.RetryMax < cfg.RetryInitial {
+		cfg.RetryMax = max(defaultOutboxRetryMax, cfg.RetryInitial)
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: defaultWebhookTimeout}
+	}
+	return &OutboxRelay{repo: s.repo, cfg: cfg, now: time.Now}
+}
+
+// RunOnce claims a batch of due messages and attempts their pending deliveries. It
+// returns the number of messages claimed.
+func (r *OutboxRelay) RunOnce(ctx context.Context) (int, error) {
+	msgs, err := r.repo.ClaimOutbox(ctx, r.now(), r.cfg.BatchSize, r.cfg.Lease)
+	if err != nil {
+		return 0, fmt.Errorf("claim outbox: %w", err)
+	}
+	for i := range msgs {
+		if err := r.process(ctx, &msgs[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(msgs), nil
+}
+
+// Start polls the outbox until ctx is cancelled, immediately again after a full batch
+func (r *OutboxRelay) Start(ctx context.Context) {
+	go func() {
+		for {
+			n, err := r.RunOnce(ctx)
+			if err != nil && r.cfg.OnError != nil {
+				r.cfg.OnError(nil, "", err)
+			}
+			if err == nil && n == r.cfg.BatchSize && ctx.Err() == nil {
+				continue
+			}
+			timer := time.NewTimer(r.cfg.PollInterval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+}
+
+// process delivers msg to the endpoints that did not accept it yet and stores the outcome
+func (r *OutboxRelay) process(ctx context.Context, msg *OutboxMessage) error {
+	var errs []error
+	for _, ep := range r.cfg.Endpoints {
+		if slices.Contains(msg.Delivered, ep.Name) {
+			continue
+		}
+		if err := r.deliver(ctx, ep, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ep.Name, err))
+			if r.cfg.OnError != nil {
+				r.cfg.OnError(msg, ep.Name, err)
+			}
+			continue
+		}
+		msg.Delivered = append(msg.Delivered, ep.Name)
+	}
+
+	msg.Attempts++
+	now := r.now()
+	if err := errors.Join(errs...); err != nil {
+		msg.LastError = err.Error()
+		msg.NextAttemptAt = now.Add(r.retryDelay(msg.Attempts))
+	} else {
+		msg.LastError = ""
+		msg.DeliveredAt = &now
+	}
+	if err := r.repo.UpdateOutbox(ctx, msg); err != nil {
+		return fmt.Errorf("update outbox message %s: %w", msg.ID.Hex(), err)
+	}
+	return nil
+}
+
+// retryDelay doubles from RetryInitial with every failed attempt, up to RetryMax
+func (r *OutboxRelay) retryDelay(attempts int) time.Duration {
+	delay := r.cfg.RetryInitial
+	for i := 1; i < attempts && delay < r.cfg.RetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, r.cfg.RetryMax)
+}
+
+func (r *OutboxRelay) deliver(ctx context.Context, ep WebhookEndpoint, msg *OutboxMessage) error {
+	body := []byte(msg.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(r.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderWebhookEvent, msg.Event)
+	req.Header.Set(HeaderWebhookDelivery, msg.ID.Hex())
+	req.Header.Set(HeaderWebhookTimestamp, timestamp)
+	if ep.Secret != "" {
+		req.Header.Set(HeaderWebhookSignature, SignWebhook(ep.Secret, timestamp, body))
+	}
+
+	resp, err := r.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook delivery: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook delivery: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SignWebhook returns the X-Ledger-Signature of a request: "sha256=" and the hex
+// HMAC-SHA256 of the X-Ledger-Timestamp, a dot and the body, keyed with secret
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook reports whether signature is the signature of a request with timestamp
+// and body, see SignWebhook. Receivers should also reject stale timestamps.
+func VerifyWebhook(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(signature), []byte(SignWebhook(secret, timestamp, body)))
+}
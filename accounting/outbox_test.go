@@ -0,0 +1,125 @@
+package accounting
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookReceiver records the requests it accepts and fails while failing is set
+type webhookReceiver struct {
+	mu       sync.Mutex
+	failing  bool
+	bodies   [][]byte
+	headers  []http.Header
+	attempts int
+}
+
+func (w *webhookReceiver) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.attempts++
+	if w.failing {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.bodies = append(w.bodies, body)
+	w.headers = append(w.headers, r.Header.Clone())
+}
+
+func TestOutboxRelay_DeliversSignedWebhooksWithRetries(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	s := NewAccountingServiceWithRepository(repo)
+	s.EnableOutbox()
+
+	client, _ := s.CreateAccount(ctx, ClientInsurance, decimal.Zero, "client")
+	gateway, _ := s.CreateAccount(ctx, PaymentGateway, decimal.Zero, "gateway")
+	require.NoError(t, s.ClientAccountTopUp(ctx, client.ID, gateway.ID, decimal.NewFromInt(75), "OUTBOX1"))
+	// a rejected posting leaves no message behind
+	require.Error(t, s.ClientPremiumPayment(ctx, client.ID, gateway.ID, decimal.NewFromInt(1000), "OUTBOX2"))
+	require.Len(t, repo.outbox, 1)
+
+	crm, erp := &webhookReceiver{}, &webhookReceiver{failing: true}
+	crmSrv, erpSrv := httptest.NewServer(crm), httptest.NewServer(erp)
+	defer crmSrv.Close()
+	defer erpSrv.Close()
+
+	now := time.Now()
+	relay := NewOutboxRelay(s, OutboxRelayConfig{
+		Endpoints: []WebhookEndpoint{
+			{Name: "crm", URL: crmSrv.URL, Secret: "crm-secret"},
+			{Name: "erp", URL: erpSrv.URL},
+		},
+		RetryInitial: time.Minute,
+	})
+	relay.now = func() time.Time { return now }
+
+	n, err := relay.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	msg := repo.outbox[0]
+	assert.Equal(t, []string{"crm"}, msg.Delivered)
+	assert.Nil(t, msg.DeliveredAt)
+	assert.Equal(t, 1, msg.Attempts)
+	assert.Contains(t, msg.LastError, "erp: webhook delivery: unexpected status 503")
+	assert.Equal(t, now.Add(time.Minute), msg.NextAttemptAt)
+
+	require.Len(t, crm.bodies, 1)
+	h := crm.headers[0]
+	assert.Equal(t, JournalPostedEvent, h.Get(HeaderWebhookEvent))
+	assert.Equal(t, msg.ID.Hex(), h.Get(HeaderWebhookDelivery))
+	assert.True(t, VerifyWebhook("crm-secret", h.Get(HeaderWebhookTimestamp), crm.bodies[0], h.Get(HeaderWebhookSignature)))
+	assert.False(t, VerifyWebhook("other-secret", h.Get(HeaderWebhookTimestamp), crm.bodies[0], h.Get(HeaderWebhookSignature)))
+	var payload struct {
+		Event string         `json:"event"`
+		Data  map[string]any `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(crm.bodies[0], &payload))
+	assert.Equal(t, JournalPostedEvent, payload.Event)
+	assert.Equal(t, "OUTBOX1", payload.Data["tranref"])
+
+	// not due yet
+	n, err = relay.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	erp.failing = false
+	now = now.Add(time.Minute)
+	n, err = relay.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	msg = repo.outbox[0]
+	assert.Equal(t, []string{"crm", "erp"}, msg.Delivered)
+	require.NotNil(t, msg.DeliveredAt)
+	assert.Empty(t, msg.LastError)
+	assert.Len(t, crm.bodies, 1, "accepted endpoints are not called again")
+	assert.Len(t, erp.bodies, 1)
+	assert.Empty(t, erp.headers[0].Get(HeaderWebhookSignature), "no secret, no signature")
+
+	n, err = relay.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestOutboxRelay_RetryDelay(t *testing.T) {
+	relay := NewOutboxRelay(NewAccountingServiceWithRepository(NewMemoryRepository()), OutboxRelayConfig{
+		RetryInitial: time.Second,
+		RetryMax:     5 * time.Second,
+	})
+	assert.Equal(t, time.Second, relay.retryDelay(1))
+	assert.Equal(t, 2*time.Second, relay.retryDelay(2))
+	assert.Equal(t, 4*time.Second, relay.retryDelay(3))
+	assert.Equal(t, 5*time.Second, relay.retryDelay(4))
+	assert.Equal(t, 5*time.Second, relay.retryDelay(60))
+}
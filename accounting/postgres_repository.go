@@ -0,0 +1,1509 @@
+package accounting
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// postgresSchema creates the tables of PostgresRepository. IDs keep the ObjectID hex form
+// so ledgers can move between stores; the legs of compound entries are a JSONB array.
+var postgresSchema = []string{
+	`CREATE TABLE IF NOT EXISTS accounts (
+		id              CHAR(24) PRIMARY KEY,
+		type            TEXT NOT NULL,
+		balance         NUMERIC NOT NULL,
+		opening_balance NUMERIC NOT NULL,
+		name            TEXT NOT NULL,
+		created_at      TIMESTAMPTZ NOT NULL,
+		allow_negative  BOOLEAN,
+		overdraft_limit NUMERIC,
+		status            TEXT,
+		status_reason     TEXT,
+		status_changed_at TIMESTAMPTZ,
+		ref               TEXT
+	)`,
+	// accounts tables created before account statuses and references
+	`ALTER TABLE accounts ADD COLUMN IF NOT EXISTS status TEXT`,
+	`ALTER TABLE accounts ADD COLUMN IF NOT EXISTS status_reason TEXT`,
+	`ALTER TABLE accounts ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMPTZ`,
+	`ALTER TABLE accounts ADD COLUMN IF NOT EXISTS ref TEXT`,
+	`CREATE INDEX IF NOT EXISTS accounts_created_at ON accounts (created_at, id)`,
+	`CREATE INDEX IF NOT EXISTS accounts_name ON accounts (name)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS accounts_ref ON accounts (ref) WHERE ref IS NOT NULL`,
+	`CREATE TABLE IF NOT EXISTS journals (
+		id              CHAR(24) PRIMARY KEY,
+		transaction_id  CHAR(24),
+		type            TEXT NOT NULL,
+		amount          NUMERIC NOT NULL,
+		tranref         TEXT NOT NULL,
+		debit_account   CHAR(24),
+		credit_account  CHAR(24),
+		created_at      TIMESTAMPTZ NOT NULL,
+		idempotency_key TEXT,
+		legs            JSONB,
+		reversal_of     CHAR(24),
+		reversed_by     CHAR(24),
+		reversed_at     TIMESTAMPTZ,
+		reversal_reason TEXT,
+		seq             BIGINT,
+		prev_hash       TEXT,
+		hash            TEXT,
+		narrative       TEXT,
+		metadata        JSONB,
+		clearing_of     CHAR(24),
+		matched_by      CHAR(24),
+		matched_at      TIMESTAMPTZ
+	)`,
+	// journals tables created before the hash chain and narratives
+	`ALTER TABLE journals ADD COLUMN IF NOT EXISTS seq BIGINT`,
+	`ALTER TABLE journals ADD COLUMN IF NOT EXISTS prev_hash TEXT`,
+	`ALTER TABLE journals ADD COLUMN IF NOT EXISTS hash TEXT`,
+	`ALTER TABLE journals ADD COLUMN IF NOT EXISTS narrative TEXT`,
+	`ALTER TABLE journals ADD COLUMN IF NOT EXISTS metadata JSONB`,
+	`CREATE INDEX IF NOT EXISTS journals_seq ON journals (seq) WHERE seq IS NOT NULL`,
+	`CREATE TABLE IF NOT EXISTS ledger_chain (
+		id   SMALLINT PRIMARY KEY,
+		seq  BIGINT NOT NULL,
+		hash TEXT NOT NULL
+	)`,
+	`INSERT INTO ledger_chain (id, seq, hash) VALUES (1, 0, '') ON CONFLICT (id) DO NOTHING`,
+	`CREATE INDEX IF NOT EXISTS journals_debit_account ON journals (debit_account, created_at)`,
+	`CREATE INDEX IF NOT EXISTS journals_credit_account ON journals (credit_account, created_at)`,
+	`CREATE INDEX IF NOT EXISTS journals_legs ON journals USING GIN (legs jsonb_path_ops)`,
+	`CREATE INDEX IF NOT EXISTS journals_metadata ON journals USING GIN (metadata jsonb_path_ops)`,
+	`CREATE INDEX IF NOT EXISTS journals_tranref ON journals (tranref)`,
+	`CREATE INDEX IF NOT EXISTS journals_created_at ON journals (created_at, id)`,
+	// entries moved by ArchiveJournals, with the columns and indexes of journals
+	`CREATE TABLE IF NOT EXISTS journals_archive (LIKE journals INCLUDING ALL)`,
+	// journals and archives created before suspense matching
+	`ALTER TABLE journals ADD COLUMN IF NOT EXISTS clearing_of CHAR(24)`,
+	`ALTER TABLE journals ADD COLUMN IF NOT EXISTS matched_by CHAR(24)`,
+	`ALTER TABLE journals ADD COLUMN IF NOT EXISTS matched_at TIMESTAMPTZ`,
+	`ALTER TABLE journals_archive ADD COLUMN IF NOT EXISTS clearing_of CHAR(24)`,
+	`ALTER TABLE journals_archive ADD COLUMN IF NOT EXISTS matched_by CHAR(24)`,
+	`ALTER TABLE journals_archive ADD COLUMN IF NOT EXISTS matched_at TIMESTAMPTZ`,
+	`CREATE TABLE IF NOT EXISTS balance_snapshots (
+		id         CHAR(24) PRIMARY KEY,
+		account_id CHAR(24) NOT NULL,
+		balance    NUMERIC NOT NULL,
+		as_of      TIMESTAMPTZ NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL,
+		UNIQUE (account_id, as_of)
+	)`,
+	`CREATE TABLE IF NOT EXISTS audit_log (
+		id         CHAR(24) PRIMARY KEY,
+		action     TEXT NOT NULL,
+		actor_id   TEXT NOT NULL DEFAULT '',
+		actor_name TEXT,
+		actor_role TEXT,
+		entity_id  CHAR(24) NOT NULL,
+		reason     TEXT,
+		before     JSONB,
+		after      JSONB,
+		at         TIMESTAMPTZ NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS audit_log_entity ON audit_log (entity_id, at DESC)`,
+	`CREATE INDEX IF NOT EXISTS audit_log_actor ON audit_log (actor_id, at DESC)`,
+	`CREATE INDEX IF NOT EXISTS audit_log_at ON audit_log (at DESC)`,
+	`CREATE TABLE IF NOT EXISTS outbox (
+		id              CHAR(24) PRIMARY KEY,
+		event           TEXT NOT NULL,
+		journal_id      CHAR(24) NOT NULL,
+		payload         TEXT NOT NULL,
+		created_at      TIMESTAMPTZ NOT NULL,
+		attempts        INTEGER NOT NULL DEFAULT 0,
+		next_attempt_at TIMESTAMPTZ NOT NULL,
+		delivered       JSONB,
+		delivered_at    TIMESTAMPTZ,
+		last_error      TEXT
+	)`,
+	`CREATE INDEX IF NOT EXISTS outbox_due ON outbox (next_attempt_at) WHERE delivered_at IS NULL`,
+	`CREATE TABLE IF NOT EXISTS scheduled_postings (
+		id              CHAR(24) PRIMARY KEY,
+		type            TEXT NOT NULL,
+		debit_account   CHAR(24) NOT NULL,
+		credit_account  CHAR(24) NOT NULL,
+		amount          NUMERIC NOT NULL,
+		tranref         TEXT NOT NULL,
+		narrative       TEXT,
+		metadata        JSONB,
+		execute_at      TIMESTAMPTZ NOT NULL,
+		status          TEXT NOT NULL,
+		attempts        INTEGER NOT NULL DEFAULT 0,
+		next_attempt_at TIMESTAMPTZ NOT NULL,
+		last_error      TEXT,
+		journal_id      CHAR(24),
+		created_at      TIMESTAMPTZ NOT NULL,
+		settled_at      TIMESTAMPTZ
+	)`,
+	`CREATE INDEX IF NOT EXISTS scheduled_postings_due ON scheduled_postings (next_attempt_at) WHERE status = 'pending'`,
+	`CREATE INDEX IF NOT EXISTS scheduled_postings_debit ON scheduled_postings (debit_account, execute_at)`,
+	`CREATE INDEX IF NOT EXISTS scheduled_postings_credit ON scheduled_postings (credit_account, execute_at)`,
+	`CREATE INDEX IF NOT EXISTS scheduled_postings_execute_at ON scheduled_postings (execute_at, id)`,
+}
+
+const (
+	pgAccountColumns  = `id, type, balance, opening_balance, name, created_at, allow_negative, overdraft_limit, status, status_reason, status_changed_at, ref`
+	pgJournalColumns  = `id, transaction_id, type, amount, tranref, debit_account, credit_account, created_at, idempotency_key, legs, reversal_of, reversed_by, reversed_at, reversal_reason, seq, prev_hash, hash, narrative, metadata, clearing_of, matched_by, matched_at`
+	pgSnapshotColumns = `id, account_id, balance, as_of, created_at`
+	pgAuditColumns    = `id, action, actor_id, actor_name, actor_role, entity_id, reason, before, after, at`
+	pgOutboxColumns   = `id, event, journal_id, payload, created_at, attempts, next_attempt_at, delivered, delivered_at, last_error`
+	pgScheduleColumns = `id, type, debit_account, credit_account, amount, tranref, narrative, metadata, execute_at, status, attempts, next_attempt_at, last_error, journal_id, created_at, settled_at`
+)
+
+// PostgresRepository stores the ledger in PostgreSQL through database/sql. Open db with
+// the driver of your choice (pgx's stdlib or lib/pq) and call CreateSchema once.
+type PostgresRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresRepository(db *sql.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+// CreateSchema creates the ledger tables and indexes that do not exist yet
+func (r *PostgresRepository) CreateSchema(ctx context.Context) error {
+	for _, stmt := range postgresSchema {
+		if _, err := r.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("create schema: %w", err)
+		}
+	}
+	return nil
+}
+
+type pgTxKey struct{}
+
+// pgQuerier is the part of *sql.DB and *sql.Tx the repository uses
+type pgQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// conn returns the transaction of ctx, or the database outside of RunInTransaction
+func (r *PostgresRepository) conn(ctx context.Context) pgQuerier {
+	if tx, ok := ctx.Value(pgTxKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return r.db
+}
+
+// RunInTransaction runs fn in a transaction carried by the context it receives. Called
+// within a transaction, fn joins it.
+func (r *PostgresRepository) RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(pgTxKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(context.WithValue(ctx, pgTxKey{}, tx)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// --------------------------
+//  Accounts
+// --------------------------
+
+func (r *PostgresRepository) InsertAccount(ctx context.Context, acc *Account) error {
+	var overdraftLimit any
+	if acc.OverdraftLimit != "" {
+		overdraftLimit = acc.OverdraftLimit
+	}
+	_, err := r.conn(ctx).ExecContext(ctx,
+		`INSERT INTO accounts (`+pgAccountColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		acc.ID.Hex(), string(acc.Type), acc.GetBalance().String(), acc.GetOpeningBalance().String(), acc.Name,
+		acc.CreatedAt, acc.AllowNegative, overdraftLimit, nullString(string(acc.Status)), nullString(acc.StatusReason), acc.StatusChangedAt,
+		nullString(acc.Ref),
+	)
+	if err != nil && acc.Ref != "" && isUniqueViolation(err) {
+		return fmt.Errorf("%w: %s", ErrDuplicateAccountRef, acc.Ref)
+	}
+	return err
+}
+
+func (r *PostgresRepository) GetAccount(ctx context.Context, accountID primitive.ObjectID) (*Account, error) {
+	row := r.conn(ctx).QueryRowContext(ctx, `SELECT `+pgAccountColumns+` FROM accounts WHERE id = $1`, accountID.Hex())
+	acc, err := scanAccount(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, accountID.Hex())
+	}
+	return acc, err
+}
+
+func (r *PostgresRepository) FindAccountByName(ctx context.Context, name string) (*Account, error) {
+	row := r.conn(ctx).QueryRowContext(ctx,
+		`SELECT `+pgAccountColumns+` FROM accounts WHERE name = $1 ORDER BY created_at, id LIMIT 1`, name)
+	acc, err := scanAccount(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, name)
+	}
+	return acc, err
+}
+
+func (r *PostgresRepository) FindAccountByRef(ctx context.Context, ref string) (*Account, error) {
+	row := r.conn(ctx).QueryRowContext(ctx, `SELECT `+pgAccountColumns+` FROM accounts WHERE ref = $1`, ref)
+	acc, err := scanAccount(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: ref %s", ErrAccountNotFound, ref)
+	}
+	return acc, err
+}
+
+func (r *PostgresRepository) ListAccounts(ctx context.Context, filter AccountFilter, page Pagination) ([]Account, int64, error) {
+	var conds []string
+	var args []any
+	add := func(cond string, v any) {
+		args = append(args, v)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.Type != "" {
+		add("type = $%d", string(filter.Type))
+	}
+	if filter.NameRegex != "" {
+		add("name ~* $%d", filter.NameRegex)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		add("created_at >= $%d", filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		add("created_at < $%d", filter.CreatedBefore)
+	}
+	if !filter.IncludeArchived {
+		add("status IS DISTINCT FROM $%d", string(AccountArchived))
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int64
+	if err := r.conn(ctx).QueryRowContext(ctx, `SELECT count(*) FROM accounts`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	args = append(args, page.Limit, page.Skip)
+	accounts, err := r.queryAccounts(ctx,
+		fmt.Sprintf(`SELECT %s FROM accounts%s ORDER BY created_at, id LIMIT $%d OFFSET $%d`, pgAccountColumns, where, len(args)-1, len(args)),
+		args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return accounts, total, nil
+}
+
+func (r *PostgresRepository) AccountsAsOf(ctx context.Context, t time.Time) ([]Account, error) {
+	if t.IsZero() {
+		return r.queryAccounts(ctx, `SELECT `+pgAccountColumns+` FROM accounts ORDER BY type, name, id`)
+	}
+	return r.queryAccounts(ctx, `SELECT `+pgAccountColumns+` FROM accounts WHERE created_at <= $1 ORDER BY type, name, id`, t)
+}
+
+func (r *PostgresRepository) queryAccounts(ctx context.Context, query string, args ...any) ([]Account, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []Account{}
+	for rows.Next() {
+		acc, err := scanAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, *acc)
+	}
+	return accounts, rows.Err()
+}
+
+func (r *PostgresRepository) SetOverdraftPolicy(ctx context.Context, accountID primitive.ObjectID, allowNegative bool, limit decimal.Decimal) error {
+	var overdraftLimit any
+	if limit.IsPositive() {
+		overdraftLimit = limit.String()
+	}
+	res, err := r.conn(ctx).ExecContext(ctx,
+		`UPDATE accounts SET allow_negative = $2, overdraft_limit = $3 WHERE id = $1`,
+		accountID.Hex(), allowNegative, overdraftLimit)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: %s", ErrAccountNotFound, accountID.Hex())
+	}
+	return nil
+}
+
+func (r *PostgresRepository) IncrementBalance(ctx context.Context, accountID primitive.ObjectID, delta decimal.Decimal, floor *decimal.Decimal) (bool, error) {
+	var min any
+	if floor != nil {
+		min = floor.String()
+	}
+	res, err := r.conn(ctx).ExecContext(ctx,
+		`UPDATE accounts SET balance = balance + $2::numeric
+		WHERE id = $1 AND (status IS NULL OR status = $4) AND ($3::numeric IS NULL OR balance >= $3::numeric)`,
+		accountID.Hex(), delta.String(), min, string(AccountActive))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *PostgresRepository) SetAccountStatus(ctx context.Context, accountID primitive.ObjectID, status AccountStatus, reason string, at time.Time) (bool, error) {
+	res, err := r.conn(ctx).ExecContext(ctx,
+		`UPDATE accounts SET status = $2, status_reason = $3, status_changed_at = $4
+		WHERE id = $1 AND ($2 <> $5 OR balance = 0)`,
+		accountID.Hex(), string(status), nullString(reason), at, string(AccountClosed))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if n > 0 {
+		return true, nil
+	}
+	if _, err := r.GetAccount(ctx, accountID); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+// pgScanner is a *sql.Row or *sql.Rows
+type pgScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAccount(row pgScanner) (*Account, error) {
+	var (
+		acc                     Account
+		id, accType             string
+		balance, openingBalance string
+		allowNegative           sql.NullBool
+		overdraftLimit          sql.NullString
+		status, statusReason    sql.NullString
+		statusChangedAt         sql.NullTime
+		ref                     sql.NullString
+	)
+	if err := row.Scan(&id, &accType, &balance, &openingBalance, &acc.Name, &acc.CreatedAt, &allowNegative, &overdraftLimit,
+		&status, &statusReason, &statusChangedAt, &ref); err != nil {
+		return nil, err
+	}
+	var err error
+	if acc.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return nil, err
+	}
+	acc.Type = AccountType(accType)
+	b, err := decimal.NewFromString(balance)
+	if err != nil {
+		return nil, fmt.Errorf("account %s balance: %w", id, err)
+	}
+	if err := acc.SetBalance(b); err != nil {
+		return nil, err
+	}
+	acc.OpeningBalance = normalizeDecimal(openingBalance)
+	if allowNegative.Valid {
+		acc.AllowNegative = &allowNegative.Bool
+	}
+	if overdraftLimit.Valid {
+		acc.OverdraftLimit = normalizeDecimal(overdraftLimit.String)
+	}
+	acc.Status = AccountStatus(status.String)
+	acc.StatusReason = statusReason.String
+	if statusChangedAt.Valid {
+		acc.StatusChangedAt = &statusChangedAt.Time
+	}
+	acc.Ref = ref.String
+	return &acc, nil
+}
+
+// normalizeDecimal drops the trailing zeros NUMERIC columns keep, matching the decimal
+// strings the Mongo repository stores
+func normalizeDecimal(s string) string {
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return s
+	}
+	return d.String()
+}
+
+// --------------------------
+//  Journals
+// --------------------------
+
+// pgLeg is the JSONB form of a JournalLeg
+type pgLeg struct {
+	AccountID string         `json:"account_id"`
+	Direction EntryDirection `json:"direction,omitempty"`
+	Amount    string         `json:"amount,omitempty"`
+}
+
+func (r *PostgresRepository) InsertJournal(ctx context.Context, entry *JournalEntry) error {
+	var legs any
+	if len(entry.Legs) > 0 {
+		pgLegs := make([]pgLeg, len(entry.Legs))
+		for i, leg := range entry.Legs {
+			pgLegs[i] = pgLeg{AccountID: leg.AccountID.Hex(), Direction: leg.Direction, Amount: leg.Amount}
+		}
+		data, err := json.Marshal(pgLegs)
+		if err != nil {
+			return err
+		}
+		legs = string(data)
+	}
+	var metadata any
+	if len(entry.Metadata) > 0 {
+		data, err := json.Marshal(entry.Metadata)
+		if err != nil {
+			return err
+		}
+		metadata = string(data)
+	}
+	_, err := r.conn(ctx).ExecContext(ctx,
+		`INSERT INTO journals (`+pgJournalColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10::jsonb, $11, $12, $13, $14, $15, $16, $17, $18, $19::jsonb, $20, $21, $22)`,
+		entry.ID.Hex(), nullID(entry.TransactionID), string(entry.Type), entry.Amount, entry.TranRef,
+		nullID(entry.DebitAccount), nullID(entry.CreditAccount), entry.CreatedAt, nullString(entry.IdempotencyKey), legs,
+		nullIDPtr(entry.ReversalOf), nullIDPtr(entry.ReversedBy), entry.ReversedAt, nullString(entry.ReversalReason),
+		nullSeq(entry.Seq), nullString(entry.PrevHash), nullString(entry.Hash), nullString(entry.Narrative), metadata,
+		nullIDPtr(entry.ClearingOf), nullIDPtr(entry.MatchedBy), entry.MatchedAt,
+	)
+	if err != nil && entry.IdempotencyKey != "" && isUniqueViolation(err) {
+		return fmt.Errorf("%w: %v", ErrDuplicateKey, err)
+	}
+	return err
+}
+
+func (r *PostgresRepository) GetJournal(ctx context.Context, journalID primitive.ObjectID) (*JournalEntry, error) {
+	row := r.conn(ctx).QueryRowContext(ctx, `SELECT `+pgJournalColumns+` FROM journals WHERE id = $1`, journalID.Hex())
+	entry, err := scanJournal(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrJournalNotFound, journalID.Hex())
+	}
+	return entry, err
+}
+
+func (r *PostgresRepository) FindJournalByIdempotencyKey(ctx context.Context, key string) (*JournalEntry, error) {
+	row := r.conn(ctx).QueryRowContext(ctx, `SELECT `+pgJournalColumns+` FROM journals WHERE idempotency_key = $1`, key)
+	entry, err := scanJournal(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return entry, err
+}
+
+// EnsureIndexes creates the schema, indexes included, see CreateSchema
+func (r *PostgresRepository) EnsureIndexes(ctx context.Context) error {
+	return r.CreateSchema(ctx)
+}
+
+func (r *PostgresRepository) EnsureIdempotencyIndex(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx,
+		`CREATE UNIQUE INDEX IF NOT EXISTS `+idempotencyIndex+` ON journals (idempotency_key) WHERE idempotency_key IS NOT NULL`)
+	return err
+}
+
+func (r *PostgresRepository) MarkReversed(ctx context.Context, journalID, group, reversedBy primitive.ObjectID, at time.Time, reason string) (bool, error) {
+	res, err := r.conn(ctx).ExecContext(ctx,
+		`UPDATE journals SET transaction_id = $2, reversed_by = $3, reversed_at = $4, reversal_reason = $5
+		WHERE id = $1 AND reversed_by IS NULL AND matched_by IS NULL`,
+		journalID.Hex(), group.Hex(), reversedBy.Hex(), at, reason)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *PostgresRepository) MarkMatched(ctx context.Context, receiptID, group, matchedBy primitive.ObjectID, at time.Time) (bool, error) {
+	res, err := r.conn(ctx).ExecContext(ctx,
+		`UPDATE journals SET transaction_id = $2, matched_by = $3, matched_at = $4
+		WHERE id = $1 AND matched_by IS NULL AND reversed_by IS NULL`,
+		receiptID.Hex(), group.Hex(), matchedBy.Hex(), at)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *PostgresRepository) UnmarkMatched(ctx context.Context, receiptID, matchedBy primitive.ObjectID) (bool, error) {
+	res, err := r.conn(ctx).ExecContext(ctx,
+		`UPDATE journals SET matched_by = NULL, matched_at = NULL WHERE id = $1 AND matched_by = $2`,
+		receiptID.Hex(), matchedBy.Hex())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *PostgresRepository) ListJournals(ctx context.Context, limit, skip int64) ([]JournalEntry, error) {
+	return r.queryJournals(ctx, `SELECT `+pgJournalColumns+` FROM journals ORDER BY created_at DESC, id DESC LIMIT $1 OFFSET $2`, limit, skip)
+}
+
+func (r *PostgresRepository) JournalsByRef(ctx context.Context, tranRef string) ([]JournalEntry, error) {
+	return r.queryJournals(ctx, `SELECT `+pgJournalColumns+` FROM journals WHERE tranref = $1 ORDER BY created_at, id`, tranRef)
+}
+
+func (r *PostgresRepository) QueryJournals(ctx context.Context, filter JournalFilter, sort JournalSort, page Pagination) ([]JournalEntry, int64, error) {
+	if err := filter.validate(); err != nil {
+		return nil, 0, err
+	}
+	table := pgJournalTable(filter)
+	where, args := pgJournalFilterWhere(filter)
+	var total int64
+	if err := r.conn(ctx).QueryRowContext(ctx, `SELECT count(*) FROM `+table+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	args = append(args, page.Limit, page.Skip)
+	entries, err := r.queryJournals(ctx,
+		fmt.Sprintf(`SELECT %s FROM %s%s ORDER BY %s LIMIT $%d OFFSET $%d`, pgJournalColumns, table, where, pgJournalOrder(sort), len(args)-1, len(args)),
+		args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+func (r *PostgresRepository) StreamJournals(ctx context.Context, filter JournalFilter, sort JournalSort, fn func(*JournalEntry) error) error {
+	if err := filter.validate(); err != nil {
+		return err
+	}
+	where, args := pgJournalFilterWhere(filter)
+	rows, err := r.conn(ctx).QueryContext(ctx, `SELECT `+pgJournalColumns+` FROM `+pgJournalTable(filter)+where+` ORDER BY `+pgJournalOrder(sort), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry, err := scanJournal(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// pgJournalTable returns the table filter searches, the journal or its archive
+func pgJournalTable(filter JournalFilter) string {
+	if filter.Archived {
+		return "journals_archive"
+	}
+	return "journals"
+}
+
+// pgLikeEscaper escapes the LIKE wildcards of a literal substring
+var pgLikeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// pgJournalFilterWhere returns the WHERE clause matching filter, empty when it matches everything
+func pgJournalFilterWhere(f JournalFilter) (string, []any) {
+	var conds []string
+	var args []any
+	add := func(cond string, v any) {
+		args = append(args, v)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if !f.AccountID.IsZero() {
+		legs, _ := json.Marshal([]pgLeg{{AccountID: f.AccountID.Hex()}})
+		args = append(args, f.AccountID.Hex(), string(legs))
+		conds = append(conds, fmt.Sprintf("(debit_account = $%[1]d OR credit_account = $%[1]d OR legs @> $%[2]d::jsonb)", len(args)-1, len(args)))
+	}
+	if f.Type != "" {
+		add("type = $%d", string(f.Type))
+	}
+	if f.TranRef != "" {
+		add("tranref = $%d", f.TranRef)
+	}
+	if f.Narrative != "" {
+		add(`narrative ILIKE $%d`, "%"+pgLikeEscaper.Replace(f.Narrative)+"%")
+	}
+	if len(f.Metadata) > 0 {
+		metadata, _ := json.Marshal(f.Metadata)
+		add("metadata @> $%d::jsonb", string(metadata))
+	}
+	if !f.MinAmount.IsZero() {
+		add("amount >= $%d::numeric", f.MinAmount.String())
+	}
+	if !f.MaxAmount.IsZero() {
+		add("amount <= $%d::numeric", f.MaxAmount.String())
+	}
+	if !f.From.IsZero() {
+		add("created_at >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		add("created_at <= $%d", f.To)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// pgJournalOrder returns the ORDER BY list of sort
+func pgJournalOrder(sort JournalSort) string {
+	column, dir := "created_at", "DESC"
+	if sort.Field == SortByAmount {
+		column = "amount"
+	}
+	if sort.Ascending {
+		dir = "ASC"
+	}
+	return column + " " + dir + ", id " + dir
+}
+
+func (r *PostgresRepository) AccountJournals(ctx context.Context, q AccountJournalQuery) ([]JournalEntry, error) {
+	where, args := pgAccountJournalsWhere(q)
+	query := `SELECT ` + pgJournalColumns + ` FROM journals_archive WHERE ` + where +
+		` UNION ALL SELECT ` + pgJournalColumns + ` FROM journals WHERE ` + where + ` ORDER BY created_at, id`
+	if q.Limit > 0 {
+		query += ` LIMIT ` + strconv.FormatInt(q.Limit, 10)
+	}
+	return r.queryJournals(ctx, query, args...)
+}
+
+func (r *PostgresRepository) CountAccountJournals(ctx context.Context, q AccountJournalQuery) (int64, error) {
+	where, args := pgAccountJournalsWhere(q)
+	var n int64
+	err := r.conn(ctx).QueryRowContext(ctx,
+		`SELECT (SELECT count(*) FROM journals_archive WHERE `+where+`) + (SELECT count(*) FROM journals WHERE `+where+`)`, args...).Scan(&n)
+	return n, err
+}
+
+func (r *PostgresRepository) ArchiveJournals(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	res, err := r.conn(ctx).ExecContext(ctx,
+		`WITH moved AS (
+			DELETE FROM journals WHERE id IN (
+				SELECT id FROM journals WHERE created_at < $1 ORDER BY created_at, id LIMIT $2
+			) RETURNING `+pgJournalColumns+`
+		)
+		INSERT INTO journals_archive (`+pgJournalColumns+`) SELECT `+pgJournalColumns+` FROM moved`,
+		cutoff, limit)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// pgAccountJournalsWhere returns the condition matching the entries of q
+func pgAccountJournalsWhere(q AccountJournalQuery) (string, []any) {
+	legs, _ := json.Marshal([]pgLeg{{AccountID: q.AccountID.Hex()}})
+	args := []any{q.AccountID.Hex(), string(legs)}
+	where := `(debit_account = $1 OR credit_account = $1 OR legs @> $2::jsonb)`
+	add := func(op string, t time.Time) {
+		if t.IsZero() {
+			return
+		}
+		args = append(args, t)
+		where += fmt.Sprintf(" AND created_at %s $%d", op, len(args))
+	}
+	add(">", q.After)
+	add(">=", q.From)
+	add("<=", q.To)
+	add("<", q.Before)
+	return where, args
+}
+
+func (r *PostgresRepository) queryJournals(ctx context.Context, query string, args ...any) ([]JournalEntry, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []JournalEntry{}
+	for rows.Next() {
+		entry, err := scanJournal(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+	return entries, rows.Err()
+}
+
+func scanJournal(row pgScanner) (*JournalEntry, error) {
+	var (
+		entry                                                JournalEntry
+		id, txType, amount                                   string
+		transactionID, debit, credit, reversalOf, reversedBy sql.NullString
+		idempotencyKey, legs, reversalReason                 sql.NullString
+		reversedAt                                           sql.NullTime
+		seq                                                  sql.NullInt64
+		prevHash, hash, narrative, metadata                  sql.NullString
+		clearingOf, matchedBy                                sql.NullString
+		matchedAt                                            sql.NullTime
+	)
+	err := row.Scan(&id, &transactionID, &txType, &amount, &entry.TranRef, &debit, &credit, &entry.CreatedAt,
+		&idempotencyKey, &legs, &reversalOf, &reversedBy, &reversedAt, &reversalReason, &seq, &prevHash, &hash, &narrative, &metadata,
+		&clearingOf, &matchedBy, &matchedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []struct {
+		hex sql.NullString
+		dst *primitive.ObjectID
+	}{
+		{sql.NullString{String: id, Valid: true}, &entry.ID},
+		{transactionID, &entry.TransactionID},
+		{debit, &entry.DebitAccount},
+		{credit, &entry.CreditAccount},
+	}
+	for _, f := range ids {
+		if !f.hex.Valid {
+			continue
+		}
+		if *f.dst, err = primitive.ObjectIDFromHex(f.hex.String); err != nil {
+			return nil, err
+		}
+	}
+	if entry.ReversalOf, err = parseNullID(reversalOf); err != nil {
+		return nil, err
+	}
+	if entry.ReversedBy, err = parseNullID(reversedBy); err != nil {
+		return nil, err
+	}
+	if reversedAt.Valid {
+		entry.ReversedAt = &reversedAt.Time
+	}
+	if entry.ClearingOf, err = parseNullID(clearingOf); err != nil {
+		return nil, err
+	}
+	if entry.MatchedBy, err = parseNullID(matchedBy); err != nil {
+		return nil, err
+	}
+	if matchedAt.Valid {
+		entry.MatchedAt = &matchedAt.Time
+	}
+	entry.Type = TransactionType(txType)
+	entry.Amount = normalizeDecimal(amount)
+	entry.IdempotencyKey = idempotencyKey.String
+	entry.ReversalReason = reversalReason.String
+	entry.Seq = seq.Int64
+	entry.PrevHash = prevHash.String
+	entry.Hash = hash.String
+	entry.Narrative = narrative.String
+	if metadata.Valid {
+		if err := json.Unmarshal([]byte(metadata.String), &entry.Metadata); err != nil {
+			return nil, fmt.Errorf("journal %s metadata: %w", id, err)
+		}
+	}
+
+	if legs.Valid {
+		var pgLegs []pgLeg
+		if err := json.Unmarshal([]byte(legs.String), &pgLegs); err != nil {
+			return nil, fmt.Errorf("journal %s legs: %w", id, err)
+		}
+		for _, l := range pgLegs {
+			accountID, err := primitive.ObjectIDFromHex(l.AccountID)
+			if err != nil {
+				return nil, err
+			}
+			entry.Legs = append(entry.Legs, JournalLeg{AccountID: accountID, Direction: l.Direction, Amount: l.Amount})
+		}
+	}
+	return &entry, nil
+}
+
+// --------------------------
+//  Dashboards
+// --------------------------
+
+func (r *PostgresRepository) BalancesByType(ctx context.Context) ([]TypeBalance, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx,
+		`SELECT type, count(*), COALESCE(sum(balance), 0) FROM accounts GROUP BY type ORDER BY type`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []TypeBalance{}
+	for rows.Next() {
+		var tb TypeBalance
+		var balance string
+		if err := rows.Scan(&tb.AccountType, &tb.AccountCount, &balance); err != nil {
+			return nil, err
+		}
+		if tb.Balance, err = decimal.NewFromString(balance); err != nil {
+			return nil, err
+		}
+		out = append(out, tb)
+	}
+	return out, rows.Err()
+}
+
+func (r *PostgresRepository) DailyPostingTotals(ctx context.Context, from, to time.Time) ([]DailyPostingTotal, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx,
+		`SELECT date_trunc('day', created_at AT TIME ZONE 'UTC') AS day, type, count(*), sum(amount)
+		FROM journals WHERE created_at >= $1 AND created_at <= $2
+		GROUP BY day, type ORDER BY day, type`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []DailyPostingTotal{}
+	for rows.Next() {
+		var total DailyPostingTotal
+		var amount string
+		if err := rows.Scan(&total.Day, &total.Type, &total.Count, &amount); err != nil {
+			return nil, err
+		}
+		if total.Amount, err = decimal.NewFromString(amount); err != nil {
+			return nil, err
+		}
+		total.Day = total.Day.UTC()
+		out = append(out, total)
+	}
+	return out, rows.Err()
+}
+
+// TopAccountsByVolume unions the two sides of double entries with the legs of compound
+// entries and sums them per account
+func (r *PostgresRepository) TopAccountsByVolume(ctx context.Context, from, to time.Time, limit int) ([]AccountVolume, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx,
+		`WITH period AS (
+			SELECT * FROM journals WHERE created_at >= $1 AND created_at <= $2
+		), legs AS (
+			SELECT debit_account AS account_id, 'DR' AS direction, amount FROM period WHERE legs IS NULL
+			UNION ALL
+			SELECT credit_account, 'CR', amount FROM period WHERE legs IS NULL
+			UNION ALL
+			SELECT l->>'account_id', l->>'direction', (l->>'amount')::numeric
+			FROM period, jsonb_array_elements(period.legs) AS l WHERE period.legs IS NOT NULL
+		)
+		SELECT legs.account_id, COALESCE(a.type, ''), COALESCE(a.name, ''), count(*),
+			COALESCE(sum(amount) FILTER (WHERE direction = 'DR'), 0),
+			COALESCE(sum(amount) FILTER (WHERE direction = 'CR'), 0),
+			sum(amount) AS volume
+		FROM legs LEFT JOIN accounts a ON a.id = legs.account_id
+		GROUP BY legs.account_id, a.type, a.name
+		ORDER BY volume DESC, legs.account_id
+		LIMIT $3`, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []AccountVolume{}
+	for rows.Next() {
+		var v AccountVolume
+		var id string
+		var amounts [3]string
+		if err := rows.Scan(&id, &v.AccountType, &v.AccountName, &v.Legs, &amounts[0], &amounts[1], &amounts[2]); err != nil {
+			return nil, err
+		}
+		if v.AccountID, err = primitive.ObjectIDFromHex(strings.TrimSpace(id)); err != nil {
+			return nil, err
+		}
+		for i, dst := range []*decimal.Decimal{&v.Debits, &v.Credits, &v.Volume} {
+			if *dst, err = decimal.NewFromString(amounts[i]); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, v)
+	}
+	return out, rows.Err()
+}
+
+// --------------------------
+//  Hash Chain
+// --------------------------
+
+// ChainHead locks the head row until the transaction ends, serializing postings
+func (r *PostgresRepository) ChainHead(ctx context.Context) (ChainLink, error) {
+	var head ChainLink
+	err := r.conn(ctx).QueryRowContext(ctx, `SELECT seq, hash FROM ledger_chain WHERE id = 1 FOR UPDATE`).Scan(&head.Seq, &head.Hash)
+	if err == sql.ErrNoRows {
+		return ChainLink{}, nil
+	}
+	return head, err
+}
+
+func (r *PostgresRepository) AdvanceChain(ctx context.Context, prev, next ChainLink) (bool, error) {
+	res, err := r.conn(ctx).ExecContext(ctx,
+		`INSERT INTO ledger_chain (id, seq, hash) VALUES (1, $1, $2)
+		ON CONFLICT (id) DO UPDATE SET seq = EXCLUDED.seq, hash = EXCLUDED.hash WHERE ledger_chain.seq = $3`,
+		next.Seq, next.Hash, prev.Seq)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *PostgresRepository) ChainSeqRange(ctx context.Context, from, to time.Time) (int64, int64, error) {
+	conds := []string{"seq IS NOT NULL"}
+	var args []any
+	if !from.IsZero() {
+		args = append(args, from)
+		conds = append(conds, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if !to.IsZero() {
+		args = append(args, to)
+		conds = append(conds, fmt.Sprintf("created_at <= $%d", len(args)))
+	}
+	where := strings.Join(conds, " AND ")
+	var first, last sql.NullInt64
+	err := r.conn(ctx).QueryRowContext(ctx,
+		`SELECT min(seq), max(seq) FROM (
+			SELECT seq FROM journals_archive WHERE `+where+` UNION ALL SELECT seq FROM journals WHERE `+where+`
+		) chain`, args...).Scan(&first, &last)
+	return first.Int64, last.Int64, err
+}
+
+func (r *PostgresRepository) StreamChain(ctx context.Context, first, last int64, fn func(*JournalEntry) error) error {
+	rows, err := r.conn(ctx).QueryContext(ctx,
+		`SELECT `+pgJournalColumns+` FROM journals_archive WHERE seq BETWEEN $1 AND $2
+		UNION ALL SELECT `+pgJournalColumns+` FROM journals WHERE seq BETWEEN $1 AND $2 ORDER BY seq`, first, last)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry, err := scanJournal(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// --------------------------
+//  Snapshots
+// --------------------------
+
+func (r *PostgresRepository) LatestSnapshot(ctx context.Context, accountID primitive.ObjectID, t time.Time, inclusive bool) (*BalanceSnapshot, error) {
+	op := "<"
+	if inclusive {
+		op = "<="
+	}
+	row := r.conn(ctx).QueryRowContext(ctx,
+		`SELECT `+pgSnapshotColumns+` FROM balance_snapshots WHERE account_id = $1 AND as_of `+op+` $2 ORDER BY as_of DESC LIMIT 1`,
+		accountID.Hex(), t)
+	snap, err := scanSnapshot(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return snap, err
+}
+
+func (r *PostgresRepository) UpsertSnapshot(ctx context.Context, snap *BalanceSnapshot) (*BalanceSnapshot, error) {
+	row := r.conn(ctx).QueryRowContext(ctx,
+		`INSERT INTO balance_snapshots (`+pgSnapshotColumns+`) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (account_id, as_of) DO UPDATE SET balance = EXCLUDED.balance, created_at = EXCLUDED.created_at
+		RETURNING `+pgSnapshotColumns,
+		snap.ID.Hex(), snap.AccountID.Hex(), snap.Balance, snap.AsOf, snap.CreatedAt)
+	return scanSnapshot(row)
+}
+
+func (r *PostgresRepository) ListSnapshots(ctx context.Context, accountID primitive.ObjectID, from, to time.Time) ([]BalanceSnapshot, error) {
+	query := `SELECT ` + pgSnapshotColumns + ` FROM balance_snapshots WHERE account_id = $1`
+	args := []any{accountID.Hex()}
+	if !from.IsZero() {
+		args = append(args, from)
+		query += fmt.Sprintf(" AND as_of >= $%d", len(args))
+	}
+	if !to.IsZero() {
+		args = append(args, to)
+		query += fmt.Sprintf(" AND as_of <= $%d", len(args))
+	}
+	rows, err := r.conn(ctx).QueryContext(ctx, query+` ORDER BY as_of`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snaps := []BalanceSnapshot{}
+	for rows.Next() {
+		snap, err := scanSnapshot(rows)
+		if err != nil {
+			return nil, err
+		}
+		snaps = append(snaps, *snap)
+	}
+	return snaps, rows.Err()
+}
+
+func scanSnapshot(row pgScanner) (*BalanceSnapshot, error) {
+	var snap BalanceSnapshot
+	var id, accountID string
+	if err := row.Scan(&id, &accountID, &snap.Balance, &snap.AsOf, &snap.CreatedAt); err != nil {
+		return nil, err
+	}
+	var err error
+	if snap.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return nil, err
+	}
+	if snap.AccountID, err = primitive.ObjectIDFromHex(accountID); err != nil {
+		return nil, err
+	}
+	snap.Balance = normalizeDecimal(snap.Balance)
+	return &snap, nil
+}
+
+// --------------------------
+//  Audit Log
+// --------------------------
+
+func (r *PostgresRepository) InsertAudit(ctx context.Context, rec *AuditRecord) error {
+	before, err := nullJSON(rec.Before)
+	if err != nil {
+		return err
+	}
+	after, err := nullJSON(rec.After)
+	if err != nil {
+		return err
+	}
+	_, err = r.conn(ctx).ExecContext(ctx,
+		`INSERT INTO audit_log (`+pgAuditColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8::jsonb, $9::jsonb, $10)`,
+		rec.ID.Hex(), string(rec.Action), rec.Actor.ID, nullString(rec.Actor.Name), nullString(rec.Actor.Role),
+		rec.EntityID, nullString(rec.Reason), before, after, rec.At,
+	)
+	return err
+}
+
+func (r *PostgresRepository) ListAudit(ctx context.Context, filter AuditFilter, page Pagination) ([]AuditRecord, int64, error) {
+	if err := filter.validate(); err != nil {
+		return nil, 0, err
+	}
+	where, args := pgAuditFilterWhere(filter)
+
+	var total int64
+	if err := r.conn(ctx).QueryRowContext(ctx, `SELECT count(*) FROM audit_log`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	args = append(args, page.Limit, page.Skip)
+	rows, err := r.conn(ctx).QueryContext(ctx,
+		fmt.Sprintf(`SELECT %s FROM audit_log%s ORDER BY at DESC, id DESC LIMIT $%d OFFSET $%d`, pgAuditColumns, where, len(args)-1, len(args)),
+		args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	records := []AuditRecord{}
+	for rows.Next() {
+		rec, err := scanAudit(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		records = append(records, *rec)
+	}
+	return records, total, rows.Err()
+}
+
+// pgAuditFilterWhere returns the WHERE clause of filter and its arguments, empty when it
+// matches every record
+func pgAuditFilterWhere(filter AuditFilter) (string, []any) {
+	var conds []string
+	var args []any
+	add := func(cond string, v any) {
+		args = append(args, v)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.EntityID != "" {
+		add("entity_id = $%d", filter.EntityID)
+	}
+	if filter.ActorID != "" {
+		add("actor_id = $%d", filter.ActorID)
+	}
+	if filter.Action != "" {
+		add("action = $%d", string(filter.Action))
+	}
+	if !filter.From.IsZero() {
+		add("at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		add("at <= $%d", filter.To)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+func scanAudit(row pgScanner) (*AuditRecord, error) {
+	var (
+		rec                          AuditRecord
+		id, action                   string
+		actorName, actorRole, reason sql.NullString
+		before, after                sql.NullString
+	)
+	err := row.Scan(&id, &action, &rec.Actor.ID, &actorName, &actorRole, &rec.EntityID, &reason, &before, &after, &rec.At)
+	if err != nil {
+		return nil, err
+	}
+	if rec.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return nil, err
+	}
+	rec.Action = AuditAction(action)
+	rec.Actor.Name = actorName.String
+	rec.Actor.Role = actorRole.String
+	rec.Reason = reason.String
+	for _, f := range []struct {
+		data sql.NullString
+		dst  *map[string]string
+	}{{before, &rec.Before}, {after, &rec.After}} {
+		if !f.data.Valid {
+			continue
+		}
+		if err := json.Unmarshal([]byte(f.data.String), f.dst); err != nil {
+			return nil, fmt.Errorf("audit record %s: %w", id, err)
+		}
+	}
+	return &rec, nil
+}
+
+// --------------------------
+//  Outbox
+// --------------------------
+
+func (r *PostgresRepository) InsertOutbox(ctx context.Context, msg *OutboxMessage) error {
+	_, err := r.conn(ctx).ExecContext(ctx,
+		`INSERT INTO outbox (`+pgOutboxColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, NULL, NULL, NULL)`,
+		msg.ID.Hex(), msg.Event, msg.JournalID.Hex(), msg.Payload, msg.CreatedAt, msg.Attempts, msg.NextAttemptAt,
+	)
+	return err
+}
+
+// ClaimOutbox locks the due rows with SKIP LOCKED, so concurrent relays claim disjoint
+// messages without waiting for each other
+func (r *PostgresRepository) ClaimOutbox(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]OutboxMessage, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx,
+		`UPDATE outbox SET next_attempt_at = $2 WHERE id IN (
+			SELECT id FROM outbox WHERE delivered_at IS NULL AND next_attempt_at <= $1
+			ORDER BY next_attempt_at, id LIMIT $3 FOR UPDATE SKIP LOCKED
+		) RETURNING `+pgOutboxColumns,
+		now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	msgs := []OutboxMessage{}
+	for rows.Next() {
+		msg, err := scanOutbox(rows)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, *msg)
+	}
+	return msgs, rows.Err()
+}
+
+func (r *PostgresRepository) UpdateOutbox(ctx context.Context, msg *OutboxMessage) error {
+	var delivered any
+	if len(msg.Delivered) > 0 {
+		data, err := json.Marshal(msg.Delivered)
+		if err != nil {
+			return err
+		}
+		delivered = string(data)
+	}
+	res, err := r.conn(ctx).ExecContext(ctx,
+		`UPDATE outbox SET attempts = $2, next_attempt_at = $3, delivered = $4::jsonb,
+		delivered_at = COALESCE($5, delivered_at), last_error = $6 WHERE id = $1`,
+		msg.ID.Hex(), msg.Attempts, msg.NextAttemptAt, delivered, msg.DeliveredAt, nullString(msg.LastError))
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("outbox message %s not found", msg.ID.Hex())
+	}
+	return nil
+}
+
+func scanOutbox(row pgScanner) (*OutboxMessage, error) {
+	var (
+		msg                  OutboxMessage
+		id, journalID        string
+		delivered, lastError sql.NullString
+		deliveredAt          sql.NullTime
+	)
+	err := row.Scan(&id, &msg.Event, &journalID, &msg.Payload, &msg.CreatedAt, &msg.Attempts, &msg.NextAttemptAt,
+		&delivered, &deliveredAt, &lastError)
+	if err != nil {
+		return nil, err
+	}
+	if msg.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return nil, err
+	}
+	if msg.JournalID, err = primitive.ObjectIDFromHex(journalID); err != nil {
+		return nil, err
+	}
+	if delivered.Valid {
+		if err := json.Unmarshal([]byte(delivered.String), &msg.Delivered); err != nil {
+			return nil, fmt.Errorf("outbox message %s: %w", id, err)
+		}
+	}
+	if deliveredAt.Valid {
+		msg.DeliveredAt = &deliveredAt.Time
+	}
+	msg.LastError = lastError.String
+	return &msg, nil
+}
+
+// --------------------------
+//  Scheduled Postings
+// --------------------------
+
+func (r *PostgresRepository) InsertSchedule(ctx context.Context, sp *ScheduledPosting) error {
+	metadata, err := nullJSON(sp.Metadata)
+	if err != nil {
+		return err
+	}
+	_, err = r.conn(ctx).ExecContext(ctx,
+		`INSERT INTO scheduled_postings (`+pgScheduleColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NULL, $14, NULL)`,
+		sp.ID.Hex(), string(sp.Type), sp.DebitAccount.Hex(), sp.CreditAccount.Hex(), sp.Amount, sp.TranRef,
+		nullString(sp.Narrative), metadata, sp.ExecuteAt, string(sp.Status), sp.Attempts, sp.NextAttemptAt,
+		nullString(sp.LastError), sp.CreatedAt,
+	)
+	return err
+}
+
+func (r *PostgresRepository) GetSchedule(ctx context.Context, id primitive.ObjectID) (*ScheduledPosting, error) {
+	row := r.conn(ctx).QueryRowContext(ctx, `SELECT `+pgScheduleColumns+` FROM scheduled_postings WHERE id = $1`, id.Hex())
+	sp, err := scanSchedule(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrScheduleNotFound, id.Hex())
+	}
+	return sp, err
+}
+
+func (r *PostgresRepository) ListSchedules(ctx context.Context, filter ScheduleFilter, page Pagination) ([]ScheduledPosting, int64, error) {
+	if err := filter.validate(); err != nil {
+		return nil, 0, err
+	}
+	where, args := pgScheduleFilterWhere(filter)
+
+	var total int64
+	if err := r.conn(ctx).QueryRowContext(ctx, `SELECT count(*) FROM scheduled_postings`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	args = append(args, page.Limit, page.Skip)
+	rows, err := r.conn(ctx).QueryContext(ctx,
+		fmt.Sprintf(`SELECT %s FROM scheduled_postings%s ORDER BY execute_at, id LIMIT $%d OFFSET $%d`, pgScheduleColumns, where, len(args)-1, len(args)),
+		args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	schedules, err := scanSchedules(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return schedules, total, nil
+}
+
+// pgScheduleFilterWhere returns the WHERE clause of filter and its arguments, empty when
+// it matches every posting
+func pgScheduleFilterWhere(filter ScheduleFilter) (string, []any) {
+	var conds []string
+	var args []any
+	add := func(cond string, v any) {
+		args = append(args, v)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.Status != "" {
+		add("status = $%d", string(filter.Status))
+	}
+	if !filter.AccountID.IsZero() {
+		add("(debit_account = $%[1]d OR credit_account = $%[1]d)", filter.AccountID.Hex())
+	}
+	if !filter.From.IsZero() {
+		add("execute_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		add("execute_at <= $%d", filter.To)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// ClaimSchedules locks the due rows with SKIP LOCKED, so concurrent runners claim
+// disjoint postings without waiting for each other
+func (r *PostgresRepository) ClaimSchedules(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]ScheduledPosting, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx,
+		`UPDATE scheduled_postings SET next_attempt_at = $2 WHERE id IN (
+			SELECT id FROM scheduled_postings WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at, id LIMIT $3 FOR UPDATE SKIP LOCKED
+		) RETURNING `+pgScheduleColumns,
+		now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanSchedules(rows)
+}
+
+func (r *PostgresRepository) UpdateSchedule(ctx context.Context, sp *ScheduledPosting) (bool, error) {
+	var journalID any
+	if sp.JournalID != nil {
+		journalID = sp.JournalID.Hex()
+	}
+	res, err := r.conn(ctx).ExecContext(ctx,
+		`UPDATE scheduled_postings SET status = $2, attempts = $3, next_attempt_at = $4, last_error = $5,
+		journal_id = COALESCE($6, journal_id), settled_at = COALESCE($7, settled_at)
+		WHERE id = $1 AND status = 'pending'`,
+		sp.ID.Hex(), string(sp.Status), sp.Attempts, sp.NextAttemptAt, nullString(sp.LastError), journalID, sp.SettledAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// scanSchedules reads and closes rows
+func scanSchedules(rows *sql.Rows) ([]ScheduledPosting, error) {
+	defer rows.Close()
+	schedules := []ScheduledPosting{}
+	for rows.Next() {
+		sp, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, *sp)
+	}
+	return schedules, rows.Err()
+}
+
+func scanSchedule(row pgScanner) (*ScheduledPosting, error) {
+	var (
+		sp                                  ScheduledPosting
+		id, txType, debit, credit, status   string
+		amount                              string
+		narrative, metadata, lastError, jid sql.NullString
+		settledAt                           sql.NullTime
+	)
+	err := row.Scan(&id, &txType, &debit, &credit, &amount, &sp.TranRef, &narrative, &metadata, &sp.ExecuteAt,
+		&status, &sp.Attempts, &sp.NextAttemptAt, &lastError, &jid, &sp.CreatedAt, &settledAt)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range []struct {
+		hex string
+		dst *primitive.ObjectID
+	}{{id, &sp.ID}, {debit, &sp.DebitAccount}, {credit, &sp.CreditAccount}} {
+		if *f.dst, err = primitive.ObjectIDFromHex(f.hex); err != nil {
+			return nil, err
+		}
+	}
+	if sp.JournalID, err = parseNullID(jid); err != nil {
+		return nil, err
+	}
+	if metadata.Valid {
+		if err := json.Unmarshal([]byte(metadata.String), &sp.Metadata); err != nil {
+			return nil, fmt.Errorf("scheduled posting %s metadata: %w", id, err)
+		}
+	}
+	if settledAt.Valid {
+		sp.SettledAt = &settledAt.Time
+	}
+	sp.Type = TransactionType(txType)
+	sp.Amount = normalizeDecimal(amount)
+	sp.Narrative = narrative.String
+	sp.Status = ScheduleStatus(status)
+	sp.LastError = lastError.String
+	return &sp, nil
+}
+
+// --------------------------
+//  Helpers
+// --------------------------
+
+// isUniqueViolation reports whether err is a PostgreSQL unique_violation. Both pgx and
+// lib/pq errors expose the SQLSTATE.
+func isUniqueViolation(err error) bool {
+	var pgErr interface{ SQLState() string }
+	return errors.As(err, &pgErr) && pgErr.SQLState() == "23505"
+}
+
+func nullID(id primitive.ObjectID) any {
+	if id.IsZero() {
+		return nil
+	}
+	return id.Hex()
+}
+
+func nullIDPtr(id *primitive.ObjectID) any {
+	if id == nil {
+		return nil
+	}
+	return nullID(*id)
+}
+
+func parseNullID(s sql.NullString) (*primitive.ObjectID, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+	id, err := primitive.ObjectIDFromHex(s.String)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func nullSeq(seq int64) any {
+	if seq == 0 {
+		return nil
+	}
+	return seq
+}
+
+// nullJSON returns the JSON form of m, nil when it is empty
+func nullJSON(m map[string]string) (any, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
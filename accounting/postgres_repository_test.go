@@ -0,0 +1,120 @@
+package accounting
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeRow scans fixed column values like a *sql.Row
+type fakeRow []any
+
+func (r fakeRow) Scan(dest ...any) error {
+	for i, d := range dest {
+		switch d := d.(type) {
+		case *string:
+			*d = r[i].(string)
+		case *time.Time:
+			*d = r[i].(time.Time)
+		case *sql.NullString:
+			if r[i] == nil {
+				*d = sql.NullString{}
+			} else {
+				*d = sql.NullString{String: r[i].(string), Valid: true}
+			}
+		case *sql.NullBool:
+			if r[i] == nil {
+				*d = sql.NullBool{}
+			} else {
+				*d = sql.NullBool{Bool: r[i].(bool), Valid: true}
+			}
+		case *sql.NullInt64:
+			if r[i] == nil {
+				*d = sql.NullInt64{}
+			} else {
+				*d = sql.NullInt64{Int64: r[i].(int64), Valid: true}
+			}
+		case *sql.NullTime:
+			if r[i] == nil {
+				*d = sql.NullTime{}
+			} else {
+				*d = sql.NullTime{Time: r[i].(time.Time), Valid: true}
+			}
+		}
+	}
+	return nil
+}
+
+func TestPgAccountJournalsWhere(t *testing.T) {
+	id := primitive.NewObjectID()
+	after := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := after.AddDate(0, 1, 0)
+
+	where, args := pgAccountJournalsWhere(AccountJournalQuery{AccountID: id, After: after, To: to})
+	assert.Equal(t, "(debit_account = $1 OR credit_account = $1 OR legs @> $2::jsonb) AND created_at > $3 AND created_at <= $4", where)
+	assert.Equal(t, []any{id.Hex(), `[{"account_id":"` + id.Hex() + `"}]`, after, to}, args)
+}
+
+func TestPgJournalFilterWhere(t *testing.T) {
+	where, args := pgJournalFilterWhere(JournalFilter{})
+	assert.Empty(t, where)
+	assert.Empty(t, args)
+
+	id := primitive.NewObjectID()
+	where, args = pgJournalFilterWhere(JournalFilter{AccountID: id, Type: Fee, MinAmount: decimal.NewFromInt(5), MaxAmount: decimal.NewFromInt(50)})
+	assert.Equal(t, " WHERE (debit_account = $1 OR credit_account = $1 OR legs @> $2::jsonb) AND type = $3 AND amount >= $4::numeric AND amount <= $5::numeric", where)
+	assert.Equal(t, []any{id.Hex(), `[{"account_id":"` + id.Hex() + `"}]`, "Fee", "5", "50"}, args)
+
+	where, args = pgJournalFilterWhere(JournalFilter{Narrative: "100%_paid", Metadata: map[string]string{"bank_ref": "FT123"}})
+	assert.Equal(t, " WHERE narrative ILIKE $1 AND metadata @> $2::jsonb", where)
+	assert.Equal(t, []any{`%100\%\_paid%`, `{"bank_ref":"FT123"}`}, args)
+
+	assert.Equal(t, "created_at DESC, id DESC", pgJournalOrder(JournalSort{}))
+	assert.Equal(t, "amount ASC, id ASC", pgJournalOrder(JournalSort{Field: SortByAmount, Ascending: true}))
+}
+
+func TestScanAccountAndJournal(t *testing.T) {
+	id, other := primitive.NewObjectID(), primitive.NewObjectID()
+	now := time.Now().UTC()
+
+	acc, err := scanAccount(fakeRow{id.Hex(), string(ClientInsurance), "150.5000", "0.00", "client", now, true, "100.00", nil, nil, nil, nil})
+	require.NoError(t, err)
+	assert.Equal(t, id, acc.ID)
+	assert.True(t, acc.GetBalance().Equal(decimal.RequireFromString("150.5")))
+	assert.Equal(t, "0", acc.OpeningBalance)
+	assert.True(t, acc.NegativeAllowed())
+	assert.Equal(t, "100", acc.OverdraftLimit)
+	assert.Equal(t, AccountActive, acc.GetStatus())
+	assert.Empty(t, acc.Ref)
+
+	acc, err = scanAccount(fakeRow{id.Hex(), string(ClientInsurance), "0", "0", "client", now, nil, nil, string(AccountFrozen), "chargeback", now, "CUST-1"})
+	require.NoError(t, err)
+	assert.Equal(t, AccountFrozen, acc.GetStatus())
+	assert.Equal(t, "chargeback", acc.StatusReason)
+	assert.Equal(t, "CUST-1", acc.Ref)
+	require.NotNil(t, acc.StatusChangedAt)
+	assert.ErrorIs(t, acc.checkPostable(), ErrAccountFrozen)
+
+	legs := `[{"account_id":"` + id.Hex() + `","direction":"DR","amount":"10"},{"account_id":"` + other.Hex() + `","direction":"CR","amount":"10"}]`
+	entry, err := scanJournal(fakeRow{id.Hex(), id.Hex(), string(TopUp), "10.00", "REF1", nil, nil, now, "TopUp:REF1", legs, nil, other.Hex(), now, "duplicate", int64(7), "prev", "hash", "bank narrative", `{"bank_ref":"FT123"}`, nil, other.Hex(), now})
+	require.NoError(t, err)
+	assert.Equal(t, "10", entry.Amount)
+	assert.True(t, entry.DebitAccount.IsZero())
+	assert.Equal(t, []JournalLeg{DebitLeg(id, decimal.NewFromInt(10)), CreditLeg(other, decimal.NewFromInt(10))}, entry.Legs)
+	assert.Nil(t, entry.ReversalOf)
+	assert.Nil(t, entry.ClearingOf)
+	require.NotNil(t, entry.MatchedBy)
+	assert.Equal(t, other, *entry.MatchedBy)
+	require.NotNil(t, entry.ReversedBy)
+	assert.Equal(t, other, *entry.ReversedBy)
+	assert.True(t, entry.IsReversed())
+	assert.EqualValues(t, 7, entry.Seq)
+	assert.Equal(t, "hash", entry.Hash)
+	assert.Equal(t, "bank narrative", entry.Narrative)
+	assert.Equal(t, map[string]string{"bank_ref": "FT123"}, entry.Metadata)
+}
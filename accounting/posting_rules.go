@@ -0,0 +1,205 @@
+package accounting
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// --------------------------
+//  Posting Rules
+// --------------------------
+
+// PostingRule declares which account types may be debited and credited by a transaction type.
+type PostingRule struct {
+	Debit  []AccountType
+	Credit []AccountType
+}
+
+// PostingRules maps every transaction type to its posting rule.
+type PostingRules map[TransactionType]PostingRule
+
+// DefaultPostingRules returns the posting rules of the built-in transaction types:
+//
+//	TopUp:                 Dr PaymentGateway            Cr ClientInsurance
+//	PremiumPayment:        Dr ClientInsurance           Cr UnderwriterPremiumPayable
+//	CommissionPayment:     Dr UnderwriterPremiumPayable Cr AgentCommissionEarned
+//	Refund:                Dr ClientInsurance           Cr PaymentGateway
+//	Fee:                   Dr ClientInsurance           Cr PlatformFeeIncome
+//	ClaimPayment:          Dr UnderwriterPremiumPayable Cr ClientInsurance or PaymentGateway
+//	PremiumRefund:         Dr UnderwriterPremiumPayable Cr ClientInsurance
+//	CommissionClawback:    Dr AgentCommissionEarned     Cr UnderwriterPremiumPayable
+//	UnderwriterSettlement: Dr UnderwriterPremiumPayable Cr PaymentGateway
+//	GatewayFee:            Dr GatewayFeeExpense         Cr PaymentGateway
+//	SuspenseReceipt:       Dr PaymentGateway            Cr Suspense
+//	SuspenseClearing:      Dr Suspense                  Cr ClientInsurance or UnderwriterPremiumPayable
+func DefaultPostingRules() PostingRules {
+	return PostingRules{
+		TopUp:             {Debit: []AccountType{PaymentGateway}, Credit: []AccountType{ClientInsurance}},
+		PremiumPayment:    {Debit: []AccountType{ClientInsurance}, Credit: []AccountType{UnderwriterPremiumPayable}},
+		CommissionPayment: {Debit: []AccountType{UnderwriterPremiumPayable}, Credit: []AccountType{AgentCommissionEarned}},
+		Refund:            {Debit: []AccountType{ClientInsurance}, Credit: []AccountType{PaymentGateway}},
+		Fee:               {Debit: []AccountType{ClientInsurance}, Credit: []AccountType{PlatformFeeIncome}},
+
+		ClaimPayment:          {Debit: []AccountType{UnderwriterPremiumPayable}, Credit: []AccountType{ClientInsurance, PaymentGateway}},
+		PremiumRefund:         {Debit: []AccountType{UnderwriterPremiumPayable}, Credit: []AccountType{ClientInsurance}},
+		CommissionClawback:    {Debit: []AccountType{AgentCommissionEarned}, Credit: []AccountType{UnderwriterPremiumPayable}},
+		UnderwriterSettlement: {Debit: []AccountType{UnderwriterPremiumPayable}, Credit: []AccountType{PaymentGateway}},
+		GatewayFee:            {Debit: []AccountType{GatewayFeeExpense}, Credit: []AccountType{PaymentGateway}},
+
+		SuspenseReceipt:  {Debit: []AccountType{PaymentGateway}, Credit: []AccountType{Suspense}},
+		SuspenseClearing: {Debit: []AccountType{Suspense}, Credit: []AccountType{ClientInsurance, UnderwriterPremiumPayable}},
+	}
+}
+
+// Validate checks that every rule names at least one debit and one credit account type.
+func (r PostingRules) Validate() error {
+	for txType, rule := range r {
+		if len(rule.Debit) == 0 || len(rule.Credit) == 0 {
+			return fmt.Errorf("posting rule %s needs debit and credit account types", txType)
+		}
+	}
+	return nil
+}
+
+// Check returns an error wrapping ErrPostingRule when the debit or credit account type does not
+// match the rule of txType, e.g. because the sides were swapped, or ErrNoPostingRule when
+// txType has no rule.
+func (r PostingRules) Check(txType TransactionType, debit, credit AccountType) error {
+	rule, ok := r[txType]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoPostingRule, txType)
+	}
+	if !containsAccountType(rule.Debit, debit) {
+		return fmt.Errorf("%w: %s cannot debit a %s account", ErrPostingRule, txType, debit)
+	}
+	if !containsAccountType(rule.Credit, credit) {
+		return fmt.Errorf("%w: %s cannot credit a %s account", ErrPostingRule, txType, credit)
+	}
+	return nil
+}
+
+// CheckLegs checks the account types of a compound entry: every debited type must be
+// allowed on the debit side of the rule of txType and every credited type on its credit side.
+func (r PostingRules) CheckLegs(txType TransactionType, debits, credits []AccountType) error {
+	rule, ok := r[txType]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoPostingRule, txType)
+	}
+	for _, debit := range debits {
+		if !containsAccountType(rule.Debit, debit) {
+			return fmt.Errorf("%w: %s cannot debit a %s account", ErrPostingRule, txType, debit)
+		}
+	}
+	for _, credit := range credits {
+		if !containsAccountType(rule.Credit, credit) {
+			return fmt.Errorf("%w: %s cannot credit a %s account", ErrPostingRule, txType, credit)
+		}
+	}
+	return nil
+}
+
+func containsAccountType(types []AccountType, t AccountType) bool {
+	for _, candidate := range types {
+		if candidate == t {
+			return true
+		}
+	}
+	return false
+}
+
+// SetPostingRules replaces the posting rules applied to every posting.
+func (s *AccountingService) SetPostingRules(rules PostingRules) error {
+	if err := rules.Validate(); err != nil {
+		return err
+	}
+	s.rules = rules
+	return nil
+}
+
+func (s *AccountingService) postingRules() PostingRules {
+	if s.rules == nil {
+		return DefaultPostingRules()
+	}
+	return s.rules
+}
+
+// PostTransaction posts a transaction of any type with a posting rule, checking the
+// debit and credit accounts against the rule.
+func (s *AccountingService) PostTransaction(ctx context.Context, txType TransactionType, amount decimal.Decimal, debitAccID, creditAccID primitive.ObjectID, tranRef string, opts ...PostingOption) error {
+	_, err := s.postDoubleEntry(ctx, txType, amount, debitAccID, creditAccID, tranRef, opts...)
+	return err
+}
+
+// PostingRequest is the data of a PostTransaction call, for postings that are stored or
+// submitted before they are posted
+type PostingRequest struct {
+	Type          TransactionType    `json:"type"`
+	DebitAccount  primitive.ObjectID `json:"debit_account_id"`
+	CreditAccount primitive.ObjectID `json:"credit_account_id"`
+	Amount        decimal.Decimal    `json:"amount"`
+	TranRef       string             `json:"tranref"`
+	Narrative     string             `json:"narrative,omitempty"`
+	Metadata      map[string]string  `json:"metadata,omitempty"`
+}
+
+// options returns the posting options setting the narrative and metadata of the request
+func (p PostingRequest) options() []PostingOption {
+	var opts []PostingOption
+	if p.Narrative != "" {
+		opts = append(opts, WithNarrative(p.Narrative))
+	}
+	if len(p.Metadata) > 0 {
+		opts = append(opts, WithMetadata(p.Metadata))
+	}
+	return opts
+}
+
+// Refund: Debit Client (liability), Credit Gateway (asset)
+func (s *AccountingService) ClientRefund(ctx context.Context, clientAccID, gatewayAccID primitive.ObjectID, amount decimal.Decimal, tranRef string, opts ...PostingOption) error {
+	_, err := s.postDoubleEntry(ctx, Refund, amount, clientAccID, gatewayAccID, tranRef, opts...)
+	return err
+}
+
+// Fee: Debit Client (liability), Credit Fee Income (revenue)
+func (s *AccountingService) ChargeClientFee(ctx context.Context, clientAccID, feeAccID primitive.ObjectID, amount decimal.Decimal, tranRef string, opts ...PostingOption) error {
+	_, err := s.postDoubleEntry(ctx, Fee, amount, clientAccID, feeAccID, tranRef, opts...)
+	return err
+}
+
+// Claim Payment: Debit Underwriter (liability), Credit Client (liability) to pay the claim
+// into the client wallet, or Credit Gateway (asset) to pay it out directly
+func (s *AccountingService) PayClaim(ctx context.Context, underwriterAccID, payeeAccID primitive.ObjectID, amount decimal.Decimal, tranRef string, opts ...PostingOption) error {
+	_, err := s.postDoubleEntry(ctx, ClaimPayment, amount, underwriterAccID, payeeAccID, tranRef, opts...)
+	return err
+}
+
+// Premium Refund: Debit Underwriter (liability), Credit Client (liability), e.g. on a
+// policy cancelled mid-term
+func (s *AccountingService) RefundPremium(ctx context.Context, underwriterAccID, clientAccID primitive.ObjectID, amount decimal.Decimal, tranRef string, opts ...PostingOption) error {
+	_, err := s.postDoubleEntry(ctx, PremiumRefund, amount, underwriterAccID, clientAccID, tranRef, opts...)
+	return err
+}
+
+// Commission Clawback: Debit Agent (revenue), Credit Underwriter (liability), recovering
+// the commission of a refunded premium
+func (s *AccountingService) ClawbackCommission(ctx context.Context, agentAccID, underwriterAccID primitive.ObjectID, amount decimal.Decimal, tranRef string, opts ...PostingOption) error {
+	_, err := s.postDoubleEntry(ctx, CommissionClawback, amount, agentAccID, underwriterAccID, tranRef, opts...)
+	return err
+}
+
+// Underwriter Settlement: Debit Underwriter (liability), Credit Gateway (asset), remitting
+// the premiums held for the underwriter
+func (s *AccountingService) SettleUnderwriter(ctx context.Context, underwriterAccID, gatewayAccID primitive.ObjectID, amount decimal.Decimal, tranRef string, opts ...PostingOption) error {
+	_, err := s.postDoubleEntry(ctx, UnderwriterSettlement, amount, underwriterAccID, gatewayAccID, tranRef, opts...)
+	return err
+}
+
+// Gateway Fee: Debit Gateway Fee (expense), Credit Gateway (asset) for the charges the
+// payment provider deducts
+func (s *AccountingService) ChargeGatewayFee(ctx context.Context, gatewayAccID, feeExpenseAccID primitive.ObjectID, amount decimal.Decimal, tranRef string, opts ...PostingOption) error {
+	_, err := s.postDoubleEntry(ctx, GatewayFee, amount, feeExpenseAccID, gatewayAccID, tranRef, opts...)
+	return err
+}
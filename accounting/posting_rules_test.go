@@ -0,0 +1,143 @@
+package accounting
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPostingRules_RejectSwappedSides(t *testing.T) {
+	rules := DefaultPostingRules()
+	assert.NoError(t, rules.Validate())
+
+	assert.NoError(t, rules.Check(TopUp, PaymentGateway, ClientInsurance))
+	assert.NoError(t, rules.Check(Fee, ClientInsurance, PlatformFeeIncome))
+
+	err := rules.Check(TopUp, ClientInsurance, PaymentGateway)
+	assert.True(t, errors.Is(err, ErrPostingRule), "swapped sides should violate the rule: %v", err)
+
+	err = rules.Check(PremiumPayment, ClientInsurance, AgentCommissionEarned)
+	assert.True(t, errors.Is(err, ErrPostingRule), "wrong credit account should violate the rule: %v", err)
+
+	err = rules.Check(TransactionType("Unknown"), ClientInsurance, PaymentGateway)
+	assert.True(t, errors.Is(err, ErrNoPostingRule))
+}
+
+func TestPostingRules_InsuranceTypes(t *testing.T) {
+	rules := DefaultPostingRules()
+	assert.NoError(t, rules.Check(ClaimPayment, UnderwriterPremiumPayable, ClientInsurance))
+	assert.NoError(t, rules.Check(ClaimPayment, UnderwriterPremiumPayable, PaymentGateway))
+	assert.NoError(t, rules.Check(PremiumRefund, UnderwriterPremiumPayable, ClientInsurance))
+	assert.NoError(t, rules.Check(CommissionClawback, AgentCommissionEarned, UnderwriterPremiumPayable))
+	assert.NoError(t, rules.Check(UnderwriterSettlement, UnderwriterPremiumPayable, PaymentGateway))
+	assert.NoError(t, rules.Check(GatewayFee, GatewayFeeExpense, PaymentGateway))
+
+	assert.True(t, errors.Is(rules.Check(PremiumRefund, ClientInsurance, UnderwriterPremiumPayable), ErrPostingRule))
+	assert.True(t, errors.Is(rules.Check(CommissionClawback, UnderwriterPremiumPayable, AgentCommissionEarned), ErrPostingRule))
+	assert.True(t, errors.Is(rules.Check(GatewayFee, PlatformFeeIncome, PaymentGateway), ErrPostingRule))
+}
+
+func TestPostingRules_ValidateRequiresBothSides(t *testing.T) {
+	rules := PostingRules{Refund: {Debit: []AccountType{ClientInsurance}}}
+	assert.Error(t, rules.Validate())
+}
+
+func TestPostingRules_CheckLegs(t *testing.T) {
+	rules := DefaultPostingRules()
+	assert.NoError(t, rules.CheckLegs(PremiumPayment, []AccountType{ClientInsurance, ClientInsurance}, []AccountType{UnderwriterPremiumPayable}))
+
+	err := rules.CheckLegs(PremiumPayment, []AccountType{ClientInsurance}, []AccountType{UnderwriterPremiumPayable, PaymentGateway})
+	assert.True(t, errors.Is(err, ErrPostingRule))
+}
+
+func TestValidateLegs(t *testing.T) {
+	client, underwriter, fees := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+
+	total, err := validateLegs([]JournalLeg{
+		DebitLeg(client, decimal.NewFromInt(1050)),
+		CreditLeg(underwriter, decimal.NewFromInt(1000)),
+		CreditLeg(fees, decimal.NewFromInt(50)),
+	})
+	require.NoError(t, err)
+	assert.True(t, total.Equal(decimal.NewFromInt(1050)))
+
+	_, err = validateLegs([]JournalLeg{DebitLeg(client, decimal.NewFromInt(1050)), CreditLeg(underwriter, decimal.NewFromInt(1000))})
+	assert.True(t, errors.Is(err, ErrUnbalanced), "debits must equal credits: %v", err)
+
+	_, err = validateLegs([]JournalLeg{DebitLeg(client, decimal.NewFromInt(10))})
+	assert.True(t, errors.Is(err, ErrUnbalanced))
+
+	_, err = validateLegs([]JournalLeg{DebitLeg(client, decimal.Zero), CreditLeg(underwriter, decimal.Zero)})
+	assert.True(t, errors.Is(err, ErrInvalidAmount))
+}
+
+func TestAccount_CheckDebit(t *testing.T) {
+	client := &Account{ID: primitive.NewObjectID(), Type: ClientInsurance}
+	require.NoError(t, client.SetBalance(decimal.NewFromInt(100)))
+	assert.False(t, client.NegativeAllowed())
+	assert.NoError(t, client.checkDebit(decimal.NewFromInt(100)))
+	assert.True(t, errors.Is(client.checkDebit(decimal.NewFromInt(101)), ErrInsufficientFunds))
+
+	gateway := &Account{ID: primitive.NewObjectID(), Type: PaymentGateway}
+	require.NoError(t, gateway.SetBalance(decimal.NewFromInt(-5000)))
+	assert.True(t, gateway.NegativeAllowed())
+	assert.NoError(t, gateway.checkDebit(decimal.NewFromInt(1000)))
+
+	allow := true
+	client.AllowNegative = &allow
+	client.OverdraftLimit = "50"
+	assert.NoError(t, client.checkDebit(decimal.NewFromInt(150)))
+	assert.True(t, errors.Is(client.checkDebit(decimal.RequireFromString("150.01")), ErrInsufficientFunds))
+
+	deny := false
+	gateway.AllowNegative = &deny
+	assert.True(t, errors.Is(gateway.checkDebit(decimal.NewFromInt(1)), ErrInsufficientFunds))
+}
+
+func TestDecimal128RoundTrip(t *testing.T) {
+	for _, s := range []string{"0", "1500", "-5000.25", "0.000001", "123456789012345678901234567890.1234"} {
+		d := decimal.RequireFromString(s)
+		v, err := toDecimal128(d)
+		require.NoError(t, err)
+		assert.True(t, fromDecimal128(v).Equal(d), s)
+	}
+	_, err := toDecimal128(decimal.RequireFromString("1234567890123456789012345678901234567.1"))
+	assert.True(t, errors.Is(err, ErrInvalidAmount))
+}
+
+func TestTransferMatrix_Check(t *testing.T) {
+	m := DefaultTransferMatrix()
+	assert.NoError(t, m.Check(ClientInsurance, ClientInsurance))
+	assert.True(t, errors.Is(m.Check(ClientInsurance, PaymentGateway), ErrPostingRule))
+
+	m = NewTransferMatrix(TransferPair{From: ClientInsurance, To: PaymentGateway})
+	assert.NoError(t, m.Check(ClientInsurance, PaymentGateway))
+	assert.Error(t, m.Check(PaymentGateway, ClientInsurance), "pairs are directed")
+
+	s := NewAccountingServiceWithRepository(nil)
+	assert.True(t, s.transferMatrix().Allows(AgentCommissionEarned, AgentCommissionEarned))
+	s.SetTransferMatrix(m)
+	assert.False(t, s.transferMatrix().Allows(AgentCommissionEarned, AgentCommissionEarned))
+}
+
+func TestPostingOptions(t *testing.T) {
+	s := NewAccountingServiceWithRepository(nil)
+	entry, err := s.newDoubleEntry(TopUp, decimal.NewFromInt(10), primitive.NewObjectID(), primitive.NewObjectID(), "MPESA1", []PostingOption{
+		WithNarrative("  M-Pesa QK12 "),
+		WithMetadata(map[string]string{"msisdn": "254700000000"}),
+		WithMetadata(map[string]string{"bank_ref": "FT1"}),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "M-Pesa QK12", entry.Narrative)
+	assert.Equal(t, map[string]string{"msisdn": "254700000000", "bank_ref": "FT1"}, entry.Metadata)
+	assert.Equal(t, "bank_ref=FT1; msisdn=254700000000", formatMetadata(entry.Metadata))
+
+	_, err = s.newDoubleEntry(TopUp, decimal.NewFromInt(10), primitive.NewObjectID(), primitive.NewObjectID(), "MPESA1", []PostingOption{
+		WithMetadata(map[string]string{"bank.ref": "FT1"}),
+	})
+	assert.True(t, errors.Is(err, ErrInvalidMetadata))
+}
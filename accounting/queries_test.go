@@ -0,0 +1,134 @@
+package accounting
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAccountFilter_Query(t *testing.T) {
+	q, err := AccountFilter{IncludeArchived: true}.query()
+	require.NoError(t, err)
+	assert.Empty(t, q)
+	q, err = AccountFilter{}.query()
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{"status": bson.M{"$ne": AccountArchived}}, q)
+
+	after := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := after.AddDate(0, 1, 0)
+	q, err = AccountFilter{Type: ClientInsurance, NameRegex: "^client", CreatedAfter: after, CreatedBefore: before}.query()
+	require.NoError(t, err)
+	assert.Equal(t, ClientInsurance, q["type"])
+	assert.Equal(t, primitive.Regex{Pattern: "^client", Options: "i"}, q["name"])
+	assert.Equal(t, bson.M{"$gte": after, "$lt": before}, q["created_at"])
+
+	_, err = AccountFilter{NameRegex: "client("}.query()
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+}
+
+func TestJournalFilter_Query(t *testing.T) {
+	q, err := JournalFilter{}.query()
+	require.NoError(t, err)
+	assert.Empty(t, q)
+
+	id := primitive.NewObjectID()
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	q, err = JournalFilter{AccountID: id, Type: TopUp, TranRef: "REF1", MinAmount: decimal.NewFromInt(10), From: from}.query()
+	require.NoError(t, err)
+	assert.Len(t, q["$or"], 3)
+	assert.Equal(t, TopUp, q["type"])
+	assert.Equal(t, "REF1", q["tranref"])
+	assert.Equal(t, bson.M{"$gte": from}, q["created_at"])
+	min, _ := toDecimal128(decimal.NewFromInt(10))
+	assert.Equal(t, bson.M{"$and": []bson.M{{"$gte": bson.A{bson.M{"$toDecimal": "$amount"}, min}}}}, q["$expr"])
+
+	q, err = JournalFilter{Narrative: "QK1.2", Metadata: map[string]string{"bank_ref": "FT1"}}.query()
+	require.NoError(t, err)
+	assert.Equal(t, primitive.Regex{Pattern: `QK1\.2`, Options: "i"}, q["narrative"])
+	assert.Equal(t, "FT1", q["metadata.bank_ref"])
+
+	_, err = JournalFilter{Metadata: map[string]string{"$where": "1"}}.query()
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+	_, err = JournalFilter{MinAmount: decimal.NewFromInt(100), MaxAmount: decimal.NewFromInt(10)}.query()
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+	_, err = JournalFilter{From: from, To: from.Add(-time.Hour)}.query()
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+	assert.True(t, errors.Is(JournalSort{Field: "tranref"}.validate(), ErrInvalidFilter))
+}
+
+func TestPagination_Normalize(t *testing.T) {
+	assert.Equal(t, Pagination{Limit: 50}, Pagination{}.normalize())
+	assert.Equal(t, Pagination{Limit: 500, Skip: 0}, Pagination{Limit: 10000, Skip: -5}.normalize())
+	assert.Equal(t, Pagination{Limit: 20, Skip: 40}, Pagination{Limit: 20, Skip: 40}.normalize())
+}
+
+func TestStatementLines_RunningBalance(t *testing.T) {
+	client, gateway, underwriter := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	entries := []JournalEntry{
+		{ID: primitive.NewObjectID(), Type: TopUp, Amount: "1000", DebitAccount: gateway, CreditAccount: client},
+		{ID: primitive.NewObjectID(), Type: PremiumPayment, Amount: "700", DebitAccount: client, CreditAccount: underwriter},
+		{ID: primitive.NewObjectID(), Type: TopUp, Amount: "50.5", DebitAccount: gateway, CreditAccount: client},
+	}
+
+	lines := statementLines(client, decimal.NewFromInt(100), entries)
+	require.Len(t, lines, 3)
+	assert.Equal(t, DirectionCredit, lines[0].Direction)
+	assert.Equal(t, gateway, lines[0].CounterAccount)
+	assert.True(t, lines[0].Balance.Equal(decimal.NewFromInt(1100)))
+	assert.Equal(t, DirectionDebit, lines[1].Direction)
+	assert.Equal(t, underwriter, lines[1].CounterAccount)
+	assert.True(t, lines[1].Amount.Equal(decimal.NewFromInt(700)))
+	assert.True(t, lines[1].Balance.Equal(decimal.NewFromInt(400)))
+	assert.True(t, lines[2].Balance.Equal(decimal.RequireFromString("450.5")))
+}
+
+func TestBuildTrialBalance(t *testing.T) {
+	accounts := []Account{
+		{ID: primitive.NewObjectID(), Type: ClientInsurance, Name: "client a"},
+		{ID: primitive.NewObjectID(), Type: ClientInsurance, Name: "client b"},
+		{ID: primitive.NewObjectID(), Type: PaymentGateway, Name: "gateway"},
+		{ID: primitive.NewObjectID(), Type: UnderwriterPremiumPayable, Name: "underwriter"},
+	}
+	balances := []decimal.Decimal{decimal.NewFromInt(300), decimal.NewFromInt(0), decimal.NewFromInt(-1000), decimal.NewFromInt(700)}
+
+	tb := buildTrialBalance(time.Now(), accounts, balances)
+	require.Len(t, tb.Lines, 4)
+	assert.True(t, tb.Lines[2].Debit.Equal(decimal.NewFromInt(1000)))
+	assert.True(t, tb.Lines[2].Credit.IsZero())
+	assert.True(t, tb.TotalDebit.Equal(decimal.NewFromInt(1000)))
+	assert.True(t, tb.TotalCredit.Equal(decimal.NewFromInt(1000)))
+	assert.True(t, tb.Balanced())
+
+	require.Len(t, tb.Subtotals, 3)
+	assert.Equal(t, ClientInsurance, tb.Subtotals[0].AccountType)
+	assert.Equal(t, 2, tb.Subtotals[0].AccountCount)
+	assert.True(t, tb.Subtotals[0].Credit.Equal(decimal.NewFromInt(300)))
+
+	balances[1] = decimal.NewFromInt(5)
+	tb = buildTrialBalance(time.Now(), accounts, balances)
+	assert.False(t, tb.Balanced())
+	assert.True(t, tb.Difference().Equal(decimal.NewFromInt(5)))
+}
+
+func TestStatementLines_CompoundEntry(t *testing.T) {
+	client, underwriter, fees := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	entry := JournalEntry{ID: primitive.NewObjectID(), Type: PremiumPayment, Amount: "1050", Legs: []JournalLeg{
+		DebitLeg(client, decimal.NewFromInt(1050)),
+		CreditLeg(underwriter, decimal.NewFromInt(1000)),
+		CreditLeg(fees, decimal.NewFromInt(50)),
+	}}
+	assert.True(t, entry.BalanceEffect(client).Equal(decimal.NewFromInt(-1050)))
+	assert.True(t, entry.BalanceEffect(fees).Equal(decimal.NewFromInt(50)))
+
+	lines := statementLines(fees, decimal.Zero, []JournalEntry{entry})
+	require.Len(t, lines, 1)
+	assert.Equal(t, DirectionCredit, lines[0].Direction)
+	assert.True(t, lines[0].Amount.Equal(decimal.NewFromInt(50)))
+	assert.True(t, lines[0].CounterAccount.IsZero())
+}
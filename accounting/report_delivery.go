@@ -0,0 +1,116 @@
+package accounting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// --------------------------
+//  Report Delivery
+// --------------------------
+
+// Delivery hands a generated ledger health report to its recipients.
+type Delivery interface {
+	Deliver(ctx context.Context, report *LedgerHealthReport) error
+}
+
+// DeliveryFunc adapts a function to the Delivery interface.
+type DeliveryFunc func(ctx context.Context, report *LedgerHealthReport) error
+
+func (f DeliveryFunc) Deliver(ctx context.Context, report *LedgerHealthReport) error {
+	return f(ctx, report)
+}
+
+// WebhookDelivery posts the report summary to a Slack compatible incoming webhook.
+type WebhookDelivery struct {
+	URL        string
+	HTTPClient *http.Client // Defaults to a client with a 10s timeout
+}
+
+func (d *WebhookDelivery) Deliver(ctx context.Context, report *LedgerHealthReport) error {
+	payload, err := json.Marshal(map[string]string{"text": "```" + report.Summary() + "```"})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := d.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook delivery: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook delivery: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// EmailDelivery mails the report summary through an SMTP server.
+type EmailDelivery struct {
+	Addr    string    // SMTP server address, host:port
+	Auth    smtp.Auth // Optional
+	From    string
+	To      []string
+	Subject string // Defaults to "Ledger health report <date>"
+}
+
+func (d *EmailDelivery) Deliver(ctx context.Context, report *LedgerHealthReport) error {
+	if len(d.To) == 0 {
+		return fmt.Errorf("email delivery: no recipients")
+	}
+	subject := d.Subject
+	if subject == "" {
+		subject = "Ledger health report " + report.ReportDate.Format("2006-01-02")
+	}
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", d.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(d.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(report.Summary(), "\n", "\r\n"))
+
+	if err := smtp.SendMail(d.Addr, d.Auth, d.From, d.To, []byte(msg.String())); err != nil {
+		return fmt.Errorf("email delivery: %w", err)
+	}
+	return nil
+}
+
+// ObjectStore is the subset of an object storage client needed to archive reports,
+// e.g. an S3 or GCS bucket wrapper.
+type ObjectStore interface {
+	PutObject(ctx context.Context, key string, body []byte, contentType string) error
+}
+
+// ObjectStorageDelivery archives the full report as JSON under
+// <Prefix>ledger-health/<date>.json.
+type ObjectStorageDelivery struct {
+	Store  ObjectStore
+	Prefix string
+}
+
+func (d *ObjectStorageDelivery) Deliver(ctx context.Context, report *LedgerHealthReport) error {
+	body, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	key := d.Prefix + "ledger-health/" + report.ReportDate.Format("2006-01-02") + ".json"
+	if err := d.Store.PutObject(ctx, key, body, "application/json"); err != nil {
+		return fmt.Errorf("object storage delivery: %w", err)
+	}
+	return nil
+}
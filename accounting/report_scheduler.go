@@ -0,0 +1,200 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// --------------------------
+//  Ledger Health Report
+// --------------------------
+
+// ReconciliationReporter produces the reconciliation of every ledger account.
+// *AccountingService implements it.
+type ReconciliationReporter interface {
+	GetReconciliationReport(ctx context.Context) ([]ReconciliationResult, error)
+}
+
+// AccountTypeBalance is one line of the trial balance, the stored balances of all
+// accounts of a type.
+type AccountTypeBalance struct {
+	AccountType  AccountType     `json:"account_type"`
+	AccountCount int             `json:"account_count"`
+	Balance      decimal.Decimal `json:"balance"`
+}
+
+// LedgerHealthReport is the daily trial balance and reconciliation summary sent to finance.
+type LedgerHealthReport struct {
+	ReportDate     time.Time              `json:"report_date"`
+	GeneratedAt    time.Time              `json:"generated_at"`
+	TrialBalance   []AccountTypeBalance   `json:"trial_balance"`
+	TotalBalance   decimal.Decimal        `json:"total_balance"` // non-zero means the ledger does not balance
+	Reconciled     int                    `json:"reconciled"`
+	Discrepancies  int                    `json:"discrepancies"`
+	NoTransactions int                    `json:"no_transactions"`
+	Accounts       []ReconciliationResult `json:"accounts"`
+}
+
+// Healthy reports whether the ledger balances and every account reconciles.
+func (r *LedgerHealthReport) Healthy() bool {
+	return r.TotalBalance.IsZero() && r.Discrepancies == 0
+}
+
+// Summary renders the report as plain text for email and chat deliveries.
+func (r *LedgerHealthReport) Summary() string {
+	var b strings.Builder
+	status := "HEALTHY"
+	if !r.Healthy() {
+		status = "ATTENTION REQUIRED"
+	}
+	fmt.Fprintf(&b, "Ledger health report %s: %s\n", r.ReportDate.Format("2006-01-02"), status)
+	fmt.Fprintf(&b, "\nTrial balance\n")
+	for _, line := range r.TrialBalance {
+		fmt.Fprintf(&b, "  %-28s %4d accounts  %s\n", line.AccountType, line.AccountCount, line.Balance.StringFixed(2))
+	}
+	fmt.Fprintf(&b, "  %-28s %18s\n", "Total", r.TotalBalance.StringFixed(2))
+	fmt.Fprintf(&b, "\nReconciliation: %d reconciled, %d discrepancies, %d without transactions\n",
+		r.Reconciled, r.Discrepancies, r.NoTransactions)
+	for _, acc := range r.Accounts {
+		if acc.Status == Discrepancy {
+			fmt.Fprintf(&b, "  %s %s: stored %s, computed %s, discrepancy %s\n",
+				acc.AccountType, acc.AccountID.Hex(),
+				acc.StoredBalance.StringFixed(2), acc.ComputedBalance.StringFixed(2), acc.Discrepancy.StringFixed(2))
+		}
+	}
+	return b.String()
+}
+
+// BuildLedgerHealthReport builds the report for reportDate from the account reconciliations.
+func BuildLedgerHealthReport(ctx context.Context, source ReconciliationReporter, reportDate time.Time) (*LedgerHealthReport, error) {
+	results, err := source.GetReconciliationReport(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reconciliation report: %w", err)
+	}
+
+	report := &LedgerHealthReport{
+		ReportDate:  reportDate,
+		GeneratedAt: time.Now(),
+		Accounts:    results,
+	}
+	byType := make(map[AccountType]*AccountTypeBalance)
+	for _, res := range results {
+		line, ok := byType[res.AccountType]
+		if !ok {
+			line = &AccountTypeBalance{AccountType: res.AccountType}
+			byType[res.AccountType] = line
+		}
+		line.AccountCount++
+		line.Balance = line.Balance.Add(res.StoredBalance)
+		report.TotalBalance = report.TotalBalance.Add(res.StoredBalance)
+
+		switch res.Status {
+		case Reconciled:
+			report.Reconciled++
+		case Discrepancy:
+			report.Discrepancies++
+		case NoTransactions:
+			report.NoTransactions++
+		}
+	}
+	for _, line := range byType {
+		report.TrialBalance = append(report.TrialBalance, *line)
+	}
+	sort.Slice(report.TrialBalance, func(i, j int) bool {
+		return report.TrialBalance[i].AccountType < report.TrialBalance[j].AccountType
+	})
+	return report, nil
+}
+
+// --------------------------
+//  Report Scheduler
+// --------------------------
+
+// ReportSchedulerConfig configures when the daily ledger health report runs.
+type ReportSchedulerConfig struct {
+	RunAt    time.Duration  // Time of day the report runs, as an offset from midnight. Defaults to 06:00
+	Location *time.Location // Time zone of RunAt and the report date, defaults to time.Local
+	OnError  func(error)    // Called when generating or delivering a report fails, optional
+}
+
+// ReportScheduler generates the ledger health report once a day and hands it to
+// every configured Delivery.
+type ReportScheduler struct {
+	source     ReconciliationReporter
+	deliveries []Delivery
+	cfg        ReportSchedulerConfig
+	now        func() time.Time
+}
+
+// NewReportScheduler creates a scheduler delivering the reports of source to deliveries.
+func NewReportScheduler(source ReconciliationReporter, cfg ReportSchedulerConfig, deliveries ...Delivery) *ReportScheduler {
+	if cfg.RunAt <= 0 || cfg.RunAt >= 24*time.Hour {
+		cfg.RunAt = 6 * time.Hour
+	}
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+	return &ReportScheduler{
+		source:     source,
+		deliveries: deliveries,
+		cfg:        cfg,
+		now:        time.Now,
+	}
+}
+
+// NextRun returns the first scheduled run strictly after t.
+func (s *ReportScheduler) NextRun(t time.Time) time.Time {
+	t = t.In(s.cfg.Location)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.cfg.Location)
+	next := midnight.Add(s.cfg.RunAt)
+	if !next.After(t) {
+		next = midnight.AddDate(0, 0, 1).Add(s.cfg.RunAt)
+	}
+	return next
+}
+
+// RunOnce generates the report for reportDate and delivers it. Every delivery is
+// attempted, the returned error joins the failures.
+func (s *ReportScheduler) RunOnce(ctx context.Context, reportDate time.Time) (*LedgerHealthReport, error) {
+	report, err := BuildLedgerHealthReport(ctx, s.source, reportDate)
+	if err != nil {
+		return nil, err
+	}
+	var errs []error
+	for _, d := range s.deliveries {
+		if err := d.Deliver(ctx, report); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return report, fmt.Errorf("report delivery failed: %w", err)
+	}
+	return report, nil
+}
+
+// Start runs the report every day at the configured time until ctx is cancelled.
+// The report covers the day before the run, so the 06:00 run reports yesterday's ledger.
+func (s *ReportScheduler) Start(ctx context.Context) {
+	go func() {
+		for {
+			next := s.NextRun(s.now())
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			day := time.Date(next.Year(), next.Month(), next.Day(), 0, 0, 0, 0, s.cfg.Location).AddDate(0, 0, -1)
+			if _, err := s.RunOnce(ctx, day); err != nil && s.cfg.OnError != nil {
+				s.cfg.OnError(err)
+			}
+		}
+	}()
+}
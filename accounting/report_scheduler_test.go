@@ -0,0 +1,59 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type staticReconciliation []ReconciliationResult
+
+func (s staticReconciliation) GetReconciliationReport(ctx context.Context) ([]ReconciliationResult, error) {
+	return s, nil
+}
+
+func TestReportScheduler_RunOnceDeliversReport(t *testing.T) {
+	source := staticReconciliation{
+		{AccountID: primitive.NewObjectID(), AccountType: ClientInsurance, StoredBalance: decimal.NewFromInt(500), Status: Reconciled},
+		{AccountID: primitive.NewObjectID(), AccountType: PaymentGateway, StoredBalance: decimal.NewFromInt(-500), Status: Reconciled},
+		{AccountID: primitive.NewObjectID(), AccountType: ClientInsurance, StoredBalance: decimal.Zero, Status: Discrepancy, Discrepancy: decimal.NewFromInt(10)},
+	}
+
+	var delivered *LedgerHealthReport
+	ok := DeliveryFunc(func(ctx context.Context, r *LedgerHealthReport) error {
+		delivered = r
+		return nil
+	})
+	failing := DeliveryFunc(func(ctx context.Context, r *LedgerHealthReport) error {
+		return errors.New("webhook down")
+	})
+	sched := NewReportScheduler(source, ReportSchedulerConfig{}, failing, ok)
+
+	report, err := sched.RunOnce(context.Background(), time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))
+	assert.Error(t, err, "failing delivery should be reported")
+	assert.Same(t, report, delivered, "remaining deliveries should still run")
+
+	assert.True(t, report.TotalBalance.IsZero())
+	assert.Equal(t, 2, report.Reconciled)
+	assert.Equal(t, 1, report.Discrepancies)
+	assert.False(t, report.Healthy())
+	assert.Len(t, report.TrialBalance, 2)
+	assert.Equal(t, ClientInsurance, report.TrialBalance[0].AccountType)
+	assert.Equal(t, 2, report.TrialBalance[0].AccountCount)
+	assert.Contains(t, report.Summary(), "ATTENTION REQUIRED")
+}
+
+func TestReportScheduler_NextRun(t *testing.T) {
+	sched := NewReportScheduler(staticReconciliation{}, ReportSchedulerConfig{RunAt: 6 * time.Hour, Location: time.UTC})
+
+	before := time.Date(2025, 3, 1, 5, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2025, 3, 1, 6, 0, 0, 0, time.UTC), sched.NextRun(before))
+
+	after := time.Date(2025, 3, 1, 6, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2025, 3, 2, 6, 0, 0, 0, time.UTC), sched.NextRun(after))
+}
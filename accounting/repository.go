@@ -0,0 +1,169 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// --------------------------
+//  Storage
+// --------------------------
+
+// ErrDuplicateKey is wrapped by AccountingRepository.InsertJournal when an entry with the
+// same idempotency key is already stored
+var ErrDuplicateKey = errors.New("duplicate idempotency key")
+
+// AccountingRepository stores the accounts, journal entries and balance snapshots of the
+// ledger. AccountingService keeps the double-entry logic on top of it, so the ledger runs
+// on any store implementing it: MongoRepository, PostgresRepository and, for tests,
+// MemoryRepository are provided.
+//
+// Lookups of a single account or entry return ErrAccountNotFound or ErrJournalNotFound
+// when it does not exist; listings return an empty slice.
+type AccountingRepository interface {
+	// RunInTransaction runs fn in a transaction. The repository calls fn makes with the
+	// context it receives are part of the transaction; an error from fn rolls it back.
+	// fn may be called again when the store retries a transaction that hit a transient
+	// failure, so it must not keep state across calls.
+	RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+
+	// InsertAccount stores a new account. It wraps ErrDuplicateAccountRef when another
+	// account has its Ref.
+	InsertAccount(ctx context.Context, acc *Account) error
+	GetAccount(ctx context.Context, accountID primitive.ObjectID) (*Account, error)
+	// FindAccountByName returns the oldest account with exactly this name
+	FindAccountByName(ctx context.Context, name string) (*Account, error)
+	// FindAccountByRef returns the account with this external reference
+	FindAccountByRef(ctx context.Context, ref string) (*Account, error)
+	// ListAccounts returns a page of the matching accounts, oldest first, and their total
+	ListAccounts(ctx context.Context, filter AccountFilter, page Pagination) ([]Account, int64, error)
+	// AccountsAsOf returns the accounts created at or before t (every account for a zero t),
+	// ordered by type, name and ID
+	AccountsAsOf(ctx context.Context, t time.Time) ([]Account, error)
+	// SetOverdraftPolicy stores the overdraft policy of an account, a zero limit clears it
+	SetOverdraftPolicy(ctx context.Context, accountID primitive.ObjectID, allowNegative bool, limit decimal.Decimal) error
+	// IncrementBalance atomically adds delta to the balance of an active account. With a
+	// floor the balance is only changed while it is at least floor; false reports it was not.
+	IncrementBalance(ctx context.Context, accountID primitive.ObjectID, delta decimal.Decimal, floor *decimal.Decimal) (bool, error)
+	// SetAccountStatus changes the status of an account. Closing it only succeeds while its
+	// balance is zero; false reports it was not closed.
+	SetAccountStatus(ctx context.Context, accountID primitive.ObjectID, status AccountStatus, reason string, at time.Time) (bool, error)
+
+	// InsertJournal stores an entry, failing with an error wrapping ErrDuplicateKey when
+	// its idempotency key is taken
+	InsertJournal(ctx context.Context, entry *JournalEntry) error
+	GetJournal(ctx context.Context, journalID primitive.ObjectID) (*JournalEntry, error)
+	// FindJournalByIdempotencyKey returns the entry posted under key, nil when there is none
+	FindJournalByIdempotencyKey(ctx context.Context, key string) (*JournalEntry, error)
+	// EnsureIndexes creates the indexes the ledger queries need, leaving existing ones
+	EnsureIndexes(ctx context.Context) error
+	// EnsureIdempotencyIndex makes idempotency keys unique across entries that have one
+	EnsureIdempotencyIndex(ctx context.Context) error
+	// MarkReversed records that reversedBy offsets the entry and moves it to group. It
+	// returns false, changing nothing, when the entry is already reversed or is a matched
+	// suspense receipt.
+	MarkReversed(ctx context.Context, journalID, group, reversedBy primitive.ObjectID, at time.Time, reason string) (bool, error)
+	// MarkMatched records that the clearing matchedBy clears the suspense receipt and moves
+	// it to group. It returns false, changing nothing, when the receipt is already matched
+	// or reversed.
+	MarkMatched(ctx context.Context, receiptID, group, matchedBy primitive.ObjectID, at time.Time) (bool, error)
+	// UnmarkMatched returns the receipt matched by matchedBy to unmatched. It returns false,
+	// changing nothing, when matchedBy is not the clearing of the receipt.
+	UnmarkMatched(ctx context.Context, receiptID, matchedBy primitive.ObjectID) (bool, error)
+	// ListJournals returns a page of the entries, newest first
+	ListJournals(ctx context.Context, limit, skip int64) ([]JournalEntry, error)
+	// JournalsByRef returns the entries posted under tranRef, oldest first with ties
+	// broken by ID
+	JournalsByRef(ctx context.Context, tranRef string) ([]JournalEntry, error)
+	// QueryJournals returns a page of the entries matching filter in the order of sort, and
+	// their total. With filter.Archived it searches the archived entries.
+	QueryJournals(ctx context.Context, filter JournalFilter, sort JournalSort, page Pagination) ([]JournalEntry, int64, error)
+	// StreamJournals calls fn with each entry matching filter in the order of sort, read
+	// through a cursor. An error from fn stops the iteration and is returned. With
+	// filter.Archived it reads the archived entries.
+	StreamJournals(ctx context.Context, filter JournalFilter, sort JournalSort, fn func(*JournalEntry) error) error
+	// AccountJournals returns the entries with a leg on an account, oldest first, archived
+	// entries included
+	AccountJournals(ctx context.Context, q AccountJournalQuery) ([]JournalEntry, error)
+	CountAccountJournals(ctx context.Context, q AccountJournalQuery) (int64, error)
+	// ArchiveJournals moves up to limit of the oldest entries created before cutoff from
+	// the journal to the archive and returns how many it moved
+	ArchiveJournals(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+
+	// BalancesByType sums the account balances per account type, ordered by type
+	BalancesByType(ctx context.Context) ([]TypeBalance, error)
+	// DailyPostingTotals counts and sums the entries created between from and to (both
+	// inclusive) per UTC day and transaction type, ordered by day and type
+	DailyPostingTotals(ctx context.Context, from, to time.Time) ([]DailyPostingTotal, error)
+	// TopAccountsByVolume returns the limit accounts with the highest sum of leg amounts
+	// over the entries created between from and to (both inclusive), highest first
+	TopAccountsByVolume(ctx context.Context, from, to time.Time, limit int) ([]AccountVolume, error)
+
+	// ChainHead returns the latest link of the journal hash chain, zero when it is empty.
+	// Within a transaction it holds the head until AdvanceChain, where the store needs it.
+	ChainHead(ctx context.Context) (ChainLink, error)
+	// AdvanceChain moves the chain head from prev to next, returning false when the head
+	// is no longer prev
+	AdvanceChain(ctx context.Context, prev, next ChainLink) (bool, error)
+	// ChainSeqRange returns the lowest and highest Seq of the chained entries created
+	// between from and to (both inclusive, zero for unbounded), zeros when there are none.
+	// It and StreamChain cover the archived entries.
+	ChainSeqRange(ctx context.Context, from, to time.Time) (first, last int64, err error)
+	// StreamChain calls fn with the chained entries from Seq first to last, in Seq order
+	StreamChain(ctx context.Context, first, last int64, fn func(*JournalEntry) error) error
+
+	// LatestSnapshot returns the latest snapshot of an account taken for an instant before
+	// t, or at t when inclusive is set; nil when there is none
+	LatestSnapshot(ctx context.Context, accountID primitive.ObjectID, t time.Time, inclusive bool) (*BalanceSnapshot, error)
+	// UpsertSnapshot stores snap, replacing the balance of a snapshot of the same account
+	// and instant, and returns the stored snapshot
+	UpsertSnapshot(ctx context.Context, snap *BalanceSnapshot) (*BalanceSnapshot, error)
+	// ListSnapshots returns the snapshots of an account for instants between from and to
+	// (both inclusive, zero for unbounded), oldest first
+	ListSnapshots(ctx context.Context, accountID primitive.ObjectID, from, to time.Time) ([]BalanceSnapshot, error)
+
+	// InsertAudit appends rec to the audit log
+	InsertAudit(ctx context.Context, rec *AuditRecord) error
+	// ListAudit returns a page of the audit records matching filter, newest first, and
+	// their total
+	ListAudit(ctx context.Context, filter AuditFilter, page Pagination) ([]AuditRecord, int64, error)
+
+	// InsertOutbox stores an outbox message
+	InsertOutbox(ctx context.Context, msg *OutboxMessage) error
+	// ClaimOutbox returns up to limit undelivered messages due at now, oldest due first,
+	// moving their NextAttemptAt lease past now so no other relay claims them meanwhile
+	ClaimOutbox(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]OutboxMessage, error)
+	// UpdateOutbox stores the delivery state of msg: attempts, delivered endpoints, next
+	// attempt, delivery time and last error
+	UpdateOutbox(ctx context.Context, msg *OutboxMessage) error
+
+	// InsertSchedule stores a scheduled posting
+	InsertSchedule(ctx context.Context, sp *ScheduledPosting) error
+	// GetSchedule returns a scheduled posting, ErrScheduleNotFound when there is none
+	GetSchedule(ctx context.Context, id primitive.ObjectID) (*ScheduledPosting, error)
+	// ListSchedules returns a page of the scheduled postings matching filter, by
+	// ExecuteAt, and their total
+	ListSchedules(ctx context.Context, filter ScheduleFilter, page Pagination) ([]ScheduledPosting, int64, error)
+	// ClaimSchedules returns up to limit pending postings due at now, oldest due first,
+	// moving their NextAttemptAt lease past now so no other runner claims them meanwhile
+	ClaimSchedules(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]ScheduledPosting, error)
+	// UpdateSchedule stores the execution state of sp: status, attempts, next attempt,
+	// last error, journal entry and settlement time. It returns false, changing nothing,
+	// when the stored posting is no longer pending.
+	UpdateSchedule(ctx context.Context, sp *ScheduledPosting) (bool, error)
+}
+
+// AccountJournalQuery selects the journal entries with a leg on an account by their
+// CreatedAt. Zero bounds are unset; Limit zero returns every entry.
+type AccountJournalQuery struct {
+	AccountID primitive.ObjectID
+	After     time.Time // exclusive lower bound
+	From      time.Time // inclusive lower bound
+	To        time.Time // inclusive upper bound
+	Before    time.Time // exclusive upper bound
+	Limit     int64
+}
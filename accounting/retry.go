@@ -0,0 +1,149 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// --------------------------
+//  Transaction Retries
+// --------------------------
+
+const (
+	// labelTransientTransaction marks an error the whole transaction can be retried after,
+	// e.g. a write conflict or a primary stepping down during a replica set election
+	labelTransientTransaction = "TransientTransactionError"
+	// labelUnknownCommitResult marks a commit that may or may not have been applied; only
+	// the commit is retried, running the transaction again could post it twice
+	labelUnknownCommitResult = "UnknownTransactionCommitResult"
+
+	codeWriteConflict = 112
+)
+
+// RetryPolicy bounds the retries of a MongoDB transaction failing with a transient error.
+// Zero fields take the values of DefaultRetryPolicy.
+type RetryPolicy struct {
+	MaxAttempts       int           // Attempts including the first one
+	BackoffInitial    time.Duration // Delay before the first retry
+	BackoffMultiplier float64       // Growth factor of the delay between retries
+	BackoffMax        time.Duration // Upper bound of the delay between retries
+}
+
+// DefaultRetryPolicy tries a transaction 5 times, waiting 50ms, 100ms, 200ms and 400ms
+// in between, enough to ride out a replica set election
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:       5,
+		BackoffInitial:    50 * time.Millisecond,
+		BackoffMultiplier: 2,
+		BackoffMax:        2 * time.Second,
+	}
+}
+
+// normalize fills the zero fields from DefaultRetryPolicy
+func (p RetryPolicy) normalize() RetryPolicy {
+	def := DefaultRetryPolicy()
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = def.MaxAttempts
+	}
+	if p.BackoffInitial <= 0 {
+		p.BackoffInitial = def.BackoffInitial
+	}
+	if p.BackoffMultiplier < 1 {
+		p.BackoffMultiplier = def.BackoffMultiplier
+	}
+	if p.BackoffMax < p.BackoffInitial {
+		p.BackoffMax = max(def.BackoffMax, p.BackoffInitial)
+	}
+	return p
+}
+
+// backoffDelay returns the wait before the given retry (1 for the first retry), growing
+// from BackoffInitial by BackoffMultiplier and capped at BackoffMax
+func (p RetryPolicy) backoffDelay(retry int) time.Duration {
+	delay := float64(p.BackoffInitial)
+	for i := 1; i < retry; i++ {
+		delay *= p.BackoffMultiplier
+		if delay >= float64(p.BackoffMax) {
+			return p.BackoffMax
+		}
+	}
+	return min(time.Duration(delay), p.BackoffMax)
+}
+
+// wait sleeps before the given retry, up to a quarter longer so concurrent postings
+// that conflicted do not retry in lockstep. It returns the ctx error when ctx is done.
+func (p RetryPolicy) wait(ctx context.Context, retry int) error {
+	delay := p.backoffDelay(retry)
+	delay += time.Duration(rand.Int64N(int64(delay)/4 + 1))
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// retry calls fn until it succeeds, fails with an error retryable says is permanent, or
+// MaxAttempts calls failed, in which case the last error is returned wrapped in a
+// *RetryExhaustedError
+func (p RetryPolicy) retry(ctx context.Context, retryable func(error) bool, fn func() error) error {
+	p = p.normalize()
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !retryable(err) {
+			return err
+		}
+		if attempt >= p.MaxAttempts {
+			return &RetryExhaustedError{Attempts: attempt, Err: err}
+		}
+		if err := p.wait(ctx, attempt); err != nil {
+			return err
+		}
+	}
+}
+
+// RetryExhaustedError is returned when a transaction still fails with a transient error
+// after the attempts of the retry policy. Err is the error of the last attempt; when it
+// carries the UnknownTransactionCommitResult label the postings may have been applied.
+type RetryExhaustedError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetryExhaustedError) Error() string {
+	return fmt.Sprintf("transaction failed after %d attempts: %v", e.Attempts, e.Err)
+}
+
+func (e *RetryExhaustedError) Unwrap() error {
+	return e.Err
+}
+
+// isTransientTxError reports whether the transaction failing with err can be run again
+func isTransientTxError(err error) bool {
+	if hasErrorLabel(err, labelUnknownCommitResult) {
+		return false
+	}
+	if hasErrorLabel(err, labelTransientTransaction) {
+		return true
+	}
+	var se mongo.ServerError
+	return errors.As(err, &se) && se.HasErrorCode(codeWriteConflict)
+}
+
+// isUnknownCommitResult reports whether the commit failing with err can be retried
+func isUnknownCommitResult(err error) bool {
+	return hasErrorLabel(err, labelUnknownCommitResult)
+}
+
+func hasErrorLabel(err error, label string) bool {
+	var le mongo.LabeledError
+	return errors.As(err, &le) && le.HasErrorLabel(label)
+}
@@ -0,0 +1,65 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestRetryPolicy_BackoffDelay(t *testing.T) {
+	p := RetryPolicy{BackoffInitial: 10 * time.Millisecond, BackoffMax: 50 * time.Millisecond}.normalize()
+	assert.Equal(t, 5, p.MaxAttempts)
+	assert.Equal(t, 10*time.Millisecond, p.backoffDelay(1))
+	assert.Equal(t, 40*time.Millisecond, p.backoffDelay(3))
+	assert.Equal(t, 50*time.Millisecond, p.backoffDelay(4))
+}
+
+func TestRetryPolicy_RetriesTransientErrors(t *testing.T) {
+	ctx := context.Background()
+	p := RetryPolicy{MaxAttempts: 3, BackoffInitial: time.Millisecond}
+	transient := mongo.CommandError{Code: 251, Labels: []string{labelTransientTransaction}}
+	writeConflict := fmt.Errorf("update balance: %w", mongo.CommandError{Code: codeWriteConflict})
+
+	calls := 0
+	err := p.retry(ctx, isTransientTxError, func() error {
+		if calls++; calls < 3 {
+			return writeConflict
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	err = p.retry(ctx, isTransientTxError, func() error {
+		calls++
+		return transient
+	})
+	var exhausted *RetryExhaustedError
+	assert.True(t, errors.As(err, &exhausted))
+	assert.Equal(t, 3, exhausted.Attempts)
+	assert.Equal(t, transient, exhausted.Err)
+
+	calls = 0
+	err = p.retry(ctx, isTransientTxError, func() error {
+		calls++
+		return ErrInsufficientFunds
+	})
+	assert.Equal(t, ErrInsufficientFunds, err)
+	assert.Equal(t, 1, calls)
+
+	// a commit with an unknown result must not run the transaction again
+	unknown := mongo.CommandError{Labels: []string{labelTransientTransaction, labelUnknownCommitResult}}
+	assert.False(t, isTransientTxError(unknown))
+	assert.True(t, isUnknownCommitResult(unknown))
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	err = RetryPolicy{BackoffInitial: time.Hour}.retry(cancelled, isTransientTxError, func() error { return transient })
+	assert.Equal(t, context.Canceled, err)
+}
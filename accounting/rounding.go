@@ -0,0 +1,108 @@
+package accounting
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// --------------------------
+//  Rounding Policy
+// --------------------------
+
+// maxRoundingScale bounds RoundingPolicy.Scale; Decimal128 balances keep 34 digits
+const maxRoundingScale = 18
+
+// RoundingMode selects how RoundingPolicy.Round drops the digits past the scale
+type RoundingMode string
+
+const (
+	RoundHalfUp   RoundingMode = "half_up"   // half away from zero: 0.125 -> 0.13, -0.125 -> -0.13
+	RoundHalfEven RoundingMode = "half_even" // banker's rounding: 0.125 -> 0.12, 0.135 -> 0.14
+	RoundDown     RoundingMode = "down"      // towards zero: 0.129 -> 0.12
+	RoundUp       RoundingMode = "up"        // away from zero: 0.121 -> 0.13
+)
+
+// RoundingPolicy is the precision of the ledger: amounts posted with more than Scale
+// decimal places are rejected with ErrExcessPrecision, and computed amounts, e.g.
+// commissions, and report figures are rounded to Scale with Mode.
+type RoundingPolicy struct {
+	Scale int32        // Decimal places of an amount, e.g. 2 for cents
+	Mode  RoundingMode // RoundHalfUp when empty
+}
+
+// Validate checks the scale and the mode
+func (p RoundingPolicy) Validate() error {
+	if p.Scale < 0 || p.Scale > maxRoundingScale {
+		return fmt.Errorf("rounding scale must be between 0 and %d, got %d", maxRoundingScale, p.Scale)
+	}
+	switch p.Mode {
+	case "", RoundHalfUp, RoundHalfEven, RoundDown, RoundUp:
+		return nil
+	}
+	return fmt.Errorf("unknown rounding mode %q", p.Mode)
+}
+
+// Round rounds d to Scale decimal places with Mode
+func (p RoundingPolicy) Round(d decimal.Decimal) decimal.Decimal {
+	switch p.Mode {
+	case RoundHalfEven:
+		return d.RoundBank(p.Scale)
+	case RoundDown:
+		return d.RoundDown(p.Scale)
+	case RoundUp:
+		return d.RoundUp(p.Scale)
+	}
+	return d.Round(p.Scale)
+}
+
+// Check returns ErrExcessPrecision when d has more than Scale significant decimal places
+func (p RoundingPolicy) Check(d decimal.Decimal) error {
+	if !d.Equal(d.Truncate(p.Scale)) {
+		return fmt.Errorf("%w: %s has more than %d decimal places", ErrExcessPrecision, d, p.Scale)
+	}
+	return nil
+}
+
+// SetRoundingPolicy applies p to every posting and report from now on. Entries already
+// posted are not changed, so reports round them. Without a policy amounts keep any
+// precision the balances can store.
+func (s *AccountingService) SetRoundingPolicy(p RoundingPolicy) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	s.rounding = &p
+	return nil
+}
+
+// RoundingPolicy returns the policy set by SetRoundingPolicy, false when there is none
+func (s *AccountingService) RoundingPolicy() (RoundingPolicy, bool) {
+	if s.rounding == nil {
+		return RoundingPolicy{}, false
+	}
+	return *s.rounding, true
+}
+
+// checkPrecision rejects an amount given to a posting that the policy cannot represent
+func (s *AccountingService) checkPrecision(d decimal.Decimal) error {
+	if s.rounding == nil {
+		return nil
+	}
+	return s.rounding.Check(d)
+}
+
+// round rounds a computed amount or report figure by the policy, when there is one
+func (s *AccountingService) round(d decimal.Decimal) decimal.Decimal {
+	if s.rounding == nil {
+		return d
+	}
+	return s.rounding.Round(d)
+}
+
+// roundLines rounds the amounts and running balances of statement lines
+func (s *AccountingService) roundLines(lines []StatementLine) {
+	for i := range lines {
+		lines[i].Amount = s.round(lines[i].Amount)
+		lines[i].Balance = s.round(lines[i].Balance)
+	}
+}
@@ -0,0 +1,81 @@
+package accounting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundingPolicy_Round(t *testing.T) {
+	cases := []struct {
+		mode     RoundingMode
+		in, want string
+	}{
+		{"", "0.125", "0.13"},
+		{RoundHalfUp, "-0.125", "-0.13"},
+		{RoundHalfEven, "0.125", "0.12"},
+		{RoundHalfEven, "0.135", "0.14"},
+		{RoundDown, "0.129", "0.12"},
+		{RoundDown, "-0.129", "-0.12"},
+		{RoundUp, "0.121", "0.13"},
+		{RoundUp, "0.12", "0.12"},
+	}
+	for _, c := range cases {
+		got := RoundingPolicy{Scale: 2, Mode: c.mode}.Round(decimal.RequireFromString(c.in))
+		assert.True(t, decimal.RequireFromString(c.want).Equal(got), "%s %s: got %s", c.mode, c.in, got)
+	}
+
+	assert.NoError(t, RoundingPolicy{Scale: 0, Mode: RoundUp}.Validate())
+	assert.Error(t, RoundingPolicy{Scale: -1}.Validate())
+	assert.Error(t, RoundingPolicy{Scale: maxRoundingScale + 1}.Validate())
+	assert.Error(t, RoundingPolicy{Scale: 2, Mode: "ceiling"}.Validate())
+
+	p := RoundingPolicy{Scale: 2}
+	assert.NoError(t, p.Check(decimal.RequireFromString("10.100")))
+	assert.ErrorIs(t, p.Check(decimal.RequireFromString("10.001")), ErrExcessPrecision)
+}
+
+func TestSetRoundingPolicy_Postings(t *testing.T) {
+	ctx := context.Background()
+	s := NewAccountingServiceWithRepository(NewMemoryRepository())
+	gateway, _ := s.CreateAccount(ctx, PaymentGateway, decimal.Zero, "gateway")
+	client, _ := s.CreateAccount(ctx, ClientInsurance, decimal.Zero, "client")
+	// posted before the policy, kept as is
+	require.NoError(t, s.ClientAccountTopUp(ctx, client.ID, gateway.ID, decimal.RequireFromString("10.125"), "GW-1"))
+
+	assert.Error(t, s.SetRoundingPolicy(RoundingPolicy{Scale: 2, Mode: "ceiling"}))
+	_, ok := s.RoundingPolicy()
+	assert.False(t, ok)
+	require.NoError(t, s.SetRoundingPolicy(RoundingPolicy{Scale: 2, Mode: RoundHalfEven}))
+
+	err := s.ClientAccountTopUp(ctx, client.ID, gateway.ID, decimal.RequireFromString("5.001"), "GW-2")
+	assert.ErrorIs(t, err, ErrExcessPrecision)
+	require.NoError(t, s.ClientAccountTopUp(ctx, client.ID, gateway.ID, decimal.RequireFromString("5.10"), "GW-3"))
+	_, err = s.PostJournal(ctx, []JournalLeg{
+		DebitLeg(gateway.ID, decimal.RequireFromString("1.005")),
+		CreditLeg(client.ID, decimal.RequireFromString("1.005")),
+	}, "GW-4", TopUp)
+	assert.ErrorIs(t, err, ErrExcessPrecision)
+	_, err = s.CreateAccount(ctx, ClientInsurance, decimal.RequireFromString("0.001"), "precise")
+	assert.ErrorIs(t, err, ErrExcessPrecision)
+	assert.ErrorIs(t, s.SetOverdraftPolicy(ctx, client.ID, true, decimal.RequireFromString("0.5555")), ErrExcessPrecision)
+
+	balance, _ := s.GetAccountBalance(ctx, client.ID)
+	assert.Equal(t, "15.225", balance.String(), "balances keep the stored precision")
+
+	stmt, err := s.GetAccountStatement(ctx, client.ID, time.Time{}, time.Time{}, Pagination{})
+	require.NoError(t, err)
+	require.Len(t, stmt.Lines, 2)
+	assert.Equal(t, "10.12", stmt.Lines[0].Amount.String())
+	assert.Equal(t, "15.22", stmt.Lines[1].Balance.String())
+	assert.Equal(t, "15.22", stmt.ClosingBalance.String())
+
+	tb, err := s.GetTrialBalance(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "15.22", tb.TotalCredit.String())
+	assert.True(t, tb.Balanced())
+}
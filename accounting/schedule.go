@@ -0,0 +1,358 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// --------------------------
+//  Scheduled Postings
+// --------------------------
+
+const (
+	defaultScheduleBatch        = 100
+	defaultSchedulePollInterval = 10 * time.Second
+	defaultScheduleLease        = time.Minute
+	defaultScheduleMaxAttempts  = 3
+	defaultScheduleRetryDelay   = time.Hour
+
+	// scheduleMetadataKey names the scheduled posting in the metadata of the entry it posted
+	scheduleMetadataKey = "schedule_id"
+)
+
+// ScheduleStatus is the lifecycle state of a scheduled posting
+type ScheduleStatus string
+
+const (
+	SchedulePending   ScheduleStatus = "pending"
+	ScheduleExecuted  ScheduleStatus = "executed"
+	ScheduleFailed    ScheduleStatus = "failed" // every attempt failed, see ScheduleRunnerConfig.MaxAttempts
+	ScheduleCancelled ScheduleStatus = "cancelled"
+)
+
+// ScheduledPosting is a posting stored by SchedulePosting and posted by a ScheduleRunner
+// once ExecuteAt is reached, e.g. an instalment of a premium plan or a commission
+// released after a cooling-off period
+type ScheduledPosting struct {
+	ID            primitive.ObjectID  `bson:"_id" json:"id"`
+	Type          TransactionType     `bson:"type" json:"type"`
+	DebitAccount  primitive.ObjectID  `bson:"debit_account" json:"debit_account_id"`
+	CreditAccount primitive.ObjectID  `bson:"credit_account" json:"credit_account_id"`
+	Amount        string              `bson:"amount" json:"amount"`
+	TranRef       string              `bson:"tranref" json:"tranref"`
+	Narrative     string              `bson:"narrative,omitempty" json:"narrative,omitempty"`
+	Metadata      map[string]string   `bson:"metadata,omitempty" json:"metadata,omitempty"`
+	ExecuteAt     time.Time           `bson:"execute_at" json:"execute_at"`
+	Status        ScheduleStatus      `bson:"status" json:"status"`
+	Attempts      int                 `bson:"attempts" json:"attempts"`
+	NextAttemptAt time.Time           `bson:"next_attempt_at" json:"next_attempt_at"`
+	LastError     string              `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	JournalID     *primitive.ObjectID `bson:"journal_id,omitempty" json:"journal_id,omitempty"` // entry posted when executed
+	CreatedAt     time.Time           `bson:"created_at" json:"created_at"`
+	SettledAt     *time.Time          `bson:"settled_at,omitempty" json:"settled_at,omitempty"` // when it left pending
+}
+
+func (sp *ScheduledPosting) GetAmount() decimal.Decimal {
+	amount, _ := decimal.NewFromString(sp.Amount)
+	return amount
+}
+
+// Request returns the posting the schedule executes
+func (sp *ScheduledPosting) Request() PostingRequest {
+	return PostingRequest{
+		Type:          sp.Type,
+		DebitAccount:  sp.DebitAccount,
+		CreditAccount: sp.CreditAccount,
+		Amount:        sp.GetAmount(),
+		TranRef:       sp.TranRef,
+		Narrative:     sp.Narrative,
+		Metadata:      sp.Metadata,
+	}
+}
+
+// ScheduleFilter selects scheduled postings. Zero fields match every posting.
+type ScheduleFilter struct {
+	Status    ScheduleStatus
+	AccountID primitive.ObjectID // Debited or credited account
+	From      time.Time          // Inclusive lower bound of ExecuteAt
+	To        time.Time          // Inclusive upper bound of ExecuteAt
+}
+
+func (f ScheduleFilter) validate() error {
+	if !f.From.IsZero() && !f.To.IsZero() && f.To.Before(f.From) {
+		return fmt.Errorf("%w: date range ends before it starts", ErrInvalidFilter)
+	}
+	return nil
+}
+
+func (f ScheduleFilter) query() (bson.M, error) {
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+	q := bson.M{}
+	if f.Status != "" {
+		q["status"] = f.Status
+	}
+	if !f.AccountID.IsZero() {
+		q["$or"] = bson.A{bson.M{"debit_account": f.AccountID}, bson.M{"credit_account": f.AccountID}}
+	}
+	at := bson.M{}
+	if !f.From.IsZero() {
+		at["$gte"] = f.From
+	}
+	if !f.To.IsZero() {
+		at["$lte"] = f.To
+	}
+	if len(at) > 0 {
+		q["execute_at"] = at
+	}
+	return q, nil
+}
+
+// SchedulePosting stores posting for execution at executeAt by a ScheduleRunner. The
+// amount, metadata and posting rule are checked now, balances and account statuses when
+// it executes. A time in the past executes on the next run.
+func (s *AccountingService) SchedulePosting(ctx context.Context, posting PostingRequest, executeAt time.Time) (*ScheduledPosting, error) {
+	if executeAt.IsZero() {
+		return nil, fmt.Errorf("%w: no execution time", ErrInvalidSchedule)
+	}
+	// validates the amount and the options the way the posting will
+	entry, err := s.newDoubleEntry(posting.Type, posting.Amount, posting.DebitAccount, posting.CreditAccount, posting.TranRef, posting.options())
+	if err != nil {
+		return nil, err
+	}
+	debitType, creditType, err := s.accountTypes(ctx, posting.DebitAccount, posting.CreditAccount)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.postingRules().Check(posting.Type, debitType, creditType); err != nil {
+		return nil, err
+	}
+
+	sp := &ScheduledPosting{
+		ID:            primitive.NewObjectID(),
+		Type:          posting.Type,
+		DebitAccount:  posting.DebitAccount,
+		CreditAccount: posting.CreditAccount,
+		Amount:        entry.Amount,
+		TranRef:       posting.TranRef,
+		Narrative:     entry.Narrative,
+		Metadata:      entry.Metadata,
+		ExecuteAt:     executeAt,
+		Status:        SchedulePending,
+		NextAttemptAt: executeAt,
+		CreatedAt:     time.Now(),
+	}
+	if err := s.repo.InsertSchedule(ctx, sp); err != nil {
+		return nil, err
+	}
+	return sp, nil
+}
+
+// GetScheduledPosting returns a scheduled posting, ErrScheduleNotFound when it does not exist
+func (s *AccountingService) GetScheduledPosting(ctx context.Context, id primitive.ObjectID) (*ScheduledPosting, error) {
+	return s.repo.GetSchedule(ctx, id)
+}
+
+// ListScheduledPostings returns a page of the scheduled postings matching filter, by
+// execution time, and their total
+func (s *AccountingService) ListScheduledPostings(ctx context.Context, filter ScheduleFilter, page Pagination) ([]ScheduledPosting, int64, error) {
+	if err := filter.validate(); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListSchedules(ctx, filter, page.normalize())
+}
+
+// CancelScheduledPosting cancels a pending scheduled posting, ErrScheduleNotPending when
+// it already executed, failed or was cancelled
+func (s *AccountingService) CancelScheduledPosting(ctx context.Context, id primitive.ObjectID, reason string) (*ScheduledPosting, error) {
+	var cancelled *ScheduledPosting
+	err := s.runInTransaction(ctx, func(sc context.Context) error {
+		sp, err := s.repo.GetSchedule(sc, id)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		sp.Status, sp.SettledAt, sp.LastError = ScheduleCancelled, &now, reason
+		ok, err := s.repo.UpdateSchedule(sc, sp)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrScheduleNotPending, id.Hex())
+		}
+		cancelled = sp
+		return s.audit(sc, AuditScheduleCancelled, id, reason,
+			map[string]string{"status": string(SchedulePending)},
+			map[string]string{"status": string(ScheduleCancelled)})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cancelled, nil
+}
+
+// --------------------------
+//  Schedule Runner
+// --------------------------
+
+// ScheduleRunnerConfig configures a ScheduleRunner
+type ScheduleRunnerConfig struct {
+	BatchSize    int           // Postings claimed per poll, defaults to 100
+	PollInterval time.Duration // Wait between polls finding no due posting, defaults to 10s
+	Lease        time.Duration // How long a claimed posting is hidden from other runners, defaults to 1m
+	MaxAttempts  int           // Attempts before a posting is marked failed, defaults to 3
+	RetryDelay   time.Duration // Wait before retrying a failed attempt, defaults to 1h
+	OnError      func(sp *ScheduledPosting, err error)
+}
+
+// ScheduleRunner executes the due scheduled postings. A posting is marked executed in
+// the transaction that posts it, so it is posted exactly once even when several runners
+// claim it or a runner stops halfway. Failed attempts, e.g. for insufficient funds, are
+// retried after RetryDelay until MaxAttempts.
+type ScheduleRunner struct {
+	svc *AccountingService
+	cfg ScheduleRunnerConfig
+	now func() time.Time
+}
+
+// NewScheduleRunner returns the runner of the scheduled postings of s
+func NewScheduleRunner(s *AccountingService, cfg ScheduleRunnerConfig) *ScheduleRunner {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultScheduleBatch
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultSchedulePollInterval
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = defaultScheduleLease
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultScheduleMaxAttempts
+	}
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = defaultScheduleRetryDelay
+	}
+	return &ScheduleRunner{svc: s, cfg: cfg, now: time.Now}
+}
+
+// RunOnce claims a batch of due postings and executes them. It returns the number of
+// postings claimed; posting failures are recorded on the postings and reported to OnError.
+func (r *ScheduleRunner) RunOnce(ctx context.Context) (int, error) {
+	due, err := r.svc.repo.ClaimSchedules(ctx, r.now(), r.cfg.BatchSize, r.cfg.Lease)
+	if err != nil {
+		return 0, fmt.Errorf("claim scheduled postings: %w", err)
+	}
+	for i := range due {
+		if err := r.process(ctx, &due[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(due), nil
+}
+
+// Start polls the due postings until ctx is cancelled, immediately again after a full batch
+func (r *ScheduleRunner) Start(ctx context.Context) {
+	go func() {
+		for {
+			n, err := r.RunOnce(ctx)
+			if err != nil && r.cfg.OnError != nil {
+				r.cfg.OnError(nil, err)
+			}
+			if err == nil && n == r.cfg.BatchSize && ctx.Err() == nil {
+				continue
+			}
+			timer := time.NewTimer(r.cfg.PollInterval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+}
+
+// process executes sp and records a failed attempt
+func (r *ScheduleRunner) process(ctx context.Context, sp *ScheduledPosting) error {
+	err := r.execute(ctx, sp)
+	if err == nil || errors.Is(err, ErrScheduleNotPending) {
+		// executed, or settled by another runner or a cancellation meanwhile
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if r.cfg.OnError != nil {
+		r.cfg.OnError(sp, err)
+	}
+
+	now := r.now()
+	sp.Attempts++
+	sp.LastError = err.Error()
+	sp.NextAttemptAt = now.Add(r.cfg.RetryDelay)
+	if sp.Attempts >= r.cfg.MaxAttempts {
+		sp.Status, sp.SettledAt = ScheduleFailed, &now
+	}
+	if _, err := r.svc.repo.UpdateSchedule(ctx, sp); err != nil {
+		return fmt.Errorf("update scheduled posting %s: %w", sp.ID.Hex(), err)
+	}
+	return nil
+}
+
+// execute posts sp and marks it executed in the same transaction. The entry carries the
+// schedule ID in its metadata.
+func (r *ScheduleRunner) execute(ctx context.Context, sp *ScheduledPosting) error {
+	req := sp.Request()
+	opts := append(req.options(), WithMetadata(map[string]string{scheduleMetadataKey: sp.ID.Hex()}))
+	entry, err := r.svc.newDoubleEntry(sp.Type, req.Amount, sp.DebitAccount, sp.CreditAccount, sp.TranRef, opts)
+	if err != nil {
+		return err
+	}
+	executed := func(journalID primitive.ObjectID) *ScheduledPosting {
+		done := *sp
+		now := r.now()
+		done.Status, done.JournalID, done.SettledAt = ScheduleExecuted, &journalID, &now
+		done.Attempts++
+		done.LastError = ""
+		return &done
+	}
+
+	posted, err := r.svc.postEntry(ctx, entry, func(sc context.Context) error {
+		debitType, creditType, err := r.svc.accountTypes(sc, sp.DebitAccount, sp.CreditAccount)
+		if err != nil {
+			return err
+		}
+		if err := r.svc.postingRules().Check(sp.Type, debitType, creditType); err != nil {
+			return err
+		}
+		return r.settle(sc, executed(entry.ID))
+	})
+	if err != nil {
+		return err
+	}
+	if posted.ID != entry.ID {
+		// idempotent postings returned the entry already posted under the reference
+		return r.settle(ctx, executed(posted.ID))
+	}
+	return nil
+}
+
+// settle stores the final state of a pending posting, ErrScheduleNotPending when it was
+// settled meanwhile
+func (r *ScheduleRunner) settle(ctx context.Context, sp *ScheduledPosting) error {
+	ok, err := r.svc.repo.UpdateSchedule(ctx, sp)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrScheduleNotPending, sp.ID.Hex())
+	}
+	return nil
+}
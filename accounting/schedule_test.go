@@ -0,0 +1,177 @@
+package accounting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestScheduleRunner_ExecutesDuePostingsOnce(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	s := NewAccountingServiceWithRepository(repo)
+
+	client, _ := s.CreateAccount(ctx, ClientInsurance, decimal.NewFromInt(100), "client")
+	underwriter, _ := s.CreateAccount(ctx, UnderwriterPremiumPayable, decimal.Zero, "underwriter")
+	gateway, _ := s.CreateAccount(ctx, PaymentGateway, decimal.Zero, "gateway")
+
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	instalment := func(n int, at time.Time) *ScheduledPosting {
+		sp, err := s.SchedulePosting(ctx, PostingRequest{
+			Type:          PremiumPayment,
+			DebitAccount:  client.ID,
+			CreditAccount: underwriter.ID,
+			Amount:        decimal.NewFromInt(40),
+			TranRef:       "PLAN1-" + string(rune('0'+n)),
+			Metadata:      map[string]string{"plan": "PLAN1"},
+		}, at)
+		require.NoError(t, err)
+		return sp
+	}
+	first := instalment(1, now)
+	second := instalment(2, now.AddDate(0, 1, 0))
+
+	runner := NewScheduleRunner(s, ScheduleRunnerConfig{})
+	runner.now = func() time.Time { return now }
+	n, err := runner.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	done, err := s.GetScheduledPosting(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ScheduleExecuted, done.Status)
+	require.NotNil(t, done.JournalID)
+	entry, err := s.repo.GetJournal(ctx, *done.JournalID)
+	require.NoError(t, err)
+	assert.Equal(t, "PLAN1-1", entry.TranRef)
+	assert.Equal(t, map[string]string{"plan": "PLAN1", scheduleMetadataKey: first.ID.Hex()}, entry.Metadata)
+	balance, _ := s.GetAccountBalance(ctx, client.ID)
+	assert.True(t, decimal.NewFromInt(60).Equal(balance), balance.String())
+
+	// a runner holding a stale claim does not post it again
+	stale := *first
+	require.NoError(t, runner.process(ctx, &stale))
+	entries, _ := s.repo.JournalsByRef(ctx, "PLAN1-1")
+	assert.Len(t, entries, 1)
+
+	// the second instalment fails for insufficient funds until the client tops up
+	runner.now = func() time.Time { return now.AddDate(0, 1, 0) }
+	var failures []error
+	runner.cfg.OnError = func(_ *ScheduledPosting, err error) { failures = append(failures, err) }
+	require.NoError(t, s.ClientPremiumPayment(ctx, client.ID, underwriter.ID, decimal.NewFromInt(30), "OTHER"))
+	_, err = runner.RunOnce(ctx)
+	require.NoError(t, err)
+	require.Len(t, failures, 1)
+	assert.ErrorIs(t, failures[0], ErrInsufficientFunds)
+	pending, _ := s.GetScheduledPosting(ctx, second.ID)
+	assert.Equal(t, SchedulePending, pending.Status)
+	assert.Equal(t, 1, pending.Attempts)
+	assert.Equal(t, now.AddDate(0, 1, 0).Add(time.Hour), pending.NextAttemptAt)
+
+	// not due again before the retry delay
+	n, err = runner.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	require.NoError(t, s.ClientAccountTopUp(ctx, client.ID, gateway.ID, decimal.NewFromInt(50), "TOPUP"))
+	runner.now = func() time.Time { return now.AddDate(0, 1, 0).Add(time.Hour) }
+	n, err = runner.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	done, _ = s.GetScheduledPosting(ctx, second.ID)
+	assert.Equal(t, ScheduleExecuted, done.Status)
+	assert.Equal(t, 2, done.Attempts)
+	assert.Empty(t, done.LastError)
+
+	executed, total, err := s.ListScheduledPostings(ctx, ScheduleFilter{Status: ScheduleExecuted, AccountID: client.ID}, Pagination{})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+	assert.Equal(t, first.ID, executed[0].ID)
+}
+
+func TestScheduleRunner_FailsAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	s := NewAccountingServiceWithRepository(NewMemoryRepository())
+	underwriter, _ := s.CreateAccount(ctx, UnderwriterPremiumPayable, decimal.Zero, "underwriter")
+	agent, _ := s.CreateAccount(ctx, AgentCommissionEarned, decimal.Zero, "agent")
+
+	now := time.Now()
+	sp, err := s.SchedulePosting(ctx, PostingRequest{
+		Type:          CommissionPayment,
+		DebitAccount:  underwriter.ID,
+		CreditAccount: agent.ID,
+		Amount:        decimal.NewFromInt(10),
+		TranRef:       "COMM1",
+	}, now)
+	require.NoError(t, err)
+	// a commission held back while the agent is suspended
+	require.NoError(t, s.FreezeAccount(ctx, agent.ID, "suspended"))
+
+	runner := NewScheduleRunner(s, ScheduleRunnerConfig{MaxAttempts: 2, RetryDelay: time.Minute})
+	runner.now = func() time.Time { return now }
+	_, err = runner.RunOnce(ctx)
+	require.NoError(t, err)
+	runner.now = func() time.Time { return now.Add(time.Minute) }
+	_, err = runner.RunOnce(ctx)
+	require.NoError(t, err)
+
+	failed, _ := s.GetScheduledPosting(ctx, sp.ID)
+	assert.Equal(t, ScheduleFailed, failed.Status)
+	assert.Equal(t, 2, failed.Attempts)
+	assert.Contains(t, failed.LastError, ErrAccountFrozen.Error())
+	assert.NotNil(t, failed.SettledAt)
+
+	_, err = s.CancelScheduledPosting(ctx, sp.ID, "too late")
+	assert.ErrorIs(t, err, ErrScheduleNotPending)
+}
+
+func TestSchedulePosting_ValidatesAndCancels(t *testing.T) {
+	ctx := WithActor(context.Background(), Actor{ID: "ops-1"})
+	s := NewAccountingServiceWithRepository(NewMemoryRepository())
+	client, _ := s.CreateAccount(ctx, ClientInsurance, decimal.Zero, "client")
+	underwriter, _ := s.CreateAccount(ctx, UnderwriterPremiumPayable, decimal.Zero, "underwriter")
+
+	req := PostingRequest{Type: PremiumPayment, DebitAccount: client.ID, CreditAccount: underwriter.ID, Amount: decimal.NewFromInt(5), TranRef: "P1"}
+	_, err := s.SchedulePosting(ctx, req, time.Time{})
+	assert.ErrorIs(t, err, ErrInvalidSchedule)
+
+	bad := req
+	bad.Amount = decimal.Zero
+	_, err = s.SchedulePosting(ctx, bad, time.Now())
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+
+	bad = req
+	bad.DebitAccount, bad.CreditAccount = underwriter.ID, client.ID
+	_, err = s.SchedulePosting(ctx, bad, time.Now())
+	assert.ErrorIs(t, err, ErrPostingRule)
+
+	sp, err := s.SchedulePosting(ctx, req, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	cancelled, err := s.CancelScheduledPosting(ctx, sp.ID, "policy cancelled")
+	require.NoError(t, err)
+	assert.Equal(t, ScheduleCancelled, cancelled.Status)
+
+	records, _, err := s.ListAuditRecords(ctx, AuditFilter{EntityID: sp.ID.Hex()}, Pagination{})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, AuditScheduleCancelled, records[0].Action)
+	assert.Equal(t, "ops-1", records[0].Actor.ID)
+
+	_, err = s.CancelScheduledPosting(ctx, sp.ID, "again")
+	assert.ErrorIs(t, err, ErrScheduleNotPending)
+	_, err = s.GetScheduledPosting(ctx, client.ID)
+	assert.ErrorIs(t, err, ErrScheduleNotFound)
+}
+
+func TestPgScheduleFilterWhere(t *testing.T) {
+	accID := primitive.NewObjectID()
+	to := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+	where, args := pgScheduleFilterWhere(ScheduleFilter{Status: SchedulePending, AccountID: accID, To: to})
+	assert.Equal(t, " WHERE status = $1 AND (debit_account = $2 OR credit_account = $2) AND execute_at <= $3", where)
+	assert.Equal(t, []any{"pending", accID.Hex(), to}, args)
+}
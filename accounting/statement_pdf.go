@@ -0,0 +1,338 @@
+package accounting
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // logo formats
+	_ "image/png"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/nana-tec/gopackages/internal/pdf"
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// --------------------------
+//  PDF Statements
+// --------------------------
+
+// StatementPeriod bounds a statement, both inclusive. A zero From starts at the creation
+// of the account and a zero To ends now.
+type StatementPeriod struct {
+	From time.Time
+	To   time.Time
+}
+
+// StatementBranding is the letterhead of the statements of RenderStatementPDF
+type StatementBranding struct {
+	CompanyName string
+	Address     []string       // Lines printed under the company name
+	Logo        []byte         // PNG or JPEG image, printed at the top left
+	Footer      string         // Printed at the bottom of every page, e.g. a regulatory notice
+	Color       color.Color    // Accent of the title and the table header, defaults to a dark blue
+	Location    *time.Location // Time zone of the dates, defaults to UTC
+}
+
+// SetStatementBranding sets the letterhead of the PDF statements. It fails when the
+// logo is not a PNG or JPEG image.
+func (s *AccountingService) SetStatementBranding(b StatementBranding) error {
+	var logo image.Image
+	if len(b.Logo) > 0 {
+		var err error
+		if logo, _, err = image.Decode(bytes.NewReader(b.Logo)); err != nil {
+			return fmt.Errorf("statement logo: %w", err)
+		}
+	}
+	s.branding, s.logo = b, logo
+	return nil
+}
+
+// Statement layout in points, see package pdf
+const (
+	stmtMargin    = 40.0
+	stmtRowHeight = 15.0
+	stmtFontSize  = 8.0
+	stmtLogoH     = 48.0
+	stmtLogoW     = 160.0
+	stmtBottom    = pdf.PageHeight - 60 // lowest baseline of a table row
+)
+
+// stmtColumns are the columns of the statement table; amounts are right aligned at X+W
+var stmtColumns = []struct {
+	Title string
+	X, W  float64
+	Right bool
+}{
+	{"Date", stmtMargin, 62, false},
+	{"Reference", 102, 88, false},
+	{"Description", 194, 140, false},
+	{"Debit", 334, 70, true},
+	{"Credit", 404, 70, true},
+	{"Balance", 474, pdf.PageWidth - stmtMargin - 474, true},
+}
+
+var (
+	stmtDefaultColor = color.RGBA{R: 0x1f, G: 0x3a, B: 0x5f, A: 0xff}
+	stmtGrey         = color.Gray{Y: 0x66}
+	stmtStripe       = color.Gray{Y: 0xf2}
+)
+
+// RenderStatementPDF writes the statement of an account over period as a PDF to send to
+// the client: the letterhead set by SetStatementBranding, the account details, a summary
+// of the opening balance, total debits and credits and the closing balance, and a line
+// per journal entry with its running balance. The document is built before anything is
+// written, so w receives nothing when it fails.
+func (s *AccountingService) RenderStatementPDF(ctx context.Context, accountID primitive.ObjectID, period StatementPeriod, w io.Writer) error {
+	acc, err := s.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	from, to := period.From, period.To
+	if from.IsZero() || from.Before(acc.CreatedAt) {
+		from = acc.CreatedAt
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if to.Before(from) {
+		return fmt.Errorf("%w: statement ends before it starts", ErrInvalidFilter)
+	}
+	opening, err := s.balanceAt(ctx, acc, from, false)
+	if err != nil {
+		return err
+	}
+
+	r := s.newStatementRenderer(acc)
+	r.header(from, to)
+	summaryY := r.y
+	r.y += 78
+	r.tableHeader()
+
+	balance, debits, credits := opening, decimal.Zero, decimal.Zero
+	r.row("", "", "Opening balance", "", "", r.amount(opening))
+	err = s.streamAccountJournals(ctx, acc.ID, from, to, func(e *JournalEntry) error {
+		line := statementLines(acc.ID, balance, []JournalEntry{*e})[0]
+		balance = line.Balance
+		debit, credit := "", ""
+		if line.Direction == DirectionDebit {
+			debits = debits.Add(line.Amount)
+			debit = r.amount(line.Amount)
+		} else {
+			credits = credits.Add(line.Amount)
+			credit = r.amount(line.Amount)
+		}
+		description := line.Narrative
+		if description == "" {
+			description = string(line.Type)
+		}
+		r.row(r.date(line.CreatedAt), line.TranRef, description, debit, credit, r.amount(line.Balance))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	r.row("", "", "Closing balance", "", "", r.amount(balance))
+	r.summary(summaryY, opening, debits, credits, balance)
+	r.footers()
+	_, err = r.doc.WriteTo(w)
+	return err
+}
+
+// statementRenderer lays out a statement, page by page
+type statementRenderer struct {
+	doc      *pdf.Document
+	page     *pdf.Page
+	y        float64 // baseline of the next text
+	rows     int
+	acc      *Account
+	branding StatementBranding
+	logo     *pdf.Image
+	accent   color.Color
+	rounding RoundingPolicy // of the amounts, two decimals without a policy
+}
+
+func (s *AccountingService) newStatementRenderer(acc *Account) *statementRenderer {
+	r := &statementRenderer{
+		doc:      pdf.New("Account statement " + acc.ID.Hex()),
+		acc:      acc,
+		branding: s.branding,
+		accent:   s.branding.Color,
+		rounding: RoundingPolicy{Scale: 2},
+	}
+	if p, ok := s.RoundingPolicy(); ok {
+		r.rounding = p
+	}
+	if r.accent == nil {
+		r.accent = stmtDefaultColor
+	}
+	if s.logo != nil {
+		r.logo = r.doc.AddImage(s.logo)
+	}
+	r.page = r.doc.AddPage()
+	return r
+}
+
+// amount formats d rounded by the rounding policy
+func (r *statementRenderer) amount(d decimal.Decimal) string {
+	return formatStatementAmount(r.rounding.Round(d), r.rounding.Scale)
+}
+
+func (r *statementRenderer) date(t time.Time) string {
+	loc := r.branding.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	return t.In(loc).Format("02 Jan 2006")
+}
+
+// header draws the letterhead and the account details of the first page
+func (r *statementRenderer) header(from, to time.Time) {
+	top := stmtMargin
+	if r.logo != nil {
+		w, h := stmtLogoW, stmtLogoW*float64(r.logo.Height)/float64(r.logo.Width)
+		if h > stmtLogoH {
+			w, h = stmtLogoH*float64(r.logo.Width)/float64(r.logo.Height), stmtLogoH
+		}
+		r.page.Image(r.logo, stmtMargin, top, w, h)
+	}
+	right := pdf.PageWidth - stmtMargin
+	y := top + 12
+	if r.branding.CompanyName != "" {
+		r.page.TextRight(right, y, pdf.HelveticaBold, 13, r.accent, r.branding.CompanyName)
+		y += 13
+	}
+	for _, line := range r.branding.Address {
+		r.page.TextRight(right, y, pdf.Helvetica, stmtFontSize+1, stmtGrey, line)
+		y += 11
+	}
+
+	r.y = max(y, top+stmtLogoH) + 30
+	r.page.Text(stmtMargin, r.y, pdf.HelveticaBold, 16, r.accent, "Account Statement")
+	r.y += 22
+	details := [][2]string{
+		{"Account", r.acc.Name},
+		{"Account ID", r.acc.ID.Hex()},
+		{"Period", r.date(from) + " - " + r.date(to)},
+		{"Issued", r.date(time.Now())},
+	}
+	for _, d := range details {
+		r.page.Text(stmtMargin, r.y, pdf.HelveticaBold, stmtFontSize+1, nil, d[0])
+		r.page.Text(stmtMargin+70, r.y, pdf.Helvetica, stmtFontSize+1, nil, d[1])
+		r.y += 13
+	}
+	r.y += 10
+}
+
+// summary draws the totals box below the account details, at y
+func (r *statementRenderer) summary(y float64, opening, debits, credits, closing decimal.Decimal) {
+	p := r.doc.Page(0)
+	width := pdf.PageWidth - 2*stmtMargin
+	p.Rect(stmtMargin, y, width, 56, stmtStripe)
+	totals := [][2]string{
+		{"Opening balance", r.amount(opening)},
+		{"Total debits", r.amount(debits)},
+		{"Total credits", r.amount(credits)},
+		{"Closing balance", r.amount(closing)},
+	}
+	cell := width / float64(len(totals))
+	for i, t := range totals {
+		x := stmtMargin + float64(i)*cell + 10
+		p.Text(x, y+20, pdf.Helvetica, stmtFontSize, stmtGrey, t[0])
+		p.Text(x, y+40, pdf.HelveticaBold, 12, r.accent, t[1])
+	}
+}
+
+// tableHeader draws the column titles at the current position
+func (r *statementRenderer) tableHeader() {
+	r.page.Rect(stmtMargin, r.y, pdf.PageWidth-2*stmtMargin, stmtRowHeight+2, r.accent)
+	for _, c := range stmtColumns {
+		r.cell(c.X, c.W, c.Right, r.y+11, pdf.HelveticaBold, color.White, c.Title)
+	}
+	r.y += stmtRowHeight + 2
+	r.rows = 0
+}
+
+// row draws a table row, starting a new page when the current one is full
+func (r *statementRenderer) row(values ...string) {
+	if r.y+stmtRowHeight > stmtBottom {
+		r.page = r.doc.AddPage()
+		r.y = stmtMargin
+		r.tableHeader()
+	}
+	if r.rows%2 == 1 {
+		r.page.Rect(stmtMargin, r.y, pdf.PageWidth-2*stmtMargin, stmtRowHeight, stmtStripe)
+	}
+	font := pdf.Helvetica
+	if values[0] == "" {
+		// opening and closing balance
+		font = pdf.HelveticaBold
+	}
+	for i, c := range stmtColumns {
+		r.cell(c.X, c.W, c.Right, r.y+10, font, nil, values[i])
+	}
+	r.y += stmtRowHeight
+	r.rows++
+}
+
+// cell draws text in a column, cut to its width
+func (r *statementRenderer) cell(x, w float64, right bool, y float64, font pdf.Font, c color.Color, text string) {
+	const padding = 4
+	if text == "" {
+		return
+	}
+	text = fitText(font, stmtFontSize, text, w-2*padding)
+	if right {
+		r.page.TextRight(x+w-padding, y, font, stmtFontSize, c, text)
+		return
+	}
+	r.page.Text(x+padding, y, font, stmtFontSize, c, text)
+}
+
+// footers draws the footer and page number of every page
+func (r *statementRenderer) footers() {
+	y := pdf.PageHeight - 30
+	for i := range r.doc.Pages() {
+		p := r.doc.Page(i)
+		p.Line(stmtMargin, y-12, pdf.PageWidth-stmtMargin, y-12, 0.5, stmtGrey)
+		if r.branding.Footer != "" {
+			p.Text(stmtMargin, y, pdf.Helvetica, stmtFontSize-1, stmtGrey, fitText(pdf.Helvetica, stmtFontSize-1, r.branding.Footer, 400))
+		}
+		p.TextRight(pdf.PageWidth-stmtMargin, y, pdf.Helvetica, stmtFontSize-1, stmtGrey, fmt.Sprintf("Page %d of %d", i+1, r.doc.Pages()))
+	}
+}
+
+// fitText cuts text with an ellipsis so it is at most width wide
+func fitText(font pdf.Font, size float64, text string, width float64) string {
+	if pdf.TextWidth(font, size, text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.TextWidth(font, size, string(runes)+"...") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + "..."
+}
+
+// formatStatementAmount formats an amount with places decimals and thousands separators
+func formatStatementAmount(d decimal.Decimal, places int32) string {
+	whole, frac, _ := strings.Cut(d.Abs().StringFixed(places), ".")
+	var b strings.Builder
+	if d.Round(places).IsNegative() {
+		b.WriteByte('-')
+	}
+	for i, c := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	if frac != "" {
+		b.WriteString("." + frac)
+	}
+	return b.String()
+}
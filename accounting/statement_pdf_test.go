@@ -0,0 +1,99 @@
+package accounting
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pdfText returns the decompressed streams of a PDF written by RenderStatementPDF
+func pdfText(t *testing.T, doc []byte) string {
+	t.Helper()
+	var b strings.Builder
+	for _, m := range regexp.MustCompile(`(?s)stream\n(.*?)\nendstream`).FindAllSubmatch(doc, -1) {
+		zr, err := zlib.NewReader(bytes.NewReader(m[1]))
+		require.NoError(t, err)
+		body, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		b.Write(body)
+	}
+	return b.String()
+}
+
+func TestRenderStatementPDF(t *testing.T) {
+	ctx := context.Background()
+	s := NewAccountingServiceWithRepository(NewMemoryRepository())
+	gateway, _ := s.CreateAccount(ctx, PaymentGateway, decimal.Zero, "gateway")
+	client, _ := s.CreateAccount(ctx, ClientInsurance, decimal.Zero, "Jane (wallet)")
+	underwriter, _ := s.CreateAccount(ctx, UnderwriterPremiumPayable, decimal.Zero, "underwriter")
+
+	require.NoError(t, s.PostTransaction(ctx, TopUp, decimal.RequireFromString("1500.5"), gateway.ID, client.ID, "MPESA1", WithNarrative("M-Pesa top-up")))
+	require.NoError(t, s.PostTransaction(ctx, PremiumPayment, decimal.NewFromInt(200), client.ID, underwriter.ID, "PREM1"))
+
+	var logo bytes.Buffer
+	require.NoError(t, png.Encode(&logo, image.NewGray(image.Rect(0, 0, 40, 10))))
+	require.NoError(t, s.SetStatementBranding(StatementBranding{
+		CompanyName: "Nana Insurance",
+		Address:     []string{"P.O. Box 1, Nairobi"},
+		Logo:        logo.Bytes(),
+		Footer:      "Regulated by the IRA",
+	}))
+
+	var buf bytes.Buffer
+	require.NoError(t, s.RenderStatementPDF(ctx, client.ID, StatementPeriod{}, &buf))
+	assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")))
+	assert.Contains(t, buf.String(), "/Subtype /Image /Width 40 /Height 10")
+	text := pdfText(t, buf.Bytes())
+	for _, want := range []string{
+		"(Nana Insurance)", "(Jane \\(wallet\\))", "(Regulated by the IRA)", "(Page 1 of 1)",
+		"(MPESA1)", "(M-Pesa top-up)", "(1,500.50)", "(PREM1)", "(PremiumPayment)", "(200.00)", "(1,300.50)",
+		"(Opening balance)", "(Closing balance)", "(Total debits)", "(Total credits)",
+	} {
+		assert.Contains(t, text, want)
+	}
+
+	buf.Reset()
+	err := s.RenderStatementPDF(ctx, client.ID, StatementPeriod{From: time.Now(), To: time.Now().Add(-time.Hour)}, &buf)
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+	assert.Zero(t, buf.Len())
+	assert.Error(t, s.SetStatementBranding(StatementBranding{Logo: []byte("not an image")}))
+}
+
+func TestRenderStatementPDF_Pages(t *testing.T) {
+	ctx := context.Background()
+	s := NewAccountingServiceWithRepository(NewMemoryRepository())
+	gateway, _ := s.CreateAccount(ctx, PaymentGateway, decimal.Zero, "gateway")
+	client, _ := s.CreateAccount(ctx, ClientInsurance, decimal.Zero, "client")
+	for i := range 60 {
+		require.NoError(t, s.PostTransaction(ctx, TopUp, decimal.NewFromInt(1), gateway.ID, client.ID, fmt.Sprintf("GW-%d", i)))
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, s.RenderStatementPDF(ctx, client.ID, StatementPeriod{}, &buf))
+	assert.Contains(t, buf.String(), "/Count 2")
+	text := pdfText(t, buf.Bytes())
+	assert.Contains(t, text, "(Page 2 of 2)")
+	assert.Equal(t, 2, strings.Count(text, "(Balance)"))
+	assert.Contains(t, text, "(60.00)")
+}
+
+func TestFormatStatementAmount(t *testing.T) {
+	for in, want := range map[string]string{
+		"0": "0.00", "12.5": "12.50", "1234567.891": "1,234,567.89", "-1000": "-1,000.00", "-0.001": "0.00", "999": "999.00",
+	} {
+		assert.Equal(t, want, formatStatementAmount(decimal.RequireFromString(in), 2), in)
+	}
+	assert.Equal(t, "1,235", formatStatementAmount(decimal.RequireFromString("1234.5"), 0))
+}
@@ -0,0 +1,148 @@
+package accounting
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// --------------------------
+//  Suspense
+// --------------------------
+
+// PostToSuspense records a gateway receipt that cannot be matched to an account yet, e.g.
+// a payment quoting an unknown policy number: Debit Gateway (asset), Credit Suspense. The
+// returned entry is cleared later with MatchSuspenseEntry once the payer is identified.
+func (s *AccountingService) PostToSuspense(ctx context.Context, gatewayAccID, suspenseAccID primitive.ObjectID, amount decimal.Decimal, tranRef string, opts ...PostingOption) (*JournalEntry, error) {
+	return s.postDoubleEntry(ctx, SuspenseReceipt, amount, gatewayAccID, suspenseAccID, tranRef, opts...)
+}
+
+// MatchSuspenseEntry clears a suspense receipt into the account it belongs to, a client
+// wallet or an underwriter account: a SuspenseClearing entry debits the suspense account
+// and credits targetAccID with the amount of the receipt, under the same TranRef. The
+// clearing names the receipt in ClearingOf and the receipt its clearing in MatchedBy, so
+// a receipt is matched at most once; matching it again fails with ErrAlreadyMatched. A
+// wrong match is undone with UnmatchSuspenseEntry.
+func (s *AccountingService) MatchSuspenseEntry(ctx context.Context, suspenseJournalID, targetAccID primitive.ObjectID) (*JournalEntry, error) {
+	var clearing *JournalEntry
+	err := s.runInTransaction(ctx, func(sc context.Context) error {
+		receipt, err := s.repo.GetJournal(sc, suspenseJournalID)
+		if err != nil {
+			return err
+		}
+		if err := checkSuspenseReceipt(receipt); err != nil {
+			return err
+		}
+		suspenseType, targetType, err := s.accountTypes(sc, receipt.CreditAccount, targetAccID)
+		if err != nil {
+			return err
+		}
+		if err := s.postingRules().Check(SuspenseClearing, suspenseType, targetType); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		group := receipt.TransactionID
+		if group.IsZero() {
+			group = receipt.ID
+		}
+		clearing = &JournalEntry{
+			ID:            primitive.NewObjectID(),
+			TransactionID: group,
+			Type:          SuspenseClearing,
+			Amount:        receipt.Amount,
+			TranRef:       receipt.TranRef,
+			Narrative:     receipt.Narrative,
+			DebitAccount:  receipt.CreditAccount,
+			CreditAccount: targetAccID,
+			CreatedAt:     now,
+			ClearingOf:    &receipt.ID,
+		}
+
+		marked, err := s.repo.MarkMatched(sc, receipt.ID, group, clearing.ID, now)
+		if err != nil {
+			return err
+		}
+		if !marked {
+			return fmt.Errorf("%w: %s", ErrAlreadyMatched, receipt.ID.Hex())
+		}
+		if err := s.applyEntry(sc, clearing); err != nil {
+			return err
+		}
+		return s.audit(sc, AuditSuspenseMatched, receipt.ID, "", nil, map[string]string{
+			"matched_by":     clearing.ID.Hex(),
+			"target_account": targetAccID.Hex(),
+			"amount":         receipt.Amount,
+			"tranref":        receipt.TranRef,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.publishPosted(ctx, clearing)
+	return clearing, nil
+}
+
+// UnmatchSuspenseEntry undoes a wrong MatchSuspenseEntry: it reverses the clearing, taking
+// the amount back from the target account to the suspense account, and returns the
+// receipt to unmatched so it can be matched again. It fails with ErrNotInSuspense for
+// entries other than clearings.
+func (s *AccountingService) UnmatchSuspenseEntry(ctx context.Context, clearingJournalID primitive.ObjectID, reason string) (*JournalEntry, error) {
+	clearing, err := s.repo.GetJournal(ctx, clearingJournalID)
+	if err != nil {
+		return nil, err
+	}
+	if clearing.ClearingOf == nil {
+		return nil, fmt.Errorf("%w: %s is a %s, not a clearing", ErrNotInSuspense, clearing.ID.Hex(), clearing.Type)
+	}
+	return s.ReverseJournalEntry(ctx, clearingJournalID, reason)
+}
+
+// unmatchReceipt returns the receipt of a clearing being reversed to unmatched
+func (s *AccountingService) unmatchReceipt(sc context.Context, clearing *JournalEntry, reason string) error {
+	unmarked, err := s.repo.UnmarkMatched(sc, *clearing.ClearingOf, clearing.ID)
+	if err != nil {
+		return err
+	}
+	if !unmarked {
+		return fmt.Errorf("%w: suspense entry %s is not matched by %s", ErrNotReversible, clearing.ClearingOf.Hex(), clearing.ID.Hex())
+	}
+	return s.audit(sc, AuditSuspenseUnmatched, *clearing.ClearingOf, reason, map[string]string{
+		"matched_by": clearing.ID.Hex(),
+	}, nil)
+}
+
+// UnmatchedSuspenseEntries returns the receipts posted to a suspense account that are
+// neither matched nor reversed, oldest first: the worklist of payment reconciliation.
+func (s *AccountingService) UnmatchedSuspenseEntries(ctx context.Context, suspenseAccID primitive.ObjectID) ([]JournalEntry, error) {
+	var entries []JournalEntry
+	filter := JournalFilter{AccountID: suspenseAccID, Type: SuspenseReceipt}
+	err := s.StreamJournals(ctx, filter, JournalSort{Ascending: true}, func(e *JournalEntry) error {
+		if !e.IsMatched() && !e.IsReversed() {
+			entries = append(entries, *e)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// checkSuspenseReceipt accepts the unmatched, unreversed double-entry suspense receipts
+func checkSuspenseReceipt(receipt *JournalEntry) error {
+	switch {
+	case receipt.Type != SuspenseReceipt:
+		return fmt.Errorf("%w: %s is a %s", ErrNotInSuspense, receipt.ID.Hex(), receipt.Type)
+	case receipt.IsCompound():
+		return fmt.Errorf("%w: %s is a compound entry", ErrNotInSuspense, receipt.ID.Hex())
+	case receipt.IsReversed():
+		return fmt.Errorf("%w: %s is reversed", ErrNotInSuspense, receipt.ID.Hex())
+	case receipt.IsMatched():
+		return fmt.Errorf("%w: %s", ErrAlreadyMatched, receipt.ID.Hex())
+	}
+	return nil
+}
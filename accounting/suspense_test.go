@@ -0,0 +1,124 @@
+package accounting
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuspense_PostAndMatch(t *testing.T) {
+	ctx := context.Background()
+	s := NewAccountingServiceWithRepository(NewMemoryRepository())
+	gateway, _ := s.CreateAccount(ctx, PaymentGateway, decimal.Zero, "gateway")
+	suspense, _ := s.CreateAccount(ctx, Suspense, decimal.Zero, "suspense")
+	client, _ := s.CreateAccount(ctx, ClientInsurance, decimal.Zero, "client")
+	agent, _ := s.CreateAccount(ctx, AgentCommissionEarned, decimal.Zero, "agent")
+
+	receipt, err := s.PostToSuspense(ctx, gateway.ID, suspense.ID, decimal.NewFromInt(250), "MPESA-1", WithNarrative("unknown policy"))
+	require.NoError(t, err)
+	other, err := s.PostToSuspense(ctx, gateway.ID, suspense.ID, decimal.NewFromInt(40), "MPESA-2")
+	require.NoError(t, err)
+	_, err = s.PostToSuspense(ctx, client.ID, suspense.ID, decimal.NewFromInt(1), "MPESA-3")
+	assert.ErrorIs(t, err, ErrPostingRule)
+
+	unmatched, err := s.UnmatchedSuspenseEntries(ctx, suspense.ID)
+	require.NoError(t, err)
+	require.Len(t, unmatched, 2)
+	assert.Equal(t, receipt.ID, unmatched[0].ID)
+
+	_, err = s.MatchSuspenseEntry(ctx, receipt.ID, agent.ID)
+	assert.ErrorIs(t, err, ErrPostingRule)
+
+	clearing, err := s.MatchSuspenseEntry(ctx, receipt.ID, client.ID)
+	require.NoError(t, err)
+	assert.Equal(t, SuspenseClearing, clearing.Type)
+	assert.Equal(t, "MPESA-1", clearing.TranRef)
+	assert.Equal(t, suspense.ID, clearing.DebitAccount)
+	assert.Equal(t, client.ID, clearing.CreditAccount)
+	assert.Nil(t, clearing.ReversalOf)
+	require.NotNil(t, clearing.ClearingOf)
+	assert.Equal(t, receipt.ID, *clearing.ClearingOf)
+
+	matched, err := s.GetJournalByID(ctx, receipt.ID)
+	require.NoError(t, err)
+	assert.False(t, matched.IsReversed())
+	require.NotNil(t, matched.MatchedBy)
+	assert.Equal(t, clearing.ID, *matched.MatchedBy)
+	assert.Equal(t, matched.TransactionID, clearing.TransactionID)
+
+	_, err = s.MatchSuspenseEntry(ctx, receipt.ID, client.ID)
+	assert.ErrorIs(t, err, ErrAlreadyMatched)
+	_, err = s.ReverseJournalEntry(ctx, receipt.ID, "bounced")
+	assert.ErrorIs(t, err, ErrNotReversible)
+	_, err = s.MatchSuspenseEntry(ctx, clearing.ID, client.ID)
+	assert.ErrorIs(t, err, ErrNotInSuspense)
+
+	balance, _ := s.GetAccountBalance(ctx, suspense.ID)
+	assert.Equal(t, "40", balance.String())
+	balance, _ = s.GetAccountBalance(ctx, client.ID)
+	assert.Equal(t, "250", balance.String())
+
+	unmatched, err = s.UnmatchedSuspenseEntries(ctx, suspense.ID)
+	require.NoError(t, err)
+	require.Len(t, unmatched, 1)
+	assert.Equal(t, other.ID, unmatched[0].ID)
+
+	audit, _, err := s.ListAuditRecords(ctx, AuditFilter{Action: AuditSuspenseMatched}, Pagination{})
+	require.NoError(t, err)
+	require.Len(t, audit, 1)
+	assert.Equal(t, clearing.ID.Hex(), audit[0].After["matched_by"])
+}
+
+func TestSuspense_Unmatch(t *testing.T) {
+	ctx := context.Background()
+	s := NewAccountingServiceWithRepository(NewMemoryRepository())
+	gateway, _ := s.CreateAccount(ctx, PaymentGateway, decimal.Zero, "gateway")
+	suspense, _ := s.CreateAccount(ctx, Suspense, decimal.Zero, "suspense")
+	wrong, _ := s.CreateAccount(ctx, ClientInsurance, decimal.Zero, "wrong client")
+	right, _ := s.CreateAccount(ctx, ClientInsurance, decimal.Zero, "right client")
+
+	receipt, err := s.PostToSuspense(ctx, gateway.ID, suspense.ID, decimal.NewFromInt(100), "MPESA-7")
+	require.NoError(t, err)
+	clearing, err := s.MatchSuspenseEntry(ctx, receipt.ID, wrong.ID)
+	require.NoError(t, err)
+
+	_, err = s.UnmatchSuspenseEntry(ctx, receipt.ID, "wrong client")
+	assert.ErrorIs(t, err, ErrNotInSuspense)
+	reversal, err := s.UnmatchSuspenseEntry(ctx, clearing.ID, "wrong client")
+	require.NoError(t, err)
+	assert.Equal(t, Reversal, reversal.Type)
+	require.NotNil(t, reversal.ReversalOf)
+	assert.Equal(t, clearing.ID, *reversal.ReversalOf)
+
+	unmatched, err := s.UnmatchedSuspenseEntries(ctx, suspense.ID)
+	require.NoError(t, err)
+	require.Len(t, unmatched, 1)
+	assert.Equal(t, receipt.ID, unmatched[0].ID)
+	balance, _ := s.GetAccountBalance(ctx, wrong.ID)
+	assert.True(t, balance.IsZero())
+
+	_, err = s.UnmatchSuspenseEntry(ctx, clearing.ID, "again")
+	assert.ErrorIs(t, err, ErrAlreadyReversed)
+	_, err = s.MatchSuspenseEntry(ctx, receipt.ID, right.ID)
+	require.NoError(t, err)
+	balance, _ = s.GetAccountBalance(ctx, right.ID)
+	assert.Equal(t, "100", balance.String())
+	balance, _ = s.GetAccountBalance(ctx, suspense.ID)
+	assert.True(t, balance.IsZero())
+
+	audit, _, err := s.ListAuditRecords(ctx, AuditFilter{Action: AuditSuspenseUnmatched}, Pagination{})
+	require.NoError(t, err)
+	require.Len(t, audit, 1)
+	assert.Equal(t, clearing.ID.Hex(), audit[0].Before["matched_by"])
+
+	// a receipt reversed before it is matched is out of suspense
+	bounced, err := s.PostToSuspense(ctx, gateway.ID, suspense.ID, decimal.NewFromInt(5), "MPESA-8")
+	require.NoError(t, err)
+	_, err = s.ReverseJournalEntry(ctx, bounced.ID, "bounced")
+	require.NoError(t, err)
+	_, err = s.MatchSuspenseEntry(ctx, bounced.ID, right.ID)
+	assert.ErrorIs(t, err, ErrNotInSuspense)
+}
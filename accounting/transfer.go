@@ -0,0 +1,91 @@
+package accounting
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// --------------------------
+//  Transfers
+// --------------------------
+
+// TransferPair is a direction Transfer may move value in: from an account of type From
+// to an account of type To
+type TransferPair struct {
+	From AccountType
+	To   AccountType
+}
+
+// TransferMatrix is the set of account type pairs Transfer accepts
+type TransferMatrix map[TransferPair]bool
+
+// DefaultTransferMatrix only allows transfers between accounts of the same type: client
+// wallet to client wallet, and between underwriter, agent or gateway accounts. Flows
+// across types go through their own posting methods, or are added to the matrix.
+func DefaultTransferMatrix() TransferMatrix {
+	return NewTransferMatrix(
+		TransferPair{ClientInsurance, ClientInsurance},
+		TransferPair{UnderwriterPremiumPayable, UnderwriterPremiumPayable},
+		TransferPair{AgentCommissionEarned, AgentCommissionEarned},
+		TransferPair{PaymentGateway, PaymentGateway},
+	)
+}
+
+// NewTransferMatrix returns the matrix allowing exactly pairs
+func NewTransferMatrix(pairs ...TransferPair) TransferMatrix {
+	m := make(TransferMatrix, len(pairs))
+	for _, p := range pairs {
+		m[p] = true
+	}
+	return m
+}
+
+// Allows reports whether value may move from an account of type from to one of type to
+func (m TransferMatrix) Allows(from, to AccountType) bool {
+	return m[TransferPair{From: from, To: to}]
+}
+
+// Check returns an error wrapping ErrPostingRule when the pair is not allowed
+func (m TransferMatrix) Check(from, to AccountType) error {
+	if !m.Allows(from, to) {
+		return fmt.Errorf("%w: cannot transfer from a %s account to a %s account", ErrPostingRule, from, to)
+	}
+	return nil
+}
+
+// SetTransferMatrix replaces the account type pairs Transfer accepts
+func (s *AccountingService) SetTransferMatrix(m TransferMatrix) {
+	s.transfers = m
+}
+
+func (s *AccountingService) transferMatrix() TransferMatrix {
+	if s.transfers == nil {
+		return DefaultTransferMatrix()
+	}
+	return s.transfers
+}
+
+// Transfer moves amount from one account to another as a Transfer entry: the from account
+// is debited and the to account credited, so the balance of from goes down and the
+// balance of to goes up. The account types must be a pair of the transfer matrix, see
+// SetTransferMatrix; the overdraft policy and account statuses apply as to any posting.
+func (s *AccountingService) Transfer(ctx context.Context, fromAccID, toAccID primitive.ObjectID, amount decimal.Decimal, tranRef, narrative string, opts ...PostingOption) (*JournalEntry, error) {
+	if fromAccID == toAccID {
+		return nil, fmt.Errorf("%w: cannot transfer to the same account", ErrPostingRule)
+	}
+	opts = append([]PostingOption{WithNarrative(narrative)}, opts...)
+	entry, err := s.newDoubleEntry(AccountTransfer, amount, fromAccID, toAccID, tranRef, opts)
+	if err != nil {
+		return nil, err
+	}
+	return s.postEntry(ctx, entry, func(sc context.Context) error {
+		fromType, toType, err := s.accountTypes(sc, fromAccID, toAccID)
+		if err != nil {
+			return err
+		}
+		return s.transferMatrix().Check(fromType, toType)
+	})
+}
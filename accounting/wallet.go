@@ -0,0 +1,150 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// --------------------------
+//  Client Wallets
+// --------------------------
+
+// walletNamePrefix marks the ClientInsurance accounts opened by a Wallet; the rest of the
+// name is the client reference
+const walletNamePrefix = "wallet:"
+
+// WalletConfig names the ledger accounts wallet postings go through
+type WalletConfig struct {
+	GatewayAccountID primitive.ObjectID // PaymentGateway account receiving top-ups
+	// Underwriters maps the underwriter references PayPremium accepts to their
+	// UnderwriterPremiumPayable accounts
+	Underwriters map[string]primitive.ObjectID
+}
+
+// WalletSummary describes the wallet of a client
+type WalletSummary struct {
+	ClientRef string          `json:"client_ref"`
+	Balance   decimal.Decimal `json:"balance"`
+	Status    AccountStatus   `json:"status"`
+	OpenedAt  time.Time       `json:"opened_at"`
+}
+
+// Wallet manages client wallets by the client reference of the calling system, e.g. a
+// customer number, instead of account IDs. Each wallet is a ClientInsurance account of
+// the underlying AccountingService.
+type Wallet struct {
+	svc      *AccountingService
+	cfg      WalletConfig
+	accounts sync.Map // client reference -> primitive.ObjectID
+}
+
+// NewWallet returns the wallet facade of svc
+func NewWallet(svc *AccountingService, cfg WalletConfig) *Wallet {
+	return &Wallet{svc: svc, cfg: cfg}
+}
+
+// OpenWallet opens the wallet of a client, or returns it when it is already open. The
+// account carries the client reference as its Ref, so racing signups open one wallet.
+func (w *Wallet) OpenWallet(ctx context.Context, clientRef string) (*WalletSummary, error) {
+	acc, err := w.findAccount(ctx, clientRef)
+	if errors.Is(err, ErrAccountNotFound) {
+		acc, err = w.svc.GetOrCreateAccount(ctx, walletNamePrefix+clientRef, ClientInsurance, walletNamePrefix+clientRef)
+	}
+	if err != nil {
+		return nil, err
+	}
+	w.accounts.Store(clientRef, acc.ID)
+	return newWalletSummary(clientRef, acc), nil
+}
+
+// GetWallet returns the wallet of a client, ErrAccountNotFound when it was never opened
+func (w *Wallet) GetWallet(ctx context.Context, clientRef string) (*WalletSummary, error) {
+	acc, err := w.findAccount(ctx, clientRef)
+	if err != nil {
+		return nil, err
+	}
+	return newWalletSummary(clientRef, acc), nil
+}
+
+// TopUp credits the wallet with a payment received through the gateway account
+func (w *Wallet) TopUp(ctx context.Context, clientRef string, amount decimal.Decimal, tranRef string, opts ...PostingOption) error {
+	accID, err := w.accountID(ctx, clientRef)
+	if err != nil {
+		return err
+	}
+	return w.svc.ClientAccountTopUp(ctx, accID, w.cfg.GatewayAccountID, amount, tranRef, opts...)
+}
+
+// PayPremium pays a premium from the wallet to the account of the underwriter known as
+// underwriterRef in the configuration
+func (w *Wallet) PayPremium(ctx context.Context, clientRef, underwriterRef string, amount decimal.Decimal, tranRef string, opts ...PostingOption) error {
+	underwriterID, ok := w.cfg.Underwriters[underwriterRef]
+	if !ok {
+		return fmt.Errorf("%w: underwriter %q", ErrAccountNotFound, underwriterRef)
+	}
+	accID, err := w.accountID(ctx, clientRef)
+	if err != nil {
+		return err
+	}
+	return w.svc.ClientPremiumPayment(ctx, accID, underwriterID, amount, tranRef, opts...)
+}
+
+// GetWalletBalance returns the current balance of the wallet
+func (w *Wallet) GetWalletBalance(ctx context.Context, clientRef string) (decimal.Decimal, error) {
+	accID, err := w.accountID(ctx, clientRef)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return w.svc.GetAccountBalance(ctx, accID)
+}
+
+// GetWalletStatement returns the statement of the wallet, see GetAccountStatement
+func (w *Wallet) GetWalletStatement(ctx context.Context, clientRef string, from, to time.Time, page Pagination) (*AccountStatement, error) {
+	accID, err := w.accountID(ctx, clientRef)
+	if err != nil {
+		return nil, err
+	}
+	return w.svc.GetAccountStatement(ctx, accID, from, to, page)
+}
+
+// accountID returns the account of a wallet, remembered after the first lookup since
+// the account of a client never changes
+func (w *Wallet) accountID(ctx context.Context, clientRef string) (primitive.ObjectID, error) {
+	if id, ok := w.accounts.Load(clientRef); ok {
+		return id.(primitive.ObjectID), nil
+	}
+	acc, err := w.findAccount(ctx, clientRef)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	w.accounts.Store(clientRef, acc.ID)
+	return acc.ID, nil
+}
+
+func (w *Wallet) findAccount(ctx context.Context, clientRef string) (*Account, error) {
+	if strings.TrimSpace(clientRef) == "" {
+		return nil, fmt.Errorf("%w: empty client reference", ErrInvalidAccountRef)
+	}
+	if id, ok := w.accounts.Load(clientRef); ok {
+		return w.svc.GetAccountByID(ctx, id.(primitive.ObjectID))
+	}
+	acc, err := w.svc.FindAccountByName(ctx, walletNamePrefix+clientRef)
+	if err != nil {
+		if errors.Is(err, ErrAccountNotFound) {
+			return nil, fmt.Errorf("%w: wallet %q", ErrAccountNotFound, clientRef)
+		}
+		return nil, err
+	}
+	return acc, nil
+}
+
+func newWalletSummary(clientRef string, acc *Account) *WalletSummary {
+	return &WalletSummary{ClientRef: clientRef, Balance: acc.GetBalance(), Status: acc.GetStatus(), OpenedAt: acc.CreatedAt}
+}
@@ -0,0 +1,104 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// accountsRepo keeps accounts and audit records in memory for the account lookups of
+// Wallet and the administrative actions. Transactions only call fn.
+type accountsRepo struct {
+	AccountingRepository
+	accounts []*Account
+	audit    []AuditRecord
+}
+
+func (r *accountsRepo) RunInTransaction(ctx context.Context, fn func(context.Context) error) error {
+	return fn(ctx)
+}
+
+func (r *accountsRepo) InsertAudit(ctx context.Context, rec *AuditRecord) error {
+	r.audit = append(r.audit, *rec)
+	return nil
+}
+
+func (r *accountsRepo) SetAccountStatus(ctx context.Context, id primitive.ObjectID, status AccountStatus, reason string, at time.Time) (bool, error) {
+	acc, err := r.GetAccount(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	acc.Status, acc.StatusReason, acc.StatusChangedAt = status, reason, &at
+	return true, nil
+}
+
+func (r *accountsRepo) InsertAccount(ctx context.Context, acc *Account) error {
+	r.accounts = append(r.accounts, acc)
+	return nil
+}
+
+func (r *accountsRepo) FindAccountByRef(ctx context.Context, ref string) (*Account, error) {
+	for _, acc := range r.accounts {
+		if acc.Ref == ref {
+			return acc, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: ref %s", ErrAccountNotFound, ref)
+}
+
+func (r *accountsRepo) GetAccount(ctx context.Context, id primitive.ObjectID) (*Account, error) {
+	for _, acc := range r.accounts {
+		if acc.ID == id {
+			return acc, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, id.Hex())
+}
+
+func (r *accountsRepo) FindAccountByName(ctx context.Context, name string) (*Account, error) {
+	for _, acc := range r.accounts {
+		if acc.Name == name {
+			return acc, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, name)
+}
+
+func TestWallet_OpenAndLookup(t *testing.T) {
+	ctx := context.Background()
+	repo := &accountsRepo{}
+	w := NewWallet(NewAccountingServiceWithRepository(repo), WalletConfig{})
+
+	opened, err := w.OpenWallet(ctx, "CUST-001")
+	require.NoError(t, err)
+	assert.Equal(t, "CUST-001", opened.ClientRef)
+	assert.Equal(t, AccountActive, opened.Status)
+	require.Len(t, repo.accounts, 1)
+	assert.Equal(t, ClientInsurance, repo.accounts[0].Type)
+	assert.Equal(t, "wallet:CUST-001", repo.accounts[0].Ref)
+
+	again, err := NewWallet(NewAccountingServiceWithRepository(repo), WalletConfig{}).OpenWallet(ctx, "CUST-001")
+	require.NoError(t, err)
+	assert.Equal(t, opened.OpenedAt, again.OpenedAt)
+	assert.Len(t, repo.accounts, 1, "opening twice reuses the wallet")
+
+	balance, err := w.GetWalletBalance(ctx, "CUST-001")
+	require.NoError(t, err)
+	assert.True(t, balance.IsZero())
+
+	_, err = w.GetWalletBalance(ctx, "CUST-002")
+	assert.True(t, errors.Is(err, ErrAccountNotFound))
+	_, err = w.OpenWallet(ctx, " ")
+	assert.True(t, errors.Is(err, ErrInvalidAccountRef))
+	assert.Len(t, repo.accounts, 1)
+
+	err = w.PayPremium(ctx, "CUST-001", "UW-X", decimal.NewFromInt(10), "PREM1")
+	assert.True(t, errors.Is(err, ErrAccountNotFound))
+}
@@ -0,0 +1,181 @@
+// Package config loads the configuration of the gopackages modules from YAML or
+// JSON files and environment variables, resolves secret references and validates
+// the result, so services do not hand-build every module Config.
+//
+// Values are applied in order: defaults, files (later files override earlier ones),
+// then environment variables. String values of the form "env:NAME" or
+// "file:/run/secrets/name" are replaced by the referenced secret; more schemes,
+// e.g. "vault", can be registered through LoadOptions.SecretResolvers.
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Section names a module section of the configuration.
+type Section string
+
+const (
+	SectionDmvic      Section = "dmvic"
+	SectionLinkValuer Section = "linkvaluer"
+	SectionEventbus   Section = "eventbus"
+	SectionLogger     Section = "logger"
+	SectionAccounting Section = "accounting"
+	SectionRisk       Section = "risk"
+)
+
+// Config holds the configuration of every module.
+type Config struct {
+	Dmvic      DmvicConfig      `yaml:"dmvic" json:"dmvic"`
+	LinkValuer LinkValuerConfig `yaml:"linkvaluer" json:"linkvaluer"`
+	Eventbus   EventbusConfig   `yaml:"eventbus" json:"eventbus"`
+	Logger     LoggerConfig     `yaml:"logger" json:"logger"`
+	Accounting AccountingConfig `yaml:"accounting" json:"accounting"`
+	Risk       RiskConfig       `yaml:"risk" json:"risk"`
+}
+
+// LoadOptions controls where Load reads the configuration from.
+type LoadOptions struct {
+	Files           []string                    // YAML (.yaml, .yml) or JSON (.json) files, applied in order
+	EnvPrefix       string                      // Prefix of every environment variable, e.g. "CLAIMS_" reads CLAIMS_DMVIC_USERNAME
+	LookupEnv       func(string) (string, bool) // Environment lookup, defaults to os.LookupEnv
+	SecretResolvers map[string]SecretResolver   // Additional secret reference schemes, the built-in env and file can be overridden
+	Require         []Section                   // Sections that must be configured, other sections are only validated when set
+}
+
+// Default returns the configuration defaults.
+func Default() *Config {
+	return &Config{
+		Dmvic: DmvicConfig{
+			Timeout: Duration(30 * time.Second),
+		},
+		LinkValuer: LinkValuerConfig{
+			Environment: "production",
+			Timeout:     Duration(30 * time.Second),
+			TokenTTL:    Duration(12 * time.Hour),
+			Retries:     2,
+
+			BackoffInitial:    Duration(500 * time.Millisecond),
+			BackoffMultiplier: 2,
+			BackoffMax:        Duration(10 * time.Second),
+		},
+		Logger: LoggerConfig{
+			Encoding: "json",
+			Level:    "info",
+		},
+		Accounting: AccountingConfig{
+			Mongo: MongoConfig{Database: "accounting"},
+		},
+		Risk: RiskConfig{
+			Mongo: MongoConfig{Database: "insurance"},
+		},
+	}
+}
+
+// Load builds the configuration from the defaults, files and environment of opts,
+// resolves secret references and validates it.
+func Load(ctx context.Context, opts LoadOptions) (*Config, error) {
+	if opts.LookupEnv == nil {
+		opts.LookupEnv = os.LookupEnv
+	}
+	cfg := Default()
+
+	for _, path := range opts.Files {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, section := range cfg.sections() {
+		if err := applyEnv(section.value.Elem(), opts.EnvPrefix, opts.LookupEnv); err != nil {
+			return nil, fmt.Errorf("config %s: %w", section.name, err)
+		}
+	}
+
+	resolvers := map[string]SecretResolver{
+		"env":  envSecretResolver{lookup: opts.LookupEnv},
+		"file": fileSecretResolver{},
+	}
+	for scheme, r := range opts.SecretResolvers {
+		resolvers[scheme] = r
+	}
+	if err := resolveSecrets(ctx, reflect.ValueOf(cfg), "", resolvers); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+
+	if err := cfg.Validate(opts.Require...); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile merges a YAML or JSON file into the configuration.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, c)
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(c)
+	default:
+		return fmt.Errorf("config: unsupported file type %s", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config: parse %s: %w", path, err)
+	}
+	return nil
+}
+
+type section struct {
+	name     Section
+	value    reflect.Value
+	isSet    func() bool
+	validate func() error
+}
+
+func (c *Config) sections() []section {
+	return []section{
+		{SectionDmvic, reflect.ValueOf(&c.Dmvic), c.Dmvic.isSet, c.Dmvic.Validate},
+		{SectionLinkValuer, reflect.ValueOf(&c.LinkValuer), c.LinkValuer.isSet, c.LinkValuer.Validate},
+		{SectionEventbus, reflect.ValueOf(&c.Eventbus), c.Eventbus.isSet, c.Eventbus.Validate},
+		{SectionLogger, reflect.ValueOf(&c.Logger), c.Logger.isSet, c.Logger.Validate},
+		{SectionAccounting, reflect.ValueOf(&c.Accounting), c.Accounting.isSet, c.Accounting.Validate},
+		{SectionRisk, reflect.ValueOf(&c.Risk), c.Risk.isSet, c.Risk.Validate},
+	}
+}
+
+// Validate checks the required sections and every other section that is configured.
+func (c *Config) Validate(require ...Section) error {
+	required := make(map[Section]bool, len(require))
+	for _, s := range require {
+		required[s] = true
+	}
+	for _, s := range c.sections() {
+		if !required[s.name] && !s.isSet() {
+			continue
+		}
+		if err := s.validate(); err != nil {
+			return fmt.Errorf("config %s: %w", s.name, err)
+		}
+		delete(required, s.name)
+	}
+	for s := range required {
+		return fmt.Errorf("config: unknown section %s", s)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_FilesEnvAndSecrets(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte(`
+dmvic:
+  username: insurer
+  password: env:DMVIC_SECRET
+  client_id: client-1
+  environment: uat
+  timeout: 45s
+  auth_cert_path: /certs/client.crt
+  auth_key_path: /certs/client.key
+  disable_custom_root_cas: true
+logger:
+  level: debug
+`), 0o600))
+	secretPath := filepath.Join(dir, "nats_password")
+	require.NoError(t, os.WriteFile(secretPath, []byte("s3cret\n"), 0o600))
+
+	env := map[string]string{
+		"DMVIC_SECRET":             "dmvic-pass",
+		"SVC_NATS_URL":             "nats://localhost:4222",
+		"SVC_NATS_APP_NAME":        "claims",
+		"SVC_NATS_USERNAME":        "claims",
+		"SVC_NATS_PASSWORD":        "file:" + secretPath,
+		"SVC_ACCOUNTING_MONGO_URI": "mongodb://localhost:27017",
+		"SVC_LOG_ENCODING":         "console",
+	}
+	cfg, err := Load(context.Background(), LoadOptions{
+		Files:     []string{yamlPath},
+		EnvPrefix: "SVC_",
+		LookupEnv: func(k string) (string, bool) { v, ok := env[k]; return v, ok },
+		Require:   []Section{SectionDmvic, SectionEventbus},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "dmvic-pass", cfg.Dmvic.ClientConfig().Credentials.Password)
+	assert.Equal(t, 45*time.Second, cfg.Dmvic.ClientConfig().Timeout)
+	assert.Equal(t, "s3cret", cfg.Eventbus.Password)
+	assert.Equal(t, "mongodb://localhost:27017", cfg.Accounting.Mongo.URI)
+	assert.Equal(t, "accounting", cfg.Accounting.Mongo.Database)
+	assert.Equal(t, "debug", cfg.Logger.LogConfig().Level)
+	assert.Equal(t, "console", cfg.Logger.LogConfig().Encoding)
+}
+
+func TestLoad_ValidatesRequiredSections(t *testing.T) {
+	noEnv := func(string) (string, bool) { return "", false }
+
+	_, err := Load(context.Background(), LoadOptions{LookupEnv: noEnv})
+	assert.NoError(t, err, "unconfigured optional sections are not validated")
+
+	_, err = Load(context.Background(), LoadOptions{LookupEnv: noEnv, Require: []Section{SectionLinkValuer}})
+	assert.ErrorContains(t, err, "linkvaluer")
+
+	_, err = Load(context.Background(), LoadOptions{
+		LookupEnv: func(k string) (string, bool) {
+			if k == "DMVIC_PASSWORD" {
+				return "env:MISSING", true
+			}
+			return "", false
+		},
+	})
+	assert.ErrorContains(t, err, "MISSING")
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// SecretResolver returns the secret a reference points to. The reference is the
+// part of the value after the "<scheme>:" prefix.
+type SecretResolver interface {
+	ResolveSecret(ctx context.Context, ref string) (string, error)
+}
+
+// SecretResolverFunc adapts a function to the SecretResolver interface.
+type SecretResolverFunc func(ctx context.Context, ref string) (string, error)
+
+func (f SecretResolverFunc) ResolveSecret(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+// envSecretResolver resolves "env:NAME" from another environment variable
+type envSecretResolver struct {
+	lookup func(string) (string, bool)
+}
+
+func (r envSecretResolver) ResolveSecret(ctx context.Context, ref string) (string, error) {
+	v, ok := r.lookup(ref)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", ref)
+	}
+	return v, nil
+}
+
+// fileSecretResolver resolves "file:/run/secrets/name", trimming the trailing newline
+// that secret mounts usually carry
+type fileSecretResolver struct{}
+
+func (fileSecretResolver) ResolveSecret(ctx context.Context, ref string) (string, error) {
+	b, err := os.ReadFile(ref)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// resolveSecrets replaces every string field of v holding a "<scheme>:<ref>" value
+// whose scheme has a resolver with the resolved secret. Values with other prefixes,
+// such as URLs, are left alone.
+func resolveSecrets(ctx context.Context, v reflect.Value, path string, resolvers map[string]SecretResolver) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return resolveSecrets(ctx, v.Elem(), path, resolvers)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			if err := resolveSecrets(ctx, v.Field(i), path+"."+t.Field(i).Name, resolvers); err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		scheme, ref, ok := strings.Cut(v.String(), ":")
+		if !ok {
+			return nil
+		}
+		resolver, ok := resolvers[scheme]
+		if !ok {
+			return nil
+		}
+		secret, err := resolver.ResolveSecret(ctx, ref)
+		if err != nil {
+			return fmt.Errorf("resolve secret %s of %s: %w", scheme, strings.TrimPrefix(path, "."), err)
+		}
+		v.SetString(secret)
+	}
+	return nil
+}
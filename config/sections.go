@@ -0,0 +1,261 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	dmvic "github.com/nana-tec/gopackages/Dmvic"
+	linkvaluer "github.com/nana-tec/gopackages/LinkValuer"
+	"github.com/nana-tec/gopackages/eventbus"
+	ntlogger "github.com/nana-tec/gopackages/logger"
+)
+
+// Duration is a time.Duration read from files and the environment as a Go
+// duration string such as "30s" or "12h".
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(strings.TrimSpace(string(text)))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// DmvicConfig is the file and environment representation of dmvic.Config.
+type DmvicConfig struct {
+	Username             string   `yaml:"username" json:"username" env:"DMVIC_USERNAME"`
+	Password             string   `yaml:"password" json:"password" env:"DMVIC_PASSWORD"`
+	ClientID             string   `yaml:"client_id" json:"client_id" env:"DMVIC_CLIENT_ID"`
+	Environment          string   `yaml:"environment" json:"environment" env:"DMVIC_ENVIRONMENT"`
+	CustomEndpoint       string   `yaml:"custom_endpoint" json:"custom_endpoint" env:"DMVIC_CUSTOM_ENDPOINT"`
+	Timeout              Duration `yaml:"timeout" json:"timeout" env:"DMVIC_TIMEOUT"`
+	TokenTTL             Duration `yaml:"token_ttl" json:"token_ttl" env:"DMVIC_TOKEN_TTL"`
+	InsecureSkipVerify   bool     `yaml:"insecure_skip_verify" json:"insecure_skip_verify" env:"DMVIC_INSECURE_SKIP_VERIFY"`
+	Debug                bool     `yaml:"debug" json:"debug" env:"DMVIC_DEBUG"`
+	AuthCertPath         string   `yaml:"auth_cert_path" json:"auth_cert_path" env:"DMVIC_AUTH_CERT_PATH"`
+	AuthKeyPath          string   `yaml:"auth_key_path" json:"auth_key_path" env:"DMVIC_AUTH_KEY_PATH"`
+	AuthCaCertPath       string   `yaml:"auth_ca_cert_path" json:"auth_ca_cert_path" env:"DMVIC_AUTH_CA_CERT_PATH"`
+	DisableCustomRootCAs bool     `yaml:"disable_custom_root_cas" json:"disable_custom_root_cas" env:"DMVIC_DISABLE_CUSTOM_ROOT_CAS"`
+	SafeRetryAttempts    int      `yaml:"safe_retry_attempts" json:"safe_retry_attempts" env:"DMVIC_SAFE_RETRY_ATTEMPTS"`
+	SafeRetryBackoff     Duration `yaml:"safe_retry_backoff" json:"safe_retry_backoff" env:"DMVIC_SAFE_RETRY_BACKOFF"`
+	APIVersion           string   `yaml:"api_version" json:"api_version" env:"DMVIC_API_VERSION"`
+	ResponseValidation   string   `yaml:"response_validation" json:"response_validation" env:"DMVIC_RESPONSE_VALIDATION"`
+	UserAgent            string   `yaml:"user_agent" json:"user_agent" env:"DMVIC_USER_AGENT"`
+	DryRun               bool     `yaml:"dry_run" json:"dry_run" env:"DMVIC_DRY_RUN"`
+}
+
+func (c DmvicConfig) isSet() bool {
+	return c.ClientID != "" || c.Username != ""
+}
+
+// ClientConfig builds the dmvic client configuration. Stores, tracing and other
+// runtime dependencies are left for the caller to set.
+func (c DmvicConfig) ClientConfig() *dmvic.Config {
+	return &dmvic.Config{
+		Credentials:          dmvic.Credentials{Username: c.Username, Password: c.Password},
+		ClientID:             c.ClientID,
+		Environment:          dmvic.Environment(c.Environment),
+		CustomEndpoint:       c.CustomEndpoint,
+		Timeout:              time.Duration(c.Timeout),
+		TokenTTL:             time.Duration(c.TokenTTL),
+		InsecureSkipVerify:   c.InsecureSkipVerify,
+		Debug:                c.Debug,
+		AuthCertPath:         c.AuthCertPath,
+		AuthKeyPath:          c.AuthKeyPath,
+		AuthCaCertPath:       c.AuthCaCertPath,
+		DisableCustomRootCAs: c.DisableCustomRootCAs,
+		SafeRetryAttempts:    c.SafeRetryAttempts,
+		SafeRetryBackoff:     time.Duration(c.SafeRetryBackoff),
+		APIVersion:           dmvic.APIVersion(c.APIVersion),
+		ResponseValidation:   dmvic.ResponseValidationMode(c.ResponseValidation),
+		UserAgent:            c.UserAgent,
+		DryRun:               c.DryRun,
+	}
+}
+
+func (c DmvicConfig) Validate() error {
+	return c.ClientConfig().Validate()
+}
+
+// LinkValuerConfig is the file and environment representation of linkvaluer.Config.
+type LinkValuerConfig struct {
+	Email               string   `yaml:"email" json:"email" env:"LINKVALUER_EMAIL"`
+	Password            string   `yaml:"password" json:"password" env:"LINKVALUER_PASSWORD"`
+	Environment         string   `yaml:"environment" json:"environment" env:"LINKVALUER_ENVIRONMENT"`
+	CustomEndpoint      string   `yaml:"custom_endpoint" json:"custom_endpoint" env:"LINKVALUER_CUSTOM_ENDPOINT"`
+	Timeout             Duration `yaml:"timeout" json:"timeout" env:"LINKVALUER_TIMEOUT"`
+	TokenTTL            Duration `yaml:"token_ttl" json:"token_ttl" env:"LINKVALUER_TOKEN_TTL"`
+	Retries             int      `yaml:"retries" json:"retries" env:"LINKVALUER_RETRIES"`
+	BackoffInitial      Duration `yaml:"backoff_initial" json:"backoff_initial" env:"LINKVALUER_BACKOFF_INITIAL"`
+	BackoffMultiplier   float64  `yaml:"backoff_multiplier" json:"backoff_multiplier" env:"LINKVALUER_BACKOFF_MULTIPLIER"`
+	BackoffMax          Duration `yaml:"backoff_max" json:"backoff_max" env:"LINKVALUER_BACKOFF_MAX"`
+	InsecureSkipVerify  bool     `yaml:"insecure_skip_verify" json:"insecure_skip_verify" env:"LINKVALUER_INSECURE_SKIP_VERIFY"`
+	Debug               bool     `yaml:"debug" json:"debug" env:"LINKVALUER_DEBUG"`
+	MaxIdleConns        int      `yaml:"max_idle_conns" json:"max_idle_conns" env:"LINKVALUER_MAX_IDLE_CONNS"`
+	MaxIdleConnsPerHost int      `yaml:"max_idle_conns_per_host" json:"max_idle_conns_per_host" env:"LINKVALUER_MAX_IDLE_CONNS_PER_HOST"`
+	DisableCompression  bool     `yaml:"disable_compression" json:"disable_compression" env:"LINKVALUER_DISABLE_COMPRESSION"`
+	DisableHTTP2        bool     `yaml:"disable_http2" json:"disable_http2" env:"LINKVALUER_DISABLE_HTTP2"`
+}
+
+func (c LinkValuerConfig) isSet() bool {
+	return c.Email != ""
+}
+
+// ClientConfig builds the linkvaluer client configuration.
+func (c LinkValuerConfig) ClientConfig() *linkvaluer.Config {
+	return &linkvaluer.Config{
+		Credentials:         linkvaluer.Credentials{Email: c.Email, Password: c.Password},
+		Environment:         linkvaluer.Environment(c.Environment),
+		CustomEndpoint:      c.CustomEndpoint,
+		Timeout:             time.Duration(c.Timeout),
+		TokenTTL:            time.Duration(c.TokenTTL),
+		Retries:             c.Retries,
+		BackoffInitial:      time.Duration(c.BackoffInitial),
+		BackoffMultiplier:   c.BackoffMultiplier,
+		BackoffMax:          time.Duration(c.BackoffMax),
+		InsecureSkipVerify:  c.InsecureSkipVerify,
+		Debug:               c.Debug,
+		MaxIdleConns:        c.MaxIdleConns,
+		MaxIdleConnsPerHost: c.MaxIdleConnsPerHost,
+		DisableCompression:  c.DisableCompression,
+		DisableHTTP2:        c.DisableHTTP2,
+	}
+}
+
+func (c LinkValuerConfig) Validate() error {
+	return c.ClientConfig().Validate()
+}
+
+// EventbusConfig configures the NATS connection of the integration event broker.
+type EventbusConfig struct {
+	NatsURL  string `yaml:"nats_url" json:"nats_url" env:"NATS_URL"`
+	AppName  string `yaml:"app_name" json:"app_name" env:"NATS_APP_NAME"`
+	Username string `yaml:"username" json:"username" env:"NATS_USERNAME"`
+	Password string `yaml:"password" json:"password" env:"NATS_PASSWORD"`
+}
+
+func (c EventbusConfig) isSet() bool {
+	return c.NatsURL != ""
+}
+
+// NatsConfig builds the eventbus connection configuration.
+func (c EventbusConfig) NatsConfig() eventbus.NatsConfig {
+	return eventbus.NewNatsConfig(c.NatsURL, c.AppName, c.Username, c.Password)
+}
+
+func (c EventbusConfig) Validate() error {
+	if c.NatsURL == "" {
+		return fmt.Errorf("missing nats_url")
+	}
+	if c.AppName == "" {
+		return fmt.Errorf("missing app_name")
+	}
+	if c.Username != "" && c.Password == "" {
+		return fmt.Errorf("missing password for nats user %s", c.Username)
+	}
+	return nil
+}
+
+// LoggerConfig is the file and environment representation of ntlogger.LogConfig.
+type LoggerConfig struct {
+	FilePath            string `yaml:"file_path" json:"file_path" env:"LOG_FILE_PATH"`
+	Encoding            string `yaml:"encoding" json:"encoding" env:"LOG_ENCODING"`
+	Level               string `yaml:"level" json:"level" env:"LOG_LEVEL"`
+	TelemetryEnabled    bool   `yaml:"telemetry_enabled" json:"telemetry_enabled" env:"TELEMETRY_ENABLED"`
+	TelemetryEndpoint   string `yaml:"telemetry_endpoint" json:"telemetry_endpoint" env:"TELEMETRY_ENDPOINT"`
+	TelemetryProjectDsn string `yaml:"telemetry_project_dsn" json:"telemetry_project_dsn" env:"TELEMETRY_PROJECT_DSN"`
+	TelemetryIsSecured  bool   `yaml:"telemetry_is_secured" json:"telemetry_is_secured" env:"TELEMETRY_IS_SECURED"`
+	AppName             string `yaml:"app_name" json:"app_name" env:"APP_NAME"`
+	AppServiceName      string `yaml:"app_service_name" json:"app_service_name" env:"APP_SERVICE_NAME"`
+	AppNameSpace        string `yaml:"app_namespace" json:"app_namespace" env:"APP_NAMESPACE"`
+	AppVersion          string `yaml:"app_version" json:"app_version" env:"APP_VERSION"`
+	Environment         string `yaml:"environment" json:"environment" env:"ENVIRONMENT"`
+}
+
+func (c LoggerConfig) isSet() bool {
+	return true
+}
+
+// LogConfig builds the logger configuration.
+func (c LoggerConfig) LogConfig() ntlogger.LogConfig {
+	return ntlogger.LogConfig{
+		FilePath:            c.FilePath,
+		Encoding:            c.Encoding,
+		Level:               c.Level,
+		TelemetryEnabled:    fmt.Sprint(c.TelemetryEnabled),
+		TelemetryEndpoint:   c.TelemetryEndpoint,
+		TelemetryProjectDsn: c.TelemetryProjectDsn,
+		TelemetryIsSecured:  fmt.Sprint(c.TelemetryIsSecured),
+		AppName:             c.AppName,
+		AppServiceName:      c.AppServiceName,
+		AppNameSpace:        c.AppNameSpace,
+		AppVersion:          c.AppVersion,
+		Environment:         c.Environment,
+	}
+}
+
+func (c LoggerConfig) Validate() error {
+	switch c.Level {
+	case "debug", "info", "warn", "error", "fatal":
+	default:
+		return fmt.Errorf("invalid log level: %s", c.Level)
+	}
+	switch c.Encoding {
+	case "json", "console":
+	default:
+		return fmt.Errorf("invalid log encoding: %s, must be 'json' or 'console'", c.Encoding)
+	}
+	if c.TelemetryEnabled && c.TelemetryEndpoint == "" {
+		return fmt.Errorf("missing telemetry_endpoint")
+	}
+	return nil
+}
+
+// MongoConfig locates the database a Mongo backed module stores its data in.
+type MongoConfig struct {
+	URI      string `yaml:"uri" json:"uri" env:"URI"`
+	Database string `yaml:"database" json:"database" env:"DATABASE"`
+}
+
+func (c MongoConfig) Validate() error {
+	if c.URI == "" {
+		return fmt.Errorf("missing mongo uri")
+	}
+	if c.Database == "" {
+		return fmt.Errorf("missing mongo database")
+	}
+	return nil
+}
+
+// AccountingConfig configures the accounting ledger.
+type AccountingConfig struct {
+	Mongo MongoConfig `yaml:"mongo" json:"mongo" env:"ACCOUNTING_MONGO"`
+}
+
+func (c AccountingConfig) isSet() bool {
+	return c.Mongo.URI != ""
+}
+
+func (c AccountingConfig) Validate() error {
+	return c.Mongo.Validate()
+}
+
+// RiskConfig configures the risk register.
+type RiskConfig struct {
+	Mongo MongoConfig `yaml:"mongo" json:"mongo" env:"RISK_MONGO"`
+}
+
+func (c RiskConfig) isSet() bool {
+	return c.Mongo.URI != ""
+}
+
+func (c RiskConfig) Validate() error {
+	return c.Mongo.Validate()
+}
@@ -0,0 +1,169 @@
+package eventbus
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ChaosBroker decorates an IntergrationEventBroker and injects publish errors,
+// duplicate deliveries, delays and out-of-order deliveries so subscribers can be
+// tested for resilience in staging. It must never be used in production.
+type ChaosBroker struct {
+	broker  IntergrationEventBroker
+	cfg     ChaosConfig
+	enabled atomic.Bool
+
+	rndMu sync.Mutex
+	rnd   *rand.Rand
+
+	publishErrors atomic.Uint64
+	duplicates    atomic.Uint64
+	delays        atomic.Uint64
+	reorders      atomic.Uint64
+}
+
+// NewChaosBroker wraps broker with the configured failure injection, enabled from the start
+func NewChaosBroker(broker IntergrationEventBroker, cfg ChaosConfig) *ChaosBroker {
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = time.Second
+	}
+	if cfg.ReorderWindow <= 0 {
+		cfg.ReorderWindow = 5 * time.Second
+	}
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	cb := &ChaosBroker{
+		broker: broker,
+		cfg:    cfg,
+		rnd:    rand.New(rand.NewSource(seed)),
+	}
+	cb.enabled.Store(true)
+	return cb
+}
+
+// SetEnabled turns failure injection on or off, a disabled ChaosBroker passes everything through
+func (cb *ChaosBroker) SetEnabled(enabled bool) {
+	cb.enabled.Store(enabled)
+}
+
+// Stats returns the number of failures injected so far
+func (cb *ChaosBroker) Stats() ChaosStats {
+	return ChaosStats{
+		PublishErrors: cb.publishErrors.Load(),
+		Duplicates:    cb.duplicates.Load(),
+		Delays:        cb.delays.Load(),
+		Reorders:      cb.reorders.Load(),
+	}
+}
+
+func (cb *ChaosBroker) roll(rate float64) bool {
+	if rate <= 0 || !cb.enabled.Load() {
+		return false
+	}
+	cb.rndMu.Lock()
+	defer cb.rndMu.Unlock()
+	return cb.rnd.Float64() < rate
+}
+
+// delay sleeps for a random duration up to MaxDelay when the delay rate hits
+func (cb *ChaosBroker) delay(ctx context.Context) error {
+	if !cb.roll(cb.cfg.DelayRate) {
+		return nil
+	}
+	cb.rndMu.Lock()
+	d := time.Duration(cb.rnd.Int63n(int64(cb.cfg.MaxDelay) + 1))
+	cb.rndMu.Unlock()
+	cb.delays.Add(1)
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (cb *ChaosBroker) Publish(ctx context.Context, pubEvent IntergrationPubEvent) error {
+	if err := cb.delay(ctx); err != nil {
+		return err
+	}
+	if cb.roll(cb.cfg.PublishErrorRate) {
+		cb.publishErrors.Add(1)
+		return ErrChaosInjected
+	}
+	if err := cb.broker.Publish(ctx, pubEvent); err != nil {
+		return err
+	}
+	if cb.roll(cb.cfg.DuplicateRate) {
+		cb.duplicates.Add(1)
+		return cb.broker.Publish(ctx, pubEvent)
+	}
+	return nil
+}
+
+func (cb *ChaosBroker) Subscribe(ctx context.Context, subscriber IntergrationSubscriber) error {
+	subscriber.handler = cb.chaosHandler(ctx, subscriber.handler)
+	return cb.broker.Subscribe(ctx, subscriber)
+}
+
+// chaosHandler wraps a subscriber handler with delayed, duplicate and out-of-order delivery.
+// A held back event is acknowledged to the broker immediately and handed to the handler
+// after the next event, or once ReorderWindow passes without one.
+func (cb *ChaosBroker) chaosHandler(ctx context.Context, handler func(event IntergrationPubEvent) error) func(event IntergrationPubEvent) error {
+	var (
+		mu    sync.Mutex
+		held  *IntergrationPubEvent
+		timer *time.Timer
+	)
+	takeHeld := func() *IntergrationPubEvent {
+		mu.Lock()
+		defer mu.Unlock()
+		ev := held
+		held = nil
+		if timer != nil {
+			timer.Stop()
+			timer = nil
+		}
+		return ev
+	}
+
+	return func(event IntergrationPubEvent) error {
+		if err := cb.delay(ctx); err != nil {
+			return err
+		}
+
+		if cb.roll(cb.cfg.ReorderRate) {
+			mu.Lock()
+			if held == nil {
+				ev := event
+				held = &ev
+				timer = time.AfterFunc(cb.cfg.ReorderWindow, func() {
+					if ev := takeHeld(); ev != nil {
+						_ = handler(*ev)
+					}
+				})
+				mu.Unlock()
+				cb.reorders.Add(1)
+				return nil
+			}
+			mu.Unlock()
+		}
+
+		err := handler(event)
+		if cb.roll(cb.cfg.DuplicateRate) {
+			cb.duplicates.Add(1)
+			_ = handler(event)
+		}
+		if ev := takeHeld(); ev != nil {
+			_ = handler(*ev)
+		}
+		return err
+	}
+}
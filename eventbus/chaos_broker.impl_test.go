@@ -0,0 +1,78 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// loopbackBroker delivers published events synchronously to the subscribers of the event name
+type loopbackBroker struct {
+	subscribers map[string][]IntergrationSubscriber
+}
+
+func (b *loopbackBroker) Publish(ctx context.Context, pubEvent IntergrationPubEvent) error {
+	for _, sub := range b.subscribers[pubEvent.EventName] {
+		_ = sub.handler(pubEvent)
+	}
+	return nil
+}
+
+func (b *loopbackBroker) Subscribe(ctx context.Context, subscriber IntergrationSubscriber) error {
+	b.subscribers[subscriber.EventName] = append(b.subscribers[subscriber.EventName], subscriber)
+	return nil
+}
+
+func TestChaosBrokerInjectsFailures(t *testing.T) {
+	ctx := context.Background()
+
+	var received []int
+	subscribe := func(cb *ChaosBroker) {
+		err := cb.Subscribe(ctx, IntergrationSubscriber{
+			SubscriberName: "test",
+			EventName:      "policy.issued",
+			handler: func(event IntergrationPubEvent) error {
+				received = append(received, event.EventData["seq"].(int))
+				return nil
+			},
+		})
+		if err != nil {
+			t.Fatalf("subscribe: %v", err)
+		}
+	}
+	publish := func(cb *ChaosBroker, seq int) error {
+		return cb.Publish(ctx, IntergrationPubEvent{EventName: "policy.issued", EventData: map[string]any{"seq": seq}, EventTimestamp: time.Now()})
+	}
+
+	failing := NewChaosBroker(&loopbackBroker{subscribers: map[string][]IntergrationSubscriber{}}, ChaosConfig{PublishErrorRate: 1})
+	if err := publish(failing, 1); !errors.Is(err, ErrChaosInjected) {
+		t.Fatalf("expected ErrChaosInjected, got %v", err)
+	}
+	failing.SetEnabled(false)
+	if err := publish(failing, 1); err != nil {
+		t.Fatalf("disabled chaos broker should pass through: %v", err)
+	}
+
+	dup := NewChaosBroker(&loopbackBroker{subscribers: map[string][]IntergrationSubscriber{}}, ChaosConfig{DuplicateRate: 1, Seed: 1})
+	subscribe(dup)
+	if err := publish(dup, 1); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	// duplicated on publish and again on delivery
+	if len(received) != 4 {
+		t.Fatalf("expected 4 deliveries, got %v", received)
+	}
+
+	received = nil
+	reorder := NewChaosBroker(&loopbackBroker{subscribers: map[string][]IntergrationSubscriber{}}, ChaosConfig{ReorderRate: 1, Seed: 1})
+	subscribe(reorder)
+	_ = publish(reorder, 1)
+	_ = publish(reorder, 2)
+	if len(received) != 2 || received[0] != 2 || received[1] != 1 {
+		t.Fatalf("expected events out of order, got %v", received)
+	}
+	if reorder.Stats().Reorders != 1 {
+		t.Fatalf("expected 1 reorder, got %+v", reorder.Stats())
+	}
+}
@@ -0,0 +1,29 @@
+package eventbus
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrChaosInjected is returned by a ChaosBroker publish that failed on purpose
+var ErrChaosInjected = errors.New("eventbus: chaos injected failure")
+
+// ChaosConfig configures the failures a ChaosBroker injects. Rates are probabilities
+// between 0 and 1 evaluated for every event; a zero config injects nothing.
+type ChaosConfig struct {
+	PublishErrorRate float64       // Publishes failing with ErrChaosInjected without reaching the broker
+	DuplicateRate    float64       // Events published twice and handed to subscribers twice
+	DelayRate        float64       // Publishes and deliveries delayed by up to MaxDelay
+	MaxDelay         time.Duration // Upper bound of injected delays, defaults to 1s
+	ReorderRate      float64       // Deliveries held back and handed to the subscriber after the next event
+	ReorderWindow    time.Duration // Longest an event is held back when no next event arrives, defaults to 5s
+	Seed             int64         // Random seed, zero seeds from the clock
+}
+
+// ChaosStats counts the failures a ChaosBroker injected
+type ChaosStats struct {
+	PublishErrors uint64 `json:"publish_errors"`
+	Duplicates    uint64 `json:"duplicates"`
+	Delays        uint64 `json:"delays"`
+	Reorders      uint64 `json:"reorders"`
+}
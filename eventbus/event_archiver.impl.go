@@ -0,0 +1,281 @@
+package eventbus
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	defaultArchivePrefix        = "events"
+	defaultArchiveMaxBatchSize  = 1000
+	defaultArchiveFlushInterval = time.Minute
+	defaultArchiveBufferBatches = 10
+)
+
+// ErrArchiveBufferFull is reported to OnError when events are dropped because the
+// buffer reached MaxBufferSize, typically while the object store is unavailable.
+var ErrArchiveBufferFull = errors.New("event archive buffer full")
+
+// EventArchiver buffers integration events and writes them to an ObjectStore as gzip
+// compressed NDJSON files partitioned by event date:
+//
+//	<prefix>/<appname>/dt=YYYY-MM-DD/<unix-nano>-<seq>.ndjson.gz
+//
+// When started it listens on the plain NATS subjects of the integration stream, so it
+// receives every published event without competing with the work-queue consumers.
+type EventArchiver struct {
+	natsConn *NatsConnInstance
+	store    ObjectStore
+	appname  string
+	cfg      EventArchiverConfig
+
+	mu      sync.Mutex
+	buffer  []IntergrationPubEvent
+	dropped int64
+	seq     int
+	subs    []*nats.Subscription
+	stop    chan struct{}
+	done    chan struct{}
+	now     func() time.Time
+}
+
+// NewEventArchiver creates an archiver for the integration events of appname.
+// natsConn may be nil when events are fed through Archive only.
+func NewEventArchiver(natsConn *NatsConnInstance, appname string, store ObjectStore, cfg EventArchiverConfig) (*EventArchiver, error) {
+	if store == nil {
+		return nil, fmt.Errorf("object store is required")
+	}
+	if appname == "" {
+		return nil, fmt.Errorf("appname is required")
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = defaultArchivePrefix
+	}
+	if cfg.MaxBatchSize <= 0 {
+		cfg.MaxBatchSize = defaultArchiveMaxBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultArchiveFlushInterval
+	}
+	if cfg.MaxBufferSize <= 0 {
+		cfg.MaxBufferSize = defaultArchiveBufferBatches * cfg.MaxBatchSize
+	}
+	if cfg.MaxBufferSize < cfg.MaxBatchSize {
+		cfg.MaxBufferSize = cfg.MaxBatchSize
+	}
+	return &EventArchiver{
+		natsConn: natsConn,
+		store:    store,
+		appname:  appname,
+		cfg:      cfg,
+		now:      time.Now,
+	}, nil
+}
+
+// Start subscribes to the configured event subjects and starts the periodic flush loop.
+func (a *EventArchiver) Start(ctx context.Context) error {
+	if a.natsConn == nil || a.natsConn.status != Active {
+		return fmt.Errorf("nats connection not active")
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.stop != nil {
+		return fmt.Errorf("archiver already started")
+	}
+
+	names := a.cfg.EventNames
+	if len(names) == 0 {
+		names = []string{">"}
+	}
+	for _, name := range names {
+		subject := fmt.Sprintf("%s.intergration.%s", a.appname, name)
+		sub, err := a.natsConn.conn.Subscribe(subject, func(msg *nats.Msg) {
+			var event IntergrationPubEvent
+			if err := json.Unmarshal(msg.Data, &event); err != nil {
+				a.reportError(fmt.Errorf("failed to unmarshal archived message from subject '%s': %w", msg.Subject, err))
+				return
+			}
+			if err := a.Archive(ctx, event); err != nil {
+				a.reportError(fmt.Errorf("failed to archive event '%s': %w", event.EventName, err))
+			}
+		})
+		if err != nil {
+			a.unsubscribe()
+			return fmt.Errorf("failed to subscribe to subject '%s': %w", subject, err)
+		}
+		a.subs = append(a.subs, sub)
+	}
+
+	a.stop = make(chan struct{})
+	a.done = make(chan struct{})
+	go a.flushLoop(ctx, a.stop, a.done)
+	return nil
+}
+
+// Archive buffers the event and flushes when the batch is full. When the buffer is at
+// MaxBufferSize the oldest event is dropped to make room.
+func (a *EventArchiver) Archive(ctx context.Context, event IntergrationPubEvent) error {
+	a.mu.Lock()
+	a.buffer = append(a.buffer, event)
+	dropped := a.trimBuffer()
+	full := len(a.buffer) >= a.cfg.MaxBatchSize
+	a.mu.Unlock()
+	a.reportDropped(dropped)
+
+	if full {
+		return a.Flush(ctx)
+	}
+	return nil
+}
+
+// Flush writes all buffered events to the object store, one object per event date.
+// Events of partitions that fail to upload are kept in the buffer for the next flush,
+// up to MaxBufferSize.
+func (a *EventArchiver) Flush(ctx context.Context) error {
+	a.mu.Lock()
+	events := a.buffer
+	a.buffer = nil
+	a.mu.Unlock()
+	if len(events) == 0 {
+		return nil
+	}
+
+	partitions := make(map[string][]IntergrationPubEvent)
+	for _, event := range events {
+		day := event.EventTimestamp.UTC().Format("2006-01-02")
+		partitions[day] = append(partitions[day], event)
+	}
+	days := make([]string, 0, len(partitions))
+	for day := range partitions {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+
+	var firstErr error
+	var failed []IntergrationPubEvent
+	for _, day := range days {
+		if err := a.writePartition(ctx, day, partitions[day]); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			failed = append(failed, partitions[day]...)
+		}
+	}
+	if len(failed) > 0 {
+		a.mu.Lock()
+		a.buffer = append(failed, a.buffer...)
+		dropped := a.trimBuffer()
+		a.mu.Unlock()
+		a.reportDropped(dropped)
+	}
+	return firstErr
+}
+
+// Dropped returns the number of events dropped because the buffer was full.
+func (a *EventArchiver) Dropped() int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.dropped
+}
+
+// trimBuffer drops the oldest events beyond MaxBufferSize and returns how many it
+// dropped, it must be called with a.mu held
+func (a *EventArchiver) trimBuffer() int {
+	over := len(a.buffer) - a.cfg.MaxBufferSize
+	if over <= 0 {
+		return 0
+	}
+	a.buffer = a.buffer[over:]
+	a.dropped += int64(over)
+	return over
+}
+
+func (a *EventArchiver) reportDropped(n int) {
+	if n > 0 {
+		a.reportError(fmt.Errorf("%w: dropped %d events", ErrArchiveBufferFull, n))
+	}
+}
+
+func (a *EventArchiver) reportError(err error) {
+	if a.cfg.OnError != nil {
+		a.cfg.OnError(err)
+	}
+}
+
+// Stop unsubscribes from NATS, stops the flush loop and flushes the remaining events.
+func (a *EventArchiver) Stop(ctx context.Context) error {
+	a.mu.Lock()
+	a.unsubscribe()
+	stop, done := a.stop, a.done
+	a.stop, a.done = nil, nil
+	a.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+	return a.Flush(ctx)
+}
+
+func (a *EventArchiver) writePartition(ctx context.Context, day string, events []IntergrationPubEvent) error {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(gz)
+	for _, event := range events {
+		if err := enc.Encode(event); err != nil {
+			return fmt.Errorf("failed to encode event '%s': %w", event.EventName, err)
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to compress archive: %w", err)
+	}
+
+	key := a.objectKey(day)
+	if err := a.store.PutObject(ctx, key, bytes.NewReader(buf.Bytes()), int64(buf.Len()), "application/x-ndjson"); err != nil {
+		return fmt.Errorf("failed to upload archive '%s': %w", key, err)
+	}
+	return nil
+}
+
+func (a *EventArchiver) objectKey(day string) string {
+	a.mu.Lock()
+	a.seq++
+	seq := a.seq
+	a.mu.Unlock()
+	return fmt.Sprintf("%s/%s/dt=%s/%d-%06d.ndjson.gz", a.cfg.Prefix, a.appname, day, a.now().UnixNano(), seq)
+}
+
+func (a *EventArchiver) flushLoop(ctx context.Context, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(a.cfg.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := a.Flush(ctx); err != nil {
+				a.reportError(fmt.Errorf("failed to flush event archive: %w", err))
+			}
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// unsubscribe must be called with a.mu held
+func (a *EventArchiver) unsubscribe() {
+	for _, sub := range a.subs {
+		_ = sub.Unsubscribe()
+	}
+	a.subs = nil
+}
@@ -0,0 +1,135 @@
+package eventbus
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryObjectStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	err     error // returned by PutObject when set
+}
+
+func (s *memoryObjectStore) PutObject(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	if s.err != nil {
+		return s.err
+	}
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = b
+	return nil
+}
+
+func TestEventArchiverFlushPartitionsByDate(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryObjectStore{objects: map[string][]byte{}}
+
+	archiver, err := NewEventArchiver(nil, "eventbus", store, EventArchiverConfig{MaxBatchSize: 10})
+	if err != nil {
+		t.Fatalf("Failed to create archiver: %v", err)
+	}
+
+	day1 := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	for _, ts := range []time.Time{day1, day1.Add(time.Hour), day2} {
+		err := archiver.Archive(ctx, IntergrationPubEvent{EventName: "policy.created", EventTimestamp: ts, EventPublisherName: "test"})
+		if err != nil {
+			t.Fatalf("Failed to archive event: %v", err)
+		}
+	}
+	if len(store.objects) != 0 {
+		t.Fatalf("Expected no uploads before flush, got %d", len(store.objects))
+	}
+
+	if err := archiver.Flush(ctx); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	if len(store.objects) != 2 {
+		t.Fatalf("Expected 2 partitions, got %d", len(store.objects))
+	}
+
+	counts := map[string]int{}
+	for key, data := range store.objects {
+		if !strings.HasPrefix(key, "events/eventbus/dt=") || !strings.HasSuffix(key, ".ndjson.gz") {
+			t.Errorf("Unexpected object key %s", key)
+		}
+		gz, err := gzip.NewReader(strings.NewReader(string(data)))
+		if err != nil {
+			t.Fatalf("Failed to open gzip archive: %v", err)
+		}
+		scanner := bufio.NewScanner(gz)
+		for scanner.Scan() {
+			var event IntergrationPubEvent
+			if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+				t.Fatalf("Failed to decode archived event: %v", err)
+			}
+			counts[event.EventTimestamp.Format("2006-01-02")]++
+		}
+	}
+	if counts["2024-03-01"] != 2 || counts["2024-03-02"] != 1 {
+		t.Errorf("Unexpected archived counts %v", counts)
+	}
+}
+
+func TestEventArchiverCapsBufferWhileStoreFails(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryObjectStore{objects: map[string][]byte{}, err: errors.New("store unavailable")}
+	var reported []error
+	archiver, err := NewEventArchiver(nil, "eventbus", store, EventArchiverConfig{
+		MaxBatchSize:  2,
+		MaxBufferSize: 3,
+		OnError:       func(err error) { reported = append(reported, err) },
+	})
+	if err != nil {
+		t.Fatalf("Failed to create archiver: %v", err)
+	}
+
+	day := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	for seq := 1; seq <= 5; seq++ {
+		// full batches are flushed and fail, the events stay buffered
+		_ = archiver.Archive(ctx, IntergrationPubEvent{EventName: "policy.created", EventTimestamp: day, EventData: map[string]any{"seq": seq}})
+	}
+	if got := archiver.Dropped(); got != 2 {
+		t.Fatalf("Expected 2 dropped events, got %d", got)
+	}
+	if len(reported) == 0 || !errors.Is(reported[0], ErrArchiveBufferFull) {
+		t.Fatalf("Expected the dropped events to be reported, got %v", reported)
+	}
+
+	store.err = nil
+	if err := archiver.Flush(ctx); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	var seqs []float64
+	for _, data := range store.objects {
+		gz, err := gzip.NewReader(strings.NewReader(string(data)))
+		if err != nil {
+			t.Fatalf("Failed to open gzip archive: %v", err)
+		}
+		scanner := bufio.NewScanner(gz)
+		for scanner.Scan() {
+			var event IntergrationPubEvent
+			if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+				t.Fatalf("Failed to decode archived event: %v", err)
+			}
+			seqs = append(seqs, event.EventData["seq"].(float64))
+		}
+	}
+	// the oldest events were dropped
+	if len(seqs) != 3 || seqs[0] != 3 || seqs[2] != 5 {
+		t.Errorf("Expected events 3 to 5 to be archived, got %v", seqs)
+	}
+}
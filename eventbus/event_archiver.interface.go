@@ -0,0 +1,33 @@
+package eventbus
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// ObjectStore is the minimal S3-compatible interface used by the event archiver.
+// Implementations wrap the storage SDK of choice (AWS S3, MinIO, GCS interop ...).
+type ObjectStore interface {
+	PutObject(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+}
+
+// EventArchiverConfig configures which integration events are archived and how they are batched.
+type EventArchiverConfig struct {
+	EventNames    []string      // Event names to archive, NATS wildcards allowed; empty archives every event
+	Prefix        string        // Object key prefix, defaults to "events"
+	MaxBatchSize  int           // Events buffered before a flush is forced, defaults to 1000
+	FlushInterval time.Duration // Interval between periodic flushes, defaults to 1 minute
+	MaxBufferSize int           // Events kept while uploads fail, defaults to 10 batches; the oldest are dropped beyond it
+	// OnError is called with the errors of the background subscription and flush loop and
+	// when buffered events are dropped. Errors are discarded when nil.
+	OnError func(err error)
+}
+
+// IntergrationEventArchiver writes integration events to long-term object storage
+type IntergrationEventArchiver interface {
+	Start(ctx context.Context) error
+	Archive(ctx context.Context, event IntergrationPubEvent) error
+	Flush(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
@@ -22,6 +22,12 @@ type IntergrationEventBroker interface {
 	Subscribe(ctx context.Context, subscriber IntergrationSubscriber) error
 }
 
+// TransactionalEventPublisher publishes events atomically with the caller's Mongo transaction.
+// ctx must carry the session of the transaction, e.g. the mongo.SessionContext of WithTransaction.
+type TransactionalEventPublisher interface {
+	PublishInTransaction(ctx context.Context, event IntergrationPubEvent) error
+}
+
 // idea save event on intergrationQueue before publishing ...and on msg processed by consumer update
 
 type IntergrationEventRepo interface {
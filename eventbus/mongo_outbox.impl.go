@@ -0,0 +1,212 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrNoTransaction is returned by PublishInTransaction when the context carries no Mongo session
+var ErrNoTransaction = errors.New("outbox: publish must run in a Mongo transaction")
+
+type OutboxStatus string
+
+const (
+	OutboxPending   OutboxStatus = "pending"
+	OutboxPublished OutboxStatus = "published"
+	OutboxFailed    OutboxStatus = "failed"
+)
+
+const (
+	defaultOutboxCollection   = "event_outbox"
+	defaultOutboxPollInterval = time.Second
+	defaultOutboxLease        = 30 * time.Second
+	defaultOutboxMaxAttempts  = 10
+	defaultOutboxBatchSize    = 100
+)
+
+// OutboxEvent is an integration event stored in the outbox collection until it is relayed to the broker.
+type OutboxEvent struct {
+	ID          primitive.ObjectID   `bson:"_id"`
+	Event       IntergrationPubEvent `bson:"event"`
+	Status      OutboxStatus         `bson:"status"`
+	Attempts    int                  `bson:"attempts"`
+	LastError   string               `bson:"last_error,omitempty"`
+	AvailableAt time.Time            `bson:"available_at"` // Not relayed before this time, used for leases and retry backoff
+	CreatedAt   time.Time            `bson:"created_at"`
+	PublishedAt *time.Time           `bson:"published_at,omitempty"`
+}
+
+type OutboxConfig struct {
+	CollectionName string        // Outbox collection, defaults to "event_outbox"
+	PollInterval   time.Duration // Relay poll interval, defaults to 1s
+	Lease          time.Duration // Time a relay owns a claimed event, defaults to 30s
+	MaxAttempts    int           // Attempts before an event is marked failed, defaults to 10
+	BatchSize      int           // Events relayed per poll, defaults to 100
+	// OnError is called with every failed publish and, with a nil event, when a relay poll
+	// fails. Errors are dropped when nil.
+	OnError func(evt *OutboxEvent, err error)
+}
+
+// MongoEventOutbox implements the transactional outbox pattern: events are written to the
+// outbox collection inside the caller's Mongo transaction and relayed to the broker afterwards,
+// so an event is published if and only if the surrounding database write commits.
+// Delivery is at-least-once; subscribers should be idempotent.
+type MongoEventOutbox struct {
+	events *mongo.Collection
+	broker IntergrationEventBroker
+	cfg    OutboxConfig
+	now    func() time.Time
+}
+
+func NewMongoEventOutbox(db *mongo.Database, broker IntergrationEventBroker, cfg OutboxConfig) *MongoEventOutbox {
+	if cfg.CollectionName == "" {
+		cfg.CollectionName = defaultOutboxCollection
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultOutboxPollInterval
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = defaultOutboxLease
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultOutboxMaxAttempts
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultOutboxBatchSize
+	}
+	return &MongoEventOutbox{
+		events: db.Collection(cfg.CollectionName),
+		broker: broker,
+		cfg:    cfg,
+		now:    time.Now,
+	}
+}
+
+// EnsureIndexes creates the index used by the relay to find pending events.
+func (o *MongoEventOutbox) EnsureIndexes(ctx context.Context) error {
+	_, err := o.events.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "available_at", Value: 1}},
+	})
+	return err
+}
+
+// PublishInTransaction writes the event to the outbox using the session of ctx, so it
+// becomes visible to the relay only when the caller's transaction commits.
+func (o *MongoEventOutbox) PublishInTransaction(ctx context.Context, event IntergrationPubEvent) error {
+	if event.EventName == "" {
+		return fmt.Errorf("event name is required")
+	}
+	if mongo.SessionFromContext(ctx) == nil {
+		return ErrNoTransaction
+	}
+	if event.EventTimestamp.IsZero() {
+		event.EventTimestamp = o.now()
+	}
+	now := o.now()
+	_, err := o.events.InsertOne(ctx, OutboxEvent{
+		ID:          primitive.NewObjectID(),
+		Event:       event,
+		Status:      OutboxPending,
+		AvailableAt: now,
+		CreatedAt:   now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write event '%s' to outbox: %w", event.EventName, err)
+	}
+	return nil
+}
+
+// Run relays pending events until ctx is cancelled.
+func (o *MongoEventOutbox) Run(ctx context.Context) error {
+	ticker := time.NewTicker(o.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := o.RelayPending(ctx); err != nil && ctx.Err() == nil {
+			o.reportError(nil, fmt.Errorf("failed to relay outbox events: %w", err))
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayPending publishes up to BatchSize pending events and returns how many were published.
+// Each event is claimed with a lease first, so several relays can run concurrently.
+func (o *MongoEventOutbox) RelayPending(ctx context.Context) (int, error) {
+	published := 0
+	for i := 0; i < o.cfg.BatchSize; i++ {
+		evt, err := o.claim(ctx)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return published, nil
+			}
+			return published, err
+		}
+		if err := o.broker.Publish(ctx, evt.Event); err != nil {
+			o.reportError(evt, err)
+			if markErr := o.markFailedAttempt(ctx, evt, err); markErr != nil {
+				return published, markErr
+			}
+			continue
+		}
+		if err := o.markPublished(ctx, evt); err != nil {
+			return published, err
+		}
+		published++
+	}
+	return published, nil
+}
+
+func (o *MongoEventOutbox) reportError(evt *OutboxEvent, err error) {
+	if o.cfg.OnError != nil {
+		o.cfg.OnError(evt, err)
+	}
+}
+
+func (o *MongoEventOutbox) claim(ctx context.Context) (*OutboxEvent, error) {
+	now := o.now()
+	filter := bson.M{"status": OutboxPending, "available_at": bson.M{"$lte": now}}
+	update := bson.M{
+		"$set": bson.M{"available_at": now.Add(o.cfg.Lease)},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "available_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var evt OutboxEvent
+	if err := o.events.FindOneAndUpdate(ctx, filter, update, opts).Decode(&evt); err != nil {
+		return nil, err
+	}
+	return &evt, nil
+}
+
+func (o *MongoEventOutbox) markPublished(ctx context.Context, evt *OutboxEvent) error {
+	now := o.now()
+	_, err := o.events.UpdateOne(ctx,
+		bson.M{"_id": evt.ID},
+		bson.M{"$set": bson.M{"status": OutboxPublished, "published_at": now}, "$unset": bson.M{"last_error": ""}},
+	)
+	return err
+}
+
+func (o *MongoEventOutbox) markFailedAttempt(ctx context.Context, evt *OutboxEvent, publishErr error) error {
+	set := bson.M{"last_error": publishErr.Error()}
+	if evt.Attempts >= o.cfg.MaxAttempts {
+		set["status"] = OutboxFailed
+	} else {
+		// linear backoff keeps retries of a broken broker from spinning
+		set["available_at"] = o.now().Add(time.Duration(evt.Attempts) * o.cfg.PollInterval)
+	}
+	_, err := o.events.UpdateOne(ctx, bson.M{"_id": evt.ID}, bson.M{"$set": set})
+	return err
+}
@@ -0,0 +1,192 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// recordingBroker records published events and fails while err is set
+type recordingBroker struct {
+	published []IntergrationPubEvent
+	err       error
+}
+
+func (b *recordingBroker) Publish(ctx context.Context, pubEvent IntergrationPubEvent) error {
+	if b.err != nil {
+		return b.err
+	}
+	b.published = append(b.published, pubEvent)
+	return nil
+}
+
+func (b *recordingBroker) Subscribe(ctx context.Context, subscriber IntergrationSubscriber) error {
+	return nil
+}
+
+// newTestOutbox returns an outbox on a throwaway database of the replica set at
+// EVENTBUS_TEST_MONGO, the relay clock starts at the returned time
+func newTestOutbox(t *testing.T, broker IntergrationEventBroker, cfg OutboxConfig) (*MongoEventOutbox, *time.Time) {
+	t.Helper()
+	uri := os.Getenv("EVENTBUS_TEST_MONGO")
+	if uri == "" {
+		t.Skip("EVENTBUS_TEST_MONGO is not set")
+	}
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	db := client.Database("eventbus_test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		_ = db.Drop(ctx)
+		_ = client.Disconnect(ctx)
+	})
+
+	outbox := NewMongoEventOutbox(db, broker, cfg)
+	if err := outbox.EnsureIndexes(ctx); err != nil {
+		t.Fatalf("Failed to create indexes: %v", err)
+	}
+	// Mongo keeps milliseconds
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	outbox.now = func() time.Time { return now }
+	return outbox, &now
+}
+
+// publish writes the events to the outbox in a transaction aborted when abort is set
+func publish(t *testing.T, o *MongoEventOutbox, abort bool, names ...string) {
+	t.Helper()
+	ctx := context.Background()
+	session, err := o.events.Database().Client().StartSession()
+	if err != nil {
+		t.Fatalf("Failed to start session: %v", err)
+	}
+	defer session.EndSession(ctx)
+	errAbort := errors.New("abort")
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		for _, name := range names {
+			if err := o.PublishInTransaction(sc, IntergrationPubEvent{EventName: name}); err != nil {
+				return nil, err
+			}
+		}
+		if abort {
+			return nil, errAbort
+		}
+		return nil, nil
+	})
+	if err != nil && !(abort && errors.Is(err, errAbort)) {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+}
+
+func TestMongoEventOutboxPublishInTransaction(t *testing.T) {
+	ctx := context.Background()
+	o, _ := newTestOutbox(t, &recordingBroker{}, OutboxConfig{})
+
+	publish(t, o, true, "policy.aborted")
+	publish(t, o, false, "policy.issued")
+	if n, _ := o.events.CountDocuments(ctx, bson.M{}); n != 1 {
+		t.Fatalf("Expected only the committed event, got %d", n)
+	}
+	if err := o.PublishInTransaction(ctx, IntergrationPubEvent{EventName: "policy.issued"}); !errors.Is(err, ErrNoTransaction) {
+		t.Fatalf("Expected ErrNoTransaction outside a transaction, got %v", err)
+	}
+}
+
+func TestMongoEventOutboxClaimLease(t *testing.T) {
+	ctx := context.Background()
+	o, now := newTestOutbox(t, &recordingBroker{}, OutboxConfig{Lease: 30 * time.Second})
+	publish(t, o, false, "policy.issued")
+	started := *now
+
+	evt, err := o.claim(ctx)
+	if err != nil {
+		t.Fatalf("Failed to claim: %v", err)
+	}
+	if evt.Attempts != 1 || !evt.AvailableAt.Equal(started.Add(30*time.Second)) {
+		t.Fatalf("Expected the first attempt leased for 30s, got %+v", evt)
+	}
+	// a leased event is hidden from other relays until the lease expires
+	if _, err := o.claim(ctx); !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Fatalf("Expected the leased event to be hidden, got %v", err)
+	}
+	*now = started.Add(30 * time.Second)
+	evt, err = o.claim(ctx)
+	if err != nil || evt.Attempts != 2 {
+		t.Fatalf("Expected the expired lease to be claimed again, got %+v, %v", evt, err)
+	}
+}
+
+func TestMongoEventOutboxMarkFailedAttempt(t *testing.T) {
+	ctx := context.Background()
+	o, now := newTestOutbox(t, &recordingBroker{}, OutboxConfig{PollInterval: time.Second, MaxAttempts: 2})
+	publish(t, o, false, "policy.issued")
+	started := *now
+
+	evt, err := o.claim(ctx)
+	if err != nil {
+		t.Fatalf("Failed to claim: %v", err)
+	}
+	if err := o.markFailedAttempt(ctx, evt, errors.New("broker down")); err != nil {
+		t.Fatalf("Failed to mark attempt: %v", err)
+	}
+	var stored OutboxEvent
+	if err := o.events.FindOne(ctx, bson.M{"_id": evt.ID}).Decode(&stored); err != nil {
+		t.Fatalf("Failed to load event: %v", err)
+	}
+	if stored.Status != OutboxPending || stored.LastError != "broker down" || !stored.AvailableAt.Equal(started.Add(time.Second)) {
+		t.Fatalf("Expected a retry after backoff, got %+v", stored)
+	}
+
+	// the last attempt marks the event failed, it is never claimed again
+	*now = started.Add(time.Second)
+	evt, err = o.claim(ctx)
+	if err != nil {
+		t.Fatalf("Failed to claim the retry: %v", err)
+	}
+	if err := o.markFailedAttempt(ctx, evt, errors.New("broker down")); err != nil {
+		t.Fatalf("Failed to mark attempt: %v", err)
+	}
+	if err := o.events.FindOne(ctx, bson.M{"_id": evt.ID}).Decode(&stored); err != nil {
+		t.Fatalf("Failed to load event: %v", err)
+	}
+	if stored.Status != OutboxFailed || stored.Attempts != 2 {
+		t.Fatalf("Expected the event to be failed after 2 attempts, got %+v", stored)
+	}
+	*now = started.Add(time.Hour)
+	if _, err := o.claim(ctx); !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Fatalf("Expected the failed event to be skipped, got %v", err)
+	}
+}
+
+func TestMongoEventOutboxRelayPending(t *testing.T) {
+	ctx := context.Background()
+	broker := &recordingBroker{err: errors.New("broker down")}
+	var reported []error
+	o, now := newTestOutbox(t, broker, OutboxConfig{PollInterval: time.Second, OnError: func(evt *OutboxEvent, err error) {
+		reported = append(reported, err)
+	}})
+	publish(t, o, false, "policy.issued", "policy.renewed")
+
+	n, err := o.RelayPending(ctx)
+	if err != nil || n != 0 || len(reported) != 2 {
+		t.Fatalf("Expected both publishes to fail and be reported, got %d, %v, %v", n, err, reported)
+	}
+
+	broker.err = nil
+	*now = now.Add(time.Second)
+	n, err = o.RelayPending(ctx)
+	if err != nil || n != 2 || len(broker.published) != 2 {
+		t.Fatalf("Expected both events to be relayed, got %d, %v", n, err)
+	}
+	if count, _ := o.events.CountDocuments(ctx, bson.M{"status": OutboxPublished, "last_error": bson.M{"$exists": false}}); count != 2 {
+		t.Fatalf("Expected both events published, got %d", count)
+	}
+}
@@ -24,6 +24,18 @@ type NatsConfig struct {
 	password            string
 }
 
+// NewNatsConfig creates the connection config of appName to natsUrl.
+// Credentials are sent when username is not empty.
+func NewNatsConfig(natsUrl, appName, username, password string) NatsConfig {
+	return NatsConfig{
+		natsUrl:             natsUrl,
+		appName:             appName,
+		requiresCredentials: username != "",
+		username:            username,
+		password:            password,
+	}
+}
+
 type NatsConnInstance struct {
 	conn   *nats.Conn
 	status ConnectionStatus
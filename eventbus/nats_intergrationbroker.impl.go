@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/nats-io/nats.go/jetstream"
@@ -15,6 +16,10 @@ type NatsIntergrationBroker struct {
 	strm                   jetstream.Stream
 	appname                string
 	intergrationStreamSubj string
+
+	metricsMu sync.Mutex
+	stats     map[string]*subscriberStats   // processing metrics per event name
+	consumers map[string]jetstream.Consumer // consumer per event name, for backlog metrics
 }
 
 func NewNatsIntergrationBroker(natsConn *NatsConnInstance, appname string) (*NatsIntergrationBroker, error) {
@@ -85,6 +90,16 @@ func (ntib *NatsIntergrationBroker) Subscribe(ctx context.Context, subscriber In
 		return fmt.Errorf("failed to create consumer for subject '%s': %w", subject, err)
 	}
 
+	stats := newSubscriberStats(subscriber.SubscriberName, subscriber.EventName)
+	ntib.metricsMu.Lock()
+	if ntib.stats == nil {
+		ntib.stats = make(map[string]*subscriberStats)
+		ntib.consumers = make(map[string]jetstream.Consumer)
+	}
+	ntib.stats[subscriber.EventName] = stats
+	ntib.consumers[subscriber.EventName] = cons
+	ntib.metricsMu.Unlock()
+
 	// Consume messages
 	_, err = cons.Consume(func(jsMsg jetstream.Msg) {
 
@@ -98,7 +113,9 @@ func (ntib *NatsIntergrationBroker) Subscribe(ctx context.Context, subscriber In
 		}
 
 		// Process the message using the provided handler
-		subscriber.handler(msg)
+		started := time.Now()
+		err := subscriber.handler(msg)
+		stats.observe(time.Since(started), err)
 		jsMsg.Ack()
 	})
 	if err != nil {
@@ -0,0 +1,158 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultScalerAccount      = "$G"
+	defaultScalerLagThreshold = 100
+
+	// latencyEWMAWeight is the weight of the newest sample in AvgLatency
+	latencyEWMAWeight = 0.2
+)
+
+// subscriberStats accumulates the processing metrics of one subscriber
+type subscriberStats struct {
+	mu      sync.Mutex
+	metrics SubscriberMetrics
+}
+
+func newSubscriberStats(subscriberName, eventName string) *subscriberStats {
+	return &subscriberStats{metrics: SubscriberMetrics{SubscriberName: subscriberName, EventName: eventName}}
+}
+
+// observe records one handled event
+func (s *subscriberStats) observe(latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := &s.metrics
+	m.Processed++
+	if err != nil {
+		m.Failed++
+	}
+	m.LastLatency = latency
+	if m.Processed == 1 {
+		m.AvgLatency = latency
+	} else {
+		m.AvgLatency = time.Duration(latencyEWMAWeight*float64(latency) + (1-latencyEWMAWeight)*float64(m.AvgLatency))
+	}
+	if latency > m.MaxLatency {
+		m.MaxLatency = latency
+	}
+	m.UpdatedAt = time.Now()
+}
+
+func (s *subscriberStats) snapshot() SubscriberMetrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.metrics
+}
+
+// SubscriberMetrics returns the metrics of every subscriber of the broker, with the
+// backlog read from the JetStream consumer
+func (ntib *NatsIntergrationBroker) SubscriberMetrics(ctx context.Context) ([]SubscriberMetrics, error) {
+	ntib.metricsMu.Lock()
+	names := make([]string, 0, len(ntib.stats))
+	for name := range ntib.stats {
+		names = append(names, name)
+	}
+	ntib.metricsMu.Unlock()
+	sort.Strings(names)
+
+	metrics := make([]SubscriberMetrics, 0, len(names))
+	for _, name := range names {
+		m, err := ntib.SubscriberMetricsFor(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// SubscriberMetricsFor returns the metrics of the subscriber of eventName
+func (ntib *NatsIntergrationBroker) SubscriberMetricsFor(ctx context.Context, eventName string) (SubscriberMetrics, error) {
+	ntib.metricsMu.Lock()
+	stats, ok := ntib.stats[eventName]
+	cons := ntib.consumers[eventName]
+	ntib.metricsMu.Unlock()
+	if !ok {
+		return SubscriberMetrics{}, fmt.Errorf("no subscriber for event '%s'", eventName)
+	}
+
+	m := stats.snapshot()
+	info, err := cons.Info(ctx)
+	if err != nil {
+		return SubscriberMetrics{}, fmt.Errorf("failed to read consumer info for event '%s': %w", eventName, err)
+	}
+	m.Backlog = info.NumPending
+	m.InFlight = info.NumAckPending
+	return m, nil
+}
+
+// ScalerMetadata returns the trigger metadata of a KEDA nats-jetstream scaler that scales
+// the subscriber of eventName on its backlog, e.g.
+//
+//	triggers:
+//	- type: nats-jetstream
+//	  metadata:
+//	    natsServerMonitoringEndpoint: "nats.nats.svc:8222"
+//	    account: "$G"
+//	    stream: "<appname>"
+//	    consumer: "<eventName>"
+//	    lagThreshold: "100"
+func (ntib *NatsIntergrationBroker) ScalerMetadata(eventName string, cfg NatsScalerConfig) map[string]string {
+	if cfg.Account == "" {
+		cfg.Account = defaultScalerAccount
+	}
+	if cfg.LagThreshold <= 0 {
+		cfg.LagThreshold = defaultScalerLagThreshold
+	}
+	return map[string]string{
+		"natsServerMonitoringEndpoint": cfg.MonitoringEndpoint,
+		"account":                      cfg.Account,
+		"stream":                       ntib.appname,
+		"consumer":                     eventName,
+		"lagThreshold":                 strconv.Itoa(cfg.LagThreshold),
+		"activationLagThreshold":       strconv.Itoa(cfg.ActivationLagThreshold),
+		"useHttps":                     strconv.FormatBool(cfg.UseHTTPS),
+	}
+}
+
+// NewSubscriberMetricsHandler serves subscriber metrics as JSON for custom autoscalers or the
+// KEDA metrics-api scaler. GET ?event=<name> returns the metrics of a single subscriber,
+// without it all subscribers are returned.
+func NewSubscriberMetricsHandler(provider SubscriberMetricsProvider) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var body any
+		if eventName := r.URL.Query().Get("event"); eventName != "" {
+			m, err := provider.SubscriberMetricsFor(r.Context(), eventName)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			body = m
+		} else {
+			metrics, err := provider.SubscriberMetrics(r.Context())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			body = metrics
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(body)
+	})
+}
@@ -0,0 +1,70 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type staticMetricsProvider struct {
+	metrics []SubscriberMetrics
+}
+
+func (p staticMetricsProvider) SubscriberMetrics(ctx context.Context) ([]SubscriberMetrics, error) {
+	return p.metrics, nil
+}
+
+func (p staticMetricsProvider) SubscriberMetricsFor(ctx context.Context, eventName string) (SubscriberMetrics, error) {
+	for _, m := range p.metrics {
+		if m.EventName == eventName {
+			return m, nil
+		}
+	}
+	return SubscriberMetrics{}, errors.New("not found")
+}
+
+func TestSubscriberStatsObserve(t *testing.T) {
+	stats := newSubscriberStats("issuer", "certificate.issue")
+	stats.observe(100*time.Millisecond, nil)
+	stats.observe(200*time.Millisecond, errors.New("failed"))
+
+	m := stats.snapshot()
+	if m.Processed != 2 || m.Failed != 1 {
+		t.Errorf("Expected 2 processed and 1 failed, got %d and %d", m.Processed, m.Failed)
+	}
+	if m.LastLatency != 200*time.Millisecond || m.MaxLatency != 200*time.Millisecond {
+		t.Errorf("Expected last and max latency of 200ms, got %v and %v", m.LastLatency, m.MaxLatency)
+	}
+	if m.AvgLatency != 120*time.Millisecond {
+		t.Errorf("Expected average latency of 120ms, got %v", m.AvgLatency)
+	}
+}
+
+func TestSubscriberMetricsHandler(t *testing.T) {
+	handler := NewSubscriberMetricsHandler(staticMetricsProvider{metrics: []SubscriberMetrics{
+		{SubscriberName: "issuer", EventName: "certificate.issue", Backlog: 42},
+	}})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?event=certificate.issue", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	var m SubscriberMetrics
+	if err := json.Unmarshal(rec.Body.Bytes(), &m); err != nil {
+		t.Fatalf("Failed to decode metrics: %v", err)
+	}
+	if m.Backlog != 42 {
+		t.Errorf("Expected backlog 42, got %d", m.Backlog)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?event=unknown", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown event, got %d", rec.Code)
+	}
+}
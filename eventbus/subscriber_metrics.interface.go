@@ -0,0 +1,37 @@
+package eventbus
+
+import (
+	"context"
+	"time"
+)
+
+// SubscriberMetrics describes how a subscriber keeps up with its events.
+// Autoscalers use Backlog to decide when to add consumers and the latency to
+// estimate how long the backlog takes to drain.
+type SubscriberMetrics struct {
+	SubscriberName string        `json:"subscriber_name"`
+	EventName      string        `json:"event_name"`
+	Processed      uint64        `json:"processed"`       // Events handled since the subscriber started
+	Failed         uint64        `json:"failed"`          // Events whose handler returned an error
+	LastLatency    time.Duration `json:"last_latency_ns"` // Handler duration of the last event
+	AvgLatency     time.Duration `json:"avg_latency_ns"`  // Exponentially weighted average handler duration
+	MaxLatency     time.Duration `json:"max_latency_ns"`  // Slowest handler duration seen
+	Backlog        uint64        `json:"backlog"`         // Events waiting to be delivered
+	InFlight       int           `json:"in_flight"`       // Events delivered but not yet acknowledged
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+// SubscriberMetricsProvider is queried by autoscalers for the processing metrics of subscribers
+type SubscriberMetricsProvider interface {
+	SubscriberMetrics(ctx context.Context) ([]SubscriberMetrics, error)
+	SubscriberMetricsFor(ctx context.Context, eventName string) (SubscriberMetrics, error)
+}
+
+// NatsScalerConfig describes the KEDA nats-jetstream scaler of a subscriber
+type NatsScalerConfig struct {
+	MonitoringEndpoint     string // NATS monitoring endpoint, e.g. "nats.nats.svc:8222"
+	Account                string // NATS account of the stream, defaults to "$G"
+	LagThreshold           int    // Backlog per replica that triggers scale out, defaults to 100
+	ActivationLagThreshold int    // Backlog that activates a scaled to zero deployment, defaults to 0
+	UseHTTPS               bool   // Query the monitoring endpoint over https
+}
@@ -0,0 +1,68 @@
+package quotation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type eligibilityEngineInstance struct {
+	now func() time.Time
+}
+
+func NewEligibilityEngineInstance() EligibilityEngine {
+	return &eligibilityEngineInstance{now: time.Now}
+}
+
+// Evaluate checks the risk and cover against every restriction of the rule and
+// collects a reason for each one that fails.
+func (eng *eligibilityEngineInstance) Evaluate(underwriterID string, rule UnderwriterEligibilityRule, cover *CoverDetails, risk *RiskDetails) EligibilityResult {
+	var reasons []string
+
+	if len(rule.AcceptedVehicleTypes) > 0 {
+		accepted := false
+		for _, vt := range rule.AcceptedVehicleTypes {
+			if vt == risk.VehicleType {
+				accepted = true
+				break
+			}
+		}
+		if !accepted {
+			reasons = append(reasons, fmt.Sprintf("vehicle type %s is not accepted", risk.VehicleType))
+		}
+	}
+
+	if rule.MaxVehicleAge > 0 {
+		if risk.YearOfManufacture <= 0 {
+			reasons = append(reasons, "year of manufacture is required to check vehicle age")
+		} else if age := eng.now().Year() - risk.YearOfManufacture; age > rule.MaxVehicleAge {
+			reasons = append(reasons, fmt.Sprintf("vehicle age %d exceeds maximum of %d years", age, rule.MaxVehicleAge))
+		}
+	}
+
+	if rule.MinSumInsured.IsPositive() && cover.SumInsured.LessThan(rule.MinSumInsured) {
+		reasons = append(reasons, fmt.Sprintf("sum insured %s is below minimum of %s", cover.SumInsured.String(), rule.MinSumInsured.String()))
+	}
+
+	if containsRegion(rule.ExcludedRegions, risk.Region) {
+		reasons = append(reasons, fmt.Sprintf("region %s is excluded", risk.Region))
+	} else if len(rule.AllowedRegions) > 0 && !containsRegion(rule.AllowedRegions, risk.Region) {
+		reasons = append(reasons, fmt.Sprintf("region %s is not covered", risk.Region))
+	}
+
+	return EligibilityResult{
+		UnderwriterID: underwriterID,
+		Eligible:      len(reasons) == 0,
+		Reasons:       reasons,
+	}
+}
+
+func containsRegion(regions []string, region string) bool {
+	region = strings.TrimSpace(region)
+	for _, r := range regions {
+		if strings.EqualFold(strings.TrimSpace(r), region) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package quotation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nana-tec/gopackages/insurance/risk"
+	"github.com/shopspring/decimal"
+)
+
+func TestEligibilityExcludesUnderwriters(t *testing.T) {
+	engine := &eligibilityEngineInstance{now: func() time.Time { return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC) }}
+
+	generator, err := NewQuotationGeneratorInstance(nil, engine, []UnderwriterRate{
+		{
+			UnderwriterID:  "uw-open",
+			Rate:           decimal.NewFromFloat(0.04),
+			MinimumPremium: decimal.NewFromInt(15000),
+		},
+		{
+			UnderwriterID: "uw-strict",
+			Rate:          decimal.NewFromFloat(0.035),
+			Eligibility: UnderwriterEligibilityRule{
+				AcceptedVehicleTypes: []risk.VehicleType{risk.Private},
+				MaxVehicleAge:        10,
+				MinSumInsured:        decimal.NewFromInt(1000000),
+				ExcludedRegions:      []string{"Mandera"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create quotation generator : %v", err)
+	}
+
+	resp, err := generator.GenerateQuotation(context.Background(), &QuotationRequest{
+		Cover: &CoverDetails{StartDate: "2025-01-01", Period: 365, SumInsured: decimal.NewFromInt(300000)},
+		Risk: &RiskDetails{
+			RegistrationNumber: "KDM330X",
+			VehicleType:        risk.PSVMatatu,
+			YearOfManufacture:  2010,
+			Region:             "mandera",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to generate quotation : %v", err)
+	}
+
+	if len(resp.Quotes) != 1 || resp.Quotes[0].UnderwriterID != "uw-open" {
+		t.Fatalf("Expected a single quote from uw-open, got %+v", resp.Quotes)
+	}
+	if !resp.Quotes[0].Premium.Equal(decimal.NewFromInt(15000)) {
+		t.Errorf("Expected minimum premium to apply, got %s", resp.Quotes[0].Premium)
+	}
+	if len(resp.Excluded) != 1 || resp.Excluded[0].UnderwriterID != "uw-strict" {
+		t.Fatalf("Expected uw-strict to be excluded, got %+v", resp.Excluded)
+	}
+	if len(resp.Excluded[0].Reasons) != 4 {
+		t.Errorf("Expected 4 exclusion reasons, got %v", resp.Excluded[0].Reasons)
+	}
+}
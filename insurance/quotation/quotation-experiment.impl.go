@@ -0,0 +1,129 @@
+package quotation
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func validatePricingExperiment(exp PricingExperiment) error {
+	if exp.ID == "" {
+		return fmt.Errorf("pricing experiment id is required")
+	}
+	switch exp.AssignBy {
+	case ExperimentByClient, ExperimentByRisk:
+	default:
+		return fmt.Errorf("pricing experiment %s: invalid assignment unit %q", exp.ID, exp.AssignBy)
+	}
+	if len(exp.Variants) == 0 {
+		return fmt.Errorf("pricing experiment %s has no variants", exp.ID)
+	}
+	names := make(map[string]bool, len(exp.Variants))
+	for _, v := range exp.Variants {
+		if v.Name == "" {
+			return fmt.Errorf("pricing experiment %s: variant name is required", exp.ID)
+		}
+		if names[v.Name] {
+			return fmt.Errorf("pricing experiment %s: duplicate variant %s", exp.ID, v.Name)
+		}
+		names[v.Name] = true
+		if v.Weight <= 0 {
+			return fmt.Errorf("pricing experiment %s: variant %s must have a positive weight", exp.ID, v.Name)
+		}
+		if v.RateAdjustment.LessThanOrEqual(decimal.NewFromInt(-1)) {
+			return fmt.Errorf("pricing experiment %s: variant %s rate adjustment must be above -1", exp.ID, v.Name)
+		}
+	}
+	return nil
+}
+
+// appliesTo reports whether the experiment prices quotes of the underwriter.
+func (exp PricingExperiment) appliesTo(underwriterID string) bool {
+	if len(exp.UnderwriterIDs) == 0 {
+		return true
+	}
+	for _, id := range exp.UnderwriterIDs {
+		if id == underwriterID {
+			return true
+		}
+	}
+	return false
+}
+
+// assignmentKey returns the client or risk identifier the experiment assigns by,
+// empty when the request does not carry one.
+func (exp PricingExperiment) assignmentKey(req *QuotationRequest) string {
+	switch exp.AssignBy {
+	case ExperimentByClient:
+		if req.Client == nil {
+			return ""
+		}
+		if req.Client.IDnumber != "" {
+			return strings.ToUpper(strings.TrimSpace(req.Client.IDnumber))
+		}
+		return strings.ToUpper(strings.TrimSpace(req.Client.PinNumber))
+	case ExperimentByRisk:
+		if req.Risk.RegistrationNumber != "" {
+			return strings.ToUpper(strings.ReplaceAll(req.Risk.RegistrationNumber, " ", ""))
+		}
+		return strings.ToUpper(strings.TrimSpace(req.Risk.ChassisNumber))
+	}
+	return ""
+}
+
+// AssignVariant deterministically picks the variant for key. The experiment ID is part
+// of the hash so that concurrent experiments split traffic independently.
+func (exp PricingExperiment) AssignVariant(key string) PricingVariant {
+	total := 0
+	for _, v := range exp.Variants {
+		total += v.Weight
+	}
+	h := fnv.New64a()
+	h.Write([]byte(exp.ID + ":" + key))
+	bucket := int(h.Sum64() % uint64(total))
+	for _, v := range exp.Variants {
+		if bucket < v.Weight {
+			return v
+		}
+		bucket -= v.Weight
+	}
+	return exp.Variants[len(exp.Variants)-1]
+}
+
+// applyExperiment reprices the quote under the first experiment that applies to the
+// underwriter and logs the exposure. Requests without an assignment key keep standard pricing.
+func (qgen *quotationGeneratorInstance) applyExperiment(ctx context.Context, quote *UnderwriterQuote, uw UnderwriterRate, req *QuotationRequest) {
+	for _, exp := range qgen.experiments {
+		if !exp.appliesTo(uw.UnderwriterID) {
+			continue
+		}
+		key := exp.assignmentKey(req)
+		if key == "" {
+			return
+		}
+		variant := exp.AssignVariant(key)
+		basePremium := quote.Premium
+		adjusted := uw
+		adjusted.Rate = uw.Rate.Mul(decimal.NewFromInt(1).Add(variant.RateAdjustment))
+		quote.Premium = calculatePremium(adjusted, req.Cover)
+		quote.ExperimentID = exp.ID
+		quote.Variant = variant.Name
+
+		if qgen.exposures != nil {
+			qgen.exposures.LogExposure(ctx, ExperimentExposure{
+				ExperimentID:  exp.ID,
+				Variant:       variant.Name,
+				UnderwriterID: uw.UnderwriterID,
+				AssignmentKey: key,
+				BasePremium:   basePremium,
+				Premium:       quote.Premium,
+				ExposedAt:     time.Now(),
+			})
+		}
+		return
+	}
+}
@@ -0,0 +1,79 @@
+package quotation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nana-tec/gopackages/insurance/risk"
+	"github.com/shopspring/decimal"
+)
+
+type recordingExposureLogger struct {
+	exposures []ExperimentExposure
+}
+
+func (l *recordingExposureLogger) LogExposure(ctx context.Context, exposure ExperimentExposure) {
+	l.exposures = append(l.exposures, exposure)
+}
+
+func TestPricingExperimentAdjustsRateAndLogsExposure(t *testing.T) {
+	exposures := &recordingExposureLogger{}
+	experiment := PricingExperiment{
+		ID:             "psv-discount",
+		AssignBy:       ExperimentByRisk,
+		UnderwriterIDs: []string{"uw-a"},
+		Variants: []PricingVariant{
+			{Name: "discount", Weight: 1, RateAdjustment: decimal.NewFromFloat(-0.1)},
+		},
+	}
+	generator, err := NewQuotationGeneratorWithExperiments(nil, nil, []UnderwriterRate{
+		{UnderwriterID: "uw-a", Rate: decimal.NewFromFloat(0.04)},
+		{UnderwriterID: "uw-b", Rate: decimal.NewFromFloat(0.04)},
+	}, []PricingExperiment{experiment}, exposures)
+	if err != nil {
+		t.Fatalf("Failed to create quotation generator : %v", err)
+	}
+
+	resp, err := generator.GenerateQuotation(context.Background(), &QuotationRequest{
+		Cover: &CoverDetails{StartDate: "2025-01-01", Period: 365, SumInsured: decimal.NewFromInt(1000000)},
+		Risk:  &RiskDetails{RegistrationNumber: "KDM 330X", VehicleType: risk.Private},
+	})
+	if err != nil {
+		t.Fatalf("Failed to generate quotation : %v", err)
+	}
+
+	if len(resp.Quotes) != 2 {
+		t.Fatalf("Expected 2 quotes, got %+v", resp.Quotes)
+	}
+	if q := resp.Quotes[0]; q.Variant != "discount" || !q.Premium.Equal(decimal.NewFromInt(36000)) {
+		t.Errorf("Expected uw-a to be priced by the discount variant at 36000, got %+v", q)
+	}
+	if q := resp.Quotes[1]; q.ExperimentID != "" || !q.Premium.Equal(decimal.NewFromInt(40000)) {
+		t.Errorf("Expected uw-b to keep standard pricing, got %+v", q)
+	}
+	if len(exposures.exposures) != 1 || exposures.exposures[0].AssignmentKey != "KDM330X" {
+		t.Fatalf("Expected a single exposure keyed by registration, got %+v", exposures.exposures)
+	}
+	if !exposures.exposures[0].BasePremium.Equal(decimal.NewFromInt(40000)) {
+		t.Errorf("Expected base premium 40000, got %s", exposures.exposures[0].BasePremium)
+	}
+}
+
+func TestPricingExperimentAssignmentIsStable(t *testing.T) {
+	experiment := PricingExperiment{
+		ID:       "split",
+		AssignBy: ExperimentByClient,
+		Variants: []PricingVariant{{Name: "control", Weight: 1}, {Name: "uplift", Weight: 1, RateAdjustment: decimal.NewFromFloat(0.05)}},
+	}
+	seen := map[string]int{}
+	for _, key := range []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12", "13", "14", "15", "16"} {
+		first := experiment.AssignVariant(key)
+		if again := experiment.AssignVariant(key); again.Name != first.Name {
+			t.Fatalf("Expected stable assignment for %s, got %s and %s", key, first.Name, again.Name)
+		}
+		seen[first.Name]++
+	}
+	if seen["control"] == 0 || seen["uplift"] == 0 {
+		t.Errorf("Expected both variants to be assigned, got %v", seen)
+	}
+}
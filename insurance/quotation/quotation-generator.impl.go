@@ -0,0 +1,92 @@
+package quotation
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+type quotationGeneratorInstance struct {
+	validator    QuotationValidator
+	eligibility  EligibilityEngine
+	underwriters []UnderwriterRate
+	experiments  []PricingExperiment
+	exposures    ExposureLogger
+}
+
+func NewQuotationGeneratorInstance(validator QuotationValidator, eligibility EligibilityEngine, underwriters []UnderwriterRate) (QuotationGenerator, error) {
+	if eligibility == nil {
+		eligibility = NewEligibilityEngineInstance()
+	}
+	return &quotationGeneratorInstance{
+		validator:    validator,
+		eligibility:  eligibility,
+		underwriters: underwriters,
+	}, nil
+}
+
+// NewQuotationGeneratorWithExperiments creates a quotation generator that prices quotes
+// under the given pricing experiments and reports every exposure to exposures.
+// A quote is priced by the first experiment that applies to its underwriter.
+func NewQuotationGeneratorWithExperiments(validator QuotationValidator, eligibility EligibilityEngine, underwriters []UnderwriterRate, experiments []PricingExperiment, exposures ExposureLogger) (QuotationGenerator, error) {
+	for _, exp := range experiments {
+		if err := validatePricingExperiment(exp); err != nil {
+			return nil, err
+		}
+	}
+	gen, err := NewQuotationGeneratorInstance(validator, eligibility, underwriters)
+	if err != nil {
+		return nil, err
+	}
+	qgen := gen.(*quotationGeneratorInstance)
+	qgen.experiments = experiments
+	qgen.exposures = exposures
+	return qgen, nil
+}
+
+// GenerateQuotation prices the request for every configured underwriter.
+// Underwriters whose eligibility rules reject the risk are returned in Excluded with the reasons.
+func (qgen *quotationGeneratorInstance) GenerateQuotation(ctx context.Context, req *QuotationRequest) (*QuotationResponse, error) {
+	if req == nil || req.Cover == nil || req.Risk == nil {
+		return nil, fmt.Errorf("cover and risk details are required")
+	}
+	if qgen.validator != nil {
+		valid, err := qgen.validator.ValidateQuotationRequest(ctx, req.Cover, req.Risk, req.Client)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quotation request %w", err)
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid quotation request")
+		}
+	}
+
+	resp := &QuotationResponse{}
+	for _, uw := range qgen.underwriters {
+		result := qgen.eligibility.Evaluate(uw.UnderwriterID, uw.Eligibility, req.Cover, req.Risk)
+		if !result.Eligible {
+			resp.Excluded = append(resp.Excluded, ExcludedUnderwriter{
+				UnderwriterID:   uw.UnderwriterID,
+				UnderwriterName: uw.UnderwriterName,
+				Reasons:         result.Reasons,
+			})
+			continue
+		}
+		quote := UnderwriterQuote{
+			UnderwriterID:   uw.UnderwriterID,
+			UnderwriterName: uw.UnderwriterName,
+			Premium:         calculatePremium(uw, req.Cover),
+		}
+		qgen.applyExperiment(ctx, &quote, uw, req)
+		resp.Quotes = append(resp.Quotes, quote)
+	}
+	return resp, nil
+}
+
+func calculatePremium(uw UnderwriterRate, cover *CoverDetails) decimal.Decimal {
+	premium := cover.SumInsured.Mul(uw.Rate).Round(2)
+	if premium.LessThan(uw.MinimumPremium) {
+		return uw.MinimumPremium
+	}
+	return premium
+}
@@ -6,10 +6,12 @@ import (
 	"time"
 
 	dmvic "github.com/nana-tec/gopackages/Dmvic"
+	"github.com/nana-tec/gopackages/insurance/risk"
 )
 
 type quotationValidatorInstance struct {
 	dmvicService dmvic.DmvicService
+	saccos       risk.SaccoRegistry
 }
 
 func NewQuotationValidatorInstance(DmvicService dmvic.DmvicService) (QuotationValidator, error) {
@@ -19,7 +21,22 @@ func NewQuotationValidatorInstance(DmvicService dmvic.DmvicService) (QuotationVa
 	}, nil
 }
 
-func (qval *quotationValidatorInstance) ValidateQuotationRequest(ctx context.Context, cover *CoverDetails, risk *RiskDetails, client *ClientDetails) (bool, error) {
+// NewQuotationValidatorWithSaccos creates a validator that also requires PSV matatu
+// risks to reference a sacco registered in saccos.
+func NewQuotationValidatorWithSaccos(DmvicService dmvic.DmvicService, saccos risk.SaccoRegistry) (QuotationValidator, error) {
+
+	return &quotationValidatorInstance{
+		dmvicService: DmvicService,
+		saccos:       saccos,
+	}, nil
+}
+
+func (qval *quotationValidatorInstance) ValidateQuotationRequest(ctx context.Context, cover *CoverDetails, riskDet *RiskDetails, client *ClientDetails) (bool, error) {
+	if qval.saccos != nil && riskDet != nil {
+		if _, err := qval.saccos.ValidateRiskSacco(ctx, riskDet.VehicleType, riskDet.SaccoCode); err != nil {
+			return false, err
+		}
+	}
 	return true, nil
 }
 
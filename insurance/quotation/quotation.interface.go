@@ -2,18 +2,26 @@ package quotation
 
 import (
 	"context"
+	"time"
 
 	dmvic "github.com/nana-tec/gopackages/Dmvic"
+	"github.com/nana-tec/gopackages/insurance/risk"
+	"github.com/shopspring/decimal"
 )
 
 type CoverDetails struct {
-	StartDate string
-	Period    int
+	StartDate  string
+	Period     int
+	SumInsured decimal.Decimal
 }
 
 type RiskDetails struct {
 	RegistrationNumber string
 	ChassisNumber      string
+	VehicleType        risk.VehicleType
+	YearOfManufacture  int
+	Region             string
+	SaccoCode          string // Registered sacco, required for PSV matatus
 	OtherDetails       map[string]any
 }
 
@@ -27,3 +35,105 @@ type QuotationValidator interface {
 	ValidateQuotationRequest(ctx context.Context, cover *CoverDetails, risk *RiskDetails, client *ClientDetails) (bool, error)
 	ValidateDmvicRiskRequest(ctx context.Context, cover *CoverDetails, risk *dmvic.RiskDetails) (dmvic.MotorCoverValidationResponse, error)
 }
+
+// UnderwriterEligibilityRule describes which risks an underwriter accepts.
+// Zero values mean the corresponding restriction is not applied.
+type UnderwriterEligibilityRule struct {
+	AcceptedVehicleTypes []risk.VehicleType // Vehicle types the underwriter covers, empty accepts all
+	MaxVehicleAge        int                // Maximum vehicle age in years
+	MinSumInsured        decimal.Decimal    // Minimum sum insured accepted
+	AllowedRegions       []string           // Regions the underwriter covers, empty accepts all
+	ExcludedRegions      []string           // Regions the underwriter never covers
+}
+
+// EligibilityResult is the outcome of evaluating an underwriter's rules against a risk.
+type EligibilityResult struct {
+	UnderwriterID string
+	Eligible      bool
+	Reasons       []string // Why the underwriter is not eligible
+}
+
+// UnderwriterRate holds the pricing and eligibility configuration of an underwriter.
+type UnderwriterRate struct {
+	UnderwriterID   string
+	UnderwriterName string
+	Rate            decimal.Decimal // Premium rate as a fraction of the sum insured, e.g. 0.04
+	MinimumPremium  decimal.Decimal
+	Eligibility     UnderwriterEligibilityRule
+}
+
+type QuotationRequest struct {
+	Cover  *CoverDetails
+	Risk   *RiskDetails
+	Client *ClientDetails
+}
+
+type UnderwriterQuote struct {
+	UnderwriterID   string
+	UnderwriterName string
+	Premium         decimal.Decimal
+	ExperimentID    string // Pricing experiment that priced the quote, empty for standard pricing
+	Variant         string // Variant of the experiment the quote was assigned to
+}
+
+// ExcludedUnderwriter is an underwriter left out of a quotation together with the reasons.
+type ExcludedUnderwriter struct {
+	UnderwriterID   string
+	UnderwriterName string
+	Reasons         []string
+}
+
+type QuotationResponse struct {
+	Quotes   []UnderwriterQuote
+	Excluded []ExcludedUnderwriter
+}
+
+// ExperimentUnit selects what a pricing experiment assigns variants by.
+type ExperimentUnit string
+
+const (
+	ExperimentByClient ExperimentUnit = "client" // Client ID or PIN number, a client always sees the same variant
+	ExperimentByRisk   ExperimentUnit = "risk"   // Registration or chassis number, a vehicle always sees the same variant
+)
+
+// PricingVariant is one arm of a pricing experiment.
+type PricingVariant struct {
+	Name           string
+	Weight         int             // Relative share of traffic assigned to the variant
+	RateAdjustment decimal.Decimal // Fractional change applied to the underwriter rate, e.g. 0.05 for +5%, zero for control
+}
+
+// PricingExperiment splits quotations between pricing variants by hashing the client or risk,
+// so the same client or vehicle is always priced with the same variant.
+type PricingExperiment struct {
+	ID             string
+	AssignBy       ExperimentUnit
+	UnderwriterIDs []string // Underwriters the experiment applies to, empty applies to all
+	Variants       []PricingVariant
+}
+
+// ExperimentExposure records that a quote was priced under an experiment variant,
+// so conversion can be measured downstream.
+type ExperimentExposure struct {
+	ExperimentID  string
+	Variant       string
+	UnderwriterID string
+	AssignmentKey string
+	BasePremium   decimal.Decimal // Premium under standard pricing
+	Premium       decimal.Decimal // Premium quoted under the variant
+	ExposedAt     time.Time
+}
+
+// ExposureLogger receives an exposure for every experiment-priced quote.
+// Implementations handle their own failures, quoting never waits on them.
+type ExposureLogger interface {
+	LogExposure(ctx context.Context, exposure ExperimentExposure)
+}
+
+type EligibilityEngine interface {
+	Evaluate(underwriterID string, rule UnderwriterEligibilityRule, cover *CoverDetails, risk *RiskDetails) EligibilityResult
+}
+
+type QuotationGenerator interface {
+	GenerateQuotation(ctx context.Context, req *QuotationRequest) (*QuotationResponse, error)
+}
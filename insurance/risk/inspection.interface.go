@@ -0,0 +1,141 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	dmvic "github.com/nana-tec/gopackages/Dmvic"
+)
+
+var (
+	// ErrInspectionNotFound is returned when an inspection reference does not match a recorded inspection.
+	ErrInspectionNotFound = errors.New("inspection not found")
+	// ErrInspectionRequired is returned when a cover cannot be issued before the risk passed inspection.
+	ErrInspectionRequired = errors.New("risk must pass inspection before issuance")
+	// ErrInspectionTransition is returned when an inspection outcome change is not allowed.
+	ErrInspectionTransition = errors.New("invalid inspection outcome transition")
+	// ErrInspectionIncomplete is returned when an inspection lacks the evidence required to pass it.
+	ErrInspectionIncomplete = errors.New("inspection is incomplete")
+)
+
+type InspectionOutcome string
+
+const (
+	InspectionPending  InspectionOutcome = "PENDING"
+	InspectionPassed   InspectionOutcome = "PASSED"
+	InspectionFailed   InspectionOutcome = "FAILED"
+	InspectionReferred InspectionOutcome = "REFERRED" // needs an underwriter's review before a final outcome
+)
+
+func (o InspectionOutcome) IsValid() bool {
+	switch o {
+	case InspectionPending, InspectionPassed, InspectionFailed, InspectionReferred:
+		return true
+	}
+	return false
+}
+
+func (o InspectionOutcome) String() string {
+	return string(o)
+}
+
+// IsFinal reports whether no further outcome changes are allowed
+func (o InspectionOutcome) IsFinal() bool {
+	return o == InspectionPassed || o == InspectionFailed
+}
+
+// inspectionTransitions lists the outcomes an inspection may move to from each outcome
+var inspectionTransitions = map[InspectionOutcome][]InspectionOutcome{
+	InspectionPending:  {InspectionPassed, InspectionFailed, InspectionReferred},
+	InspectionReferred: {InspectionPassed, InspectionFailed},
+}
+
+// CanTransitionTo reports whether an inspection with outcome o may move to next
+func (o InspectionOutcome) CanTransitionTo(next InspectionOutcome) bool {
+	for _, allowed := range inspectionTransitions[o] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// InspectionChecklistItem is a single item checked by the inspector, e.g. "Windscreen" or "Tyres"
+type InspectionChecklistItem struct {
+	Item   string `json:"item" bson:"item"`
+	Passed bool   `json:"passed" bson:"passed"`
+	Notes  string `json:"notes,omitempty" bson:"notes,omitempty"`
+}
+
+// InspectionPhoto references a photo held in object storage, the inspection only keeps the reference
+type InspectionPhoto struct {
+	Ref     string    `json:"ref" bson:"ref"`
+	Caption string    `json:"caption,omitempty" bson:"caption,omitempty"`
+	TakenAt time.Time `json:"taken_at,omitempty" bson:"taken_at,omitempty"`
+}
+
+type Inspector struct {
+	ID    string `json:"id" bson:"id"`
+	Name  string `json:"name" bson:"name"`
+	Phone string `json:"phone,omitempty" bson:"phone,omitempty"`
+}
+
+// GeoPoint is the location the inspection took place at
+type GeoPoint struct {
+	Latitude  float64 `json:"latitude" bson:"latitude"`
+	Longitude float64 `json:"longitude" bson:"longitude"`
+}
+
+func (p GeoPoint) IsValid() bool {
+	if p.Latitude == 0 && p.Longitude == 0 {
+		return false
+	}
+	return p.Latitude >= -90 && p.Latitude <= 90 && p.Longitude >= -180 && p.Longitude <= 180
+}
+
+// Inspection is a physical inspection of a motor risk, required before
+// comprehensive covers can be issued
+type Inspection struct {
+	InspectionRef string                    `json:"inspection_ref" bson:"inspection_ref"`
+	RiskSystemRef string                    `json:"risk_system_ref" bson:"risk_system_ref"`
+	Inspector     Inspector                 `json:"inspector" bson:"inspector"`
+	Checklist     []InspectionChecklistItem `json:"checklist" bson:"checklist"`
+	Photos        []InspectionPhoto         `json:"photos" bson:"photos"`
+	Location      GeoPoint                  `json:"location" bson:"location"`
+	InspectedAt   time.Time                 `json:"inspected_at" bson:"inspected_at"`
+	Outcome       InspectionOutcome         `json:"outcome" bson:"outcome"`
+	Remarks       string                    `json:"remarks,omitempty" bson:"remarks,omitempty"`
+	CreatedAt     time.Time                 `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time                 `json:"updated_at" bson:"updated_at"`
+}
+
+type InspectionRepository interface {
+
+	// GetInspectionByRef returns an Inspection by its reference
+	GetInspectionByRef(ctx context.Context, inspectionRef string) (*Inspection, error)
+
+	// ListInspectionsByRisk returns the inspections of a risk, latest first
+	ListInspectionsByRisk(ctx context.Context, riskSystemRef string) ([]Inspection, error)
+
+	// SaveInspection saves a new Inspection
+	SaveInspection(ctx context.Context, inspection *Inspection) error
+
+	// UpdateInspection updates an Inspection
+	UpdateInspection(ctx context.Context, inspection *Inspection) error
+}
+
+type InspectionUsecase interface {
+	// RecordInspection stores a new pending inspection for a risk and returns its reference
+	RecordInspection(ctx context.Context, inspection *Inspection) (string, error)
+	GetInspection(ctx context.Context, inspectionRef string) (*Inspection, error)
+	ListRiskInspections(ctx context.Context, riskSystemRef string) ([]Inspection, error)
+	// SetInspectionOutcome moves an inspection to a new outcome following the status rules
+	SetInspectionOutcome(ctx context.Context, inspectionRef string, outcome InspectionOutcome, remarks string) (*Inspection, error)
+	// IsRiskInspected reports whether the latest inspection of the risk passed
+	IsRiskInspected(ctx context.Context, riskSystemRef string) (bool, error)
+	// CheckIssuance fails with ErrInspectionRequired when the cover type needs a passed inspection the risk does not have
+	CheckIssuance(ctx context.Context, riskSystemRef string, coverType int) error
+	// PrepareConfirmation checks issuance and sets IsVehicleInspected on the DMVIC confirmation request
+	PrepareConfirmation(ctx context.Context, riskSystemRef string, coverType int, req *dmvic.ConfirmationRequest) error
+}
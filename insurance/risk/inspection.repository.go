@@ -0,0 +1,65 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// impliment inspection repository interface in mongo db
+
+type inspectionMongoRepository struct {
+	inspections *mongo.Collection
+}
+
+func NewInspectionMongoRepository(db *mongo.Database) *inspectionMongoRepository {
+	return &inspectionMongoRepository{
+		inspections: db.Collection("risk_inspections"),
+	}
+}
+
+func (repo *inspectionMongoRepository) GetInspectionByRef(ctx context.Context, inspectionRef string) (*Inspection, error) {
+	var inspection Inspection
+	err := repo.inspections.FindOne(ctx, bson.M{"inspection_ref": inspectionRef}).Decode(&inspection)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("%w: %s", ErrInspectionNotFound, inspectionRef)
+		}
+		return nil, err
+	}
+	return &inspection, nil
+}
+
+func (repo *inspectionMongoRepository) ListInspectionsByRisk(ctx context.Context, riskSystemRef string) ([]Inspection, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := repo.inspections.Find(ctx, bson.M{"risk_system_ref": riskSystemRef}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var inspections []Inspection
+	if err := cursor.All(ctx, &inspections); err != nil {
+		return nil, err
+	}
+	return inspections, nil
+}
+
+func (repo *inspectionMongoRepository) SaveInspection(ctx context.Context, inspection *Inspection) error {
+	_, err := repo.inspections.InsertOne(ctx, inspection)
+	return err
+}
+
+func (repo *inspectionMongoRepository) UpdateInspection(ctx context.Context, inspection *Inspection) error {
+	res, err := repo.inspections.ReplaceOne(ctx, bson.M{"inspection_ref": inspection.InspectionRef}, inspection)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", ErrInspectionNotFound, inspection.InspectionRef)
+	}
+	return nil
+}
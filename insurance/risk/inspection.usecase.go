@@ -0,0 +1,172 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	dmvic "github.com/nana-tec/gopackages/Dmvic"
+)
+
+type inspectionUsecase struct {
+	repo InspectionRepository
+	now  func() time.Time
+}
+
+func NewInspectionUsecase(repo InspectionRepository) *inspectionUsecase {
+	return &inspectionUsecase{
+		repo: repo,
+		now:  time.Now,
+	}
+}
+
+// RequiresInspection reports whether the DMVIC cover type can only be issued after a passed inspection
+func RequiresInspection(coverType int) bool {
+	return coverType == dmvic.CoverTypeComprehensive
+}
+
+// validateForPass checks the inspection carries the evidence needed to pass it
+func validateForPass(inspection *Inspection) error {
+	if strings.TrimSpace(inspection.Inspector.ID) == "" {
+		return fmt.Errorf("%w: inspector is required", ErrInspectionIncomplete)
+	}
+	if inspection.InspectedAt.IsZero() {
+		return fmt.Errorf("%w: inspection time is required", ErrInspectionIncomplete)
+	}
+	if !inspection.Location.IsValid() {
+		return fmt.Errorf("%w: a valid GPS location is required", ErrInspectionIncomplete)
+	}
+	if len(inspection.Photos) == 0 {
+		return fmt.Errorf("%w: at least one photo is required", ErrInspectionIncomplete)
+	}
+	if len(inspection.Checklist) == 0 {
+		return fmt.Errorf("%w: checklist is required", ErrInspectionIncomplete)
+	}
+	for _, item := range inspection.Checklist {
+		if !item.Passed {
+			return fmt.Errorf("%w: checklist item %q did not pass", ErrInspectionIncomplete, item.Item)
+		}
+	}
+	return nil
+}
+
+// applyOutcome moves the inspection to outcome, enforcing the transition rules
+func (uc *inspectionUsecase) applyOutcome(inspection *Inspection, outcome InspectionOutcome) error {
+	if !outcome.IsValid() {
+		return fmt.Errorf("invalid inspection outcome: %s", outcome)
+	}
+	if outcome == inspection.Outcome {
+		return nil
+	}
+	if !inspection.Outcome.CanTransitionTo(outcome) {
+		return fmt.Errorf("%w: %s to %s", ErrInspectionTransition, inspection.Outcome, outcome)
+	}
+	if outcome == InspectionPassed {
+		if err := validateForPass(inspection); err != nil {
+			return err
+		}
+	}
+	inspection.Outcome = outcome
+	return nil
+}
+
+func (uc *inspectionUsecase) RecordInspection(ctx context.Context, inspection *Inspection) (string, error) {
+	if strings.TrimSpace(inspection.RiskSystemRef) == "" {
+		return "", fmt.Errorf("risk system ref is required")
+	}
+	outcome := inspection.Outcome
+	if outcome == "" {
+		outcome = InspectionPending
+	}
+	inspection.InspectionRef = uuid.New().String()
+	inspection.Outcome = InspectionPending
+	if err := uc.applyOutcome(inspection, outcome); err != nil {
+		return "", err
+	}
+	inspection.CreatedAt = uc.now()
+	inspection.UpdatedAt = inspection.CreatedAt
+
+	if err := uc.repo.SaveInspection(ctx, inspection); err != nil {
+		return "", err
+	}
+	return inspection.InspectionRef, nil
+}
+
+func (uc *inspectionUsecase) GetInspection(ctx context.Context, inspectionRef string) (*Inspection, error) {
+	return uc.repo.GetInspectionByRef(ctx, inspectionRef)
+}
+
+func (uc *inspectionUsecase) ListRiskInspections(ctx context.Context, riskSystemRef string) ([]Inspection, error) {
+	return uc.repo.ListInspectionsByRisk(ctx, riskSystemRef)
+}
+
+func (uc *inspectionUsecase) SetInspectionOutcome(ctx context.Context, inspectionRef string, outcome InspectionOutcome, remarks string) (*Inspection, error) {
+	inspection, err := uc.repo.GetInspectionByRef(ctx, inspectionRef)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.applyOutcome(inspection, outcome); err != nil {
+		return nil, err
+	}
+	if remarks != "" {
+		inspection.Remarks = remarks
+	}
+	inspection.UpdatedAt = uc.now()
+
+	if err := uc.repo.UpdateInspection(ctx, inspection); err != nil {
+		return nil, err
+	}
+	return inspection, nil
+}
+
+func (uc *inspectionUsecase) IsRiskInspected(ctx context.Context, riskSystemRef string) (bool, error) {
+	inspections, err := uc.repo.ListInspectionsByRisk(ctx, riskSystemRef)
+	if err != nil {
+		return false, err
+	}
+	// only the latest inspection counts, a later failed inspection overrides an earlier pass
+	latest := latestInspection(inspections)
+	return latest != nil && latest.Outcome == InspectionPassed, nil
+}
+
+func (uc *inspectionUsecase) CheckIssuance(ctx context.Context, riskSystemRef string, coverType int) error {
+	if !RequiresInspection(coverType) {
+		return nil
+	}
+	inspected, err := uc.IsRiskInspected(ctx, riskSystemRef)
+	if err != nil {
+		return err
+	}
+	if !inspected {
+		return fmt.Errorf("%w: %s cover for risk %s", ErrInspectionRequired, dmvic.GetCoverTypeDescription(coverType), riskSystemRef)
+	}
+	return nil
+}
+
+func (uc *inspectionUsecase) PrepareConfirmation(ctx context.Context, riskSystemRef string, coverType int, req *dmvic.ConfirmationRequest) error {
+	if req == nil {
+		return fmt.Errorf("confirmation request is required")
+	}
+	inspected, err := uc.IsRiskInspected(ctx, riskSystemRef)
+	if err != nil {
+		return err
+	}
+	req.IsVehicleInspected = inspected
+	if !inspected && RequiresInspection(coverType) {
+		return fmt.Errorf("%w: %s cover for risk %s", ErrInspectionRequired, dmvic.GetCoverTypeDescription(coverType), riskSystemRef)
+	}
+	return nil
+}
+
+// latestInspection returns the most recently created inspection
+func latestInspection(inspections []Inspection) *Inspection {
+	var latest *Inspection
+	for i := range inspections {
+		if latest == nil || inspections[i].CreatedAt.After(latest.CreatedAt) {
+			latest = &inspections[i]
+		}
+	}
+	return latest
+}
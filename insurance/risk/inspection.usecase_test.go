@@ -0,0 +1,104 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	dmvic "github.com/nana-tec/gopackages/Dmvic"
+)
+
+type memInspectionRepo struct {
+	inspections map[string]Inspection
+}
+
+func (m *memInspectionRepo) GetInspectionByRef(ctx context.Context, ref string) (*Inspection, error) {
+	insp, ok := m.inspections[ref]
+	if !ok {
+		return nil, ErrInspectionNotFound
+	}
+	return &insp, nil
+}
+
+func (m *memInspectionRepo) ListInspectionsByRisk(ctx context.Context, riskRef string) ([]Inspection, error) {
+	var out []Inspection
+	for _, insp := range m.inspections {
+		if insp.RiskSystemRef == riskRef {
+			out = append(out, insp)
+		}
+	}
+	return out, nil
+}
+
+func (m *memInspectionRepo) SaveInspection(ctx context.Context, insp *Inspection) error {
+	m.inspections[insp.InspectionRef] = *insp
+	return nil
+}
+
+func (m *memInspectionRepo) UpdateInspection(ctx context.Context, insp *Inspection) error {
+	m.inspections[insp.InspectionRef] = *insp
+	return nil
+}
+
+func completeInspection(riskRef string) *Inspection {
+	return &Inspection{
+		RiskSystemRef: riskRef,
+		Inspector:     Inspector{ID: "INS-1", Name: "Jane Wanjiru"},
+		Checklist:     []InspectionChecklistItem{{Item: "Windscreen", Passed: true}, {Item: "Tyres", Passed: true}},
+		Photos:        []InspectionPhoto{{Ref: "inspections/front.jpg"}},
+		Location:      GeoPoint{Latitude: -1.2921, Longitude: 36.8219},
+		InspectedAt:   time.Now(),
+	}
+}
+
+func TestInspectionOutcomeRules(t *testing.T) {
+	ctx := context.Background()
+	uc := NewInspectionUsecase(&memInspectionRepo{inspections: map[string]Inspection{}})
+
+	ref, err := uc.RecordInspection(ctx, completeInspection("risk-1"))
+	if err != nil {
+		t.Fatalf("record inspection: %v", err)
+	}
+	if _, err := uc.SetInspectionOutcome(ctx, ref, InspectionReferred, "check chassis"); err != nil {
+		t.Fatalf("refer inspection: %v", err)
+	}
+	if _, err := uc.SetInspectionOutcome(ctx, ref, InspectionPassed, ""); err != nil {
+		t.Fatalf("pass referred inspection: %v", err)
+	}
+	if _, err := uc.SetInspectionOutcome(ctx, ref, InspectionFailed, ""); !errors.Is(err, ErrInspectionTransition) {
+		t.Fatalf("expected ErrInspectionTransition, got %v", err)
+	}
+
+	incomplete := completeInspection("risk-2")
+	incomplete.Photos = nil
+	incomplete.Outcome = InspectionPassed
+	if _, err := uc.RecordInspection(ctx, incomplete); !errors.Is(err, ErrInspectionIncomplete) {
+		t.Fatalf("expected ErrInspectionIncomplete, got %v", err)
+	}
+}
+
+func TestInspectionBlocksComprehensiveIssuance(t *testing.T) {
+	ctx := context.Background()
+	uc := NewInspectionUsecase(&memInspectionRepo{inspections: map[string]Inspection{}})
+
+	if err := uc.CheckIssuance(ctx, "risk-1", dmvic.CoverTypeThirdParty); err != nil {
+		t.Fatalf("third party cover should not need inspection: %v", err)
+	}
+	req := &dmvic.ConfirmationRequest{}
+	if err := uc.PrepareConfirmation(ctx, "risk-1", dmvic.CoverTypeComprehensive, req); !errors.Is(err, ErrInspectionRequired) {
+		t.Fatalf("expected ErrInspectionRequired, got %v", err)
+	}
+
+	insp := completeInspection("risk-1")
+	insp.Outcome = InspectionPassed
+	if _, err := uc.RecordInspection(ctx, insp); err != nil {
+		t.Fatalf("record inspection: %v", err)
+	}
+	if err := uc.PrepareConfirmation(ctx, "risk-1", dmvic.CoverTypeComprehensive, req); err != nil {
+		t.Fatalf("prepare confirmation: %v", err)
+	}
+	if !req.IsVehicleInspected {
+		t.Fatal("expected IsVehicleInspected to be set")
+	}
+}
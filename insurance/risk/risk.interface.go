@@ -2,7 +2,11 @@ package risk
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 type VehicleType string
@@ -172,7 +176,33 @@ type MotorRiskModel struct {
 	VehicleType        VehicleType `json:"vehicle_type" bson:"vehicle_type"`
 	BodyType           BodyType    `json:"body_type" bson:"body_type"`
 	NameOfSacco        string      `json:"name_of_sacco" bson:"name_of_sacco"`
+	SaccoCode          string      `json:"sacco_code,omitempty" bson:"sacco_code,omitempty"`
 	RiskSystemRef      string      `json:"risk_system_ref" bson:"risk_system_ref"`
+
+	// Valuation of the vehicle, see the insurance/valuation usecase
+	ValuationBookingNo string     `json:"valuation_booking_no,omitempty" bson:"valuation_booking_no,omitempty"`
+	ValuationStatus    string     `json:"valuation_status,omitempty" bson:"valuation_status,omitempty"`
+	MarketValue        string     `json:"market_value,omitempty" bson:"market_value,omitempty"` // decimal string
+	SumInsured         string     `json:"sum_insured,omitempty" bson:"sum_insured,omitempty"`   // decimal string
+	ValuedAt           *time.Time `json:"valued_at,omitempty" bson:"valued_at,omitempty"`
+}
+
+func (m *MotorRiskModel) GetMarketValue() decimal.Decimal {
+	d, _ := decimal.NewFromString(m.MarketValue)
+	return d
+}
+
+func (m *MotorRiskModel) SetMarketValue(d decimal.Decimal) {
+	m.MarketValue = d.String()
+}
+
+func (m *MotorRiskModel) GetSumInsured() decimal.Decimal {
+	d, _ := decimal.NewFromString(m.SumInsured)
+	return d
+}
+
+func (m *MotorRiskModel) SetSumInsured(d decimal.Decimal) {
+	m.SumInsured = d.String()
 }
 
 type MotorRisk struct {
@@ -187,8 +217,18 @@ type MotorRisk struct {
 	VehicleType        VehicleType
 	BodyType           BodyType
 	NameOfSacco        string
+	SaccoCode          string // Registry code of the sacco, takes precedence over NameOfSacco
 }
 
+var (
+	// ErrRiskNotFound is returned when a reference does not match a stored risk.
+	ErrRiskNotFound = errors.New("risk not found")
+	// ErrInvalidRisk is returned when a risk amendment fails validation.
+	ErrInvalidRisk = errors.New("invalid risk")
+	// ErrRiskFieldNotUpdatable is returned when an amendment changes a field UpdateRisk does not allow.
+	ErrRiskFieldNotUpdatable = errors.New("risk field cannot be updated")
+)
+
 type RiskRepository interface {
 
 	// GetMotorRisk returns a MotorRisk by registration number
@@ -221,6 +261,8 @@ type riskValidateDoubleInsuranceResponse struct {
 type RiskUsecase interface {
 	CreateUpdateRisk(ctx context.Context, motorRisk *MotorRisk) (string, error)
 	ValidateRiskDoubleInsurance(ctx context.Context, riskRef string, PolicyStartDate string, PolicyEndDate string) (riskValidateDoubleInsuranceResponse, error)
+	// GetRiskByRef returns a risk by risk system ref, registration number or chassis number
 	GetRiskByRef(ctx context.Context, riskRef string) (*MotorRiskModel, error)
+	// UpdateRisk amends the whitelisted vehicle details of the risk with motorRisk.RiskSystemRef
 	UpdateRisk(ctx context.Context, motorRisk *MotorRiskModel) error
 }
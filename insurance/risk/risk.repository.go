@@ -32,7 +32,7 @@ func (repo *riskMongoRepository) GetMotorRiskByRegistrationNumber(ctx context.Co
 	err := repo.risks.FindOne(ctx, bson.M{"registration_number": registrationNumber}).Decode(&rsk)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("risk not found: %s", registrationNumber)
+			return nil, fmt.Errorf("%w: %s", ErrRiskNotFound, registrationNumber)
 		}
 		return nil, err
 	}
@@ -44,7 +44,7 @@ func (repo *riskMongoRepository) GetMotorRiskByChassisNumber(ctx context.Context
 	err := repo.risks.FindOne(ctx, bson.M{"chassis_number": chassisNumber}).Decode(&rsk)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("risk not found: %s", chassisNumber)
+			return nil, fmt.Errorf("%w: %s", ErrRiskNotFound, chassisNumber)
 		}
 		return nil, err
 	}
@@ -56,7 +56,7 @@ func (repo *riskMongoRepository) GetMotorRiskByRiskSystemRef(ctx context.Context
 	err := repo.risks.FindOne(ctx, bson.M{"risk_system_ref": riskSystemRef}).Decode(&rsk)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("risk not found: %s", riskSystemRef)
+			return nil, fmt.Errorf("%w: %s", ErrRiskNotFound, riskSystemRef)
 		}
 		return nil, err
 	}
@@ -75,7 +75,7 @@ func (repo *riskMongoRepository) GetMotorRiskByRef(ctx context.Context, riskRef
 	err := repo.risks.FindOne(ctx, filter).Decode(&rsk)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("risk not found: %s", riskRef)
+			return nil, fmt.Errorf("%w: %s", ErrRiskNotFound, riskRef)
 		}
 		return nil, err
 	}
@@ -93,6 +93,9 @@ func (repo *riskMongoRepository) GetMotorRiskByRegistrationNumberOrChassis(ctx c
 	}
 	err := repo.risks.FindOne(ctx, filter).Decode(&rsk)
 	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("%w: %s/%s", ErrRiskNotFound, registrationNumber, chassisNumber)
+		}
 		return nil, err
 	}
 	return &rsk, nil
@@ -109,10 +112,13 @@ func (repo *riskMongoRepository) SaveMotorRisk(ctx context.Context, motorRisk *M
 }
 func (repo *riskMongoRepository) UpdateMotorRisk(ctx context.Context, motorRisk *MotorRiskModel) error {
 
-	_, err := repo.risks.UpdateOne(ctx, bson.M{"risk_system_ref": motorRisk.RiskSystemRef}, bson.M{"$set": motorRisk})
+	res, err := repo.risks.UpdateOne(ctx, bson.M{"risk_system_ref": motorRisk.RiskSystemRef}, bson.M{"$set": motorRisk})
 	if err != nil {
 		return err
 	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", ErrRiskNotFound, motorRisk.RiskSystemRef)
+	}
 
 	return nil
 }
@@ -9,6 +9,13 @@ import (
 func NewRiskService(db *mongo.Database, dmvic dmvic.Client, logger *ntlogger.Logger) (*riskUsecase, error) {
 
 	repo := NewRiskMongoRepository(db, logger)
-	riskUsecase := NewRiskUsecase(repo, dmvic, logger)
+	saccos := NewSaccoRegistry(NewSaccoMongoRepository(db))
+	riskUsecase := NewRiskUsecase(repo, saccos, dmvic, logger)
 	return riskUsecase, nil
 }
+
+func NewInspectionService(db *mongo.Database) (*inspectionUsecase, error) {
+
+	repo := NewInspectionMongoRepository(db)
+	return NewInspectionUsecase(repo), nil
+}
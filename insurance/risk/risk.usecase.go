@@ -2,23 +2,28 @@ package risk
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	dmvic "github.com/nana-tec/gopackages/Dmvic"
 	ntlogger "github.com/nana-tec/gopackages/logger"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type riskUsecase struct {
 	repo   RiskRepository
+	saccos SaccoRegistry
 	dmvic  dmvic.Client
 	logger *ntlogger.Logger
 }
 
-func NewRiskUsecase(repo RiskRepository, dmvic dmvic.Client, logger *ntlogger.Logger) *riskUsecase {
+func NewRiskUsecase(repo RiskRepository, saccos SaccoRegistry, dmvic dmvic.Client, logger *ntlogger.Logger) *riskUsecase {
 	return &riskUsecase{
 		repo:   repo,
+		saccos: saccos,
 		dmvic:  dmvic,
 		logger: logger,
 	}
@@ -40,14 +45,36 @@ func (uc *riskUsecase) motorRiskModelFromRisk(risk *MotorRisk) *MotorRiskModel {
 		VehicleType:        risk.VehicleType,
 		BodyType:           risk.BodyType,
 		NameOfSacco:        risk.NameOfSacco,
+		SaccoCode:          risk.SaccoCode,
 	}
 }
 
+// resolveSacco replaces the free-text sacco name with the registered sacco,
+// failing for PSV matatus that do not reference one
+func (uc *riskUsecase) resolveSacco(ctx context.Context, rsk *MotorRiskModel) error {
+	saccoRef := rsk.SaccoCode
+	if saccoRef == "" {
+		saccoRef = rsk.NameOfSacco
+	}
+	sacco, err := uc.saccos.ValidateRiskSacco(ctx, rsk.VehicleType, saccoRef)
+	if err != nil {
+		return err
+	}
+	if sacco != nil {
+		rsk.SaccoCode = sacco.Code
+		rsk.NameOfSacco = sacco.Name
+	}
+	return nil
+}
+
 func (uc *riskUsecase) CreateUpdateRisk(ctx context.Context, motorRisk *MotorRisk) (string, error) {
 	var rsk = uc.motorRiskModelFromRisk(motorRisk)
-	_, err := uc.repo.GetMotorRiskByRegistrationNumberOrChassis(ctx, motorRisk.RegistrationNumber, motorRisk.ChassisNumber)
+	if err := uc.resolveSacco(ctx, rsk); err != nil {
+		return "", err
+	}
+	existing, err := uc.repo.GetMotorRiskByRegistrationNumberOrChassis(ctx, motorRisk.RegistrationNumber, motorRisk.ChassisNumber)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
+		if errors.Is(err, ErrRiskNotFound) {
 			// create new risk
 
 			err = uc.repo.SaveMotorRisk(ctx, rsk)
@@ -59,13 +86,14 @@ func (uc *riskUsecase) CreateUpdateRisk(ctx context.Context, motorRisk *MotorRis
 		return "", err
 	}
 
-	// update risk
+	// update risk, keeping its reference
+	rsk.RiskSystemRef = existing.RiskSystemRef
 	err = uc.repo.UpdateMotorRisk(ctx, rsk)
 	if err != nil {
 		return "", err
 	}
 
-	return "", nil
+	return rsk.RiskSystemRef, nil
 }
 
 func (uc *riskUsecase) ValidateRiskDoubleInsurance(ctx context.Context, riskRef string, PolicyStartDate string, PolicyEndDate string) (riskValidateDoubleInsuranceResponse, error) {
@@ -100,15 +128,136 @@ func (uc *riskUsecase) ValidateRiskDoubleInsurance(ctx context.Context, riskRef
 	return riskValidateDoubleInsuranceResponse{}, nil
 }
 
+// GetRiskByRef looks the risk up by risk system ref first, then by registration or
+// chassis number, failing with ErrRiskNotFound when neither matches
 func (uc *riskUsecase) GetRiskByRef(ctx context.Context, riskRef string) (*MotorRiskModel, error) {
+	riskRef = strings.TrimSpace(riskRef)
+	if riskRef == "" {
+		return nil, fmt.Errorf("%w: risk reference is required", ErrInvalidRisk)
+	}
+	rsk, err := uc.repo.GetMotorRiskByRiskSystemRef(ctx, riskRef)
+	if err == nil || !errors.Is(err, ErrRiskNotFound) {
+		return rsk, err
+	}
+	return uc.repo.GetMotorRiskByRef(ctx, riskRef)
+}
 
-	//return uc.repo.GetMotorRiskByRiskSystemRef(ctx, riskRef)
+// UpdateRisk amends the risk with motorRisk.RiskSystemRef. Only the vehicle details may
+// change: make, model, seating capacity, tonnage, year of manufacture, cubic capacity,
+// vehicle and body type and the sacco; zero values keep the stored value. The registration
+// and chassis numbers identify the vehicle to DMVIC and the valuation fields belong to the
+// valuation usecase, so changing them fails with ErrRiskFieldNotUpdatable. On success
+// motorRisk holds the stored risk.
+func (uc *riskUsecase) UpdateRisk(ctx context.Context, motorRisk *MotorRiskModel) error {
+	if motorRisk == nil || strings.TrimSpace(motorRisk.RiskSystemRef) == "" {
+		return fmt.Errorf("%w: risk system ref is required", ErrInvalidRisk)
+	}
+	stored, err := uc.repo.GetMotorRiskByRiskSystemRef(ctx, motorRisk.RiskSystemRef)
+	if err != nil {
+		return err
+	}
+	if err := checkRiskNotUpdatable(stored, motorRisk); err != nil {
+		return err
+	}
 
-	return nil, nil
+	amended := *stored
+	amendRisk(&amended, motorRisk)
+	if err := validateRiskAmendment(stored, &amended); err != nil {
+		return err
+	}
+	if amended.VehicleType != stored.VehicleType || amended.NameOfSacco != stored.NameOfSacco || amended.SaccoCode != stored.SaccoCode {
+		if err := uc.resolveSacco(ctx, &amended); err != nil {
+			return err
+		}
+	}
+	if err := uc.repo.UpdateMotorRisk(ctx, &amended); err != nil {
+		return err
+	}
+	*motorRisk = amended
+	return nil
 }
 
-func (uc *riskUsecase) UpdateRisk(ctx context.Context, motorRisk *MotorRiskModel) error {
+// checkRiskNotUpdatable fails when the amendment sets a field outside the whitelist of
+// UpdateRisk to another value than the stored one
+func checkRiskNotUpdatable(stored, amendment *MotorRiskModel) error {
+	fields := []struct {
+		name            string
+		stored, amended string
+	}{
+		{"registration_number", stored.RegistrationNumber, amendment.RegistrationNumber},
+		{"chassis_number", stored.ChassisNumber, amendment.ChassisNumber},
+		{"valuation_booking_no", stored.ValuationBookingNo, amendment.ValuationBookingNo},
+		{"valuation_status", stored.ValuationStatus, amendment.ValuationStatus},
+		{"market_value", stored.MarketValue, amendment.MarketValue},
+		{"sum_insured", stored.SumInsured, amendment.SumInsured},
+	}
+	for _, f := range fields {
+		if f.amended != "" && f.amended != f.stored {
+			return fmt.Errorf("%w: %s", ErrRiskFieldNotUpdatable, f.name)
+		}
+	}
+	if amendment.ValuedAt != nil && (stored.ValuedAt == nil || !amendment.ValuedAt.Equal(*stored.ValuedAt)) {
+		return fmt.Errorf("%w: valued_at", ErrRiskFieldNotUpdatable)
+	}
+	return nil
+}
 
-	//return uc.repo.GetMotorRiskByChassisNumber(ctx, chassisNumber)
+// amendRisk copies the whitelisted fields set on the amendment to rsk
+func amendRisk(rsk, amendment *MotorRiskModel) {
+	if amendment.CarMake != "" {
+		rsk.CarMake = amendment.CarMake
+	}
+	if amendment.CarModel != "" {
+		rsk.CarModel = amendment.CarModel
+	}
+	if amendment.SeatingCapacity != 0 {
+		rsk.SeatingCapacity = amendment.SeatingCapacity
+	}
+	if amendment.Tonnage != 0 {
+		rsk.Tonnage = amendment.Tonnage
+	}
+	if amendment.YearOfManufacture != "" {
+		rsk.YearOfManufacture = amendment.YearOfManufacture
+	}
+	if amendment.CubicCapacity != "" {
+		rsk.CubicCapacity = amendment.CubicCapacity
+	}
+	if amendment.VehicleType != "" {
+		rsk.VehicleType = amendment.VehicleType
+	}
+	if amendment.BodyType != "" {
+		rsk.BodyType = amendment.BodyType
+	}
+	switch {
+	case amendment.SaccoCode != "":
+		rsk.SaccoCode, rsk.NameOfSacco = amendment.SaccoCode, amendment.NameOfSacco
+	case amendment.NameOfSacco != "" && amendment.NameOfSacco != rsk.NameOfSacco:
+		// a new sacco named without its code is resolved by name
+		rsk.SaccoCode, rsk.NameOfSacco = "", amendment.NameOfSacco
+	}
+}
+
+// validateRiskAmendment checks the fields the amendment changed
+func validateRiskAmendment(stored, amended *MotorRiskModel) error {
+	if amended.SeatingCapacity < 0 {
+		return fmt.Errorf("%w: seating capacity must not be negative", ErrInvalidRisk)
+	}
+	if amended.Tonnage < 0 {
+		return fmt.Errorf("%w: tonnage must not be negative", ErrInvalidRisk)
+	}
+	if amended.YearOfManufacture != stored.YearOfManufacture {
+		year, err := strconv.Atoi(amended.YearOfManufacture)
+		if err != nil || year < 1900 || year > time.Now().Year()+1 {
+			return fmt.Errorf("%w: year of manufacture %q", ErrInvalidRisk, amended.YearOfManufacture)
+		}
+	}
+	if amended.VehicleType != stored.VehicleType || amended.BodyType != stored.BodyType {
+		if !amended.VehicleType.IsValid() {
+			return fmt.Errorf("%w: vehicle type %q", ErrInvalidRisk, amended.VehicleType)
+		}
+		if _, err := ValidateBodyTypeAgainstVehicleType(VehicleTypeMap[amended.VehicleType], amended.BodyType.String()); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidRisk, err)
+		}
+	}
 	return nil
 }
@@ -0,0 +1,195 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type memRiskRepo struct {
+	RiskRepository
+	risks map[string]MotorRiskModel
+}
+
+func (m *memRiskRepo) GetMotorRiskByRiskSystemRef(ctx context.Context, ref string) (*MotorRiskModel, error) {
+	rsk, ok := m.risks[ref]
+	if !ok {
+		return nil, ErrRiskNotFound
+	}
+	return &rsk, nil
+}
+
+func (m *memRiskRepo) GetMotorRiskByRef(ctx context.Context, ref string) (*MotorRiskModel, error) {
+	for _, rsk := range m.risks {
+		if rsk.RegistrationNumber == ref || rsk.ChassisNumber == ref {
+			return &rsk, nil
+		}
+	}
+	return nil, ErrRiskNotFound
+}
+
+func (m *memRiskRepo) GetMotorRiskByRegistrationNumberOrChassis(ctx context.Context, registrationNumber, chassisNumber string) (*MotorRiskModel, error) {
+	for _, rsk := range m.risks {
+		if rsk.RegistrationNumber == registrationNumber || rsk.ChassisNumber == chassisNumber {
+			return &rsk, nil
+		}
+	}
+	return nil, ErrRiskNotFound
+}
+
+func (m *memRiskRepo) SaveMotorRisk(ctx context.Context, rsk *MotorRiskModel) error {
+	m.risks[rsk.RiskSystemRef] = *rsk
+	return nil
+}
+
+func (m *memRiskRepo) UpdateMotorRisk(ctx context.Context, rsk *MotorRiskModel) error {
+	if _, ok := m.risks[rsk.RiskSystemRef]; !ok {
+		return ErrRiskNotFound
+	}
+	m.risks[rsk.RiskSystemRef] = *rsk
+	return nil
+}
+
+type memSaccoRepo struct {
+	saccos []Sacco
+}
+
+func (m *memSaccoRepo) GetSaccoByCode(ctx context.Context, code string) (*Sacco, error) {
+	for _, s := range m.saccos {
+		if s.Code == code {
+			return &s, nil
+		}
+	}
+	return nil, ErrSaccoNotFound
+}
+
+func (m *memSaccoRepo) ListSaccos(ctx context.Context) ([]Sacco, error) {
+	return m.saccos, nil
+}
+
+func (m *memSaccoRepo) SaveSacco(ctx context.Context, sacco *Sacco) error {
+	m.saccos = append(m.saccos, *sacco)
+	return nil
+}
+
+func newTestRiskUsecase() *riskUsecase {
+	repo := &memRiskRepo{risks: map[string]MotorRiskModel{
+		"risk-1": {
+			RiskSystemRef:      "risk-1",
+			RegistrationNumber: "KDA123A",
+			ChassisNumber:      "CH-001",
+			CarMake:            "Toyota",
+			CarModel:           "Probox",
+			YearOfManufacture:  "2015",
+			VehicleType:        Private,
+			BodyType:           StationWagon,
+			MarketValue:        "850000",
+		},
+	}}
+	saccos := NewSaccoRegistry(&memSaccoRepo{saccos: []Sacco{{Code: "SM01", Name: "Super Metro"}}})
+	return NewRiskUsecase(repo, saccos, nil, nil)
+}
+
+func TestGetRiskByRef(t *testing.T) {
+	ctx := context.Background()
+	uc := newTestRiskUsecase()
+
+	for _, ref := range []string{"risk-1", "KDA123A", " CH-001 "} {
+		rsk, err := uc.GetRiskByRef(ctx, ref)
+		if err != nil || rsk.RiskSystemRef != "risk-1" {
+			t.Errorf("lookup by %q: %v %v", ref, rsk, err)
+		}
+	}
+	if _, err := uc.GetRiskByRef(ctx, "KBZ999Z"); !errors.Is(err, ErrRiskNotFound) {
+		t.Errorf("expected ErrRiskNotFound, got %v", err)
+	}
+	if _, err := uc.GetRiskByRef(ctx, " "); !errors.Is(err, ErrInvalidRisk) {
+		t.Errorf("expected ErrInvalidRisk for an empty ref, got %v", err)
+	}
+}
+
+func TestUpdateRisk(t *testing.T) {
+	ctx := context.Background()
+	uc := newTestRiskUsecase()
+
+	// a risk read back and amended keeps its protected fields
+	rsk, _ := uc.GetRiskByRef(ctx, "risk-1")
+	rsk.CarModel = "Fielder"
+	rsk.BodyType = Saloon
+	if err := uc.UpdateRisk(ctx, rsk); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	stored, _ := uc.GetRiskByRef(ctx, "risk-1")
+	if stored.CarModel != "Fielder" || stored.BodyType != Saloon || stored.CarMake != "Toyota" || stored.MarketValue != "850000" {
+		t.Errorf("unexpected stored risk %+v", stored)
+	}
+
+	// partial amendment
+	if err := uc.UpdateRisk(ctx, &MotorRiskModel{RiskSystemRef: "risk-1", SeatingCapacity: 5}); err != nil {
+		t.Fatalf("partial update: %v", err)
+	}
+	if stored, _ = uc.GetRiskByRef(ctx, "risk-1"); stored.SeatingCapacity != 5 || stored.CarModel != "Fielder" {
+		t.Errorf("partial update: unexpected stored risk %+v", stored)
+	}
+
+	cases := []struct {
+		name      string
+		amendment MotorRiskModel
+		want      error
+	}{
+		{"registration number", MotorRiskModel{RiskSystemRef: "risk-1", RegistrationNumber: "KDB456B"}, ErrRiskFieldNotUpdatable},
+		{"market value", MotorRiskModel{RiskSystemRef: "risk-1", MarketValue: "1"}, ErrRiskFieldNotUpdatable},
+		{"body type", MotorRiskModel{RiskSystemRef: "risk-1", BodyType: Truck}, ErrInvalidRisk},
+		{"vehicle type", MotorRiskModel{RiskSystemRef: "risk-1", VehicleType: "HOVERCRAFT"}, ErrInvalidRisk},
+		{"year", MotorRiskModel{RiskSystemRef: "risk-1", YearOfManufacture: "15"}, ErrInvalidRisk},
+		{"tonnage", MotorRiskModel{RiskSystemRef: "risk-1", Tonnage: -1}, ErrInvalidRisk},
+		{"matatu without sacco", MotorRiskModel{RiskSystemRef: "risk-1", VehicleType: PSVMatatu, BodyType: StationWagon}, ErrSaccoRequired},
+		{"unknown risk", MotorRiskModel{RiskSystemRef: "risk-2", CarModel: "Vitz"}, ErrRiskNotFound},
+		{"no ref", MotorRiskModel{CarModel: "Vitz"}, ErrInvalidRisk},
+	}
+	for _, c := range cases {
+		amendment := c.amendment
+		if err := uc.UpdateRisk(ctx, &amendment); !errors.Is(err, c.want) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.want, err)
+		}
+	}
+
+	// moving to a matatu resolves the sacco by name
+	amendment := &MotorRiskModel{RiskSystemRef: "risk-1", VehicleType: PSVMatatu, BodyType: StationWagon, NameOfSacco: "super metro sacco"}
+	if err := uc.UpdateRisk(ctx, amendment); err != nil {
+		t.Fatalf("matatu update: %v", err)
+	}
+	if amendment.SaccoCode != "SM01" || amendment.NameOfSacco != "Super Metro" || amendment.RegistrationNumber != "KDA123A" {
+		t.Errorf("matatu update: unexpected risk %+v", amendment)
+	}
+}
+
+func TestCreateUpdateRisk_CreatesUnknownRisk(t *testing.T) {
+	ctx := context.Background()
+	uc := newTestRiskUsecase()
+
+	ref, err := uc.CreateUpdateRisk(ctx, &MotorRisk{RegistrationNumber: "KCX777X", ChassisNumber: "CH-777", VehicleType: Private, BodyType: Saloon})
+	if err != nil || ref == "" {
+		t.Fatalf("create: %q %v", ref, err)
+	}
+	if rsk, err := uc.GetRiskByRef(ctx, "KCX777X"); err != nil || rsk.RiskSystemRef != ref {
+		t.Errorf("created risk not found by registration number: %v %v", rsk, err)
+	}
+}
+
+func TestCreateUpdateRisk_UpdatesExistingRisk(t *testing.T) {
+	ctx := context.Background()
+	uc := newTestRiskUsecase()
+
+	ref, err := uc.CreateUpdateRisk(ctx, &MotorRisk{RegistrationNumber: "KDA123A", ChassisNumber: "CH-001", CarMake: "Toyota", CarModel: "Fielder", VehicleType: Private, BodyType: StationWagon})
+	if err != nil || ref != "risk-1" {
+		t.Fatalf("expected the existing ref, got %q %v", ref, err)
+	}
+	stored, err := uc.GetRiskByRef(ctx, "risk-1")
+	if err != nil || stored.CarModel != "Fielder" {
+		t.Errorf("existing risk not updated: %v %v", stored, err)
+	}
+	if len(uc.repo.(*memRiskRepo).risks) != 1 {
+		t.Errorf("expected no new risk, got %v", uc.repo.(*memRiskRepo).risks)
+	}
+}
@@ -0,0 +1,48 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrSaccoNotFound is returned when a sacco reference does not match a registered sacco.
+	ErrSaccoNotFound = errors.New("sacco not found")
+	// ErrSaccoAmbiguous is returned when a sacco name matches more than one registered sacco.
+	ErrSaccoAmbiguous = errors.New("sacco name is ambiguous")
+	// ErrSaccoRequired is returned when a PSV matatu risk does not reference a sacco.
+	ErrSaccoRequired = errors.New("PSV matatu risks must reference a registered sacco")
+)
+
+// Sacco is a PSV savings and credit co-operative that matatus must belong to.
+type Sacco struct {
+	Code         string    `json:"code" bson:"code"`
+	Name         string    `json:"name" bson:"name"`
+	ContactName  string    `json:"contact_name" bson:"contact_name"`
+	ContactPhone string    `json:"contact_phone" bson:"contact_phone"`
+	ContactEmail string    `json:"contact_email" bson:"contact_email"`
+	CreatedAt    time.Time `json:"created_at" bson:"created_at"`
+}
+
+type SaccoRepository interface {
+
+	// GetSaccoByCode returns a Sacco by its registry code
+	GetSaccoByCode(ctx context.Context, code string) (*Sacco, error)
+
+	// ListSaccos returns all registered saccos
+	ListSaccos(ctx context.Context) ([]Sacco, error)
+
+	// SaveSacco inserts or replaces a Sacco by code
+	SaveSacco(ctx context.Context, sacco *Sacco) error
+}
+
+type SaccoRegistry interface {
+	RegisterSacco(ctx context.Context, sacco *Sacco) error
+	GetSacco(ctx context.Context, code string) (*Sacco, error)
+	// LookupSacco resolves a sacco code or a possibly misspelt sacco name
+	LookupSacco(ctx context.Context, nameOrCode string) (*Sacco, error)
+	// ValidateRiskSacco checks that a risk of the given vehicle type references a registered sacco
+	// when one is required, returning the resolved sacco if any
+	ValidateRiskSacco(ctx context.Context, vehicleType VehicleType, saccoRef string) (*Sacco, error)
+}
@@ -0,0 +1,53 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// impliment sacco repository interface in mongo db
+
+type saccoMongoRepository struct {
+	saccos *mongo.Collection
+}
+
+func NewSaccoMongoRepository(db *mongo.Database) *saccoMongoRepository {
+	return &saccoMongoRepository{
+		saccos: db.Collection("saccos"),
+	}
+}
+
+func (repo *saccoMongoRepository) GetSaccoByCode(ctx context.Context, code string) (*Sacco, error) {
+	var sacco Sacco
+	err := repo.saccos.FindOne(ctx, bson.M{"code": code}).Decode(&sacco)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("%w: %s", ErrSaccoNotFound, code)
+		}
+		return nil, err
+	}
+	return &sacco, nil
+}
+
+func (repo *saccoMongoRepository) ListSaccos(ctx context.Context) ([]Sacco, error) {
+	cursor, err := repo.saccos.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"name": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var saccos []Sacco
+	if err := cursor.All(ctx, &saccos); err != nil {
+		return nil, err
+	}
+	return saccos, nil
+}
+
+func (repo *saccoMongoRepository) SaveSacco(ctx context.Context, sacco *Sacco) error {
+	_, err := repo.saccos.ReplaceOne(ctx, bson.M{"code": sacco.Code}, sacco, options.Replace().SetUpsert(true))
+	return err
+}
@@ -0,0 +1,154 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// saccoNameNoise are words commonly added to or left out of sacco names
+var saccoNameNoise = map[string]bool{
+	"SACCO": true, "SACCOS": true, "SOCIETY": true, "LTD": true, "LIMITED": true,
+	"CO": true, "OPERATIVE": true, "COOPERATIVE": true, "THE": true,
+}
+
+type saccoRegistry struct {
+	repo SaccoRepository
+	now  func() time.Time
+}
+
+func NewSaccoRegistry(repo SaccoRepository) *saccoRegistry {
+	return &saccoRegistry{
+		repo: repo,
+		now:  time.Now,
+	}
+}
+
+func (reg *saccoRegistry) RegisterSacco(ctx context.Context, sacco *Sacco) error {
+	sacco.Code = strings.ToUpper(strings.TrimSpace(sacco.Code))
+	sacco.Name = strings.TrimSpace(sacco.Name)
+	if sacco.Code == "" {
+		return fmt.Errorf("sacco code is required")
+	}
+	if sacco.Name == "" {
+		return fmt.Errorf("sacco name is required")
+	}
+	if sacco.CreatedAt.IsZero() {
+		sacco.CreatedAt = reg.now()
+	}
+	return reg.repo.SaveSacco(ctx, sacco)
+}
+
+func (reg *saccoRegistry) GetSacco(ctx context.Context, code string) (*Sacco, error) {
+	return reg.repo.GetSaccoByCode(ctx, strings.ToUpper(strings.TrimSpace(code)))
+}
+
+// LookupSacco matches nameOrCode against the registry: first by code, then by
+// normalized name and finally by the closest name within a small edit distance,
+// so "Super Metro Sacco Ltd" and "supermetro" resolve to the same sacco.
+func (reg *saccoRegistry) LookupSacco(ctx context.Context, nameOrCode string) (*Sacco, error) {
+	ref := strings.TrimSpace(nameOrCode)
+	if ref == "" {
+		return nil, ErrSaccoNotFound
+	}
+	saccos, err := reg.repo.ListSaccos(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	code := strings.ToUpper(ref)
+	for i := range saccos {
+		if saccos[i].Code == code {
+			return &saccos[i], nil
+		}
+	}
+
+	key := normalizeSaccoName(ref)
+	if key == "" {
+		return nil, fmt.Errorf("%w: %s", ErrSaccoNotFound, ref)
+	}
+	best, bestDistance, ties := -1, -1, 0
+	for i := range saccos {
+		d := levenshtein(key, normalizeSaccoName(saccos[i].Name))
+		switch {
+		case bestDistance < 0 || d < bestDistance:
+			best, bestDistance, ties = i, d, 0
+		case d == bestDistance:
+			ties++
+		}
+	}
+	if best < 0 || bestDistance > maxSaccoNameDistance(key) {
+		return nil, fmt.Errorf("%w: %s", ErrSaccoNotFound, ref)
+	}
+	if ties > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrSaccoAmbiguous, ref)
+	}
+	return &saccos[best], nil
+}
+
+func (reg *saccoRegistry) ValidateRiskSacco(ctx context.Context, vehicleType VehicleType, saccoRef string) (*Sacco, error) {
+	required := vehicleType == PSVMatatu
+	if strings.TrimSpace(saccoRef) == "" {
+		if required {
+			return nil, ErrSaccoRequired
+		}
+		return nil, nil
+	}
+	sacco, err := reg.LookupSacco(ctx, saccoRef)
+	if err != nil {
+		if required {
+			return nil, fmt.Errorf("%w: %w", ErrSaccoRequired, err)
+		}
+		if errors.Is(err, ErrSaccoNotFound) || errors.Is(err, ErrSaccoAmbiguous) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return sacco, nil
+}
+
+// normalizeSaccoName upper-cases the name and drops punctuation and noise words
+func normalizeSaccoName(name string) string {
+	words := strings.FieldsFunc(strings.ToUpper(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, w := range words {
+		if !saccoNameNoise[w] {
+			b.WriteString(w)
+		}
+	}
+	return b.String()
+}
+
+// maxSaccoNameDistance allows roughly one typo per five characters
+func maxSaccoNameDistance(key string) int {
+	if d := len(key) / 5; d > 1 {
+		return d
+	}
+	return 1
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,45 @@
+package valuation
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	linkvaluer "github.com/nana-tec/gopackages/LinkValuer"
+	"github.com/nana-tec/gopackages/insurance/risk"
+)
+
+var (
+	// ErrNoValuation is returned when the risk has no valuation booked
+	ErrNoValuation = errors.New("risk has no valuation booked")
+	// ErrBookingMismatch is returned when a callback does not match the booking stored against the risk
+	ErrBookingMismatch = errors.New("valuation booking does not match the risk")
+	// ErrValuationCancelled is returned when the valuation was cancelled before completing
+	ErrValuationCancelled = errors.New("valuation was cancelled")
+	// ErrNoMarketValue is returned when a completed valuation carries no market value
+	ErrNoMarketValue = errors.New("valuation completed without a market value")
+)
+
+// Customer is the vehicle owner the valuer contacts to book the assessment
+type Customer struct {
+	Name         string
+	Phone        string
+	Email        string
+	PolicyNumber string
+}
+
+type ValuationUsecase interface {
+	// RequestValuation books a LinkValuer valuation for the risk and stores the booking
+	// number against it. A risk with a valuation already in progress or completed keeps
+	// its booking; a cancelled one is booked again.
+	RequestValuation(ctx context.Context, rsk *risk.MotorRiskModel, customer Customer) (string, error)
+
+	// HandleCallback applies a LinkValuer callback to the risk it was booked for and
+	// returns the updated risk. Completed valuations set the market value and sum insured.
+	HandleCallback(ctx context.Context, cb *linkvaluer.CallbackResponse) (*risk.MotorRiskModel, error)
+
+	// AwaitValuation polls LinkValuer every interval until the valuation of the risk
+	// completes, for deployments without a public callback URL, then updates the risk
+	// like HandleCallback.
+	AwaitValuation(ctx context.Context, riskSystemRef string, interval time.Duration) (*risk.MotorRiskModel, error)
+}
@@ -0,0 +1,14 @@
+package valuation
+
+import (
+	linkvaluer "github.com/nana-tec/gopackages/LinkValuer"
+	"github.com/nana-tec/gopackages/insurance/risk"
+	ntlogger "github.com/nana-tec/gopackages/logger"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func NewValuationService(db *mongo.Database, valuer linkvaluer.Client, insurer, callbackURL string, logger *ntlogger.Logger) (*valuationUsecase, error) {
+
+	repo := risk.NewRiskMongoRepository(db, logger)
+	return NewValuationUsecase(repo, valuer, insurer, callbackURL), nil
+}
@@ -0,0 +1,134 @@
+package valuation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	linkvaluer "github.com/nana-tec/gopackages/LinkValuer"
+	"github.com/nana-tec/gopackages/insurance/risk"
+	"github.com/shopspring/decimal"
+)
+
+type valuationUsecase struct {
+	risks       risk.RiskRepository
+	valuer      linkvaluer.Client
+	insurer     string // Insurance company named on the valuation requests
+	callbackURL string // LinkValuer callback URL, empty when AwaitValuation is used instead
+	now         func() time.Time
+}
+
+func NewValuationUsecase(risks risk.RiskRepository, valuer linkvaluer.Client, insurer, callbackURL string) *valuationUsecase {
+	return &valuationUsecase{
+		risks:       risks,
+		valuer:      valuer,
+		insurer:     insurer,
+		callbackURL: callbackURL,
+		now:         time.Now,
+	}
+}
+
+func (uc *valuationUsecase) RequestValuation(ctx context.Context, rsk *risk.MotorRiskModel, customer Customer) (string, error) {
+	if strings.TrimSpace(rsk.RiskSystemRef) == "" {
+		return "", fmt.Errorf("risk system ref is required")
+	}
+	if rsk.ValuationBookingNo != "" && linkvaluer.AssessmentStatus(rsk.ValuationStatus) != linkvaluer.StatusCancelled {
+		return rsk.ValuationBookingNo, nil
+	}
+
+	// the risk system ref is the partner reference, so retried requests reuse the booking
+	// and callbacks can be matched back to the risk
+	payload, _, err := uc.valuer.CreateValuationIdempotent(ctx, &linkvaluer.CreateRequest{
+		CustomerName:       customer.Name,
+		CustomerPhone:      customer.Phone,
+		CustomerEmail:      customer.Email,
+		PolicyNumber:       customer.PolicyNumber,
+		RegistrationNumber: rsk.RegistrationNumber,
+		InsuranceCompany:   uc.insurer,
+		CallBackURL:        uc.callbackURL,
+		PartnerReference:   rsk.RiskSystemRef,
+	})
+	if err != nil {
+		return "", err
+	}
+	bookingNo := payload.Data.BookingNo
+	if bookingNo == "" {
+		return "", fmt.Errorf("valuation of risk %s returned no booking number", rsk.RiskSystemRef)
+	}
+
+	rsk.ValuationBookingNo = bookingNo
+	rsk.ValuationStatus = linkvaluer.StatusRequested.String()
+	if err := uc.risks.UpdateMotorRisk(ctx, rsk); err != nil {
+		return "", err
+	}
+	return bookingNo, nil
+}
+
+func (uc *valuationUsecase) HandleCallback(ctx context.Context, cb *linkvaluer.CallbackResponse) (*risk.MotorRiskModel, error) {
+	if cb.PartnerReference == "" {
+		return nil, fmt.Errorf("%w: callback for %s has no partner reference", ErrBookingMismatch, cb.BookingNo)
+	}
+	rsk, err := uc.risks.GetMotorRiskByRiskSystemRef(ctx, cb.PartnerReference)
+	if err != nil {
+		return nil, err
+	}
+	if rsk.ValuationBookingNo != cb.BookingNo {
+		return nil, fmt.Errorf("%w: got %s, risk %s has %q", ErrBookingMismatch, cb.BookingNo, rsk.RiskSystemRef, rsk.ValuationBookingNo)
+	}
+
+	completedAt, err := time.Parse(time.RFC3339Nano, cb.CompletionDate)
+	if err != nil {
+		completedAt = uc.now()
+	}
+	if err := uc.apply(ctx, rsk, cb.Status, cb.MarketValue, completedAt); err != nil {
+		return nil, err
+	}
+	return rsk, nil
+}
+
+func (uc *valuationUsecase) AwaitValuation(ctx context.Context, riskSystemRef string, interval time.Duration) (*risk.MotorRiskModel, error) {
+	rsk, err := uc.risks.GetMotorRiskByRiskSystemRef(ctx, riskSystemRef)
+	if err != nil {
+		return nil, err
+	}
+	if rsk.ValuationBookingNo == "" {
+		return nil, fmt.Errorf("%w: %s", ErrNoValuation, riskSystemRef)
+	}
+
+	item, err := uc.valuer.WaitForCompletion(ctx, rsk.ValuationBookingNo, interval)
+	if err != nil {
+		var ce *linkvaluer.ClientError
+		if item != nil && errors.As(err, &ce) && ce.Code == linkvaluer.ErrBookingCancelled {
+			return nil, uc.apply(ctx, rsk, item.Status, linkvaluer.FlexibleAmount{}, uc.now())
+		}
+		return nil, err
+	}
+	if err := uc.apply(ctx, rsk, item.Status, item.AssessedValue, uc.now()); err != nil {
+		return nil, err
+	}
+	return rsk, nil
+}
+
+// apply records a valuation status on the risk. A completed valuation sets the market
+// value and the sum insured to it; a cancelled one is stored and reported as ErrValuationCancelled.
+func (uc *valuationUsecase) apply(ctx context.Context, rsk *risk.MotorRiskModel, status linkvaluer.AssessmentStatus, marketValue linkvaluer.FlexibleAmount, valuedAt time.Time) error {
+	switch status {
+	case linkvaluer.StatusCompleted:
+		if !marketValue.IsSet() || marketValue.Decimal().LessThanOrEqual(decimal.Zero) {
+			return fmt.Errorf("%w: booking %s", ErrNoMarketValue, rsk.ValuationBookingNo)
+		}
+		rsk.SetMarketValue(marketValue.Decimal())
+		rsk.SetSumInsured(marketValue.Decimal())
+		rsk.ValuedAt = &valuedAt
+	case linkvaluer.StatusCancelled:
+		rsk.ValuationStatus = status.String()
+		if err := uc.risks.UpdateMotorRisk(ctx, rsk); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: booking %s", ErrValuationCancelled, rsk.ValuationBookingNo)
+	}
+	rsk.ValuationStatus = status.String()
+	return uc.risks.UpdateMotorRisk(ctx, rsk)
+}
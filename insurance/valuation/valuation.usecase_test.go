@@ -0,0 +1,196 @@
+package valuation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	linkvaluer "github.com/nana-tec/gopackages/LinkValuer"
+	"github.com/nana-tec/gopackages/insurance/risk"
+	"github.com/shopspring/decimal"
+)
+
+type memRiskRepo struct {
+	risk.RiskRepository
+	risks map[string]risk.MotorRiskModel
+}
+
+func (m *memRiskRepo) GetMotorRiskByRiskSystemRef(ctx context.Context, ref string) (*risk.MotorRiskModel, error) {
+	rsk, ok := m.risks[ref]
+	if !ok {
+		return nil, fmt.Errorf("risk not found: %s", ref)
+	}
+	return &rsk, nil
+}
+
+func (m *memRiskRepo) UpdateMotorRisk(ctx context.Context, rsk *risk.MotorRiskModel) error {
+	m.risks[rsk.RiskSystemRef] = *rsk
+	return nil
+}
+
+type fakeValuer struct {
+	linkvaluer.Client
+	creates []*linkvaluer.CreateRequest
+	item    *linkvaluer.AssessmentItem
+	waitErr error
+}
+
+func (f *fakeValuer) CreateValuationIdempotent(ctx context.Context, req *linkvaluer.CreateRequest) (*linkvaluer.CreateValuationPayload, bool, error) {
+	f.creates = append(f.creates, req)
+	payload := &linkvaluer.CreateValuationPayload{Success: true}
+	payload.Data.BookingNo = fmt.Sprintf("LV_%d", len(f.creates))
+	return payload, true, nil
+}
+
+func (f *fakeValuer) WaitForCompletion(ctx context.Context, bookingNo string, interval time.Duration) (*linkvaluer.AssessmentItem, error) {
+	return f.item, f.waitErr
+}
+
+func newTestUsecase() (*valuationUsecase, *memRiskRepo, *fakeValuer) {
+	repo := &memRiskRepo{risks: map[string]risk.MotorRiskModel{
+		"RISK-1": {RiskSystemRef: "RISK-1", RegistrationNumber: "KDA 123A"},
+	}}
+	valuer := &fakeValuer{}
+	return NewValuationUsecase(repo, valuer, "Acme Insurance", "https://example.com/linkvaluer/callback"), repo, valuer
+}
+
+func callback(t *testing.T, raw string) *linkvaluer.CallbackResponse {
+	t.Helper()
+	var cb linkvaluer.CallbackResponse
+	if err := json.Unmarshal([]byte(raw), &cb); err != nil {
+		t.Fatalf("unmarshal callback: %v", err)
+	}
+	return &cb
+}
+
+func TestRequestValuationStoresBooking(t *testing.T) {
+	uc, repo, valuer := newTestUsecase()
+	rsk := repo.risks["RISK-1"]
+
+	bookingNo, err := uc.RequestValuation(context.Background(), &rsk, Customer{Name: "Jane", Phone: "0712345678"})
+	if err != nil {
+		t.Fatalf("RequestValuation: %v", err)
+	}
+	if bookingNo != "LV_1" || repo.risks["RISK-1"].ValuationBookingNo != "LV_1" {
+		t.Errorf("booking not stored: %q %+v", bookingNo, repo.risks["RISK-1"])
+	}
+	if got := valuer.creates[0]; got.PartnerReference != "RISK-1" || got.RegistrationNumber != "KDA 123A" || got.InsuranceCompany != "Acme Insurance" {
+		t.Errorf("unexpected create request %+v", got)
+	}
+
+	// a second request keeps the booking in progress
+	if bookingNo, err = uc.RequestValuation(context.Background(), &rsk, Customer{}); err != nil || bookingNo != "LV_1" {
+		t.Errorf("expected LV_1 to be reused, got %q %v", bookingNo, err)
+	}
+	if len(valuer.creates) != 1 {
+		t.Errorf("expected a single booking, got %d", len(valuer.creates))
+	}
+}
+
+func TestHandleCallbackUpdatesValue(t *testing.T) {
+	uc, repo, _ := newTestUsecase()
+	rsk := repo.risks["RISK-1"]
+	if _, err := uc.RequestValuation(context.Background(), &rsk, Customer{}); err != nil {
+		t.Fatalf("RequestValuation: %v", err)
+	}
+
+	got, err := uc.HandleCallback(context.Background(), callback(t, `{"booking_no":"LV_1","status":"completed","partner_reference":"RISK-1",
+		"completion_date":"2025-10-14T12:05:10.643616Z","market_value":"1,250,000"}`))
+	if err != nil {
+		t.Fatalf("HandleCallback: %v", err)
+	}
+	stored := repo.risks["RISK-1"]
+	want := decimal.NewFromInt(1250000)
+	if !stored.GetMarketValue().Equal(want) || !stored.GetSumInsured().Equal(want) {
+		t.Errorf("expected market value and sum insured %s, got %s %s", want, stored.MarketValue, stored.SumInsured)
+	}
+	if stored.ValuationStatus != "completed" || stored.ValuedAt == nil || stored.ValuedAt.Year() != 2025 {
+		t.Errorf("unexpected valuation %+v", stored)
+	}
+	if got.MarketValue != stored.MarketValue {
+		t.Errorf("returned risk differs from the stored one")
+	}
+}
+
+func TestHandleCallbackRejectsOtherBooking(t *testing.T) {
+	uc, repo, _ := newTestUsecase()
+	rsk := repo.risks["RISK-1"]
+	if _, err := uc.RequestValuation(context.Background(), &rsk, Customer{}); err != nil {
+		t.Fatalf("RequestValuation: %v", err)
+	}
+
+	_, err := uc.HandleCallback(context.Background(), callback(t, `{"booking_no":"LV_9","status":"completed","partner_reference":"RISK-1","market_value":"900000"}`))
+	if !errors.Is(err, ErrBookingMismatch) {
+		t.Fatalf("expected ErrBookingMismatch, got %v", err)
+	}
+	if repo.risks["RISK-1"].MarketValue != "" {
+		t.Error("risk updated from a foreign booking")
+	}
+}
+
+func TestHandleCallbackWithoutValue(t *testing.T) {
+	uc, repo, _ := newTestUsecase()
+	rsk := repo.risks["RISK-1"]
+	if _, err := uc.RequestValuation(context.Background(), &rsk, Customer{}); err != nil {
+		t.Fatalf("RequestValuation: %v", err)
+	}
+
+	_, err := uc.HandleCallback(context.Background(), callback(t, `{"booking_no":"LV_1","status":"completed","partner_reference":"RISK-1","market_value":""}`))
+	if !errors.Is(err, ErrNoMarketValue) {
+		t.Fatalf("expected ErrNoMarketValue, got %v", err)
+	}
+}
+
+func TestCancelledValuationBookedAgain(t *testing.T) {
+	uc, repo, valuer := newTestUsecase()
+	rsk := repo.risks["RISK-1"]
+	if _, err := uc.RequestValuation(context.Background(), &rsk, Customer{}); err != nil {
+		t.Fatalf("RequestValuation: %v", err)
+	}
+
+	_, err := uc.HandleCallback(context.Background(), callback(t, `{"booking_no":"LV_1","status":"canceled","partner_reference":"RISK-1"}`))
+	if !errors.Is(err, ErrValuationCancelled) {
+		t.Fatalf("expected ErrValuationCancelled, got %v", err)
+	}
+	rsk = repo.risks["RISK-1"]
+	if rsk.ValuationStatus != "cancelled" {
+		t.Errorf("expected cancelled status, got %q", rsk.ValuationStatus)
+	}
+
+	bookingNo, err := uc.RequestValuation(context.Background(), &rsk, Customer{})
+	if err != nil || bookingNo != "LV_2" || len(valuer.creates) != 2 {
+		t.Errorf("expected a new booking, got %q %v", bookingNo, err)
+	}
+}
+
+func TestAwaitValuation(t *testing.T) {
+	uc, repo, valuer := newTestUsecase()
+	rsk := repo.risks["RISK-1"]
+	if _, err := uc.RequestValuation(context.Background(), &rsk, Customer{}); err != nil {
+		t.Fatalf("RequestValuation: %v", err)
+	}
+	valuer.item = &linkvaluer.AssessmentItem{
+		BookingNo:     "LV_1",
+		Status:        linkvaluer.StatusCompleted,
+		AssessedValue: linkvaluer.NewFlexibleAmount(decimal.NewFromInt(800000)),
+	}
+
+	got, err := uc.AwaitValuation(context.Background(), "RISK-1", time.Millisecond)
+	if err != nil {
+		t.Fatalf("AwaitValuation: %v", err)
+	}
+	if !got.GetSumInsured().Equal(decimal.NewFromInt(800000)) || repo.risks["RISK-1"].SumInsured != "800000" {
+		t.Errorf("sum insured not updated: %+v", repo.risks["RISK-1"])
+	}
+}
+
+func TestAwaitValuationWithoutBooking(t *testing.T) {
+	uc, _, _ := newTestUsecase()
+
+	if _, err := uc.AwaitValuation(context.Background(), "RISK-1", time.Millisecond); !errors.Is(err, ErrNoValuation) {
+		t.Fatalf("expected ErrNoValuation, got %v", err)
+	}
+}
@@ -0,0 +1,307 @@
+// Package pdf writes simple A4 PDF documents: text in the standard Helvetica fonts,
+// lines, filled rectangles and raster images, for generated documents that are too
+// simple to justify a PDF library. Pages are built in memory and written by WriteTo.
+//
+// Coordinates are in points from the top left corner of the page; the y of text is its
+// baseline.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A4 page size in points
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Font is one of the standard fonts every PDF reader provides
+type Font int
+
+const (
+	Helvetica Font = iota
+	HelveticaBold
+)
+
+var fontNames = [...]string{Helvetica: "Helvetica", HelveticaBold: "Helvetica-Bold"}
+
+// Document is a PDF document under construction
+type Document struct {
+	title  string
+	pages  []*Page
+	images []*Image
+}
+
+// New starts an empty document, title is shown by PDF readers
+func New(title string) *Document {
+	return &Document{title: title}
+}
+
+// Page is a page of a Document
+type Page struct {
+	content bytes.Buffer
+}
+
+// Image is a raster image added to a Document, drawn by Page.Image
+type Image struct {
+	Width, Height int // in pixels
+
+	id   int
+	data []byte // deflated RGB samples
+}
+
+// AddPage appends a blank page
+func (d *Document) AddPage() *Page {
+	p := &Page{}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Pages returns the number of pages
+func (d *Document) Pages() int {
+	return len(d.pages)
+}
+
+// Page returns the page at the zero-based index i
+func (d *Document) Page(i int) *Page {
+	return d.pages[i]
+}
+
+// AddImage adds img, drawn any number of times by Page.Image. Transparent pixels are
+// blended onto white.
+func (d *Document) AddImage(img image.Image) *Image {
+	b := img.Bounds()
+	var raw bytes.Buffer
+	raw.Grow(b.Dx() * b.Dy() * 3)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			raw.WriteByte(onWhite(c.R, c.A))
+			raw.WriteByte(onWhite(c.G, c.A))
+			raw.WriteByte(onWhite(c.B, c.A))
+		}
+	}
+	im := &Image{Width: b.Dx(), Height: b.Dy(), id: len(d.images) + 1, data: deflate(raw.Bytes())}
+	d.images = append(d.images, im)
+	return im
+}
+
+func onWhite(v, alpha uint8) uint8 {
+	return uint8((int(v)*int(alpha) + 255*(255-int(alpha))) / 255)
+}
+
+// Text draws s with its baseline at y. Characters outside Windows-1252 are drawn as '?'.
+func (p *Page) Text(x, y float64, font Font, size float64, c color.Color, s string) {
+	fmt.Fprintf(&p.content, "BT %s /F%d %s Tf %s %s Td (%s) Tj ET\n",
+		fillColor(c), int(font)+1, num(size), num(x), num(PageHeight-y), escape(s))
+}
+
+// TextRight draws s like Text, ending at x
+func (p *Page) TextRight(x, y float64, font Font, size float64, c color.Color, s string) {
+	p.Text(x-TextWidth(font, size, s), y, font, size, c, s)
+}
+
+// Line draws a line of the given width between two points
+func (p *Page) Line(x1, y1, x2, y2, width float64, c color.Color) {
+	r, g, b := rgb(c)
+	fmt.Fprintf(&p.content, "%s %s %s RG %s w %s %s m %s %s l S\n",
+		r, g, b, num(width), num(x1), num(PageHeight-y1), num(x2), num(PageHeight-y2))
+}
+
+// Rect fills a rectangle whose top left corner is at x, y
+func (p *Page) Rect(x, y, w, h float64, c color.Color) {
+	fmt.Fprintf(&p.content, "%s %s %s %s %s re f\n", fillColor(c), num(x), num(PageHeight-y-h), num(w), num(h))
+}
+
+// Image draws img scaled to w by h, its top left corner at x, y
+func (p *Page) Image(img *Image, x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "q %s 0 0 %s %s %s cm /Im%d Do Q\n", num(w), num(h), num(x), num(PageHeight-y-h), img.id)
+}
+
+// TextWidth returns the width of s drawn in font at size
+func TextWidth(font Font, size float64, s string) float64 {
+	widths := &helveticaWidths
+	if font == HelveticaBold {
+		widths = &helveticaBoldWidths
+	}
+	total := 0
+	for _, b := range encode(s) {
+		switch {
+		case b >= 32 && b <= 126:
+			total += widths[b-32]
+		case b == 0xa0:
+			total += widths[0] // no-break space
+		default:
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// WriteTo writes the document to w
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	var offsets []int64
+	object := func(body string) {
+		offsets = append(offsets, cw.n)
+		fmt.Fprintf(cw, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	stream := func(dict string, data []byte) {
+		offsets = append(offsets, cw.n)
+		fmt.Fprintf(cw, "%d 0 obj\n<< %s /Length %d >>\nstream\n", len(offsets), dict, len(data))
+		cw.Write(data)
+		io.WriteString(cw, "\nendstream\nendobj\n")
+	}
+
+	// objects 1 to 4 are the catalog, the page tree, the info dictionary and the fonts,
+	// then come the images and a page and its content per page
+	firstImage := 4 + len(fontNames)
+	firstPage := firstImage + len(d.images)
+	var kids, xobjects strings.Builder
+	for i := range d.pages {
+		fmt.Fprintf(&kids, "%d 0 R ", firstPage+2*i)
+	}
+	for i := range d.images {
+		fmt.Fprintf(&xobjects, "/Im%d %d 0 R ", i+1, firstImage+i)
+	}
+	var fonts strings.Builder
+	for i := range fontNames {
+		fmt.Fprintf(&fonts, "/F%d %d 0 R ", i+1, 4+i)
+	}
+	resources := fmt.Sprintf("<< /Font << %s>> /XObject << %s>> >>", fonts.String(), xobjects.String())
+
+	io.WriteString(cw, "%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.TrimSpace(kids.String()), len(d.pages)))
+	object(fmt.Sprintf("<< /Title (%s) /Producer (gopackages) >>", escape(d.title)))
+	for _, name := range fontNames {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+	}
+	for _, im := range d.images {
+		stream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode",
+			im.Width, im.Height), im.data)
+	}
+	for i, p := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources %s /Contents %d 0 R >>",
+			num(PageWidth), num(PageHeight), resources, firstPage+2*i+1))
+		stream("/Filter /FlateDecode", deflate(p.content.Bytes()))
+	}
+
+	xref := cw.n
+	fmt.Fprintf(cw, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(cw, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(cw, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return cw.n, cw.err
+}
+
+// countWriter counts the bytes written and keeps the first error, so WriteTo checks once
+type countWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
+
+func deflate(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	return buf.Bytes()
+}
+
+func num(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+func rgb(c color.Color) (r, g, b string) {
+	if c == nil {
+		c = color.Black
+	}
+	cr, cg, cb, _ := c.RGBA()
+	return num(float64(cr) / 0xffff), num(float64(cg) / 0xffff), num(float64(cb) / 0xffff)
+}
+
+func fillColor(c color.Color) string {
+	r, g, b := rgb(c)
+	return r + " " + g + " " + b + " rg"
+}
+
+// winAnsi maps the characters of Windows-1252 outside Latin-1 to their code
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a,
+	'‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// encode returns s in Windows-1252, the encoding of the fonts
+func encode(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch b, ok := winAnsi[r]; {
+		case ok:
+			out = append(out, b)
+		case r >= 32 && r <= 126, r >= 0xa0 && r <= 0xff:
+			out = append(out, byte(r))
+		default:
+			out = append(out, '?')
+		}
+	}
+	return out
+}
+
+// escape returns s encoded as the body of a PDF string literal
+func escape(s string) string {
+	var b strings.Builder
+	for _, c := range encode(s) {
+		if c == '(' || c == ')' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// Advance widths of the printable ASCII characters, from the Adobe font metrics
+var (
+	helveticaWidths = [95]int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space to /
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556, // 0 to ?
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, // @ to O
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, // P to _
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, // ` to o
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584, // p to ~
+	}
+	helveticaBoldWidths = [95]int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+)
@@ -0,0 +1,71 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"image"
+	"image/color"
+	"io"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument_WriteTo(t *testing.T) {
+	doc := New("Statement (May)")
+	logo := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	logo.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	img := doc.AddImage(logo)
+	p := doc.AddPage()
+	p.Image(img, 40, 40, 20, 10)
+	p.Text(40, 100, HelveticaBold, 12, nil, `Café (net) \ 5€`)
+	p.Rect(40, 110, 100, 20, color.Gray{Y: 200})
+	doc.AddPage().Line(40, 40, 200, 40, 1, color.Black)
+
+	var buf bytes.Buffer
+	n, err := doc.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+	out := buf.Bytes()
+	assert.True(t, bytes.HasPrefix(out, []byte("%PDF-1.4\n")))
+	assert.Contains(t, string(out), "/Count 2")
+	assert.Contains(t, string(out), `/Title (Statement \(May\))`)
+
+	// every xref entry points at its object
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(out)
+	require.NotNil(t, m)
+	xref, _ := strconv.Atoi(string(m[1]))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out[xref:], -1)
+	require.Len(t, entries, 4+len(fontNames)+2*2)
+	for i, e := range entries {
+		off, _ := strconv.Atoi(string(e[1]))
+		assert.True(t, bytes.HasPrefix(out[off:], []byte(strconv.Itoa(i+1)+" 0 obj\n")), "object %d", i+1)
+	}
+
+	content := inflate(t, regexp.MustCompile(`(?s)/Contents \d+ 0 R >>\nendobj\n\d+ 0 obj\n<< /Filter /FlateDecode /Length \d+ >>\nstream\n(.*?)\nendstream`).FindSubmatch(out)[1])
+	assert.Contains(t, content, "/Im1 Do")
+	assert.Contains(t, content, "/F2 12 Tf 40 741.89 Td (Caf\xe9 \\(net\\) \\\\ 5\x80) Tj")
+	assert.Contains(t, content, "0.78 0.78 0.78 rg 40 711.89 100 20 re f")
+
+	pixels := inflate(t, regexp.MustCompile(`(?s)/Subtype /Image .*?stream\n(.*?)\nendstream`).FindSubmatch(out)[1])
+	assert.Equal(t, "\xff\x00\x00\xff\xff\xff", pixels) // transparent pixel on white
+}
+
+func TestTextWidth(t *testing.T) {
+	assert.InDelta(t, 0, TextWidth(Helvetica, 10, ""), 1e-9)
+	assert.InDelta(t, 5.56*3, TextWidth(Helvetica, 10, "100"), 1e-9)
+	assert.Greater(t, TextWidth(HelveticaBold, 10, "Wallet"), TextWidth(Helvetica, 10, "Wallet"))
+	assert.InDelta(t, TextWidth(Helvetica, 10, "?"), TextWidth(Helvetica, 10, "漢"), 1e-9)
+}
+
+func inflate(t *testing.T, data []byte) string {
+	t.Helper()
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	out, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	return string(out)
+}
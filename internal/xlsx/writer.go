@@ -0,0 +1,176 @@
+// Package xlsx writes single-sheet XLSX workbooks row by row, for exports that are
+// too simple to justify a spreadsheet library. Rows are streamed to the underlying
+// writer, so large exports are not held in memory.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// DateLayout is the layout time.Time cells are written with
+const DateLayout = "2006-01-02 15:04:05"
+
+var errClosed = errors.New("xlsx: writer is closed")
+
+// Writer streams rows into the only sheet of a workbook. Close must be called to
+// complete the file.
+type Writer struct {
+	zw     *zip.Writer
+	sheet  *bufio.Writer
+	row    int
+	closed bool
+}
+
+// NewWriter starts a workbook on w with a single sheet named sheetName.
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+	if sheetName == "" {
+		sheetName = "Sheet1"
+	}
+	zw := zip.NewWriter(w)
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", contentTypes},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", fmt.Sprintf(workbook, escape(sheetName))},
+		{"xl/_rels/workbook.xml.rels", workbookRels},
+		{"xl/styles.xml", styles},
+	}
+	for _, p := range parts {
+		f, err := zw.Create(p.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, xml.Header+p.body); err != nil {
+			return nil, err
+		}
+	}
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sheet := bufio.NewWriter(f)
+	if _, err := io.WriteString(sheet, xml.Header+sheetStart); err != nil {
+		return nil, err
+	}
+	return &Writer{zw: zw, sheet: sheet}, nil
+}
+
+// WriteRow appends a row. Strings, booleans, integers, floats, decimals and times
+// are supported, nil leaves the cell empty and other values are written with fmt.
+func (w *Writer) WriteRow(values ...any) error {
+	if w.closed {
+		return errClosed
+	}
+	w.row++
+	if _, err := fmt.Fprintf(w.sheet, `<row r="%d">`, w.row); err != nil {
+		return err
+	}
+	for i, v := range values {
+		if err := w.writeCell(cellRef(i, w.row), v); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w.sheet, `</row>`)
+	return err
+}
+
+func (w *Writer) writeCell(ref string, v any) error {
+	var err error
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case string:
+		_, err = fmt.Fprintf(w.sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, escape(t))
+	case bool:
+		b := 0
+		if t {
+			b = 1
+		}
+		_, err = fmt.Fprintf(w.sheet, `<c r="%s" t="b"><v>%d</v></c>`, ref, b)
+	case int:
+		_, err = fmt.Fprintf(w.sheet, `<c r="%s"><v>%d</v></c>`, ref, t)
+	case int64:
+		_, err = fmt.Fprintf(w.sheet, `<c r="%s"><v>%d</v></c>`, ref, t)
+	case float64:
+		_, err = fmt.Fprintf(w.sheet, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(t, 'f', -1, 64))
+	case decimal.Decimal:
+		_, err = fmt.Fprintf(w.sheet, `<c r="%s"><v>%s</v></c>`, ref, t.String())
+	case time.Time:
+		if t.IsZero() {
+			return nil
+		}
+		return w.writeCell(ref, t.Format(DateLayout))
+	case fmt.Stringer:
+		return w.writeCell(ref, t.String())
+	default:
+		return w.writeCell(ref, fmt.Sprint(v))
+	}
+	return err
+}
+
+// Close completes the sheet and the workbook. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if _, err := io.WriteString(w.sheet, sheetEnd); err != nil {
+		return err
+	}
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+	return w.zw.Close()
+}
+
+// cellRef returns the A1 reference of the zero-based column col in row
+func cellRef(col, row int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name + strconv.Itoa(row)
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+const (
+	contentTypes = `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`</Types>`
+	rootRels = `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	workbook = `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	workbookRels = `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`
+	styles = `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="1"><fill><patternFill patternType="none"/></fill></fills>` +
+		`<borders count="1"><border/></borders>` +
+		`<cellStyleXfs count="1"><xf/></cellStyleXfs>` +
+		`<cellXfs count="1"><xf xfId="0"/></cellXfs>` +
+		`</styleSheet>`
+	sheetStart = `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	sheetEnd   = `</sheetData></worksheet>`
+)
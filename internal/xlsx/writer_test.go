@@ -0,0 +1,49 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter_WritesWorkbook(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "Assessments & fees")
+	require.NoError(t, err)
+	require.NoError(t, w.WriteRow("booking", "value", "paid", "date"))
+	require.NoError(t, w.WriteRow("LV_<1>", decimal.RequireFromString("2500.50"), true, time.Date(2025, 10, 14, 12, 5, 0, 0, time.UTC)))
+	require.NoError(t, w.Close())
+	assert.ErrorIs(t, w.WriteRow("late"), errClosed)
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		body, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		files[f.Name] = string(body)
+	}
+	assert.Contains(t, files, "[Content_Types].xml")
+	assert.Contains(t, files["xl/workbook.xml"], `name="Assessments &amp; fees"`)
+
+	sheet := files["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, sheet, `<c r="A2" t="inlineStr"><is><t xml:space="preserve">LV_&lt;1&gt;</t></is></c>`)
+	assert.Contains(t, sheet, `<c r="B2"><v>2500.5</v></c>`)
+	assert.Contains(t, sheet, `<c r="C2" t="b"><v>1</v></c>`)
+	assert.Contains(t, sheet, `2025-10-14 12:05:00`)
+}
+
+func TestCellRef(t *testing.T) {
+	assert.Equal(t, "A1", cellRef(0, 1))
+	assert.Equal(t, "Z3", cellRef(25, 3))
+	assert.Equal(t, "AA3", cellRef(26, 3))
+	assert.Equal(t, "AZ10", cellRef(51, 10))
+}
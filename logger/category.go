@@ -17,6 +17,7 @@ const (
 	RequestBody  ExtraKey = "RequestBody"
 	ResponseBody ExtraKey = "ResponseBody"
 	ErrorMessage ExtraKey = "ErrorMessage"
+	RequestID    ExtraKey = "RequestId"
 )
 
 type LogConfig struct {
@@ -0,0 +1,221 @@
+package ntlogger
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/nana-tec/gopackages/eventbus"
+)
+
+const (
+	defaultErrorEventName      = "log.error"
+	defaultErrorEventTimeout   = 5 * time.Second
+	defaultErrorEventQueueSize = 256
+)
+
+// ErrorEventHook publishes high-severity log entries (Error/Fatal) onto the
+// integration event broker so a central incident service can react to error
+// bursts without scraping log files.
+//
+// Events are published by a background worker, so a slow or unavailable broker never
+// stalls logging: entries logged while QueueSize events are waiting are not published.
+// Entries the broker itself logs while publishing are not published either, see publish.
+type ErrorEventHook struct {
+	Broker        eventbus.IntergrationEventBroker // Broker used to publish the events
+	EventName     string                           // Integration event name, defaults to "log.error"
+	PublisherName string                           // Name of the publishing service, defaults to LogConfig.AppName
+	Codes         []string                         // Only these codes are published; empty publishes every entry, including Errorf/Fatalf
+	Timeout       time.Duration                    // Publish timeout, defaults to 5s
+	QueueSize     int                              // Events waiting to be published, defaults to 256
+}
+
+// publishingKey marks the context the worker publishes with, see publish
+type publishingKey struct{}
+
+type errorEventLogger struct {
+	Logger
+	hook  ErrorEventHook
+	codes map[string]struct{}
+
+	queue      chan queuedEvent
+	publishing atomic.Bool  // set while the worker publishes
+	dropped    atomic.Int64 // events dropped on a full queue since the last warning
+}
+
+// queuedEvent is an event waiting for the worker, done is closed once it was handled.
+// A nil event only waits for the events queued before it.
+type queuedEvent struct {
+	event *eventbus.IntergrationPubEvent
+	done  chan struct{}
+}
+
+// NewLoggerWithErrorEvents creates the default logger and wraps it with the error event hook.
+func NewLoggerWithErrorEvents(cfg LogConfig, hook ErrorEventHook) Logger {
+	if hook.PublisherName == "" {
+		hook.PublisherName = cfg.AppName
+	}
+	return WithErrorEventHook(NewLogger(cfg), hook)
+}
+
+// WithErrorEventHook wraps base so that Error, Errorf, Fatal and Fatalf entries
+// matching the hook codes are also published as integration events. If the hook has no
+// broker, base is returned unchanged.
+func WithErrorEventHook(base Logger, hook ErrorEventHook) Logger {
+	if hook.Broker == nil {
+		return base
+	}
+	if hook.EventName == "" {
+		hook.EventName = defaultErrorEventName
+	}
+	if hook.Timeout == 0 {
+		hook.Timeout = defaultErrorEventTimeout
+	}
+	if hook.QueueSize <= 0 {
+		hook.QueueSize = defaultErrorEventQueueSize
+	}
+	codes := make(map[string]struct{}, len(hook.Codes))
+	for _, code := range hook.Codes {
+		codes[code] = struct{}{}
+	}
+	l := &errorEventLogger{Logger: base, hook: hook, codes: codes, queue: make(chan queuedEvent, hook.QueueSize)}
+	go l.run()
+	return l
+}
+
+func (l *errorEventLogger) Error(ctx context.Context, code string, msg string, extra map[ExtraKey]interface{}) {
+	l.Logger.Error(ctx, code, msg, extra)
+	if event := l.event(ctx, "error", code, msg, extra); event != nil {
+		l.enqueue(event)
+	}
+}
+
+func (l *errorEventLogger) Fatal(ctx context.Context, code string, msg string, extra map[ExtraKey]interface{}) {
+	// publish first, the underlying logger exits the process
+	if event := l.event(ctx, "fatal", code, msg, extra); event != nil {
+		l.enqueueAndWait(event, l.hook.Timeout)
+	}
+	l.Logger.Fatal(ctx, code, msg, extra)
+}
+
+// Errorf and Fatalf entries carry no code, they are only published when the hook has no codes.
+func (l *errorEventLogger) Errorf(template string, args ...interface{}) {
+	l.Logger.Errorf(template, args...)
+	if event := l.uncodedEvent("error", fmt.Sprintf(template, args...)); event != nil {
+		l.enqueue(event)
+	}
+}
+
+func (l *errorEventLogger) Fatalf(template string, args ...interface{}) {
+	if event := l.uncodedEvent("fatal", fmt.Sprintf(template, args...)); event != nil {
+		l.enqueueAndWait(event, l.hook.Timeout)
+	}
+	l.Logger.Fatalf(template, args...)
+}
+
+func (l *errorEventLogger) shouldPublish(code string) bool {
+	if len(l.codes) == 0 {
+		return true
+	}
+	_, ok := l.codes[code]
+	return ok
+}
+
+// uncodedEvent returns the event of an Errorf or Fatalf entry. These entries carry no
+// context to tell them apart from entries of the broker, so none are published while
+// the worker publishes.
+func (l *errorEventLogger) uncodedEvent(level string, msg string) *eventbus.IntergrationPubEvent {
+	if l.publishing.Load() {
+		return nil
+	}
+	return l.event(context.Background(), level, "", msg, nil)
+}
+
+// event returns the event of a log entry, or nil when the entry is not published:
+// its code is not published, or it was logged by the broker with the context of a
+// publish, which would otherwise feed the broker its own errors.
+func (l *errorEventLogger) event(ctx context.Context, level string, code string, msg string, extra map[ExtraKey]interface{}) *eventbus.IntergrationPubEvent {
+	if !l.shouldPublish(code) {
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if ctx.Value(publishingKey{}) != nil {
+		return nil
+	}
+
+	data := map[string]any{
+		"level":   level,
+		"code":    code,
+		"message": msg,
+	}
+	for k, v := range extra {
+		data[string(k)] = fmt.Sprint(v)
+	}
+	data = InjectRequestID(ctx, data)
+
+	return &eventbus.IntergrationPubEvent{
+		EventName:          l.hook.EventName,
+		EventTimestamp:     time.Now(),
+		EventData:          data,
+		EventPublisherName: l.hook.PublisherName,
+	}
+}
+
+// enqueue hands the event to the worker, dropping it when the queue is full
+func (l *errorEventLogger) enqueue(event *eventbus.IntergrationPubEvent) {
+	select {
+	case l.queue <- queuedEvent{event: event}:
+	default:
+		l.dropped.Add(1)
+	}
+}
+
+// enqueueAndWait hands the event to the worker and waits until it is published, for
+// at most timeout. A nil event waits for the events queued before.
+func (l *errorEventLogger) enqueueAndWait(event *eventbus.IntergrationPubEvent, timeout time.Duration) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	done := make(chan struct{})
+	select {
+	case l.queue <- queuedEvent{event: event, done: done}:
+	case <-timer.C:
+		l.dropped.Add(1)
+		return
+	}
+	select {
+	case <-done:
+	case <-timer.C:
+	}
+}
+
+// run publishes the queued events one at a time
+func (l *errorEventLogger) run() {
+	for q := range l.queue {
+		if q.event != nil {
+			l.publish(q.event)
+		}
+		if q.done != nil {
+			close(q.done)
+		}
+	}
+}
+
+// publish sends the event to the broker. The context is marked, so entries the broker
+// logs through this logger with it are not published in turn.
+func (l *errorEventLogger) publish(event *eventbus.IntergrationPubEvent) {
+	if n := l.dropped.Swap(0); n > 0 {
+		l.Logger.Warnf("dropped %d log error events %s, the publish queue was full", n, l.hook.EventName)
+	}
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), publishingKey{}, true), l.hook.Timeout)
+	defer cancel()
+
+	l.publishing.Store(true)
+	err := l.hook.Broker.Publish(ctx, *event)
+	l.publishing.Store(false)
+	if err != nil {
+		l.Logger.Warnf("failed to publish log error event %s: %v", l.hook.EventName, err)
+	}
+}
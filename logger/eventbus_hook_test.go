@@ -0,0 +1,216 @@
+package ntlogger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nana-tec/gopackages/eventbus"
+)
+
+// recordingLogger records the entries written through it
+type recordingLogger struct {
+	Logger
+	mu      sync.Mutex
+	entries []string
+}
+
+func (l *recordingLogger) record(entry string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+}
+
+func (l *recordingLogger) Error(ctx context.Context, code string, msg string, extra map[ExtraKey]interface{}) {
+	l.record("error " + code + ": " + msg)
+}
+
+func (l *recordingLogger) Errorf(template string, args ...interface{}) {
+	l.record("errorf: " + fmt.Sprintf(template, args...))
+}
+
+func (l *recordingLogger) Warnf(template string, args ...interface{}) {
+	l.record("warnf: " + fmt.Sprintf(template, args...))
+}
+
+// fakeBroker records published events and fails or blocks when told to
+type fakeBroker struct {
+	mu     sync.Mutex
+	events []eventbus.IntergrationPubEvent
+	err    error
+	block  bool
+}
+
+func (b *fakeBroker) Publish(ctx context.Context, pubEvent eventbus.IntergrationPubEvent) error {
+	if b.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = append(b.events, pubEvent)
+	return b.err
+}
+
+func (b *fakeBroker) Subscribe(ctx context.Context, subscriber eventbus.IntergrationSubscriber) error {
+	return nil
+}
+
+// waitForErrorEvents waits until the events logged through log were handled by the worker
+func waitForErrorEvents(log Logger) {
+	log.(*errorEventLogger).enqueueAndWait(nil, time.Second)
+}
+
+func TestErrorEventHook(t *testing.T) {
+	base := &recordingLogger{}
+	broker := &fakeBroker{}
+	log := WithErrorEventHook(base, ErrorEventHook{Broker: broker, PublisherName: "policy-service"})
+
+	log.Error(context.Background(), "E100", "payment failed", map[ExtraKey]interface{}{"policy": 42})
+	log.Errorf("payment %d failed", 7)
+	waitForErrorEvents(log)
+
+	if len(base.entries) != 2 {
+		t.Fatalf("expected both entries to be logged, got %v", base.entries)
+	}
+	if len(broker.events) != 2 {
+		t.Fatalf("expected 2 published events, got %d", len(broker.events))
+	}
+	event := broker.events[0]
+	if event.EventName != defaultErrorEventName || event.EventPublisherName != "policy-service" {
+		t.Errorf("unexpected event %+v", event)
+	}
+	if event.EventData["code"] != "E100" || event.EventData["message"] != "payment failed" || event.EventData["policy"] != "42" {
+		t.Errorf("unexpected event data %v", event.EventData)
+	}
+	if data := broker.events[1].EventData; data["level"] != "error" || data["message"] != "payment 7 failed" {
+		t.Errorf("unexpected Errorf event data %v", data)
+	}
+}
+
+func TestErrorEventHookCodes(t *testing.T) {
+	broker := &fakeBroker{}
+	log := WithErrorEventHook(&recordingLogger{}, ErrorEventHook{Broker: broker, Codes: []string{"E100"}})
+
+	log.Error(context.Background(), "E200", "ignored", nil)
+	log.Errorf("no code %s", "ignored")
+	log.Error(context.Background(), "E100", "published", nil)
+	waitForErrorEvents(log)
+
+	if len(broker.events) != 1 || broker.events[0].EventData["code"] != "E100" {
+		t.Errorf("expected only the E100 entry to be published, got %v", broker.events)
+	}
+}
+
+func TestErrorEventHookPublishFailure(t *testing.T) {
+	base := &recordingLogger{}
+	broker := &fakeBroker{err: errors.New("broker down")}
+	log := WithErrorEventHook(base, ErrorEventHook{Broker: broker})
+
+	log.Error(context.Background(), "E100", "payment failed", nil)
+	waitForErrorEvents(log)
+	if len(base.entries) != 2 || base.entries[0] != "error E100: payment failed" {
+		t.Fatalf("expected the entry and a warning to be logged, got %v", base.entries)
+	}
+
+	// a broker that does not answer is given up on after the timeout
+	broker = &fakeBroker{block: true}
+	base = &recordingLogger{}
+	log = WithErrorEventHook(base, ErrorEventHook{Broker: broker, Timeout: 20 * time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		log.Error(ctx, "E100", "payment failed", nil)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("logging blocked on the broker")
+	}
+	waitForErrorEvents(log)
+	if len(base.entries) != 2 {
+		t.Errorf("expected the entry and a warning to be logged, got %v", base.entries)
+	}
+}
+
+// gateBroker blocks every publish until it is released
+type gateBroker struct {
+	entered chan struct{}
+	release chan struct{}
+	mu      sync.Mutex
+	events  int
+}
+
+func (b *gateBroker) Publish(ctx context.Context, pubEvent eventbus.IntergrationPubEvent) error {
+	b.entered <- struct{}{}
+	<-b.release
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events++
+	return nil
+}
+
+func (b *gateBroker) Subscribe(ctx context.Context, subscriber eventbus.IntergrationSubscriber) error {
+	return nil
+}
+
+func TestErrorEventHookDropsOnFullQueue(t *testing.T) {
+	base := &recordingLogger{}
+	broker := &gateBroker{entered: make(chan struct{}, 10), release: make(chan struct{})}
+	log := WithErrorEventHook(base, ErrorEventHook{Broker: broker, QueueSize: 1})
+
+	log.Error(context.Background(), "E100", "published", nil)
+	<-broker.entered // the worker holds the first event
+	log.Error(context.Background(), "E100", "queued", nil)
+	log.Error(context.Background(), "E100", "dropped", nil)
+	log.Error(context.Background(), "E100", "dropped too", nil)
+	close(broker.release)
+	waitForErrorEvents(log)
+
+	if broker.events != 2 {
+		t.Errorf("expected 2 published events, got %d", broker.events)
+	}
+	if len(base.entries) != 5 || base.entries[4] != "warnf: dropped 2 log error events log.error, the publish queue was full" {
+		t.Errorf("expected the entries and a warning about the dropped events, got %v", base.entries)
+	}
+}
+
+// loggingBroker logs its own errors through the logger it publishes for
+type loggingBroker struct {
+	log    Logger
+	events int
+}
+
+func (b *loggingBroker) Publish(ctx context.Context, pubEvent eventbus.IntergrationPubEvent) error {
+	b.events++
+	b.log.Error(ctx, "NATS", "publish failed", nil)
+	b.log.Errorf("publish failed")
+	return nil
+}
+
+func (b *loggingBroker) Subscribe(ctx context.Context, subscriber eventbus.IntergrationSubscriber) error {
+	return nil
+}
+
+func TestErrorEventHookIgnoresBrokerEntries(t *testing.T) {
+	base := &recordingLogger{}
+	broker := &loggingBroker{}
+	log := WithErrorEventHook(base, ErrorEventHook{Broker: broker})
+	broker.log = log
+
+	log.Error(context.Background(), "E100", "payment failed", nil)
+	waitForErrorEvents(log)
+	waitForErrorEvents(log)
+
+	if broker.events != 1 {
+		t.Errorf("expected only the entry to be published, got %d events", broker.events)
+	}
+	if len(base.entries) != 3 {
+		t.Errorf("expected the entries of the broker to be logged, got %v", base.entries)
+	}
+}
@@ -0,0 +1,86 @@
+package ntlogger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestIDHeader is the HTTP header carrying the request ID between services.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDEventKey is the EventData key carrying the request ID on integration events.
+const RequestIDEventKey = "request_id"
+
+type requestIDKey struct{}
+
+// NewRequestID returns a new random 128 bit request ID encoded as 32 hex characters.
+func NewRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic("ntlogger: failed to generate request id: " + err.Error())
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// ContextWithRequestID returns a copy of ctx carrying the request ID.
+// Log entries written with the returned context include it as the RequestId field.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID attached to ctx, if any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	requestID, ok := ctx.Value(requestIDKey{}).(string)
+	return requestID, ok && requestID != ""
+}
+
+// EnsureRequestID returns ctx unchanged when it already carries a request ID,
+// otherwise a copy with a new one, together with the request ID in use.
+func EnsureRequestID(ctx context.Context) (context.Context, string) {
+	if requestID, ok := RequestIDFromContext(ctx); ok {
+		return ctx, requestID
+	}
+	requestID := NewRequestID()
+	return ContextWithRequestID(ctx, requestID), requestID
+}
+
+// RequestIDMiddleware reuses the X-Request-ID header of incoming requests or generates
+// a new ID, attaches it to the request context and echoes it on the response.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" {
+			requestID = NewRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(ContextWithRequestID(r.Context(), requestID)))
+	})
+}
+
+// RequestIDFromEvent returns the request ID carried in integration event data, if any.
+func RequestIDFromEvent(eventData map[string]any) (string, bool) {
+	requestID, ok := eventData[RequestIDEventKey].(string)
+	return requestID, ok && requestID != ""
+}
+
+// InjectRequestID copies the request ID of ctx into integration event data so
+// subscribers can continue the trace with ContextWithRequestID.
+func InjectRequestID(ctx context.Context, eventData map[string]any) map[string]any {
+	requestID, ok := RequestIDFromContext(ctx)
+	if !ok {
+		return eventData
+	}
+	if eventData == nil {
+		eventData = make(map[string]any)
+	}
+	eventData[RequestIDEventKey] = requestID
+	return eventData
+}
@@ -79,7 +79,7 @@ func (l *zapLogger) Init() {
 
 // ctx context.Context, code string, msg string, extra map[ExtraKey]interface{}
 func (l *zapLogger) Debug(ctx context.Context, code string, msg string, extra map[ExtraKey]interface{}) {
-	params := prepareLogInfo(code, extra)
+	params := prepareLogInfo(ctx, code, extra)
 
 	l.logger.Debugw(msg, params...)
 }
@@ -89,7 +89,7 @@ func (l *zapLogger) Debugf(template string, args ...interface{}) {
 }
 
 func (l *zapLogger) Info(ctx context.Context, code string, msg string, extra map[ExtraKey]interface{}) {
-	params := prepareLogInfo(code, extra)
+	params := prepareLogInfo(ctx, code, extra)
 	l.logger.Infow(msg, params...)
 }
 
@@ -98,7 +98,7 @@ func (l *zapLogger) Infof(template string, args ...interface{}) {
 }
 
 func (l *zapLogger) Warn(ctx context.Context, code string, msg string, extra map[ExtraKey]interface{}) {
-	params := prepareLogInfo(code, extra)
+	params := prepareLogInfo(ctx, code, extra)
 	l.logger.Warnw(msg, params...)
 }
 
@@ -107,7 +107,7 @@ func (l *zapLogger) Warnf(template string, args ...interface{}) {
 }
 
 func (l *zapLogger) Error(ctx context.Context, code string, msg string, extra map[ExtraKey]interface{}) {
-	params := prepareLogInfo(code, extra)
+	params := prepareLogInfo(ctx, code, extra)
 	l.logger.Errorw(msg, params...)
 }
 
@@ -116,7 +116,7 @@ func (l *zapLogger) Errorf(template string, args ...interface{}) {
 }
 
 func (l *zapLogger) Fatal(ctx context.Context, code string, msg string, extra map[ExtraKey]interface{}) {
-	params := prepareLogInfo(code, extra)
+	params := prepareLogInfo(ctx, code, extra)
 	l.logger.Fatalw(msg, params...)
 }
 
@@ -124,11 +124,16 @@ func (l *zapLogger) Fatalf(template string, args ...interface{}) {
 	l.logger.Fatalf(template, args)
 }
 
-func prepareLogInfo(code string, extra map[ExtraKey]interface{}) []interface{} {
+func prepareLogInfo(ctx context.Context, code string, extra map[ExtraKey]interface{}) []interface{} {
 	if extra == nil {
 		extra = make(map[ExtraKey]interface{})
 	}
 	extra["code"] = code
+	if requestID, ok := RequestIDFromContext(ctx); ok {
+		if _, set := extra[RequestID]; !set {
+			extra[RequestID] = requestID
+		}
+	}
 
 	return logParamsToZapParams(extra)
 }